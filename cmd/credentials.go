@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"superview/internal/database"
+	"superview/internal/logger"
+	"superview/internal/models"
+	"superview/internal/repository"
+	"superview/internal/secrets"
+)
+
+const credentialsUsage = "Usage: superview credentials generate-key | status | rotate --new-key-file <path> | decrypt"
+
+// credentialKeyFile 默认密钥文件：与主配置文件同目录
+func credentialKeyFile(mainConfigPath string) string {
+	return filepath.Join(filepath.Dir(mainConfigPath), "credentials.key")
+}
+
+// setupCredentialCipher 加载节点凭据密钥并启用透明加解密
+func setupCredentialCipher(mainConfigPath string) *secrets.Keyring {
+	keyring, source, err := secrets.LoadKeyring(credentialKeyFile(mainConfigPath))
+	if err != nil {
+		logger.Fatal("Failed to load node credential key", zap.Error(err))
+	}
+	models.SetCredentialCipher(keyring)
+
+	switch {
+	case source.Env:
+		logger.Info("Node credential key loaded from environment", zap.String("key_id", keyring.PrimaryKeyID()))
+	case source.Generated:
+		logger.Warn("Generated new node credential key; back it up separately, stored node passwords cannot be recovered without it",
+			zap.String("key_file", source.File),
+			zap.String("key_id", keyring.PrimaryKeyID()))
+	default:
+		logger.Info("Node credential key loaded from file",
+			zap.String("key_file", source.File),
+			zap.String("key_id", keyring.PrimaryKeyID()))
+	}
+	return keyring
+}
+
+// encryptStoredCredentials 启动时加密历史明文凭据，并把旧密钥加密的凭据迁移到当前密钥
+func encryptStoredCredentials(keyring *secrets.Keyring) {
+	count, err := repository.ReencryptNodeCredentials(database.DB, keyring)
+	if err != nil {
+		logger.Fatal("Failed to encrypt stored credentials", zap.Error(err))
+	}
+	if count > 0 {
+		logger.Info("Stored credentials encrypted with current key",
+			zap.Int("count", count),
+			zap.String("key_id", keyring.PrimaryKeyID()))
+	}
+}
+
+// credentialsCommand 执行 credentials 子命令：
+// generate-key 输出新密钥；status 查看加密状态；rotate 用新密钥重新加密所有凭据；decrypt 还原为明文（降级前使用）
+func credentialsCommand(dbConfig *database.DatabaseConfig, mainConfigPath string, args []string) {
+	if len(args) == 0 {
+		logger.Fatal(credentialsUsage)
+	}
+
+	if args[0] == "generate-key" {
+		raw, err := secrets.GenerateKey()
+		if err != nil {
+			logger.Fatal("Failed to generate key", zap.Error(err))
+		}
+		fmt.Println(secrets.EncodeKey(raw))
+		return
+	}
+
+	keyring := setupCredentialCipher(mainConfigPath)
+	dbConfig.HealthCheckEnabled = false
+	if err := database.InitDBWithConfig(dbConfig); err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer database.Close()
+
+	switch args[0] {
+	case "status":
+		status, err := repository.NodeCredentialStatus(database.DB)
+		if err != nil {
+			logger.Fatal("Failed to read credential status", zap.Error(err))
+		}
+		fmt.Printf("current key: %s\n", keyring.PrimaryKeyID())
+		fmt.Printf("credentials: %d (empty %d, plaintext %d)\n", status.Total, status.Empty, status.Plaintext)
+		tables := make([]string, 0, len(status.ByTable))
+		for table := range status.ByTable {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+		for _, table := range tables {
+			fmt.Printf("  %s: %d\n", table, status.ByTable[table])
+		}
+		keyIDs := make([]string, 0, len(status.ByKey))
+		for id := range status.ByKey {
+			keyIDs = append(keyIDs, id)
+		}
+		sort.Strings(keyIDs)
+		for _, id := range keyIDs {
+			marker := ""
+			if id == keyring.PrimaryKeyID() {
+				marker = " (current)"
+			}
+			fmt.Printf("  key %s: %d%s\n", id, status.ByKey[id], marker)
+		}
+	case "rotate":
+		if len(args) < 3 || args[1] != "--new-key-file" {
+			logger.Fatal(credentialsUsage)
+		}
+		newKeyFile := args[2]
+		raw, err := secrets.ReadKeyFile(newKeyFile)
+		if os.IsNotExist(err) {
+			// 新密钥文件不存在时生成
+			if raw, err = secrets.GenerateKey(); err == nil {
+				err = secrets.WriteKeyFile(newKeyFile, raw)
+			}
+		}
+		if err != nil {
+			logger.Fatal("Failed to load new key", zap.String("key_file", newKeyFile), zap.Error(err))
+		}
+		rotated, err := keyring.WithPrimary(raw)
+		if err != nil {
+			logger.Fatal("Invalid new key", zap.Error(err))
+		}
+		count, err := repository.ReencryptNodeCredentials(database.DB, rotated)
+		if err != nil {
+			logger.Fatal("Key rotation failed, no credentials were changed", zap.Error(err))
+		}
+		fmt.Printf("re-encrypted %d credentials with key %s\n", count, rotated.PrimaryKeyID())
+		fmt.Printf("set %s=%s (or replace the current key file) on every instance and restart;\n", secrets.EnvKeyFile, newKeyFile)
+		fmt.Printf("keep the old key %s in %s until all instances have restarted\n", keyring.PrimaryKeyID(), secrets.EnvPreviousKeys)
+	case "decrypt":
+		count, err := repository.DecryptNodeCredentials(database.DB, keyring)
+		if err != nil {
+			logger.Fatal("Failed to decrypt credentials", zap.Error(err))
+		}
+		fmt.Printf("decrypted %d credentials; they will be re-encrypted on the next start\n", count)
+	default:
+		logger.Fatal(credentialsUsage)
+	}
+}
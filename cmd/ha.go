@@ -0,0 +1,161 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"superview/internal/cluster"
+	"superview/internal/config"
+	"superview/internal/database"
+	"superview/internal/logger"
+	"superview/internal/services"
+	"superview/internal/supervisor"
+	"superview/internal/websocket"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// clusterComponents 多实例部署组件；单实例模式下 elector 和 relay 为 nil
+type clusterComponents struct {
+	leadership       cluster.Leadership
+	elector          *cluster.Elector
+	relay            *cluster.EventRelay
+	nodeSyncInterval time.Duration
+	stopNodeSync     chan struct{}
+}
+
+// syncNodes 多实例模式下定期从数据库同步节点列表，使其他实例增删节点、切换维护模式后本实例随之更新
+func (c *clusterComponents) syncNodes(reloader *services.NodeReloader) {
+	if c.elector == nil {
+		return
+	}
+	c.stopNodeSync = reloader.StartSync(c.nodeSyncInterval)
+}
+
+// start 开始参与选主（所有身份变化回调注册完成后调用）
+func (c *clusterComponents) start() {
+	if c.elector != nil {
+		c.elector.Start()
+	}
+}
+
+// stop 让出主节点身份并停止事件转发
+func (c *clusterComponents) stop() {
+	if c.elector != nil {
+		c.elector.Stop()
+	}
+	if c.relay != nil {
+		c.relay.Stop()
+	}
+	if c.stopNodeSync != nil {
+		close(c.stopNodeSync)
+		c.stopNodeSync = nil
+	}
+}
+
+// setupCluster 根据 [ha] 配置创建选主器和实例间事件转发
+func setupCluster(db *gorm.DB, haConfig config.HAConfig, hub *websocket.Hub) (*clusterComponents, error) {
+	settings, err := haConfig.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	if !settings.Enabled {
+		return &clusterComponents{leadership: cluster.Standalone(settings.InstanceID)}, nil
+	}
+
+	if database.DialectOf(db) == database.DialectSQLite {
+		logger.Warn("HA mode is enabled with SQLite; all instances must share the same database file, PostgreSQL or MySQL is recommended")
+	}
+	if settings.AdvertiseAddr == "" {
+		logger.Warn("ha.advertise_addr is not set; other instances cannot forward leader-only requests to this instance")
+	}
+
+	relay := cluster.NewEventRelay(db, settings, hub.BroadcastLocal)
+	if err := relay.Start(); err != nil {
+		return nil, err
+	}
+	hub.SetRelay(relay)
+
+	logger.Info("HA mode enabled",
+		zap.String("instance_id", settings.InstanceID),
+		zap.String("advertise_addr", settings.AdvertiseAddr),
+		zap.Duration("node_sync_interval", settings.NodeSyncInterval))
+
+	elector := cluster.NewElector(db, settings)
+	return &clusterComponents{
+		leadership:       elector,
+		elector:          elector,
+		relay:            relay,
+		nodeSyncInterval: settings.NodeSyncInterval,
+	}, nil
+}
+
+// leaderRoles 只在主节点运行的后台任务：节点状态监控（活动日志）、告警监控和指标历史记录
+// 自动刷新不在其中：每个实例都需要最新的节点状态来响应 API 和 WebSocket
+type leaderRoles struct {
+	mu             sync.Mutex
+	service        *supervisor.SupervisorService
+	alertMonitor   *services.AlertMonitor
+	recorder       *services.MetricsHistoryRecorder
+	interval       time.Duration
+	stopMonitoring chan struct{}
+	active         bool
+}
+
+func newLeaderRoles(service *supervisor.SupervisorService, alertMonitor *services.AlertMonitor, recorder *services.MetricsHistoryRecorder, interval time.Duration) *leaderRoles {
+	return &leaderRoles{
+		service:      service,
+		alertMonitor: alertMonitor,
+		recorder:     recorder,
+		interval:     interval,
+	}
+}
+
+// handleLeadershipChange 成为主节点时启动，失去身份时停止
+func (r *leaderRoles) handleLeadershipChange(isLeader bool) {
+	if isLeader {
+		r.start()
+	} else {
+		r.stop()
+	}
+}
+
+func (r *leaderRoles) start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active {
+		return
+	}
+	r.stopMonitoring = r.service.StartMonitoring(r.interval)
+	r.alertMonitor.Start()
+	r.recorder.Start()
+	r.active = true
+	logger.Info("Leader roles started", zap.Duration("monitoring_interval", r.interval))
+}
+
+func (r *leaderRoles) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active {
+		return
+	}
+	r.recorder.Stop()
+	r.alertMonitor.Stop()
+	r.service.StopMonitoring(r.stopMonitoring)
+	r.stopMonitoring = nil
+	r.active = false
+	logger.Info("Leader roles stopped")
+}
+
+// setInterval 更新监控间隔，运行中时重启状态监控
+func (r *leaderRoles) setInterval(interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interval = interval
+	if r.active {
+		r.service.StopMonitoring(r.stopMonitoring)
+		r.stopMonitoring = r.service.StartMonitoring(interval)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"superview/internal/logger"
 	"superview/internal/loggers"
 	"superview/internal/metrics"
+	"superview/internal/metrics/instrument"
 	"superview/internal/middleware"
 	"superview/internal/models"
 	"superview/internal/services"
@@ -44,12 +45,13 @@ type Config struct {
 		Email    string `mapstructure:"email"`
 	} `mapstructure:"admin"`
 	Nodes []struct {
-		Name        string `mapstructure:"name"`
-		Environment string `mapstructure:"environment"`
-		Host        string `mapstructure:"host"`
-		Port        int    `mapstructure:"port"`
-		Username    string `mapstructure:"username"`
-		Password    string `mapstructure:"password"`
+		Name        string            `mapstructure:"name"`
+		Environment string            `mapstructure:"environment"`
+		Host        string            `mapstructure:"host"`
+		Port        int               `mapstructure:"port"`
+		Username    string            `mapstructure:"username"`
+		Password    string            `mapstructure:"password"`
+		Labels      map[string]string `mapstructure:"labels"`
 	} `mapstructure:"nodes"`
 }
 
@@ -82,8 +84,8 @@ func ensureAdminUser(db *gorm.DB, config *Config) error {
 	return nil
 }
 
-func createAdminCommand(username, password, email string) {
-	if err := database.InitDB(); err != nil {
+func createAdminCommand(dbConfig *database.DatabaseConfig, username, password, email string) {
+	if err := database.InitDBWithConfig(dbConfig); err != nil {
 		logger.Fatal("Failed to initialize database", zap.Error(err))
 	}
 	db := database.DB
@@ -105,6 +107,45 @@ func createAdminCommand(username, password, email string) {
 		zap.String("email", email))
 }
 
+// buildDatabaseConfig 根据 [database] 配置段（或旧版 database 字符串）生成数据库连接配置
+func buildDatabaseConfig(appConfig *config.Config) (*database.DatabaseConfig, error) {
+	section := appConfig.ResolvedDatabase()
+
+	dbConfig, err := database.GetDefaultConfigForDriver(section.Driver)
+	if err != nil {
+		return nil, err
+	}
+	dbConfig.DSN = section.DSN
+
+	if section.MaxOpenConns > 0 {
+		dbConfig.MaxOpenConns = section.MaxOpenConns
+	}
+	if section.MaxIdleConns > 0 {
+		dbConfig.MaxIdleConns = section.MaxIdleConns
+	}
+	durations := []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"conn_max_lifetime", section.ConnMaxLifetime, &dbConfig.ConnMaxLifetime},
+		{"conn_max_idle_time", section.ConnMaxIdleTime, &dbConfig.ConnMaxIdleTime},
+		{"query_timeout", section.QueryTimeout, &dbConfig.QueryTimeout},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid database.%s %q: %v", d.name, d.value, err)
+		}
+		*d.dest = parsed
+	}
+
+	return dbConfig, nil
+}
+
 // validateEnvironmentVariables 验证必要的环境变量
 func validateEnvironmentVariables() error {
 	requiredEnvVars := []string{
@@ -199,6 +240,11 @@ func main() {
 	}
 	logger.Info("Application config loaded successfully")
 
+	dbConfig, err := buildDatabaseConfig(appConfig)
+	if err != nil {
+		logger.Fatal("Invalid database configuration", zap.Error(err))
+	}
+
 	if len(os.Args) > 1 && os.Args[1] == "create-admin" {
 		if len(os.Args) < 6 {
 			logger.Fatal("Usage: go run cmd/main.go create-admin --username <username> --password <password> --email <email>")
@@ -221,10 +267,23 @@ func main() {
 		if username == "" || password == "" || email == "" {
 			logger.Fatal("Missing required arguments")
 		}
-		createAdminCommand(username, password, email)
+		createAdminCommand(dbConfig, username, password, email)
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrateCommand(dbConfig, os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "credentials" {
+		credentialsCommand(dbConfig, mainConfigPath, os.Args[2:])
 		return
 	}
 
+	// 节点凭据落库加密（密钥来自 .env 或密钥文件）
+	credentialKeyring := setupCredentialCipher(mainConfigPath)
+
 	// 加载节点配置
 	nodeConfig, err := loadConfig()
 	if err != nil {
@@ -236,10 +295,11 @@ func main() {
 		zap.Int("nodes_count", len(nodeConfig.Nodes)))
 
 	// 初始化数据库
-	if err := database.InitDB(); err != nil {
+	if err := database.InitDBWithConfig(dbConfig); err != nil {
 		logger.Fatal("Failed to initialize database", zap.Error(err))
 	}
 	db := database.DB
+	encryptStoredCredentials(credentialKeyring)
 
 	// 确保管理员用户存在（仅首次 seed）
 	if err := ensureAdminUser(db, nodeConfig); err != nil {
@@ -283,18 +343,45 @@ func main() {
 	// 初始化WebSocket Hub
 	hub := websocket.NewHub(supervisorService)
 	go hub.Run()
+	activityLogService.SetHub(hub)
+
+	// 初始化多实例选主和实例间广播转发（未启用 [ha] 时本实例始终是主节点）
+	clusterComponents, err := setupCluster(db, appConfig.HA, hub)
+	if err != nil {
+		logger.Fatal("Failed to set up HA", zap.Error(err))
+	}
 
-	// 初始化Alert服务和监控
+	// 初始化Alert服务和监控（监控只在主节点运行，见 leaderRoles）
 	alertService := services.NewAlertService(db)
 	alertMonitor := services.NewAlertMonitor(alertService, supervisorService, hub)
-	alertMonitor.Start()
-	logger.Info("Alert Monitor started")
+	alertingSettings, err := appConfig.Alerting.Resolve()
+	if err != nil {
+		logger.Fatal("Invalid alerting configuration", zap.Error(err))
+	}
+	alertMonitor.SetEvaluationInterval(alertingSettings.EvaluationInterval)
+	alertService.SetGrouping(alertingSettings)
+
+	// 进程指标历史（供 /api/v1/query_range 查询），同样只在主节点记录
+	historyInterval, historyRetention, err := appConfig.Metrics.HistorySettings()
+	if err != nil {
+		logger.Fatal("Invalid metrics configuration", zap.Error(err))
+	}
+	historyRecorder := services.NewMetricsHistoryRecorder(db, supervisorService, historyInterval, historyRetention)
+
+	// 进程抖动检测（在状态监控中进行，因此只在主节点）；隔离记录存数据库，所有实例都据此拒绝启动
+	flappingSettings, err := appConfig.Flapping.Resolve()
+	if err != nil {
+		logger.Fatal("Invalid flapping configuration", zap.Error(err))
+	}
+	supervisorService.SetFlapWindows(flappingSettings.Windows)
+	flappingService := services.NewFlappingService(db, alertService, supervisorService, activityLogService, hub, flappingSettings.Quarantine)
 
 	// 同步 nodelist 配置到数据库（配置作为种子，数据库是唯一真相源）
+	// 通过 API 删除的节点保留软删除记录，不会被重新导入
 	logger.Info("Syncing nodelist config to database", zap.Int("config_nodes", len(nodeConfig.Nodes)))
 	for _, node := range nodeConfig.Nodes {
 		var count int64
-		db.Model(&models.Node{}).Where("host = ? AND port = ?", node.Host, node.Port).Count(&count)
+		db.Unscoped().Model(&models.Node{}).Where("host = ? AND port = ?", node.Host, node.Port).Count(&count)
 		if count > 0 {
 			logger.Debug("Node already in database, skipping",
 				zap.String("host", node.Host),
@@ -309,7 +396,9 @@ func main() {
 			Password:    node.Password,
 			Environment: node.Environment,
 			Status:      "configured",
+			Source:      models.NodeSourceConfig,
 		}
+		dbNode.SetLabels(node.Labels)
 		if err := db.Create(&dbNode).Error; err != nil {
 			logger.Error("Failed to seed node to database",
 				zap.String("name", node.Name),
@@ -346,16 +435,21 @@ func main() {
 					zap.String("node_name", node.Name),
 					zap.Error(err))
 			} else {
+				if node.Maintenance {
+					supervisorService.SetNodeMaintenance(node.Name, true)
+				}
+				supervisorService.SetNodeLabels(node.Name, node.GetLabels())
 				logger.Info("Node loaded", zap.String("name", node.Name),
 					zap.String("host", node.Host), zap.Int("port", node.Port))
 			}
 		}
 	}
 
-	// 启动自动刷新和状态监控（从系统设置读取间隔）
+	// 启动自动刷新（从系统设置读取间隔）；状态监控和告警监控只在主节点运行
 	refreshInterval := getRefreshIntervalFromSettings(db)
 	stopRefresh := supervisorService.StartAutoRefresh(refreshInterval)
-	stopMonitoring := supervisorService.StartMonitoring(refreshInterval)
+	roles := newLeaderRoles(supervisorService, alertMonitor, historyRecorder, refreshInterval)
+	clusterComponents.leadership.OnLeadershipChange(roles.handleLeadershipChange)
 	
 	// 设置 WebSocket Hub 的刷新间隔
 	processRefreshInterval := getProcessRefreshIntervalFromSettings(db)
@@ -367,7 +461,7 @@ func main() {
 		zap.Duration("interval", processRefreshInterval))
 	
 	// 启动配置监听器，当刷新间隔改变时重启自动刷新和监控
-	go watchRefreshIntervalChanges(db, supervisorService, hub, &stopRefresh, &stopMonitoring)
+	go watchRefreshIntervalChanges(db, supervisorService, hub, roles, &stopRefresh)
 
 	// 设置Gin路由
 	router := gin.Default()
@@ -392,12 +486,38 @@ func main() {
 		logger.Fatal("Failed to get working directory", zap.Error(err))
 	}
 
+	// 节点列表热重载：SIGHUP、nodelist.toml 变化和 API 都通过 NodeReloader 同步新增、修改和移除
+	nodeReloader := services.NewNodeReloader(db, supervisorService, func() ([]config.NodeConfig, error) {
+		cfg, err := config.NewConfigLoader(mainConfigPath, nodeListPath).LoadWithDefaults()
+		if err != nil {
+			return nil, err
+		}
+		return cfg.Nodes, nil
+	}, activityLogService)
+
+	configManager := config.NewAtomicConfigManager()
+	if _, err := configManager.LoadWithNodeList(mainConfigPath, nodeListPath); err != nil {
+		logger.Warn("Node list file watch disabled", zap.Error(err))
+	} else if err := configManager.WatchNodeList(nodeListPath, func(nodes []config.NodeConfig) {
+		if _, err := nodeReloader.Apply(nodes, "file_watch"); err != nil {
+			logger.Error("Failed to apply node list change", zap.Error(err))
+		}
+	}); err != nil {
+		logger.Warn("Node list file watch disabled", zap.Error(err))
+	}
+
+	clusterComponents.syncNodes(nodeReloader)
+
 	// 设置API路由
-	api.SetupRoutes(router, db, supervisorService, hub)
+	api.SetupRoutes(router, db, supervisorService, hub, clusterComponents.leadership, nodeReloader, flappingService)
+
+	// 所有身份变化回调注册完成后开始选主
+	clusterComponents.start()
 
 	// 设置 Prometheus metrics 端点
 	if appConfig.Metrics.Enabled {
 		promMetrics := metrics.NewPrometheusMetrics(supervisorService)
+		instrument.MustRegister(alertService.MetricsCollector())
 		metricsPath := appConfig.Metrics.Path
 		if metricsPath == "" {
 			metricsPath = "/metrics"
@@ -500,23 +620,9 @@ func main() {
 				continue
 			}
 
-			// 更新节点配置
-			for _, node := range newConfig.Nodes {
-				if _, err := supervisorService.GetNode(node.Name); err != nil {
-					// 新增节点
-					if err := supervisorService.AddNode(
-						node.Name,
-						node.Environment,
-						node.Host,
-						node.Port,
-						node.Username,
-						node.Password,
-					); err != nil {
-						logger.Error("Failed to add new node",
-							zap.String("node_name", node.Name),
-							zap.Error(err))
-					}
-				}
+			// 同步节点：新增、连接参数变化的重连、已移除的下线
+			if _, err := nodeReloader.Reload("sighup"); err != nil {
+				logger.Error("Failed to reload nodes", zap.Error(err))
 			}
 
 			// 更新admin配置已移除：数据库为管理员信息的唯一真相源
@@ -555,16 +661,20 @@ func main() {
 		logger.Error("Server forced to shutdown", zap.Error(err))
 	}
 
+	// 停止节点列表文件监听
+	configManager.Stop()
+
+	// 让出主节点身份（停止监控、调度和扫描），停止实例间事件转发
+	clusterComponents.stop()
+
 	// 关闭WebSocket Hub
 	hub.Close()
 
-	// 停止Alert Monitor
-	alertMonitor.Stop()
-	logger.Info("Alert Monitor stopped")
+	// 停止Alert Monitor和状态监控（单实例模式）
+	roles.stop()
 
-	// 停止自动刷新和监控
+	// 停止自动刷新
 	supervisorService.StopAutoRefresh(stopRefresh)
-	supervisorService.StopMonitoring(stopMonitoring)
 
 	// 停止性能监控
 	if appConfig.Performance.MemoryMonitoringEnabled {
@@ -633,12 +743,13 @@ func loadConfig() (*Config, error) {
 	
 	// 转换节点配置
 	cfg.Nodes = make([]struct {
-		Name        string `mapstructure:"name"`
-		Environment string `mapstructure:"environment"`
-		Host        string `mapstructure:"host"`
-		Port        int    `mapstructure:"port"`
-		Username    string `mapstructure:"username"`
-		Password    string `mapstructure:"password"`
+		Name        string            `mapstructure:"name"`
+		Environment string            `mapstructure:"environment"`
+		Host        string            `mapstructure:"host"`
+		Port        int               `mapstructure:"port"`
+		Username    string            `mapstructure:"username"`
+		Password    string            `mapstructure:"password"`
+		Labels      map[string]string `mapstructure:"labels"`
 	}, len(appCfg.Nodes))
 	
 	for i, node := range appCfg.Nodes {
@@ -648,6 +759,7 @@ func loadConfig() (*Config, error) {
 		cfg.Nodes[i].Port = node.Port
 		cfg.Nodes[i].Username = node.Username
 		cfg.Nodes[i].Password = node.Password
+		cfg.Nodes[i].Labels = node.Labels
 	}
 
 	logger.Info("Config loaded",
@@ -660,7 +772,7 @@ func loadConfig() (*Config, error) {
 // getRefreshIntervalFromSettings 从系统设置中读取刷新间隔
 func getRefreshIntervalFromSettings(db *gorm.DB) time.Duration {
 	var setting models.SystemSettings
-	result := db.Where("key = ?", "refresh_interval").First(&setting)
+	result := db.Where(&models.SystemSettings{Key: "refresh_interval"}).First(&setting)
 	
 	if result.Error != nil || setting.Value == "" {
 		// 默认 30 秒
@@ -679,7 +791,7 @@ func getRefreshIntervalFromSettings(db *gorm.DB) time.Duration {
 // getProcessRefreshIntervalFromSettings 从系统设置中读取进程页面刷新间隔
 func getProcessRefreshIntervalFromSettings(db *gorm.DB) time.Duration {
 	var setting models.SystemSettings
-	result := db.Where("key = ?", "process_refresh_interval").First(&setting)
+	result := db.Where(&models.SystemSettings{Key: "process_refresh_interval"}).First(&setting)
 	
 	if result.Error != nil || setting.Value == "" {
 		// 默认 5 秒
@@ -696,7 +808,7 @@ func getProcessRefreshIntervalFromSettings(db *gorm.DB) time.Duration {
 }
 
 // watchRefreshIntervalChanges 监听刷新间隔配置的变化
-func watchRefreshIntervalChanges(db *gorm.DB, service *supervisor.SupervisorService, hub *websocket.Hub, stopRefresh *chan struct{}, stopMonitoring *chan struct{}) {
+func watchRefreshIntervalChanges(db *gorm.DB, service *supervisor.SupervisorService, hub *websocket.Hub, roles *leaderRoles, stopRefresh *chan struct{}) {
 	ticker := time.NewTicker(10 * time.Second) // 每 10 秒检查一次配置
 	defer ticker.Stop()
 	
@@ -713,13 +825,10 @@ func watchRefreshIntervalChanges(db *gorm.DB, service *supervisor.SupervisorServ
 				zap.Duration("old_interval", lastInterval),
 				zap.Duration("new_interval", currentInterval))
 			
-			// 停止旧的自动刷新和监控
+			// 重启自动刷新；状态监控仅在主节点上重启
 			close(*stopRefresh)
-			close(*stopMonitoring)
-			
-			// 启动新的自动刷新和监控
 			*stopRefresh = service.StartAutoRefresh(currentInterval)
-			*stopMonitoring = service.StartMonitoring(currentInterval)
+			roles.setInterval(currentInterval)
 			lastInterval = currentInterval
 		}
 		
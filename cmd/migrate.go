@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"superview/internal/database"
+	"superview/internal/logger"
+)
+
+const migrateUsage = "Usage: superview migrate up | down <N> | status"
+
+// migrateCommand 执行 migrate 子命令：up 执行所有未执行的迁移，down N 回滚最近 N 个迁移，status 查看迁移状态
+func migrateCommand(dbConfig *database.DatabaseConfig, args []string) {
+	if len(args) == 0 {
+		logger.Fatal(migrateUsage)
+	}
+
+	// 迁移由命令自身管理，连接时不自动执行
+	dbConfig.SkipMigrations = true
+	dbConfig.HealthCheckEnabled = false
+	if err := database.InitDBWithConfig(dbConfig); err != nil {
+		logger.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer database.Close()
+	// 只输出迁移结果，不打印每条 SQL
+	db := database.DB.Session(&gorm.Session{Logger: gormlogger.Default.LogMode(gormlogger.Warn)})
+
+	switch args[0] {
+	case "up":
+		applied, err := database.MigrateUp(db)
+		for _, m := range applied {
+			fmt.Printf("applied  %04d %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			logger.Fatal("Migration failed", zap.Error(err))
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		if len(args) < 2 {
+			logger.Fatal(migrateUsage)
+		}
+		steps, err := strconv.Atoi(args[1])
+		if err != nil || steps <= 0 {
+			logger.Fatal("Invalid step count, expected a positive integer", zap.String("value", args[1]))
+		}
+		rolledBack, err := database.MigrateDown(db, steps)
+		for _, m := range rolledBack {
+			fmt.Printf("reverted %04d %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			logger.Fatal("Rollback failed", zap.Error(err))
+		}
+	case "status":
+		states, err := database.MigrationStatus(db)
+		if err != nil {
+			logger.Fatal("Failed to read migration status", zap.Error(err))
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range states {
+			status, appliedAt := "pending", "-"
+			if s.Applied {
+				status = "applied"
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Unknown {
+				status = "unknown (newer binary)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+		}
+		w.Flush()
+		if err := database.CheckSchemaVersion(db); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		logger.Fatal(migrateUsage)
+	}
+}
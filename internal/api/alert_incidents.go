@@ -0,0 +1,186 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"superview/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlertEscalationPolicyRequest 创建或更新升级策略的请求
+type AlertEscalationPolicyRequest struct {
+	Name        string                  `json:"name" binding:"required,max=100"`
+	Description string                  `json:"description"`
+	Steps       []models.EscalationStep `json:"steps" binding:"required"`
+}
+
+func (r *AlertEscalationPolicyRequest) toModel() *models.AlertEscalationPolicy {
+	return &models.AlertEscalationPolicy{
+		Name:        r.Name,
+		Description: r.Description,
+		Steps:       r.Steps,
+	}
+}
+
+// GetIncidents 获取告警事件列表，可按 status（open、acknowledged、resolved）过滤
+func (h *AlertHandler) GetIncidents(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	incidents, err := h.alertService.ListIncidents(c.Query("status"), limit)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"incidents": incidents,
+	})
+}
+
+// GetIncident 获取告警事件及其告警
+func (h *AlertHandler) GetIncident(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "incident")
+	if !ok {
+		return
+	}
+	incident, err := h.alertService.GetIncident(id)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"incident": incident,
+	})
+}
+
+// AcknowledgeIncident 确认告警事件及其所有活跃告警，停止重复通知和升级
+func (h *AlertHandler) AcknowledgeIncident(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "incident")
+	if !ok {
+		return
+	}
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+	if err := h.alertService.AcknowledgeIncident(id, userID); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Acknowledged alert incident ID: %d", id)
+		h.activityLogService.LogWithContext(c, "INFO", "acknowledge_incident", "alert_incident", fmt.Sprintf("%d", id), msg, nil)
+	}
+
+	handleSuccess(c, "Incident acknowledged", nil)
+}
+
+// GetEscalationPolicies 获取升级策略列表
+func (h *AlertHandler) GetEscalationPolicies(c *gin.Context) {
+	policies, err := h.alertService.ListEscalationPolicies()
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":              "success",
+		"escalation_policies": policies,
+	})
+}
+
+// GetEscalationPolicy 获取单个升级策略
+func (h *AlertHandler) GetEscalationPolicy(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "escalation policy")
+	if !ok {
+		return
+	}
+	policy, err := h.alertService.GetEscalationPolicy(id)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":            "success",
+		"escalation_policy": policy,
+	})
+}
+
+// CreateEscalationPolicy 创建升级策略
+func (h *AlertHandler) CreateEscalationPolicy(c *gin.Context) {
+	var req AlertEscalationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+	policy := req.toModel()
+	policy.CreatedBy = userID
+	if err := h.alertService.CreateEscalationPolicy(policy); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Created escalation policy %s with %d steps", policy.Name, len(policy.Steps))
+		h.activityLogService.LogWithContext(c, "INFO", "create_escalation_policy", "escalation_policy", policy.Name, msg, nil)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":            "success",
+		"escalation_policy": policy,
+	})
+}
+
+// UpdateEscalationPolicy 更新升级策略
+func (h *AlertHandler) UpdateEscalationPolicy(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "escalation policy")
+	if !ok {
+		return
+	}
+	var req AlertEscalationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	policy, err := h.alertService.UpdateEscalationPolicy(id, req.toModel())
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Updated escalation policy %s", policy.Name)
+		h.activityLogService.LogWithContext(c, "INFO", "update_escalation_policy", "escalation_policy", fmt.Sprintf("%d", id), msg, nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":            "success",
+		"escalation_policy": policy,
+	})
+}
+
+// DeleteEscalationPolicy 删除升级策略
+func (h *AlertHandler) DeleteEscalationPolicy(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "escalation policy")
+	if !ok {
+		return
+	}
+	if err := h.alertService.DeleteEscalationPolicy(id); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Deleted escalation policy %d", id)
+		h.activityLogService.LogWithContext(c, "WARNING", "delete_escalation_policy", "escalation_policy", fmt.Sprintf("%d", id), msg, nil)
+	}
+
+	handleSuccess(c, "Escalation policy deleted", nil)
+}
@@ -0,0 +1,416 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"superview/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlertSilenceRequest 创建或更新静默的请求。EndsAt 为空时使用 Duration（如 2h）计算
+type AlertSilenceRequest struct {
+	models.AlertMatcher
+	Comment  string    `json:"comment"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Duration string    `json:"duration"`
+}
+
+func (r *AlertSilenceRequest) toModel() (*models.AlertSilence, error) {
+	silence := &models.AlertSilence{
+		AlertMatcher: r.AlertMatcher,
+		Comment:      r.Comment,
+		StartsAt:     r.StartsAt,
+		EndsAt:       r.EndsAt,
+	}
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = time.Now()
+	}
+	if silence.EndsAt.IsZero() && r.Duration != "" {
+		d, err := time.ParseDuration(r.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration: %w", err)
+		}
+		silence.EndsAt = silence.StartsAt.Add(d)
+	}
+	return silence, nil
+}
+
+// AlertMaintenanceWindowRequest 创建或更新维护窗口的请求
+type AlertMaintenanceWindowRequest struct {
+	models.AlertMatcher
+	Name            string `json:"name" binding:"required,max=100"`
+	Schedule        string `json:"schedule" binding:"required"`
+	DurationMinutes int    `json:"duration_minutes" binding:"required,min=1"`
+	Enabled         *bool  `json:"enabled"` // 默认启用
+	Comment         string `json:"comment"`
+}
+
+func (r *AlertMaintenanceWindowRequest) toModel() *models.AlertMaintenanceWindow {
+	return &models.AlertMaintenanceWindow{
+		Name:            r.Name,
+		AlertMatcher:    r.AlertMatcher,
+		Schedule:        r.Schedule,
+		DurationMinutes: r.DurationMinutes,
+		Enabled:         r.Enabled == nil || *r.Enabled,
+		Comment:         r.Comment,
+	}
+}
+
+// AlertInhibitRuleRequest 创建或更新抑制规则的请求
+type AlertInhibitRuleRequest struct {
+	Name           string `json:"name" binding:"required,max=100"`
+	SourceRuleID   *uint  `json:"source_rule_id"`
+	SourceSeverity string `json:"source_severity"`
+	TargetRuleID   *uint  `json:"target_rule_id"`
+	TargetSeverity string `json:"target_severity"`
+	Equal          string `json:"equal"`   // 默认 node
+	Enabled        *bool  `json:"enabled"` // 默认启用
+}
+
+func (r *AlertInhibitRuleRequest) toModel() *models.AlertInhibitRule {
+	return &models.AlertInhibitRule{
+		Name:           r.Name,
+		SourceRuleID:   r.SourceRuleID,
+		SourceSeverity: r.SourceSeverity,
+		TargetRuleID:   r.TargetRuleID,
+		TargetSeverity: r.TargetSeverity,
+		Equal:          r.Equal,
+		Enabled:        r.Enabled == nil || *r.Enabled,
+	}
+}
+
+// GetSilences 获取静默列表，可按 status（pending、active、expired）过滤
+func (h *AlertHandler) GetSilences(c *gin.Context) {
+	status := c.Query("status")
+	if active, err := strconv.ParseBool(c.Query("active")); err == nil && active {
+		status = models.SilenceStatusActive
+	}
+	silences, err := h.alertService.ListSilences(status)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"silences": silences,
+	})
+}
+
+// GetSilence 获取单个静默
+func (h *AlertHandler) GetSilence(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "silence")
+	if !ok {
+		return
+	}
+	silence, err := h.alertService.GetSilence(id)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"silence": silence,
+	})
+}
+
+// CreateSilence 创建静默
+func (h *AlertHandler) CreateSilence(c *gin.Context) {
+	var req AlertSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+	silence, err := req.toModel()
+	if err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	silence.CreatedBy = userID
+	if err := h.alertService.CreateSilence(silence); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Created alert silence %d until %s", silence.ID, silence.EndsAt.Format(time.RFC3339))
+		h.activityLogService.LogWithContext(c, "INFO", "create_alert_silence", "alert_silence", fmt.Sprintf("%d", silence.ID), msg, nil)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"silence": silence,
+	})
+}
+
+// UpdateSilence 更新静默，可将 ends_at 设为当前时间以提前结束
+func (h *AlertHandler) UpdateSilence(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "silence")
+	if !ok {
+		return
+	}
+	var req AlertSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	update, err := req.toModel()
+	if err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	silence, err := h.alertService.UpdateSilence(id, update)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Updated alert silence %d", id)
+		h.activityLogService.LogWithContext(c, "INFO", "update_alert_silence", "alert_silence", fmt.Sprintf("%d", id), msg, nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"silence": silence,
+	})
+}
+
+// DeleteSilence 删除静默
+func (h *AlertHandler) DeleteSilence(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "silence")
+	if !ok {
+		return
+	}
+	if err := h.alertService.DeleteSilence(id); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Deleted alert silence %d", id)
+		h.activityLogService.LogWithContext(c, "WARNING", "delete_alert_silence", "alert_silence", fmt.Sprintf("%d", id), msg, nil)
+	}
+
+	handleSuccess(c, "Silence deleted", nil)
+}
+
+// GetMaintenanceWindows 获取维护窗口列表
+func (h *AlertHandler) GetMaintenanceWindows(c *gin.Context) {
+	windows, err := h.alertService.ListMaintenanceWindows()
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":              "success",
+		"maintenance_windows": windows,
+	})
+}
+
+// GetMaintenanceWindow 获取单个维护窗口
+func (h *AlertHandler) GetMaintenanceWindow(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "maintenance window")
+	if !ok {
+		return
+	}
+	window, err := h.alertService.GetMaintenanceWindow(id)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":             "success",
+		"maintenance_window": window,
+	})
+}
+
+// CreateMaintenanceWindow 创建维护窗口
+func (h *AlertHandler) CreateMaintenanceWindow(c *gin.Context) {
+	var req AlertMaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+	window := req.toModel()
+	window.CreatedBy = userID
+	if err := h.alertService.CreateMaintenanceWindow(window); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Created maintenance window %s (%s, %d minutes)", window.Name, window.Schedule, window.DurationMinutes)
+		h.activityLogService.LogWithContext(c, "INFO", "create_maintenance_window", "maintenance_window", window.Name, msg, nil)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":             "success",
+		"maintenance_window": window,
+	})
+}
+
+// UpdateMaintenanceWindow 更新维护窗口
+func (h *AlertHandler) UpdateMaintenanceWindow(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "maintenance window")
+	if !ok {
+		return
+	}
+	var req AlertMaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	window, err := h.alertService.UpdateMaintenanceWindow(id, req.toModel())
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Updated maintenance window %s", window.Name)
+		h.activityLogService.LogWithContext(c, "INFO", "update_maintenance_window", "maintenance_window", fmt.Sprintf("%d", id), msg, nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":             "success",
+		"maintenance_window": window,
+	})
+}
+
+// DeleteMaintenanceWindow 删除维护窗口
+func (h *AlertHandler) DeleteMaintenanceWindow(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "maintenance window")
+	if !ok {
+		return
+	}
+	if err := h.alertService.DeleteMaintenanceWindow(id); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Deleted maintenance window %d", id)
+		h.activityLogService.LogWithContext(c, "WARNING", "delete_maintenance_window", "maintenance_window", fmt.Sprintf("%d", id), msg, nil)
+	}
+
+	handleSuccess(c, "Maintenance window deleted", nil)
+}
+
+// GetInhibitRules 获取抑制规则列表
+func (h *AlertHandler) GetInhibitRules(c *gin.Context) {
+	rules, err := h.alertService.ListInhibitRules()
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "success",
+		"inhibit_rules": rules,
+	})
+}
+
+// GetInhibitRule 获取单个抑制规则
+func (h *AlertHandler) GetInhibitRule(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "inhibit rule")
+	if !ok {
+		return
+	}
+	rule, err := h.alertService.GetInhibitRule(id)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "success",
+		"inhibit_rule": rule,
+	})
+}
+
+// CreateInhibitRule 创建抑制规则
+func (h *AlertHandler) CreateInhibitRule(c *gin.Context) {
+	var req AlertInhibitRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+	rule := req.toModel()
+	rule.CreatedBy = userID
+	if err := h.alertService.CreateInhibitRule(rule); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Created inhibit rule %s", rule.Name)
+		h.activityLogService.LogWithContext(c, "INFO", "create_inhibit_rule", "inhibit_rule", rule.Name, msg, nil)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":       "success",
+		"inhibit_rule": rule,
+	})
+}
+
+// UpdateInhibitRule 更新抑制规则
+func (h *AlertHandler) UpdateInhibitRule(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "inhibit rule")
+	if !ok {
+		return
+	}
+	var req AlertInhibitRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	rule, err := h.alertService.UpdateInhibitRule(id, req.toModel())
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Updated inhibit rule %s", rule.Name)
+		h.activityLogService.LogWithContext(c, "INFO", "update_inhibit_rule", "inhibit_rule", fmt.Sprintf("%d", id), msg, nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "success",
+		"inhibit_rule": rule,
+	})
+}
+
+// DeleteInhibitRule 删除抑制规则
+func (h *AlertHandler) DeleteInhibitRule(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "inhibit rule")
+	if !ok {
+		return
+	}
+	if err := h.alertService.DeleteInhibitRule(id); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("Deleted inhibit rule %d", id)
+		h.activityLogService.LogWithContext(c, "WARNING", "delete_inhibit_rule", "inhibit_rule", fmt.Sprintf("%d", id), msg, nil)
+	}
+
+	handleSuccess(c, "Inhibit rule deleted", nil)
+}
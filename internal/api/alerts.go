@@ -9,6 +9,7 @@ import (
 
 	"superview/internal/models"
 	"superview/internal/services"
+	"superview/internal/websocket"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -48,6 +49,11 @@ func (h *AlertHandler) CreateAlertRule(c *gin.Context) {
 		ProcessName *string `json:"process_name,omitempty"`
 		Tags        string  `json:"tags"`
 		ChannelIDs  []uint  `json:"channel_ids"`
+		// 恢复阈值差值和恢复持续秒数
+		Hysteresis    float64 `json:"hysteresis" binding:"gte=0"`
+		ClearDuration int     `json:"clear_duration" binding:"gte=0"`
+		// 升级策略，为空时通知发送到 channel_ids
+		EscalationPolicyID *uint `json:"escalation_policy_id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -76,6 +82,17 @@ func (h *AlertHandler) CreateAlertRule(c *gin.Context) {
 		return
 	}
 
+	if err := services.ValidateAlertRuleTags(req.Tags); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.EscalationPolicyID != nil {
+		if err := h.alertService.ValidateEscalationPolicyID(*req.EscalationPolicyID); err != nil {
+			handleAppError(c, err)
+			return
+		}
+	}
+
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -97,6 +114,11 @@ func (h *AlertHandler) CreateAlertRule(c *gin.Context) {
 		ProcessName: req.ProcessName,
 		Tags:        req.Tags,
 		CreatedBy:   userIDStr,
+
+		Hysteresis:    req.Hysteresis,
+		ClearDuration: req.ClearDuration,
+
+		EscalationPolicyID: req.EscalationPolicyID,
 	}
 
 	err := h.alertService.CreateAlertRule(rule)
@@ -201,12 +223,21 @@ func (h *AlertHandler) UpdateAlertRule(c *gin.Context) {
 		ProcessName *string `json:"process_name"`
 		Tags        string  `json:"tags"`
 		ChannelIDs  []uint  `json:"channel_ids"`
+
+		Hysteresis    *float64 `json:"hysteresis" binding:"omitempty,gte=0"`
+		ClearDuration *int     `json:"clear_duration" binding:"omitempty,gte=0"`
+		// 0 表示取消升级策略
+		EscalationPolicyID *uint `json:"escalation_policy_id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := services.ValidateAlertRuleTags(req.Tags); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	updates := make(map[string]interface{})
 	if req.Name != "" {
@@ -242,6 +273,22 @@ func (h *AlertHandler) UpdateAlertRule(c *gin.Context) {
 	if req.Tags != "" {
 		updates["tags"] = req.Tags
 	}
+	if req.Hysteresis != nil {
+		updates["hysteresis"] = *req.Hysteresis
+	}
+	if req.ClearDuration != nil {
+		updates["clear_duration"] = *req.ClearDuration
+	}
+	if req.EscalationPolicyID != nil {
+		if *req.EscalationPolicyID == 0 {
+			updates["escalation_policy_id"] = nil
+		} else if err := h.alertService.ValidateEscalationPolicyID(*req.EscalationPolicyID); err != nil {
+			handleAppError(c, err)
+			return
+		} else {
+			updates["escalation_policy_id"] = *req.EscalationPolicyID
+		}
+	}
 
 	err := h.alertService.UpdateAlertRule(id, updates)
 	if err != nil {
@@ -323,6 +370,11 @@ func (h *AlertHandler) GetAlerts(c *gin.Context) {
 	if search := c.Query("search"); search != "" {
 		filters["search"] = search
 	}
+	if suppressed := c.Query("suppressed"); suppressed != "" {
+		if v, err := strconv.ParseBool(suppressed); err == nil {
+			filters["suppressed"] = v
+		}
+	}
 
 	alerts, total, err := h.alertService.GetAlerts(page, pageSize, filters)
 	if err != nil {
@@ -712,14 +764,23 @@ func (h *AlertHandler) TestNotificationChannel(c *gin.Context) {
 	})
 }
 
-// broadcastAlertEvent 广播告警事件
+// broadcastAlertEvent 广播告警事件到订阅了 alerts、对应级别或节点主题的客户端
 func (h *AlertHandler) broadcastAlertEvent(eventType string, alertID uint) {
 	if h.hub == nil {
 		return
 	}
 
+	topics := []string{websocket.TopicAlerts}
+	if alert, err := h.alertService.GetAlertByID(alertID); err == nil {
+		topics = append(topics, websocket.TopicAlerts+":severity="+alert.Severity)
+		if alert.NodeName != "" {
+			topics = append(topics, websocket.TopicNode+":"+alert.NodeName)
+		}
+	}
+
 	event := map[string]interface{}{
 		"type":      eventType,
+		"topics":    topics,
 		"alert_id":  alertID,
 		"timestamp": time.Now().Format(time.RFC3339),
 	}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"superview/internal/auth"
+	"superview/internal/cluster"
 	"superview/internal/middleware"
 	"superview/internal/repository"
 	"superview/internal/services"
@@ -17,23 +18,40 @@ type WebSocketHub interface {
 	GetConnectionCount() int64
 }
 
-func SetupRoutes(r *gin.Engine, db *gorm.DB, service *supervisor.SupervisorService, hub WebSocketHub) {
+// eventStreamHub 支持 Server-Sent Events 的 hub
+type eventStreamHub interface {
+	HandleSSE(c *gin.Context)
+}
+
+// SetupRoutes 注册所有 API 路由
+// leadership 决定监控、调度和扫描是否在本实例运行；非主节点收到扫描和调度请求时转发给主节点
+// nodeReloader 为 nil 时节点列表重载接口不可用，flappingService 为 nil 时进程抖动接口不可用
+func SetupRoutes(r *gin.Engine, db *gorm.DB, service *supervisor.SupervisorService, hub WebSocketHub, leadership cluster.Leadership,
+	nodeReloader *services.NodeReloader, flappingService *services.FlappingService) {
 	// 添加性能监控中间件
 	r.Use(middleware.PerformanceMiddleware())
 
 	activityLogService := services.NewActivityLogService(db)
+	activityLogService.SetHub(hub)
 	authService := auth.NewAuthService(db, activityLogService)
 	nodesAPI := NewNodesAPI(service, db, activityLogService)
+	nodesAPI.SetNodeReloader(nodeReloader)
+	nodesAPI.SetFlappingService(flappingService)
 	userAPI := NewUserAPI(db, activityLogService)
 	environmentsAPI := NewEnvironmentsAPI(service)
 	groupsAPI := NewGroupsAPI(service, activityLogService)
 	processesAPI := NewProcessesAPI(service, activityLogService)
 	activityLogsAPI := NewActivityLogsAPI(activityLogService)
 	healthAPI := NewHealthAPI(db, service)
+	clusterAPI := NewClusterAPI(leadership)
+	leaderOnly := cluster.ForwardToLeader(leadership)
 	logManagementAPI := NewLogManagementAPI()
+	promQueryAPI := NewPromQueryAPI(db)
+	flappingAPI := NewFlappingAPI(flappingService, service, activityLogService)
 
 	roleHandler := NewRoleHandler(db, activityLogService)
 	processEnhancedHandler := NewProcessEnhancedHandler(db, activityLogService)
+	processEnhancedHandler.service.SetSupervisorService(service)
 	configurationHandler := NewConfigurationHandler(db, activityLogService)
 	logAnalysisHandler := NewLogAnalysisHandler(db, activityLogService)
 
@@ -44,6 +62,27 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, service *supervisor.SupervisorServi
 	discoveryService := services.NewDiscoveryService(db, discoveryRepo, nodeRepo, hub, service)
 	discoveryAPI := NewDiscoveryAPI(discoveryService, activityLogService)
 
+	// 只有主节点运行调度器和扫描
+	leadership.OnLeadershipChange(processEnhancedHandler.service.HandleLeadershipChange)
+	leadership.OnLeadershipChange(discoveryService.HandleLeadershipChange)
+
+	// 滚动重启只在主节点执行
+	rolloutService := services.NewRolloutService(db, service, hub, activityLogService)
+	rolloutsAPI := NewRolloutsAPI(rolloutService, service, activityLogService)
+	leadership.OnLeadershipChange(rolloutService.HandleLeadershipChange)
+
+	// 按进程依赖顺序启停
+	processOrchestrator := services.NewProcessOrchestrator(db, service, activityLogService)
+	nodesAPI.SetProcessOrchestrator(processOrchestrator)
+	groupsAPI.SetProcessOrchestrator(processOrchestrator)
+	processEnhancedHandler.SetProcessOrchestrator(processOrchestrator)
+	if commandHub, ok := hub.(commandHub); ok {
+		commandHub.SetCommandHandler(NewWSCommandHandler(db, service, processOrchestrator, activityLogService))
+	}
+	if topicHub, ok := hub.(topicHub); ok {
+		topicHub.SetTopicAuthorizer(NewWSTopicAuthorizer(db))
+	}
+
 	// Auth routes
 	authGroup := r.Group("/api/auth")
 	{
@@ -55,24 +94,56 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, service *supervisor.SupervisorServi
 	// Protected API routes
 	apiGroup := r.Group("/api", authService.AuthMiddleware())
 	{
+		// 事件流：与 /ws 推送相同的消息，用于无法建立 WebSocket 的代理环境
+		if sseHub, ok := hub.(eventStreamHub); ok {
+			apiGroup.GET("/events", eventStreamScope, sseHub.HandleSSE)
+		}
+
 		// Health check endpoints
 		healthGroup := apiGroup.Group("/health")
 		{
 			healthGroup.GET("", healthAPI.GetHealth)
 			healthGroup.GET("/live", healthAPI.GetHealthLive)
 			healthGroup.GET("/ready", healthAPI.GetHealthReady)
+			healthGroup.GET("/cluster", clusterAPI.GetClusterStatus)
+		}
+
+		// Prometheus 兼容的历史指标查询，供 Grafana 数据源使用
+		promGroup := apiGroup.Group("/v1")
+		{
+			promGroup.GET("/query", promQueryAPI.Query)
+			promGroup.POST("/query", promQueryAPI.Query)
+			promGroup.GET("/query_range", promQueryAPI.QueryRange)
+			promGroup.POST("/query_range", promQueryAPI.QueryRange)
+			promGroup.GET("/series", promQueryAPI.Series)
+			promGroup.POST("/series", promQueryAPI.Series)
+			promGroup.GET("/labels", promQueryAPI.LabelNames)
+			promGroup.POST("/labels", promQueryAPI.LabelNames)
+			promGroup.GET("/label/:name/values", promQueryAPI.LabelValues)
 		}
 
 		// Nodes routes
 		nodesGroup := apiGroup.Group("/nodes")
 		{
 			nodesGroup.GET("", nodesAPI.GetNodes)
+			nodesGroup.POST("", nodesAPI.CreateNode)
+			// 以主节点的节点列表文件为准，其他实例通过节点同步加载变更
+			nodesGroup.POST("/reload", leaderOnly, nodesAPI.ReloadNodes)
 			nodesGroup.GET("/:node_name", nodesAPI.GetNode)
 			nodesGroup.PUT("/:node_name", nodesAPI.UpdateNode)
-			nodesGroup.GET("/:node_name/processes", nodesAPI.GetNodeProcesses)
+			nodesGroup.DELETE("/:node_name", nodesAPI.DeleteNode)
+			nodesGroup.POST("/:node_name/test", nodesAPI.TestNodeConnection)
+			nodesGroup.PUT("/:node_name/maintenance", nodesAPI.SetNodeMaintenance)
+			nodesGroup.PUT("/:node_name/labels", nodesAPI.SetNodeLabels)
+			// 进程列表附带的抖动得分只在主节点累积
+			nodesGroup.GET("/:node_name/processes", leaderOnly, nodesAPI.GetNodeProcesses)
 			nodesGroup.POST("/:node_name/processes/:process_name/start", nodesAPI.StartProcess)
 			nodesGroup.POST("/:node_name/processes/:process_name/stop", nodesAPI.StopProcess)
 			nodesGroup.POST("/:node_name/processes/:process_name/restart", nodesAPI.RestartProcess)
+			nodesGroup.POST("/:node_name/processes/:process_name/signal", nodesAPI.SignalProcess)
+			if flappingService != nil {
+				nodesGroup.POST("/:node_name/processes/:process_name/quarantine/acknowledge", leaderOnly, flappingAPI.AcknowledgeQuarantine)
+			}
 			nodesGroup.GET("/:node_name/processes/:process_name/logs", nodesAPI.GetProcessLogs)
 			nodesGroup.GET("/:node_name/processes/:process_name/logs/stream", nodesAPI.GetProcessLogStream)
 			// Batch operations
@@ -95,10 +166,18 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, service *supervisor.SupervisorServi
 		}
 
 		// Profile management API
+		apiTokensAPI := NewAPITokensAPI(db, activityLogService)
 		profileGroup := apiGroup.Group("/profile")
 		{
 			profileGroup.GET("", userAPI.GetProfile)
 			profileGroup.PUT("", userAPI.UpdateProfile)
+			profileGroup.PUT("/password", userAPI.ChangeOwnPassword)
+			profileGroup.GET("/password-policy", userAPI.GetPasswordPolicy)
+
+			// Personal API tokens
+			profileGroup.GET("/tokens", apiTokensAPI.ListTokens)
+			profileGroup.POST("/tokens", apiTokensAPI.CreateToken)
+			profileGroup.DELETE("/tokens/:id", apiTokensAPI.RevokeToken)
 		}
 
 		// Environments API
@@ -122,9 +201,19 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, service *supervisor.SupervisorServi
 		processesGroup := apiGroup.Group("/processes")
 		{
 			processesGroup.GET("/aggregated", processesAPI.GetAggregatedProcesses)
+			if flappingService != nil {
+				// 重启记录只在主节点累积
+				processesGroup.GET("/flapping", leaderOnly, flappingAPI.ListFlapping)
+			}
 			processesGroup.POST("/:process_name/start", processesAPI.BatchStartProcess)
 			processesGroup.POST("/:process_name/stop", processesAPI.BatchStopProcess)
 			processesGroup.POST("/:process_name/restart", processesAPI.BatchRestartProcess)
+			processesGroup.POST("/rollouts", leaderOnly, rolloutsAPI.CreateRollout)
+			processesGroup.GET("/rollouts", rolloutsAPI.ListRollouts)
+			processesGroup.GET("/rollouts/:id", rolloutsAPI.GetRollout)
+			processesGroup.POST("/rollouts/:id/pause", leaderOnly, rolloutsAPI.PauseRollout)
+			processesGroup.POST("/rollouts/:id/resume", leaderOnly, rolloutsAPI.ResumeRollout)
+			processesGroup.POST("/rollouts/:id/cancel", leaderOnly, rolloutsAPI.CancelRollout)
 		}
 
 		// Activity Logs API
@@ -179,6 +268,33 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, service *supervisor.SupervisorServi
 			alertsGroup.POST("/:id/acknowledge", alertHandler.AcknowledgeAlert)
 			alertsGroup.POST("/:id/resolve", alertHandler.ResolveAlert)
 
+			// Silences, maintenance windows and inhibit rules
+			alertsGroup.GET("/silences", alertHandler.GetSilences)
+			alertsGroup.POST("/silences", alertHandler.CreateSilence)
+			alertsGroup.GET("/silences/:id", alertHandler.GetSilence)
+			alertsGroup.PUT("/silences/:id", alertHandler.UpdateSilence)
+			alertsGroup.DELETE("/silences/:id", alertHandler.DeleteSilence)
+			alertsGroup.GET("/maintenance-windows", alertHandler.GetMaintenanceWindows)
+			alertsGroup.POST("/maintenance-windows", alertHandler.CreateMaintenanceWindow)
+			alertsGroup.GET("/maintenance-windows/:id", alertHandler.GetMaintenanceWindow)
+			alertsGroup.PUT("/maintenance-windows/:id", alertHandler.UpdateMaintenanceWindow)
+			alertsGroup.DELETE("/maintenance-windows/:id", alertHandler.DeleteMaintenanceWindow)
+			alertsGroup.GET("/inhibit-rules", alertHandler.GetInhibitRules)
+			alertsGroup.POST("/inhibit-rules", alertHandler.CreateInhibitRule)
+			alertsGroup.GET("/inhibit-rules/:id", alertHandler.GetInhibitRule)
+			alertsGroup.PUT("/inhibit-rules/:id", alertHandler.UpdateInhibitRule)
+			alertsGroup.DELETE("/inhibit-rules/:id", alertHandler.DeleteInhibitRule)
+
+			// Incidents and escalation policies
+			alertsGroup.GET("/incidents", alertHandler.GetIncidents)
+			alertsGroup.GET("/incidents/:id", alertHandler.GetIncident)
+			alertsGroup.POST("/incidents/:id/acknowledge", alertHandler.AcknowledgeIncident)
+			alertsGroup.GET("/escalation-policies", alertHandler.GetEscalationPolicies)
+			alertsGroup.POST("/escalation-policies", alertHandler.CreateEscalationPolicy)
+			alertsGroup.GET("/escalation-policies/:id", alertHandler.GetEscalationPolicy)
+			alertsGroup.PUT("/escalation-policies/:id", alertHandler.UpdateEscalationPolicy)
+			alertsGroup.DELETE("/escalation-policies/:id", alertHandler.DeleteEscalationPolicy)
+
 			// Notification channels management
 			alertsGroup.POST("/channels", alertHandler.CreateNotificationChannel)
 			alertsGroup.GET("/channels", alertHandler.GetNotificationChannels)
@@ -197,8 +313,8 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, service *supervisor.SupervisorServi
 		processEnhancedGroup := apiGroup.Group("/process-enhanced")
 		{
 			// Task scheduler management
-			processEnhancedGroup.POST("/scheduler/start", processEnhancedHandler.StartScheduler)
-			processEnhancedGroup.POST("/scheduler/stop", processEnhancedHandler.StopScheduler)
+			processEnhancedGroup.POST("/scheduler/start", leaderOnly, processEnhancedHandler.StartScheduler)
+			processEnhancedGroup.POST("/scheduler/stop", leaderOnly, processEnhancedHandler.StopScheduler)
 
 			// Process group management
 			processEnhancedGroup.POST("/groups", processEnhancedHandler.CreateProcessGroup)
@@ -209,16 +325,20 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, service *supervisor.SupervisorServi
 			processEnhancedGroup.POST("/groups/:id/processes", processEnhancedHandler.AddProcessToGroup)
 			processEnhancedGroup.DELETE("/groups/:id/processes", processEnhancedHandler.RemoveProcessFromGroup)
 			processEnhancedGroup.PUT("/groups/:id/reorder", processEnhancedHandler.ReorderProcessesInGroup)
+			processEnhancedGroup.POST("/groups/:id/start", processEnhancedHandler.StartProcessGroup)
+			processEnhancedGroup.POST("/groups/:id/stop", processEnhancedHandler.StopProcessGroup)
+			processEnhancedGroup.POST("/groups/:id/restart", processEnhancedHandler.RestartProcessGroup)
 
 			// Process dependency management
 			processEnhancedGroup.POST("/dependencies", processEnhancedHandler.CreateProcessDependency)
 			processEnhancedGroup.GET("/dependencies", processEnhancedHandler.GetProcessDependencies)
+			processEnhancedGroup.GET("/dependencies/graph", processEnhancedHandler.GetDependencyGraph)
 			processEnhancedGroup.GET("/dependent-processes", processEnhancedHandler.GetDependentProcesses)
 			processEnhancedGroup.DELETE("/dependencies/:id", processEnhancedHandler.DeleteProcessDependency)
 			processEnhancedGroup.POST("/startup-order", processEnhancedHandler.GetStartupOrder)
 
 			// Scheduled task management
-			processEnhancedGroup.POST("/scheduled-tasks", processEnhancedHandler.CreateScheduledTask)
+			processEnhancedGroup.POST("/scheduled-tasks", leaderOnly, processEnhancedHandler.CreateScheduledTask)
 			processEnhancedGroup.GET("/scheduled-tasks", processEnhancedHandler.GetScheduledTasks)
 			processEnhancedGroup.GET("/scheduled-tasks/:id", processEnhancedHandler.GetScheduledTask)
 			processEnhancedGroup.PUT("/scheduled-tasks/:id", processEnhancedHandler.UpdateScheduledTask)
@@ -415,13 +535,36 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, service *supervisor.SupervisorServi
 		// Requirements: 9.3, 9.4 - All discovery endpoints require authentication
 		discoveryGroup := apiGroup.Group("/discovery")
 		{
-			discoveryGroup.POST("/tasks", discoveryAPI.StartDiscovery)
+			discoveryGroup.POST("/tasks", leaderOnly, discoveryAPI.StartDiscovery)
 			discoveryGroup.GET("/tasks", discoveryAPI.ListTasks)
 			discoveryGroup.GET("/tasks/:id", discoveryAPI.GetTask)
-			discoveryGroup.POST("/tasks/:id/cancel", discoveryAPI.CancelTask)
+			discoveryGroup.POST("/tasks/:id/cancel", leaderOnly, discoveryAPI.CancelTask)
 			discoveryGroup.DELETE("/tasks/:id", discoveryAPI.DeleteTask)
 			discoveryGroup.GET("/tasks/:id/progress", discoveryAPI.GetTaskProgress)
 			discoveryGroup.POST("/validate-cidr", discoveryAPI.ValidateCIDR)
+
+			// Scheduled discovery, change reports and auto-registration rules
+			discoveryGroup.GET("/schedules", discoveryAPI.ListSchedules)
+			discoveryGroup.POST("/schedules", leaderOnly, discoveryAPI.CreateSchedule)
+			discoveryGroup.GET("/schedules/:id", discoveryAPI.GetSchedule)
+			discoveryGroup.PUT("/schedules/:id", leaderOnly, discoveryAPI.UpdateSchedule)
+			discoveryGroup.DELETE("/schedules/:id", leaderOnly, discoveryAPI.DeleteSchedule)
+			discoveryGroup.POST("/schedules/:id/run", leaderOnly, discoveryAPI.RunSchedule)
+			discoveryGroup.GET("/schedules/:id/reports", discoveryAPI.ListReports)
+			discoveryGroup.GET("/reports", discoveryAPI.ListReports)
+			discoveryGroup.GET("/reports/:id", discoveryAPI.GetReport)
+			discoveryGroup.GET("/rules", discoveryAPI.ListRules)
+			discoveryGroup.POST("/rules", discoveryAPI.CreateRule)
+			discoveryGroup.PUT("/rules/:id", discoveryAPI.UpdateRule)
+			discoveryGroup.DELETE("/rules/:id", discoveryAPI.DeleteRule)
+
+			// Review queue and blocklist for discovered nodes
+			discoveryGroup.GET("/candidates", discoveryAPI.ListCandidates)
+			discoveryGroup.POST("/candidates/approve", leaderOnly, discoveryAPI.ApproveCandidates)
+			discoveryGroup.POST("/candidates/reject", discoveryAPI.RejectCandidates)
+			discoveryGroup.GET("/blocklist", discoveryAPI.ListBlocklist)
+			discoveryGroup.POST("/blocklist", discoveryAPI.AddBlocklistEntry)
+			discoveryGroup.DELETE("/blocklist/:id", discoveryAPI.DeleteBlocklistEntry)
 		}
 	}
 }
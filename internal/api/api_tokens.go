@@ -0,0 +1,104 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"superview/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// APITokensAPI 个人 API 令牌管理接口
+type APITokensAPI struct {
+	tokenService       *services.APITokenService
+	activityLogService *services.ActivityLogService
+}
+
+// NewAPITokensAPI 创建 API 令牌管理接口
+func NewAPITokensAPI(db *gorm.DB, activityLogService *services.ActivityLogService) *APITokensAPI {
+	return &APITokensAPI{
+		tokenService:       services.NewAPITokenService(db),
+		activityLogService: activityLogService,
+	}
+}
+
+// ListTokens 获取当前用户的 API 令牌列表
+func (api *APITokensAPI) ListTokens(c *gin.Context) {
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := api.tokenService.ListTokens(userID)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	data := make([]map[string]interface{}, len(tokens))
+	for i := range tokens {
+		data[i] = tokens[i].Serialize()
+	}
+	Success(c, data)
+}
+
+// CreateToken 为当前用户创建 API 令牌，令牌明文仅在响应中返回一次
+func (api *APITokensAPI) CreateToken(c *gin.Context) {
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+
+	var req services.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	plain, token, err := api.tokenService.CreateToken(userID, &req)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		msg := fmt.Sprintf("Created API token %s (%s)", token.Name, token.TokenPrefix)
+		api.activityLogService.LogWithContext(c, "INFO", "create_api_token", "api_token", token.Name, msg, nil)
+	}
+
+	data := token.Serialize()
+	data["token"] = plain
+	c.JSON(http.StatusCreated, Response{
+		Status:  "success",
+		Message: "Token created. Store it now, it will not be shown again",
+		Data:    data,
+	})
+}
+
+// RevokeToken 吊销当前用户的 API 令牌
+func (api *APITokensAPI) RevokeToken(c *gin.Context) {
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseAndValidateID(c, "id", "api token")
+	if !ok {
+		return
+	}
+
+	token, err := api.tokenService.RevokeToken(userID, id)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		msg := fmt.Sprintf("Revoked API token %s (%s)", token.Name, token.TokenPrefix)
+		api.activityLogService.LogWithContext(c, "WARNING", "revoke_api_token", "api_token", token.Name, msg, nil)
+	}
+
+	handleSuccess(c, "Token revoked", token.Serialize())
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"superview/internal/cluster"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClusterAPI 多实例部署状态
+type ClusterAPI struct {
+	leadership cluster.Leadership
+}
+
+// NewClusterAPI 创建集群状态API
+func NewClusterAPI(leadership cluster.Leadership) *ClusterAPI {
+	return &ClusterAPI{leadership: leadership}
+}
+
+// GetClusterStatus 获取当前实例身份和主节点租约
+func (a *ClusterAPI) GetClusterStatus(c *gin.Context) {
+	_, haEnabled := a.leadership.(*cluster.Elector)
+	status := gin.H{
+		"ha_enabled":  haEnabled,
+		"instance_id": a.leadership.InstanceID(),
+		"is_leader":   a.leadership.IsLeader(),
+	}
+
+	if lease, err := a.leadership.Leader(); err == nil {
+		leader := gin.H{
+			"instance_id": lease.Holder,
+			"address":     lease.Address,
+		}
+		if !lease.ExpiresAt.IsZero() {
+			leader["lease_expires_at"] = lease.ExpiresAt
+			leader["lease_valid"] = lease.ExpiresAt.After(time.Now())
+		}
+		status["leader"] = leader
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   status,
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"strconv"
 
 	appErrors "superview/internal/errors"
+	"superview/internal/labels"
+	"superview/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -142,3 +144,32 @@ func validateUserAuth(c *gin.Context) (uint, bool) {
 	}
 	return userID, true
 }
+
+// requirePermission 检查当前用户是否为超级管理员或拥有指定权限，不满足时写入错误响应
+func requirePermission(c *gin.Context, permission string) bool {
+	user, exists := c.Get("user")
+	if !exists {
+		handleUnauthorized(c)
+		return false
+	}
+	currentUser, ok := user.(*models.User)
+	if !ok {
+		handleUnauthorized(c)
+		return false
+	}
+	if !currentUser.IsSuperAdmin() && !currentUser.HasPermission(permission) {
+		handleForbidden(c, "Insufficient permissions")
+		return false
+	}
+	return true
+}
+
+// parseSelector 解析 selector 查询参数（节点标签选择器），格式错误时返回 400
+func parseSelector(c *gin.Context) (labels.Selector, bool) {
+	selector, err := labels.Parse(c.Query("selector"))
+	if err != nil {
+		handleAppError(c, appErrors.NewValidationError("selector", err.Error()))
+		return nil, false
+	}
+	return selector, true
+}
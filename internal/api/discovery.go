@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"superview/internal/errors"
+	"superview/internal/models"
 	"superview/internal/services"
 	"superview/internal/utils"
 
@@ -28,13 +29,18 @@ func NewDiscoveryAPI(service *services.DiscoveryService, activityLogService *ser
 }
 
 // StartDiscoveryRequest represents the request body for starting a discovery task.
+// Either cidr or targets must be set; port and ports may be combined.
 type StartDiscoveryRequest struct {
-	CIDR           string `json:"cidr" binding:"required"`
-	Port           int    `json:"port" binding:"required,min=1,max=65535"`
-	Username       string `json:"username" binding:"required"`
-	Password       string `json:"password" binding:"required"`
-	TimeoutSeconds int    `json:"timeout_seconds"` // optional, default 3
-	MaxWorkers     int    `json:"max_workers"`     // optional, default 50
+	CIDR            string                   `json:"cidr"`
+	Port            int                      `json:"port" binding:"omitempty,min=1,max=65535"`
+	Targets         *models.DiscoveryTargets `json:"targets"`
+	Ports           string                   `json:"ports"` // e.g. "9001,9002,9100-9105"
+	Username        string                   `json:"username" binding:"required"`
+	Password        string                   `json:"password" binding:"required"`
+	TimeoutSeconds  int                      `json:"timeout_seconds"`  // optional, default 3
+	MaxWorkers      int                      `json:"max_workers"`      // optional, default 50
+	RateLimit       int                      `json:"rate_limit"`       // optional probes per second, default unlimited
+	RequireApproval bool                     `json:"require_approval"` // optional, queue new nodes for review
 }
 
 // StartDiscovery handles POST /api/discovery/tasks
@@ -57,19 +63,25 @@ func (api *DiscoveryAPI) StartDiscovery(c *gin.Context) {
 	serviceReq := &services.DiscoveryRequest{
 		CIDR:           req.CIDR,
 		Port:           req.Port,
+		Targets:        req.Targets,
+		Ports:          req.Ports,
 		Username:       req.Username,
 		Password:       req.Password,
 		TimeoutSeconds: req.TimeoutSeconds,
 		MaxWorkers:     req.MaxWorkers,
+		RateLimit:      req.RateLimit,
 		CreatedBy:      userID,
+
+		RequireApproval: req.RequireApproval,
 	}
 
 	task, err := api.service.StartDiscovery(serviceReq)
 	if err != nil {
 		// Log discovery failure - Requirements: 8.4
 		if api.activityLogService != nil {
-			message := fmt.Sprintf("Discovery task failed to start for CIDR %s: %s", req.CIDR, err.Error())
-			api.activityLogService.LogWithContext(c, "ERROR", "discovery_failed", "discovery", req.CIDR, message, nil)
+			scope := (&models.DiscoveryTask{CIDR: req.CIDR, Targets: req.Targets}).Scope()
+			message := fmt.Sprintf("Discovery task failed to start for %s: %s", scope, err.Error())
+			api.activityLogService.LogWithContext(c, "ERROR", "discovery_failed", "discovery", scope, message, nil)
 		}
 		handleAppError(c, err)
 		return
@@ -77,8 +89,12 @@ func (api *DiscoveryAPI) StartDiscovery(c *gin.Context) {
 
 	// Log discovery started - Requirements: 8.1
 	if api.activityLogService != nil {
-		message := fmt.Sprintf("Discovery task started for CIDR %s on port %d (%d IPs to scan)",
-			req.CIDR, req.Port, task.TotalIPs)
+		ports := fmt.Sprintf("port %d", task.Port)
+		if task.Ports != "" {
+			ports = "ports " + task.Ports
+		}
+		message := fmt.Sprintf("Discovery task started for %s on %s (%d IPs to scan)",
+			task.Scope(), ports, task.TotalIPs)
 		api.activityLogService.LogWithContext(c, "INFO", "discovery_started", "discovery", fmt.Sprintf("task-%d", task.ID), message, nil)
 	}
 
@@ -175,8 +191,8 @@ func (api *DiscoveryAPI) CancelTask(c *gin.Context) {
 
 	// Log task cancellation - Requirements: 8.3
 	if api.activityLogService != nil {
-		message := fmt.Sprintf("Discovery task %d cancelled for CIDR %s (scanned %d/%d IPs, found %d nodes)",
-			taskID, task.CIDR, task.ScannedIPs, task.TotalIPs, task.FoundNodes)
+		message := fmt.Sprintf("Discovery task %d cancelled for %s (scanned %d/%d IPs, found %d nodes)",
+			taskID, task.Scope(), task.ScannedIPs, task.TotalIPs, task.FoundNodes)
 		api.activityLogService.LogWithContext(c, "INFO", "discovery_cancelled", "discovery", fmt.Sprintf("task-%d", taskID), message, nil)
 	}
 
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"superview/internal/labels"
+	"superview/internal/models"
+	"superview/internal/services"
+	"superview/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ApproveCandidatesRequest represents the request body for approving discovered nodes.
+// Names renames individual candidates; Environment and Labels apply to all of them.
+type ApproveCandidatesRequest struct {
+	IDs         []uint            `json:"ids" binding:"required,min=1,max=500"`
+	Names       map[uint]string   `json:"names"`
+	Environment string            `json:"environment" binding:"max=50"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// RejectCandidatesRequest represents the request body for rejecting discovered nodes.
+type RejectCandidatesRequest struct {
+	IDs       []uint `json:"ids" binding:"required,min=1,max=500"`
+	Blocklist bool   `json:"blocklist"` // also skip this address and port in later scans
+	Reason    string `json:"reason" binding:"max=255"`
+}
+
+// BlocklistEntryRequest represents the request body for adding a blocklist entry.
+type BlocklistEntryRequest struct {
+	Address string `json:"address" binding:"required,max=255"`
+	Port    int    `json:"port"` // optional, 0 blocks every port
+	Reason  string `json:"reason" binding:"max=255"`
+}
+
+// ListCandidates handles GET /api/discovery/candidates
+// Lists pending candidates by default; ?status= selects approved, rejected or all.
+func (api *DiscoveryAPI) ListCandidates(c *gin.Context) {
+	status := c.DefaultQuery("status", models.CandidateStatusPending)
+	if status == "all" {
+		status = ""
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	candidates, total, err := api.service.ListCandidates(status, (page-1)*limit, limit)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"candidates": candidates,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// ApproveCandidates handles POST /api/discovery/candidates/approve
+func (api *DiscoveryAPI) ApproveCandidates(c *gin.Context) {
+	var req ApproveCandidatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	validator := validation.NewValidator()
+	for id, name := range req.Names {
+		if name = strings.TrimSpace(name); name != "" {
+			validator.ValidateNodeName(fmt.Sprintf("names.%d", id), name)
+		}
+	}
+	if err := labels.Validate(req.Labels); err != nil {
+		validator.AddError("labels", err.Error())
+	}
+	if validator.HasErrors() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "输入验证失败",
+			"errors":  validator.Errors(),
+		})
+		return
+	}
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+
+	outcomes := api.service.ApproveCandidates(req.IDs, services.ApproveOptions{
+		Names:       req.Names,
+		Environment: req.Environment,
+		Labels:      req.Labels,
+		ReviewedBy:  userID,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"results": outcomes,
+		"summary": summarizeOutcomes(outcomes),
+	})
+}
+
+// RejectCandidates handles POST /api/discovery/candidates/reject
+func (api *DiscoveryAPI) RejectCandidates(c *gin.Context) {
+	var req RejectCandidatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+
+	outcomes := api.service.RejectCandidates(req.IDs, req.Blocklist, req.Reason, userID)
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"results": outcomes,
+		"summary": summarizeOutcomes(outcomes),
+	})
+}
+
+// summarizeOutcomes counts outcomes by status
+func summarizeOutcomes(outcomes []services.CandidateOutcome) map[string]int {
+	summary := make(map[string]int)
+	for _, outcome := range outcomes {
+		summary[outcome.Status]++
+	}
+	return summary
+}
+
+// ListBlocklist handles GET /api/discovery/blocklist
+func (api *DiscoveryAPI) ListBlocklist(c *gin.Context) {
+	entries, err := api.service.Blocklist()
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"blocklist": entries,
+	})
+}
+
+// AddBlocklistEntry handles POST /api/discovery/blocklist
+func (api *DiscoveryAPI) AddBlocklistEntry(c *gin.Context) {
+	var req BlocklistEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+
+	entry := &models.DiscoveryBlocklistEntry{
+		Address:   req.Address,
+		Port:      req.Port,
+		Reason:    req.Reason,
+		CreatedBy: userID,
+	}
+	if err := api.service.AddBlocklistEntry(entry); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		message := fmt.Sprintf("%s (port %d) added to the discovery blocklist", entry.Address, entry.Port)
+		api.activityLogService.LogWithContext(c, "INFO", "discovery_blocklist_added", "discovery", fmt.Sprintf("blocklist-%d", entry.ID), message, nil)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"entry":  entry,
+	})
+}
+
+// DeleteBlocklistEntry handles DELETE /api/discovery/blocklist/:id
+func (api *DiscoveryAPI) DeleteBlocklistEntry(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "blocklist entry")
+	if !ok {
+		return
+	}
+	if err := api.service.DeleteBlocklistEntry(id); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		api.activityLogService.LogWithContext(c, "INFO", "discovery_blocklist_removed", "discovery", fmt.Sprintf("blocklist-%d", id),
+			fmt.Sprintf("Discovery blocklist entry %d removed", id), nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Blocklist entry deleted",
+	})
+}
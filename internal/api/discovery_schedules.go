@@ -0,0 +1,359 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"superview/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DiscoveryScheduleRequest represents the request body for creating or updating a schedule.
+// Password may be omitted on update to keep the stored one.
+type DiscoveryScheduleRequest struct {
+	Name            string                   `json:"name" binding:"required,max=100"`
+	CronExpr        string                   `json:"cron_expr" binding:"required"`
+	Enabled         *bool                    `json:"enabled"` // optional, default true
+	CIDR            string                   `json:"cidr"`
+	Port            int                      `json:"port" binding:"omitempty,min=1,max=65535"`
+	Targets         *models.DiscoveryTargets `json:"targets"`
+	Ports           string                   `json:"ports"`
+	Username        string                   `json:"username" binding:"required"`
+	Password        string                   `json:"password"`
+	TimeoutSeconds  int                      `json:"timeout_seconds"`
+	MaxWorkers      int                      `json:"max_workers"`
+	RateLimit       int                      `json:"rate_limit"`
+	RequireApproval bool                     `json:"require_approval"`
+}
+
+func (r *DiscoveryScheduleRequest) toModel() *models.DiscoverySchedule {
+	enabled := r.Enabled == nil || *r.Enabled
+	return &models.DiscoverySchedule{
+		Name:           r.Name,
+		CronExpr:       r.CronExpr,
+		Enabled:        enabled,
+		CIDR:           r.CIDR,
+		Port:           r.Port,
+		Targets:        r.Targets,
+		Ports:          r.Ports,
+		Username:       r.Username,
+		Password:       r.Password,
+		TimeoutSeconds: r.TimeoutSeconds,
+		MaxWorkers:     r.MaxWorkers,
+		RateLimit:      r.RateLimit,
+
+		RequireApproval: r.RequireApproval,
+	}
+}
+
+// ListSchedules handles GET /api/discovery/schedules
+func (api *DiscoveryAPI) ListSchedules(c *gin.Context) {
+	schedules, err := api.service.ListSchedules()
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"schedules": schedules,
+	})
+}
+
+// GetSchedule handles GET /api/discovery/schedules/:id
+func (api *DiscoveryAPI) GetSchedule(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "schedule")
+	if !ok {
+		return
+	}
+	schedule, err := api.service.GetSchedule(id)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"schedule": schedule,
+	})
+}
+
+// CreateSchedule handles POST /api/discovery/schedules
+func (api *DiscoveryAPI) CreateSchedule(c *gin.Context) {
+	var req DiscoveryScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+
+	schedule := req.toModel()
+	schedule.CreatedBy = userID
+	if err := api.service.CreateSchedule(schedule); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		message := fmt.Sprintf("Discovery schedule %s created (%s)", schedule.Name, schedule.CronExpr)
+		api.activityLogService.LogWithContext(c, "INFO", "discovery_schedule_created", "discovery", fmt.Sprintf("schedule-%d", schedule.ID), message, nil)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":   "success",
+		"schedule": schedule,
+	})
+}
+
+// UpdateSchedule handles PUT /api/discovery/schedules/:id
+func (api *DiscoveryAPI) UpdateSchedule(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "schedule")
+	if !ok {
+		return
+	}
+	var req DiscoveryScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	schedule, err := api.service.UpdateSchedule(id, req.toModel())
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		message := fmt.Sprintf("Discovery schedule %s updated (%s, enabled=%t)", schedule.Name, schedule.CronExpr, schedule.Enabled)
+		api.activityLogService.LogWithContext(c, "INFO", "discovery_schedule_updated", "discovery", fmt.Sprintf("schedule-%d", id), message, nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"schedule": schedule,
+	})
+}
+
+// DeleteSchedule handles DELETE /api/discovery/schedules/:id
+func (api *DiscoveryAPI) DeleteSchedule(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "schedule")
+	if !ok {
+		return
+	}
+	if err := api.service.DeleteSchedule(id); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		api.activityLogService.LogWithContext(c, "INFO", "discovery_schedule_deleted", "discovery", fmt.Sprintf("schedule-%d", id),
+			fmt.Sprintf("Discovery schedule %d deleted", id), nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Schedule deleted",
+	})
+}
+
+// RunSchedule handles POST /api/discovery/schedules/:id/run
+// Starts a run immediately instead of waiting for the cron.
+func (api *DiscoveryAPI) RunSchedule(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "schedule")
+	if !ok {
+		return
+	}
+	task, err := api.service.RunSchedule(id)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		message := fmt.Sprintf("Discovery schedule %d run manually as task %d (%d IPs to scan)", id, task.ID, task.TotalIPs)
+		api.activityLogService.LogWithContext(c, "INFO", "discovery_started", "discovery", fmt.Sprintf("task-%d", task.ID), message, nil)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"task":   task,
+	})
+}
+
+// ListReports handles GET /api/discovery/reports and GET /api/discovery/schedules/:id/reports
+func (api *DiscoveryAPI) ListReports(c *gin.Context) {
+	var scheduleID uint
+	if c.Param("id") != "" {
+		id, ok := parseAndValidateID(c, "id", "schedule")
+		if !ok {
+			return
+		}
+		scheduleID = id
+	} else if value := c.Query("schedule_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			handleInvalidID(c, "schedule")
+			return
+		}
+		scheduleID = uint(id)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	reports, total, err := api.service.ListReports(scheduleID, (page-1)*limit, limit)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"reports": reports,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GetReport handles GET /api/discovery/reports/:id
+func (api *DiscoveryAPI) GetReport(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "report")
+	if !ok {
+		return
+	}
+	report, err := api.service.GetReport(id)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"report": report,
+	})
+}
+
+// DiscoveryRuleRequest represents the request body for creating or updating an auto-registration rule.
+type DiscoveryRuleRequest struct {
+	Name        string            `json:"name" binding:"required,max=100"`
+	Priority    int               `json:"priority"`
+	Enabled     *bool             `json:"enabled"` // optional, default true
+	CIDR        string            `json:"cidr"`
+	HostPattern string            `json:"host_pattern"`
+	Action      string            `json:"action" binding:"required"`
+	Environment string            `json:"environment" binding:"max=50"`
+	NamePrefix  string            `json:"name_prefix" binding:"max=50"`
+	Labels      map[string]string `json:"labels"`
+}
+
+func (r *DiscoveryRuleRequest) toModel() *models.DiscoveryRule {
+	return &models.DiscoveryRule{
+		Name:        r.Name,
+		Priority:    r.Priority,
+		Enabled:     r.Enabled == nil || *r.Enabled,
+		CIDR:        r.CIDR,
+		HostPattern: r.HostPattern,
+		Action:      r.Action,
+		Environment: r.Environment,
+		NamePrefix:  r.NamePrefix,
+		Labels:      r.Labels,
+	}
+}
+
+// ListRules handles GET /api/discovery/rules
+func (api *DiscoveryAPI) ListRules(c *gin.Context) {
+	rules, err := api.service.ListRules()
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"rules":  rules,
+	})
+}
+
+// CreateRule handles POST /api/discovery/rules
+func (api *DiscoveryAPI) CreateRule(c *gin.Context) {
+	var req DiscoveryRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	rule := req.toModel()
+	if err := api.service.CreateRule(rule); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		message := fmt.Sprintf("Discovery rule %s created (action %s)", rule.Name, rule.Action)
+		api.activityLogService.LogWithContext(c, "INFO", "discovery_rule_created", "discovery", fmt.Sprintf("rule-%d", rule.ID), message, nil)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"rule":   rule,
+	})
+}
+
+// UpdateRule handles PUT /api/discovery/rules/:id
+func (api *DiscoveryAPI) UpdateRule(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "rule")
+	if !ok {
+		return
+	}
+	var req DiscoveryRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	rule, err := api.service.UpdateRule(id, req.toModel())
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		message := fmt.Sprintf("Discovery rule %s updated (action %s)", rule.Name, rule.Action)
+		api.activityLogService.LogWithContext(c, "INFO", "discovery_rule_updated", "discovery", fmt.Sprintf("rule-%d", id), message, nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"rule":   rule,
+	})
+}
+
+// DeleteRule handles DELETE /api/discovery/rules/:id
+func (api *DiscoveryAPI) DeleteRule(c *gin.Context) {
+	id, ok := parseAndValidateID(c, "id", "rule")
+	if !ok {
+		return
+	}
+	if err := api.service.DeleteRule(id); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		api.activityLogService.LogWithContext(c, "INFO", "discovery_rule_deleted", "discovery", fmt.Sprintf("rule-%d", id),
+			fmt.Sprintf("Discovery rule %d deleted", id), nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Rule deleted",
+	})
+}
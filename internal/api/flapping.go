@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"superview/internal/models"
+	"superview/internal/services"
+	"superview/internal/supervisor"
+	"superview/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FlappingAPI 进程抖动和隔离
+type FlappingAPI struct {
+	service            *services.FlappingService
+	supervisor         *supervisor.SupervisorService
+	activityLogService *services.ActivityLogService
+}
+
+// NewFlappingAPI 创建进程抖动 API
+func NewFlappingAPI(service *services.FlappingService, supervisorService *supervisor.SupervisorService, activityLogService *services.ActivityLogService) *FlappingAPI {
+	return &FlappingAPI{
+		service:            service,
+		supervisor:         supervisorService,
+		activityLogService: activityLogService,
+	}
+}
+
+// ListFlapping GET /api/processes/flapping
+// 返回统计窗口内有重启记录的进程（按得分从高到低）和未确认的隔离
+func (api *FlappingAPI) ListFlapping(c *gin.Context) {
+	processes := api.supervisor.FlappingProcesses()
+	sortFlapStatuses(processes)
+	if processes == nil {
+		processes = []supervisor.FlapStatus{}
+	}
+
+	quarantines, err := api.service.ListQuarantines(c.Query("all") != "true")
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "success",
+		"processes":   processes,
+		"quarantines": quarantines,
+	})
+}
+
+// AcknowledgeQuarantine POST /api/nodes/:node_name/processes/:process_name/quarantine/acknowledge
+// 确认隔离后进程可以再次启动，抖动告警同时解决
+func (api *FlappingAPI) AcknowledgeQuarantine(c *gin.Context) {
+	if !requirePermission(c, models.PermissionProcessWrite) {
+		return
+	}
+	nodeName := c.Param("node_name")
+	processName := c.Param("process_name")
+
+	validator := validation.NewValidator()
+	validator.ValidateNodeName("node_name", nodeName)
+	validator.ValidateProcessName("process_name", processName)
+	if validator.HasErrors() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "输入验证失败",
+			"errors":  validator.Errors(),
+		})
+		return
+	}
+
+	username := ""
+	if user, ok := c.Get("user"); ok {
+		if currentUser, ok := user.(*models.User); ok {
+			username = currentUser.Username
+		}
+	}
+
+	record, err := api.service.Acknowledge(nodeName, processName, username)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		msg := fmt.Sprintf("Acknowledged flapping quarantine of process %s on node %s", processName, nodeName)
+		api.activityLogService.LogWithContext(c, "INFO", "acknowledge_quarantine", "process", processName, msg, nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"quarantine": record,
+	})
+}
+
+// sortFlapStatuses 按得分从高到低，得分相同时按节点和进程名排序
+func sortFlapStatuses(statuses []supervisor.FlapStatus) {
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Score != statuses[j].Score {
+			return statuses[i].Score > statuses[j].Score
+		}
+		if statuses[i].NodeName != statuses[j].NodeName {
+			return statuses[i].NodeName < statuses[j].NodeName
+		}
+		return statuses[i].ProcessName < statuses[j].ProcessName
+	})
+}
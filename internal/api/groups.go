@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 
+	"superview/internal/auth"
+	"superview/internal/labels"
 	"superview/internal/services"
 	"superview/internal/supervisor"
 
@@ -13,6 +15,7 @@ import (
 type GroupsAPI struct {
 	service            *supervisor.SupervisorService
 	activityLogService *services.ActivityLogService
+	orchestrator       *services.ProcessOrchestrator
 }
 
 func NewGroupsAPI(service *supervisor.SupervisorService, activityLogService ...*services.ActivityLogService) *GroupsAPI {
@@ -23,6 +26,11 @@ func NewGroupsAPI(service *supervisor.SupervisorService, activityLogService ...*
 	return api
 }
 
+// SetProcessOrchestrator 设置进程编排器，设置后分组启停按进程依赖顺序执行
+func (g *GroupsAPI) SetProcessOrchestrator(orchestrator *services.ProcessOrchestrator) {
+	g.orchestrator = orchestrator
+}
+
 // GetGroups 获取所有进程分组
 func (g *GroupsAPI) GetGroups(c *gin.Context) {
 	groups := g.service.GetGroups()
@@ -56,8 +64,17 @@ func (g *GroupsAPI) GetGroupDetails(c *gin.Context) {
 func (g *GroupsAPI) StartGroupProcesses(c *gin.Context) {
 	groupName := c.Param("group_name")
 	environmentName := c.Query("environment")
+	selector, ok := parseSelector(c)
+	if !ok {
+		return
+	}
+
+	if g.orchestrator != nil {
+		g.orchestrateGroup(c, groupName, environmentName, selector, services.OrchestrateStart, "start_group", "Started", "Group processes started successfully")
+		return
+	}
 
-	err := g.service.StartGroupProcesses(groupName, environmentName)
+	err := g.service.StartGroupProcesses(groupName, environmentName, selector)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -81,8 +98,17 @@ func (g *GroupsAPI) StartGroupProcesses(c *gin.Context) {
 func (g *GroupsAPI) StopGroupProcesses(c *gin.Context) {
 	groupName := c.Param("group_name")
 	environmentName := c.Query("environment")
+	selector, ok := parseSelector(c)
+	if !ok {
+		return
+	}
 
-	err := g.service.StopGroupProcesses(groupName, environmentName)
+	if g.orchestrator != nil {
+		g.orchestrateGroup(c, groupName, environmentName, selector, services.OrchestrateStop, "stop_group", "Stopped", "Group processes stopped successfully")
+		return
+	}
+
+	err := g.service.StopGroupProcesses(groupName, environmentName, selector)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -106,8 +132,17 @@ func (g *GroupsAPI) StopGroupProcesses(c *gin.Context) {
 func (g *GroupsAPI) RestartGroupProcesses(c *gin.Context) {
 	groupName := c.Param("group_name")
 	environmentName := c.Query("environment")
+	selector, ok := parseSelector(c)
+	if !ok {
+		return
+	}
 
-	err := g.service.RestartGroupProcesses(groupName, environmentName)
+	if g.orchestrator != nil {
+		g.orchestrateGroup(c, groupName, environmentName, selector, services.OrchestrateRestart, "restart_group", "Restarted", "Group processes restarted successfully")
+		return
+	}
+
+	err := g.service.RestartGroupProcesses(groupName, environmentName, selector)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -126,3 +161,32 @@ func (g *GroupsAPI) RestartGroupProcesses(c *gin.Context) {
 		"message": "Group processes restarted successfully",
 	})
 }
+
+// orchestrateGroup 按依赖顺序操作匹配节点上属于该分组的进程，跳过当前请求无权访问的节点
+func (g *GroupsAPI) orchestrateGroup(c *gin.Context, groupName, environmentName string, selector labels.Selector, operation, action, verb, message string) {
+	var nodeNames []string
+	for _, node := range g.service.GetNodesBySelector(selector) {
+		if environmentName != "" && node.Environment != environmentName {
+			continue
+		}
+		if auth.NodeAllowed(c, node.Name, node.Environment) {
+			nodeNames = append(nodeNames, node.Name)
+		}
+	}
+	units := g.orchestrator.GroupUnits(nodeNames, groupName)
+	if len(units) == 0 {
+		handleNotFound(c, "group", groupName)
+		return
+	}
+	result, ok := runOrchestration(c, g.orchestrator, operation, units)
+	if !ok {
+		return
+	}
+
+	if g.activityLogService != nil {
+		msg := fmt.Sprintf("%s all processes in group %s in dependency order (%s)", verb, groupName, orchestrationSummary(result))
+		g.activityLogService.LogWithContext(c, "INFO", action, "group", groupName, msg, nil)
+	}
+
+	respondOrchestration(c, message, result)
+}
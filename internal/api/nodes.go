@@ -3,8 +3,14 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
+	"superview/internal/auth"
+	appErrors "superview/internal/errors"
+	"superview/internal/labels"
 	"superview/internal/models"
 	"superview/internal/services"
 	"superview/internal/supervisor"
@@ -18,6 +24,24 @@ type NodesAPI struct {
 	service            *supervisor.SupervisorService
 	db                 *gorm.DB
 	activityLogService *services.ActivityLogService
+	reloader           *services.NodeReloader
+	orchestrator       *services.ProcessOrchestrator
+	flapping           *services.FlappingService
+}
+
+// SetNodeReloader 设置节点列表重载器（未设置时重载接口返回 503）
+func (api *NodesAPI) SetNodeReloader(reloader *services.NodeReloader) {
+	api.reloader = reloader
+}
+
+// SetProcessOrchestrator 设置进程编排器，设置后整节点启停按进程依赖顺序执行
+func (api *NodesAPI) SetProcessOrchestrator(orchestrator *services.ProcessOrchestrator) {
+	api.orchestrator = orchestrator
+}
+
+// SetFlappingService 设置后进程列表附带抖动得分和隔离状态
+func (api *NodesAPI) SetFlappingService(flapping *services.FlappingService) {
+	api.flapping = flapping
 }
 
 func NewNodesAPI(service *supervisor.SupervisorService, db *gorm.DB, activityLogService ...*services.ActivityLogService) *NodesAPI {
@@ -28,11 +52,19 @@ func NewNodesAPI(service *supervisor.SupervisorService, db *gorm.DB, activityLog
 	return api
 }
 
+// GetNodes 节点列表，selector 参数按标签过滤（如 role=worker,region in (eu,us)）
 func (api *NodesAPI) GetNodes(c *gin.Context) {
-	nodes := api.service.GetAllNodes()
-	response := make([]map[string]interface{}, len(nodes))
-	for i, node := range nodes {
-		response[i] = node.Serialize()
+	selector, ok := parseSelector(c)
+	if !ok {
+		return
+	}
+	nodes := api.service.GetNodesBySelector(selector)
+	response := make([]map[string]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		if !auth.NodeAllowed(c, node.Name, node.Environment) {
+			continue
+		}
+		response = append(response, node.Serialize())
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
@@ -96,6 +128,14 @@ func (api *NodesAPI) GetNodeProcesses(c *gin.Context) {
 	
 	// 使用SerializeProcesses方法返回格式化的数据
 	processes := node.SerializeProcesses()
+	for _, p := range processes {
+		name, _ := p["name"].(string)
+		p["flapping_score"] = api.service.FlapStatus(nodeName, name).Score
+		if api.flapping != nil {
+			quarantined, _ := api.flapping.IsQuarantined(nodeName, name)
+			p["quarantined"] = quarantined
+		}
+	}
 	
 	c.JSON(http.StatusOK, gin.H{
 		"status":    "success",
@@ -209,6 +249,65 @@ func (api *NodesAPI) RestartProcess(c *gin.Context) {
 	handleSuccess(c, "Process restarted successfully", nil)
 }
 
+// processSignalPattern 信号名称（HUP、SIGUSR1）或编号
+var processSignalPattern = regexp.MustCompile(`^(SIG)?[A-Z0-9]{1,8}$`)
+
+// validateProcessSignal 校验并规范化信号
+func validateProcessSignal(signal string) (string, error) {
+	signal = strings.ToUpper(strings.TrimSpace(signal))
+	if !processSignalPattern.MatchString(signal) {
+		return "", appErrors.NewValidationError("signal", "signal must be a name such as HUP or a number")
+	}
+	return signal, nil
+}
+
+// SignalProcess 向进程发送信号
+func (api *NodesAPI) SignalProcess(c *gin.Context) {
+	nodeName := c.Param("node_name")
+	processName := c.Param("process_name")
+
+	var req struct {
+		Signal string `json:"signal" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	// 输入验证
+	validator := validation.NewValidator()
+	validator.ValidateNodeName("node_name", nodeName)
+	validator.ValidateProcessName("process_name", processName)
+	validator.ValidateNoSQLInjection("node_name", nodeName)
+	validator.ValidateNoSQLInjection("process_name", processName)
+
+	if validator.HasErrors() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "输入验证失败",
+			"errors":  validator.Errors(),
+		})
+		return
+	}
+	signal, err := validateProcessSignal(req.Signal)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if err := api.service.SignalProcess(nodeName, processName, signal); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		msg := fmt.Sprintf("Sent signal %s to process %s on node %s", signal, processName, nodeName)
+		api.activityLogService.LogWithContext(c, "INFO", "signal_process", "process", processName, msg, nil)
+	}
+
+	handleSuccess(c, "Signal sent", nil)
+}
+
 func (api *NodesAPI) GetProcessLogs(c *gin.Context) {
 	nodeName := c.Param("node_name")
 	processName := c.Param("process_name")
@@ -327,6 +426,10 @@ func (api *NodesAPI) StartAllProcesses(c *gin.Context) {
 		return
 	}
 
+	if api.orchestrator != nil {
+		api.orchestrateNode(c, nodeName, services.OrchestrateStart, "start_process", "Started", "All processes started")
+		return
+	}
 
 	if err := api.service.StartAllProcesses(nodeName); err != nil {
 		handleAppError(c, err)
@@ -359,6 +462,10 @@ func (api *NodesAPI) StopAllProcesses(c *gin.Context) {
 		return
 	}
 
+	if api.orchestrator != nil {
+		api.orchestrateNode(c, nodeName, services.OrchestrateStop, "stop_process", "Stopped", "All processes stopped")
+		return
+	}
 
 	if err := api.service.StopAllProcesses(nodeName); err != nil {
 		handleAppError(c, err)
@@ -391,6 +498,10 @@ func (api *NodesAPI) RestartAllProcesses(c *gin.Context) {
 		return
 	}
 
+	if api.orchestrator != nil {
+		api.orchestrateNode(c, nodeName, services.OrchestrateRestart, "restart_process", "Restarted", "All processes restarted")
+		return
+	}
 
 	if err := api.service.RestartAllProcesses(nodeName); err != nil {
 		handleInternalError(c, err)
@@ -405,6 +516,26 @@ func (api *NodesAPI) RestartAllProcesses(c *gin.Context) {
 	handleSuccess(c, "All processes restarted", nil)
 }
 
+// orchestrateNode 按依赖顺序操作节点上的所有进程
+func (api *NodesAPI) orchestrateNode(c *gin.Context, nodeName, operation, action, verb, message string) {
+	units, err := api.orchestrator.NodeUnits(nodeName)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	result, ok := runOrchestration(c, api.orchestrator, operation, units)
+	if !ok {
+		return
+	}
+
+	if api.activityLogService != nil {
+		msg := fmt.Sprintf("%s all processes on node %s in dependency order (%s)", verb, nodeName, orchestrationSummary(result))
+		api.activityLogService.LogWithContext(c, "INFO", action, "node", nodeName, msg, nil)
+	}
+
+	respondOrchestration(c, message, result)
+}
+
 // UpdateNode updates a node's name and environment
 func (api *NodesAPI) UpdateNode(c *gin.Context) {
 	nodeName := c.Param("node_name")
@@ -455,3 +586,355 @@ func (api *NodesAPI) UpdateNode(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Node updated"})
 }
+
+// CreateNode 添加节点：保存前先测试连接，连接失败时除非 force=true 否则拒绝
+func (api *NodesAPI) CreateNode(c *gin.Context) {
+	if !requirePermission(c, models.PermissionNodeWrite) {
+		return
+	}
+
+	var req struct {
+		Name        string `json:"name" binding:"required,min=1,max=100"`
+		Host        string `json:"host" binding:"required,max=100"`
+		Port        int    `json:"port" binding:"required,min=1,max=65535"`
+		Username    string `json:"username" binding:"max=50"`
+		Password    string `json:"password" binding:"max=100"`
+		Environment string `json:"environment" binding:"max=50"`
+		Description string            `json:"description" binding:"max=500"`
+		Labels      map[string]string `json:"labels"`
+		Force       bool              `json:"force"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	validator := validation.NewValidator()
+	validator.ValidateNodeName("name", req.Name)
+	validator.ValidateNoSQLInjection("name", req.Name)
+	validator.ValidateNoSQLInjection("host", req.Host)
+	if err := labels.Validate(req.Labels); err != nil {
+		validator.AddError("labels", err.Error())
+	}
+	if validator.HasErrors() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "输入验证失败",
+			"errors":  validator.Errors(),
+		})
+		return
+	}
+
+	if req.Environment == "" {
+		req.Environment = "default"
+	}
+	if !auth.NodeAllowed(c, req.Name, req.Environment) {
+		handleForbidden(c, "API token is not allowed to manage this node")
+		return
+	}
+
+	var count int64
+	api.db.Model(&models.Node{}).Where("name = ?", req.Name).Count(&count)
+	if count > 0 {
+		handleConflict(c, "node", "node name already exists")
+		return
+	}
+	api.db.Model(&models.Node{}).Where("host = ? AND port = ?", req.Host, req.Port).Count(&count)
+	if count > 0 {
+		handleConflict(c, "node", fmt.Sprintf("node %s:%d already exists", req.Host, req.Port))
+		return
+	}
+
+	testResult := supervisor.TestConnection(req.Host, req.Port, req.Username, req.Password)
+	if !testResult.Connected && !req.Force {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":          "error",
+			"message":         "Connection test failed, set force=true to add the node anyway",
+			"connection_test": testResult,
+		})
+		return
+	}
+
+	// 清除同名或同地址的已删除记录（名称唯一索引包含软删除行）
+	api.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND (name = ? OR (host = ? AND port = ?))", req.Name, req.Host, req.Port).
+		Delete(&models.Node{})
+
+	node := models.Node{
+		Name:        req.Name,
+		Host:        req.Host,
+		Port:        req.Port,
+		Username:    req.Username,
+		Password:    req.Password,
+		Environment: req.Environment,
+		Description: req.Description,
+		Status:      "configured",
+		Source:      models.NodeSourceAPI,
+	}
+	node.SetLabels(req.Labels)
+	if err := api.db.Create(&node).Error; err != nil {
+		handleInternalError(c, err)
+		return
+	}
+
+	// 多实例部署时节点同步可能已从数据库加载了该节点
+	err := api.service.AddNode(node.Name, node.Environment, node.Host, node.Port, node.Username, node.Password)
+	if err != nil && !appErrors.IsConflictError(err) {
+		api.db.Unscoped().Delete(&node)
+		handleAppError(c, err)
+		return
+	}
+	api.service.SetNodeLabels(node.Name, req.Labels)
+
+	if api.activityLogService != nil {
+		msg := fmt.Sprintf("Created node %s (%s:%d, environment=%s)", node.Name, node.Host, node.Port, node.Environment)
+		api.activityLogService.LogWithContext(c, "INFO", "create_node", "node", node.Name, msg, nil)
+	}
+
+	response := gin.H{"status": "success", "message": "Node created", "connection_test": testResult}
+	if memNode, err := api.service.GetNode(node.Name); err == nil {
+		response["node"] = memNode.Serialize()
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// DeleteNode 删除节点，同时停止该节点的监控、告警和 WebSocket 推送
+// 数据库记录软删除，避免重启时被 nodelist 配置重新导入
+func (api *NodesAPI) DeleteNode(c *gin.Context) {
+	if !requirePermission(c, models.PermissionNodeDelete) {
+		return
+	}
+	nodeName := c.Param("node_name")
+
+	var node models.Node
+	err := api.db.Where("name = ?", nodeName).First(&node).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		handleInternalError(c, err)
+		return
+	}
+	found := err == nil
+
+	memNode, memErr := api.service.GetNode(nodeName)
+	if !found && memErr != nil {
+		handleNotFound(c, "node", nodeName)
+		return
+	}
+	environment := node.Environment
+	if memErr == nil {
+		environment = memNode.Environment
+	}
+	if !auth.NodeAllowed(c, nodeName, environment) {
+		handleForbidden(c, "API token is not allowed to manage this node")
+		return
+	}
+
+	if found {
+		if err := api.db.Delete(&node).Error; err != nil {
+			handleInternalError(c, err)
+			return
+		}
+	}
+	if memErr == nil {
+		if err := api.service.RemoveNode(nodeName); err != nil && !appErrors.IsNotFoundError(err) {
+			handleAppError(c, err)
+			return
+		}
+	}
+
+	if api.activityLogService != nil {
+		msg := fmt.Sprintf("Deleted node %s", nodeName)
+		api.activityLogService.LogWithContext(c, "WARNING", "delete_node", "node", nodeName, msg, nil)
+	}
+
+	handleSuccess(c, "Node deleted", nil)
+}
+
+// TestNodeConnection 使用节点当前配置测试连接，不修改节点状态
+func (api *NodesAPI) TestNodeConnection(c *gin.Context) {
+	if !requirePermission(c, models.PermissionNodeWrite) {
+		return
+	}
+	nodeName := c.Param("node_name")
+
+	node, err := api.service.GetNode(nodeName)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	if !auth.NodeAllowed(c, node.Name, node.Environment) {
+		handleForbidden(c, "API token is not allowed to manage this node")
+		return
+	}
+
+	result := supervisor.TestConnection(node.Host, node.Port, node.Username, node.Password)
+	c.JSON(http.StatusOK, gin.H{
+		"status":          "success",
+		"node_name":       node.Name,
+		"connection_test": result,
+	})
+}
+
+// SetNodeMaintenance 开启或关闭节点维护模式
+func (api *NodesAPI) SetNodeMaintenance(c *gin.Context) {
+	if !requirePermission(c, models.PermissionNodeWrite) {
+		return
+	}
+	nodeName := c.Param("node_name")
+
+	var req struct {
+		Enabled *bool  `json:"enabled" binding:"required"`
+		Reason  string `json:"reason" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	var node models.Node
+	if err := api.db.Where("name = ?", nodeName).First(&node).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleNotFound(c, "node", nodeName)
+			return
+		}
+		handleInternalError(c, err)
+		return
+	}
+	if !auth.NodeAllowed(c, node.Name, node.Environment) {
+		handleForbidden(c, "API token is not allowed to manage this node")
+		return
+	}
+
+	enabled := *req.Enabled
+	node.Maintenance = enabled
+	node.MaintenanceReason = ""
+	node.MaintenanceSince = nil
+	if enabled {
+		now := time.Now()
+		node.MaintenanceReason = req.Reason
+		node.MaintenanceSince = &now
+	}
+	err := api.db.Model(&node).
+		Select("maintenance", "maintenance_reason", "maintenance_since").
+		Updates(&node).Error
+	if err != nil {
+		handleInternalError(c, err)
+		return
+	}
+
+	// 节点尚未同步到本实例时，由节点同步加载时应用维护模式
+	if err := api.service.SetNodeMaintenance(nodeName, enabled); err != nil && !appErrors.IsNotFoundError(err) {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		msg := fmt.Sprintf("Node %s left maintenance mode", nodeName)
+		if enabled {
+			msg = fmt.Sprintf("Node %s entered maintenance mode", nodeName)
+			if req.Reason != "" {
+				msg += ": " + req.Reason
+			}
+		}
+		api.activityLogService.LogWithContext(c, "INFO", "node_maintenance", "node", nodeName, msg, nil)
+	}
+
+	handleSuccess(c, "Node maintenance mode updated", gin.H{
+		"node_name":          node.Name,
+		"maintenance":        node.Maintenance,
+		"maintenance_reason": node.MaintenanceReason,
+		"maintenance_since":  node.MaintenanceSince,
+	})
+}
+
+// ReloadNodes 重新读取配置文件中的节点列表并同步到数据库和内存；dry_run=true 时只返回变更预览
+func (api *NodesAPI) ReloadNodes(c *gin.Context) {
+	if !requirePermission(c, models.PermissionNodeWrite) {
+		return
+	}
+	// 重载影响所有节点，限定了节点或环境的 API 令牌不能调用
+	if token := auth.APITokenFromContext(c); token != nil && (len(token.GetNodes()) > 0 || len(token.GetEnvironments()) > 0) {
+		handleForbidden(c, "API token restricted to specific nodes cannot reload the node list")
+		return
+	}
+	if api.reloader == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "error", "message": "node list reload is not available"})
+		return
+	}
+
+	if dryRun, _ := strconv.ParseBool(c.Query("dry_run")); dryRun {
+		plan, err := api.reloader.Preview()
+		if err != nil {
+			handleAppError(c, err)
+			return
+		}
+		handleSuccess(c, "Node reload preview", plan)
+		return
+	}
+
+	plan, err := api.reloader.Reload("api")
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		msg := fmt.Sprintf("Reloaded node list: %d added, %d updated, %d removed, %d skipped",
+			plan.Summary[services.NodeChangeAdd], plan.Summary[services.NodeChangeUpdate],
+			plan.Summary[services.NodeChangeRemove], plan.Summary[services.NodeChangeSkip])
+		api.activityLogService.LogWithContext(c, "INFO", "reload_nodes", "node", "nodelist", msg, nil)
+	}
+
+	handleSuccess(c, "Node list reloaded", plan)
+}
+
+// SetNodeLabels 替换节点标签
+// 配置文件中的节点如果在 nodelist.toml 中设置了 labels，下次重载时以配置文件为准
+func (api *NodesAPI) SetNodeLabels(c *gin.Context) {
+	if !requirePermission(c, models.PermissionNodeWrite) {
+		return
+	}
+	nodeName := c.Param("node_name")
+
+	var req struct {
+		Labels map[string]string `json:"labels"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	if err := labels.Validate(req.Labels); err != nil {
+		handleAppError(c, appErrors.NewValidationError("labels", err.Error()))
+		return
+	}
+
+	var node models.Node
+	if err := api.db.Where("name = ?", nodeName).First(&node).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			handleNotFound(c, "node", nodeName)
+			return
+		}
+		handleInternalError(c, err)
+		return
+	}
+	if !auth.NodeAllowed(c, node.Name, node.Environment) {
+		handleForbidden(c, "API token is not allowed to manage this node")
+		return
+	}
+
+	node.SetLabels(req.Labels)
+	if err := api.db.Model(&node).Select("labels").Updates(&node).Error; err != nil {
+		handleInternalError(c, err)
+		return
+	}
+	if err := api.service.SetNodeLabels(nodeName, req.Labels); err != nil && !appErrors.IsNotFoundError(err) {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		msg := fmt.Sprintf("Set labels on node %s: %s", nodeName, labels.Format(req.Labels))
+		api.activityLogService.LogWithContext(c, "INFO", "update_node", "node", nodeName, msg, nil)
+	}
+
+	handleSuccess(c, "Node labels updated", gin.H{"name": nodeName, "labels": node.GetLabels()})
+}
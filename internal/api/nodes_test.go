@@ -0,0 +1,99 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"superview/internal/models"
+	"superview/internal/services"
+	"superview/internal/supervisor"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// nodeInstance 共享数据库的一个 Superview 实例
+type nodeInstance struct {
+	service  *supervisor.SupervisorService
+	reloader *services.NodeReloader
+	router   *gin.Engine
+}
+
+func newNodeInstance(db *gorm.DB) *nodeInstance {
+	service := supervisor.NewSupervisorService()
+	nodesAPI := NewNodesAPI(service, db)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: "admin", Username: "admin", IsAdmin: true})
+	})
+	router.POST("/nodes", nodesAPI.CreateNode)
+	router.DELETE("/nodes/:node_name", nodesAPI.DeleteNode)
+	router.PUT("/nodes/:node_name/maintenance", nodesAPI.SetNodeMaintenance)
+
+	return &nodeInstance{
+		service:  service,
+		reloader: services.NewNodeReloader(db, service, nil),
+		router:   router,
+	}
+}
+
+func (i *nodeInstance) do(t *testing.T, method, path, body string) {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	i.router.ServeHTTP(w, req)
+	require.Less(t, w.Code, 300, w.Body.String())
+}
+
+func TestNodeChangesPropagateBetweenInstances(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "superview.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Node{}))
+
+	leader := newNodeInstance(db)
+	follower := newNodeInstance(db)
+	defer leader.service.Shutdown(context.Background())
+	defer follower.service.Shutdown(context.Background())
+
+	// 非主节点创建的节点在主节点同步后开始监控
+	follower.do(t, http.MethodPost, "/nodes", `{"name":"web-1","host":"127.0.0.1","port":1,"environment":"prod","force":true}`)
+	_, err = leader.service.GetNode("web-1")
+	require.Error(t, err, "leader has not synced yet")
+
+	changes, err := leader.reloader.Sync()
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	node, err := leader.service.GetNode("web-1")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", node.Environment)
+
+	// 维护模式
+	follower.do(t, http.MethodPut, "/nodes/web-1/maintenance", `{"enabled":true,"reason":"patching"}`)
+	_, err = leader.reloader.Sync()
+	require.NoError(t, err)
+	assert.True(t, node.InMaintenance())
+
+	// 删除后主节点停止轮询和告警
+	follower.do(t, http.MethodDelete, "/nodes/web-1", "")
+	_, err = leader.reloader.Sync()
+	require.NoError(t, err)
+	_, err = leader.service.GetNode("web-1")
+	assert.Error(t, err)
+
+	// 同步结果稳定后不再产生变更
+	changes, err = follower.reloader.Sync()
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"superview/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// runOrchestration 按依赖顺序执行启停并返回逐步结果；部分步骤失败时仍返回 200，由 result 说明
+func runOrchestration(c *gin.Context, orchestrator *services.ProcessOrchestrator, operation string, units []services.ProcessUnit) (*services.OrchestrationResult, bool) {
+	var (
+		result *services.OrchestrationResult
+		err    error
+	)
+	switch operation {
+	case services.OrchestrateStart:
+		result, err = orchestrator.Start(units)
+	case services.OrchestrateStop:
+		result, err = orchestrator.Stop(units)
+	default:
+		result, err = orchestrator.Restart(units)
+	}
+	if err != nil {
+		handleAppError(c, err)
+		return nil, false
+	}
+	return result, true
+}
+
+// orchestrationSummary 活动日志中的结果摘要
+func orchestrationSummary(result *services.OrchestrationResult) string {
+	return fmt.Sprintf("%d succeeded, %d failed, %d skipped", result.Succeeded, result.Failed, result.Skipped)
+}
+
+func respondOrchestration(c *gin.Context, message string, result *services.OrchestrationResult) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": message,
+		"result":  result,
+	})
+}
@@ -6,6 +6,9 @@ import (
 	"strconv"
 	"time"
 
+	"superview/internal/auth"
+	appErrors "superview/internal/errors"
+	"superview/internal/labels"
 	"superview/internal/models"
 	"superview/internal/services"
 
@@ -18,6 +21,7 @@ type ProcessEnhancedHandler struct {
 	service            *services.ProcessEnhancedService
 	db                 *gorm.DB
 	activityLogService *services.ActivityLogService
+	orchestrator       *services.ProcessOrchestrator
 }
 
 // NewProcessEnhancedHandler 创建进程增强处理器实例
@@ -156,6 +160,70 @@ func (h *ProcessEnhancedHandler) GetProcessGroup(c *gin.Context) {
 	c.JSON(http.StatusOK, group)
 }
 
+// SetProcessOrchestrator 设置进程编排器，用于按依赖顺序启停进程分组
+func (h *ProcessEnhancedHandler) SetProcessOrchestrator(orchestrator *services.ProcessOrchestrator) {
+	h.orchestrator = orchestrator
+}
+
+// StartProcessGroup 按依赖顺序启动进程分组中的进程
+func (h *ProcessEnhancedHandler) StartProcessGroup(c *gin.Context) {
+	h.operateProcessGroup(c, services.OrchestrateStart, "start_process_group", "Started", "Process group started")
+}
+
+// StopProcessGroup 按依赖的相反顺序停止进程分组中的进程
+func (h *ProcessEnhancedHandler) StopProcessGroup(c *gin.Context) {
+	h.operateProcessGroup(c, services.OrchestrateStop, "stop_process_group", "Stopped", "Process group stopped")
+}
+
+// RestartProcessGroup 重启进程分组中的进程
+func (h *ProcessEnhancedHandler) RestartProcessGroup(c *gin.Context) {
+	h.operateProcessGroup(c, services.OrchestrateRestart, "restart_process_group", "Restarted", "Process group restarted")
+}
+
+// operateProcessGroup 分组中有当前请求无权访问的节点时拒绝整个操作
+func (h *ProcessEnhancedHandler) operateProcessGroup(c *gin.Context, operation, action, verb, message string) {
+	if !requirePermission(c, models.PermissionProcessExecute) {
+		return
+	}
+	id, ok := parseAndValidateID(c, "id", "process_group")
+	if !ok {
+		return
+	}
+	if h.orchestrator == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Process orchestration is not available"})
+		return
+	}
+
+	units, err := h.orchestrator.ProcessGroupUnits(id)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	for _, unit := range units {
+		var node models.Node
+		environment := ""
+		if err := h.db.Select("environment").Where("name = ?", unit.Node).First(&node).Error; err == nil {
+			environment = node.Environment
+		}
+		if !auth.NodeAllowed(c, unit.Node, environment) {
+			handleAppError(c, appErrors.NewForbiddenError("not allowed to access node "+unit.Node))
+			return
+		}
+	}
+
+	result, ok := runOrchestration(c, h.orchestrator, operation, units)
+	if !ok {
+		return
+	}
+
+	if h.activityLogService != nil {
+		msg := fmt.Sprintf("%s process group %d in dependency order (%s)", verb, id, orchestrationSummary(result))
+		h.activityLogService.LogWithContext(c, "INFO", action, "process_group", fmt.Sprintf("%d", id), msg, nil)
+	}
+
+	respondOrchestration(c, message, result)
+}
+
 // UpdateProcessGroup 更新进程分组
 func (h *ProcessEnhancedHandler) UpdateProcessGroup(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -292,12 +360,17 @@ func (h *ProcessEnhancedHandler) CreateProcessDependency(c *gin.Context) {
 		return
 	}
 
+	// 两端可以用 node:process 引用（process / depends_on），也可以用节点 ID 加进程名
 	var req struct {
-		ProcessName      string `json:"process_name" binding:"required"`
-		NodeID           uint   `json:"node_id" binding:"required"`
-		DependentProcess string `json:"dependent_process" binding:"required"`
-		DependentNodeID  uint   `json:"dependent_node_id" binding:"required"`
-		DependencyType   string `json:"dependency_type" binding:"required"`
+		Process          string `json:"process"`
+		DependsOn        string `json:"depends_on"`
+		ProcessName      string `json:"process_name"`
+		NodeID           uint   `json:"node_id"`
+		DependentProcess string `json:"dependent_process"`
+		DependentNodeID  uint   `json:"dependent_node_id"`
+		DependencyType   string `json:"dependency_type"`
+		Required         *bool  `json:"required"`
+		Timeout          int    `json:"timeout" binding:"min=0,max=3600"`
 		Description      string `json:"description"`
 		Enabled          bool   `json:"enabled"`
 	}
@@ -307,25 +380,80 @@ func (h *ProcessEnhancedHandler) CreateProcessDependency(c *gin.Context) {
 		return
 	}
 
+	var err error
+	if req.Process != "" {
+		if req.NodeID, req.ProcessName, err = h.service.ResolveProcessRef(req.Process); err != nil {
+			handleAppError(c, err)
+			return
+		}
+	}
+	if req.DependsOn != "" {
+		if req.DependentNodeID, req.DependentProcess, err = h.service.ResolveProcessRef(req.DependsOn); err != nil {
+			handleAppError(c, err)
+			return
+		}
+	}
+	if req.ProcessName == "" || req.NodeID == 0 || req.DependentProcess == "" || req.DependentNodeID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "process and depends_on (node:process) or node_id/process_name and dependent_node_id/dependent_process are required"})
+		return
+	}
+	for _, nodeID := range []uint{req.NodeID, req.DependentNodeID} {
+		if !h.nodeIDAllowed(c, nodeID) {
+			handleAppError(c, appErrors.NewForbiddenError(fmt.Sprintf("not allowed to access node %d", nodeID)))
+			return
+		}
+	}
+
 	dependency := &models.ProcessDependency{
 		ProcessName:      req.ProcessName,
 		NodeID:           req.NodeID,
 		DependentProcess: req.DependentProcess,
 		DependentNodeID:  req.DependentNodeID,
 		DependencyType:   req.DependencyType,
-		Required:         true,
-		Timeout:          30,
+		Required:         req.Required == nil || *req.Required,
+		Timeout:          req.Timeout,
 	}
 
-	err := h.service.CreateProcessDependency(dependency)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.CreateProcessDependency(dependency); err != nil {
+		handleAppError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, dependency)
 }
 
+// nodeIDAllowed 限定了节点或环境的 API 令牌只能操作其范围内的节点；节点不存在时交由后续校验处理
+func (h *ProcessEnhancedHandler) nodeIDAllowed(c *gin.Context, nodeID uint) bool {
+	var node models.Node
+	if err := h.db.Select("name", "environment").First(&node, nodeID).Error; err != nil {
+		return true
+	}
+	return auth.NodeAllowed(c, node.Name, node.Environment)
+}
+
+// GetDependencyGraph GET /api/process-enhanced/dependencies/graph
+// 返回所有节点上的依赖图，format=dot 时直接返回 Graphviz DOT 文本
+func (h *ProcessEnhancedHandler) GetDependencyGraph(c *gin.Context) {
+	graph, err := h.service.GetDependencyGraph()
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	graph = graph.Filter(func(node, environment string) bool {
+		return auth.NodeAllowed(c, node, environment)
+	})
+
+	if c.Query("format") == "dot" {
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(graph.DOT()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"nodes": graph.Nodes,
+		"edges": graph.Edges,
+		"dot":   graph.DOT(),
+	})
+}
+
 // GetProcessDependencies 获取进程依赖列表
 func (h *ProcessEnhancedHandler) GetProcessDependencies(c *gin.Context) {
 	processName := c.Query("process_name")
@@ -407,7 +535,7 @@ func (h *ProcessEnhancedHandler) GetStartupOrder(c *gin.Context) {
 
 	order, err := h.service.GetStartupOrder(req.Processes, req.NodeID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleAppError(c, err)
 		return
 	}
 
@@ -423,31 +551,39 @@ func (h *ProcessEnhancedHandler) CreateScheduledTask(c *gin.Context) {
 	}
 
 	var req struct {
-		Name        string  `json:"name" binding:"required"`
-		Description string  `json:"description"`
-		CronExpr    string  `json:"cron_expr" binding:"required"`
-		TaskType    string  `json:"task_type" binding:"required"`
-		TargetType  string  `json:"target_type" binding:"required"`
-		TargetID    string  `json:"target_id" binding:"required"`
-		Command     *string `json:"command"`
-		Enabled     bool    `json:"enabled"`
+		Name         string  `json:"name" binding:"required"`
+		Description  string  `json:"description"`
+		CronExpr     string  `json:"cron_expr" binding:"required"`
+		TaskType     string  `json:"task_type" binding:"required"`
+		TargetType   string  `json:"target_type" binding:"required"`
+		TargetID     string  `json:"target_id" binding:"required"`
+		NodeID       *uint   `json:"node_id"`
+		NodeSelector string  `json:"node_selector" binding:"max=500"`
+		Command      *string `json:"command"`
+		Enabled      bool    `json:"enabled"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if _, err := labels.Parse(req.NodeSelector); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	task := &models.ScheduledTask{
-		Name:        req.Name,
-		Description: req.Description,
-		CronExpr:    req.CronExpr,
-		TaskType:    req.TaskType,
-		TargetType:  req.TargetType,
-		TargetID:    req.TargetID,
-		Command:     req.Command,
-		Enabled:     req.Enabled,
-		CreatedBy:   userID.(uint),
+		Name:         req.Name,
+		Description:  req.Description,
+		CronExpr:     req.CronExpr,
+		TaskType:     req.TaskType,
+		TargetType:   req.TargetType,
+		TargetID:     req.TargetID,
+		NodeID:       req.NodeID,
+		NodeSelector: req.NodeSelector,
+		Command:      req.Command,
+		Enabled:      req.Enabled,
+		CreatedBy:    userID.(uint),
 	}
 
 	err := h.service.CreateScheduledTask(task)
@@ -540,6 +676,14 @@ func (h *ProcessEnhancedHandler) UpdateScheduledTask(c *gin.Context) {
 		return
 	}
 
+	if selector, exists := updates["node_selector"]; exists {
+		value, _ := selector.(string)
+		if _, err := labels.Parse(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// 添加更新时间
 	updates["updated_at"] = time.Now()
 
@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	"superview/internal/auth"
+	"superview/internal/labels"
 	"superview/internal/services"
 	"superview/internal/supervisor"
 	"superview/internal/validation"
@@ -48,9 +50,13 @@ type ProcessInstance struct {
 	Group       string  `json:"group"`
 }
 
-// GetAggregatedProcesses 获取聚合的进程列表
+// GetAggregatedProcesses 获取聚合的进程列表，selector 参数按节点标签过滤
 func (api *ProcessesAPI) GetAggregatedProcesses(c *gin.Context) {
-	nodes := api.service.GetAllNodes()
+	selector, ok := parseSelector(c)
+	if !ok {
+		return
+	}
+	nodes := api.service.GetNodesBySelector(selector)
 	if nodes == nil {
 		c.JSON(http.StatusOK, gin.H{
 			"status":    "success",
@@ -63,7 +69,7 @@ func (api *ProcessesAPI) GetAggregatedProcesses(c *gin.Context) {
 	processMap := make(map[string]*AggregatedProcess)
 
 	for _, node := range nodes {
-		if !node.IsConnected {
+		if !node.IsConnected || !auth.NodeAllowed(c, node.Name, node.Environment) {
 			continue
 		}
 
@@ -153,8 +159,13 @@ func (api *ProcessesAPI) BatchStartProcess(c *gin.Context) {
 		return
 	}
 
+	selector, ok := parseSelector(c)
+	if !ok {
+		return
+	}
+
 	// 执行批量操作
-	result := api.batchOperation(processName, "start")
+	result := api.batchOperation(c, processName, "start", selector)
 
 	// 记录日志
 	if api.activityLogService != nil {
@@ -187,8 +198,13 @@ func (api *ProcessesAPI) BatchStopProcess(c *gin.Context) {
 		return
 	}
 
+	selector, ok := parseSelector(c)
+	if !ok {
+		return
+	}
+
 	// 执行批量操作
-	result := api.batchOperation(processName, "stop")
+	result := api.batchOperation(c, processName, "stop", selector)
 
 	// 记录日志
 	if api.activityLogService != nil {
@@ -221,8 +237,13 @@ func (api *ProcessesAPI) BatchRestartProcess(c *gin.Context) {
 		return
 	}
 
+	selector, ok := parseSelector(c)
+	if !ok {
+		return
+	}
+
 	// 执行批量操作
-	result := api.batchOperation(processName, "restart")
+	result := api.batchOperation(c, processName, "restart", selector)
 
 	// 记录日志
 	if api.activityLogService != nil {
@@ -237,9 +258,9 @@ func (api *ProcessesAPI) BatchRestartProcess(c *gin.Context) {
 	})
 }
 
-// batchOperation 执行批量操作
-func (api *ProcessesAPI) batchOperation(processName, operation string) BatchOperationResult {
-	nodes := api.service.GetAllNodes()
+// batchOperation 执行批量操作（仅作用于当前请求允许访问且标签满足 selector 的节点）
+func (api *ProcessesAPI) batchOperation(c *gin.Context, processName, operation string, selector labels.Selector) BatchOperationResult {
+	nodes := api.service.GetNodesBySelector(selector)
 	
 	result := BatchOperationResult{
 		ProcessName: processName,
@@ -259,7 +280,7 @@ func (api *ProcessesAPI) batchOperation(processName, operation string) BatchOper
 	resultChan := make(chan InstanceOperationResult, len(nodes))
 
 	for _, node := range nodes {
-		if !node.IsConnected {
+		if !node.IsConnected || !auth.NodeAllowed(c, node.Name, node.Environment) {
 			continue
 		}
 
@@ -0,0 +1,315 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"superview/internal/auth"
+	"superview/internal/models"
+	"superview/internal/promql"
+	"superview/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultSeriesWindow /api/v1/series 等接口未指定 start 时向前查找的时长
+const defaultSeriesWindow = time.Hour
+
+// PromQueryAPI 兼容 Prometheus HTTP API 的历史指标查询接口（/api/v1/query、/api/v1/query_range 等），
+// Grafana 的 Prometheus 数据源可以直接以 <superview>/api 为地址使用
+type PromQueryAPI struct {
+	db     *gorm.DB
+	store  *services.MetricsHistoryStore
+	engine *promql.Engine
+}
+
+// NewPromQueryAPI 创建查询接口
+func NewPromQueryAPI(db *gorm.DB) *PromQueryAPI {
+	store := services.NewMetricsHistoryStore(db)
+	return &PromQueryAPI{db: db, store: store, engine: promql.NewEngine(store)}
+}
+
+// scopedStorage 只返回 API 令牌可访问节点的序列，求值前过滤，聚合结果不包含范围外的节点
+type scopedStorage struct {
+	storage promql.Storage
+	allowed func(nodeName string) bool
+}
+
+// Select 实现 promql.Storage，不属于任何节点的序列同样过滤掉
+func (s *scopedStorage) Select(hints promql.SelectHints, matchers []*promql.Matcher) ([]promql.Series, error) {
+	series, err := s.storage.Select(hints, matchers)
+	if err != nil {
+		return nil, err
+	}
+	visible := series[:0]
+	for _, item := range series {
+		if node := item.Labels["node"]; node != "" && s.allowed(node) {
+			visible = append(visible, item)
+		}
+	}
+	return visible, nil
+}
+
+// storageFor 请求可查询的数据源，受节点限制的 API 令牌只能看到范围内节点的序列
+func (a *PromQueryAPI) storageFor(c *gin.Context) (promql.Storage, error) {
+	token := auth.APITokenFromContext(c)
+	if token == nil || !token.HasNodeRestrictions() {
+		return a.store, nil
+	}
+
+	// 包括已删除的节点，与历史数据的节点名称保持一致
+	var nodes []models.Node
+	if err := a.db.Unscoped().Select("name", "environment").Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	environments := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		environments[node.Name] = node.Environment
+	}
+	return &scopedStorage{storage: a.store, allowed: func(nodeName string) bool {
+		return auth.NodeAllowed(c, nodeName, environments[nodeName])
+	}}, nil
+}
+
+// engineFor 在请求可查询的数据源上执行查询的引擎
+func (a *PromQueryAPI) engineFor(c *gin.Context) (*promql.Engine, error) {
+	storage, err := a.storageFor(c)
+	if err != nil {
+		return nil, err
+	}
+	if _, scoped := storage.(*scopedStorage); !scoped {
+		return a.engine, nil
+	}
+	engine := promql.NewEngine(storage)
+	engine.Lookback = a.engine.Lookback
+	return engine, nil
+}
+
+// Query 瞬时查询：query、time（可选，默认当前时间）
+func (a *PromQueryAPI) Query(c *gin.Context) {
+	query := c.Request.FormValue("query")
+	if query == "" {
+		promError(c, "missing parameter \"query\"")
+		return
+	}
+	ts, err := parsePromTime(c.Request.FormValue("time"), time.Now())
+	if err != nil {
+		promError(c, fmt.Sprintf("invalid parameter \"time\": %v", err))
+		return
+	}
+
+	engine, err := a.engineFor(c)
+	if err != nil {
+		promError(c, err.Error())
+		return
+	}
+	value, err := engine.Instant(query, ts)
+	if err != nil {
+		promError(c, err.Error())
+		return
+	}
+	promSuccess(c, gin.H{"resultType": value.Type(), "result": encodeValue(value)})
+}
+
+// QueryRange 区间查询：query、start、end、step
+func (a *PromQueryAPI) QueryRange(c *gin.Context) {
+	query := c.Request.FormValue("query")
+	if query == "" {
+		promError(c, "missing parameter \"query\"")
+		return
+	}
+	start, err := parsePromTime(c.Request.FormValue("start"), time.Time{})
+	if err != nil || start.IsZero() {
+		promError(c, "invalid parameter \"start\"")
+		return
+	}
+	end, err := parsePromTime(c.Request.FormValue("end"), time.Time{})
+	if err != nil || end.IsZero() {
+		promError(c, "invalid parameter \"end\"")
+		return
+	}
+	step, err := promql.ParseDuration(c.Request.FormValue("step"))
+	if err != nil || step <= 0 {
+		promError(c, "invalid parameter \"step\": zero or negative query resolution step widths are not accepted")
+		return
+	}
+
+	engine, err := a.engineFor(c)
+	if err != nil {
+		promError(c, err.Error())
+		return
+	}
+	matrix, err := engine.Range(query, start, end, step)
+	if err != nil {
+		promError(c, err.Error())
+		return
+	}
+	promSuccess(c, gin.H{"resultType": matrix.Type(), "result": encodeValue(matrix)})
+}
+
+// Series 按 match[] 选择器列出序列的标签集合
+func (a *PromQueryAPI) Series(c *gin.Context) {
+	if err := c.Request.ParseForm(); err != nil {
+		promError(c, err.Error())
+		return
+	}
+	selectors := c.Request.Form["match[]"]
+	if len(selectors) == 0 {
+		promError(c, "no match[] parameter provided")
+		return
+	}
+	series, err := a.selectSeries(c, selectors)
+	if err != nil {
+		promError(c, err.Error())
+		return
+	}
+
+	result := make([]promql.Labels, 0, len(series))
+	for _, s := range series {
+		result = append(result, s.Labels)
+	}
+	promSuccess(c, result)
+}
+
+// LabelNames 列出标签名称
+func (a *PromQueryAPI) LabelNames(c *gin.Context) {
+	promSuccess(c, a.store.LabelNames())
+}
+
+// LabelValues 列出标签的取值，__name__ 返回所有可查询的指标名称
+func (a *PromQueryAPI) LabelValues(c *gin.Context) {
+	name := c.Param("name")
+	if name == promql.MetricNameLabel {
+		names, err := a.store.MetricNames()
+		if err != nil {
+			promError(c, err.Error())
+			return
+		}
+		promSuccess(c, names)
+		return
+	}
+
+	series, err := a.selectSeries(c, []string{`{__name__=~".+"}`})
+	if err != nil {
+		promError(c, err.Error())
+		return
+	}
+	seen := make(map[string]bool)
+	values := []string{}
+	for _, s := range series {
+		if value, ok := s.Labels[name]; ok && value != "" && !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+	promSuccess(c, values)
+}
+
+// selectSeries 在 start/end 范围内（默认最近一小时）查找满足任一选择器的序列，按标签集合去重
+func (a *PromQueryAPI) selectSeries(c *gin.Context, selectors []string) ([]promql.Series, error) {
+	end, err := parsePromTime(c.Request.FormValue("end"), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameter \"end\": %v", err)
+	}
+	start, err := parsePromTime(c.Request.FormValue("start"), end.Add(-defaultSeriesWindow))
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameter \"start\": %v", err)
+	}
+
+	storage, err := a.storageFor(c)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var result []promql.Series
+	for _, selector := range selectors {
+		expr, err := promql.ParseExpr(selector)
+		if err != nil {
+			return nil, err
+		}
+		vs, ok := expr.(*promql.VectorSelector)
+		if !ok {
+			return nil, fmt.Errorf("match[] must be a series selector: %s", selector)
+		}
+		series, err := storage.Select(promql.SelectHints{Start: start, End: end}, vs.Matchers)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range series {
+			key := fmt.Sprint(s.Labels)
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, s)
+			}
+		}
+	}
+	return result, nil
+}
+
+// parsePromTime 解析 RFC3339 或 Unix 秒（可带小数）格式的时间，空字符串返回 fallback
+func parsePromTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		sec, frac := math.Modf(seconds)
+		return time.Unix(int64(sec), int64(math.Round(frac*1e3))*int64(time.Millisecond)), nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// encodeValue 按 Prometheus API 的 JSON 格式输出查询结果
+func encodeValue(value promql.Value) interface{} {
+	switch v := value.(type) {
+	case promql.Scalar:
+		return encodePoint(promql.Point(v))
+	case promql.Vector:
+		result := make([]gin.H, 0, len(v))
+		for _, sample := range v {
+			result = append(result, gin.H{"metric": sample.Labels, "value": encodePoint(sample.Point)})
+		}
+		return result
+	case promql.Matrix:
+		result := make([]gin.H, 0, len(v))
+		for _, series := range v {
+			values := make([][]interface{}, 0, len(series.Points))
+			for _, p := range series.Points {
+				values = append(values, encodePoint(p))
+			}
+			result = append(result, gin.H{"metric": series.Labels, "values": values})
+		}
+		return result
+	}
+	return nil
+}
+
+// encodePoint [秒级时间戳, "数值"]
+func encodePoint(p promql.Point) []interface{} {
+	var value string
+	switch {
+	case math.IsInf(p.V, 1):
+		value = "+Inf"
+	case math.IsInf(p.V, -1):
+		value = "-Inf"
+	case math.IsNaN(p.V):
+		value = "NaN"
+	default:
+		value = strconv.FormatFloat(p.V, 'f', -1, 64)
+	}
+	return []interface{}{float64(p.T) / 1000, value}
+}
+
+func promSuccess(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": data})
+}
+
+func promError(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{"status": "error", "errorType": "bad_data", "error": message})
+}
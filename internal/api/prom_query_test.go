@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"superview/internal/auth"
+	"superview/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromQueryNodeScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Node{}, &models.ProcessMetrics{}, &models.SystemMetric{},
+		&models.AlertRule{}, &models.Alert{}))
+	require.NoError(t, db.Create(&models.Node{Name: "web-1", Host: "10.0.0.1", Port: 9001, Environment: "staging"}).Error)
+	require.NoError(t, db.Create(&models.Node{Name: "db-1", Host: "10.0.0.2", Port: 9001, Environment: "prod"}).Error)
+	now := time.Now()
+	for nodeID, restarts := range map[uint]int{1: 2, 2: 5} {
+		require.NoError(t, db.Create(&models.ProcessMetrics{ProcessName: "api", NodeID: nodeID, Restarts: restarts, Timestamp: now.Add(-time.Minute)}).Error)
+	}
+
+	promAPI := NewPromQueryAPI(db)
+	call := func(handler gin.HandlerFunc, token *models.APIToken, form url.Values) map[string]interface{} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/query", strings.NewReader(form.Encode()))
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		c.Params = gin.Params{{Key: "name", Value: "node"}}
+		if token != nil {
+			c.Set(auth.APITokenContextKey, token)
+		}
+		handler(c)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+	sum := func(token *models.APIToken) string {
+		body := call(promAPI.Query, token, url.Values{"query": {`sum(superview_process_restarts_total)`}})
+		result := body["data"].(map[string]interface{})["result"].([]interface{})
+		require.Len(t, result, 1)
+		return result[0].(map[string]interface{})["value"].([]interface{})[1].(string)
+	}
+
+	token := &models.APIToken{}
+	token.SetEnvironments([]string{"staging"})
+	assert.Equal(t, "7", sum(nil))
+	assert.Equal(t, "7", sum(&models.APIToken{}))
+	// 聚合在过滤之后进行，不包含范围外节点的数据
+	assert.Equal(t, "2", sum(token))
+
+	body := call(promAPI.QueryRange, token, url.Values{
+		"query": {`superview_process_restarts_total`},
+		"start": {now.Add(-2 * time.Minute).Format(time.RFC3339)},
+		"end":   {now.Format(time.RFC3339)},
+		"step":  {"30s"},
+	})
+	result := body["data"].(map[string]interface{})["result"].([]interface{})
+	require.Len(t, result, 1)
+	assert.Equal(t, "web-1", result[0].(map[string]interface{})["metric"].(map[string]interface{})["node"])
+
+	body = call(promAPI.Series, token, url.Values{"match[]": {`superview_process_restarts_total`}})
+	assert.Len(t, body["data"], 1)
+	body = call(promAPI.LabelValues, token, url.Values{})
+	assert.Equal(t, []interface{}{"web-1"}, body["data"])
+	body = call(promAPI.LabelValues, nil, url.Values{})
+	assert.Equal(t, []interface{}{"db-1", "web-1"}, body["data"])
+}
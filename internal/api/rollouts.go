@@ -0,0 +1,241 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"superview/internal/auth"
+	appErrors "superview/internal/errors"
+	"superview/internal/labels"
+	"superview/internal/models"
+	"superview/internal/services"
+	"superview/internal/supervisor"
+	"superview/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RolloutsAPI 跨节点滚动重启
+type RolloutsAPI struct {
+	service            *services.RolloutService
+	supervisor         *supervisor.SupervisorService
+	activityLogService *services.ActivityLogService
+}
+
+// NewRolloutsAPI 创建滚动重启 API
+func NewRolloutsAPI(service *services.RolloutService, supervisorService *supervisor.SupervisorService, activityLogService *services.ActivityLogService) *RolloutsAPI {
+	return &RolloutsAPI{
+		service:            service,
+		supervisor:         supervisorService,
+		activityLogService: activityLogService,
+	}
+}
+
+// CreateRolloutRequest 创建滚动重启的请求体
+type CreateRolloutRequest struct {
+	ProcessName          string `json:"process_name"`
+	GroupName            string `json:"group_name"`
+	Selector             string `json:"selector" binding:"max=500"`
+	BatchSize            int    `json:"batch_size"`
+	BatchPercent         int    `json:"batch_percent"`
+	PauseSeconds         int    `json:"pause_seconds"`
+	HealthySeconds       int    `json:"healthy_seconds"`
+	HealthTimeoutSeconds int    `json:"health_timeout_seconds"`
+	MaxFailures          int    `json:"max_failures"`
+	MaxFailurePercent    int    `json:"max_failure_percent"`
+}
+
+// CreateRollout POST /api/processes/rollouts
+// 在 selector 匹配且当前请求有权访问的节点上分批重启进程或进程组
+func (api *RolloutsAPI) CreateRollout(c *gin.Context) {
+	if !requirePermission(c, models.PermissionProcessExecute) {
+		return
+	}
+	var req CreateRolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	validator := validation.NewValidator()
+	if req.ProcessName != "" {
+		validator.ValidateProcessName("process_name", req.ProcessName)
+	}
+	if req.GroupName != "" {
+		validator.ValidateProcessName("group_name", req.GroupName)
+	}
+	if validator.HasErrors() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "输入验证失败",
+			"errors":  validator.Errors(),
+		})
+		return
+	}
+
+	selector, err := labels.Parse(req.Selector)
+	if err != nil {
+		handleAppError(c, appErrors.NewValidationError("selector", err.Error()))
+		return
+	}
+	userID, ok := validateUserAuthString(c)
+	if !ok {
+		return
+	}
+
+	var nodes []services.RolloutNode
+	for _, node := range api.supervisor.GetNodesBySelector(selector) {
+		if auth.NodeAllowed(c, node.Name, node.Environment) {
+			nodes = append(nodes, services.RolloutNode{Name: node.Name, Environment: node.Environment})
+		}
+	}
+
+	detail, err := api.service.Start(&services.RolloutRequest{
+		ProcessName:          req.ProcessName,
+		GroupName:            req.GroupName,
+		Selector:             selector.String(),
+		Nodes:                nodes,
+		BatchSize:            req.BatchSize,
+		BatchPercent:         req.BatchPercent,
+		PauseSeconds:         req.PauseSeconds,
+		HealthySeconds:       req.HealthySeconds,
+		HealthTimeoutSeconds: req.HealthTimeoutSeconds,
+		MaxFailures:          req.MaxFailures,
+		MaxFailurePercent:    req.MaxFailurePercent,
+		CreatedBy:            userID,
+	})
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		target := req.ProcessName
+		if target == "" {
+			target = req.GroupName
+		}
+		message := fmt.Sprintf("Started rollout %d of %s on %d nodes in %d batches",
+			detail.ID, target, detail.TotalTargets, detail.TotalBatches)
+		api.activityLogService.LogWithContext(c, "INFO", "start_rollout", "rollout", fmt.Sprintf("rollout-%d", detail.ID), message, nil)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"rollout": detail,
+	})
+}
+
+// ListRollouts GET /api/processes/rollouts
+func (api *RolloutsAPI) ListRollouts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	rollouts, total, err := api.service.List((page-1)*limit, limit, c.Query("status"))
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+	visible := make([]*services.RolloutDetail, 0, len(rollouts))
+	for _, rollout := range rollouts {
+		if rolloutAllowed(c, rollout) {
+			visible = append(visible, rollout)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"rollouts": visible,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// GetRollout GET /api/processes/rollouts/:id
+func (api *RolloutsAPI) GetRollout(c *gin.Context) {
+	detail, ok := api.loadRollout(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"rollout": detail,
+	})
+}
+
+// PauseRollout POST /api/processes/rollouts/:id/pause
+func (api *RolloutsAPI) PauseRollout(c *gin.Context) {
+	api.control(c, "pause_rollout", "paused", api.service.Pause)
+}
+
+// ResumeRollout POST /api/processes/rollouts/:id/resume
+func (api *RolloutsAPI) ResumeRollout(c *gin.Context) {
+	api.control(c, "resume_rollout", "resumed", api.service.Resume)
+}
+
+// CancelRollout POST /api/processes/rollouts/:id/cancel
+func (api *RolloutsAPI) CancelRollout(c *gin.Context) {
+	api.control(c, "cancel_rollout", "cancelled", api.service.Cancel)
+}
+
+// control 执行暂停/恢复/取消并记录活动日志
+func (api *RolloutsAPI) control(c *gin.Context, action, verb string, operation func(id uint) (*services.RolloutDetail, error)) {
+	if !requirePermission(c, models.PermissionProcessExecute) {
+		return
+	}
+	current, ok := api.loadRollout(c)
+	if !ok {
+		return
+	}
+
+	detail, err := operation(current.ID)
+	if err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	if api.activityLogService != nil {
+		message := fmt.Sprintf("Rollout %d %s at batch %d/%d", detail.ID, verb, detail.CurrentBatch, detail.TotalBatches)
+		api.activityLogService.LogWithContext(c, "INFO", action, "rollout", fmt.Sprintf("rollout-%d", detail.ID), message, nil)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"rollout": detail,
+	})
+}
+
+// loadRollout 读取路径中的滚动重启，当前请求无权访问其中任一节点时返回 404
+func (api *RolloutsAPI) loadRollout(c *gin.Context) (*services.RolloutDetail, bool) {
+	id, ok := parseAndValidateID(c, "id", "rollout")
+	if !ok {
+		return nil, false
+	}
+	detail, err := api.service.Get(id)
+	if err != nil {
+		handleAppError(c, err)
+		return nil, false
+	}
+	if !rolloutAllowed(c, detail) {
+		handleNotFound(c, "rollout", c.Param("id"))
+		return nil, false
+	}
+	return detail, true
+}
+
+// rolloutAllowed 限定了节点或环境的 API 令牌只能看到完全在其范围内的滚动重启
+func rolloutAllowed(c *gin.Context, detail *services.RolloutDetail) bool {
+	for _, target := range detail.Targets {
+		if !auth.NodeAllowed(c, target.Node, target.Environment) {
+			return false
+		}
+	}
+	return true
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,11 +18,12 @@ import (
 type SystemSettingsAPI struct {
 	db                 *gorm.DB
 	activityLogService *services.ActivityLogService
+	passwordPolicy     *services.PasswordPolicyService
 }
 
 // NewSystemSettingsAPI creates a new SystemSettingsAPI instance
 func NewSystemSettingsAPI(db *gorm.DB, activityLogService ...*services.ActivityLogService) *SystemSettingsAPI {
-	api := &SystemSettingsAPI{db: db}
+	api := &SystemSettingsAPI{db: db, passwordPolicy: services.NewPasswordPolicyService(db)}
 	if len(activityLogService) > 0 {
 		api.activityLogService = activityLogService[0]
 	}
@@ -58,7 +60,7 @@ func (api *SystemSettingsAPI) GetSystemSetting(c *gin.Context) {
 	}
 
 	var setting models.SystemSettings
-	result := api.db.Where("key = ?", key).First(&setting)
+	result := api.db.Where(&models.SystemSettings{Key: key}).First(&setting)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Setting not found"})
@@ -100,7 +102,7 @@ func (api *SystemSettingsAPI) UpdateSystemSetting(c *gin.Context) {
 
 	// Check if setting exists
 	var setting models.SystemSettings
-	result := api.db.Where("key = ?", key).First(&setting)
+	result := api.db.Where(&models.SystemSettings{Key: key}).First(&setting)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			// Create new setting
@@ -134,6 +136,7 @@ func (api *SystemSettingsAPI) UpdateSystemSetting(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save setting"})
 		return
 	}
+	api.invalidateCaches(key)
 
 	if api.activityLogService != nil {
 		msg := fmt.Sprintf("Updated system setting: %s", key)
@@ -172,7 +175,7 @@ func (api *SystemSettingsAPI) UpdateMultipleSettings(c *gin.Context) {
 
 	for key, value := range request.Settings {
 		var setting models.SystemSettings
-		result := tx.Where("key = ?", key).First(&setting)
+		result := tx.Where(&models.SystemSettings{Key: key}).First(&setting)
 		
 		valueStr := ""
 		switch v := value.(type) {
@@ -225,6 +228,9 @@ func (api *SystemSettingsAPI) UpdateMultipleSettings(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit settings update"})
 		return
 	}
+	for key := range request.Settings {
+		api.invalidateCaches(key)
+	}
 
 	if api.activityLogService != nil {
 		msg := fmt.Sprintf("Updated %d system settings", len(request.Settings))
@@ -242,7 +248,7 @@ func (api *SystemSettingsAPI) DeleteSystemSetting(c *gin.Context) {
 		return
 	}
 
-	result := api.db.Where("key = ?", key).Delete(&models.SystemSettings{})
+	result := api.db.Where(&models.SystemSettings{Key: key}).Delete(&models.SystemSettings{})
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete setting"})
 		return
@@ -252,6 +258,7 @@ func (api *SystemSettingsAPI) DeleteSystemSetting(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Setting not found"})
 		return
 	}
+	api.invalidateCaches(key)
 
 	if api.activityLogService != nil {
 		msg := fmt.Sprintf("Deleted system setting: %s", key)
@@ -604,6 +611,13 @@ func (api *SystemSettingsAPI) TestEmailConfiguration(c *gin.Context) {
 	})
 }
 
+// invalidateCaches drops cached values derived from the given setting key
+func (api *SystemSettingsAPI) invalidateCaches(key string) {
+	if strings.HasPrefix(key, "password.") {
+		api.passwordPolicy.InvalidateCache()
+	}
+}
+
 // ResetToDefaults resets system settings to default values
 func (api *SystemSettingsAPI) ResetToDefaults(c *gin.Context) {
 	category := c.Query("category")
@@ -639,10 +653,19 @@ func (api *SystemSettingsAPI) ResetToDefaults(c *gin.Context) {
 		{Key: "system.auto_refresh", Value: "true", Category: "system", Description: "Enable auto refresh"},
 		{Key: "system.refresh_interval", Value: "5", Category: "system", Description: "Auto refresh interval in seconds"},
 		{Key: "language.current", Value: "en", Category: "language", Description: "Current system language"},
+		{Key: "password.min_length", Value: "8", Category: "security", Description: "Minimum password length"},
+		{Key: "password.require_uppercase", Value: "false", Category: "security", Description: "Require an uppercase letter in passwords"},
+		{Key: "password.require_lowercase", Value: "false", Category: "security", Description: "Require a lowercase letter in passwords"},
+		{Key: "password.require_digit", Value: "false", Category: "security", Description: "Require a digit in passwords"},
+		{Key: "password.require_special", Value: "false", Category: "security", Description: "Require a special character in passwords"},
+		{Key: "password.history_count", Value: "0", Category: "security", Description: "Number of previous passwords that cannot be reused"},
+		{Key: "password.max_age_days", Value: "0", Category: "security", Description: "Maximum password age in days (0 disables expiry)"},
+		{Key: "password.wordlist_check", Value: "true", Category: "security", Description: "Reject passwords found in the breached password list"},
 	}
 
 	for _, setting := range defaultSettings {
 		if category == "" || setting.Category == category {
+			setting.ID = uuid.New().String()
 			if err := tx.Create(&setting).Error; err != nil {
 				tx.Rollback()
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create default settings"})
@@ -655,6 +678,7 @@ func (api *SystemSettingsAPI) ResetToDefaults(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit settings reset"})
 		return
 	}
+	api.passwordPolicy.InvalidateCache()
 
 	if api.activityLogService != nil {
 		target := "all"
@@ -14,10 +14,11 @@ import (
 type UserAPI struct {
 	db                 *gorm.DB
 	activityLogService *services.ActivityLogService
+	passwordPolicy     *services.PasswordPolicyService
 }
 
 func NewUserAPI(db *gorm.DB, activityLogService ...*services.ActivityLogService) *UserAPI {
-	api := &UserAPI{db: db}
+	api := &UserAPI{db: db, passwordPolicy: services.NewPasswordPolicyService(db)}
 	if len(activityLogService) > 0 {
 		api.activityLogService = activityLogService[0]
 	}
@@ -209,35 +210,91 @@ func (u *UserAPI) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	// 设置新密码
-	if err := targetUser.SetPassword(req.NewPassword); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+	// 按密码策略校验并保存新密码（包含历史记录检查）
+	if err := u.passwordPolicy.ChangePassword(&targetUser, req.NewPassword, false); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "密码修改成功",
+	})
+
+	if u.activityLogService != nil {
+		msg := fmt.Sprintf("User %s changed password", username)
+		u.activityLogService.LogWithContext(c, "INFO", "change_password", "user", username, msg, nil)
+	}
+}
+
+// ChangeOwnPassword 当前用户修改自己的密码，也是强制修改/密码过期时唯一可用的修改入口
+func (u *UserAPI) ChangeOwnPassword(c *gin.Context) {
+	var req struct {
+		OldPassword string `json:"old_password" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
-			"message": "设置新密码失败",
+			"message": "请求格式无效",
 		})
 		return
 	}
 
-	// 保存更新
-	if err := u.db.Save(&targetUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+	var user models.User
+	if err := u.db.Where("id = ?", c.GetString("user_id")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
 			"status":  "error",
-			"message": "更新密码失败",
+			"message": "当前用户不存在",
+		})
+		return
+	}
+
+	if !user.VerifyPassword(req.OldPassword) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "当前密码错误",
 		})
 		return
 	}
 
+	if err := u.passwordPolicy.ChangePassword(&user, req.NewPassword, false); err != nil {
+		handleAppError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "密码修改成功",
 	})
 
 	if u.activityLogService != nil {
-		msg := fmt.Sprintf("User %s changed password", username)
-		u.activityLogService.LogWithContext(c, "INFO", "change_password", "user", username, msg, nil)
+		msg := fmt.Sprintf("User %s changed password", user.Username)
+		u.activityLogService.LogWithContext(c, "INFO", "change_password", "user", user.Username, msg, nil)
 	}
 }
 
+// GetPasswordPolicy 获取当前生效的密码策略及当前用户的密码状态
+func (u *UserAPI) GetPasswordPolicy(c *gin.Context) {
+	policy := u.passwordPolicy.GetPolicy()
+	data := gin.H{"policy": policy}
+
+	var user models.User
+	if err := u.db.Where("id = ?", c.GetString("user_id")).First(&user).Error; err == nil {
+		data["must_change_password"] = user.MustChangePassword
+		data["password_expired"] = policy.IsExpired(&user)
+		data["password_changed_at"] = user.PasswordChangedAt
+		if policy.MaxAgeDays > 0 && user.PasswordChangedAt != nil {
+			data["password_expires_at"] = user.PasswordChangedAt.AddDate(0, 0, policy.MaxAgeDays)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   data,
+	})
+}
+
 func (u *UserAPI) DeleteUser(c *gin.Context) {
 	// 只有管理员可以删除用户
 	if !u.checkAdmin(c) {
@@ -6,6 +6,8 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"superview/internal/logger"
 	"superview/internal/models"
 	"superview/internal/repository"
 	"superview/internal/services"
@@ -15,6 +17,7 @@ import (
 type UserHandler struct {
 	db                 *gorm.DB
 	userService        *services.UserService
+	passwordPolicy     *services.PasswordPolicyService
 	activityLogService *services.ActivityLogService
 }
 
@@ -22,7 +25,8 @@ func NewUserHandler(db *gorm.DB, activityLogService ...*services.ActivityLogServ
 	repo := repository.NewRepository(db)
 	h := &UserHandler{
 		db:          db,
-		userService: services.NewUserService(repo),
+		userService:    services.NewUserService(repo),
+		passwordPolicy: services.NewPasswordPolicyService(db),
 	}
 	if len(activityLogService) > 0 {
 		h.activityLogService = activityLogService[0]
@@ -69,6 +73,8 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		Password string `json:"password" binding:"required,min=6"`
 		FullName string `json:"full_name"`
 		IsAdmin  bool   `json:"is_admin"`
+		// 管理员创建的初始密码可要求用户首次登录后修改
+		MustChangePassword bool `json:"must_change_password"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -79,11 +85,21 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	if problems := h.passwordPolicy.GetPolicy().Validate(req.Password, req.Username); len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Password does not meet policy",
+			"errors":  problems,
+		})
+		return
+	}
+
 	user := &models.User{
-		Username: req.Username,
-		Email:    req.Email,
-		FullName: req.FullName,
-		IsAdmin:  req.IsAdmin,
+		Username:           req.Username,
+		Email:              req.Email,
+		FullName:           req.FullName,
+		IsAdmin:            req.IsAdmin,
+		MustChangePassword: req.MustChangePassword,
 	}
 
 	if err := user.SetPassword(req.Password); err != nil {
@@ -101,6 +117,9 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		})
 		return
 	}
+	if err := h.passwordPolicy.RecordInitialPassword(user); err != nil {
+		logger.Warn("Failed to record initial password history", zap.String("username", user.Username), zap.Error(err))
+	}
 
 	// 清除密码
 	user.Password = ""
@@ -287,7 +306,8 @@ func (h *UserHandler) ResetPassword(c *gin.Context) {
 	id := c.Param("id")
 
 	var req struct {
-		NewPassword string `json:"new_password" binding:"required,min=6"`
+		NewPassword        string `json:"new_password" binding:"required,min=6"`
+		MustChangePassword *bool  `json:"must_change_password"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -307,19 +327,10 @@ func (h *UserHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	if err := user.SetPassword(req.NewPassword); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Failed to hash password",
-		})
-		return
-	}
-
-	if err := h.userService.UpdateUser(user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": err.Error(),
-		})
+	// 默认要求用户下次登录时修改管理员设置的密码
+	mustChange := req.MustChangePassword == nil || *req.MustChangePassword
+	if err := h.passwordPolicy.ChangePassword(user, req.NewPassword, mustChange); err != nil {
+		handleAppError(c, err)
 		return
 	}
 
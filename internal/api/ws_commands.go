@@ -0,0 +1,262 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"superview/internal/auth"
+	appErrors "superview/internal/errors"
+	"superview/internal/labels"
+	"superview/internal/services"
+	"superview/internal/supervisor"
+	"superview/internal/validation"
+	"superview/internal/websocket"
+
+	"gorm.io/gorm"
+)
+
+// 命令作用范围
+const (
+	commandScopeProcess = "process"
+	commandScopeNode    = "node"
+	commandScopeGroup   = "group"
+)
+
+// wsCommandSpec 命令对应的 REST 路由与操作，权限由路由推导，与 REST 接口保持一致
+type wsCommandSpec struct {
+	route     string
+	scope     string
+	operation string
+}
+
+var wsCommandSpecs = map[string]wsCommandSpec{
+	"start_process":   {"/api/nodes/:node_name/processes/:process_name/start", commandScopeProcess, services.OrchestrateStart},
+	"stop_process":    {"/api/nodes/:node_name/processes/:process_name/stop", commandScopeProcess, services.OrchestrateStop},
+	"restart_process": {"/api/nodes/:node_name/processes/:process_name/restart", commandScopeProcess, services.OrchestrateRestart},
+	"signal_process":  {"/api/nodes/:node_name/processes/:process_name/signal", commandScopeProcess, "signal"},
+	"start_all":       {"/api/nodes/:node_name/processes/start-all", commandScopeNode, services.OrchestrateStart},
+	"stop_all":        {"/api/nodes/:node_name/processes/stop-all", commandScopeNode, services.OrchestrateStop},
+	"restart_all":     {"/api/nodes/:node_name/processes/restart-all", commandScopeNode, services.OrchestrateRestart},
+	"start_group":     {"/api/groups/:group_name/start", commandScopeGroup, services.OrchestrateStart},
+	"stop_group":      {"/api/groups/:group_name/stop", commandScopeGroup, services.OrchestrateStop},
+	"restart_group":   {"/api/groups/:group_name/restart", commandScopeGroup, services.OrchestrateRestart},
+}
+
+// 活动日志中的动词，与 REST 接口一致
+var commandVerbs = map[string]string{
+	services.OrchestrateStart:   "Started",
+	services.OrchestrateStop:    "Stopped",
+	services.OrchestrateRestart: "Restarted",
+}
+
+// WSCommandHandler 执行 WebSocket 进程控制命令
+type WSCommandHandler struct {
+	db                 *gorm.DB
+	service            *supervisor.SupervisorService
+	orchestrator       *services.ProcessOrchestrator
+	activityLogService *services.ActivityLogService
+	passwordPolicy     *services.PasswordPolicyService
+}
+
+// NewWSCommandHandler 创建命令处理器，orchestrator 为 nil 时整节点和分组操作不按依赖顺序执行
+func NewWSCommandHandler(db *gorm.DB, service *supervisor.SupervisorService, orchestrator *services.ProcessOrchestrator, activityLogService *services.ActivityLogService) *WSCommandHandler {
+	return &WSCommandHandler{
+		db:                 db,
+		service:            service,
+		orchestrator:       orchestrator,
+		activityLogService: activityLogService,
+		passwordPolicy:     services.NewPasswordPolicyService(db),
+	}
+}
+
+// Authorize 校验命令参数和用户权限
+func (h *WSCommandHandler) Authorize(ctx *websocket.CommandContext, cmd *websocket.Command) error {
+	spec, ok := wsCommandSpecs[cmd.Action]
+	if !ok {
+		return appErrors.NewValidationError("action", fmt.Sprintf("unknown action: %s", cmd.Action))
+	}
+	if err := validateCommand(spec, cmd); err != nil {
+		return err
+	}
+
+	user, err := loadActiveUser(h.db, h.passwordPolicy, ctx.UserID)
+	if err != nil {
+		return err
+	}
+
+	permission := auth.RequiredPermissionForRequest(http.MethodPost, spec.route)
+	if !user.IsSuperAdmin() && !user.HasPermission(permission) {
+		return appErrors.NewForbiddenError(fmt.Sprintf("permission %s is required", permission))
+	}
+	return nil
+}
+
+// validateCommand 按 REST 接口的规则校验参数，signal 会被规范化
+func validateCommand(spec wsCommandSpec, cmd *websocket.Command) error {
+	validator := validation.NewValidator()
+	switch spec.scope {
+	case commandScopeProcess:
+		validator.ValidateNodeName("node_name", cmd.NodeName)
+		validator.ValidateProcessName("process_name", cmd.ProcessName)
+		validator.ValidateNoSQLInjection("node_name", cmd.NodeName)
+		validator.ValidateNoSQLInjection("process_name", cmd.ProcessName)
+	case commandScopeNode:
+		validator.ValidateNodeName("node_name", cmd.NodeName)
+		validator.ValidateNoSQLInjection("node_name", cmd.NodeName)
+	case commandScopeGroup:
+		validator.ValidateRequired("group_name", cmd.GroupName)
+		validator.ValidateNoSQLInjection("group_name", cmd.GroupName)
+		if _, err := labels.Parse(cmd.Selector); err != nil {
+			return appErrors.NewValidationError("selector", err.Error())
+		}
+	}
+	if validator.HasErrors() {
+		first := validator.Errors()[0]
+		return appErrors.NewValidationError(first.Field, first.Message)
+	}
+
+	if spec.operation == "signal" {
+		signal, err := validateProcessSignal(cmd.Signal)
+		if err != nil {
+			return err
+		}
+		cmd.Signal = signal
+	}
+	return nil
+}
+
+// Execute 执行已通过校验的命令
+func (h *WSCommandHandler) Execute(ctx *websocket.CommandContext, cmd *websocket.Command, progress func(data interface{})) (interface{}, error) {
+	spec := wsCommandSpecs[cmd.Action]
+	switch spec.scope {
+	case commandScopeProcess:
+		return h.executeProcess(ctx, cmd, spec, progress)
+	case commandScopeNode:
+		return h.executeNode(ctx, cmd, spec, progress)
+	default:
+		return h.executeGroup(ctx, cmd, spec, progress)
+	}
+}
+
+func (h *WSCommandHandler) executeProcess(ctx *websocket.CommandContext, cmd *websocket.Command, spec wsCommandSpec, progress func(data interface{})) (interface{}, error) {
+	nodeName, processName := cmd.NodeName, cmd.ProcessName
+	var (
+		err error
+		msg string
+	)
+	switch spec.operation {
+	case services.OrchestrateStart:
+		err = h.service.StartProcess(nodeName, processName)
+		msg = fmt.Sprintf("Started process %s on node %s", processName, nodeName)
+	case services.OrchestrateStop:
+		err = h.service.StopProcess(nodeName, processName)
+		msg = fmt.Sprintf("Stopped process %s on node %s", processName, nodeName)
+	case services.OrchestrateRestart:
+		if err = h.service.StopProcess(nodeName, processName); err == nil {
+			progress(map[string]interface{}{"node": nodeName, "process": processName, "status": "stopped"})
+			err = h.service.StartProcess(nodeName, processName)
+		}
+		msg = fmt.Sprintf("Restarted process %s on node %s", processName, nodeName)
+	default:
+		err = h.service.SignalProcess(nodeName, processName, cmd.Signal)
+		msg = fmt.Sprintf("Sent signal %s to process %s on node %s", cmd.Signal, processName, nodeName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	h.log(ctx, cmd.Action, "process", processName, msg+" via WebSocket")
+	return map[string]interface{}{"node": nodeName, "process": processName}, nil
+}
+
+func (h *WSCommandHandler) executeNode(ctx *websocket.CommandContext, cmd *websocket.Command, spec wsCommandSpec, progress func(data interface{})) (interface{}, error) {
+	nodeName := cmd.NodeName
+	action := spec.operation + "_process"
+	verb := commandVerbs[spec.operation]
+
+	if h.orchestrator != nil {
+		units, err := h.orchestrator.NodeUnits(nodeName)
+		if err != nil {
+			return nil, err
+		}
+		result, err := h.runOrchestration(spec.operation, units, progress)
+		if err != nil {
+			return nil, err
+		}
+		h.log(ctx, action, "node", nodeName, fmt.Sprintf("%s all processes on node %s in dependency order (%s) via WebSocket", verb, nodeName, orchestrationSummary(result)))
+		return result, nil
+	}
+
+	var err error
+	switch spec.operation {
+	case services.OrchestrateStart:
+		err = h.service.StartAllProcesses(nodeName)
+	case services.OrchestrateStop:
+		err = h.service.StopAllProcesses(nodeName)
+	default:
+		err = h.service.RestartAllProcesses(nodeName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	h.log(ctx, action, "node", nodeName, fmt.Sprintf("%s all processes on node %s via WebSocket", verb, nodeName))
+	return map[string]interface{}{"node": nodeName}, nil
+}
+
+func (h *WSCommandHandler) executeGroup(ctx *websocket.CommandContext, cmd *websocket.Command, spec wsCommandSpec, progress func(data interface{})) (interface{}, error) {
+	groupName := cmd.GroupName
+	selector, _ := labels.Parse(cmd.Selector)
+	verb := commandVerbs[spec.operation]
+
+	if h.orchestrator != nil {
+		var nodeNames []string
+		for _, node := range h.service.GetNodesBySelector(selector) {
+			if cmd.Environment == "" || node.Environment == cmd.Environment {
+				nodeNames = append(nodeNames, node.Name)
+			}
+		}
+		units := h.orchestrator.GroupUnits(nodeNames, groupName)
+		if len(units) == 0 {
+			return nil, appErrors.NewNotFoundError("group", groupName)
+		}
+		result, err := h.runOrchestration(spec.operation, units, progress)
+		if err != nil {
+			return nil, err
+		}
+		h.log(ctx, cmd.Action, "group", groupName, fmt.Sprintf("%s all processes in group %s in dependency order (%s) via WebSocket", verb, groupName, orchestrationSummary(result)))
+		return result, nil
+	}
+
+	var err error
+	switch spec.operation {
+	case services.OrchestrateStart:
+		err = h.service.StartGroupProcesses(groupName, cmd.Environment, selector)
+	case services.OrchestrateStop:
+		err = h.service.StopGroupProcesses(groupName, cmd.Environment, selector)
+	default:
+		err = h.service.RestartGroupProcesses(groupName, cmd.Environment, selector)
+	}
+	if err != nil {
+		return nil, err
+	}
+	h.log(ctx, cmd.Action, "group", groupName, fmt.Sprintf("%s all processes in group %s via WebSocket", verb, groupName))
+	return map[string]interface{}{"group": groupName}, nil
+}
+
+// runOrchestration 按依赖顺序执行，每完成一步推送一次进度
+func (h *WSCommandHandler) runOrchestration(operation string, units []services.ProcessUnit, progress func(data interface{})) (*services.OrchestrationResult, error) {
+	return h.orchestrator.Run(operation, units, func(step services.OrchestrationStep) {
+		progress(step)
+	})
+}
+
+func (h *WSCommandHandler) log(ctx *websocket.CommandContext, action, resource, target, msg string) {
+	if h.activityLogService != nil {
+		h.activityLogService.LogForUser(ctx.UserID, ctx.ClientIP, ctx.UserAgent, "INFO", action, resource, target, msg)
+	}
+}
+
+// commandHub 支持 WebSocket 命令的 hub
+type commandHub interface {
+	SetCommandHandler(handler websocket.CommandHandler)
+}
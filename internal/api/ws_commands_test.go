@@ -0,0 +1,72 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"superview/internal/auth"
+	appErrors "superview/internal/errors"
+	"superview/internal/models"
+	"superview/internal/websocket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWSCommandAuthorize(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{}, &models.NodeAccess{}))
+
+	execute := models.Permission{ID: "p1", Name: models.PermissionProcessExecute}
+	operator := models.Role{ID: "r1", Name: "operator", Permissions: []models.Permission{execute}}
+	require.NoError(t, db.Create(&operator).Error)
+	users := []*models.User{
+		{ID: "admin", Username: "admin", Password: "x", Email: "a@example.com", IsAdmin: true},
+		{ID: "operator", Username: "operator", Password: "x", Email: "o@example.com", Roles: []models.Role{operator}},
+		{ID: "viewer", Username: "viewer", Password: "x", Email: "v@example.com"},
+	}
+	for _, user := range users {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	handler := NewWSCommandHandler(db, nil, nil, nil)
+	authorize := func(userID string, cmd websocket.Command) error {
+		return handler.Authorize(&websocket.CommandContext{UserID: userID}, &cmd)
+	}
+	restart := websocket.Command{ID: "1", Action: "restart_process", NodeName: "web1", ProcessName: "app"}
+
+	assert.NoError(t, authorize("admin", restart))
+	assert.NoError(t, authorize("operator", restart))
+	err := authorize("viewer", restart)
+	require.Error(t, err)
+	assert.Equal(t, "FORBIDDEN", err.(appErrors.AppError).Code())
+	assert.Error(t, authorize("missing", restart))
+
+	assert.NoError(t, authorize("operator", websocket.Command{ID: "2", Action: "stop_group", GroupName: "workers", Selector: "env=prod"}))
+	assert.Error(t, authorize("admin", websocket.Command{ID: "3", Action: "reboot", NodeName: "web1"}), "unknown action")
+	assert.Error(t, authorize("admin", websocket.Command{ID: "4", Action: "start_process", NodeName: "web1"}), "missing process")
+	assert.Error(t, authorize("admin", websocket.Command{ID: "5", Action: "start_group", GroupName: "g", Selector: "env in ("}))
+	assert.Error(t, authorize("admin", websocket.Command{ID: "6", Action: "signal_process", NodeName: "web1", ProcessName: "app", Signal: "HUP; rm"}))
+
+	// 管理员重置密码后必须先修改密码，与 REST 接口一致
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", "operator").Update("must_change_password", true).Error)
+	err = authorize("operator", restart)
+	require.Error(t, err)
+	assert.Equal(t, auth.PasswordChangeRequiredCode, err.(*auth.PasswordChangeRequiredError).Code())
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", "operator").Update("must_change_password", false).Error)
+
+	// 密码过期
+	require.NoError(t, db.AutoMigrate(&models.SystemSettings{}))
+	require.NoError(t, db.Create(&models.SystemSettings{ID: "s1", Key: "password.max_age_days", Value: "30"}).Error)
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", "operator").
+		Update("password_changed_at", time.Now().AddDate(0, 0, -31)).Error)
+	handler = NewWSCommandHandler(db, nil, nil, nil)
+	err = authorize("operator", restart)
+	require.Error(t, err)
+	assert.Equal(t, auth.PasswordChangeRequiredCode, err.(*auth.PasswordChangeRequiredError).Code())
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", "operator").Update("password_changed_at", time.Now()).Error)
+
+	signal := websocket.Command{ID: "7", Action: "signal_process", NodeName: "web1", ProcessName: "app", Signal: "usr1"}
+	require.NoError(t, handler.Authorize(&websocket.CommandContext{UserID: "operator"}, &signal))
+	assert.Equal(t, "USR1", signal.Signal)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"fmt"
+
+	"superview/internal/auth"
+	appErrors "superview/internal/errors"
+	"superview/internal/models"
+	"superview/internal/services"
+	"superview/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 各主题需要的权限，与对应的 REST 查询接口一致
+var topicPermissions = map[string]string{
+	websocket.TopicAlerts:    "node:read",
+	websocket.TopicDiscovery: "node:read",
+	websocket.TopicActivity:  "log:read",
+	websocket.TopicNode:      "node:read",
+	websocket.TopicLogs:      "process:read",
+}
+
+// WSTopicAuthorizer 校验 WebSocket/SSE 主题订阅权限
+type WSTopicAuthorizer struct {
+	db             *gorm.DB
+	passwordPolicy *services.PasswordPolicyService
+}
+
+// NewWSTopicAuthorizer 创建主题权限校验器
+func NewWSTopicAuthorizer(db *gorm.DB) *WSTopicAuthorizer {
+	return &WSTopicAuthorizer{db: db, passwordPolicy: services.NewPasswordPolicyService(db)}
+}
+
+// AuthorizeTopic 校验用户能否订阅主题。通过 API 令牌连接时还需令牌本身具有该权限，
+// node 和 logs 主题的节点需在令牌的节点/环境范围内
+func (a *WSTopicAuthorizer) AuthorizeTopic(userID string, scope websocket.ClientScope, topic string) error {
+	permission, ok := topicPermissions[websocket.TopicName(topic)]
+	if !ok {
+		return appErrors.NewValidationError("topic", fmt.Sprintf("unknown topic: %s", topic))
+	}
+
+	user, err := loadActiveUser(a.db, a.passwordPolicy, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsSuperAdmin() && !user.HasPermission(permission) {
+		return appErrors.NewForbiddenError(fmt.Sprintf("permission %s is required", permission))
+	}
+	if scope == nil {
+		return nil
+	}
+
+	if !scope.HasPermission(permission) {
+		return appErrors.NewForbiddenError(fmt.Sprintf("API token lacks permission %s", permission))
+	}
+	if nodeName := websocket.TopicNodeName(topic); nodeName != "" && scope.HasNodeRestrictions() {
+		var node models.Node
+		if err := a.db.Select("environment").Where("name = ?", nodeName).Limit(1).Find(&node).Error; err != nil {
+			return appErrors.NewDatabaseError("get node", err)
+		}
+		if !scope.AllowsNode(nodeName, node.Environment) {
+			return appErrors.NewForbiddenError(fmt.Sprintf("API token is not allowed to access node %s", nodeName))
+		}
+	}
+	return nil
+}
+
+// loadActiveUser 加载用户及其角色权限，用户不存在、已禁用或必须先修改密码时返回错误
+// 与 REST 接口一致：管理员重置后或密码过期的用户不能通过 WebSocket 订阅主题或操作进程
+func loadActiveUser(db *gorm.DB, passwordPolicy *services.PasswordPolicyService, userID string) (*models.User, error) {
+	var user models.User
+	if err := db.Preload("Roles.Permissions").Where("id = ?", userID).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.NewUnauthorizedError("User not found")
+		}
+		return nil, appErrors.NewDatabaseError("get user", err)
+	}
+	if !user.IsActive {
+		return nil, appErrors.NewForbiddenError("User account is disabled")
+	}
+	if reason := passwordPolicy.GetPolicy().ChangeRequiredReason(&user); reason != "" {
+		return nil, &auth.PasswordChangeRequiredError{Reason: reason}
+	}
+	return &user, nil
+}
+
+// topicHub 支持主题订阅权限校验的 hub
+type topicHub interface {
+	SetTopicAuthorizer(authorizer websocket.TopicAuthorizer)
+}
+
+// eventStreamScope 通过 API 令牌连接事件流时，把令牌交给 hub 限制可见节点和可订阅主题
+func eventStreamScope(c *gin.Context) {
+	if token := auth.APITokenFromContext(c); token != nil {
+		c.Set(websocket.ClientScopeContextKey, websocket.ClientScope(token))
+	}
+	c.Next()
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"testing"
+
+	appErrors "superview/internal/errors"
+	"superview/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWSTopicAuthorize(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{}, &models.NodeAccess{}, &models.Node{}))
+
+	nodeRead := models.Permission{ID: "p1", Name: "node:read"}
+	viewer := models.Role{ID: "r1", Name: "viewer", Permissions: []models.Permission{nodeRead}}
+	require.NoError(t, db.Create(&viewer).Error)
+	users := []*models.User{
+		{ID: "admin", Username: "admin", Password: "x", Email: "a@example.com", IsAdmin: true},
+		{ID: "viewer", Username: "viewer", Password: "x", Email: "v@example.com", Roles: []models.Role{viewer}},
+	}
+	for _, user := range users {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	authorizer := NewWSTopicAuthorizer(db)
+	assert.NoError(t, authorizer.AuthorizeTopic("viewer", nil, "alerts:severity=critical"))
+	assert.NoError(t, authorizer.AuthorizeTopic("viewer", nil, "node:web-1"))
+	err := authorizer.AuthorizeTopic("viewer", nil, "activity")
+	require.Error(t, err)
+	assert.Equal(t, "FORBIDDEN", err.(appErrors.AppError).Code())
+	assert.Error(t, authorizer.AuthorizeTopic("viewer", nil, "logs:web-1:app"))
+	assert.NoError(t, authorizer.AuthorizeTopic("admin", nil, "activity"))
+	assert.Error(t, authorizer.AuthorizeTopic("missing", nil, "alerts"))
+
+	// API 令牌只能订阅自身权限和节点范围内的主题
+	nodeProcessRead := models.Permission{ID: "p2", Name: "process:read"}
+	require.NoError(t, db.Model(&viewer).Association("Permissions").Append(&nodeProcessRead))
+	token := &models.APIToken{UserID: "viewer"}
+	token.SetPermissions([]string{"node:read"})
+	token.SetNodes([]string{"web-1"})
+	assert.NoError(t, authorizer.AuthorizeTopic("viewer", token, "alerts"))
+	assert.NoError(t, authorizer.AuthorizeTopic("viewer", token, "node:web-1"))
+	assert.Error(t, authorizer.AuthorizeTopic("viewer", token, "node:web-2"))
+	assert.NoError(t, authorizer.AuthorizeTopic("viewer", nil, "logs:web-1:app"))
+	assert.Error(t, authorizer.AuthorizeTopic("viewer", token, "logs:web-1:app"))
+
+	require.NoError(t, db.Create(&models.Node{Name: "db-1", Host: "10.0.0.2", Port: 9001, Environment: "prod"}).Error)
+	envToken := &models.APIToken{UserID: "admin"}
+	envToken.SetEnvironments([]string{"prod"})
+	assert.NoError(t, authorizer.AuthorizeTopic("admin", envToken, "logs:db-1:app"))
+	assert.Error(t, authorizer.AuthorizeTopic("admin", envToken, "node:web-1"))
+}
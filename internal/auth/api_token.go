@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"superview/internal/logger"
+	"superview/internal/models"
+)
+
+// APITokenContextKey 通过 API 令牌认证时，令牌对象在 gin 上下文中的键
+const APITokenContextKey = "api_token"
+
+// processExecuteActions 视为进程执行操作的路由末段
+var processExecuteActions = map[string]bool{
+	"start":       true,
+	"stop":        true,
+	"restart":     true,
+	"start-all":   true,
+	"stop-all":    true,
+	"restart-all": true,
+	"signal":      true,
+	"rollouts":    true,
+	"pause":       true,
+	"resume":      true,
+	"cancel":      true,
+}
+
+// APITokenFromContext 获取当前请求使用的 API 令牌（JWT/Cookie 认证时返回 nil）
+func APITokenFromContext(c *gin.Context) *models.APIToken {
+	value, exists := c.Get(APITokenContextKey)
+	if !exists {
+		return nil
+	}
+	token, _ := value.(*models.APIToken)
+	return token
+}
+
+// NodeAllowed 检查当前请求是否允许操作指定节点
+// 非 API 令牌认证的请求不受节点/环境限制
+func NodeAllowed(c *gin.Context, nodeName, environment string) bool {
+	token := APITokenFromContext(c)
+	if token == nil {
+		return true
+	}
+	return token.AllowsNode(nodeName, environment)
+}
+
+// RequiredPermissionForRequest 根据请求方法和路由模板推断 API 令牌所需的权限
+// 返回空字符串表示该路由不需要额外权限（如健康检查）
+func RequiredPermissionForRequest(method, fullPath string) string {
+	path := strings.TrimPrefix(fullPath, "/api/")
+	segments := strings.Split(path, "/")
+	if len(segments) == 0 || segments[0] == "" {
+		return ""
+	}
+	last := segments[len(segments)-1]
+
+	resource := ""
+	switch segments[0] {
+	case "health":
+		return ""
+	case "v1":
+		// PromQL 查询接口只读，Grafana 以 POST 提交查询
+		return models.PermissionNodeRead
+	case "nodes":
+		resource = "node"
+		if strings.Contains(path, "/processes") {
+			resource = "process"
+		}
+	case "processes", "groups", "process-enhanced":
+		resource = "process"
+	case "environments", "discovery", "events":
+		resource = "node"
+	case "users", "roles", "role-users", "permissions", "profile":
+		resource = "user"
+	case "activity-logs", "logs":
+		resource = "log"
+	case "configuration":
+		resource = "config"
+	default:
+		resource = "system"
+	}
+
+	if resource == "system" {
+		if method == http.MethodGet || method == http.MethodHead {
+			return models.PermissionSystemConfig
+		}
+		return models.PermissionSystemManage
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return resource + ":read"
+	case http.MethodDelete:
+		return resource + ":delete"
+	default:
+		if resource == "process" && processExecuteActions[last] {
+			return models.PermissionProcessExecute
+		}
+		return resource + ":write"
+	}
+}
+
+// authenticateAPIToken 处理 sv_ 前缀的个人 API 令牌认证，失败时终止请求
+func (s *AuthService) authenticateAPIToken(c *gin.Context, tokenString string) bool {
+	token, user, err := s.apiTokenService.Authenticate(tokenString, c.ClientIP())
+	if err != nil {
+		logger.Warn("API token authentication failed",
+			zap.String("remote_addr", c.ClientIP()),
+			zap.Error(err))
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Invalid or expired token",
+		})
+		c.Abort()
+		return false
+	}
+
+	if message, ok := apiTokenScopeAllows(c, s, token); !ok {
+		logger.Warn("API token scope denied",
+			zap.Uint("token_id", token.ID),
+			zap.String("user_id", user.ID),
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.FullPath()),
+			zap.String("reason", message))
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": message,
+		})
+		c.Abort()
+		return false
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("user", user)
+	c.Set(APITokenContextKey, token)
+
+	// 令牌所属用户必须修改密码或密码已过期时，与登录会话一样只放行修改密码相关接口
+	return s.enforcePasswordChange(c, user)
+}
+
+// apiTokenScopeAllows 校验令牌的权限子集与节点/环境限制
+func apiTokenScopeAllows(c *gin.Context, s *AuthService, token *models.APIToken) (string, bool) {
+	fullPath := c.FullPath()
+	if fullPath == "" {
+		fullPath = c.Request.URL.Path
+	}
+
+	// 令牌不能用于管理令牌本身，避免自动化凭证自我扩权
+	if strings.HasPrefix(fullPath, "/api/profile/tokens") {
+		return "API tokens cannot manage API tokens", false
+	}
+
+	if permission := RequiredPermissionForRequest(c.Request.Method, fullPath); permission != "" {
+		if !token.HasPermission(permission) {
+			return "API token lacks permission " + permission, false
+		}
+	}
+
+	if !token.HasNodeRestrictions() {
+		return "", true
+	}
+
+	if environment := c.Query("environment"); environment != "" {
+		if !token.AllowsNode("", environment) {
+			return "API token is not allowed to access environment " + environment, false
+		}
+	} else if strings.HasPrefix(fullPath, "/api/groups/:group_name/") && c.Request.Method != http.MethodGet {
+		// 分组操作会跨所有节点执行，受限令牌必须显式指定允许的环境
+		return "API token restricted to nodes/environments must specify an environment", false
+	}
+
+	if nodeName := c.Param("node_name"); nodeName != "" {
+		environment := ""
+		if s.db != nil {
+			var node models.Node
+			if err := s.db.Select("environment").Where("name = ?", nodeName).First(&node).Error; err == nil {
+				environment = node.Environment
+			}
+		}
+		if !token.AllowsNode(nodeName, environment) {
+			return "API token is not allowed to access node " + nodeName, false
+		}
+	}
+
+	return "", true
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"superview/internal/models"
+	"superview/internal/services"
+)
+
+func setupAPITokenTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{}, &models.Node{}, &models.APIToken{}))
+	return db
+}
+
+func newAPITokenTestRouter(db *gorm.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	authService := NewAuthService(db)
+	router := gin.New()
+	group := router.Group("/api", authService.AuthMiddleware())
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"user_id": c.GetString("user_id")}) }
+	group.GET("/nodes", ok)
+	group.POST("/processes/:process_name/restart", ok)
+	group.POST("/nodes/:node_name/processes/:process_name/restart", ok)
+	group.DELETE("/users/:id", ok)
+	group.GET("/profile/tokens", ok)
+	return router
+}
+
+func doTokenRequest(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequiredPermissionForRequest(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{http.MethodGet, "/api/nodes", models.PermissionNodeRead},
+		{http.MethodPut, "/api/nodes/:node_name", models.PermissionNodeWrite},
+		{http.MethodGet, "/api/nodes/:node_name/processes", models.PermissionProcessRead},
+		{http.MethodPost, "/api/nodes/:node_name/processes/:process_name/restart", models.PermissionProcessExecute},
+		{http.MethodPost, "/api/processes/:process_name/restart", models.PermissionProcessExecute},
+		{http.MethodPost, "/api/groups/:group_name/stop", models.PermissionProcessExecute},
+		{http.MethodPost, "/api/processes/rollouts", models.PermissionProcessExecute},
+		{http.MethodPost, "/api/v1/query_range", models.PermissionNodeRead},
+		{http.MethodPost, "/api/processes/rollouts/:id/cancel", models.PermissionProcessExecute},
+		{http.MethodGet, "/api/processes/rollouts/:id", models.PermissionProcessRead},
+		{http.MethodPost, "/api/process-enhanced/groups/:id/start", models.PermissionProcessExecute},
+		{http.MethodDelete, "/api/users/:id", models.PermissionUserDelete},
+		{http.MethodGet, "/api/activity-logs", models.PermissionLogRead},
+		{http.MethodPut, "/api/system-settings/:key", models.PermissionSystemManage},
+		{http.MethodGet, "/api/health", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, RequiredPermissionForRequest(tt.method, tt.path), "%s %s", tt.method, tt.path)
+	}
+}
+
+func TestAuthMiddleware_APIToken(t *testing.T) {
+	db := setupAPITokenTestDB(t)
+	router := newAPITokenTestRouter(db)
+	tokenService := services.NewAPITokenService(db)
+
+	user := models.User{Username: "deployer", Password: "x", IsActive: true, IsAdmin: true}
+	require.NoError(t, db.Create(&user).Error)
+	require.NoError(t, db.Create(&models.Node{Name: "web-1", Host: "10.0.0.1", Port: 9001, Environment: "prod"}).Error)
+	require.NoError(t, db.Create(&models.Node{Name: "db-1", Host: "10.0.0.2", Port: 9001, Environment: "staging"}).Error)
+
+	plain, token, err := tokenService.CreateToken(user.ID, &services.CreateAPITokenRequest{
+		Name:         "ci",
+		Permissions:  []string{models.PermissionProcessExecute},
+		Environments: []string{"prod"},
+	})
+	require.NoError(t, err)
+	assert.True(t, services.IsAPIToken(plain))
+	assert.NotEqual(t, plain, token.TokenHash, "token must be hashed at rest")
+
+	t.Run("allowed permission", func(t *testing.T) {
+		w := doTokenRequest(router, http.MethodPost, "/api/processes/app/restart", plain)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), user.ID)
+	})
+
+	t.Run("last used is recorded", func(t *testing.T) {
+		var stored models.APIToken
+		require.NoError(t, db.First(&stored, token.ID).Error)
+		assert.NotNil(t, stored.LastUsedAt)
+	})
+
+	t.Run("missing permission", func(t *testing.T) {
+		w := doTokenRequest(router, http.MethodDelete, "/api/users/1", plain)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("node in allowed environment", func(t *testing.T) {
+		w := doTokenRequest(router, http.MethodPost, "/api/nodes/web-1/processes/app/restart", plain)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("node outside allowed environment", func(t *testing.T) {
+		w := doTokenRequest(router, http.MethodPost, "/api/nodes/db-1/processes/app/restart", plain)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("tokens cannot manage tokens", func(t *testing.T) {
+		w := doTokenRequest(router, http.MethodGet, "/api/profile/tokens", plain)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		w := doTokenRequest(router, http.MethodGet, "/api/nodes", "sv_deadbeef")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		_, err := tokenService.RevokeToken(user.ID, token.ID)
+		require.NoError(t, err)
+		w := doTokenRequest(router, http.MethodPost, "/api/processes/app/restart", plain)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAPITokenService_PermissionSubset(t *testing.T) {
+	db := setupAPITokenTestDB(t)
+	tokenService := services.NewAPITokenService(db)
+
+	user := models.User{Username: "viewer", Password: "x", IsActive: true}
+	require.NoError(t, db.Create(&user).Error)
+
+	_, _, err := tokenService.CreateToken(user.ID, &services.CreateAPITokenRequest{
+		Name:        "too-broad",
+		Permissions: []string{models.PermissionProcessExecute},
+	})
+	assert.Error(t, err, "non-admin user without the permission must not be able to grant it")
+
+	_, _, err = tokenService.CreateToken(user.ID, &services.CreateAPITokenRequest{
+		Name:        "bogus",
+		Permissions: []string{"bogus:perm"},
+	})
+	assert.Error(t, err)
+}
@@ -15,10 +15,16 @@ import (
 type AuthService struct {
 	db                 *gorm.DB
 	activityLogService *services.ActivityLogService
+	apiTokenService    *services.APITokenService
+	passwordPolicy     *services.PasswordPolicyService
 }
 
 func NewAuthService(db *gorm.DB, activityLogService ...*services.ActivityLogService) *AuthService {
-	s := &AuthService{db: db}
+	s := &AuthService{
+		db:              db,
+		apiTokenService: services.NewAPITokenService(db),
+		passwordPolicy:  services.NewPasswordPolicyService(db),
+	}
 	if len(activityLogService) > 0 {
 		s.activityLogService = activityLogService[0]
 	}
@@ -119,7 +125,9 @@ func (s *AuthService) Login(c *gin.Context) {
 		"status":  "success",
 		"message": "Login successful",
 		"data": gin.H{
-			"token": token,
+			"token":                token,
+			"must_change_password": user.MustChangePassword,
+			"password_expired":     s.passwordPolicy.GetPolicy().IsExpired(&user),
 			"user": gin.H{
 				"id":         user.ID,
 				"username":   user.Username,
@@ -279,6 +287,14 @@ func (s *AuthService) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// 个人 API 令牌（sv_ 前缀）走独立的认证和权限范围校验
+		if services.IsAPIToken(tokenString) {
+			if s.authenticateAPIToken(c, tokenString) {
+				c.Next()
+			}
+			return
+		}
+
 		// 验证令牌
 		claims, err := ParseToken(tokenString)
 		if err != nil {
@@ -297,6 +313,11 @@ func (s *AuthService) AuthMiddleware() gin.HandlerFunc {
 		var user models.User
 		if err := s.db.Where("id = ?", claims.UserID).First(&user).Error; err == nil {
 			c.Set("user", &user)
+
+			// 必须修改密码或密码已过期时，只放行修改密码相关接口
+			if !s.enforcePasswordChange(c, &user) {
+				return
+			}
 		}
 
 		c.Next()
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"superview/internal/models"
+)
+
+// PasswordChangeRequiredCode 需要修改密码时返回的错误码，前端据此跳转到修改密码页面
+const PasswordChangeRequiredCode = "PASSWORD_CHANGE_REQUIRED"
+
+// PasswordChangeRequiredError WebSocket 命令和主题订阅拒绝需要修改密码的用户时返回，错误码与 REST 接口一致
+type PasswordChangeRequiredError struct {
+	Reason string
+}
+
+func (e *PasswordChangeRequiredError) Error() string { return e.Reason }
+
+// Code 错误码
+func (e *PasswordChangeRequiredError) Code() string { return PasswordChangeRequiredCode }
+
+// Message 错误信息
+func (e *PasswordChangeRequiredError) Message() string { return e.Reason }
+
+// passwordChangeAllowedRoutes 必须修改密码时仍可访问的路由
+var passwordChangeAllowedRoutes = map[string]bool{
+	http.MethodGet + " /api/profile":                 true,
+	http.MethodPut + " /api/profile/password":        true,
+	http.MethodGet + " /api/profile/password-policy": true,
+}
+
+// enforcePasswordChange 管理员重置后或密码过期的用户只能访问修改密码相关接口
+func (s *AuthService) enforcePasswordChange(c *gin.Context, user *models.User) bool {
+	if s.passwordPolicy == nil {
+		return true
+	}
+
+	reason := s.passwordPolicy.GetPolicy().ChangeRequiredReason(user)
+	if reason == "" {
+		return true
+	}
+
+	fullPath := c.FullPath()
+	if fullPath == "" {
+		fullPath = c.Request.URL.Path
+	}
+	if strings.HasPrefix(fullPath, "/api/auth/") || passwordChangeAllowedRoutes[c.Request.Method+" "+fullPath] {
+		return true
+	}
+	// 页面请求交给前端处理跳转
+	if !strings.HasPrefix(fullPath, "/api/") {
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"status":  "error",
+		"code":    PasswordChangeRequiredCode,
+		"message": reason,
+	})
+	c.Abort()
+	return false
+}
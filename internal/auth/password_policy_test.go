@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"superview/internal/models"
+	"superview/internal/services"
+)
+
+func TestAuthMiddleware_MustChangePassword(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret-for-password-policy-middleware")
+	db := setupAPITokenTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.SystemSettings{}))
+
+	gin.SetMode(gin.TestMode)
+	authService := NewAuthService(db)
+	router := gin.New()
+	group := router.Group("/api", authService.AuthMiddleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	group.GET("/nodes", ok)
+	group.GET("/profile", ok)
+	group.PUT("/profile/password", ok)
+
+	user := models.User{Username: "reset-me", IsActive: true, MustChangePassword: true}
+	require.NoError(t, user.SetPassword("temporary-Pass-1"))
+	require.NoError(t, db.Create(&user).Error)
+
+	token, err := GenerateToken(user.ID)
+	require.NoError(t, err)
+
+	w := doTokenRequest(router, http.MethodGet, "/api/nodes", token)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), PasswordChangeRequiredCode)
+
+	assert.Equal(t, http.StatusOK, doTokenRequest(router, http.MethodGet, "/api/profile", token).Code)
+	assert.Equal(t, http.StatusOK, doTokenRequest(router, http.MethodPut, "/api/profile/password", token).Code)
+
+	require.NoError(t, db.Model(&user).Update("must_change_password", false).Error)
+	assert.Equal(t, http.StatusOK, doTokenRequest(router, http.MethodGet, "/api/nodes", token).Code)
+}
+
+func TestAuthMiddleware_APITokenPasswordChange(t *testing.T) {
+	db := setupAPITokenTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.SystemSettings{}))
+	router := newAPITokenTestRouter(db)
+
+	user := models.User{Username: "deployer", IsActive: true, IsAdmin: true, MustChangePassword: true}
+	require.NoError(t, user.SetPassword("temporary-Pass-1"))
+	require.NoError(t, db.Create(&user).Error)
+	plain, _, err := services.NewAPITokenService(db).CreateToken(user.ID, &services.CreateAPITokenRequest{
+		Name:        "ci",
+		Permissions: []string{models.PermissionNodeRead},
+	})
+	require.NoError(t, err)
+
+	// 令牌所属用户需要修改密码时，令牌请求同样被拦截
+	w := doTokenRequest(router, http.MethodGet, "/api/nodes", plain)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), PasswordChangeRequiredCode)
+
+	require.NoError(t, db.Model(&user).Update("must_change_password", false).Error)
+	assert.Equal(t, http.StatusOK, doTokenRequest(router, http.MethodGet, "/api/nodes", plain).Code)
+
+	// 策略更新后使缓存失效，密码过期立即生效
+	changedAt := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, db.Model(&user).Update("password_changed_at", changedAt).Error)
+	require.NoError(t, db.Create(&models.SystemSettings{ID: "max-age", Key: services.SettingPasswordMaxAgeDays, Value: "1", Category: "security"}).Error)
+	services.NewPasswordPolicyService(db).InvalidateCache()
+	w = doTokenRequest(router, http.MethodGet, "/api/nodes", plain)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Password expired")
+}
@@ -0,0 +1,245 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"superview/internal/config"
+	"superview/internal/logger"
+	"superview/internal/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LeaseName 主节点租约名称
+const LeaseName = "superview-leader"
+
+// Leadership 主节点身份，只有主节点运行监控、调度和扫描
+type Leadership interface {
+	// InstanceID 当前实例标识
+	InstanceID() string
+	// IsLeader 当前实例是否为主节点
+	IsLeader() bool
+	// OnLeadershipChange 注册身份变化回调；注册时已是主节点则立即以 true 调用
+	OnLeadershipChange(fn func(isLeader bool))
+	// Leader 当前租约信息
+	Leader() (*models.LeaderLease, error)
+}
+
+// standalone 单实例部署：始终是主节点
+type standalone struct {
+	instanceID string
+}
+
+// Standalone 创建单实例模式的 Leadership
+func Standalone(instanceID string) Leadership {
+	return &standalone{instanceID: instanceID}
+}
+
+func (s *standalone) InstanceID() string { return s.instanceID }
+
+func (s *standalone) IsLeader() bool { return true }
+
+func (s *standalone) OnLeadershipChange(fn func(isLeader bool)) { fn(true) }
+
+func (s *standalone) Leader() (*models.LeaderLease, error) {
+	return &models.LeaderLease{Name: LeaseName, Holder: s.instanceID}, nil
+}
+
+// Elector 基于数据库租约的选主
+// 各实例按 RenewInterval 尝试获取或续约租约：租约由自己持有或已过期时 UPDATE 成功即为主节点。
+// 租约过期时间使用本地时钟，实例间时钟偏差需远小于 LeaseDuration - RenewInterval。
+type Elector struct {
+	db            *gorm.DB
+	instanceID    string
+	address       string
+	leaseDuration time.Duration
+	renewInterval time.Duration
+
+	mu        sync.RWMutex
+	leader    bool
+	lastRenew time.Time
+	callbacks []func(isLeader bool)
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewElector 创建选主器
+func NewElector(db *gorm.DB, settings *config.HASettings) *Elector {
+	return &Elector{
+		db:            db,
+		instanceID:    settings.InstanceID,
+		address:       settings.AdvertiseAddr,
+		leaseDuration: settings.LeaseDuration,
+		renewInterval: settings.RenewInterval,
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// InstanceID 当前实例标识
+func (e *Elector) InstanceID() string {
+	return e.instanceID
+}
+
+// IsLeader 当前实例是否为主节点
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leader
+}
+
+// OnLeadershipChange 注册身份变化回调，回调在选主 goroutine 中按注册顺序执行
+func (e *Elector) OnLeadershipChange(fn func(isLeader bool)) {
+	e.mu.Lock()
+	e.callbacks = append(e.callbacks, fn)
+	isLeader := e.leader
+	e.mu.Unlock()
+
+	if isLeader {
+		fn(true)
+	}
+}
+
+// Leader 读取当前租约
+func (e *Elector) Leader() (*models.LeaderLease, error) {
+	var lease models.LeaderLease
+	if err := e.db.Where("name = ?", LeaseName).First(&lease).Error; err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+// Start 启动选主循环
+func (e *Elector) Start() {
+	logger.Info("Starting leader election",
+		zap.String("instance_id", e.instanceID),
+		zap.Duration("lease_duration", e.leaseDuration),
+		zap.Duration("renew_interval", e.renewInterval))
+
+	e.wg.Add(1)
+	go e.run()
+}
+
+// Stop 停止选主：先执行降级回调，再释放租约，让其他实例尽快接管
+func (e *Elector) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.stopChan)
+		e.wg.Wait()
+	})
+}
+
+func (e *Elector) run() {
+	defer e.wg.Done()
+
+	e.tick(time.Now())
+	ticker := time.NewTicker(e.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stopChan:
+			wasLeader := e.IsLeader()
+			e.setLeader(false)
+			if wasLeader {
+				e.release()
+			}
+			return
+		case now := <-ticker.C:
+			e.tick(now)
+		}
+	}
+}
+
+// tick 获取或续约一次租约并更新身份
+func (e *Elector) tick(now time.Time) {
+	acquired, err := e.tryAcquire(now)
+	if err != nil {
+		logger.Warn("Failed to renew leader lease", zap.String("instance_id", e.instanceID), zap.Error(err))
+		// 数据库不可用时无法确认租约，在本地租约到期前主动降级，避免与新主节点同时运行
+		e.mu.RLock()
+		expiring := e.leader && now.Add(e.renewInterval).After(e.lastRenew.Add(e.leaseDuration))
+		e.mu.RUnlock()
+		if expiring {
+			e.setLeader(false)
+		}
+		return
+	}
+
+	if acquired {
+		e.mu.Lock()
+		e.lastRenew = now
+		e.mu.Unlock()
+	}
+	e.setLeader(acquired)
+}
+
+// tryAcquire 租约由自己持有或已过期时接管；租约不存在时创建
+func (e *Elector) tryAcquire(now time.Time) (bool, error) {
+	expiresAt := now.Add(e.leaseDuration)
+
+	result := e.db.Model(&models.LeaderLease{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", LeaseName, e.instanceID, now).
+		Updates(map[string]interface{}{
+			"holder":     e.instanceID,
+			"address":    e.address,
+			"expires_at": expiresAt,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	lease := models.LeaderLease{
+		Name:      LeaseName,
+		Holder:    e.instanceID,
+		Address:   e.address,
+		ExpiresAt: expiresAt,
+		UpdatedAt: now,
+	}
+	result = e.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&lease)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// release 主动让出租约
+func (e *Elector) release() {
+	err := e.db.Model(&models.LeaderLease{}).
+		Where("name = ? AND holder = ?", LeaseName, e.instanceID).
+		Update("expires_at", time.Now()).Error
+	if err != nil {
+		logger.Warn("Failed to release leader lease", zap.String("instance_id", e.instanceID), zap.Error(err))
+		return
+	}
+	logger.Info("Leader lease released", zap.String("instance_id", e.instanceID))
+}
+
+// setLeader 更新身份，变化时依次执行回调
+func (e *Elector) setLeader(isLeader bool) {
+	e.mu.Lock()
+	if e.leader == isLeader {
+		e.mu.Unlock()
+		return
+	}
+	e.leader = isLeader
+	callbacks := make([]func(bool), len(e.callbacks))
+	copy(callbacks, e.callbacks)
+	e.mu.Unlock()
+
+	if isLeader {
+		logger.Info("This instance is now the leader", zap.String("instance_id", e.instanceID))
+	} else {
+		logger.Warn("This instance is no longer the leader", zap.String("instance_id", e.instanceID))
+	}
+	for _, fn := range callbacks {
+		fn(isLeader)
+	}
+}
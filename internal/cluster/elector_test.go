@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"superview/internal/config"
+	"superview/internal/models"
+)
+
+func setupClusterDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cluster.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.LeaderLease{}, &models.ClusterEvent{}))
+	return db
+}
+
+func testSettings(instanceID string) *config.HASettings {
+	return &config.HASettings{
+		Enabled:           true,
+		InstanceID:        instanceID,
+		AdvertiseAddr:     "http://" + instanceID + ":8081",
+		LeaseDuration:     15 * time.Second,
+		RenewInterval:     5 * time.Second,
+		EventPollInterval: 10 * time.Millisecond,
+		EventRetention:    time.Minute,
+	}
+}
+
+func TestElector_SingleLeader(t *testing.T) {
+	db := setupClusterDB(t)
+	a := NewElector(db, testSettings("a"))
+	b := NewElector(db, testSettings("b"))
+
+	var aChanges, bChanges []bool
+	a.OnLeadershipChange(func(isLeader bool) { aChanges = append(aChanges, isLeader) })
+	b.OnLeadershipChange(func(isLeader bool) { bChanges = append(bChanges, isLeader) })
+
+	now := time.Now()
+	a.tick(now)
+	b.tick(now)
+	assert.True(t, a.IsLeader())
+	assert.False(t, b.IsLeader())
+
+	// 续约不会触发回调
+	a.tick(now.Add(5 * time.Second))
+	b.tick(now.Add(5 * time.Second))
+	assert.True(t, a.IsLeader())
+	assert.False(t, b.IsLeader())
+	assert.Equal(t, []bool{true}, aChanges)
+	assert.Empty(t, bChanges)
+
+	lease, err := b.Leader()
+	require.NoError(t, err)
+	assert.Equal(t, "a", lease.Holder)
+	assert.Equal(t, "http://a:8081", lease.Address)
+}
+
+func TestElector_TakeoverAfterExpiry(t *testing.T) {
+	db := setupClusterDB(t)
+	a := NewElector(db, testSettings("a"))
+	b := NewElector(db, testSettings("b"))
+
+	now := time.Now()
+	a.tick(now)
+	require.True(t, a.IsLeader())
+
+	// a 停止续约，租约过期后 b 接管
+	later := now.Add(16 * time.Second)
+	b.tick(later)
+	assert.True(t, b.IsLeader())
+
+	// a 恢复后发现租约已被接管，降级
+	a.tick(later.Add(time.Second))
+	assert.False(t, a.IsLeader())
+}
+
+func TestElector_StopReleasesLease(t *testing.T) {
+	db := setupClusterDB(t)
+	a := NewElector(db, testSettings("a"))
+	b := NewElector(db, testSettings("b"))
+
+	demoted := make(chan struct{})
+	a.OnLeadershipChange(func(isLeader bool) {
+		if !isLeader {
+			close(demoted)
+		}
+	})
+
+	a.Start()
+	require.Eventually(t, a.IsLeader, time.Second, 10*time.Millisecond)
+	a.Stop()
+
+	select {
+	case <-demoted:
+	default:
+		t.Fatal("Stop should demote the leader before returning")
+	}
+
+	// 租约已释放，b 无需等待过期即可接管
+	b.tick(time.Now())
+	assert.True(t, b.IsLeader())
+}
+
+func TestElector_LateCallbackRegistration(t *testing.T) {
+	db := setupClusterDB(t)
+	a := NewElector(db, testSettings("a"))
+	a.tick(time.Now())
+	require.True(t, a.IsLeader())
+
+	called := false
+	a.OnLeadershipChange(func(isLeader bool) { called = isLeader })
+	assert.True(t, called, "callbacks registered while leader are invoked immediately")
+}
+
+func TestStandalone(t *testing.T) {
+	l := Standalone("single")
+	assert.True(t, l.IsLeader())
+
+	called := false
+	l.OnLeadershipChange(func(isLeader bool) { called = isLeader })
+	assert.True(t, called)
+}
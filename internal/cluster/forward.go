@@ -0,0 +1,73 @@
+package cluster
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"superview/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ForwardedByHeader 转发请求时携带的来源实例标识，用于防止转发环路
+const ForwardedByHeader = "X-Superview-Forwarded-By"
+
+// ForwardToLeader 非主节点收到只能由主节点处理的请求（发现扫描、调度器开关等）时，
+// 将请求原样转发到主节点的 advertise_addr；主节点直接处理
+func ForwardToLeader(leadership Leadership) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if leadership.IsLeader() {
+			c.Next()
+			return
+		}
+
+		if forwardedBy := c.GetHeader(ForwardedByHeader); forwardedBy != "" {
+			// 已经被转发过一次仍未到达主节点（主节点切换中），不再继续转发
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "error",
+				"message": "Leader changed while forwarding request, please retry",
+			})
+			return
+		}
+
+		lease, err := leadership.Leader()
+		if err != nil || lease.Address == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "error",
+				"message": "No leader available to handle this request",
+			})
+			return
+		}
+
+		target, err := url.Parse(lease.Address)
+		if err != nil || target.Host == "" {
+			logger.Error("Invalid leader advertise address",
+				zap.String("leader", lease.Holder),
+				zap.String("address", lease.Address))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "error",
+				"message": "Leader advertise address is invalid",
+			})
+			return
+		}
+
+		logger.Debug("Forwarding request to leader",
+			zap.String("path", c.Request.URL.Path),
+			zap.String("leader", lease.Holder))
+
+		proxy := httputil.NewSingleHostReverseProxy(target)
+		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Warn("Failed to forward request to leader",
+				zap.String("leader", lease.Holder),
+				zap.Error(err))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"status":"error","message":"Failed to reach leader instance"}`))
+		}
+		c.Request.Header.Set(ForwardedByHeader, leadership.InstanceID())
+		proxy.ServeHTTP(c.Writer, c.Request)
+		c.Abort()
+	}
+}
@@ -0,0 +1,214 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"superview/internal/config"
+	"superview/internal/logger"
+	"superview/internal/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	relayBatchSize  = 500
+	relayQueueSize  = 1024
+	relayGapTimeout = 5 * time.Second
+)
+
+// EventRelay 通过 cluster_events 表在实例间转发 WebSocket 广播
+// Publish 写入的事件由其他实例轮询读取后交给本地 Hub 投递。
+type EventRelay struct {
+	db           *gorm.DB
+	instanceID   string
+	pollInterval time.Duration
+	retention    time.Duration
+
+	deliver func(payload []byte)
+	queue   chan models.ClusterEvent
+
+	// cursor 之前（含）的事件已全部处理；seen 记录 cursor 之后已处理的事件，
+	// gaps 记录等待中的空缺 ID（并发事务提交顺序可能与 ID 顺序不一致）
+	cursor uint
+	seen   map[uint]bool
+	gaps   map[uint]time.Time
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewEventRelay 创建事件转发器，deliver 负责将其他实例的事件投递给本地客户端
+func NewEventRelay(db *gorm.DB, settings *config.HASettings, deliver func(payload []byte)) *EventRelay {
+	return &EventRelay{
+		db:           db,
+		instanceID:   settings.InstanceID,
+		pollInterval: settings.EventPollInterval,
+		retention:    settings.EventRetention,
+		deliver:      deliver,
+		queue:        make(chan models.ClusterEvent, relayQueueSize),
+		seen:         make(map[uint]bool),
+		gaps:         make(map[uint]time.Time),
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// Publish 异步写入事件，队列满时丢弃（与 Hub 广播通道满时的行为一致）
+func (r *EventRelay) Publish(payload []byte) {
+	event := models.ClusterEvent{
+		Origin:    r.instanceID,
+		Payload:   string(payload),
+		CreatedAt: time.Now(),
+	}
+	select {
+	case r.queue <- event:
+	default:
+		logger.Warn("Cluster event queue full, event not relayed to other instances")
+	}
+}
+
+// Start 从当前最新事件之后开始转发
+func (r *EventRelay) Start() error {
+	var latest models.ClusterEvent
+	err := r.db.Order("id DESC").Limit(1).Find(&latest).Error
+	if err != nil {
+		return err
+	}
+	r.cursor = latest.ID
+
+	r.wg.Add(2)
+	go r.writeLoop()
+	go r.pollLoop()
+	return nil
+}
+
+// Stop 停止转发，尚未写入的事件会被写完
+func (r *EventRelay) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+		r.wg.Wait()
+	})
+}
+
+func (r *EventRelay) writeLoop() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case event := <-r.queue:
+			batch := []models.ClusterEvent{event}
+		drain:
+			for len(batch) < relayBatchSize {
+				select {
+				case next := <-r.queue:
+					batch = append(batch, next)
+				default:
+					break drain
+				}
+			}
+			if err := r.db.CreateInBatches(&batch, relayBatchSize).Error; err != nil {
+				logger.Error("Failed to write cluster events", zap.Int("count", len(batch)), zap.Error(err))
+			}
+		case <-r.stopChan:
+			// 写完队列中剩余事件后退出
+			var batch []models.ClusterEvent
+			for {
+				select {
+				case event := <-r.queue:
+					batch = append(batch, event)
+				default:
+					if len(batch) > 0 {
+						r.db.CreateInBatches(&batch, relayBatchSize)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *EventRelay) pollLoop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	pruneTicker := time.NewTicker(r.retention / 2)
+	defer pruneTicker.Stop()
+
+	for {
+		select {
+		case <-r.stopChan:
+			return
+		case now := <-ticker.C:
+			if err := r.poll(now); err != nil {
+				logger.Warn("Failed to poll cluster events", zap.Error(err))
+			}
+		case now := <-pruneTicker.C:
+			r.prune(now)
+		}
+	}
+}
+
+// poll 读取 cursor 之后的事件，投递其他实例写入的事件并推进 cursor
+func (r *EventRelay) poll(now time.Time) error {
+	var events []models.ClusterEvent
+	err := r.db.Where("id > ?", r.cursor).Order("id ASC").Limit(relayBatchSize).Find(&events).Error
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	for _, event := range events {
+		if r.seen[event.ID] {
+			continue
+		}
+		r.seen[event.ID] = true
+		if event.Origin != r.instanceID && r.deliver != nil {
+			r.deliver([]byte(event.Payload))
+		}
+	}
+	r.advance(events[len(events)-1].ID, now)
+	return nil
+}
+
+// advance 推进 cursor；遇到空缺 ID 时最多等待 relayGapTimeout，超时视为已回滚的事务
+func (r *EventRelay) advance(maxID uint, now time.Time) {
+	blocked := false
+	for next := r.cursor + 1; next <= maxID; next++ {
+		if r.seen[next] {
+			delete(r.gaps, next)
+			if !blocked {
+				delete(r.seen, next)
+				r.cursor = next
+			}
+			continue
+		}
+		firstSeen, waiting := r.gaps[next]
+		if !waiting {
+			r.gaps[next] = now
+			firstSeen = now
+		}
+		if blocked || now.Sub(firstSeen) < relayGapTimeout {
+			blocked = true
+			continue
+		}
+		delete(r.gaps, next)
+		r.cursor = next
+	}
+}
+
+// prune 删除超过保留时间的事件
+func (r *EventRelay) prune(now time.Time) {
+	result := r.db.Where("created_at < ?", now.Add(-r.retention)).Delete(&models.ClusterEvent{})
+	if result.Error != nil {
+		logger.Warn("Failed to prune cluster events", zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.Debug("Pruned cluster events", zap.Int64("count", result.RowsAffected))
+	}
+}
@@ -0,0 +1,97 @@
+package cluster
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"superview/internal/models"
+)
+
+type collector struct {
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (c *collector) deliver(payload []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.payloads = append(c.payloads, string(payload))
+}
+
+func (c *collector) get() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.payloads...)
+}
+
+func TestEventRelay_FanOut(t *testing.T) {
+	db := setupClusterDB(t)
+
+	// 启动前写入的事件不会被投递
+	require.NoError(t, db.Create(&models.ClusterEvent{Origin: "old", Payload: "stale", CreatedAt: time.Now()}).Error)
+
+	var aReceived, bReceived collector
+	a := NewEventRelay(db, testSettings("a"), aReceived.deliver)
+	b := NewEventRelay(db, testSettings("b"), bReceived.deliver)
+	require.NoError(t, a.Start())
+	require.NoError(t, b.Start())
+	defer a.Stop()
+	defer b.Stop()
+
+	a.Publish([]byte(`{"type":"alert_created"}`))
+	b.Publish([]byte(`{"type":"discovery_progress"}`))
+
+	require.Eventually(t, func() bool {
+		return len(aReceived.get()) == 1 && len(bReceived.get()) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// 自己发布的事件不会回送
+	assert.Equal(t, []string{`{"type":"discovery_progress"}`}, aReceived.get())
+	assert.Equal(t, []string{`{"type":"alert_created"}`}, bReceived.get())
+}
+
+func TestEventRelay_AdvanceWaitsForGaps(t *testing.T) {
+	r := NewEventRelay(nil, testSettings("a"), nil)
+	now := time.Now()
+
+	// 事件 2 尚未提交：cursor 停在 1，已处理的 3 保留在 seen 中
+	r.seen[1] = true
+	r.seen[3] = true
+	r.advance(3, now)
+	assert.Equal(t, uint(1), r.cursor)
+	assert.True(t, r.seen[3])
+
+	// 事件 2 在等待期内提交
+	r.seen[2] = true
+	r.advance(3, now.Add(time.Second))
+	assert.Equal(t, uint(3), r.cursor)
+	assert.Empty(t, r.seen)
+	assert.Empty(t, r.gaps)
+
+	// 事件 4 永远不会出现（事务回滚），超时后跳过
+	r.seen[5] = true
+	r.advance(5, now)
+	assert.Equal(t, uint(3), r.cursor)
+	r.advance(5, now.Add(relayGapTimeout+time.Second))
+	assert.Equal(t, uint(5), r.cursor)
+}
+
+func TestEventRelay_Prune(t *testing.T) {
+	db := setupClusterDB(t)
+	r := NewEventRelay(db, testSettings("a"), nil)
+
+	now := time.Now()
+	require.NoError(t, db.Create(&models.ClusterEvent{Origin: "a", Payload: "old", CreatedAt: now.Add(-2 * time.Minute)}).Error)
+	require.NoError(t, db.Create(&models.ClusterEvent{Origin: "a", Payload: "new", CreatedAt: now}).Error)
+
+	r.prune(now)
+
+	var remaining []models.ClusterEvent
+	require.NoError(t, db.Find(&remaining).Error)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "new", remaining[0].Payload)
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// 告警评估和分组的默认参数
+const (
+	DefaultAlertEvaluationInterval = 30 * time.Second
+	DefaultAlertGroupWait          = 30 * time.Second
+	DefaultAlertRepeatInterval     = 4 * time.Hour
+)
+
+// DefaultAlertGroupBy 默认按节点和规则分组
+var DefaultAlertGroupBy = []string{"node", "rule"}
+
+// AlertGroupKeys 可用的分组键
+var AlertGroupKeys = []string{"node", "environment", "rule"}
+
+// AlertingConfig [alerting] 配置段
+//
+//	[alerting]
+//	evaluation_interval = "30s"   # 告警规则评估间隔
+//	group_by = ["node", "rule"]   # 告警分组键：node、environment、rule
+//	group_wait = "30s"            # 新事件等待同组告警的时间，之后发送第一条通知
+//	repeat_interval = "4h"        # 未确认事件的重复通知间隔，"0" 表示不重复
+type AlertingConfig struct {
+	EvaluationInterval string   `mapstructure:"evaluation_interval" toml:"evaluation_interval" json:"evaluation_interval"`
+	GroupBy            []string `mapstructure:"group_by" toml:"group_by" json:"group_by"`
+	GroupWait          string   `mapstructure:"group_wait" toml:"group_wait" json:"group_wait"`
+	RepeatInterval     string   `mapstructure:"repeat_interval" toml:"repeat_interval" json:"repeat_interval"`
+}
+
+// AlertingSettings 解析后的告警参数
+type AlertingSettings struct {
+	EvaluationInterval time.Duration
+	GroupBy            []string
+	GroupWait          time.Duration
+	RepeatInterval     time.Duration
+}
+
+// DefaultAlertingSettings 返回默认告警参数
+func DefaultAlertingSettings() *AlertingSettings {
+	return &AlertingSettings{
+		EvaluationInterval: DefaultAlertEvaluationInterval,
+		GroupBy:            append([]string(nil), DefaultAlertGroupBy...),
+		GroupWait:          DefaultAlertGroupWait,
+		RepeatInterval:     DefaultAlertRepeatInterval,
+	}
+}
+
+// Resolve 解析时长并填充默认值
+func (c AlertingConfig) Resolve() (*AlertingSettings, error) {
+	settings := DefaultAlertingSettings()
+	if c.EvaluationInterval != "" {
+		interval, err := time.ParseDuration(c.EvaluationInterval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid alerting.evaluation_interval %q", c.EvaluationInterval)
+		}
+		settings.EvaluationInterval = interval
+	}
+	if c.GroupBy != nil {
+		seen := make(map[string]bool)
+		settings.GroupBy = nil
+		for _, key := range c.GroupBy {
+			if !isAlertGroupKey(key) {
+				return nil, fmt.Errorf("invalid alerting.group_by key %q, must be one of %v", key, AlertGroupKeys)
+			}
+			if !seen[key] {
+				seen[key] = true
+				settings.GroupBy = append(settings.GroupBy, key)
+			}
+		}
+	}
+	if c.GroupWait != "" {
+		wait, err := time.ParseDuration(c.GroupWait)
+		if err != nil || wait < 0 {
+			return nil, fmt.Errorf("invalid alerting.group_wait %q", c.GroupWait)
+		}
+		settings.GroupWait = wait
+	}
+	if c.RepeatInterval != "" {
+		repeat, err := time.ParseDuration(c.RepeatInterval)
+		if err != nil || repeat < 0 {
+			return nil, fmt.Errorf("invalid alerting.repeat_interval %q", c.RepeatInterval)
+		}
+		settings.RepeatInterval = repeat
+	}
+	return settings, nil
+}
+
+func isAlertGroupKey(key string) bool {
+	for _, k := range AlertGroupKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
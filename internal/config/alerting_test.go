@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertingConfig_Resolve(t *testing.T) {
+	settings, err := AlertingConfig{}.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultAlertEvaluationInterval, settings.EvaluationInterval)
+
+	settings, err = AlertingConfig{EvaluationInterval: "15s"}.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Second, settings.EvaluationInterval)
+
+	_, err = AlertingConfig{EvaluationInterval: "0s"}.Resolve()
+	assert.Error(t, err)
+}
+
+func TestAlertingConfig_ResolveGrouping(t *testing.T) {
+	settings, err := AlertingConfig{}.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node", "rule"}, settings.GroupBy)
+	assert.Equal(t, DefaultAlertGroupWait, settings.GroupWait)
+	assert.Equal(t, DefaultAlertRepeatInterval, settings.RepeatInterval)
+
+	settings, err = AlertingConfig{GroupBy: []string{"environment", "environment"}, GroupWait: "0s", RepeatInterval: "0"}.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"environment"}, settings.GroupBy)
+	assert.Zero(t, settings.GroupWait)
+	assert.Zero(t, settings.RepeatInterval)
+
+	settings, err = AlertingConfig{GroupBy: []string{}}.Resolve()
+	require.NoError(t, err)
+	assert.Empty(t, settings.GroupBy)
+
+	_, err = AlertingConfig{GroupBy: []string{"process"}}.Resolve()
+	assert.Error(t, err)
+	_, err = AlertingConfig{GroupWait: "-1s"}.Resolve()
+	assert.Error(t, err)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -162,6 +163,7 @@ func (m *AtomicConfigManager) Watch(callback func(*Config)) error {
 }
 
 // WatchNodeList 监听节点列表文件变化
+// 监听所在目录而不是文件本身：编辑器"写临时文件再重命名"式的保存会让文件级监听失效
 func (m *AtomicConfigManager) WatchNodeList(nodeListPath string, callback func([]NodeConfig)) error {
 	if atomic.LoadInt32(&m.stopped) != 0 {
 		return fmt.Errorf("manager is stopped")
@@ -175,8 +177,9 @@ func (m *AtomicConfigManager) WatchNodeList(nodeListPath string, callback func([
 		return nil
 	}
 
-	if _, err := os.Stat(nodeListPath); os.IsNotExist(err) {
-		logger.Info("Node list file does not exist, skipping watch", zap.String("file", nodeListPath))
+	dir := filepath.Dir(nodeListPath)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		logger.Info("Node list directory does not exist, skipping watch", zap.String("dir", dir))
 		return nil
 	}
 
@@ -186,11 +189,11 @@ func (m *AtomicConfigManager) WatchNodeList(nodeListPath string, callback func([
 	}
 	m.nodeListWatcher = watcher
 
-	if err := watcher.Add(nodeListPath); err != nil {
-		return fmt.Errorf("failed to watch node list file: %w", err)
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch node list directory: %w", err)
 	}
 
-	go m.watchNodeListLoop()
+	go m.watchNodeListLoop(filepath.Clean(nodeListPath))
 
 	logger.Info("Node list file watcher started", zap.String("file", nodeListPath))
 	return nil
@@ -219,18 +222,36 @@ func (m *AtomicConfigManager) watchLoop() {
 	}
 }
 
+// nodeListDebounce 节点列表变化后等待的时间，合并一次保存产生的多个文件事件
+const nodeListDebounce = 500 * time.Millisecond
+
 // watchNodeListLoop 节点列表文件监听循环
-func (m *AtomicConfigManager) watchNodeListLoop() {
+func (m *AtomicConfigManager) watchNodeListLoop(nodeListPath string) {
+	debounce := time.NewTimer(nodeListDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
 	for {
 		select {
 		case event, ok := <-m.nodeListWatcher.Events:
 			if !ok {
 				return
 			}
-			if event.Op&fsnotify.Write == fsnotify.Write {
-				logger.Info("Node list file changed, reloading", zap.String("file", event.Name))
-				m.safeReloadNodeList()
+			if filepath.Clean(event.Name) != nodeListPath {
+				continue
 			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				logger.Debug("Node list file changed", zap.String("file", event.Name), zap.String("op", event.Op.String()))
+				debounce.Reset(nodeListDebounce)
+			}
+		case <-debounce.C:
+			// 文件被移走或删除时不重载，避免把"文件暂时不存在"当作清空节点列表
+			if _, err := os.Stat(nodeListPath); err != nil {
+				logger.Warn("Node list file is missing, skipping reload", zap.String("file", nodeListPath))
+				continue
+			}
+			logger.Info("Node list file changed, reloading", zap.String("file", nodeListPath))
+			m.safeReloadNodeList()
 		case err, ok := <-m.nodeListWatcher.Errors:
 			if !ok {
 				return
@@ -351,24 +372,15 @@ func (m *AtomicConfigManager) reloadNodeList() {
 	// 使用 ConfigLoader 重新加载完整配置
 	loader := NewConfigLoader(mainPath, nodeListPath)
 	
-	mainConfig, err := loader.LoadMainConfig()
-	if err != nil {
-		logger.Error("Failed to reload main config during node list reload", 
-			zap.Error(err),
-			zap.Int64("version", oldVersion))
-		return
-	}
-	
-	nodeListNodes, err := loader.LoadNodeList()
+	// LoadWithDefaults 会合并 config.toml 与 nodelist.toml 中的节点并展开环境变量
+	loaded, err := loader.LoadWithDefaults()
 	if err != nil {
 		logger.Error("Failed to reload node list", 
 			zap.Error(err),
 			zap.Int64("version", oldVersion))
 		return
 	}
-
-	// 合并节点配置
-	mergedNodes := loader.MergeNodes(mainConfig.Nodes, nodeListNodes)
+	mergedNodes := loaded.Nodes
 
 	// 验证新节点配置
 	validator := NewValidator()
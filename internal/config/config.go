@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"time"
 	
 	"github.com/spf13/viper"
@@ -8,7 +9,11 @@ import (
 )
 
 type Config struct {
-	Database         string                   `mapstructure:"database"`
+	// Database 旧版数据库配置（单个路径或 DSN 字符串），由 [database] 段或 database = "..." 解析得到
+	Database         string                   `mapstructure:"-" toml:"-"`
+	DatabaseSection  DatabaseSection          `mapstructure:"-" toml:"-"`
+	// RawDatabase 原始 database 配置项，可以是字符串（旧格式）或 [database] 表
+	RawDatabase      interface{}              `mapstructure:"database" toml:"database"`
 	ActivityLog      string                   `mapstructure:"activity_log"`
 	Admin            AdminConfig              `mapstructure:"admin"`
 	// 保留向后兼容的字段
@@ -22,6 +27,9 @@ type Config struct {
 	Metrics          MetricsConfig            `mapstructure:"metrics"`
 	WebSocket        WebSocketConfig          `mapstructure:"websocket"`
 	CORS             CORSConfig               `mapstructure:"cors"`
+	HA               HAConfig                 `mapstructure:"ha" toml:"ha"`
+	Flapping         FlappingConfig           `mapstructure:"flapping" toml:"flapping"`
+	Alerting         AlertingConfig           `mapstructure:"alerting" toml:"alerting"`
 }
 
 // MetricsConfig Prometheus 指标暴露配置
@@ -30,6 +38,31 @@ type MetricsConfig struct {
 	Path     string `mapstructure:"path"`
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
+	// 进程指标历史的记录间隔和保留时长，供 /api/v1/query_range 查询；interval 为 "0" 时不记录
+	HistoryInterval  string `mapstructure:"history_interval"`
+	HistoryRetention string `mapstructure:"history_retention"`
+}
+
+// 指标历史默认参数
+const (
+	DefaultMetricsHistoryInterval  = time.Minute
+	DefaultMetricsHistoryRetention = 7 * 24 * time.Hour
+)
+
+// HistorySettings 解析指标历史的记录间隔和保留时长，未配置时使用默认值
+func (c MetricsConfig) HistorySettings() (interval, retention time.Duration, err error) {
+	interval, retention = DefaultMetricsHistoryInterval, DefaultMetricsHistoryRetention
+	if c.HistoryInterval != "" {
+		if interval, err = time.ParseDuration(c.HistoryInterval); err != nil || interval < 0 {
+			return 0, 0, fmt.Errorf("invalid metrics.history_interval %q", c.HistoryInterval)
+		}
+	}
+	if c.HistoryRetention != "" {
+		if retention, err = time.ParseDuration(c.HistoryRetention); err != nil || retention <= 0 {
+			return 0, 0, fmt.Errorf("invalid metrics.history_retention %q", c.HistoryRetention)
+		}
+	}
+	return interval, retention, nil
 }
 
 // AdminConfig 管理员配置
@@ -87,6 +120,8 @@ type NodeConfig struct {
 	Port        int    `mapstructure:"port" toml:"port"`
 	Username    string `mapstructure:"username" toml:"username"`
 	Password    string `mapstructure:"password" toml:"password"`
+	// Labels 节点标签；未设置时重载不修改节点现有标签（可能是通过 API 设置的）
+	Labels map[string]string `mapstructure:"labels" toml:"labels"`
 }
 
 func Load(configPath string) (*Config, error) {
@@ -100,6 +135,9 @@ func Load(configPath string) (*Config, error) {
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, err
 	}
+	if err := cfg.normalizeDatabase(); err != nil {
+		return nil, err
+	}
 
 	// 处理新旧配置格式的兼容性
 	// 如果使用新格式（Admin 结构体），将其复制到旧字段
@@ -112,7 +150,7 @@ func Load(configPath string) (*Config, error) {
 
 	// Set defaults
 	if cfg.Database == "" {
-		cfg.Database = "sqlite:///users.db"
+		cfg.Database = DefaultDatabase
 	}
 	if cfg.ActivityLog == "" {
 		cfg.ActivityLog = "activity.log"
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// DefaultDatabase 未配置数据库时的占位值，实际使用 data/superview.db
+const DefaultDatabase = "sqlite:///users.db"
+
+// DatabaseSection [database] 配置段
+//
+//	[database]
+//	driver = "postgres"                 # sqlite | postgres | mysql
+//	dsn = "postgres://superview:${DB_PASSWORD}@db:5432/superview?sslmode=require"
+//	max_open_conns = 20
+//	max_idle_conns = 10
+//	conn_max_lifetime = "30m"
+//	conn_max_idle_time = "5m"
+//	query_timeout = "30s"
+type DatabaseSection struct {
+	Driver          string `mapstructure:"driver" toml:"driver" json:"driver"`
+	DSN             string `mapstructure:"dsn" toml:"dsn" json:"-"`
+	MaxOpenConns    int    `mapstructure:"max_open_conns" toml:"max_open_conns" json:"max_open_conns"`
+	MaxIdleConns    int    `mapstructure:"max_idle_conns" toml:"max_idle_conns" json:"max_idle_conns"`
+	ConnMaxLifetime string `mapstructure:"conn_max_lifetime" toml:"conn_max_lifetime" json:"conn_max_lifetime"`
+	ConnMaxIdleTime string `mapstructure:"conn_max_idle_time" toml:"conn_max_idle_time" json:"conn_max_idle_time"`
+	QueryTimeout    string `mapstructure:"query_timeout" toml:"query_timeout" json:"query_timeout"`
+}
+
+// normalizeDatabase 将原始 database 配置项解析为 Database（旧格式字符串）和 DatabaseSection
+func (c *Config) normalizeDatabase() error {
+	switch raw := c.RawDatabase.(type) {
+	case nil:
+	case string:
+		c.Database = raw
+	case map[string]interface{}:
+		// 通过 TOML 重新编码解码，viper 和 go-toml 得到的 map 都能统一处理
+		data, err := toml.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("invalid [database] section: %w", err)
+		}
+		var section DatabaseSection
+		if err := toml.Unmarshal(data, &section); err != nil {
+			return fmt.Errorf("invalid [database] section: %w", err)
+		}
+		c.DatabaseSection = section
+		c.Database = section.DSN
+	default:
+		return fmt.Errorf("invalid database config: expected string or [database] section, got %T", raw)
+	}
+	c.RawDatabase = nil
+	return nil
+}
+
+// ResolvedDatabase 返回最终生效的数据库驱动和 DSN
+// 优先使用 [database] 段；否则根据旧格式 database 字符串推断驱动；都未配置时使用 SQLite 默认路径（DSN 为空）
+func (c *Config) ResolvedDatabase() DatabaseSection {
+	section := c.DatabaseSection
+	if section.Driver != "" {
+		section.Driver = strings.ToLower(strings.TrimSpace(section.Driver))
+		return section
+	}
+
+	legacy := strings.TrimSpace(c.Database)
+	if section.DSN != "" {
+		legacy = section.DSN
+	}
+	if legacy == "" || legacy == DefaultDatabase {
+		section.Driver = "sqlite"
+		section.DSN = ""
+		return section
+	}
+
+	switch {
+	case strings.HasPrefix(legacy, "postgres://"), strings.HasPrefix(legacy, "postgresql://"):
+		section.Driver = "postgres"
+		section.DSN = legacy
+	case strings.HasPrefix(legacy, "mysql://"):
+		section.Driver = "mysql"
+		section.DSN = strings.TrimPrefix(legacy, "mysql://")
+	default:
+		section.Driver = "sqlite"
+		section.DSN = legacy
+		// sqlite:///relative/path 与 sqlite:////abs/path 均按 URL 惯例去掉一层斜杠；不带前缀的路径原样使用
+		if path, ok := strings.CutPrefix(legacy, "sqlite://"); ok {
+			section.DSN = strings.TrimPrefix(path, "/")
+		}
+	}
+	return section
+}
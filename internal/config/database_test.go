@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigLoader_DatabaseSection(t *testing.T) {
+	tmpDir := t.TempDir()
+	mainConfigPath := filepath.Join(tmpDir, "config.toml")
+
+	configContent := `
+[database]
+driver = "postgres"
+dsn = "postgres://superview:${TEST_DB_PASSWORD}@db:5432/superview"
+max_open_conns = 40
+conn_max_lifetime = "15m"
+
+[admin]
+username = "admin"
+`
+	require.NoError(t, os.WriteFile(mainConfigPath, []byte(configContent), 0644))
+	t.Setenv("TEST_DB_PASSWORD", "s3cret")
+
+	// 热重载路径（go-toml）
+	cfg, err := NewConfigLoader(mainConfigPath, "").Load()
+	require.NoError(t, err)
+	resolved := cfg.ResolvedDatabase()
+	assert.Equal(t, "postgres", resolved.Driver)
+	assert.Equal(t, "postgres://superview:s3cret@db:5432/superview", resolved.DSN)
+	assert.Equal(t, 40, resolved.MaxOpenConns)
+	assert.Equal(t, "15m", resolved.ConnMaxLifetime)
+
+	// 启动路径（viper）
+	cfg, err = NewConfigLoader(mainConfigPath, "").LoadWithDefaults()
+	require.NoError(t, err)
+	assert.Equal(t, "postgres", cfg.ResolvedDatabase().Driver)
+	assert.Equal(t, 40, cfg.ResolvedDatabase().MaxOpenConns)
+}
+
+func TestConfig_ResolvedDatabaseLegacy(t *testing.T) {
+	tests := []struct {
+		database string
+		driver   string
+		dsn      string
+	}{
+		{"", "sqlite", ""},
+		{DefaultDatabase, "sqlite", ""},
+		{"data/superview.db", "sqlite", "data/superview.db"},
+		{"/var/lib/superview.db", "sqlite", "/var/lib/superview.db"},
+		{"sqlite:///data/app.db", "sqlite", "data/app.db"},
+		{"sqlite:////var/lib/superview.db", "sqlite", "/var/lib/superview.db"},
+		{"postgres://u:p@db/superview", "postgres", "postgres://u:p@db/superview"},
+		{"mysql://u:p@tcp(db:3306)/superview", "mysql", "u:p@tcp(db:3306)/superview"},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Database: tt.database}
+		resolved := cfg.ResolvedDatabase()
+		assert.Equal(t, tt.driver, resolved.Driver, tt.database)
+		assert.Equal(t, tt.dsn, resolved.DSN, tt.database)
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultFlapWindows 未配置窗口时使用的抖动判定：10 分钟内重启 5 次或 1 小时内重启 10 次
+var DefaultFlapWindows = []FlapWindow{
+	{Window: 10 * time.Minute, MaxRestarts: 5},
+	{Window: time.Hour, MaxRestarts: 10},
+}
+
+// FlappingConfig [flapping] 配置段：按时间窗口统计进程重启次数，超过阈值视为抖动
+//
+//	[flapping]
+//	quarantine = true      # 抖动时停止进程并隔离，人工确认后才能再次启动
+//
+//	[[flapping.windows]]
+//	window = "10m"
+//	max_restarts = 5
+//
+//	[[flapping.windows]]
+//	window = "1h"
+//	max_restarts = 10
+type FlappingConfig struct {
+	Quarantine bool               `mapstructure:"quarantine" toml:"quarantine" json:"quarantine"`
+	Windows    []FlapWindowConfig `mapstructure:"windows" toml:"windows" json:"windows"`
+}
+
+// FlapWindowConfig 一个统计窗口
+type FlapWindowConfig struct {
+	Window      string `mapstructure:"window" toml:"window" json:"window"`
+	MaxRestarts int    `mapstructure:"max_restarts" toml:"max_restarts" json:"max_restarts"`
+}
+
+// FlapWindow 解析后的统计窗口：Window 时长内重启次数达到 MaxRestarts 即为抖动
+type FlapWindow struct {
+	Window      time.Duration
+	MaxRestarts int
+}
+
+// FlappingSettings 解析后的抖动检测参数
+type FlappingSettings struct {
+	Quarantine bool
+	Windows    []FlapWindow
+}
+
+// Resolve 解析窗口时长，未配置窗口时使用 DefaultFlapWindows
+func (c FlappingConfig) Resolve() (*FlappingSettings, error) {
+	settings := &FlappingSettings{Quarantine: c.Quarantine}
+	for i, w := range c.Windows {
+		window, err := time.ParseDuration(w.Window)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid flapping.windows[%d].window %q", i, w.Window)
+		}
+		if w.MaxRestarts <= 0 {
+			return nil, fmt.Errorf("invalid flapping.windows[%d].max_restarts %d: must be positive", i, w.MaxRestarts)
+		}
+		settings.Windows = append(settings.Windows, FlapWindow{Window: window, MaxRestarts: w.MaxRestarts})
+	}
+	if len(settings.Windows) == 0 {
+		settings.Windows = append(settings.Windows, DefaultFlapWindows...)
+	}
+	return settings, nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlappingConfig_Resolve(t *testing.T) {
+	settings, err := FlappingConfig{}.Resolve()
+	require.NoError(t, err)
+	assert.False(t, settings.Quarantine)
+	assert.Equal(t, DefaultFlapWindows, settings.Windows)
+
+	_, err = FlappingConfig{Windows: []FlapWindowConfig{{Window: "abc", MaxRestarts: 3}}}.Resolve()
+	assert.Error(t, err)
+	_, err = FlappingConfig{Windows: []FlapWindowConfig{{Window: "5m", MaxRestarts: 0}}}.Resolve()
+	assert.Error(t, err)
+}
+
+func TestConfigLoader_FlappingSection(t *testing.T) {
+	mainConfigPath := filepath.Join(t.TempDir(), "config.toml")
+	configContent := `
+[flapping]
+quarantine = true
+
+[[flapping.windows]]
+window = "15m"
+max_restarts = 4
+`
+	require.NoError(t, os.WriteFile(mainConfigPath, []byte(configContent), 0644))
+
+	cfg, err := NewConfigLoader(mainConfigPath, "").Load()
+	require.NoError(t, err)
+	settings, err := cfg.Flapping.Resolve()
+	require.NoError(t, err)
+	assert.True(t, settings.Quarantine)
+	assert.Equal(t, []FlapWindow{{Window: 15 * time.Minute, MaxRestarts: 4}}, settings.Windows)
+}
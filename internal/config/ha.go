@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// HA 默认参数
+const (
+	DefaultLeaseDuration     = 15 * time.Second
+	DefaultRenewInterval     = 5 * time.Second
+	DefaultEventPollInterval = time.Second
+	DefaultEventRetention    = 10 * time.Minute
+	DefaultNodeSyncInterval  = 5 * time.Second
+)
+
+// HAConfig [ha] 配置段：多实例部署时通过数据库租约选主
+//
+//	[ha]
+//	enabled = true
+//	instance_id = "superview-1"          # 默认使用主机名
+//	advertise_addr = "http://10.0.0.11:8081"  # 其他实例转发请求时使用的地址
+//	lease_duration = "15s"
+//	renew_interval = "5s"
+//	event_poll_interval = "1s"
+//	event_retention = "10m"
+//	node_sync_interval = "5s"
+type HAConfig struct {
+	Enabled           bool   `mapstructure:"enabled" toml:"enabled" json:"enabled"`
+	InstanceID        string `mapstructure:"instance_id" toml:"instance_id" json:"instance_id"`
+	AdvertiseAddr     string `mapstructure:"advertise_addr" toml:"advertise_addr" json:"advertise_addr"`
+	LeaseDuration     string `mapstructure:"lease_duration" toml:"lease_duration" json:"lease_duration"`
+	RenewInterval     string `mapstructure:"renew_interval" toml:"renew_interval" json:"renew_interval"`
+	EventPollInterval string `mapstructure:"event_poll_interval" toml:"event_poll_interval" json:"event_poll_interval"`
+	EventRetention    string `mapstructure:"event_retention" toml:"event_retention" json:"event_retention"`
+	NodeSyncInterval  string `mapstructure:"node_sync_interval" toml:"node_sync_interval" json:"node_sync_interval"`
+}
+
+// HASettings 解析后的 HA 参数
+type HASettings struct {
+	Enabled           bool
+	InstanceID        string
+	AdvertiseAddr     string
+	LeaseDuration     time.Duration
+	RenewInterval     time.Duration
+	EventPollInterval time.Duration
+	EventRetention    time.Duration
+	NodeSyncInterval  time.Duration
+}
+
+// Resolve 解析时长并填充默认值
+func (c HAConfig) Resolve() (*HASettings, error) {
+	settings := &HASettings{
+		Enabled:           c.Enabled,
+		InstanceID:        c.InstanceID,
+		AdvertiseAddr:     c.AdvertiseAddr,
+		LeaseDuration:     DefaultLeaseDuration,
+		RenewInterval:     DefaultRenewInterval,
+		EventPollInterval: DefaultEventPollInterval,
+		EventRetention:    DefaultEventRetention,
+		NodeSyncInterval:  DefaultNodeSyncInterval,
+	}
+
+	if settings.InstanceID == "" {
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			hostname = "superview"
+		}
+		settings.InstanceID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+
+	durations := []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"lease_duration", c.LeaseDuration, &settings.LeaseDuration},
+		{"renew_interval", c.RenewInterval, &settings.RenewInterval},
+		{"event_poll_interval", c.EventPollInterval, &settings.EventPollInterval},
+		{"event_retention", c.EventRetention, &settings.EventRetention},
+		{"node_sync_interval", c.NodeSyncInterval, &settings.NodeSyncInterval},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ha.%s %q: %v", d.name, d.value, err)
+		}
+		if parsed <= 0 {
+			return nil, fmt.Errorf("invalid ha.%s %q: must be positive", d.name, d.value)
+		}
+		*d.dest = parsed
+	}
+
+	// 续约间隔必须明显小于租约时长，否则网络抖动一次就会丢失主节点身份
+	if settings.RenewInterval*2 > settings.LeaseDuration {
+		return nil, fmt.Errorf("ha.renew_interval (%s) must be at most half of ha.lease_duration (%s)",
+			settings.RenewInterval, settings.LeaseDuration)
+	}
+	return settings, nil
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHAConfig_ResolveDefaults(t *testing.T) {
+	settings, err := HAConfig{}.Resolve()
+	require.NoError(t, err)
+	assert.False(t, settings.Enabled)
+	assert.NotEmpty(t, settings.InstanceID)
+	assert.Equal(t, DefaultLeaseDuration, settings.LeaseDuration)
+	assert.Equal(t, DefaultRenewInterval, settings.RenewInterval)
+	assert.Equal(t, DefaultEventPollInterval, settings.EventPollInterval)
+	assert.Equal(t, DefaultEventRetention, settings.EventRetention)
+	assert.Equal(t, DefaultNodeSyncInterval, settings.NodeSyncInterval)
+}
+
+func TestHAConfig_ResolveValidation(t *testing.T) {
+	_, err := HAConfig{LeaseDuration: "abc"}.Resolve()
+	assert.Error(t, err)
+
+	_, err = HAConfig{EventRetention: "-1m"}.Resolve()
+	assert.Error(t, err)
+
+	// 续约间隔超过租约时长的一半
+	_, err = HAConfig{LeaseDuration: "10s", RenewInterval: "6s"}.Resolve()
+	assert.Error(t, err)
+
+	settings, err := HAConfig{LeaseDuration: "30s", RenewInterval: "10s"}.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, settings.LeaseDuration)
+}
+
+func TestConfigLoader_HASection(t *testing.T) {
+	mainConfigPath := filepath.Join(t.TempDir(), "config.toml")
+	configContent := `
+[ha]
+enabled = true
+instance_id = "sv-1"
+advertise_addr = "http://10.0.0.11:8081"
+lease_duration = "20s"
+`
+	require.NoError(t, os.WriteFile(mainConfigPath, []byte(configContent), 0644))
+
+	cfg, err := NewConfigLoader(mainConfigPath, "").Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.HA.Enabled)
+	assert.Equal(t, "sv-1", cfg.HA.InstanceID)
+	assert.Equal(t, "http://10.0.0.11:8081", cfg.HA.AdvertiseAddr)
+	assert.Equal(t, "20s", cfg.HA.LeaseDuration)
+}
@@ -85,6 +85,9 @@ func (l *ConfigLoader) LoadMainConfig() (*Config, error) {
 	if err := toml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("%s: parse error: %w", l.mainConfigPath, err)
 	}
+	if err := cfg.normalizeDatabase(); err != nil {
+		return nil, fmt.Errorf("%s: %w", l.mainConfigPath, err)
+	}
 
 	// 处理新旧配置格式的兼容性
 	if cfg.Admin.Username != "" {
@@ -164,8 +167,9 @@ func (l *ConfigLoader) expandEnvVars(cfg *Config) {
 	cfg.AdminUsername = os.ExpandEnv(cfg.AdminUsername)
 	cfg.AdminPassword = os.ExpandEnv(cfg.AdminPassword)
 
-	// 展开数据库路径
+	// 展开数据库路径和 DSN（DSN 中通常包含从环境变量读取的密码）
 	cfg.Database = os.ExpandEnv(cfg.Database)
+	cfg.DatabaseSection.DSN = os.ExpandEnv(cfg.DatabaseSection.DSN)
 
 	// 展开活动日志路径
 	cfg.ActivityLog = os.ExpandEnv(cfg.ActivityLog)
@@ -282,3 +282,20 @@ func TestConfigLoader_MergeNodes_EmptyMainNodes(t *testing.T) {
 	assert.Len(t, merged, 1)
 	assert.Equal(t, "node-1", merged[0].Name)
 }
+
+// 示例配置与默认配置保持一致，且能被加载
+func TestConfigExampleMatchesDefault(t *testing.T) {
+	example, err := os.ReadFile("../../config/config.toml.example")
+	require.NoError(t, err)
+	current, err := os.ReadFile("../../config/config.toml")
+	require.NoError(t, err)
+	assert.Equal(t, string(current), string(example), "config/config.toml.example is out of date")
+
+	mainConfigPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(mainConfigPath, example, 0644))
+	cfg, err := NewConfigLoader(mainConfigPath, "").LoadWithDefaults()
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", cfg.ResolvedDatabase().Driver)
+	assert.Equal(t, []string{"node", "rule"}, cfg.Alerting.GroupBy)
+	assert.Len(t, cfg.Flapping.Windows, 2)
+}
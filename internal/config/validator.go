@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"superview/internal/labels"
 )
 
 // Validator 配置验证器接口
@@ -109,6 +111,10 @@ func (v *validator) ValidateNode(node NodeConfig) error {
 		errors = append(errors, "port must be between 1 and 65535")
 	}
 
+	if err := labels.Validate(node.Labels); err != nil {
+		errors = append(errors, err.Error())
+	}
+
 	if len(errors) > 0 {
 		return fmt.Errorf("%s", strings.Join(errors, ", "))
 	}
@@ -0,0 +1,38 @@
+package baseline
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ActivityLog struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 日志基本信息
+	Level    string `json:"level" gorm:"size:20;not null"`     // INFO, WARNING, ERROR
+	Message  string `json:"message" gorm:"type:text;not null"` // 日志消息
+	Action   string `json:"action" gorm:"size:50;index"`       // 操作类型：start, stop, restart, login, logout等
+	Resource string `json:"resource" gorm:"size:100"`          // 资源类型：process, node, user等
+	Target   string `json:"target" gorm:"size:200"`            // 目标对象：进程名、节点名、用户名等
+
+	// 用户信息
+	UserID   string `json:"user_id" gorm:"size:50;index"`   // 操作用户ID
+	Username string `json:"username" gorm:"size:100;index"` // 操作用户名
+
+	// 请求信息
+	IPAddress string `json:"ip_address" gorm:"size:45"`   // 客户端IP地址
+	UserAgent string `json:"user_agent" gorm:"type:text"` // 用户代理
+
+	// 额外信息
+	Details  string `json:"details" gorm:"type:text"`                // 详细信息（JSON格式）
+	Status   string `json:"status" gorm:"size:20;default:'success'"` // success, error, warning
+	Duration int64  `json:"duration"`                                // 操作耗时（毫秒）
+}
+
+func (ActivityLog) TableName() string {
+	return "activity_logs"
+}
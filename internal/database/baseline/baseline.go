@@ -0,0 +1,55 @@
+// Package baseline 保存 0001 基线迁移时的表结构快照。
+// 这里的结构体与引入版本化迁移之前的 models 一致（唯一索引的字符串列限定 size:191 以兼容 MySQL）且不再修改：之后的表结构变化都通过新的迁移完成，
+// 新建数据库按迁移顺序逐步得到当前结构
+package baseline
+
+// Models 基线迁移创建的表，顺序与旧版启动时的 AutoMigrate 一致。
+// SystemSettings 手动管理，避免 GORM 迁移问题（见 fixEmptyCategories）
+func Models() []interface{} {
+	return []interface{}{
+		&User{},
+		&ActivityLog{},
+		&Role{},
+		&Permission{},
+		&UserRole{},
+		&RolePermission{},
+		&NodeAccess{},
+		&Node{},
+		&AlertRule{},
+		&Alert{},
+		&NotificationChannel{},
+		&Notification{},
+		&AlertRuleNotificationChannel{},
+		&SystemMetric{},
+		&ProcessGroup{},
+		&ProcessGroupItem{},
+		&ProcessDependency{},
+		&ScheduledTask{},
+		&TaskExecution{},
+		&ProcessTemplate{},
+		&ProcessBackup{},
+		&ProcessMetrics{},
+		&Configuration{},
+		&EnvironmentVariable{},
+		&ConfigurationHistory{},
+		&ConfigurationBackup{},
+		&ConfigurationTemplate{},
+		&ConfigurationValidation{},
+		&ConfigurationAudit{},
+		&LogEntry{},
+		&LogAnalysisRule{},
+		&LogStatistics{},
+		&LogAlert{},
+		&LogFilter{},
+		&LogExport{},
+		&LogRetentionPolicy{},
+		&BackupRecord{},
+		&DataExportRecord{},
+		&DataImportRecord{},
+		&UserPreferences{},
+		&WebhookConfig{},
+		&WebhookLog{},
+		&DiscoveryTask{},
+		&DiscoveryResult{},
+	}
+}
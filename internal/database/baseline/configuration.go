@@ -0,0 +1,155 @@
+package baseline
+
+import (
+	"time"
+)
+
+// Configuration 系统配置模型
+type Configuration struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Key          string    `json:"key" gorm:"size:191;uniqueIndex;not null"`
+	Value        string    `json:"value" gorm:"type:text"`
+	DefaultValue string    `json:"default_value" gorm:"type:text"`
+	Description  string    `json:"description" gorm:"type:text"`
+	Category     string    `json:"category" gorm:"not null;index"`
+	Type         string    `json:"type" gorm:"not null;default:'string'"`
+	Scope        string    `json:"scope" gorm:"not null;default:'global'"`
+	NodeID       *uint     `json:"node_id" gorm:"index"`
+	UserID       *uint     `json:"user_id" gorm:"index"`
+	IsRequired   bool      `json:"is_required" gorm:"default:false"`
+	IsReadonly   bool      `json:"is_readonly" gorm:"default:false"`
+	IsSecret     bool      `json:"is_secret" gorm:"default:false"`
+	Validation   *string   `json:"validation" gorm:"type:text"` // JSON格式的验证规则
+	Options      *string   `json:"options" gorm:"type:text"`    // JSON格式的选项列表
+	Order        int       `json:"order" gorm:"default:0"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	CreatedBy    uint      `json:"created_by" gorm:"not null"`
+	UpdatedBy    *uint     `json:"updated_by"`
+
+	// 关联
+	User    *User `json:"user,omitempty" gorm:"foreignKey:CreatedBy"`
+	Updater *User `json:"updater,omitempty" gorm:"foreignKey:UpdatedBy"`
+	Node    *Node `json:"node,omitempty" gorm:"foreignKey:NodeID"`
+	Owner   *User `json:"owner,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// EnvironmentVariable 环境变量模型
+type EnvironmentVariable struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null;index"`
+	Value       string    `json:"value" gorm:"type:text"`
+	Description string    `json:"description" gorm:"type:text"`
+	NodeID      *uint     `json:"node_id" gorm:"index"`
+	ProcessName *string   `json:"process_name" gorm:"index"`
+	IsSecret    bool      `json:"is_secret" gorm:"default:false"`
+	IsActive    bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedBy   uint      `json:"created_by" gorm:"not null"`
+	UpdatedBy   *uint     `json:"updated_by"`
+
+	// 关联
+	User    *User `json:"user,omitempty" gorm:"foreignKey:CreatedBy"`
+	Updater *User `json:"updater,omitempty" gorm:"foreignKey:UpdatedBy"`
+	Node    *Node `json:"node,omitempty" gorm:"foreignKey:NodeID"`
+}
+
+// ConfigurationHistory 配置变更历史模型
+type ConfigurationHistory struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ConfigID   *uint     `json:"config_id" gorm:"index"`
+	EnvVarID   *uint     `json:"env_var_id" gorm:"index"`
+	ChangeType string    `json:"change_type" gorm:"not null;index"`
+	FieldName  string    `json:"field_name" gorm:"not null"`
+	OldValue   *string   `json:"old_value" gorm:"type:text"`
+	NewValue   *string   `json:"new_value" gorm:"type:text"`
+	Reason     string    `json:"reason" gorm:"type:text"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	CreatedBy  uint      `json:"created_by" gorm:"not null"`
+
+	// 关联
+	User          *User                `json:"user,omitempty" gorm:"foreignKey:CreatedBy"`
+	Configuration *Configuration       `json:"configuration,omitempty" gorm:"foreignKey:ConfigID"`
+	EnvVar        *EnvironmentVariable `json:"env_var,omitempty" gorm:"foreignKey:EnvVarID"`
+}
+
+// ConfigurationBackup 配置备份模型
+type ConfigurationBackup struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	BackupType  string    `json:"backup_type" gorm:"not null;index"` // full, partial, auto
+	Scope       string    `json:"scope" gorm:"not null"`             // global, node, user
+	NodeID      *uint     `json:"node_id" gorm:"index"`
+	UserID      *uint     `json:"user_id" gorm:"index"`
+	Data        string    `json:"data" gorm:"type:longtext;not null"` // JSON格式的配置数据
+	Checksum    string    `json:"checksum" gorm:"not null"`           // 数据校验和
+	Size        int64     `json:"size" gorm:"not null"`               // 备份大小（字节）
+	Version     string    `json:"version"`                            // 系统版本
+	IsAutomatic bool      `json:"is_automatic" gorm:"default:false"`  // 是否自动备份
+	CreatedAt   time.Time `json:"created_at"`
+	CreatedBy   uint      `json:"created_by" gorm:"not null"`
+
+	// 关联
+	User  *User `json:"user,omitempty" gorm:"foreignKey:CreatedBy"`
+	Node  *Node `json:"node,omitempty" gorm:"foreignKey:NodeID"`
+	Owner *User `json:"owner,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// ConfigurationTemplate 配置模板模型
+type ConfigurationTemplate struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"size:191;not null;uniqueIndex"`
+	Description string    `json:"description" gorm:"type:text"`
+	Category    string    `json:"category" gorm:"not null;index"`
+	Template    string    `json:"template" gorm:"type:longtext;not null"` // JSON格式的模板数据
+	IsPublic    bool      `json:"is_public" gorm:"default:false"`
+	UsageCount  int       `json:"usage_count" gorm:"default:0"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedBy   uint      `json:"created_by" gorm:"not null"`
+	UpdatedBy   *uint     `json:"updated_by"`
+
+	// 关联
+	User    *User `json:"user,omitempty" gorm:"foreignKey:CreatedBy"`
+	Updater *User `json:"updater,omitempty" gorm:"foreignKey:UpdatedBy"`
+}
+
+// ConfigurationValidation 配置验证规则模型
+type ConfigurationValidation struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ConfigKey      string    `json:"config_key" gorm:"not null;index"`
+	ValidatorType  string    `json:"validator_type" gorm:"not null"` // required, min, max, pattern, enum, custom
+	ValidatorValue string    `json:"validator_value" gorm:"type:text"`
+	ErrorMessage   string    `json:"error_message" gorm:"type:text"`
+	IsActive       bool      `json:"is_active" gorm:"default:true"`
+	Order          int       `json:"order" gorm:"default:0"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	CreatedBy      uint      `json:"created_by" gorm:"not null"`
+
+	// 关联
+	User *User `json:"user,omitempty" gorm:"foreignKey:CreatedBy"`
+}
+
+// ConfigurationAudit 配置审计日志模型
+type ConfigurationAudit struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Action       string    `json:"action" gorm:"not null;index"`        // view, create, update, delete, export, import
+	ResourceType string    `json:"resource_type" gorm:"not null;index"` // configuration, environment_variable, backup, template
+	ResourceID   uint      `json:"resource_id" gorm:"not null;index"`
+	ResourceName string    `json:"resource_name" gorm:"not null"`
+	Details      *string   `json:"details" gorm:"type:text"` // JSON格式的详细信息
+	IPAddress    string    `json:"ip_address"`
+	UserAgent    string    `json:"user_agent"`
+	Success      bool      `json:"success" gorm:"default:true"`
+	ErrorMessage *string   `json:"error_message" gorm:"type:text"`
+	CreatedAt    time.Time `json:"created_at"`
+	CreatedBy    uint      `json:"created_by" gorm:"not null"`
+
+	// 关联
+	User *User `json:"user,omitempty" gorm:"foreignKey:CreatedBy"`
+}
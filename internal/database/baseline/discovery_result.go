@@ -0,0 +1,29 @@
+package baseline
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DiscoveryResult represents the outcome of probing a single IP address.
+// Each result is linked to a parent DiscoveryTask.
+type DiscoveryResult struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index:idx_discovery_result_deleted_at" json:"-"`
+
+	TaskID uint   `gorm:"index:idx_discovery_result_task_id;not null" json:"task_id"`
+	IP     string `gorm:"size:50;not null" json:"ip"`
+	Port   int    `gorm:"not null;check:port > 0 AND port <= 65535" json:"port"`
+
+	Status string `gorm:"size:20;not null" json:"status"`
+	// success, timeout, connection_refused, auth_failed, error
+
+	NodeID   *uint  `json:"node_id,omitempty"`                   // If registered
+	NodeName string `gorm:"size:100" json:"node_name,omitempty"` // Generated name
+	Version  string `gorm:"size:50" json:"version,omitempty"`    // Supervisor version
+	ErrorMsg string `gorm:"size:500" json:"error_msg,omitempty"` // Error details
+
+	Duration int64 `json:"duration_ms"` // Probe duration in milliseconds
+}
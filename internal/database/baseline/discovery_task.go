@@ -0,0 +1,34 @@
+package baseline
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DiscoveryTask represents a network discovery scan operation.
+// It tracks the progress and results of scanning a CIDR range for Supervisor nodes.
+type DiscoveryTask struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `gorm:"not null;index:idx_discovery_task_created_at" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index:idx_discovery_task_deleted_at" json:"-"`
+
+	CIDR     string `gorm:"size:50;not null" json:"cidr"`
+	Port     int    `gorm:"not null;check:port > 0 AND port <= 65535" json:"port"`
+	Username string `gorm:"size:50" json:"username"`
+	// Password NOT stored - security requirement
+
+	Status string `gorm:"size:20;not null;default:'pending';index:idx_discovery_task_status" json:"status"`
+
+	TotalIPs   int `gorm:"not null;default:0" json:"total_ips"`
+	ScannedIPs int `gorm:"not null;default:0" json:"scanned_ips"`
+	FoundNodes int `gorm:"not null;default:0" json:"found_nodes"`
+	FailedIPs  int `gorm:"not null;default:0" json:"failed_ips"`
+
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ErrorMsg    string     `gorm:"size:500" json:"error_msg,omitempty"`
+
+	CreatedBy string `gorm:"size:100;not null" json:"created_by"`
+}
@@ -4,15 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"superview/internal/models"
 	"go.uber.org/zap"
-	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -27,6 +24,8 @@ var (
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
+	Driver             string        // 数据库驱动：sqlite（默认）、postgres、mysql
+	DSN                string        // 连接串；SQLite 为文件路径，为空时使用 data/superview.db
 	MaxOpenConns       int           // 最大打开连接数
 	MaxIdleConns       int           // 最大空闲连接数
 	ConnMaxLifetime    time.Duration // 连接最大生命周期
@@ -35,6 +34,7 @@ type DatabaseConfig struct {
 	HealthCheckEnabled bool          // 是否启用健康检查
 	HealthCheckInterval time.Duration // 健康检查间隔
 	TransactionTimeout time.Duration // 事务超时时间
+	SkipMigrations     bool          // 跳过启动时的自动迁移（migrate 命令自行管理迁移）
 }
 
 // GetDefaultConfig 获取默认数据库配置
@@ -52,23 +52,35 @@ func GetDefaultConfig() *DatabaseConfig {
 	}
 }
 
+// GetDefaultConfigForDriver 获取指定驱动的默认配置
+// PostgreSQL/MySQL 是服务端数据库，可以使用真正的连接池
+func GetDefaultConfigForDriver(driver string) (*DatabaseConfig, error) {
+	dialect, err := ParseDialect(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	config := GetDefaultConfig()
+	config.Driver = string(dialect)
+	if dialect != DialectSQLite {
+		config.MaxOpenConns = 25
+		config.MaxIdleConns = 5
+		config.ConnMaxLifetime = 5 * time.Minute
+		config.ConnMaxIdleTime = 1 * time.Minute
+	}
+	return config, nil
+}
+
 func InitDB() error {
 	return InitDBWithConfig(GetDefaultConfig())
 }
 
 func InitDBWithConfig(config *DatabaseConfig) error {
-	// 获取当前工作目录作为项目根目录
-	projectRoot, err := os.Getwd()
+	dialector, err := openDialector(config)
 	if err != nil {
-		return fmt.Errorf("failed to get working directory: %v", err)
-	}
-
-	// 确保数据库目录存在
-	dataDir := filepath.Join(projectRoot, "data")
-	zap.L().Info("Database directory", zap.String("path", dataDir))
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
-		return fmt.Errorf("failed to create data directory: %v", err)
+		return err
 	}
+	zap.L().Info("Connecting database", zap.String("driver", dialector.Name()))
 
 	// 配置GORM日志
 	gormConfig := &gorm.Config{
@@ -79,10 +91,7 @@ func InitDBWithConfig(config *DatabaseConfig) error {
 		PrepareStmt: true, // 启用预编译语句缓存
 	}
 
-	// 连接SQLite数据库
-	dbPath := filepath.Join(dataDir, "superview.db")
-	dsn := fmt.Sprintf("%s?cache=shared&mode=rwc&_journal_mode=WAL&_synchronous=NORMAL&_foreign_keys=1", dbPath)
-	db, err := gorm.Open(sqlite.Open(dsn), gormConfig)
+	db, err := gorm.Open(dialector, gormConfig)
 	if err != nil {
 		return fmt.Errorf("failed to connect database: %v", err)
 	}
@@ -106,65 +115,17 @@ func InitDBWithConfig(config *DatabaseConfig) error {
 		return fmt.Errorf("failed to ping database: %v", err)
 	}
 
-	// 在迁移前修复 system_settings 表的空 category 字段
-	if err := fixEmptyCategories(db); err != nil {
-		zap.L().Warn("Failed to fix empty categories", zap.Error(err))
-	}
-
-	// 自动迁移数据库模式（SystemSettings 单独处理，避免 GORM 迁移问题）
-	err = db.AutoMigrate(
-		&models.User{},
-		&models.ActivityLog{},
-		&models.Role{},
-		&models.Permission{},
-		&models.UserRole{},
-		&models.RolePermission{},
-		&models.NodeAccess{},
-		&models.AlertRule{},
-		&models.Alert{},
-		&models.NotificationChannel{},
-		&models.Notification{},
-		&models.AlertRuleNotificationChannel{},
-		&models.SystemMetric{},
-		&models.ProcessGroup{},
-		&models.ProcessGroupItem{},
-		&models.ProcessDependency{},
-		&models.ScheduledTask{},
-		&models.TaskExecution{},
-		&models.ProcessTemplate{},
-		&models.ProcessBackup{},
-		&models.ProcessMetrics{},
-		&models.Configuration{},
-		&models.EnvironmentVariable{},
-		&models.ConfigurationHistory{},
-		&models.ConfigurationBackup{},
-		&models.ConfigurationTemplate{},
-		&models.ConfigurationValidation{},
-		&models.ConfigurationAudit{},
-		&models.LogEntry{},
-		&models.LogAnalysisRule{},
-		&models.LogStatistics{},
-		&models.LogAlert{},
-		&models.LogFilter{},
-		&models.LogExport{},
-		&models.LogRetentionPolicy{},
-		&models.BackupRecord{},
-		&models.DataExportRecord{},
-		&models.DataImportRecord{},
-		// &models.SystemSettings{}, // 手动管理，避免 GORM 迁移问题
-		&models.UserPreferences{},
-		&models.WebhookConfig{},
-		&models.WebhookLog{},
-		&models.DiscoveryTask{},
-		&models.DiscoveryResult{},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to migrate models: %v", err)
-	}
-
-	// 执行自定义迁移
-	if err := runCustomMigrations(db); err != nil {
-		return fmt.Errorf("failed to run custom migrations: %v", err)
+	// 执行版本化迁移（拒绝在包含未知迁移的更新版本数据库上运行）
+	if !config.SkipMigrations {
+		applied, err := MigrateUp(db)
+		if err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+		if len(applied) > 0 {
+			zap.L().Info("Database migrations applied",
+				zap.Int("count", len(applied)),
+				zap.Uint("schema_version", LatestMigrationVersion()))
+		}
 	}
 
 	DB = db
@@ -175,6 +136,7 @@ func InitDBWithConfig(config *DatabaseConfig) error {
 	}
 
 	zap.L().Info("Database initialized successfully",
+		zap.String("driver", string(DialectOf(db))),
 		zap.Int("max_open_conns", config.MaxOpenConns),
 		zap.Int("max_idle_conns", config.MaxIdleConns))
 	return nil
@@ -327,37 +289,31 @@ func Close() error {
 
 // fixEmptyCategories 手动管理 system_settings 表，避免 GORM AutoMigrate 的问题
 func fixEmptyCategories(db *gorm.DB) error {
-	// 检查表是否存在
-	var count int64
-	if err := db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='system_settings'").Scan(&count).Error; err != nil {
-		return err
-	}
+	dialect := DialectOf(db)
 
 	// 表不存在，创建新表
-	if count == 0 {
-		zap.L().Info("Creating system_settings table")
-		createSQL := `CREATE TABLE system_settings (
-			id TEXT PRIMARY KEY,
-			category TEXT DEFAULT 'general',
-			key TEXT NOT NULL,
-			value TEXT,
-			value_type TEXT DEFAULT 'string',
-			description TEXT,
-			is_public NUMERIC DEFAULT false,
-			updated_by TEXT,
-			created_at DATETIME,
-			updated_at DATETIME,
-			deleted_at DATETIME
-		)`
-		if err := db.Exec(createSQL).Error; err != nil {
+	if !db.Migrator().HasTable("system_settings") {
+		zap.L().Info("Creating system_settings table", zap.String("dialect", string(dialect)))
+		if err := db.Exec(systemSettingsTableSQL(dialect)).Error; err != nil {
 			return err
 		}
-		db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_category_key ON system_settings(category, key)")
-		db.Exec("CREATE INDEX IF NOT EXISTS idx_system_settings_deleted_at ON system_settings(deleted_at)")
+		for _, indexSQL := range systemSettingsIndexSQL(dialect) {
+			db.Exec(indexSQL)
+		}
 		zap.L().Info("Created system_settings table")
 		return nil
 	}
 
+	if dialect != DialectSQLite {
+		// 服务端数据库可以直接删除外键约束，无需重建表
+		if db.Migrator().HasConstraint(&models.SystemSettings{}, "Updater") {
+			if err := db.Migrator().DropConstraint(&models.SystemSettings{}, "Updater"); err != nil {
+				return err
+			}
+		}
+		return db.Exec("UPDATE system_settings SET category = 'general' WHERE category IS NULL OR category = ''").Error
+	}
+
 	// 表存在，检查是否需要重建（有外键约束）
 	var tableSQL string
 	if err := db.Raw("SELECT sql FROM sqlite_master WHERE type='table' AND name='system_settings'").Scan(&tableSQL).Error; err != nil {
@@ -436,27 +392,36 @@ func fixEmptyCategories(db *gorm.DB) error {
 
 // runCustomMigrations 执行自定义数据库迁移
 func runCustomMigrations(db *gorm.DB) error {
+	dialect := DialectOf(db)
+
 	// 删除旧的全局唯一索引（如果存在）
-	if err := db.Exec("DROP INDEX IF EXISTS idx_alert_unique").Error; err != nil {
-		zap.L().Warn("Failed to drop old index idx_alert_unique", zap.Error(err))
+	if db.Migrator().HasIndex(&models.Alert{}, "idx_alert_unique") {
+		if err := db.Migrator().DropIndex(&models.Alert{}, "idx_alert_unique"); err != nil {
+			zap.L().Warn("Failed to drop old index idx_alert_unique", zap.Error(err))
+		}
 	}
 	
 	// 创建条件唯一索引：仅对 status='active' 的记录生效
 	// 这允许同一个 (rule_id, node_name, process_name) 组合在不同状态下存在多条记录
 	// 但在 active 状态下只能有一条记录
-	createIndexSQL := `
-		CREATE UNIQUE INDEX IF NOT EXISTS idx_alert_unique_active 
-		ON alerts(rule_id, node_name, process_name, status) 
-		WHERE status = 'active'
-	`
-	if err := db.Exec(createIndexSQL).Error; err != nil {
-		return fmt.Errorf("failed to create conditional unique index: %v", err)
+	if !db.Migrator().HasIndex(&models.Alert{}, "idx_alert_unique_active") {
+		columnSQL, indexSQL := alertActiveUniqueIndexSQL(dialect)
+		if columnSQL != "" && !db.Migrator().HasColumn(&models.Alert{}, "active_marker") {
+			if err := db.Exec(columnSQL).Error; err != nil {
+				return fmt.Errorf("failed to add active_marker column: %v", err)
+			}
+		}
+		if err := db.Exec(indexSQL).Error; err != nil {
+			return fmt.Errorf("failed to create conditional unique index: %v", err)
+		}
 	}
 	
 	// 修复 system_settings 表的外键约束问题
 	// SQLite 不支持直接删除外键，需要重建表
-	if err := fixSystemSettingsForeignKey(db); err != nil {
-		zap.L().Warn("Failed to fix system_settings foreign key", zap.Error(err))
+	if dialect == DialectSQLite {
+		if err := fixSystemSettingsForeignKey(db); err != nil {
+			zap.L().Warn("Failed to fix system_settings foreign key", zap.Error(err))
+		}
 	}
 	
 	zap.L().Info("Custom migrations completed successfully")
@@ -0,0 +1,215 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Dialect 支持的数据库方言
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+	DialectMySQL    Dialect = "mysql"
+)
+
+// sqliteDSNParams SQLite 连接参数：WAL 模式、外键约束
+const sqliteDSNParams = "cache=shared&mode=rwc&_journal_mode=WAL&_synchronous=NORMAL&_foreign_keys=1"
+
+// ParseDialect 解析配置中的驱动名称
+func ParseDialect(driver string) (Dialect, error) {
+	switch strings.ToLower(strings.TrimSpace(driver)) {
+	case "", "sqlite", "sqlite3":
+		return DialectSQLite, nil
+	case "postgres", "postgresql", "pgx":
+		return DialectPostgres, nil
+	case "mysql", "mariadb":
+		return DialectMySQL, nil
+	default:
+		return "", fmt.Errorf("unsupported database driver %q (supported: sqlite, postgres, mysql)", driver)
+	}
+}
+
+// DialectOf 获取已打开连接的方言
+func DialectOf(db *gorm.DB) Dialect {
+	if db == nil || db.Dialector == nil {
+		return DialectSQLite
+	}
+	switch db.Dialector.Name() {
+	case "postgres":
+		return DialectPostgres
+	case "mysql":
+		return DialectMySQL
+	default:
+		return DialectSQLite
+	}
+}
+
+// Quote 按方言引用标识符（key 等列名在 MySQL 中是保留字）
+func (d Dialect) Quote(identifier string) string {
+	if d == DialectMySQL {
+		return "`" + identifier + "`"
+	}
+	return `"` + identifier + `"`
+}
+
+// openDialector 根据配置创建 GORM Dialector
+func openDialector(config *DatabaseConfig) (gorm.Dialector, error) {
+	dialect, err := ParseDialect(config.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	switch dialect {
+	case DialectPostgres:
+		if config.DSN == "" {
+			return nil, fmt.Errorf("database dsn is required for driver postgres")
+		}
+		return postgres.Open(config.DSN), nil
+	case DialectMySQL:
+		if config.DSN == "" {
+			return nil, fmt.Errorf("database dsn is required for driver mysql")
+		}
+		return mysql.Open(mysqlDSN(config.DSN)), nil
+	default:
+		dsn, err := sqliteDSN(config.DSN)
+		if err != nil {
+			return nil, err
+		}
+		return sqlite.Open(dsn), nil
+	}
+}
+
+// sqliteDSN 生成 SQLite DSN，未指定路径时使用工作目录下的 data/superview.db
+func sqliteDSN(path string) (string, error) {
+	if path == ":memory:" || strings.HasPrefix(path, "file:") {
+		return path, nil
+	}
+
+	if path == "" {
+		projectRoot, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get working directory: %v", err)
+		}
+		path = filepath.Join(projectRoot, "data", "superview.db")
+	}
+
+	if strings.Contains(path, "?") {
+		return path, nil
+	}
+
+	// 确保数据库目录存在
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %v", err)
+	}
+	return fmt.Sprintf("%s?%s", path, sqliteDSNParams), nil
+}
+
+// mysqlDSN 补充 MySQL 必需的连接参数（time.Time 解析、utf8mb4）
+func mysqlDSN(dsn string) string {
+	params := map[string]string{
+		"parseTime": "true",
+		"charset":   "utf8mb4",
+		"loc":       "Local",
+	}
+	for _, key := range []string{"parseTime", "charset", "loc"} {
+		if strings.Contains(dsn, key+"=") {
+			continue
+		}
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + key + "=" + params[key]
+	}
+	return dsn
+}
+
+// systemSettingsTableSQL system_settings 建表语句（不含指向 users 的外键）
+func systemSettingsTableSQL(d Dialect) string {
+	key := d.Quote("key")
+	switch d {
+	case DialectPostgres:
+		return `CREATE TABLE system_settings (
+			id VARCHAR(36) PRIMARY KEY,
+			category VARCHAR(50) DEFAULT 'general',
+			` + key + ` VARCHAR(100) NOT NULL,
+			value TEXT,
+			value_type VARCHAR(20) DEFAULT 'string',
+			description VARCHAR(500),
+			is_public BOOLEAN DEFAULT false,
+			updated_by VARCHAR(50),
+			created_at TIMESTAMPTZ,
+			updated_at TIMESTAMPTZ,
+			deleted_at TIMESTAMPTZ
+		)`
+	case DialectMySQL:
+		return `CREATE TABLE system_settings (
+			id VARCHAR(36) PRIMARY KEY,
+			category VARCHAR(50) DEFAULT 'general',
+			` + key + ` VARCHAR(100) NOT NULL,
+			value TEXT,
+			value_type VARCHAR(20) DEFAULT 'string',
+			description VARCHAR(500),
+			is_public BOOLEAN DEFAULT false,
+			updated_by VARCHAR(50),
+			created_at DATETIME(3),
+			updated_at DATETIME(3),
+			deleted_at DATETIME(3)
+		) DEFAULT CHARSET=utf8mb4`
+	default:
+		return `CREATE TABLE system_settings (
+			id TEXT PRIMARY KEY,
+			category TEXT DEFAULT 'general',
+			key TEXT NOT NULL,
+			value TEXT,
+			value_type TEXT DEFAULT 'string',
+			description TEXT,
+			is_public NUMERIC DEFAULT false,
+			updated_by TEXT,
+			created_at DATETIME,
+			updated_at DATETIME,
+			deleted_at DATETIME
+		)`
+	}
+}
+
+// systemSettingsIndexSQL system_settings 索引语句
+func systemSettingsIndexSQL(d Dialect) []string {
+	key := d.Quote("key")
+	if d == DialectMySQL {
+		// MySQL 不支持 CREATE INDEX IF NOT EXISTS，建表后立即执行，无需判断
+		return []string{
+			"CREATE UNIQUE INDEX idx_category_key ON system_settings(category, " + key + ")",
+			"CREATE INDEX idx_system_settings_deleted_at ON system_settings(deleted_at)",
+		}
+	}
+	return []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_category_key ON system_settings(category, " + key + ")",
+		"CREATE INDEX IF NOT EXISTS idx_system_settings_deleted_at ON system_settings(deleted_at)",
+	}
+}
+
+// alertActiveUniqueIndexSQL 保证同一 (rule_id, node_name, process_name) 只有一条 active 告警
+// SQLite/PostgreSQL 使用部分索引；MySQL 不支持部分索引，改用虚拟列 active_marker
+// （非 active 时为 NULL，唯一索引允许多个 NULL），columnSQL 为添加该列的语句
+func alertActiveUniqueIndexSQL(d Dialect) (columnSQL, indexSQL string) {
+	if d == DialectMySQL {
+		return "ALTER TABLE alerts ADD COLUMN active_marker TINYINT AS (IF(status = 'active', 1, NULL)) VIRTUAL",
+			"CREATE UNIQUE INDEX idx_alert_unique_active ON alerts(rule_id, node_name, process_name, active_marker)"
+	}
+	return "", `
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_alert_unique_active
+		ON alerts(rule_id, node_name, process_name, status)
+		WHERE status = 'active'
+	`
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"superview/internal/models"
+)
+
+func TestParseDialect(t *testing.T) {
+	tests := map[string]Dialect{
+		"":           DialectSQLite,
+		"sqlite3":    DialectSQLite,
+		"Postgres":   DialectPostgres,
+		"postgresql": DialectPostgres,
+		"pgx":        DialectPostgres,
+		"mysql":      DialectMySQL,
+		"mariadb":    DialectMySQL,
+	}
+	for driver, expected := range tests {
+		dialect, err := ParseDialect(driver)
+		require.NoError(t, err, driver)
+		assert.Equal(t, expected, dialect, driver)
+	}
+
+	_, err := ParseDialect("oracle")
+	assert.Error(t, err)
+}
+
+func TestDialectOf(t *testing.T) {
+	pg, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=superview"}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+	assert.Equal(t, DialectPostgres, DialectOf(pg))
+
+	my, err := gorm.Open(mysql.New(mysql.Config{DSN: "u:p@tcp(localhost:3306)/superview", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+	assert.Equal(t, DialectMySQL, DialectOf(my))
+
+	lite, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	assert.Equal(t, DialectSQLite, DialectOf(lite))
+}
+
+func TestSystemSettingsSQL_QuotesKeyColumn(t *testing.T) {
+	assert.Contains(t, systemSettingsTableSQL(DialectMySQL), "`key` VARCHAR(100) NOT NULL")
+	assert.Contains(t, systemSettingsTableSQL(DialectPostgres), `"key" VARCHAR(100) NOT NULL`)
+	assert.Contains(t, systemSettingsTableSQL(DialectPostgres), "TIMESTAMPTZ")
+	assert.NotContains(t, systemSettingsTableSQL(DialectPostgres), "DATETIME")
+
+	for _, stmt := range systemSettingsIndexSQL(DialectMySQL) {
+		assert.NotContains(t, stmt, "IF NOT EXISTS", "MySQL does not support CREATE INDEX IF NOT EXISTS")
+	}
+	assert.Contains(t, systemSettingsIndexSQL(DialectPostgres)[0], `(category, "key")`)
+}
+
+func TestAlertActiveUniqueIndexSQL(t *testing.T) {
+	column, index := alertActiveUniqueIndexSQL(DialectPostgres)
+	assert.Empty(t, column)
+	assert.Contains(t, index, "WHERE status = 'active'")
+
+	column, index = alertActiveUniqueIndexSQL(DialectMySQL)
+	assert.Contains(t, column, "active_marker")
+	assert.Contains(t, index, "active_marker")
+	assert.NotContains(t, index, "WHERE", "MySQL has no partial indexes")
+}
+
+func TestMySQLDSN(t *testing.T) {
+	dsn := mysqlDSN("u:p@tcp(db:3306)/superview")
+	assert.Equal(t, "u:p@tcp(db:3306)/superview?parseTime=true&charset=utf8mb4&loc=Local", dsn)
+
+	dsn = mysqlDSN("u:p@tcp(db:3306)/superview?parseTime=false&charset=utf8")
+	assert.Equal(t, "u:p@tcp(db:3306)/superview?parseTime=false&charset=utf8&loc=Local", dsn)
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "superview.db")
+	dsn, err := sqliteDSN(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(dsn, path+"?"))
+	assert.Contains(t, dsn, "_journal_mode=WAL")
+	assert.DirExists(t, filepath.Dir(path))
+
+	dsn, err = sqliteDSN(":memory:")
+	require.NoError(t, err)
+	assert.Equal(t, ":memory:", dsn)
+}
+
+func TestGetDefaultConfigForDriver(t *testing.T) {
+	sqliteConfig, err := GetDefaultConfigForDriver("sqlite")
+	require.NoError(t, err)
+	assert.Equal(t, 1, sqliteConfig.MaxOpenConns)
+
+	pgConfig, err := GetDefaultConfigForDriver("postgres")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres", pgConfig.Driver)
+	assert.Greater(t, pgConfig.MaxOpenConns, 1)
+
+	_, err = openDialector(&DatabaseConfig{Driver: "postgres"})
+	assert.Error(t, err, "postgres requires a DSN")
+}
+
+func TestCustomMigrations_SQLite(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	require.NoError(t, fixEmptyCategories(db))
+	require.NoError(t, db.AutoMigrate(&models.Alert{}))
+	require.NoError(t, runCustomMigrations(db))
+	// 重复执行必须是幂等的
+	require.NoError(t, runCustomMigrations(db))
+
+	assert.True(t, db.Migrator().HasTable("system_settings"))
+	assert.True(t, db.Migrator().HasIndex(&models.Alert{}, "idx_alert_unique_active"))
+
+	require.NoError(t, db.Create(&models.SystemSettings{ID: "1", Key: "k", Value: "v"}).Error)
+	var setting models.SystemSettings
+	require.NoError(t, db.Where(&models.SystemSettings{Key: "k"}).First(&setting).Error)
+	assert.Equal(t, "general", setting.Category)
+}
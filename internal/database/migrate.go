@@ -0,0 +1,208 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ErrSchemaTooNew 数据库中存在当前版本未知的迁移（由更新版本的程序执行），拒绝启动
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+// Migration 版本化的数据库迁移
+// 迁移不在事务中执行：SQLite 的 PRAGMA 和 MySQL 的 DDL 都不支持事务，Up/Down 需要自行保证幂等
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(db *gorm.DB) error
+	Down    func(db *gorm.DB) error // nil 表示不可回滚
+}
+
+// SchemaMigration 已执行迁移的记录
+type SchemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `gorm:"size:191;not null" json:"name"`
+	AppliedAt time.Time `gorm:"not null" json:"applied_at"`
+}
+
+// TableName 指定表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationState 迁移状态（用于 migrate status）
+type MigrationState struct {
+	Version   uint       `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Unknown   bool       `json:"unknown"` // 数据库中存在但当前程序未定义
+}
+
+var registeredMigrations []Migration
+
+// registerMigration 注册迁移，每个 migration_XXXX_*.go 文件在 init 中调用
+func registerMigration(m Migration) {
+	for _, existing := range registeredMigrations {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("duplicate migration version %d (%s, %s)", m.Version, existing.Name, m.Name))
+		}
+	}
+	registeredMigrations = append(registeredMigrations, m)
+	sort.Slice(registeredMigrations, func(i, j int) bool {
+		return registeredMigrations[i].Version < registeredMigrations[j].Version
+	})
+}
+
+// Migrations 获取按版本排序的全部迁移
+func Migrations() []Migration {
+	result := make([]Migration, len(registeredMigrations))
+	copy(result, registeredMigrations)
+	return result
+}
+
+// LatestMigrationVersion 当前程序已知的最新迁移版本
+func LatestMigrationVersion() uint {
+	if len(registeredMigrations) == 0 {
+		return 0
+	}
+	return registeredMigrations[len(registeredMigrations)-1].Version
+}
+
+// appliedMigrations 读取已执行的迁移（按版本升序）
+func appliedMigrations(db *gorm.DB) ([]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %v", err)
+	}
+	var applied []SchemaMigration
+	if err := db.Order("version ASC").Find(&applied).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+	return applied, nil
+}
+
+// CheckSchemaVersion 检查数据库是否包含当前程序未知的迁移
+func CheckSchemaVersion(db *gorm.DB) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[uint]bool, len(registeredMigrations))
+	for _, m := range registeredMigrations {
+		known[m.Version] = true
+	}
+	for _, a := range applied {
+		if !known[a.Version] {
+			return fmt.Errorf("%w: migration %d (%s) is not known to this binary (latest known: %d)",
+				ErrSchemaTooNew, a.Version, a.Name, LatestMigrationVersion())
+		}
+	}
+	return nil
+}
+
+// MigrateUp 按版本顺序执行所有未执行的迁移，返回本次执行的迁移
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	if err := CheckSchemaVersion(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[uint]bool, len(applied))
+	for _, a := range applied {
+		done[a.Version] = true
+	}
+
+	var ran []Migration
+	for _, m := range registeredMigrations {
+		if done[m.Version] {
+			continue
+		}
+		zap.L().Info("Applying migration", zap.Uint("version", m.Version), zap.String("name", m.Name))
+		if err := m.Up(db); err != nil {
+			return ran, fmt.Errorf("migration %d (%s) failed: %v", m.Version, m.Name, err)
+		}
+		record := SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}
+		if err := db.Create(&record).Error; err != nil {
+			return ran, fmt.Errorf("failed to record migration %d: %v", m.Version, err)
+		}
+		ran = append(ran, m)
+	}
+	return ran, nil
+}
+
+// MigrateDown 回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("steps must be positive")
+	}
+	if err := CheckSchemaVersion(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]Migration, len(registeredMigrations))
+	for _, m := range registeredMigrations {
+		byVersion[m.Version] = m
+	}
+
+	var rolledBack []Migration
+	for i := len(applied) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		m := byVersion[applied[i].Version]
+		if m.Down == nil {
+			return rolledBack, fmt.Errorf("migration %d (%s) is irreversible", m.Version, m.Name)
+		}
+		zap.L().Info("Rolling back migration", zap.Uint("version", m.Version), zap.String("name", m.Name))
+		if err := m.Down(db); err != nil {
+			return rolledBack, fmt.Errorf("rollback of migration %d (%s) failed: %v", m.Version, m.Name, err)
+		}
+		if err := db.Delete(&SchemaMigration{}, "version = ?", m.Version).Error; err != nil {
+			return rolledBack, fmt.Errorf("failed to remove migration record %d: %v", m.Version, err)
+		}
+		rolledBack = append(rolledBack, m)
+	}
+	return rolledBack, nil
+}
+
+// MigrationStatus 获取所有迁移的执行状态
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	appliedByVersion := make(map[uint]SchemaMigration, len(applied))
+	for _, a := range applied {
+		appliedByVersion[a.Version] = a
+	}
+
+	states := make([]MigrationState, 0, len(registeredMigrations))
+	for _, m := range registeredMigrations {
+		state := MigrationState{Version: m.Version, Name: m.Name}
+		if a, ok := appliedByVersion[m.Version]; ok {
+			appliedAt := a.AppliedAt
+			state.Applied = true
+			state.AppliedAt = &appliedAt
+			delete(appliedByVersion, m.Version)
+		}
+		states = append(states, state)
+	}
+	// 数据库中存在但程序未定义的迁移（更新版本的程序执行过）
+	for _, a := range applied {
+		if _, ok := appliedByVersion[a.Version]; ok {
+			appliedAt := a.AppliedAt
+			states = append(states, MigrationState{
+				Version: a.Version, Name: a.Name, Applied: true, AppliedAt: &appliedAt, Unknown: true,
+			})
+		}
+	}
+	return states, nil
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"superview/internal/models"
+)
+
+func openMigrationTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")), &gorm.Config{})
+	require.NoError(t, err)
+	return db
+}
+
+func TestMigrateUpDownStatus(t *testing.T) {
+	db := openMigrationTestDB(t)
+
+	applied, err := MigrateUp(db)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(Migrations()))
+	assert.True(t, db.Migrator().HasTable(&models.APIToken{}))
+	assert.True(t, db.Migrator().HasTable(&models.Node{}))
+	assert.True(t, db.Migrator().HasTable("system_settings"))
+
+	// 再次执行为空操作
+	applied, err = MigrateUp(db)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	states, err := MigrationStatus(db)
+	require.NoError(t, err)
+	for _, s := range states {
+		assert.True(t, s.Applied, "migration %d", s.Version)
+	}
+
+	// 回滚到基线
+	reversible := len(Migrations()) - 1
+	rolledBack, err := MigrateDown(db, reversible)
+	require.NoError(t, err)
+	require.Len(t, rolledBack, reversible)
+	assert.Equal(t, LatestMigrationVersion(), rolledBack[0].Version)
+	assert.False(t, db.Migrator().HasTable(&models.PasswordHistory{}))
+	assert.False(t, db.Migrator().HasTable(&models.APIToken{}))
+	assert.False(t, db.Migrator().HasColumn(&models.User{}, "must_change_password"))
+
+	states, err = MigrationStatus(db)
+	require.NoError(t, err)
+	pending := 0
+	for _, s := range states {
+		if !s.Applied {
+			pending++
+		}
+	}
+	assert.Equal(t, reversible, pending)
+
+	// 基线不可回滚
+	_, err = MigrateDown(db, 1)
+	assert.Error(t, err)
+
+	applied, err = MigrateUp(db)
+	require.NoError(t, err)
+	assert.Len(t, applied, reversible)
+	assert.True(t, db.Migrator().HasTable(&models.APIToken{}))
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	db := openMigrationTestDB(t)
+
+	_, err := MigrateUp(db)
+	require.NoError(t, err)
+
+	future := SchemaMigration{Version: LatestMigrationVersion() + 100, Name: "from_the_future", AppliedAt: time.Now()}
+	require.NoError(t, db.Create(&future).Error)
+
+	err = CheckSchemaVersion(db)
+	assert.True(t, errors.Is(err, ErrSchemaTooNew))
+
+	_, err = MigrateUp(db)
+	assert.True(t, errors.Is(err, ErrSchemaTooNew))
+
+	states, err := MigrationStatus(db)
+	require.NoError(t, err)
+	last := states[len(states)-1]
+	assert.True(t, last.Unknown)
+	assert.Equal(t, future.Version, last.Version)
+}
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	migrations := Migrations()
+	require.NotEmpty(t, migrations)
+	for i := 1; i < len(migrations); i++ {
+		assert.Less(t, migrations[i-1].Version, migrations[i].Version)
+	}
+}
+
+// 基线使用冻结的表结构，之后加入的列由各自的迁移添加
+func TestBaselineSchemaIsFrozen(t *testing.T) {
+	db := openMigrationTestDB(t)
+	require.NoError(t, Migrations()[0].Up(db))
+	assert.True(t, db.Migrator().HasTable(&models.Node{}))
+	assert.False(t, db.Migrator().HasColumn(&models.User{}, "must_change_password"))
+	assert.False(t, db.Migrator().HasColumn(&models.Node{}, "maintenance"))
+	assert.False(t, db.Migrator().HasColumn(&models.Node{}, "source"))
+	assert.False(t, db.Migrator().HasColumn(&models.Alert{}, "suppressed_by"))
+	assert.False(t, db.Migrator().HasColumn(&models.AlertRule{}, "escalation_policy_id"))
+}
+
+// 每个迁移只创建当时的表结构，之后加入的列由后续迁移添加
+func TestMigrationsAreFrozen(t *testing.T) {
+	db := openMigrationTestDB(t)
+	upTo := func(version uint) {
+		for _, m := range Migrations() {
+			if m.Version > version {
+				return
+			}
+			require.NoError(t, m.Up(db), "migration %d", m.Version)
+		}
+	}
+
+	upTo(2)
+	assert.True(t, db.Migrator().HasTable(&models.APIToken{}))
+	assert.False(t, db.Migrator().HasColumn(&models.User{}, "must_change_password"))
+
+	db = openMigrationTestDB(t)
+	upTo(5)
+	assert.True(t, db.Migrator().HasColumn(&models.Node{}, "maintenance"))
+	assert.False(t, db.Migrator().HasColumn(&models.Node{}, "source"))
+	assert.False(t, db.Migrator().HasColumn(&models.Node{}, "labels"))
+
+	db = openMigrationTestDB(t)
+	upTo(11)
+	assert.True(t, db.Migrator().HasTable(&models.DiscoverySchedule{}))
+	assert.True(t, db.Migrator().HasColumn(&models.DiscoveryTask{}, "schedule_id"))
+	assert.False(t, db.Migrator().HasColumn(&models.DiscoverySchedule{}, "require_approval"))
+	assert.False(t, db.Migrator().HasColumn(&models.DiscoveryTask{}, "require_approval"))
+
+	db = openMigrationTestDB(t)
+	upTo(15)
+	assert.True(t, db.Migrator().HasColumn(&models.Alert{}, "suppressed_by"))
+	assert.False(t, db.Migrator().HasColumn(&models.Alert{}, "incident_id"))
+	assert.False(t, db.Migrator().HasColumn(&models.AlertRule{}, "escalation_policy_id"))
+	assert.False(t, db.Migrator().HasTable(&models.AlertIncident{}))
+}
+
+// 全部迁移执行后，新建数据库的表结构应覆盖当前所有模型的列和索引
+func TestMigrationsMatchModels(t *testing.T) {
+	db := openMigrationTestDB(t)
+	_, err := MigrateUp(db)
+	require.NoError(t, err)
+
+	current := []interface{}{
+		&models.User{}, &models.ActivityLog{}, &models.Role{}, &models.Permission{}, &models.UserRole{},
+		&models.RolePermission{}, &models.NodeAccess{}, &models.Node{}, &models.AlertRule{}, &models.Alert{},
+		&models.NotificationChannel{}, &models.Notification{}, &models.AlertRuleNotificationChannel{},
+		&models.SystemMetric{}, &models.ProcessGroup{}, &models.ProcessGroupItem{}, &models.ProcessDependency{},
+		&models.ScheduledTask{}, &models.TaskExecution{}, &models.ProcessTemplate{}, &models.ProcessBackup{},
+		&models.ProcessMetrics{}, &models.Configuration{}, &models.EnvironmentVariable{},
+		&models.ConfigurationHistory{}, &models.ConfigurationBackup{}, &models.ConfigurationTemplate{},
+		&models.ConfigurationValidation{}, &models.ConfigurationAudit{}, &models.LogEntry{},
+		&models.LogAnalysisRule{}, &models.LogStatistics{}, &models.LogAlert{}, &models.LogFilter{},
+		&models.LogExport{}, &models.LogRetentionPolicy{}, &models.BackupRecord{}, &models.DataExportRecord{},
+		&models.DataImportRecord{}, &models.UserPreferences{}, &models.WebhookConfig{}, &models.WebhookLog{},
+		&models.DiscoveryTask{}, &models.DiscoveryResult{},
+		&models.APIToken{}, &models.PasswordHistory{}, &models.LeaderLease{}, &models.ClusterEvent{},
+		&models.Rollout{}, &models.DiscoverySchedule{}, &models.DiscoveryRule{},
+		&models.DiscoveryReport{}, &models.DiscoveryCandidate{}, &models.DiscoveryBlocklistEntry{},
+		&models.ProcessQuarantine{}, &models.AlertSilence{}, &models.AlertMaintenanceWindow{},
+		&models.AlertInhibitRule{}, &models.AlertIncident{}, &models.AlertEscalationPolicy{},
+	}
+	for _, model := range current {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(model))
+		table := stmt.Schema.Table
+		require.True(t, db.Migrator().HasTable(table), "table %s", table)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" {
+				assert.True(t, db.Migrator().HasColumn(model, field.DBName), "column %s.%s", table, field.DBName)
+			}
+		}
+		for _, index := range stmt.Schema.ParseIndexes() {
+			assert.True(t, db.Migrator().HasIndex(model, index.Name), "index %s on %s", index.Name, table)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"fmt"
+
+	"superview/internal/database/baseline"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 0001 基线：与旧版启动时的 AutoMigrate + 自定义迁移等价，对已有数据库是幂等的。
+// 使用 baseline 包中冻结的表结构，之后的迁移各自添加新列
+func init() {
+	registerMigration(Migration{
+		Version: 1,
+		Name:    "baseline_schema",
+		Up: func(db *gorm.DB) error {
+			// 在迁移前修复 system_settings 表的空 category 字段
+			if err := fixEmptyCategories(db); err != nil {
+				zap.L().Warn("Failed to fix empty categories", zap.Error(err))
+			}
+
+			if err := db.AutoMigrate(baseline.Models()...); err != nil {
+				return fmt.Errorf("failed to migrate models: %v", err)
+			}
+
+			if err := runCustomMigrations(db); err != nil {
+				return fmt.Errorf("failed to run custom migrations: %v", err)
+			}
+			return nil
+		},
+		// 基线迁移不可回滚，删除全部业务表请直接重建数据库
+		Down: nil,
+	})
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"superview/internal/database/baseline"
+)
+
+// apiToken0002 0002 时 api_tokens 表的结构快照
+type apiToken0002 struct {
+	ID           uint       `gorm:"primaryKey"`
+	UserID       string     `gorm:"size:36;not null;index:idx_api_token_user"`
+	Name         string     `gorm:"size:100;not null"`
+	TokenHash    string     `gorm:"size:64;not null;uniqueIndex:idx_api_token_hash"`
+	TokenPrefix  string     `gorm:"size:16"`
+	Permissions  string     `gorm:"type:text"`
+	Nodes        string     `gorm:"type:text"`
+	Environments string     `gorm:"type:text"`
+	ExpiresAt    *time.Time `gorm:"index:idx_api_token_expires"`
+	LastUsedAt   *time.Time
+	LastUsedIP   string `gorm:"size:45"`
+	RevokedAt    *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index:idx_api_token_deleted_at"`
+
+	User baseline.User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (apiToken0002) TableName() string {
+	return "api_tokens"
+}
+
+// 0002 个人 API 令牌
+func init() {
+	registerMigration(Migration{
+		Version: 2,
+		Name:    "api_tokens",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&apiToken0002{})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&apiToken0002{})
+		},
+	})
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// user0003 0003 给 users 表新增的列
+type user0003 struct {
+	PasswordChangedAt  *time.Time
+	MustChangePassword bool `gorm:"default:false;not null"`
+}
+
+func (user0003) TableName() string {
+	return "users"
+}
+
+// passwordHistory0003 0003 时 password_histories 表的结构快照
+type passwordHistory0003 struct {
+	ID           uint      `gorm:"primaryKey"`
+	UserID       string    `gorm:"size:36;not null;index:idx_password_history_user"`
+	PasswordHash string    `gorm:"size:120;not null"`
+	CreatedAt    time.Time `gorm:"index:idx_password_history_created_at"`
+}
+
+func (passwordHistory0003) TableName() string {
+	return "password_histories"
+}
+
+// 0003 密码策略：密码修改时间、强制修改标记和历史密码
+func init() {
+	registerMigration(Migration{
+		Version: 3,
+		Name:    "password_policy",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&user0003{}, &passwordHistory0003{})
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Migrator().DropTable(&passwordHistory0003{}); err != nil {
+				return err
+			}
+			for _, column := range []string{"password_changed_at", "must_change_password"} {
+				if db.Migrator().HasColumn(&user0003{}, column) {
+					if err := db.Migrator().DropColumn(&user0003{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// leaderLease0004 0004 时 leader_leases 表的结构快照
+type leaderLease0004 struct {
+	Name      string    `gorm:"primaryKey;size:100"`
+	Holder    string    `gorm:"size:191;not null"`
+	Address   string    `gorm:"size:255"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+func (leaderLease0004) TableName() string {
+	return "leader_leases"
+}
+
+// clusterEvent0004 0004 时 cluster_events 表的结构快照
+type clusterEvent0004 struct {
+	ID        uint      `gorm:"primaryKey"`
+	Origin    string    `gorm:"size:191;not null"`
+	Payload   string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"not null;index:idx_cluster_event_created_at"`
+}
+
+func (clusterEvent0004) TableName() string {
+	return "cluster_events"
+}
+
+// 0004 多实例高可用：选主租约和实例间事件表
+func init() {
+	registerMigration(Migration{
+		Version: 4,
+		Name:    "cluster",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&leaderLease0004{}, &clusterEvent0004{})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&clusterEvent0004{}, &leaderLease0004{})
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// node0005 0005 给 nodes 表新增的列
+type node0005 struct {
+	Maintenance       bool   `gorm:"not null;default:false"`
+	MaintenanceReason string `gorm:"size:255"`
+	MaintenanceSince  *time.Time
+}
+
+func (node0005) TableName() string {
+	return "nodes"
+}
+
+// 0005 节点维护模式
+func init() {
+	registerMigration(Migration{
+		Version: 5,
+		Name:    "node_maintenance",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&node0005{})
+		},
+		Down: func(db *gorm.DB) error {
+			for _, column := range []string{"maintenance", "maintenance_reason", "maintenance_since"} {
+				if db.Migrator().HasColumn(&node0005{}, column) {
+					if err := db.Migrator().DropColumn(&node0005{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	})
+}
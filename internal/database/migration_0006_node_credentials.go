@@ -0,0 +1,33 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// node0006 0006 加宽后的 nodes.password 列
+type node0006 struct {
+	Password string `gorm:"size:512"`
+}
+
+func (node0006) TableName() string {
+	return "nodes"
+}
+
+// 0006 节点凭据加密：加宽 password 列以容纳密文（数据加密在启动时由 repository.ReencryptNodeCredentials 完成）
+func init() {
+	registerMigration(Migration{
+		Version: 6,
+		Name:    "node_credentials",
+		Up: func(db *gorm.DB) error {
+			// SQLite 不限制 VARCHAR 长度，无需重建表
+			if DialectOf(db) == DialectSQLite {
+				return nil
+			}
+			return db.Migrator().AlterColumn(&node0006{}, "Password")
+		},
+		Down: func(db *gorm.DB) error {
+			// 不收窄列宽：已加密的凭据超过原长度，回滚前需先用 credentials decrypt 还原明文
+			return nil
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// node0007 0007 给 nodes 表新增的列
+type node0007 struct {
+	Source string `gorm:"size:20;not null;default:'config';index:idx_node_source"`
+}
+
+func (node0007) TableName() string {
+	return "nodes"
+}
+
+// 0007 节点来源：配置重载只同步 source=config 的节点
+func init() {
+	registerMigration(Migration{
+		Version: 7,
+		Name:    "node_source",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&node0007{}); err != nil {
+				return err
+			}
+			// 已有节点：扫描发现的标记为 discovery，其余视为配置文件导入
+			return db.Table("nodes").
+				Where("status = ?", "discovered").
+				UpdateColumn("source", "discovery").Error
+		},
+		Down: func(db *gorm.DB) error {
+			if db.Migrator().HasIndex(&node0007{}, "idx_node_source") {
+				if err := db.Migrator().DropIndex(&node0007{}, "idx_node_source"); err != nil {
+					return err
+				}
+			}
+			if db.Migrator().HasColumn(&node0007{}, "source") {
+				return db.Migrator().DropColumn(&node0007{}, "source")
+			}
+			return nil
+		},
+	})
+}
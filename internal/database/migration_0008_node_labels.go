@@ -0,0 +1,45 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// node0008 0008 给 nodes 表新增的列
+type node0008 struct {
+	Labels string `gorm:"type:text"`
+}
+
+func (node0008) TableName() string {
+	return "nodes"
+}
+
+// scheduledTask0008 0008 给 scheduled_tasks 表新增的列
+type scheduledTask0008 struct {
+	NodeSelector string `gorm:"size:500"`
+}
+
+func (scheduledTask0008) TableName() string {
+	return "scheduled_tasks"
+}
+
+// 0008 节点标签，定时任务按标签选择节点
+func init() {
+	registerMigration(Migration{
+		Version: 8,
+		Name:    "node_labels",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&node0008{}, &scheduledTask0008{})
+		},
+		Down: func(db *gorm.DB) error {
+			if db.Migrator().HasColumn(&scheduledTask0008{}, "node_selector") {
+				if err := db.Migrator().DropColumn(&scheduledTask0008{}, "node_selector"); err != nil {
+					return err
+				}
+			}
+			if db.Migrator().HasColumn(&node0008{}, "labels") {
+				return db.Migrator().DropColumn(&node0008{}, "labels")
+			}
+			return nil
+		},
+	})
+}
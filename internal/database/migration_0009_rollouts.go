@@ -0,0 +1,54 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// rollout0009 0009 时 rollouts 表的结构快照
+type rollout0009 struct {
+	ID          uint   `gorm:"primaryKey"`
+	ProcessName string `gorm:"size:100"`
+	GroupName   string `gorm:"size:100"`
+	Selector    string `gorm:"size:500"`
+
+	BatchSize            int `gorm:"not null;default:1"`
+	PauseSeconds         int `gorm:"not null;default:0"`
+	HealthySeconds       int `gorm:"not null;default:0"`
+	HealthTimeoutSeconds int `gorm:"not null;default:60"`
+	MaxFailures          int `gorm:"not null;default:0"`
+
+	Status           string `gorm:"size:20;not null;default:'pending';index:idx_rollout_status"`
+	TotalBatches     int    `gorm:"not null;default:0"`
+	CurrentBatch     int    `gorm:"not null;default:0"`
+	TotalTargets     int    `gorm:"not null;default:0"`
+	SucceededTargets int    `gorm:"not null;default:0"`
+	FailedTargets    int    `gorm:"not null;default:0"`
+	Targets          string `gorm:"type:text"`
+
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	ErrorMsg    string    `gorm:"size:500"`
+	CreatedBy   string    `gorm:"size:100;not null"`
+	CreatedAt   time.Time `gorm:"index:idx_rollout_created_at"`
+	UpdatedAt   time.Time
+}
+
+func (rollout0009) TableName() string {
+	return "rollouts"
+}
+
+// 0009 跨节点滚动重启
+func init() {
+	registerMigration(Migration{
+		Version: 9,
+		Name:    "rollouts",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&rollout0009{})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&rollout0009{})
+		},
+	})
+}
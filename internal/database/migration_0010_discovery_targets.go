@@ -0,0 +1,49 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// discoveryTask0010 0010 给 discovery_tasks 表新增的列
+type discoveryTask0010 struct {
+	Targets   string `gorm:"type:text"`
+	Ports     string `gorm:"size:200"`
+	RateLimit int    `gorm:"not null;default:0"`
+}
+
+func (discoveryTask0010) TableName() string {
+	return "discovery_tasks"
+}
+
+// discoveryResult0010 0010 给 discovery_results 表新增的列
+type discoveryResult0010 struct {
+	Host string `gorm:"size:255"`
+}
+
+func (discoveryResult0010) TableName() string {
+	return "discovery_results"
+}
+
+// 0010 发现任务支持多种目标、端口列表和速率限制
+func init() {
+	registerMigration(Migration{
+		Version: 10,
+		Name:    "discovery_targets",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&discoveryTask0010{}, &discoveryResult0010{})
+		},
+		Down: func(db *gorm.DB) error {
+			for _, column := range []string{"targets", "ports", "rate_limit"} {
+				if db.Migrator().HasColumn(&discoveryTask0010{}, column) {
+					if err := db.Migrator().DropColumn(&discoveryTask0010{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			if db.Migrator().HasColumn(&discoveryResult0010{}, "host") {
+				return db.Migrator().DropColumn(&discoveryResult0010{}, "host")
+			}
+			return nil
+		},
+	})
+}
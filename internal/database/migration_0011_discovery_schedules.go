@@ -0,0 +1,131 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// discoveryTask0011 0011 给 discovery_tasks 表新增的列
+type discoveryTask0011 struct {
+	ScheduleID *uint `gorm:"index:idx_discovery_task_schedule_id"`
+}
+
+func (discoveryTask0011) TableName() string {
+	return "discovery_tasks"
+}
+
+// discoveryResult0011 0011 给 discovery_results 表新增的列
+type discoveryResult0011 struct {
+	Action string `gorm:"size:20"`
+	Rule   string `gorm:"size:100"`
+}
+
+func (discoveryResult0011) TableName() string {
+	return "discovery_results"
+}
+
+// discoverySchedule0011 0011 时 discovery_schedules 表的结构快照
+type discoverySchedule0011 struct {
+	ID        uint           `gorm:"primaryKey"`
+	CreatedAt time.Time      `gorm:"not null"`
+	UpdatedAt time.Time      `gorm:"not null"`
+	DeletedAt gorm.DeletedAt `gorm:"index:idx_discovery_schedule_deleted_at"`
+
+	Name     string `gorm:"size:100;not null;uniqueIndex:idx_discovery_schedule_name"`
+	CronExpr string `gorm:"size:100;not null"`
+	Enabled  bool   `gorm:"not null;default:true"`
+
+	CIDR     string `gorm:"size:50"`
+	Port     int
+	Targets  string `gorm:"type:text"`
+	Ports    string `gorm:"size:200"`
+	Username string `gorm:"size:50"`
+	Password string `gorm:"size:512"`
+
+	TimeoutSeconds int `gorm:"not null;default:0"`
+	MaxWorkers     int `gorm:"not null;default:0"`
+	RateLimit      int `gorm:"not null;default:0"`
+
+	LastRunAt  *time.Time
+	LastTaskID *uint
+	LastError  string `gorm:"size:500"`
+
+	CreatedBy string `gorm:"size:50;not null"`
+}
+
+func (discoverySchedule0011) TableName() string {
+	return "discovery_schedules"
+}
+
+// discoveryRule0011 0011 时 discovery_rules 表的结构快照
+type discoveryRule0011 struct {
+	ID        uint           `gorm:"primaryKey"`
+	CreatedAt time.Time      `gorm:"not null"`
+	UpdatedAt time.Time      `gorm:"not null"`
+	DeletedAt gorm.DeletedAt `gorm:"index:idx_discovery_rule_deleted_at"`
+
+	Name     string `gorm:"size:100;not null"`
+	Priority int    `gorm:"not null;default:0;index:idx_discovery_rule_priority"`
+	Enabled  bool   `gorm:"not null;default:true"`
+
+	CIDR        string `gorm:"size:50"`
+	HostPattern string `gorm:"size:255"`
+
+	Action      string `gorm:"size:20;not null"`
+	Environment string `gorm:"size:50"`
+	NamePrefix  string `gorm:"size:50"`
+	Labels      string `gorm:"type:text"`
+}
+
+func (discoveryRule0011) TableName() string {
+	return "discovery_rules"
+}
+
+// discoveryReport0011 0011 时 discovery_reports 表的结构快照
+type discoveryReport0011 struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"not null"`
+
+	ScheduleID     uint `gorm:"not null;index:idx_discovery_report_schedule_id"`
+	TaskID         uint `gorm:"not null;uniqueIndex:idx_discovery_report_task_id"`
+	BaselineTaskID *uint
+
+	Added    string `gorm:"type:text"`
+	Vanished string `gorm:"type:text"`
+	Changed  string `gorm:"type:text"`
+}
+
+func (discoveryReport0011) TableName() string {
+	return "discovery_reports"
+}
+
+// 0011 定时发现、自动注册规则和变化报告
+func init() {
+	registerMigration(Migration{
+		Version: 11,
+		Name:    "discovery_schedules",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&discoveryTask0011{}, &discoveryResult0011{},
+				&discoverySchedule0011{}, &discoveryRule0011{}, &discoveryReport0011{})
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Migrator().DropTable(&discoveryReport0011{}, &discoveryRule0011{}, &discoverySchedule0011{}); err != nil {
+				return err
+			}
+			if db.Migrator().HasColumn(&discoveryTask0011{}, "schedule_id") {
+				if err := db.Migrator().DropColumn(&discoveryTask0011{}, "schedule_id"); err != nil {
+					return err
+				}
+			}
+			for _, column := range []string{"action", "rule"} {
+				if db.Migrator().HasColumn(&discoveryResult0011{}, column) {
+					if err := db.Migrator().DropColumn(&discoveryResult0011{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// discoveryTask0012 0012 给 discovery_tasks 表新增的列
+type discoveryTask0012 struct {
+	RequireApproval bool `gorm:"not null;default:false"`
+}
+
+func (discoveryTask0012) TableName() string {
+	return "discovery_tasks"
+}
+
+// discoverySchedule0012 0012 给 discovery_schedules 表新增的列
+type discoverySchedule0012 struct {
+	RequireApproval bool `gorm:"not null;default:false"`
+}
+
+func (discoverySchedule0012) TableName() string {
+	return "discovery_schedules"
+}
+
+// discoveryCandidate0012 0012 时 discovery_candidates 表的结构快照
+type discoveryCandidate0012 struct {
+	ID        uint           `gorm:"primaryKey"`
+	CreatedAt time.Time      `gorm:"not null"`
+	UpdatedAt time.Time      `gorm:"not null"`
+	DeletedAt gorm.DeletedAt `gorm:"index:idx_discovery_candidate_deleted_at"`
+
+	TaskID uint   `gorm:"not null;index:idx_discovery_candidate_task_id"`
+	Host   string `gorm:"size:255"`
+	IP     string `gorm:"size:50;not null"`
+	Port   int    `gorm:"not null;check:port > 0 AND port <= 65535"`
+
+	Username string `gorm:"size:50"`
+	Password string `gorm:"size:512"`
+
+	Version        string    `gorm:"size:50"`
+	Identification string    `gorm:"size:255"`
+	ProcessCount   int       `gorm:"not null;default:0"`
+	LastSeenAt     time.Time `gorm:"not null"`
+
+	Rule        string `gorm:"size:100"`
+	Name        string `gorm:"size:100"`
+	Environment string `gorm:"size:50"`
+	Labels      string `gorm:"type:text"`
+
+	Status     string `gorm:"size:20;not null;default:'pending';index:idx_discovery_candidate_status"`
+	ReviewedBy string `gorm:"size:50"`
+	ReviewedAt *time.Time
+	Reason     string `gorm:"size:255"`
+	NodeID     *uint
+}
+
+func (discoveryCandidate0012) TableName() string {
+	return "discovery_candidates"
+}
+
+// discoveryBlocklistEntry0012 0012 时 discovery_blocklist 表的结构快照
+type discoveryBlocklistEntry0012 struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"not null"`
+
+	Address   string `gorm:"size:255;not null;index:idx_discovery_blocklist_address"`
+	Port      int    `gorm:"not null;default:0"`
+	Reason    string `gorm:"size:255"`
+	CreatedBy string `gorm:"size:50"`
+}
+
+func (discoveryBlocklistEntry0012) TableName() string {
+	return "discovery_blocklist"
+}
+
+// 0012 发现节点审核队列和屏蔽列表
+func init() {
+	registerMigration(Migration{
+		Version: 12,
+		Name:    "discovery_candidates",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&discoveryTask0012{}, &discoverySchedule0012{},
+				&discoveryCandidate0012{}, &discoveryBlocklistEntry0012{})
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Migrator().DropTable(&discoveryBlocklistEntry0012{}, &discoveryCandidate0012{}); err != nil {
+				return err
+			}
+			for _, model := range []interface{}{&discoveryTask0012{}, &discoverySchedule0012{}} {
+				if db.Migrator().HasColumn(model, "require_approval") {
+					if err := db.Migrator().DropColumn(model, "require_approval"); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// processQuarantine0013 0013 时 process_quarantines 表的结构快照
+type processQuarantine0013 struct {
+	ID          uint   `gorm:"primaryKey"`
+	NodeName    string `gorm:"size:100;not null;index:idx_quarantine_process"`
+	ProcessName string `gorm:"size:100;not null;index:idx_quarantine_process"`
+	Reason      string `gorm:"size:500"`
+	Score       float64
+	Restarts    int
+	Window      string `gorm:"size:20"`
+	AlertID     *uint
+
+	QuarantinedAt  time.Time  `gorm:"not null"`
+	AcknowledgedBy string     `gorm:"size:100"`
+	AcknowledgedAt *time.Time `gorm:"index:idx_quarantine_acknowledged_at"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (processQuarantine0013) TableName() string {
+	return "process_quarantines"
+}
+
+// 0013 进程抖动隔离
+func init() {
+	registerMigration(Migration{
+		Version: 13,
+		Name:    "process_quarantines",
+		Up: func(db *gorm.DB) error {
+			return db.AutoMigrate(&processQuarantine0013{})
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&processQuarantine0013{})
+		},
+	})
+}
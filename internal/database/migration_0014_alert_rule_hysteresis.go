@@ -0,0 +1,43 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// alertRule0014 0014 给 alert_rules 表新增的列
+type alertRule0014 struct {
+	Hysteresis    float64 `gorm:"not null;default:0"`
+	ClearDuration int     `gorm:"not null;default:0"`
+}
+
+func (alertRule0014) TableName() string {
+	return "alert_rules"
+}
+
+// 0014 告警规则的恢复阈值和恢复持续时间
+func init() {
+	registerMigration(Migration{
+		Version: 14,
+		Name:    "alert_rule_hysteresis",
+		Up: func(db *gorm.DB) error {
+			for _, column := range []string{"Hysteresis", "ClearDuration"} {
+				if !db.Migrator().HasColumn(&alertRule0014{}, column) {
+					if err := db.Migrator().AddColumn(&alertRule0014{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(db *gorm.DB) error {
+			for _, column := range []string{"hysteresis", "clear_duration"} {
+				if db.Migrator().HasColumn(&alertRule0014{}, column) {
+					if err := db.Migrator().DropColumn(&alertRule0014{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	})
+}
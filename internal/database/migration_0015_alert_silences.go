@@ -0,0 +1,103 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// alert0015 0015 给 alerts 表新增的列
+type alert0015 struct {
+	SuppressedBy string `gorm:"size:100"`
+}
+
+func (alert0015) TableName() string {
+	return "alerts"
+}
+
+// alertMatcher0015 静默和维护窗口共用的匹配条件列
+type alertMatcher0015 struct {
+	NodeName    string `gorm:"size:100"`
+	ProcessName string `gorm:"size:100"`
+	Environment string `gorm:"size:50"`
+	Selector    string `gorm:"size:500"`
+	Severity    string `gorm:"size:20"`
+}
+
+// alertSilence0015 0015 时 alert_silences 表的结构快照
+type alertSilence0015 struct {
+	ID        uint             `gorm:"primaryKey"`
+	Matcher   alertMatcher0015 `gorm:"embedded"`
+	Comment   string           `gorm:"size:500"`
+	StartsAt  time.Time        `gorm:"not null;index:idx_alert_silence_starts_at"`
+	EndsAt    time.Time        `gorm:"not null;index:idx_alert_silence_ends_at"`
+	CreatedBy string           `gorm:"size:36;not null"`
+	CreatedAt time.Time        `gorm:"not null"`
+	UpdatedAt time.Time        `gorm:"not null"`
+}
+
+func (alertSilence0015) TableName() string {
+	return "alert_silences"
+}
+
+// alertMaintenanceWindow0015 0015 时 alert_maintenance_windows 表的结构快照
+type alertMaintenanceWindow0015 struct {
+	ID              uint             `gorm:"primaryKey"`
+	Name            string           `gorm:"size:100;not null;uniqueIndex:idx_alert_maintenance_window_name"`
+	Matcher         alertMatcher0015 `gorm:"embedded"`
+	Schedule        string           `gorm:"size:100;not null"`
+	DurationMinutes int              `gorm:"not null"`
+	Enabled         bool             `gorm:"not null;default:true"`
+	Comment         string           `gorm:"size:500"`
+	CreatedBy       string           `gorm:"size:36;not null"`
+	CreatedAt       time.Time        `gorm:"not null"`
+	UpdatedAt       time.Time        `gorm:"not null"`
+}
+
+func (alertMaintenanceWindow0015) TableName() string {
+	return "alert_maintenance_windows"
+}
+
+// alertInhibitRule0015 0015 时 alert_inhibit_rules 表的结构快照
+type alertInhibitRule0015 struct {
+	ID             uint   `gorm:"primaryKey"`
+	Name           string `gorm:"size:100;not null;uniqueIndex:idx_alert_inhibit_rule_name"`
+	SourceRuleID   *uint
+	SourceSeverity string `gorm:"size:20"`
+	TargetRuleID   *uint
+	TargetSeverity string    `gorm:"size:20"`
+	Equal          string    `gorm:"size:100;not null;default:'node'"`
+	Enabled        bool      `gorm:"not null;default:true"`
+	CreatedBy      string    `gorm:"size:36;not null"`
+	CreatedAt      time.Time `gorm:"not null"`
+	UpdatedAt      time.Time `gorm:"not null"`
+}
+
+func (alertInhibitRule0015) TableName() string {
+	return "alert_inhibit_rules"
+}
+
+// 0015 告警静默、维护窗口和抑制规则
+func init() {
+	registerMigration(Migration{
+		Version: 15,
+		Name:    "alert_silences",
+		Up: func(db *gorm.DB) error {
+			if !db.Migrator().HasColumn(&alert0015{}, "SuppressedBy") {
+				if err := db.Migrator().AddColumn(&alert0015{}, "SuppressedBy"); err != nil {
+					return err
+				}
+			}
+			return db.AutoMigrate(&alertSilence0015{}, &alertMaintenanceWindow0015{}, &alertInhibitRule0015{})
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Migrator().DropTable(&alertInhibitRule0015{}, &alertMaintenanceWindow0015{}, &alertSilence0015{}); err != nil {
+				return err
+			}
+			if db.Migrator().HasColumn(&alert0015{}, "suppressed_by") {
+				return db.Migrator().DropColumn(&alert0015{}, "suppressed_by")
+			}
+			return nil
+		},
+	})
+}
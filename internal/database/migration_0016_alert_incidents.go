@@ -0,0 +1,125 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// alert0016 0016 给 alerts 表新增的列
+type alert0016 struct {
+	IncidentID *uint `gorm:"index:idx_alert_incident_id"`
+}
+
+func (alert0016) TableName() string {
+	return "alerts"
+}
+
+// alertRule0016 0016 给 alert_rules 表新增的列
+type alertRule0016 struct {
+	EscalationPolicyID *uint
+}
+
+func (alertRule0016) TableName() string {
+	return "alert_rules"
+}
+
+// notification0016 0016 给 notifications 表新增的列
+type notification0016 struct {
+	IncidentID *uint
+}
+
+func (notification0016) TableName() string {
+	return "notifications"
+}
+
+// alertIncident0016 0016 时 alert_incidents 表的结构快照
+type alertIncident0016 struct {
+	ID                 uint   `gorm:"primaryKey"`
+	GroupKey           string `gorm:"size:255;not null;index:idx_alert_incident_group_key"`
+	GroupLabels        string `gorm:"type:text"`
+	Status             string `gorm:"size:20;not null;default:'open';index:idx_alert_incident_status"`
+	Severity           string `gorm:"size:20;not null"`
+	RuleID             uint   `gorm:"not null"`
+	EscalationPolicyID *uint
+	EscalationStep     int       `gorm:"not null;default:0"`
+	AlertCount         int       `gorm:"not null;default:0"`
+	FirstAlertAt       time.Time `gorm:"not null"`
+	LastNotifiedAt     *time.Time
+	NextNotifyAt       *time.Time `gorm:"index:idx_alert_incident_next_notify"`
+	NextEscalationAt   *time.Time `gorm:"index:idx_alert_incident_next_escalation"`
+	AckedBy            *string    `gorm:"size:36"`
+	AckedAt            *time.Time
+	ResolvedAt         *time.Time
+	CreatedAt          time.Time `gorm:"not null"`
+	UpdatedAt          time.Time `gorm:"not null"`
+}
+
+func (alertIncident0016) TableName() string {
+	return "alert_incidents"
+}
+
+// alertEscalationPolicy0016 0016 时 alert_escalation_policies 表的结构快照
+type alertEscalationPolicy0016 struct {
+	ID          uint      `gorm:"primaryKey"`
+	Name        string    `gorm:"size:100;not null;uniqueIndex:idx_alert_escalation_policy_name"`
+	Description string    `gorm:"size:500"`
+	Steps       string    `gorm:"type:text"`
+	CreatedBy   string    `gorm:"size:36;not null"`
+	CreatedAt   time.Time `gorm:"not null"`
+	UpdatedAt   time.Time `gorm:"not null"`
+}
+
+func (alertEscalationPolicy0016) TableName() string {
+	return "alert_escalation_policies"
+}
+
+// 0016 告警事件和升级策略
+func init() {
+	registerMigration(Migration{
+		Version: 16,
+		Name:    "alert_incidents",
+		Up: func(db *gorm.DB) error {
+			if err := db.AutoMigrate(&alertIncident0016{}, &alertEscalationPolicy0016{}); err != nil {
+				return err
+			}
+			columns := []struct {
+				model interface{}
+				field string
+			}{
+				{&alert0016{}, "IncidentID"},
+				{&alertRule0016{}, "EscalationPolicyID"},
+				{&notification0016{}, "IncidentID"},
+			}
+			for _, c := range columns {
+				if !db.Migrator().HasColumn(c.model, c.field) {
+					if err := db.Migrator().AddColumn(c.model, c.field); err != nil {
+						return err
+					}
+				}
+			}
+			if !db.Migrator().HasIndex(&alert0016{}, "idx_alert_incident_id") {
+				return db.Migrator().CreateIndex(&alert0016{}, "idx_alert_incident_id")
+			}
+			return nil
+		},
+		Down: func(db *gorm.DB) error {
+			columns := []struct {
+				model  interface{}
+				column string
+			}{
+				{&notification0016{}, "incident_id"},
+				{&alertRule0016{}, "escalation_policy_id"},
+				{&alert0016{}, "incident_id"},
+			}
+			for _, c := range columns {
+				if db.Migrator().HasColumn(c.model, c.column) {
+					if err := db.Migrator().DropColumn(c.model, c.column); err != nil {
+						return err
+					}
+				}
+			}
+			return db.Migrator().DropTable(&alertEscalationPolicy0016{}, &alertIncident0016{})
+		},
+	})
+}
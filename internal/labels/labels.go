@@ -0,0 +1,90 @@
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MaxLabels 单个节点的标签数上限
+const MaxLabels = 32
+
+// maxLength 标签键和值的最大长度
+const maxLength = 63
+
+var (
+	keyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_./-]*[A-Za-z0-9])?$`)
+	valuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9_.-]*[A-Za-z0-9])?)?$`)
+)
+
+// ValidateKey 校验标签键：字母数字开头结尾，中间可含 - _ . /，最长 63
+func ValidateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("label key is empty")
+	}
+	if len(key) > maxLength || !keyPattern.MatchString(key) {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+	return nil
+}
+
+// ValidateValue 校验标签值：可以为空，否则字母数字开头结尾，中间可含 - _ .，最长 63
+func ValidateValue(value string) error {
+	if len(value) > maxLength || !valuePattern.MatchString(value) {
+		return fmt.Errorf("invalid label value %q", value)
+	}
+	return nil
+}
+
+// Validate 校验整组标签
+func Validate(labels map[string]string) error {
+	if len(labels) > MaxLabels {
+		return fmt.Errorf("too many labels (max %d)", MaxLabels)
+	}
+	for key, value := range labels {
+		if err := ValidateKey(key); err != nil {
+			return err
+		}
+		if err := ValidateValue(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Equal 比较两组标签，nil 与空 map 视为相同
+func Equal(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone 复制标签
+func Clone(labels map[string]string) map[string]string {
+	result := make(map[string]string, len(labels))
+	for key, value := range labels {
+		result[key] = value
+	}
+	return result
+}
+
+// Format 按键排序输出 k=v 列表，用于日志
+func Format(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key + "=" + labels[key]
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,195 @@
+package labels
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 选择器运算符
+const (
+	OpEquals       = "="
+	OpNotEquals    = "!="
+	OpIn           = "in"
+	OpNotIn        = "notin"
+	OpExists       = "exists"
+	OpDoesNotExist = "!"
+)
+
+// Requirement 单个匹配条件
+type Requirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+// Matches 判断标签是否满足条件
+func (r Requirement) Matches(labels map[string]string) bool {
+	value, exists := labels[r.Key]
+	switch r.Operator {
+	case OpEquals:
+		return exists && value == r.Values[0]
+	case OpNotEquals:
+		return !exists || value != r.Values[0]
+	case OpIn:
+		return exists && containsValue(r.Values, value)
+	case OpNotIn:
+		return !exists || !containsValue(r.Values, value)
+	case OpExists:
+		return exists
+	case OpDoesNotExist:
+		return !exists
+	}
+	return false
+}
+
+func (r Requirement) String() string {
+	switch r.Operator {
+	case OpEquals, OpNotEquals:
+		return r.Key + r.Operator + r.Values[0]
+	case OpIn, OpNotIn:
+		return r.Key + " " + r.Operator + " (" + strings.Join(r.Values, ",") + ")"
+	case OpDoesNotExist:
+		return "!" + r.Key
+	}
+	return r.Key
+}
+
+// Selector 标签选择器，所有条件同时满足才匹配；空选择器匹配所有节点
+type Selector []Requirement
+
+// Empty 是否为空选择器
+func (s Selector) Empty() bool {
+	return len(s) == 0
+}
+
+// Matches 判断标签是否满足所有条件
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	parts := make([]string, len(s))
+	for i, r := range s {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// Parse 解析选择器，语法：
+//
+//	role=worker,region in (eu,us),tier!=db,canary,!legacy,zone notin (a,b)
+//
+// 条件之间用逗号分隔；"==" 等同于 "="
+func Parse(selector string) (Selector, error) {
+	var result Selector
+	for _, term := range splitTerms(selector) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %v", term, err)
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// splitTerms 按逗号拆分条件，括号内的逗号不拆分
+func splitTerms(selector string) []string {
+	var terms []string
+	depth, start := 0, 0
+	for i, ch := range selector {
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, selector[start:])
+}
+
+func parseRequirement(term string) (Requirement, error) {
+	if strings.HasPrefix(term, "!") && !strings.Contains(term, "=") {
+		key := strings.TrimSpace(term[1:])
+		if err := ValidateKey(key); err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key, Operator: OpDoesNotExist}, nil
+	}
+
+	for _, op := range []string{"!=", "==", "="} {
+		if idx := strings.Index(term, op); idx >= 0 {
+			key := strings.TrimSpace(term[:idx])
+			value := strings.TrimSpace(term[idx+len(op):])
+			if err := ValidateKey(key); err != nil {
+				return Requirement{}, err
+			}
+			if err := ValidateValue(value); err != nil {
+				return Requirement{}, err
+			}
+			operator := OpEquals
+			if op == "!=" {
+				operator = OpNotEquals
+			}
+			return Requirement{Key: key, Operator: operator, Values: []string{value}}, nil
+		}
+	}
+
+	fields := strings.Fields(term)
+	if len(fields) == 1 {
+		if err := ValidateKey(fields[0]); err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: fields[0], Operator: OpExists}, nil
+	}
+	if len(fields) < 2 || (fields[1] != OpIn && fields[1] != OpNotIn) {
+		return Requirement{}, fmt.Errorf("expected =, !=, in or notin")
+	}
+
+	key := fields[0]
+	if err := ValidateKey(key); err != nil {
+		return Requirement{}, err
+	}
+	rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(term[len(key):]), fields[1]))
+	if !strings.HasPrefix(rest, "(") || !strings.HasSuffix(rest, ")") {
+		return Requirement{}, fmt.Errorf("values must be enclosed in parentheses")
+	}
+	var values []string
+	for _, value := range strings.Split(rest[1:len(rest)-1], ",") {
+		value = strings.TrimSpace(value)
+		if err := ValidateValue(value); err != nil {
+			return Requirement{}, err
+		}
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return Requirement{}, fmt.Errorf("value list is empty")
+	}
+	sort.Strings(values)
+	return Requirement{Key: key, Operator: fields[1], Values: values}, nil
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package labels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAndMatch(t *testing.T) {
+	node := map[string]string{"role": "worker", "region": "eu", "rack": "a3"}
+
+	cases := []struct {
+		selector string
+		matches  bool
+	}{
+		{"", true},
+		{"role=worker", true},
+		{"role==worker", true},
+		{"role=db", false},
+		{"role!=db", true},
+		{"role=worker,region in (eu,us)", true},
+		{"role=worker, region in (us, ap)", false},
+		{"region notin (us,ap)", true},
+		{"region notin (eu)", false},
+		{"rack", true},
+		{"canary", false},
+		{"!canary", true},
+		{"!rack", false},
+		{"tier!=db", true},
+	}
+	for _, tc := range cases {
+		selector, err := Parse(tc.selector)
+		require.NoError(t, err, tc.selector)
+		assert.Equal(t, tc.matches, selector.Matches(node), tc.selector)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, selector := range []string{
+		"region in eu",
+		"region in ()",
+		"region between (a,b)",
+		"=worker",
+		"role=bad value",
+		"-role=worker",
+	} {
+		_, err := Parse(selector)
+		assert.Error(t, err, selector)
+	}
+}
+
+func TestSelectorString(t *testing.T) {
+	selector, err := Parse("role=worker, region in (us,eu), !legacy")
+	require.NoError(t, err)
+	assert.Equal(t, "role=worker,region in (eu,us),!legacy", selector.String())
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(map[string]string{"region": "eu", "example.com/team": "infra", "empty": ""}))
+	assert.Error(t, Validate(map[string]string{"bad key": "x"}))
+	assert.Error(t, Validate(map[string]string{"key": "bad/value"}))
+}
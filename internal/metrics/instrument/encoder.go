@@ -0,0 +1,133 @@
+package instrument
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Format 指标输出格式
+type Format int
+
+const (
+	// FormatText Prometheus 文本格式 0.0.4
+	FormatText Format = iota
+	// FormatOpenMetrics OpenMetrics 1.0.0
+	FormatOpenMetrics
+)
+
+// Content-Type
+const (
+	ContentTypeText        = "text/plain; version=0.0.4; charset=utf-8"
+	ContentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// NegotiateFormat 根据 Accept 请求头选择输出格式，Prometheus 2.5+ 会优先请求 OpenMetrics
+func NegotiateFormat(accept string) Format {
+	if strings.Contains(accept, "application/openmetrics-text") {
+		return FormatOpenMetrics
+	}
+	return FormatText
+}
+
+// ContentType 格式对应的 Content-Type
+func (f Format) ContentType() string {
+	if f == FormatOpenMetrics {
+		return ContentTypeOpenMetrics
+	}
+	return ContentTypeText
+}
+
+// Encoder 按所选格式写出指标族和样本。同一指标族的样本必须在一次 Family 调用之后连续写出
+type Encoder struct {
+	w      *bufio.Writer
+	format Format
+}
+
+// NewEncoder 创建 Encoder，写完后需要调用 Close
+func NewEncoder(w io.Writer, format Format) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w), format: format}
+}
+
+// Family 写出指标族的 HELP 和 TYPE（OpenMetrics 下还有 UNIT）。
+// 计数器传入带 _total 的名称，OpenMetrics 格式下指标族名称去掉该后缀
+func (e *Encoder) Family(name, help, metricType string) {
+	family := name
+	if e.format == FormatOpenMetrics && metricType == TypeCounter {
+		family = strings.TrimSuffix(name, "_total")
+	}
+	e.w.WriteString("# HELP " + family + " " + escapeHelp(help) + "\n")
+	e.w.WriteString("# TYPE " + family + " " + metricType + "\n")
+	if e.format == FormatOpenMetrics {
+		if unit := unitOf(family); unit != "" {
+			e.w.WriteString("# UNIT " + family + " " + unit + "\n")
+		}
+	}
+}
+
+// Sample 写出一个样本，names 和 values 一一对应
+func (e *Encoder) Sample(name string, names, values []string, value float64) {
+	e.w.WriteString(name)
+	if len(names) > 0 {
+		e.w.WriteByte('{')
+		for i, label := range names {
+			if i > 0 {
+				e.w.WriteByte(',')
+			}
+			var labelValue string
+			if i < len(values) {
+				labelValue = values[i]
+			}
+			e.w.WriteString(label + `="` + EscapeLabel(labelValue) + `"`)
+		}
+		e.w.WriteByte('}')
+	}
+	e.w.WriteByte(' ')
+	e.w.WriteString(formatFloat(value))
+	e.w.WriteByte('\n')
+}
+
+// Close 写出 OpenMetrics 结束标记并刷新缓冲
+func (e *Encoder) Close() error {
+	if e.format == FormatOpenMetrics {
+		e.w.WriteString("# EOF\n")
+	}
+	return e.w.Flush()
+}
+
+// EscapeLabel 转义标签值中的反斜杠、双引号和换行
+func EscapeLabel(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return s
+}
+
+func escapeHelp(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	return strings.ReplaceAll(s, "\n", "\\n")
+}
+
+// unitOf 从指标族名称的后缀推断单位
+func unitOf(family string) string {
+	for _, unit := range []string{"seconds", "bytes"} {
+		if strings.HasSuffix(family, "_"+unit) {
+			return unit
+		}
+	}
+	return ""
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,306 @@
+// Package instrument 提供进程内的计数器、仪表和直方图，并按 Prometheus 文本格式或 OpenMetrics 格式输出。
+// 不依赖其他内部包，supervisor、websocket、services 等都可以直接埋点
+package instrument
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 指标类型
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultBuckets 默认直方图分桶（秒），覆盖 5ms 到 10s
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Collector 在每次抓取时把自己的指标写入 Encoder
+type Collector interface {
+	Collect(e *Encoder)
+}
+
+// Registry 按注册顺序输出一组 Collector
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+}
+
+// NewRegistry 创建空的 Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// MustRegister 注册 Collector
+func (r *Registry) MustRegister(collectors ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collectors...)
+}
+
+// Collect 依次输出所有已注册的 Collector
+func (r *Registry) Collect(e *Encoder) {
+	r.mu.RLock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.RUnlock()
+	for _, c := range collectors {
+		c.Collect(e)
+	}
+}
+
+// Default 由 /metrics 端点输出的全局 Registry
+var Default = NewRegistry()
+
+// MustRegister 注册到 Default
+func MustRegister(collectors ...Collector) {
+	Default.MustRegister(collectors...)
+}
+
+// series 一组标签值对应的样本
+type series struct {
+	labelValues []string
+	value       float64
+	// 仅直方图使用
+	buckets []uint64
+	count   uint64
+}
+
+// vec 按标签值保存样本，三种指标共用
+type vec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func newVec(name, help string, labels []string) vec {
+	return vec{name: name, help: help, labels: labels, series: make(map[string]*series)}
+}
+
+// get 取出（必要时创建）标签值对应的样本，调用方持有 mu。标签值个数不符时截断或补空
+func (v *vec) get(labelValues []string) *series {
+	values, key := v.key(labelValues)
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{labelValues: values}
+		v.series[key] = s
+	}
+	return s
+}
+
+// find 查找标签值对应的样本，不存在时返回空样本且不创建，调用方持有 mu
+func (v *vec) find(labelValues []string) series {
+	_, key := v.key(labelValues)
+	if s, ok := v.series[key]; ok {
+		return *s
+	}
+	return series{}
+}
+
+func (v *vec) key(labelValues []string) ([]string, string) {
+	values := make([]string, len(v.labels))
+	copy(values, labelValues)
+	return values, strings.Join(values, "\xff")
+}
+
+// sorted 按标签值排序的样本快照，调用方持有 mu
+func (v *vec) sorted() []*series {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]*series, len(keys))
+	for i, key := range keys {
+		s := *v.series[key]
+		s.buckets = append([]uint64(nil), s.buckets...)
+		result[i] = &s
+	}
+	return result
+}
+
+// CounterVec 只增不减的计数器，名称应以 _total 结尾
+type CounterVec struct {
+	vec
+}
+
+// NewCounterVec 创建计数器
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{vec: newVec(name, help, labels)}
+}
+
+// Inc 计数加一
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 计数增加 delta，负数被忽略
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.get(labelValues).value += delta
+	c.mu.Unlock()
+}
+
+// Value 当前计数
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.find(labelValues).value
+}
+
+// Collect 实现 Collector
+func (c *CounterVec) Collect(e *Encoder) {
+	c.mu.Lock()
+	samples := c.sorted()
+	c.mu.Unlock()
+
+	e.Family(c.name, c.help, TypeCounter)
+	for _, s := range samples {
+		e.Sample(c.name, c.labels, s.labelValues, s.value)
+	}
+}
+
+// GaugeVec 可增可减的仪表
+type GaugeVec struct {
+	vec
+}
+
+// NewGaugeVec 创建仪表
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{vec: newVec(name, help, labels)}
+}
+
+// Set 设置数值
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value = value
+	g.mu.Unlock()
+}
+
+// Add 数值增加 delta（可为负数）
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value += delta
+	g.mu.Unlock()
+}
+
+// Inc 数值加一
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec 数值减一
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+// Value 当前数值
+func (g *GaugeVec) Value(labelValues ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.find(labelValues).value
+}
+
+// Collect 实现 Collector
+func (g *GaugeVec) Collect(e *Encoder) {
+	g.mu.Lock()
+	samples := g.sorted()
+	g.mu.Unlock()
+
+	e.Family(g.name, g.help, TypeGauge)
+	for _, s := range samples {
+		e.Sample(g.name, g.labels, s.labelValues, s.value)
+	}
+}
+
+// HistogramVec 按分桶统计观测值的直方图
+type HistogramVec struct {
+	vec
+	upperBounds []float64
+}
+
+// NewHistogramVec 创建直方图，buckets 为空时使用 DefaultBuckets
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	return &HistogramVec{vec: newVec(name, help, labels), upperBounds: bounds}
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(labelValues)
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(h.upperBounds))
+	}
+	for i, bound := range h.upperBounds {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.value += value
+}
+
+// Count 观测次数
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.find(labelValues).count
+}
+
+// Collect 实现 Collector，输出累积的 _bucket、_sum 和 _count
+func (h *HistogramVec) Collect(e *Encoder) {
+	h.mu.Lock()
+	samples := h.sorted()
+	h.mu.Unlock()
+
+	e.Family(h.name, h.help, TypeHistogram)
+	bucketLabels := append(append([]string(nil), h.labels...), "le")
+	for _, s := range samples {
+		for i, bound := range h.upperBounds {
+			var count uint64
+			if s.buckets != nil {
+				count = s.buckets[i]
+			}
+			e.Sample(h.name+"_bucket", bucketLabels, append(append([]string(nil), s.labelValues...), formatFloat(bound)), float64(count))
+		}
+		e.Sample(h.name+"_bucket", bucketLabels, append(append([]string(nil), s.labelValues...), "+Inf"), float64(s.count))
+		e.Sample(h.name+"_sum", h.labels, s.labelValues, s.value)
+		e.Sample(h.name+"_count", h.labels, s.labelValues, float64(s.count))
+	}
+}
+
+// GaugeFunc 抓取时通过回调计算数值的仪表，适合从数据库统计的数据
+type GaugeFunc struct {
+	name    string
+	help    string
+	labels  []string
+	collect func(set func(value float64, labelValues ...string))
+}
+
+// NewGaugeFunc 创建回调仪表，collect 对每组标签值调用一次 set
+func NewGaugeFunc(name, help string, labels []string, collect func(set func(value float64, labelValues ...string))) *GaugeFunc {
+	return &GaugeFunc{name: name, help: help, labels: labels, collect: collect}
+}
+
+// Collect 实现 Collector
+func (g *GaugeFunc) Collect(e *Encoder) {
+	gauge := NewGaugeVec(g.name, g.help, g.labels...)
+	g.collect(func(value float64, labelValues ...string) {
+		gauge.Set(value, labelValues...)
+	})
+	gauge.Collect(e)
+}
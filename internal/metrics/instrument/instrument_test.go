@@ -0,0 +1,82 @@
+package instrument
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encode(format Format, collectors ...Collector) string {
+	var sb strings.Builder
+	enc := NewEncoder(&sb, format)
+	for _, c := range collectors {
+		c.Collect(enc)
+	}
+	enc.Close()
+	return sb.String()
+}
+
+func TestCounterVecFormats(t *testing.T) {
+	c := NewCounterVec("test_errors_total", "Errors", "node", "method")
+	c.Inc("web-2", "supervisor.stopProcess")
+	c.Add(2, "web-1", "supervisor.getAllProcessInfo")
+	c.Add(-5, "web-1", "supervisor.getAllProcessInfo")
+	assert.Equal(t, 2.0, c.Value("web-1", "supervisor.getAllProcessInfo"))
+
+	assert.Equal(t, `# HELP test_errors_total Errors
+# TYPE test_errors_total counter
+test_errors_total{node="web-1",method="supervisor.getAllProcessInfo"} 2
+test_errors_total{node="web-2",method="supervisor.stopProcess"} 1
+`, encode(FormatText, c))
+
+	assert.Equal(t, `# HELP test_errors Errors
+# TYPE test_errors counter
+test_errors_total{node="web-1",method="supervisor.getAllProcessInfo"} 2
+test_errors_total{node="web-2",method="supervisor.stopProcess"} 1
+# EOF
+`, encode(FormatOpenMetrics, c))
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("test_duration_seconds", "Duration", []float64{1, 0.1}, "route")
+	h.Observe(0.05, "/api/nodes")
+	h.Observe(0.5, "/api/nodes")
+	h.Observe(3, "/api/nodes")
+	assert.Equal(t, uint64(3), h.Count("/api/nodes"))
+
+	assert.Equal(t, `# HELP test_duration_seconds Duration
+# TYPE test_duration_seconds histogram
+# UNIT test_duration_seconds seconds
+test_duration_seconds_bucket{route="/api/nodes",le="0.1"} 1
+test_duration_seconds_bucket{route="/api/nodes",le="1"} 2
+test_duration_seconds_bucket{route="/api/nodes",le="+Inf"} 3
+test_duration_seconds_sum{route="/api/nodes"} 3.55
+test_duration_seconds_count{route="/api/nodes"} 3
+# EOF
+`, encode(FormatOpenMetrics, h))
+}
+
+func TestGaugeVecAndGaugeFunc(t *testing.T) {
+	g := NewGaugeVec("test_connections", "Connections", "transport")
+	g.Inc("sse")
+	g.Inc("websocket")
+	g.Inc("websocket")
+	g.Dec("sse")
+	assert.Equal(t, 2.0, g.Value("websocket"))
+
+	f := NewGaugeFunc("test_alerts", "Alerts", []string{"severity"}, func(set func(float64, ...string)) {
+		set(4, `say "hi"`)
+	})
+	out := encode(FormatText, g, f)
+	assert.Contains(t, out, "test_connections{transport=\"sse\"} 0\ntest_connections{transport=\"websocket\"} 2\n")
+	assert.Contains(t, out, `test_alerts{severity="say \"hi\""} 4`)
+	assert.NotContains(t, out, "# EOF")
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	assert.Equal(t, FormatOpenMetrics, NegotiateFormat("application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5"))
+	assert.Equal(t, FormatText, NegotiateFormat("text/plain"))
+	assert.Equal(t, FormatText, NegotiateFormat(""))
+	assert.Equal(t, ContentTypeOpenMetrics, FormatOpenMetrics.ContentType())
+}
@@ -1,49 +1,56 @@
 package metrics
 
 import (
-	"fmt"
+	"math"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"superview/internal/metrics/instrument"
 	"superview/internal/supervisor"
 )
 
 // PrometheusMetrics 管理 Prometheus 指标收集
 type PrometheusMetrics struct {
 	supervisorService *supervisor.SupervisorService
+	registry          *instrument.Registry
 	mu                sync.RWMutex
-	lastCollectTime   time.Time
-	cachedMetrics     string
+	lastCollectTime   map[instrument.Format]time.Time
+	cachedMetrics     map[instrument.Format]string
 	cacheDuration     time.Duration
 }
 
-// NewPrometheusMetrics 创建 PrometheusMetrics 实例
+// NewPrometheusMetrics 创建 PrometheusMetrics 实例，除节点和进程状态外还输出 instrument.Default 中的运行指标
 func NewPrometheusMetrics(svc *supervisor.SupervisorService) *PrometheusMetrics {
 	return &PrometheusMetrics{
 		supervisorService: svc,
+		registry:          instrument.Default,
+		lastCollectTime:   make(map[instrument.Format]time.Time),
+		cachedMetrics:     make(map[instrument.Format]string),
 		cacheDuration:     5 * time.Second, // 缓存5秒避免频繁采集
 	}
 }
 
-// Handler 返回 Prometheus metrics HTTP handler
+// Handler 返回 Prometheus metrics HTTP handler，按 Accept 头选择文本格式或 OpenMetrics
 func (p *PrometheusMetrics) Handler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		metrics := p.collectMetrics()
-		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		format := instrument.NegotiateFormat(r.Header.Get("Accept"))
+		metrics := p.collectMetrics(format)
+		w.Header().Set("Content-Type", format.ContentType())
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(metrics))
 	}
 }
 
 // collectMetrics 收集所有指标
-func (p *PrometheusMetrics) collectMetrics() string {
+func (p *PrometheusMetrics) collectMetrics(format instrument.Format) string {
 	p.mu.RLock()
-	if time.Since(p.lastCollectTime) < p.cacheDuration && p.cachedMetrics != "" {
+	if cached, ok := p.cachedMetrics[format]; ok && time.Since(p.lastCollectTime[format]) < p.cacheDuration {
 		defer p.mu.RUnlock()
-		return p.cachedMetrics
+		return cached
 	}
 	p.mu.RUnlock()
 
@@ -51,119 +58,81 @@ func (p *PrometheusMetrics) collectMetrics() string {
 	defer p.mu.Unlock()
 
 	// 双重检查
-	if time.Since(p.lastCollectTime) < p.cacheDuration && p.cachedMetrics != "" {
-		return p.cachedMetrics
+	if cached, ok := p.cachedMetrics[format]; ok && time.Since(p.lastCollectTime[format]) < p.cacheDuration {
+		return cached
 	}
 
 	var sb strings.Builder
-
-	// 写入 HELP 和 TYPE 注释
-	p.writeMetricHelp(&sb)
+	enc := instrument.NewEncoder(&sb, format)
 
 	// 收集节点指标
-	p.collectNodeMetrics(&sb)
+	p.collectNodeMetrics(enc)
 
 	// 收集进程指标
-	p.collectProcessMetrics(&sb)
+	p.collectProcessMetrics(enc)
 
 	// 收集汇总指标
-	p.collectSummaryMetrics(&sb)
-
-	p.cachedMetrics = sb.String()
-	p.lastCollectTime = time.Now()
-
-	return p.cachedMetrics
-}
-
-// writeMetricHelp 写入指标帮助信息
-func (p *PrometheusMetrics) writeMetricHelp(sb *strings.Builder) {
-	// 节点指标
-	sb.WriteString("# HELP superview_node_up Node connection status (1=connected, 0=disconnected)\n")
-	sb.WriteString("# TYPE superview_node_up gauge\n")
-
-	sb.WriteString("# HELP superview_node_last_ping_timestamp_seconds Last successful ping timestamp\n")
-	sb.WriteString("# TYPE superview_node_last_ping_timestamp_seconds gauge\n")
-
-	// 进程指标
-	sb.WriteString("# HELP superview_process_state Process state (0=STOPPED, 10=STARTING, 20=RUNNING, 30=BACKOFF, 40=STOPPING, 100=EXITED, 200=FATAL, 1000=UNKNOWN)\n")
-	sb.WriteString("# TYPE superview_process_state gauge\n")
-
-	sb.WriteString("# HELP superview_process_up Process running status (1=running, 0=not running)\n")
-	sb.WriteString("# TYPE superview_process_up gauge\n")
-
-	sb.WriteString("# HELP superview_process_pid Process PID\n")
-	sb.WriteString("# TYPE superview_process_pid gauge\n")
-
-	sb.WriteString("# HELP superview_process_uptime_seconds Process uptime in seconds\n")
-	sb.WriteString("# TYPE superview_process_uptime_seconds gauge\n")
-
-	sb.WriteString("# HELP superview_process_start_timestamp_seconds Process start timestamp\n")
-	sb.WriteString("# TYPE superview_process_start_timestamp_seconds gauge\n")
-
-	sb.WriteString("# HELP superview_process_exit_status Process exit status code\n")
-	sb.WriteString("# TYPE superview_process_exit_status gauge\n")
-
-	// 汇总指标
-	sb.WriteString("# HELP superview_nodes_total Total number of configured nodes\n")
-	sb.WriteString("# TYPE superview_nodes_total gauge\n")
-
-	sb.WriteString("# HELP superview_nodes_connected Number of connected nodes\n")
-	sb.WriteString("# TYPE superview_nodes_connected gauge\n")
-
-	sb.WriteString("# HELP superview_processes_total Total number of processes\n")
-	sb.WriteString("# TYPE superview_processes_total gauge\n")
+	p.collectSummaryMetrics(enc)
 
-	sb.WriteString("# HELP superview_processes_running Number of running processes\n")
-	sb.WriteString("# TYPE superview_processes_running gauge\n")
+	// 计数器和直方图
+	p.registry.Collect(enc)
 
-	sb.WriteString("# HELP superview_processes_stopped Number of stopped processes\n")
-	sb.WriteString("# TYPE superview_processes_stopped gauge\n")
+	enc.Close()
 
-	sb.WriteString("# HELP superview_processes_failed Number of failed processes (FATAL or EXITED with error)\n")
-	sb.WriteString("# TYPE superview_processes_failed gauge\n")
+	p.cachedMetrics[format] = sb.String()
+	p.lastCollectTime[format] = time.Now()
 
-	sb.WriteString("# HELP superview_info Superview build information\n")
-	sb.WriteString("# TYPE superview_info gauge\n")
+	return p.cachedMetrics[format]
 }
 
 // collectNodeMetrics 收集节点指标
-func (p *PrometheusMetrics) collectNodeMetrics(sb *strings.Builder) {
+func (p *PrometheusMetrics) collectNodeMetrics(enc *instrument.Encoder) {
 	nodes := p.supervisorService.GetAllNodes()
 	if nodes == nil {
 		return
 	}
 
+	nodeUp := instrument.NewGaugeVec("superview_node_up",
+		"Node connection status (1=connected, 0=disconnected)", "node", "environment", "host", "port")
+	lastPing := instrument.NewGaugeVec("superview_node_last_ping_timestamp_seconds",
+		"Last successful ping timestamp", "node")
+
 	for _, node := range nodes {
-		isConnected, lastPing := node.GetConnectionStatus()
+		isConnected, lastPingTime := node.GetConnectionStatus()
 
 		// superview_node_up
-		upValue := 0
+		upValue := 0.0
 		if isConnected {
 			upValue = 1
 		}
-		sb.WriteString(fmt.Sprintf("superview_node_up{node=\"%s\",environment=\"%s\",host=\"%s\",port=\"%d\"} %d\n",
-			escapeLabel(node.Name),
-			escapeLabel(node.Environment),
-			escapeLabel(node.Host),
-			node.Port,
-			upValue))
+		nodeUp.Set(upValue, node.Name, node.Environment, node.Host, strconv.Itoa(node.Port))
 
 		// superview_node_last_ping_timestamp_seconds
-		if !lastPing.IsZero() {
-			sb.WriteString(fmt.Sprintf("superview_node_last_ping_timestamp_seconds{node=\"%s\"} %d\n",
-				escapeLabel(node.Name),
-				lastPing.Unix()))
+		if !lastPingTime.IsZero() {
+			lastPing.Set(float64(lastPingTime.Unix()), node.Name)
 		}
 	}
+
+	nodeUp.Collect(enc)
+	lastPing.Collect(enc)
 }
 
 // collectProcessMetrics 收集进程指标
-func (p *PrometheusMetrics) collectProcessMetrics(sb *strings.Builder) {
+func (p *PrometheusMetrics) collectProcessMetrics(enc *instrument.Encoder) {
 	nodes := p.supervisorService.GetAllNodes()
 	if nodes == nil {
 		return
 	}
 
+	processLabels := []string{"node", "process", "group"}
+	state := instrument.NewGaugeVec("superview_process_state",
+		"Process state (0=STOPPED, 10=STARTING, 20=RUNNING, 30=BACKOFF, 40=STOPPING, 100=EXITED, 200=FATAL, 1000=UNKNOWN)", processLabels...)
+	up := instrument.NewGaugeVec("superview_process_up", "Process running status (1=running, 0=not running)", processLabels...)
+	pidGauge := instrument.NewGaugeVec("superview_process_pid", "Process PID", processLabels...)
+	uptimeGauge := instrument.NewGaugeVec("superview_process_uptime_seconds", "Process uptime in seconds", processLabels...)
+	start := instrument.NewGaugeVec("superview_process_start_timestamp_seconds", "Process start timestamp", processLabels...)
+	exit := instrument.NewGaugeVec("superview_process_exit_status", "Process exit status code", processLabels...)
+
 	for _, node := range nodes {
 		isConnected, _ := node.GetConnectionStatus()
 		if !isConnected {
@@ -174,42 +143,43 @@ func (p *PrometheusMetrics) collectProcessMetrics(sb *strings.Builder) {
 		for _, proc := range processes {
 			name, _ := proc["name"].(string)
 			group, _ := proc["group"].(string)
-			state, _ := proc["state"].(int)
+			stateCode, _ := proc["state"].(int)
 			pid, _ := proc["pid"].(int)
 			uptime, _ := proc["uptime"].(float64)
 			startTime, _ := proc["start"].(int64)
 			exitStatus, _ := proc["exitstatus"].(int)
 
-			labels := fmt.Sprintf("node=\"%s\",process=\"%s\",group=\"%s\"",
-				escapeLabel(node.Name),
-				escapeLabel(name),
-				escapeLabel(group))
+			labels := []string{node.Name, name, group}
 
 			// superview_process_state
-			sb.WriteString(fmt.Sprintf("superview_process_state{%s} %d\n", labels, state))
+			state.Set(float64(stateCode), labels...)
 
 			// superview_process_up (1 if RUNNING, 0 otherwise)
-			upValue := 0
-			if state == 20 { // RUNNING
+			upValue := 0.0
+			if stateCode == 20 { // RUNNING
 				upValue = 1
 			}
-			sb.WriteString(fmt.Sprintf("superview_process_up{%s} %d\n", labels, upValue))
+			up.Set(upValue, labels...)
 
 			// superview_process_pid
-			sb.WriteString(fmt.Sprintf("superview_process_pid{%s} %d\n", labels, pid))
+			pidGauge.Set(float64(pid), labels...)
 
 			// superview_process_uptime_seconds
-			sb.WriteString(fmt.Sprintf("superview_process_uptime_seconds{%s} %.0f\n", labels, uptime))
+			uptimeGauge.Set(math.Round(uptime), labels...)
 
 			// superview_process_start_timestamp_seconds
 			if startTime > 0 {
-				sb.WriteString(fmt.Sprintf("superview_process_start_timestamp_seconds{%s} %d\n", labels, startTime))
+				start.Set(float64(startTime), labels...)
 			}
 
 			// superview_process_exit_status
-			sb.WriteString(fmt.Sprintf("superview_process_exit_status{%s} %d\n", labels, exitStatus))
+			exit.Set(float64(exitStatus), labels...)
 		}
 	}
+
+	for _, gauge := range []*instrument.GaugeVec{state, up, pidGauge, uptimeGauge, start, exit} {
+		gauge.Collect(enc)
+	}
 }
 
 // 构建信息 - 从环境变量或编译时注入获取版本
@@ -225,7 +195,7 @@ func (p *PrometheusMetrics) getVersion() string {
 var Version = ""
 
 // collectSummaryMetrics 收集汇总指标
-func (p *PrometheusMetrics) collectSummaryMetrics(sb *strings.Builder) {
+func (p *PrometheusMetrics) collectSummaryMetrics(enc *instrument.Encoder) {
 	nodes := p.supervisorService.GetAllNodes()
 	if nodes == nil {
 		return
@@ -279,37 +249,42 @@ func (p *PrometheusMetrics) collectSummaryMetrics(sb *strings.Builder) {
 		envStats[env] = stats
 	}
 
-	// 总体指标
-	sb.WriteString(fmt.Sprintf("superview_nodes_total %d\n", totalNodes))
-	sb.WriteString(fmt.Sprintf("superview_nodes_connected %d\n", connectedNodes))
-	sb.WriteString(fmt.Sprintf("superview_processes_total %d\n", totalProcesses))
-	sb.WriteString(fmt.Sprintf("superview_processes_running %d\n", runningProcesses))
-	sb.WriteString(fmt.Sprintf("superview_processes_stopped %d\n", stoppedProcesses))
-	sb.WriteString(fmt.Sprintf("superview_processes_failed %d\n", failedProcesses))
-
-	// 按环境统计
+	// 总体指标及按环境统计，同一指标族的样本连续输出
 	envNames := make([]string, 0, len(envStats))
 	for env := range envStats {
 		envNames = append(envNames, env)
 	}
 	sort.Strings(envNames)
 
-	for _, env := range envNames {
-		stats := envStats[env]
-		sb.WriteString(fmt.Sprintf("superview_nodes_total{environment=\"%s\"} %d\n", escapeLabel(env), stats.total))
-		sb.WriteString(fmt.Sprintf("superview_nodes_connected{environment=\"%s\"} %d\n", escapeLabel(env), stats.connected))
-		sb.WriteString(fmt.Sprintf("superview_processes_total{environment=\"%s\"} %d\n", escapeLabel(env), stats.processes))
-		sb.WriteString(fmt.Sprintf("superview_processes_running{environment=\"%s\"} %d\n", escapeLabel(env), stats.running))
+	summaries := []struct {
+		name  string
+		help  string
+		total int
+		byEnv func(env string) int
+	}{
+		{"superview_nodes_total", "Total number of configured nodes", totalNodes,
+			func(env string) int { return envStats[env].total }},
+		{"superview_nodes_connected", "Number of connected nodes", connectedNodes,
+			func(env string) int { return envStats[env].connected }},
+		{"superview_processes_total", "Total number of processes", totalProcesses,
+			func(env string) int { return envStats[env].processes }},
+		{"superview_processes_running", "Number of running processes", runningProcesses,
+			func(env string) int { return envStats[env].running }},
+		{"superview_processes_stopped", "Number of stopped processes", stoppedProcesses, nil},
+		{"superview_processes_failed", "Number of failed processes (FATAL or EXITED with error)", failedProcesses, nil},
+	}
+	for _, summary := range summaries {
+		enc.Family(summary.name, summary.help, instrument.TypeGauge)
+		enc.Sample(summary.name, nil, nil, float64(summary.total))
+		if summary.byEnv == nil {
+			continue
+		}
+		for _, env := range envNames {
+			enc.Sample(summary.name, []string{"environment"}, []string{env}, float64(summary.byEnv(env)))
+		}
 	}
 
 	// 构建信息
-	sb.WriteString(fmt.Sprintf("superview_info{version=\"%s\"} 1\n", p.getVersion()))
-}
-
-// escapeLabel 转义 Prometheus label 值中的特殊字符
-func escapeLabel(s string) string {
-	s = strings.ReplaceAll(s, "\\", "\\\\")
-	s = strings.ReplaceAll(s, "\"", "\\\"")
-	s = strings.ReplaceAll(s, "\n", "\\n")
-	return s
+	enc.Family("superview_info", "Superview build information", instrument.TypeGauge)
+	enc.Sample("superview_info", []string{"version"}, []string{p.getVersion()}, 1)
 }
@@ -2,11 +2,13 @@ package middleware
 
 import (
 	"runtime"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"superview/internal/logger"
+	"superview/internal/metrics/instrument"
 	"go.uber.org/zap"
 )
 
@@ -56,6 +58,14 @@ var globalMetrics = &PerformanceMetrics{
 	MemoryMetrics:    &MemoryMetrics{LastUpdated: time.Now()},
 }
 
+// httpRequestDuration 按路由和状态码统计的请求耗时，由 /metrics 端点输出
+var httpRequestDuration = instrument.NewHistogramVec("superview_http_request_duration_seconds",
+	"API request duration by route and status", nil, "method", "route", "status")
+
+func init() {
+	instrument.MustRegister(httpRequestDuration)
+}
+
 // 内存监控定时器
 var memoryUpdateTicker *time.Ticker
 var memoryUpdateStop chan bool
@@ -77,6 +87,11 @@ func PerformanceMiddleware() gin.HandlerFunc {
 
 		// 记录性能指标
 		recordMetrics(endpoint, duration, statusCode)
+		route := path
+		if route == "" {
+			route = "unmatched" // 未匹配路由时不使用原始路径，避免标签基数失控
+		}
+		httpRequestDuration.Observe(duration.Seconds(), method, route, strconv.Itoa(statusCode))
 
 		// 记录慢请求日志（超过1秒）
 		if duration > time.Second {
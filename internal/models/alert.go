@@ -15,6 +15,12 @@ type AlertRule struct {
 	Condition   string         `json:"condition" gorm:"not null;size:20" validate:"required,oneof=> < >= <= == !="`
 	Threshold   float64        `json:"threshold" gorm:"not null" validate:"required,gte=0"`
 	Duration    int            `json:"duration" gorm:"not null;check:duration > 0" validate:"required,min=1"`
+	// Hysteresis 恢复阈值与触发阈值的差值，告警触发后指标需越过 Threshold∓Hysteresis 才恢复
+	Hysteresis float64 `json:"hysteresis" gorm:"not null;default:0" validate:"gte=0"`
+	// ClearDuration 恢复条件需持续的秒数，0 表示满足即恢复
+	ClearDuration int `json:"clear_duration" gorm:"not null;default:0" validate:"gte=0"`
+	// EscalationPolicyID 升级策略，为空时事件通知发送到规则绑定的通知渠道
+	EscalationPolicyID *uint `json:"escalation_policy_id,omitempty"`
 	Severity    string         `json:"severity" gorm:"not null;size:20;index:idx_severity" validate:"required,oneof=low medium high critical"`
 	Enabled     bool           `json:"enabled" gorm:"default:true;not null;index:idx_enabled"`
 	NodeID      *uint          `json:"node_id,omitempty" gorm:"index:idx_node_id" validate:"omitempty,gt=0"`
@@ -47,6 +53,10 @@ type Alert struct {
 	ResolvedBy  *string        `json:"resolved_by,omitempty" gorm:"size:36;index:idx_resolved_by"`
 	ResolvedAt  *time.Time     `json:"resolved_at,omitempty"`
 	Metadata    string         `json:"metadata" gorm:"type:text" validate:"omitempty,json"`
+	// SuppressedBy 通知被静默、维护窗口或抑制规则拦截时记录原因，如 silence:3
+	SuppressedBy string `json:"suppressed_by,omitempty" gorm:"size:100"`
+	// IncidentID 告警所属的事件
+	IncidentID *uint `json:"incident_id,omitempty" gorm:"index:idx_alert_incident_id"`
 	CreatedAt   time.Time      `json:"created_at" gorm:"not null;index:idx_alert_created_at"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"not null"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
@@ -85,6 +95,7 @@ type NotificationChannel struct {
 type Notification struct {
 	ID         uint       `json:"id" gorm:"primaryKey"`
 	AlertID    uint       `json:"alert_id" gorm:"not null"`
+	IncidentID *uint      `json:"incident_id,omitempty"` // 事件通知对应的事件
 	ChannelID  uint       `json:"channel_id" gorm:"not null"`
 	Status     string     `json:"status" gorm:"not null;size:20;default:'pending'"` // pending, sent, failed, retry
 	Message    string     `json:"message" gorm:"type:text"`
@@ -247,6 +258,27 @@ func (r *AlertRule) ShouldTrigger(value float64) bool {
 	}
 }
 
+// ShouldClear 检查已触发的告警是否满足恢复条件。比较类条件按 Hysteresis 放宽阈值，
+// 例如 cpu > 90、Hysteresis 为 10 时，指标降到 80 及以下才恢复
+func (r *AlertRule) ShouldClear(value float64) bool {
+	switch r.Condition {
+	case ">":
+		return value <= r.Threshold-r.Hysteresis
+	case ">=":
+		return value < r.Threshold-r.Hysteresis
+	case "<":
+		return value >= r.Threshold+r.Hysteresis
+	case "<=":
+		return value > r.Threshold+r.Hysteresis
+	case "==":
+		return value != r.Threshold
+	case "!=":
+		return value == r.Threshold
+	default:
+		return true
+	}
+}
+
 // GetConditionText 获取条件的文本描述
 func (r *AlertRule) GetConditionText() string {
 	return fmt.Sprintf("%s %s %.2f", r.Metric, r.Condition, r.Threshold)
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// AlertIncident 告警事件：分组键相同的告警合并为一个事件，按事件发送通知和升级
+type AlertIncident struct {
+	ID          uint              `gorm:"primaryKey" json:"id"`
+	GroupKey    string            `gorm:"size:255;not null;index:idx_alert_incident_group_key" json:"group_key"`
+	GroupLabels map[string]string `gorm:"type:text;serializer:json" json:"group_labels"`
+	Status      string            `gorm:"size:20;not null;default:'open';index:idx_alert_incident_status" json:"status"`
+	// Severity 事件内告警的最高级别
+	Severity string `gorm:"size:20;not null" json:"severity"`
+	// RuleID 第一条告警的规则，事件的升级策略取自该规则
+	RuleID             uint       `gorm:"not null" json:"rule_id"`
+	EscalationPolicyID *uint      `json:"escalation_policy_id,omitempty"`
+	EscalationStep     int        `gorm:"not null;default:0" json:"escalation_step"`
+	AlertCount         int        `gorm:"not null;default:0" json:"alert_count"`
+	FirstAlertAt       time.Time  `gorm:"not null" json:"first_alert_at"`
+	LastNotifiedAt     *time.Time `json:"last_notified_at,omitempty"`
+	NextNotifyAt       *time.Time `gorm:"index:idx_alert_incident_next_notify" json:"next_notify_at,omitempty"`
+	NextEscalationAt   *time.Time `gorm:"index:idx_alert_incident_next_escalation" json:"next_escalation_at,omitempty"`
+	AckedBy            *string    `gorm:"size:36" json:"acked_by,omitempty"`
+	AckedAt            *time.Time `json:"acked_at,omitempty"`
+	ResolvedAt         *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt          time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt          time.Time  `gorm:"not null" json:"updated_at"`
+
+	Alerts []Alert `gorm:"foreignKey:IncidentID" json:"alerts,omitempty"`
+}
+
+// TableName 指定表名
+func (AlertIncident) TableName() string {
+	return "alert_incidents"
+}
+
+// 事件状态
+const (
+	IncidentStatusOpen         = "open"
+	IncidentStatusAcknowledged = "acknowledged"
+	IncidentStatusResolved     = "resolved"
+)
+
+// EscalationStep 升级步骤：上一步通知后 AfterMinutes 分钟内事件仍未确认，则通知本步的渠道。
+// 第一步在事件的首次通知时使用，AfterMinutes 被忽略
+type EscalationStep struct {
+	ChannelIDs   []uint `json:"channel_ids"`
+	AfterMinutes int    `json:"after_minutes"`
+}
+
+// AlertEscalationPolicy 升级策略，通过告警规则的 EscalationPolicyID 关联
+type AlertEscalationPolicy struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	Name        string           `gorm:"size:100;not null;uniqueIndex:idx_alert_escalation_policy_name" json:"name"`
+	Description string           `gorm:"size:500" json:"description"`
+	Steps       []EscalationStep `gorm:"type:text;serializer:json" json:"steps"`
+	CreatedBy   string           `gorm:"size:36;not null" json:"created_by"`
+	CreatedAt   time.Time        `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time        `gorm:"not null" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (AlertEscalationPolicy) TableName() string {
+	return "alert_escalation_policies"
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"time"
+)
+
+// AlertMatcher 告警匹配条件，空字段匹配任意值。
+// NodeName、ProcessName 支持通配符（如 web-*），ProcessName 也可写 group:name；
+// Selector 为节点标签选择器，如 role=web,region in (eu,us)
+type AlertMatcher struct {
+	NodeName    string `gorm:"size:100" json:"node_name,omitempty"`
+	ProcessName string `gorm:"size:100" json:"process_name,omitempty"`
+	Environment string `gorm:"size:50" json:"environment,omitempty"`
+	Selector    string `gorm:"size:500" json:"selector,omitempty"`
+	Severity    string `gorm:"size:20" json:"severity,omitempty"`
+}
+
+// IsEmpty 是否没有任何匹配条件（会匹配所有告警）
+func (m AlertMatcher) IsEmpty() bool {
+	return m.NodeName == "" && m.ProcessName == "" && m.Environment == "" && m.Selector == "" && m.Severity == ""
+}
+
+// AlertSilence 告警静默：StartsAt 到 EndsAt 之间匹配的告警不发送通知
+type AlertSilence struct {
+	ID           uint `gorm:"primaryKey" json:"id"`
+	AlertMatcher `gorm:"embedded"`
+	Comment      string    `gorm:"size:500" json:"comment"`
+	StartsAt     time.Time `gorm:"not null;index:idx_alert_silence_starts_at" json:"starts_at"`
+	EndsAt       time.Time `gorm:"not null;index:idx_alert_silence_ends_at" json:"ends_at"`
+	CreatedBy    string    `gorm:"size:36;not null" json:"created_by"`
+	CreatedAt    time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"not null" json:"updated_at"`
+
+	// Status 由 StartsAt、EndsAt 计算：pending、active、expired
+	Status string `gorm:"-" json:"status"`
+}
+
+// TableName 指定表名
+func (AlertSilence) TableName() string {
+	return "alert_silences"
+}
+
+// 静默状态
+const (
+	SilenceStatusPending = "pending"
+	SilenceStatusActive  = "active"
+	SilenceStatusExpired = "expired"
+)
+
+// StatusAt 静默在 t 时刻的状态
+func (s *AlertSilence) StatusAt(t time.Time) string {
+	switch {
+	case t.Before(s.StartsAt):
+		return SilenceStatusPending
+	case t.Before(s.EndsAt):
+		return SilenceStatusActive
+	default:
+		return SilenceStatusExpired
+	}
+}
+
+// AlertMaintenanceWindow 周期性维护窗口：Schedule（cron 表达式，支持 CRON_TZ= 前缀）每次触发后的
+// DurationMinutes 分钟内，匹配的告警不发送通知
+type AlertMaintenanceWindow struct {
+	ID              uint   `gorm:"primaryKey" json:"id"`
+	Name            string `gorm:"size:100;not null;uniqueIndex:idx_alert_maintenance_window_name" json:"name"`
+	AlertMatcher    `gorm:"embedded"`
+	Schedule        string    `gorm:"size:100;not null" json:"schedule"`
+	DurationMinutes int       `gorm:"not null" json:"duration_minutes"`
+	Enabled         bool      `gorm:"not null;default:true" json:"enabled"`
+	Comment         string    `gorm:"size:500" json:"comment"`
+	CreatedBy       string    `gorm:"size:36;not null" json:"created_by"`
+	CreatedAt       time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"not null" json:"updated_at"`
+
+	// Active 当前是否处于维护窗口内，查询时计算
+	Active bool `gorm:"-" json:"active"`
+}
+
+// TableName 指定表名
+func (AlertMaintenanceWindow) TableName() string {
+	return "alert_maintenance_windows"
+}
+
+// AlertInhibitRule 告警抑制规则：存在未解决的源告警时，Equal 字段相同的目标告警不发送通知。
+// 例如 SourceRuleID 为节点离线规则、TargetRuleID 为进程停止规则、Equal 为 node 时，
+// 节点离线期间该节点上的进程停止告警被抑制
+type AlertInhibitRule struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Name           string    `gorm:"size:100;not null;uniqueIndex:idx_alert_inhibit_rule_name" json:"name"`
+	SourceRuleID   *uint     `json:"source_rule_id,omitempty"`
+	SourceSeverity string    `gorm:"size:20" json:"source_severity,omitempty"`
+	TargetRuleID   *uint     `json:"target_rule_id,omitempty"`
+	TargetSeverity string    `gorm:"size:20" json:"target_severity,omitempty"`
+	Equal          string    `gorm:"size:100;not null;default:'node'" json:"equal"` // 逗号分隔：node、process
+	Enabled        bool      `gorm:"not null;default:true" json:"enabled"`
+	CreatedBy      string    `gorm:"size:36;not null" json:"created_by"`
+	CreatedAt      time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (AlertInhibitRule) TableName() string {
+	return "alert_inhibit_rules"
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APITokenPrefix 个人 API 令牌的固定前缀，用于在 Authorization 头中区分 JWT
+const APITokenPrefix = "sv_"
+
+// APIToken 个人 API 令牌（用于自动化和 CI 调用）
+// 令牌明文只在创建时返回一次，数据库中仅保存 SHA-256 摘要
+type APIToken struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	UserID       string         `gorm:"size:36;not null;index:idx_api_token_user" json:"user_id"`
+	Name         string         `gorm:"size:100;not null" json:"name" validate:"required,min=1,max=100"`
+	TokenHash    string         `gorm:"size:64;not null;uniqueIndex:idx_api_token_hash" json:"-"`
+	TokenPrefix  string         `gorm:"size:16" json:"token_prefix"`                // 明文前几位，便于用户识别
+	Permissions  string         `gorm:"type:text" json:"-"`                         // JSON 数组，为空表示继承用户全部权限
+	Nodes        string         `gorm:"type:text" json:"-"`                         // JSON 数组，为空表示不限制节点
+	Environments string         `gorm:"type:text" json:"-"`                         // JSON 数组，为空表示不限制环境
+	ExpiresAt    *time.Time     `gorm:"index:idx_api_token_expires" json:"expires_at"`
+	LastUsedAt   *time.Time     `json:"last_used_at"`
+	LastUsedIP   string         `gorm:"size:45" json:"last_used_ip"`
+	RevokedAt    *time.Time     `json:"revoked_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index:idx_api_token_deleted_at" json:"-"`
+
+	// 关联关系
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// TableName 指定表名
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// IsExpired 检查令牌是否已过期
+func (t *APIToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
+
+// IsRevoked 检查令牌是否已被吊销
+func (t *APIToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// IsUsable 检查令牌当前是否可用于认证
+func (t *APIToken) IsUsable() bool {
+	return !t.IsExpired() && !t.IsRevoked()
+}
+
+// GetPermissions 获取令牌权限子集
+func (t *APIToken) GetPermissions() []string {
+	return decodeStringList(t.Permissions)
+}
+
+// SetPermissions 设置令牌权限子集
+func (t *APIToken) SetPermissions(permissions []string) {
+	t.Permissions = encodeStringList(permissions)
+}
+
+// GetNodes 获取令牌允许访问的节点
+func (t *APIToken) GetNodes() []string {
+	return decodeStringList(t.Nodes)
+}
+
+// SetNodes 设置令牌允许访问的节点
+func (t *APIToken) SetNodes(nodes []string) {
+	t.Nodes = encodeStringList(nodes)
+}
+
+// GetEnvironments 获取令牌允许访问的环境
+func (t *APIToken) GetEnvironments() []string {
+	return decodeStringList(t.Environments)
+}
+
+// SetEnvironments 设置令牌允许访问的环境
+func (t *APIToken) SetEnvironments(environments []string) {
+	t.Environments = encodeStringList(environments)
+}
+
+// HasPermission 检查令牌是否包含指定权限（未限制时返回 true）
+func (t *APIToken) HasPermission(permission string) bool {
+	permissions := t.GetPermissions()
+	if len(permissions) == 0 {
+		return true
+	}
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsNode 检查令牌是否允许访问指定节点
+// 节点名与环境任一命中限制列表即允许；两个列表都为空时不做限制
+func (t *APIToken) AllowsNode(nodeName, environment string) bool {
+	nodes := t.GetNodes()
+	environments := t.GetEnvironments()
+	if len(nodes) == 0 && len(environments) == 0 {
+		return true
+	}
+	for _, n := range nodes {
+		if n == nodeName {
+			return true
+		}
+	}
+	for _, e := range environments {
+		if e == environment {
+			return true
+		}
+	}
+	return false
+}
+
+// HasNodeRestrictions 检查令牌是否有节点或环境限制
+func (t *APIToken) HasNodeRestrictions() bool {
+	return len(t.GetNodes()) > 0 || len(t.GetEnvironments()) > 0
+}
+
+// Serialize 返回令牌的对外展示信息（不含摘要）
+func (t *APIToken) Serialize() map[string]interface{} {
+	return map[string]interface{}{
+		"id":           t.ID,
+		"name":         t.Name,
+		"token_prefix": t.TokenPrefix,
+		"permissions":  t.GetPermissions(),
+		"nodes":        t.GetNodes(),
+		"environments": t.GetEnvironments(),
+		"expires_at":   t.ExpiresAt,
+		"last_used_at": t.LastUsedAt,
+		"last_used_ip": t.LastUsedIP,
+		"revoked_at":   t.RevokedAt,
+		"created_at":   t.CreatedAt,
+	}
+}
+
+// encodeStringList 将字符串列表编码为 JSON，空列表编码为空字符串
+func encodeStringList(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// decodeStringList 解码 JSON 字符串列表，格式错误时返回空列表
+func decodeStringList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	var values []string
+	if err := json.Unmarshal([]byte(value), &values); err != nil {
+		return []string{}
+	}
+	return values
+}
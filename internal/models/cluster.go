@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// LeaderLease 多实例选主租约，每个 Name 一行，Holder 在 ExpiresAt 之前持有主节点身份
+type LeaderLease struct {
+	Name      string    `gorm:"primaryKey;size:100" json:"name"`
+	Holder    string    `gorm:"size:191;not null" json:"holder"`
+	Address   string    `gorm:"size:255" json:"address"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (LeaderLease) TableName() string {
+	return "leader_leases"
+}
+
+// IsHeldBy 租约是否由指定实例持有且未过期
+func (l *LeaderLease) IsHeldBy(instanceID string, now time.Time) bool {
+	return l.Holder == instanceID && l.ExpiresAt.After(now)
+}
+
+// ClusterEvent 实例间广播事件，各实例轮询读取其他实例写入的 WebSocket 消息
+type ClusterEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Origin    string    `gorm:"size:191;not null" json:"origin"`
+	Payload   string    `gorm:"type:text;not null" json:"payload"`
+	CreatedAt time.Time `gorm:"not null;index:idx_cluster_event_created_at" json:"created_at"`
+}
+
+// TableName 指定表名
+func (ClusterEvent) TableName() string {
+	return "cluster_events"
+}
@@ -48,7 +48,7 @@ const (
 // Configuration 系统配置模型
 type Configuration struct {
 	ID           uint      `json:"id" gorm:"primaryKey"`
-	Key          string    `json:"key" gorm:"uniqueIndex;not null"`
+	Key          string    `json:"key" gorm:"size:191;uniqueIndex;not null"`
 	Value        string    `json:"value" gorm:"type:text"`
 	DefaultValue string    `json:"default_value" gorm:"type:text"`
 	Description  string    `json:"description" gorm:"type:text"`
@@ -233,7 +233,7 @@ func (c *ConfigurationBackup) SetData(data map[string]interface{}) error {
 // ConfigurationTemplate 配置模板模型
 type ConfigurationTemplate struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null;uniqueIndex"`
+	Name        string    `json:"name" gorm:"size:191;not null;uniqueIndex"`
 	Description string    `json:"description" gorm:"type:text"`
 	Category    string    `json:"category" gorm:"not null;index"`
 	Template    string    `json:"template" gorm:"type:longtext;not null"` // JSON格式的模板数据
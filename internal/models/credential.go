@@ -0,0 +1,82 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"superview/internal/secrets"
+
+	"gorm.io/gorm/schema"
+)
+
+// CredentialCipher 凭据加解密接口（由 secrets.Keyring 实现）
+type CredentialCipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(value string) (string, error)
+	IsEncrypted(value string) bool
+}
+
+var (
+	credentialCipher   CredentialCipher
+	credentialCipherMu sync.RWMutex
+)
+
+// SetCredentialCipher 设置凭据加密器；未设置时凭据按明文读写
+func SetCredentialCipher(c CredentialCipher) {
+	credentialCipherMu.Lock()
+	defer credentialCipherMu.Unlock()
+	credentialCipher = c
+}
+
+func getCredentialCipher() CredentialCipher {
+	credentialCipherMu.RLock()
+	defer credentialCipherMu.RUnlock()
+	return credentialCipher
+}
+
+func init() {
+	schema.RegisterSerializer("credential", credentialSerializer{})
+}
+
+// credentialSerializer 写入时加密、读取时解密的字段序列化器，
+// 使用 `gorm:"serializer:credential"` 的字段对上层代码始终是明文
+type credentialSerializer struct{}
+
+// Scan 读取时解密
+func (credentialSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("unsupported credential column type %T", dbValue)
+	}
+
+	if c := getCredentialCipher(); c != nil {
+		plaintext, err := c.Decrypt(value)
+		if err != nil {
+			return err
+		}
+		value = plaintext
+	} else if secrets.IsEncrypted(value) {
+		return fmt.Errorf("credential is encrypted but no credential key is configured")
+	}
+
+	field.ReflectValueOf(ctx, dst).SetString(value)
+	return nil
+}
+
+// Value 写入时加密；已是密文的值原样写入
+func (credentialSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, _ := fieldValue.(string)
+	c := getCredentialCipher()
+	if c == nil || value == "" || c.IsEncrypted(value) {
+		return value, nil
+	}
+	return c.Encrypt(value)
+}
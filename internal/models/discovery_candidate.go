@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Discovery candidate status constants
+const (
+	CandidateStatusPending  = "pending"
+	CandidateStatusApproved = "approved"
+	CandidateStatusRejected = "rejected"
+)
+
+// DiscoveryCandidate is a discovered Supervisor waiting for review before it
+// becomes a node. Name, Environment and Labels are suggestions from the
+// matching discovery rule and can be overridden on approval.
+type DiscoveryCandidate struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index:idx_discovery_candidate_deleted_at" json:"-"`
+
+	TaskID uint   `gorm:"not null;index:idx_discovery_candidate_task_id" json:"task_id"` // Last task that found it
+	Host   string `gorm:"size:255" json:"host,omitempty"`
+	IP     string `gorm:"size:50;not null" json:"ip"`
+	Port   int    `gorm:"not null;check:port > 0 AND port <= 65535" json:"port"`
+
+	// Credentials the scan used, needed to register the node on approval
+	Username string `gorm:"size:50" json:"username"`
+	Password string `gorm:"size:512;serializer:credential" json:"-"`
+
+	// Probe details
+	Version        string    `gorm:"size:50" json:"version,omitempty"`
+	Identification string    `gorm:"size:255" json:"identification,omitempty"`
+	ProcessCount   int       `gorm:"not null;default:0" json:"process_count"`
+	LastSeenAt     time.Time `gorm:"not null" json:"last_seen_at"`
+
+	Rule        string            `gorm:"size:100" json:"rule,omitempty"`
+	Name        string            `gorm:"size:100" json:"name"`
+	Environment string            `gorm:"size:50" json:"environment"`
+	Labels      map[string]string `gorm:"type:text;serializer:json" json:"labels,omitempty"`
+
+	Status     string     `gorm:"size:20;not null;default:'pending';index:idx_discovery_candidate_status" json:"status"`
+	ReviewedBy string     `gorm:"size:50" json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	Reason     string     `gorm:"size:255" json:"reason,omitempty"` // Rejection reason
+	NodeID     *uint      `json:"node_id,omitempty"`                // Set once approved
+}
+
+// Address returns the hostname if known, otherwise the IP.
+func (c *DiscoveryCandidate) Address() string {
+	if c.Host != "" {
+		return c.Host
+	}
+	return c.IP
+}
+
+// DiscoveryBlocklistEntry keeps matching Supervisors out of discovery.
+// Address is an IP, a CIDR or a hostname; Port 0 blocks every port.
+type DiscoveryBlocklistEntry struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+
+	Address   string `gorm:"size:255;not null;index:idx_discovery_blocklist_address" json:"address"`
+	Port      int    `gorm:"not null;default:0" json:"port"`
+	Reason    string `gorm:"size:255" json:"reason,omitempty"`
+	CreatedBy string `gorm:"size:50" json:"created_by"`
+}
+
+// TableName keeps the table name short
+func (DiscoveryBlocklistEntry) TableName() string {
+	return "discovery_blocklist"
+}
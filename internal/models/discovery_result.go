@@ -15,6 +15,15 @@ const (
 	ResultStatusError             = "error"
 )
 
+// What happened to a successful probe
+const (
+	ResultActionRegistered = "registered" // Added as a new node
+	ResultActionExisting   = "existing"   // A node with the same host:port already exists
+	ResultActionPending    = "pending"    // Held for approval by a discovery rule
+	ResultActionIgnored    = "ignored"    // Skipped by a discovery rule
+	ResultActionBlocked    = "blocked"    // Matches the discovery blocklist
+)
+
 // DiscoveryResult represents the outcome of probing a single IP address.
 // Each result is linked to a parent DiscoveryTask.
 type DiscoveryResult struct {
@@ -23,6 +32,7 @@ type DiscoveryResult struct {
 	DeletedAt gorm.DeletedAt `gorm:"index:idx_discovery_result_deleted_at" json:"-"`
 
 	TaskID uint   `gorm:"index:idx_discovery_result_task_id;not null" json:"task_id"`
+	Host   string `gorm:"size:255" json:"host,omitempty"` // Hostname the IP was resolved from
 	IP     string `gorm:"size:50;not null" json:"ip"`
 	Port   int    `gorm:"not null;check:port > 0 AND port <= 65535" json:"port"`
 
@@ -33,6 +43,8 @@ type DiscoveryResult struct {
 	NodeName string `gorm:"size:100" json:"node_name,omitempty"`     // Generated name
 	Version  string `gorm:"size:50" json:"version,omitempty"`        // Supervisor version
 	ErrorMsg string `gorm:"size:500" json:"error_msg,omitempty"`     // Error details
+	Action   string `gorm:"size:20" json:"action,omitempty"`         // Outcome for successful probes
+	Rule     string `gorm:"size:100" json:"rule,omitempty"`          // Discovery rule that decided the outcome
 
 	Duration int64 `json:"duration_ms"` // Probe duration in milliseconds
 }
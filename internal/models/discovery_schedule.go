@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DiscoverySchedule re-scans saved targets on a cron schedule.
+// Each run creates a regular DiscoveryTask and a DiscoveryReport comparing it
+// with the previous completed run of the same schedule.
+type DiscoverySchedule struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index:idx_discovery_schedule_deleted_at" json:"-"`
+
+	Name     string `gorm:"size:100;not null;uniqueIndex:idx_discovery_schedule_name" json:"name"`
+	CronExpr string `gorm:"size:100;not null" json:"cron_expr"`
+	Enabled  bool   `gorm:"not null;default:true" json:"enabled"`
+
+	CIDR     string            `gorm:"size:50" json:"cidr,omitempty"`
+	Port     int               `json:"port,omitempty"`
+	Targets  *DiscoveryTargets `gorm:"type:text;serializer:json" json:"targets,omitempty"`
+	Ports    string            `gorm:"size:200" json:"ports,omitempty"`
+	Username string            `gorm:"size:50" json:"username"`
+	Password string            `gorm:"size:512;serializer:credential" json:"-"` // Encrypted at rest, see credential.go
+
+	TimeoutSeconds int `gorm:"not null;default:0" json:"timeout_seconds"`
+	MaxWorkers     int `gorm:"not null;default:0" json:"max_workers"`
+	RateLimit      int `gorm:"not null;default:0" json:"rate_limit"`
+
+	RequireApproval bool `gorm:"not null;default:false" json:"require_approval"`
+
+	LastRunAt  *time.Time `json:"last_run_at,omitempty"`
+	LastTaskID *uint      `json:"last_task_id,omitempty"`
+	LastError  string     `gorm:"size:500" json:"last_error,omitempty"`
+
+	CreatedBy string `gorm:"size:50;not null" json:"created_by"`
+}
+
+// Discovery rule actions
+const (
+	DiscoveryActionRegister = "register" // Add the node right away
+	DiscoveryActionApprove  = "approve"  // Hold the node for review
+	DiscoveryActionIgnore   = "ignore"   // Do not add the node
+)
+
+// DiscoveryRule decides what happens to a newly found supervisord instance.
+// Rules are evaluated by ascending priority; the first rule whose CIDR and
+// hostname pattern both match wins. Empty match fields match everything.
+// Without a matching rule nodes are registered in environment "discovered".
+type DiscoveryRule struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index:idx_discovery_rule_deleted_at" json:"-"`
+
+	Name     string `gorm:"size:100;not null" json:"name"`
+	Priority int    `gorm:"not null;default:0;index:idx_discovery_rule_priority" json:"priority"`
+	Enabled  bool   `gorm:"not null;default:true" json:"enabled"`
+
+	// Match conditions
+	CIDR        string `gorm:"size:50" json:"cidr,omitempty"`
+	HostPattern string `gorm:"size:255" json:"host_pattern,omitempty"` // Glob on the hostname, e.g. *.prod.example.com
+
+	// Outcome
+	Action      string            `gorm:"size:20;not null" json:"action"`
+	Environment string            `gorm:"size:50" json:"environment,omitempty"`
+	NamePrefix  string            `gorm:"size:50" json:"name_prefix,omitempty"` // Replaces the default "node-" prefix
+	Labels      map[string]string `gorm:"type:text;serializer:json" json:"labels,omitempty"`
+}
+
+// DiscoveryChange is one entry of a DiscoveryReport.
+type DiscoveryChange struct {
+	Host            string `json:"host,omitempty"`
+	IP              string `json:"ip"`
+	Port            int    `json:"port"`
+	Version         string `json:"version,omitempty"`
+	PreviousVersion string `json:"previous_version,omitempty"`
+	NodeName        string `json:"node_name,omitempty"`
+	Action          string `json:"action,omitempty"` // See DiscoveryResult.Action
+}
+
+// DiscoveryReport lists the supervisord instances that appeared, vanished or
+// changed version between two runs of a schedule.
+type DiscoveryReport struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+
+	ScheduleID     uint  `gorm:"not null;index:idx_discovery_report_schedule_id" json:"schedule_id"`
+	TaskID         uint  `gorm:"not null;uniqueIndex:idx_discovery_report_task_id" json:"task_id"`
+	BaselineTaskID *uint `json:"baseline_task_id,omitempty"` // Nil for the first run
+
+	Added    []DiscoveryChange `gorm:"type:text;serializer:json" json:"added"`
+	Vanished []DiscoveryChange `gorm:"type:text;serializer:json" json:"vanished"`
+	Changed  []DiscoveryChange `gorm:"type:text;serializer:json" json:"changed"`
+}
+
+// HasChanges returns true if the report has any entry.
+func (r *DiscoveryReport) HasChanges() bool {
+	return len(r.Added)+len(r.Vanished)+len(r.Changed) > 0
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,6 +16,23 @@ const (
 	DiscoveryStatusFailed    = "failed"
 )
 
+// DiscoveryTargets lists what a discovery task scans in addition to the legacy
+// single CIDR. Hostnames may contain numeric ranges such as app-[1-3].example.com;
+// SRV names are resolved to host and port pairs.
+type DiscoveryTargets struct {
+	CIDRs     []string `json:"cidrs,omitempty"`
+	IPRanges  []string `json:"ip_ranges,omitempty"`
+	Hostnames []string `json:"hostnames,omitempty"`
+	SRV       []string `json:"srv,omitempty"`
+	// Exclude accepts IPs, CIDRs, IP ranges and hostnames
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Empty returns true if no scan targets are set (Exclude is not a target).
+func (t *DiscoveryTargets) Empty() bool {
+	return t == nil || len(t.CIDRs)+len(t.IPRanges)+len(t.Hostnames)+len(t.SRV) == 0
+}
+
 // DiscoveryTask represents a network discovery scan operation.
 // It tracks the progress and results of scanning a CIDR range for Supervisor nodes.
 type DiscoveryTask struct {
@@ -26,6 +44,17 @@ type DiscoveryTask struct {
 	CIDR     string `gorm:"size:50;not null" json:"cidr"`
 	Port     int    `gorm:"not null;check:port > 0 AND port <= 65535" json:"port"`
 	Username string `gorm:"size:50" json:"username"`
+
+	// Targets and Ports extend CIDR and Port; Port holds the first port of the list
+	Targets   *DiscoveryTargets `gorm:"type:text;serializer:json" json:"targets,omitempty"`
+	Ports     string            `gorm:"size:200" json:"ports,omitempty"`
+	RateLimit int               `gorm:"not null;default:0" json:"rate_limit"` // probes per second, 0 = unlimited
+
+	// RequireApproval holds every new node for review instead of registering it
+	RequireApproval bool `gorm:"not null;default:false" json:"require_approval"`
+
+	// ScheduleID is set for tasks started by a DiscoverySchedule
+	ScheduleID *uint `gorm:"index:idx_discovery_task_schedule_id" json:"schedule_id,omitempty"`
 	// Password NOT stored - security requirement
 
 	Status string `gorm:"size:20;not null;default:'pending';index:idx_discovery_task_status" json:"status"`
@@ -72,6 +101,25 @@ func (t *DiscoveryTask) IsTerminal() bool {
 	return t.IsCompleted() || t.IsCancelled() || t.IsFailed()
 }
 
+// Scope describes what the task scans, for logs and messages.
+func (t *DiscoveryTask) Scope() string {
+	var parts []string
+	if t.CIDR != "" {
+		parts = append(parts, t.CIDR)
+	}
+	if t.Targets != nil {
+		parts = append(parts, t.Targets.CIDRs...)
+		parts = append(parts, t.Targets.IPRanges...)
+		parts = append(parts, t.Targets.Hostnames...)
+		parts = append(parts, t.Targets.SRV...)
+	}
+	scope := strings.Join(parts, ", ")
+	if len(scope) > 200 {
+		scope = scope[:197] + "..."
+	}
+	return scope
+}
+
 // Progress returns the scan progress as a percentage (0-100).
 func (t *DiscoveryTask) Progress() float64 {
 	if t.TotalIPs == 0 {
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 	"gorm.io/gorm"
@@ -15,12 +16,52 @@ type Node struct {
 	Host        string `gorm:"size:100;not null;index:idx_host" json:"host" validate:"required,hostname_rfc1123|ip"`
 	Port        int    `gorm:"not null;check:port > 0 AND port <= 65535" json:"port" validate:"required,min=1,max=65535"`
 	Username    string `gorm:"size:50" json:"username" validate:"omitempty,max=50"`
-	Password    string `gorm:"size:100" json:"-" validate:"omitempty,max=100"`
+	Password    string `gorm:"size:512;serializer:credential" json:"-" validate:"omitempty,max=100"` // 落库时加密，见 credential.go
 	Status      string `gorm:"size:20;default:'unknown';index:idx_status" json:"status" validate:"omitempty,oneof=unknown active inactive connected disconnected"`
 	Environment string `gorm:"size:50;index:idx_environment" json:"environment" validate:"omitempty,max=50"`
 	Description string `gorm:"size:500" json:"description" validate:"omitempty,max=500"`
+	// 维护模式：暂停状态轮询和告警
+	Maintenance       bool       `gorm:"not null;default:false" json:"maintenance"`
+	MaintenanceReason string     `gorm:"size:255" json:"maintenance_reason,omitempty" validate:"omitempty,max=255"`
+	MaintenanceSince  *time.Time `json:"maintenance_since,omitempty"`
+	// 节点来源：config 节点随配置文件重载增删改，api/discovery 节点只能通过 API 管理
+	Source string `gorm:"size:20;not null;default:'config';index:idx_node_source" json:"source"`
+	// 标签（JSON 对象），用于选择器定位节点，如 region=eu、role=worker
+	Labels string `gorm:"type:text" json:"-"`
 }
 
+// GetLabels 获取节点标签
+func (n *Node) GetLabels() map[string]string {
+	labels := make(map[string]string)
+	if n.Labels != "" {
+		if err := json.Unmarshal([]byte(n.Labels), &labels); err != nil {
+			return make(map[string]string)
+		}
+	}
+	return labels
+}
+
+// SetLabels 设置节点标签
+func (n *Node) SetLabels(labels map[string]string) {
+	if len(labels) == 0 {
+		n.Labels = ""
+		return
+	}
+	data, err := json.Marshal(labels)
+	if err != nil {
+		n.Labels = ""
+		return
+	}
+	n.Labels = string(data)
+}
+
+// 节点来源
+const (
+	NodeSourceConfig    = "config"
+	NodeSourceAPI       = "api"
+	NodeSourceDiscovery = "discovery"
+)
+
 func (n *Node) GetConnectionString() string {
 	return fmt.Sprintf("%s:%d", n.Host, n.Port)
 }
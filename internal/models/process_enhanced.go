@@ -53,23 +53,24 @@ type ProcessDependency struct {
 
 // ScheduledTask 定时任务
 type ScheduledTask struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	Name        string         `json:"name" gorm:"not null;size:100"`
-	Description string         `json:"description" gorm:"size:500"`
-	TaskType    string         `json:"task_type" gorm:"not null;size:20"`   // start, stop, restart, custom_command
-	TargetType  string         `json:"target_type" gorm:"not null;size:20"` // process, group, node
-	TargetID    string         `json:"target_id" gorm:"not null;size:100"`  // 目标ID或名称
-	NodeID      *uint          `json:"node_id,omitempty"`
-	CronExpr    string         `json:"cron_expr" gorm:"not null;size:100"` // Cron表达式
-	Command     *string        `json:"command,omitempty" gorm:"type:text"` // 自定义命令
-	Enabled     bool           `json:"enabled" gorm:"default:true"`
-	LastRun     *time.Time     `json:"last_run,omitempty"`
-	NextRun     *time.Time     `json:"next_run,omitempty"`
-	RunCount    int            `json:"run_count" gorm:"default:0"`
-	CreatedBy   uint           `json:"created_by"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Name         string         `json:"name" gorm:"not null;size:100"`
+	Description  string         `json:"description" gorm:"size:500"`
+	TaskType     string         `json:"task_type" gorm:"not null;size:20"`   // start, stop, restart, custom_command
+	TargetType   string         `json:"target_type" gorm:"not null;size:20"` // process, group, node
+	TargetID     string         `json:"target_id" gorm:"not null;size:100"`  // 目标ID或名称
+	NodeID       *uint          `json:"node_id,omitempty"`
+	NodeSelector string         `json:"node_selector,omitempty" gorm:"size:500"` // 节点标签选择器，与 NodeID 同时设置时取交集
+	CronExpr     string         `json:"cron_expr" gorm:"not null;size:100"`      // Cron表达式
+	Command      *string        `json:"command,omitempty" gorm:"type:text"`      // 自定义命令
+	Enabled      bool           `json:"enabled" gorm:"default:true"`
+	LastRun      *time.Time     `json:"last_run,omitempty"`
+	NextRun      *time.Time     `json:"next_run,omitempty"`
+	RunCount     int            `json:"run_count" gorm:"default:0"`
+	CreatedBy    uint           `json:"created_by"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// 关联
 	User       User            `json:"user,omitempty" gorm:"foreignKey:CreatedBy"`
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// ProcessQuarantine 因抖动被停止并隔离的进程。未确认（AcknowledgedAt 为空）期间拒绝启动该进程
+type ProcessQuarantine struct {
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	NodeName    string  `gorm:"size:100;not null;index:idx_quarantine_process" json:"node_name"`
+	ProcessName string  `gorm:"size:100;not null;index:idx_quarantine_process" json:"process_name"`
+	Reason      string  `gorm:"size:500" json:"reason"`
+	Score       float64 `json:"flapping_score"`
+	Restarts    int     `json:"restarts"`
+	Window      string  `gorm:"size:20" json:"window"`
+	AlertID     *uint   `json:"alert_id,omitempty"`
+
+	QuarantinedAt  time.Time  `gorm:"not null" json:"quarantined_at"`
+	AcknowledgedBy string     `gorm:"size:100" json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time `gorm:"index:idx_quarantine_acknowledged_at" json:"acknowledged_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ProcessQuarantine) TableName() string {
+	return "process_quarantines"
+}
+
+// AlertRuleProcessFlapping 进程抖动告警使用的系统规则名称
+const AlertRuleProcessFlapping = "Process Flapping"
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 滚动重启状态
+const (
+	RolloutStatusPending   = "pending"
+	RolloutStatusRunning   = "running"
+	RolloutStatusPaused    = "paused"
+	RolloutStatusCompleted = "completed"
+	RolloutStatusFailed    = "failed"
+	RolloutStatusCancelled = "cancelled"
+)
+
+// 滚动重启中单个节点的状态
+const (
+	RolloutTargetPending    = "pending"
+	RolloutTargetRestarting = "restarting"
+	RolloutTargetHealthy    = "healthy"
+	RolloutTargetFailed     = "failed"
+	RolloutTargetSkipped    = "skipped"
+)
+
+// RolloutTarget 滚动重启的一个节点及其需要重启的进程
+type RolloutTarget struct {
+	Node        string     `json:"node"`
+	Environment string     `json:"environment"`
+	Processes   []string   `json:"processes"`
+	Batch       int        `json:"batch"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// Rollout 跨节点分批滚动重启一个进程或进程组
+// 每批重启后等待进程持续 RUNNING 达到 HealthySeconds 才进入下一批，失败数超过阈值时中止
+type Rollout struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	ProcessName string `gorm:"size:100" json:"process_name,omitempty"`
+	GroupName   string `gorm:"size:100" json:"group_name,omitempty"`
+	Selector    string `gorm:"size:500" json:"selector,omitempty"`
+
+	BatchSize            int `gorm:"not null;default:1" json:"batch_size"`
+	PauseSeconds         int `gorm:"not null;default:0" json:"pause_seconds"`
+	HealthySeconds       int `gorm:"not null;default:0" json:"healthy_seconds"`
+	HealthTimeoutSeconds int `gorm:"not null;default:60" json:"health_timeout_seconds"`
+	MaxFailures          int `gorm:"not null;default:0" json:"max_failures"` // 允许失败的节点数，超过即中止
+
+	Status           string `gorm:"size:20;not null;default:'pending';index:idx_rollout_status" json:"status"`
+	TotalBatches     int    `gorm:"not null;default:0" json:"total_batches"`
+	CurrentBatch     int    `gorm:"not null;default:0" json:"current_batch"`
+	TotalTargets     int    `gorm:"not null;default:0" json:"total_targets"`
+	SucceededTargets int    `gorm:"not null;default:0" json:"succeeded_targets"`
+	FailedTargets    int    `gorm:"not null;default:0" json:"failed_targets"`
+	Targets          string `gorm:"type:text" json:"-"` // JSON 数组，见 RolloutTarget
+
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ErrorMsg    string     `gorm:"size:500" json:"error_msg,omitempty"`
+	CreatedBy   string     `gorm:"size:100;not null" json:"created_by"`
+	CreatedAt   time.Time  `gorm:"index:idx_rollout_created_at" json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (Rollout) TableName() string {
+	return "rollouts"
+}
+
+// IsTerminal 是否已结束（完成、失败或取消）
+func (r *Rollout) IsTerminal() bool {
+	return r.Status == RolloutStatusCompleted || r.Status == RolloutStatusFailed || r.Status == RolloutStatusCancelled
+}
+
+// GetTargets 获取目标节点列表
+func (r *Rollout) GetTargets() []RolloutTarget {
+	var targets []RolloutTarget
+	if r.Targets != "" {
+		json.Unmarshal([]byte(r.Targets), &targets)
+	}
+	return targets
+}
+
+// SetTargets 设置目标节点列表
+func (r *Rollout) SetTargets(targets []RolloutTarget) {
+	data, err := json.Marshal(targets)
+	if err != nil {
+		return
+	}
+	r.Targets = string(data)
+}
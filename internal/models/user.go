@@ -16,6 +16,10 @@ type User struct {
 	IsActive  bool           `gorm:"default:true;not null;index:idx_active" json:"is_active"`
 	IsAdmin   bool           `gorm:"default:false;not null;index:idx_admin" json:"is_admin"` // 保持向后兼容
 	LastLogin *time.Time     `gorm:"index:idx_last_login" json:"last_login"`
+
+	// 密码策略相关
+	PasswordChangedAt  *time.Time `json:"password_changed_at"`
+	MustChangePassword bool       `gorm:"default:false;not null" json:"must_change_password"` // 下次登录必须修改密码
 	CreatedAt time.Time      `gorm:"not null;index:idx_created_at" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index:idx_user_deleted_at" json:"-"`
@@ -33,15 +37,40 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// SetPassword 设置密码哈希并记录修改时间
+// 密码强度和历史校验由 services.PasswordPolicy 负责
 func (u *User) SetPassword(password string) error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 	u.Password = string(hashedPassword)
+	now := time.Now()
+	u.PasswordChangedAt = &now
 	return nil
 }
 
+// PasswordAge 获取当前密码已使用的时长（从未记录修改时间时按创建时间计算）
+func (u *User) PasswordAge() time.Duration {
+	if u.PasswordChangedAt != nil {
+		return time.Since(*u.PasswordChangedAt)
+	}
+	return time.Since(u.CreatedAt)
+}
+
+// PasswordHistory 用户历史密码哈希，用于阻止重复使用最近的密码
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       string    `gorm:"size:36;not null;index:idx_password_history_user" json:"user_id"`
+	PasswordHash string    `gorm:"size:120;not null" json:"-"`
+	CreatedAt    time.Time `gorm:"index:idx_password_history_created_at" json:"created_at"`
+}
+
+// TableName 指定表名
+func (PasswordHistory) TableName() string {
+	return "password_histories"
+}
+
 func (u *User) VerifyPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
@@ -0,0 +1,425 @@
+package promql
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultLookback 瞬时选择器向前查找采样点的时间窗口，与 Prometheus 一致
+	DefaultLookback = 5 * time.Minute
+	// MaxPoints 单条序列在区间查询中允许的最大点数，与 Prometheus 一致
+	MaxPoints = 11000
+)
+
+// Engine 在 Storage 之上执行查询
+type Engine struct {
+	storage  Storage
+	Lookback time.Duration
+}
+
+// NewEngine 创建查询引擎
+func NewEngine(storage Storage) *Engine {
+	return &Engine{storage: storage, Lookback: DefaultLookback}
+}
+
+// Instant 在时间点 t 执行瞬时查询，结果为 Vector、Scalar，或区间选择器对应的 Matrix
+func (e *Engine) Instant(query string, t time.Time) (Value, error) {
+	expr, err := ParseExpr(query)
+	if err != nil {
+		return nil, err
+	}
+	ev, err := e.newEvaluator(expr, t, t, 0)
+	if err != nil {
+		return nil, err
+	}
+	ts := t.UnixMilli()
+
+	if selector, ok := expr.(*VectorSelector); ok && selector.Range > 0 {
+		matrix := Matrix{}
+		for _, s := range ev.data[selector] {
+			points := window(s.Points, ts-selector.Range.Milliseconds(), ts)
+			if len(points) > 0 {
+				matrix = append(matrix, Series{Labels: s.Labels, Points: points})
+			}
+		}
+		sortMatrix(matrix)
+		return matrix, nil
+	}
+
+	value, err := ev.eval(expr, ts)
+	if err != nil {
+		return nil, err
+	}
+	if vector, ok := value.(Vector); ok {
+		sort.Slice(vector, func(i, j int) bool { return vector[i].Labels.signature() < vector[j].Labels.signature() })
+	}
+	return value, nil
+}
+
+// Range 在 [start, end] 内按 step 执行区间查询，结果为 Matrix
+func (e *Engine) Range(query string, start, end time.Time, step time.Duration) (Matrix, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("zero or negative query resolution step widths are not accepted")
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("end timestamp must not be before start time")
+	}
+	if int64(end.Sub(start)/step) >= MaxPoints {
+		return nil, fmt.Errorf("exceeded maximum resolution of %d points per timeseries, try decreasing the query resolution", MaxPoints)
+	}
+
+	expr, err := ParseExpr(query)
+	if err != nil {
+		return nil, err
+	}
+	if selector, ok := expr.(*VectorSelector); ok && selector.Range > 0 {
+		return nil, fmt.Errorf("range vector selectors are not allowed in range queries, use a function such as rate()")
+	}
+	ev, err := e.newEvaluator(expr, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+
+	series := make(map[string]*Series)
+	for t := start; !t.After(end); t = t.Add(step) {
+		ts := t.UnixMilli()
+		value, err := ev.eval(expr, ts)
+		if err != nil {
+			return nil, err
+		}
+		switch v := value.(type) {
+		case Scalar:
+			appendPoint(series, Labels{}, Point{T: ts, V: v.V})
+		case Vector:
+			for _, sample := range v {
+				if !appendPoint(series, sample.Labels, Point{T: ts, V: sample.V}) {
+					return nil, fmt.Errorf("vector cannot contain metrics with the same labelset")
+				}
+			}
+		}
+	}
+
+	matrix := make(Matrix, 0, len(series))
+	for _, s := range series {
+		matrix = append(matrix, *s)
+	}
+	sortMatrix(matrix)
+	return matrix, nil
+}
+
+// appendPoint 把点追加到对应序列，同一时间点出现重复标签集合时返回 false
+func appendPoint(series map[string]*Series, labels Labels, p Point) bool {
+	key := labels.signature()
+	s, ok := series[key]
+	if !ok {
+		s = &Series{Labels: labels}
+		series[key] = s
+	}
+	if n := len(s.Points); n > 0 && s.Points[n-1].T == p.T {
+		return false
+	}
+	s.Points = append(s.Points, p)
+	return true
+}
+
+func sortMatrix(m Matrix) {
+	sort.Slice(m, func(i, j int) bool { return m[i].Labels.signature() < m[j].Labels.signature() })
+}
+
+// evaluator 保存一次查询预先加载的数据，每个选择器只访问一次 Storage
+type evaluator struct {
+	lookback int64
+	data     map[*VectorSelector][]Series
+}
+
+func (e *Engine) newEvaluator(expr Expr, start, end time.Time, step time.Duration) (*evaluator, error) {
+	lookback := e.Lookback
+	if lookback <= 0 {
+		lookback = DefaultLookback
+	}
+	ev := &evaluator{lookback: lookback.Milliseconds(), data: make(map[*VectorSelector][]Series)}
+
+	var selectors []*VectorSelector
+	walk(expr, func(s *VectorSelector) { selectors = append(selectors, s) })
+	for _, selector := range selectors {
+		hints := SelectHints{Start: start, End: end, Step: step, Range: selector.Range, Lookback: lookback}
+		series, err := e.storage.Select(hints, selector.Matchers)
+		if err != nil {
+			return nil, err
+		}
+		ev.data[selector] = series
+	}
+	return ev, nil
+}
+
+func walk(expr Expr, fn func(*VectorSelector)) {
+	switch n := expr.(type) {
+	case *VectorSelector:
+		fn(n)
+	case *Call:
+		walk(n.Arg, fn)
+	case *Aggregate:
+		walk(n.Expr, fn)
+	case *Binary:
+		walk(n.LHS, fn)
+		walk(n.RHS, fn)
+	}
+}
+
+// eval 在时间点 ts 上求值，返回 Vector 或 Scalar
+func (ev *evaluator) eval(expr Expr, ts int64) (Value, error) {
+	switch n := expr.(type) {
+	case *NumberLiteral:
+		return Scalar{T: ts, V: n.Val}, nil
+
+	case *VectorSelector:
+		if n.Range > 0 {
+			return nil, fmt.Errorf("unexpected range vector selector, use a function such as rate()")
+		}
+		vector := Vector{}
+		for _, s := range ev.data[n] {
+			points := window(s.Points, ts-ev.lookback, ts)
+			if len(points) > 0 {
+				vector = append(vector, Sample{Labels: s.Labels, Point: Point{T: ts, V: points[len(points)-1].V}})
+			}
+		}
+		return vector, nil
+
+	case *Call:
+		selector := n.Arg.(*VectorSelector)
+		rangeMs := selector.Range.Milliseconds()
+		vector := Vector{}
+		for _, s := range ev.data[selector] {
+			value, ok := applyRangeFunction(n.Func, window(s.Points, ts-rangeMs, ts), selector.Range)
+			if ok {
+				vector = append(vector, Sample{Labels: s.Labels.copyWithout(MetricNameLabel), Point: Point{T: ts, V: value}})
+			}
+		}
+		return vector, nil
+
+	case *Aggregate:
+		value, err := ev.eval(n.Expr, ts)
+		if err != nil {
+			return nil, err
+		}
+		vector, ok := value.(Vector)
+		if !ok {
+			return nil, fmt.Errorf("%s expects an instant vector", n.Op)
+		}
+		return aggregate(n, vector, ts), nil
+
+	case *Binary:
+		lhs, err := ev.eval(n.LHS, ts)
+		if err != nil {
+			return nil, err
+		}
+		rhs, err := ev.eval(n.RHS, ts)
+		if err != nil {
+			return nil, err
+		}
+		return binary(n.Op, lhs, rhs, ts), nil
+	}
+	return nil, fmt.Errorf("unsupported expression %T", expr)
+}
+
+// window 返回 (from, to] 内的采样点，points 按时间升序
+func window(points []Point, from, to int64) []Point {
+	lo := sort.Search(len(points), func(i int) bool { return points[i].T > from })
+	hi := sort.Search(len(points), func(i int) bool { return points[i].T > to })
+	return points[lo:hi]
+}
+
+// applyRangeFunction 计算区间函数。rate/increase/delta 不做 Prometheus 的边界外推，
+// 而是按区间内首尾采样点的时间差换算，采样点不足两个时没有结果
+func applyRangeFunction(fn string, points []Point, rng time.Duration) (float64, bool) {
+	if len(points) == 0 {
+		return 0, false
+	}
+	first, last := points[0], points[len(points)-1]
+
+	switch fn {
+	case "rate", "increase", "delta":
+		if len(points) < 2 || last.T == first.T {
+			return 0, false
+		}
+		span := float64(last.T-first.T) / 1000
+		var change float64
+		if fn == "delta" {
+			change = last.V - first.V
+		} else {
+			change = counterIncrease(points)
+		}
+		perSecond := change / span
+		if fn == "rate" {
+			return perSecond, true
+		}
+		return perSecond * rng.Seconds(), true
+
+	case "irate":
+		if len(points) < 2 {
+			return 0, false
+		}
+		prev := points[len(points)-2]
+		change := last.V - prev.V
+		if last.V < prev.V {
+			change = last.V
+		}
+		return change / (float64(last.T-prev.T) / 1000), true
+
+	case "avg_over_time", "sum_over_time":
+		var sum float64
+		for _, p := range points {
+			sum += p.V
+		}
+		if fn == "avg_over_time" {
+			return sum / float64(len(points)), true
+		}
+		return sum, true
+
+	case "min_over_time", "max_over_time":
+		result := first.V
+		for _, p := range points[1:] {
+			if (fn == "min_over_time" && p.V < result) || (fn == "max_over_time" && p.V > result) {
+				result = p.V
+			}
+		}
+		return result, true
+
+	case "count_over_time":
+		return float64(len(points)), true
+
+	case "last_over_time":
+		return last.V, true
+	}
+	return 0, false
+}
+
+// counterIncrease 计数器的增量，数值变小视为计数器重置
+func counterIncrease(points []Point) float64 {
+	var total float64
+	for i := 1; i < len(points); i++ {
+		if points[i].V < points[i-1].V {
+			total += points[i].V
+		} else {
+			total += points[i].V - points[i-1].V
+		}
+	}
+	return total
+}
+
+// aggregate 按 by/without 分组聚合
+func aggregate(agg *Aggregate, vector Vector, ts int64) Vector {
+	type group struct {
+		labels Labels
+		value  float64
+		count  int
+	}
+	groups := make(map[string]*group)
+	var order []string
+
+	for _, sample := range vector {
+		labels := groupLabels(agg, sample.Labels)
+		key := labels.signature()
+		g, ok := groups[key]
+		if !ok {
+			g = &group{labels: labels, value: sample.V}
+			groups[key] = g
+			order = append(order, key)
+			g.count = 1
+			continue
+		}
+		g.count++
+		switch agg.Op {
+		case "sum", "avg":
+			g.value += sample.V
+		case "min":
+			g.value = math.Min(g.value, sample.V)
+		case "max":
+			g.value = math.Max(g.value, sample.V)
+		}
+	}
+
+	result := make(Vector, 0, len(order))
+	for _, key := range order {
+		g := groups[key]
+		value := g.value
+		switch agg.Op {
+		case "avg":
+			value = g.value / float64(g.count)
+		case "count":
+			value = float64(g.count)
+		}
+		result = append(result, Sample{Labels: g.labels, Point: Point{T: ts, V: value}})
+	}
+	return result
+}
+
+func groupLabels(agg *Aggregate, labels Labels) Labels {
+	if agg.Without {
+		return labels.copyWithout(append(agg.Grouping, MetricNameLabel)...)
+	}
+	result := Labels{}
+	for _, name := range agg.Grouping {
+		if value, ok := labels[name]; ok {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// binary 四则运算。向量之间按去掉 __name__ 后的标签一一匹配，结果不保留指标名称
+func binary(op string, lhs, rhs Value, ts int64) Value {
+	ls, lIsScalar := lhs.(Scalar)
+	rs, rIsScalar := rhs.(Scalar)
+
+	switch {
+	case lIsScalar && rIsScalar:
+		return Scalar{T: ts, V: arithmetic(op, ls.V, rs.V)}
+
+	case rIsScalar:
+		result := Vector{}
+		for _, sample := range lhs.(Vector) {
+			result = append(result, Sample{Labels: sample.Labels.copyWithout(MetricNameLabel), Point: Point{T: ts, V: arithmetic(op, sample.V, rs.V)}})
+		}
+		return result
+
+	case lIsScalar:
+		result := Vector{}
+		for _, sample := range rhs.(Vector) {
+			result = append(result, Sample{Labels: sample.Labels.copyWithout(MetricNameLabel), Point: Point{T: ts, V: arithmetic(op, ls.V, sample.V)}})
+		}
+		return result
+	}
+
+	right := make(map[string]Sample)
+	for _, sample := range rhs.(Vector) {
+		right[sample.Labels.copyWithout(MetricNameLabel).signature()] = sample
+	}
+	result := Vector{}
+	for _, sample := range lhs.(Vector) {
+		labels := sample.Labels.copyWithout(MetricNameLabel)
+		other, ok := right[labels.signature()]
+		if !ok {
+			continue
+		}
+		result = append(result, Sample{Labels: labels, Point: Point{T: ts, V: arithmetic(op, sample.V, other.V)}})
+	}
+	return result
+}
+
+func arithmetic(op string, a, b float64) float64 {
+	switch op {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "/":
+		return a / b
+	}
+	return math.NaN()
+}
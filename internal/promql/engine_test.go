@@ -0,0 +1,208 @@
+package promql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memStorage 内存中的测试数据
+type memStorage []Series
+
+func (m memStorage) Select(hints SelectHints, matchers []*Matcher) ([]Series, error) {
+	var result []Series
+	for _, s := range m {
+		if MatchLabels(s.Labels, matchers) {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+var base = time.Unix(1700000000, 0)
+
+// counter 每分钟一个采样点的序列
+func counter(labels Labels, values ...float64) Series {
+	s := Series{Labels: labels}
+	for i, v := range values {
+		s.Points = append(s.Points, Point{T: base.Add(time.Duration(i) * time.Minute).UnixMilli(), V: v})
+	}
+	return s
+}
+
+func testStorage() memStorage {
+	return memStorage{
+		counter(Labels{MetricNameLabel: "restarts_total", "node": "web-1", "process": "api"}, 0, 1, 2, 0, 1),
+		counter(Labels{MetricNameLabel: "restarts_total", "node": "web-1", "process": "worker"}, 5, 5, 5, 5, 5),
+		counter(Labels{MetricNameLabel: "restarts_total", "node": "web-2", "process": "api"}, 0, 2, 4, 6, 8),
+		counter(Labels{MetricNameLabel: "uptime_seconds", "node": "web-1", "process": "api"}, 60, 120, 180, 240, 300),
+	}
+}
+
+func TestParseExpr(t *testing.T) {
+	valid := []string{
+		`restarts_total`,
+		`restarts_total{node="web-1", process=~"api|worker"}`,
+		`{__name__="restarts_total",node!='web-2'}`,
+		`rate(restarts_total[5m])`,
+		`sum by (node) (rate(restarts_total{process!~"w.*"}[1h30m]))`,
+		`sum(increase(restarts_total[1d])) without (process)`,
+		`count(uptime_seconds) * 2 - -1`,
+		`uptime_seconds / 60`,
+		`(max_over_time(uptime_seconds[5m]))`,
+	}
+	for _, query := range valid {
+		_, err := ParseExpr(query)
+		assert.NoError(t, err, query)
+	}
+
+	invalid := []string{
+		``,
+		`{}`,
+		`rate(restarts_total)`,
+		`histogram_quantile(0.9, x)`,
+		`restarts_total{node="web-1"`,
+		`restarts_total{node=web}`,
+		`restarts_total[5x]`,
+		`sum by node (x)`,
+		`x{node=~"("}`,
+		`x > 1`,
+	}
+	for _, query := range invalid {
+		_, err := ParseExpr(query)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"30s":   30 * time.Second,
+		"5m":    5 * time.Minute,
+		"1h30m": 90 * time.Minute,
+		"2d":    48 * time.Hour,
+		"1w":    7 * 24 * time.Hour,
+		"250ms": 250 * time.Millisecond,
+		"15":    15 * time.Second,
+		"0.5":   500 * time.Millisecond,
+	}
+	for input, expected := range cases {
+		d, err := ParseDuration(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, d, input)
+	}
+	_, err := ParseDuration("5mm")
+	assert.Error(t, err)
+}
+
+func TestInstantSelectorAndLookback(t *testing.T) {
+	engine := NewEngine(testStorage())
+
+	value, err := engine.Instant(`restarts_total{process="api"}`, base.Add(4*time.Minute))
+	require.NoError(t, err)
+	vector := value.(Vector)
+	require.Len(t, vector, 2)
+	assert.Equal(t, "web-1", vector[0].Labels["node"])
+	assert.Equal(t, 1.0, vector[0].V)
+	assert.Equal(t, 8.0, vector[1].V)
+
+	// 最后一个采样点之后 5 分钟内仍然可见，超过后消失
+	value, err = engine.Instant(`uptime_seconds`, base.Add(8*time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, value.(Vector), 1)
+	value, err = engine.Instant(`uptime_seconds`, base.Add(10*time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, value.(Vector), 0)
+
+	value, err = engine.Instant(`1 + 2 * 3`, base)
+	require.NoError(t, err)
+	assert.Equal(t, 7.0, value.(Scalar).V)
+
+	value, err = engine.Instant(`uptime_seconds[2m]`, base.Add(4*time.Minute))
+	require.NoError(t, err)
+	matrix := value.(Matrix)
+	require.Len(t, matrix, 1)
+	assert.Len(t, matrix[0].Points, 2)
+}
+
+func TestRateHandlesCounterResets(t *testing.T) {
+	engine := NewEngine(testStorage())
+
+	// api@web-1: 0,1,2,0,1，第 3 分钟重置，增量为 1+1+0+1=3，跨度 240 秒
+	value, err := engine.Instant(`rate(restarts_total{node="web-1",process="api"}[10m])`, base.Add(4*time.Minute))
+	require.NoError(t, err)
+	vector := value.(Vector)
+	require.Len(t, vector, 1)
+	assert.InDelta(t, 3.0/240, vector[0].V, 1e-9)
+	assert.NotContains(t, vector[0].Labels, MetricNameLabel)
+
+	value, err = engine.Instant(`increase(restarts_total{node="web-2"}[4m])`, base.Add(4*time.Minute))
+	require.NoError(t, err)
+	// 窗口 (0m, 4m] 内为 2,4,6,8，180 秒增加 6，换算到 4 分钟为 8
+	assert.InDelta(t, 8.0, value.(Vector)[0].V, 1e-9)
+
+	value, err = engine.Instant(`irate(restarts_total{node="web-2"}[5m])`, base.Add(4*time.Minute))
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0/60, value.(Vector)[0].V, 1e-9)
+}
+
+func TestAggregation(t *testing.T) {
+	engine := NewEngine(testStorage())
+	at := base.Add(4 * time.Minute)
+
+	value, err := engine.Instant(`sum by (node) (restarts_total)`, at)
+	require.NoError(t, err)
+	vector := value.(Vector)
+	require.Len(t, vector, 2)
+	assert.Equal(t, Labels{"node": "web-1"}, vector[0].Labels)
+	assert.Equal(t, 6.0, vector[0].V)
+	assert.Equal(t, 8.0, vector[1].V)
+
+	value, err = engine.Instant(`count without (node) (restarts_total)`, at)
+	require.NoError(t, err)
+	vector = value.(Vector)
+	require.Len(t, vector, 2)
+	assert.Equal(t, Labels{"process": "api"}, vector[0].Labels)
+	assert.Equal(t, 2.0, vector[0].V)
+
+	value, err = engine.Instant(`max(restarts_total)`, at)
+	require.NoError(t, err)
+	assert.Equal(t, Vector{{Labels: Labels{}, Point: Point{T: at.UnixMilli(), V: 8}}}, value)
+}
+
+func TestBinaryVectorMatching(t *testing.T) {
+	engine := NewEngine(testStorage())
+
+	value, err := engine.Instant(`uptime_seconds / restarts_total`, base.Add(4*time.Minute))
+	require.NoError(t, err)
+	vector := value.(Vector)
+	require.Len(t, vector, 1)
+	assert.Equal(t, Labels{"node": "web-1", "process": "api"}, vector[0].Labels)
+	assert.Equal(t, 300.0, vector[0].V)
+
+	value, err = engine.Instant(`-uptime_seconds`, base)
+	require.NoError(t, err)
+	assert.Equal(t, -60.0, value.(Vector)[0].V)
+}
+
+func TestRangeQuery(t *testing.T) {
+	engine := NewEngine(testStorage())
+
+	matrix, err := engine.Range(`sum by (process) (restarts_total)`, base, base.Add(4*time.Minute), 2*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, matrix, 2)
+	assert.Equal(t, Labels{"process": "api"}, matrix[0].Labels)
+	assert.Equal(t, []Point{
+		{T: base.UnixMilli(), V: 0},
+		{T: base.Add(2 * time.Minute).UnixMilli(), V: 6},
+		{T: base.Add(4 * time.Minute).UnixMilli(), V: 9},
+	}, matrix[0].Points)
+
+	_, err = engine.Range(`restarts_total[5m]`, base, base.Add(time.Minute), time.Minute)
+	assert.Error(t, err)
+	_, err = engine.Range(`restarts_total`, base, base.Add(time.Hour), 0)
+	assert.Error(t, err)
+	_, err = engine.Range(`restarts_total`, base, base.Add(24*time.Hour), time.Second)
+	assert.Error(t, err)
+}
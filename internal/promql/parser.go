@@ -0,0 +1,482 @@
+package promql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expr 表达式节点
+type Expr interface{}
+
+// NumberLiteral 数字常量
+type NumberLiteral struct {
+	Val float64
+}
+
+// VectorSelector 指标选择器，Range 不为 0 时是区间选择器 metric[5m]
+type VectorSelector struct {
+	Matchers []*Matcher
+	Range    time.Duration
+}
+
+// Call 函数调用
+type Call struct {
+	Func string
+	Arg  Expr
+}
+
+// Aggregate 聚合表达式，如 sum by (node) (expr)
+type Aggregate struct {
+	Op       string
+	Expr     Expr
+	Grouping []string
+	Without  bool
+}
+
+// Binary 二元运算
+type Binary struct {
+	Op  string
+	LHS Expr
+	RHS Expr
+}
+
+// rangeFunctions 支持的区间函数，参数必须是区间选择器
+var rangeFunctions = map[string]bool{
+	"rate": true, "irate": true, "increase": true, "delta": true,
+	"avg_over_time": true, "min_over_time": true, "max_over_time": true,
+	"sum_over_time": true, "count_over_time": true, "last_over_time": true,
+}
+
+// aggregateOps 支持的聚合操作
+var aggregateOps = map[string]bool{"sum": true, "avg": true, "min": true, "max": true, "count": true}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenDuration
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex 把查询拆分成词法单元
+func lex(input string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(input) {
+		c := rune(input[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'' || c == '`':
+			quote := input[i]
+			j := i + 1
+			for j < len(input) && input[j] != quote {
+				if input[j] == '\\' && quote != '`' {
+					j++
+				}
+				j++
+			}
+			if j >= len(input) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			raw := input[i : j+1]
+			value := raw[1 : len(raw)-1]
+			if quote != '`' {
+				if quote == '\'' {
+					raw = `"` + strings.ReplaceAll(strings.ReplaceAll(value, `\'`, `'`), `"`, `\"`) + `"`
+				}
+				unquoted, err := strconv.Unquote(raw)
+				if err != nil {
+					return nil, fmt.Errorf("invalid string at position %d: %v", i, err)
+				}
+				value = unquoted
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value, pos: i})
+			i = j + 1
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(input) && unicode.IsDigit(rune(input[i+1]))):
+			j := i
+			for j < len(input) && (isIdentChar(input[j]) || input[j] == '.' ||
+				((input[j] == '+' || input[j] == '-') && (input[j-1] == 'e' || input[j-1] == 'E') && !strings.ContainsAny(input[i:j], "smhdwy"))) {
+				j++
+			}
+			text := input[i:j]
+			kind := tokenNumber
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				kind = tokenDuration
+			}
+			tokens = append(tokens, token{kind: kind, text: text, pos: i})
+			i = j
+		case isIdentChar(input[i]):
+			j := i
+			for j < len(input) && (isIdentChar(input[j]) || input[j] == ':') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: input[i:j], pos: i})
+			i = j
+		default:
+			op := string(c)
+			if i+1 < len(input) {
+				switch two := input[i : i+2]; two {
+				case "!=", "=~", "!~":
+					op = two
+				}
+			}
+			if !strings.Contains("{}()[],=+-*/", op) && op != "!=" && op != "=~" && op != "!~" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(input)}), nil
+}
+
+func isMatchOp(op string) bool {
+	switch MatchType(op) {
+	case MatchEqual, MatchNotEqual, MatchRegexp, MatchNotRegexp:
+		return true
+	}
+	return false
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// ParseExpr 解析查询表达式
+func ParseExpr(input string) (Expr, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isOp(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokenOp && tok.text == text
+}
+
+func (p *parser) expect(text string) error {
+	tok := p.next()
+	if tok.kind != tokenOp || tok.text != text {
+		if tok.kind == tokenEOF {
+			return fmt.Errorf("unexpected end of input, expected %q", text)
+		}
+		return fmt.Errorf("unexpected %q at position %d, expected %q", tok.text, tok.pos, text)
+	}
+	return nil
+}
+
+// parseExpr 加减，优先级最低
+func (p *parser) parseExpr() (Expr, error) {
+	lhs, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+") || p.isOp("-") {
+		op := p.next().text
+		rhs, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &Binary{Op: op, LHS: lhs, RHS: rhs}
+	}
+	return lhs, nil
+}
+
+// parseTerm 乘除
+func (p *parser) parseTerm() (Expr, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*") || p.isOp("/") {
+		op := p.next().text
+		rhs, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &Binary{Op: op, LHS: lhs, RHS: rhs}
+	}
+	return lhs, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.isOp("-") || p.isOp("+") {
+		op := p.next().text
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			return expr, nil
+		}
+		if number, ok := expr.(*NumberLiteral); ok {
+			return &NumberLiteral{Val: -number.Val}, nil
+		}
+		return &Binary{Op: "*", LHS: expr, RHS: &NumberLiteral{Val: -1}}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenNumber:
+		p.next()
+		value, _ := strconv.ParseFloat(tok.text, 64)
+		return &NumberLiteral{Val: value}, nil
+
+	case tok.kind == tokenOp && tok.text == "(":
+		p.next()
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+
+	case tok.kind == tokenOp && tok.text == "{":
+		return p.parseSelector("")
+
+	case tok.kind == tokenIdent:
+		p.next()
+		name := tok.text
+		if aggregateOps[name] && (p.isOp("(") || p.peekIdent("by") || p.peekIdent("without")) {
+			return p.parseAggregate(name)
+		}
+		if p.isOp("(") {
+			return p.parseCall(name, tok.pos)
+		}
+		return p.parseSelector(name)
+
+	case tok.kind == tokenEOF:
+		return nil, fmt.Errorf("unexpected end of input")
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
+
+func (p *parser) peekIdent(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokenIdent && tok.text == text
+}
+
+func (p *parser) parseCall(name string, pos int) (Expr, error) {
+	if !rangeFunctions[name] {
+		return nil, fmt.Errorf("unsupported function %q at position %d", name, pos)
+	}
+	p.next() // (
+	arg, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	selector, ok := arg.(*VectorSelector)
+	if !ok || selector.Range == 0 {
+		return nil, fmt.Errorf("function %s expects a range vector selector such as metric[5m]", name)
+	}
+	return &Call{Func: name, Arg: arg}, nil
+}
+
+// parseAggregate 支持 sum by (a) (expr)、sum (expr) by (a) 和 sum(expr)
+func (p *parser) parseAggregate(op string) (Expr, error) {
+	agg := &Aggregate{Op: op}
+	if p.peekIdent("by") || p.peekIdent("without") {
+		if err := p.parseGrouping(agg); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	agg.Expr = expr
+	if agg.Grouping == nil && !agg.Without && (p.peekIdent("by") || p.peekIdent("without")) {
+		if err := p.parseGrouping(agg); err != nil {
+			return nil, err
+		}
+	}
+	return agg, nil
+}
+
+func (p *parser) parseGrouping(agg *Aggregate) error {
+	agg.Without = p.next().text == "without"
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	agg.Grouping = []string{}
+	for !p.isOp(")") {
+		tok := p.next()
+		if tok.kind != tokenIdent {
+			return fmt.Errorf("expected label name at position %d", tok.pos)
+		}
+		agg.Grouping = append(agg.Grouping, tok.text)
+		if !p.isOp(",") {
+			break
+		}
+		p.next()
+	}
+	return p.expect(")")
+}
+
+// parseSelector 解析 name{label="value",...}[range]
+func (p *parser) parseSelector(name string) (Expr, error) {
+	selector := &VectorSelector{}
+	if name != "" {
+		m, _ := NewMatcher(MatchEqual, MetricNameLabel, name)
+		selector.Matchers = append(selector.Matchers, m)
+	}
+
+	if p.isOp("{") {
+		p.next()
+		for !p.isOp("}") {
+			labelTok := p.next()
+			if labelTok.kind != tokenIdent {
+				return nil, fmt.Errorf("expected label name at position %d", labelTok.pos)
+			}
+			opTok := p.next()
+			if opTok.kind != tokenOp || !isMatchOp(opTok.text) {
+				return nil, fmt.Errorf("expected label matcher operator at position %d", opTok.pos)
+			}
+			valueTok := p.next()
+			if valueTok.kind != tokenString {
+				return nil, fmt.Errorf("expected quoted label value at position %d", valueTok.pos)
+			}
+			m, err := NewMatcher(MatchType(opTok.text), labelTok.text, valueTok.text)
+			if err != nil {
+				return nil, err
+			}
+			selector.Matchers = append(selector.Matchers, m)
+			if !p.isOp(",") {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect("}"); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(selector.Matchers) == 0 {
+		return nil, fmt.Errorf("vector selector must contain at least one matcher")
+	}
+
+	if p.isOp("[") {
+		p.next()
+		tok := p.next()
+		if tok.kind != tokenDuration && tok.kind != tokenNumber {
+			return nil, fmt.Errorf("expected duration at position %d", tok.pos)
+		}
+		d, err := ParseDuration(tok.text)
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("range must be positive")
+		}
+		selector.Range = d
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+	return selector, nil
+}
+
+// durationUnits Prometheus 时长单位
+var durationUnits = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{"ms", time.Millisecond},
+	{"s", time.Second},
+	{"m", time.Minute},
+	{"h", time.Hour},
+	{"d", 24 * time.Hour},
+	{"w", 7 * 24 * time.Hour},
+	{"y", 365 * 24 * time.Hour},
+}
+
+// ParseDuration 解析 Prometheus 时长（5m、1h30m、2d），纯数字按秒处理
+func ParseDuration(s string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	var total time.Duration
+	rest := s
+	for rest != "" {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		n, _ := strconv.Atoi(rest[:i])
+		rest = rest[i:]
+		matched := false
+		for _, u := range durationUnits {
+			if strings.HasPrefix(rest, u.suffix) {
+				// "m" 不能吞掉 "ms"
+				if u.suffix == "m" && strings.HasPrefix(rest, "ms") {
+					continue
+				}
+				total += time.Duration(n) * u.unit
+				rest = rest[len(u.suffix):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,164 @@
+// Package promql 实现 PromQL 的一个子集：标签匹配、rate/increase 等区间函数、sum/avg/min/max/count by 聚合
+// 以及与标量的四则运算，用于让 Grafana 的 Prometheus 数据源直接查询 Superview 记录的历史指标。
+// 数据来源通过 Storage 接口注入，本包不关心存储细节
+package promql
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MetricNameLabel 保存指标名称的标签
+const MetricNameLabel = "__name__"
+
+// Labels 一条序列的标签集合，指标名称保存在 __name__ 中
+type Labels map[string]string
+
+// signature 标签集合的唯一标识
+func (l Labels) signature() string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('\xff')
+		sb.WriteString(l[k])
+		sb.WriteByte('\xff')
+	}
+	return sb.String()
+}
+
+// copyWithout 复制标签并去掉指定的标签
+func (l Labels) copyWithout(names ...string) Labels {
+	result := make(Labels, len(l))
+	for k, v := range l {
+		result[k] = v
+	}
+	for _, name := range names {
+		delete(result, name)
+	}
+	return result
+}
+
+// Point 一个采样点，T 为毫秒时间戳
+type Point struct {
+	T int64
+	V float64
+}
+
+// Series 一条带时间序列采样点的序列，Points 按时间升序
+type Series struct {
+	Labels Labels
+	Points []Point
+}
+
+// Sample 瞬时向量中的一个元素
+type Sample struct {
+	Labels Labels
+	Point
+}
+
+// Vector 瞬时向量
+type Vector []Sample
+
+// Matrix 区间向量或区间查询结果
+type Matrix []Series
+
+// Scalar 标量
+type Scalar Point
+
+// Value 查询结果：Vector、Matrix 或 Scalar
+type Value interface {
+	Type() string
+}
+
+// Type 返回 Prometheus API 中的 resultType
+func (Vector) Type() string { return "vector" }
+
+// Type 返回 Prometheus API 中的 resultType
+func (Matrix) Type() string { return "matrix" }
+
+// Type 返回 Prometheus API 中的 resultType
+func (Scalar) Type() string { return "scalar" }
+
+// MatchType 标签匹配方式
+type MatchType string
+
+// 支持的匹配方式
+const (
+	MatchEqual     MatchType = "="
+	MatchNotEqual  MatchType = "!="
+	MatchRegexp    MatchType = "=~"
+	MatchNotRegexp MatchType = "!~"
+)
+
+// Matcher 标签匹配条件，正则表达式与 Prometheus 一样整体匹配
+type Matcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+	re    *regexp.Regexp
+}
+
+// NewMatcher 创建匹配条件
+func NewMatcher(t MatchType, name, value string) (*Matcher, error) {
+	m := &Matcher{Type: t, Name: name, Value: value}
+	switch t {
+	case MatchEqual, MatchNotEqual:
+	case MatchRegexp, MatchNotRegexp:
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", value, err)
+		}
+		m.re = re
+	default:
+		return nil, fmt.Errorf("unknown match type %q", t)
+	}
+	return m, nil
+}
+
+// Matches 标签值是否满足条件，缺失的标签按空字符串处理
+func (m *Matcher) Matches(value string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+// MatchLabels 标签集合是否满足所有条件
+func MatchLabels(labels Labels, matchers []*Matcher) bool {
+	for _, m := range matchers {
+		if !m.Matches(labels[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectHints 查询范围提示。Start/End 是求值的时间范围，Step 为区间查询的步长（瞬时查询为 0），
+// Range 为选择器的区间长度；存储应返回 [Start-Lookback-Range, End] 内的采样点
+type SelectHints struct {
+	Start    time.Time
+	End      time.Time
+	Step     time.Duration
+	Range    time.Duration
+	Lookback time.Duration
+}
+
+// Storage 历史指标的数据来源
+type Storage interface {
+	Select(hints SelectHints, matchers []*Matcher) ([]Series, error)
+}
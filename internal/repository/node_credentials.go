@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"superview/internal/errors"
+	"superview/internal/secrets"
+	"gorm.io/gorm"
+)
+
+// credentialTables 含 credential 序列化字段（password 列）的表，轮换、解密、状态统计和备份脱敏都覆盖这些表
+var credentialTables = []string{
+	"nodes",
+	"discovery_schedules",
+	"discovery_candidates",
+}
+
+// CredentialTables 返回含 credential 序列化字段的表名
+func CredentialTables() []string {
+	return append([]string(nil), credentialTables...)
+}
+
+// rawCredential 直接读取 password 列（不经过 credential 序列化器）
+type rawCredential struct {
+	Table    string `gorm:"-"`
+	ID       uint
+	Password string
+}
+
+// CredentialStatus 凭据加密状态
+type CredentialStatus struct {
+	Total     int            `json:"total"`
+	Empty     int            `json:"empty"`
+	Plaintext int            `json:"plaintext"`
+	ByKey     map[string]int `json:"by_key"`
+	ByTable   map[string]int `json:"by_table"`
+}
+
+func loadRawCredentials(db *gorm.DB) ([]rawCredential, error) {
+	var all []rawCredential
+	for _, table := range credentialTables {
+		// 尚未迁移的旧库中表可能不存在
+		if !db.Migrator().HasTable(table) {
+			continue
+		}
+		var rows []rawCredential
+		// 包含软删除的行，恢复或重新导入时同样需要可解密
+		if err := db.Table(table).Select("id", "password").Order("id").Find(&rows).Error; err != nil {
+			return nil, errors.NewDatabaseError("load "+table+" credentials", err)
+		}
+		for i := range rows {
+			rows[i].Table = table
+		}
+		all = append(all, rows...)
+	}
+	return all, nil
+}
+
+// NodeCredentialStatus 统计所有凭据表中明文和各密钥加密的凭据数量
+func NodeCredentialStatus(db *gorm.DB) (*CredentialStatus, error) {
+	rows, err := loadRawCredentials(db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &CredentialStatus{Total: len(rows), ByKey: make(map[string]int), ByTable: make(map[string]int)}
+	for _, row := range rows {
+		status.ByTable[row.Table]++
+		switch {
+		case row.Password == "":
+			status.Empty++
+		case secrets.IsEncrypted(row.Password):
+			status.ByKey[secrets.EncryptedKeyID(row.Password)]++
+		default:
+			status.Plaintext++
+		}
+	}
+	return status, nil
+}
+
+// ReencryptNodeCredentials 用密钥环的当前密钥重新加密所有明文或旧密钥加密的凭据，返回更新的行数
+// 在一个事务中完成：任一行无法解密（缺少旧密钥）时全部回滚
+func ReencryptNodeCredentials(db *gorm.DB, keyring *secrets.Keyring) (int, error) {
+	return rewriteCredentials(db, func(value string) (string, bool, error) {
+		if !keyring.NeedsRotation(value) {
+			return value, false, nil
+		}
+		plaintext, err := keyring.Decrypt(value)
+		if err != nil {
+			return "", false, err
+		}
+		encrypted, err := keyring.Encrypt(plaintext)
+		return encrypted, true, err
+	})
+}
+
+// DecryptNodeCredentials 将所有凭据还原为明文（回滚到不支持加密的版本前使用），返回更新的行数
+func DecryptNodeCredentials(db *gorm.DB, keyring *secrets.Keyring) (int, error) {
+	return rewriteCredentials(db, func(value string) (string, bool, error) {
+		if !secrets.IsEncrypted(value) {
+			return value, false, nil
+		}
+		plaintext, err := keyring.Decrypt(value)
+		return plaintext, true, err
+	})
+}
+
+func rewriteCredentials(db *gorm.DB, rewrite func(value string) (string, bool, error)) (int, error) {
+	updated := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		rows, err := loadRawCredentials(tx)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			value, changed, err := rewrite(row.Password)
+			if err != nil {
+				return errors.NewInternalError("rewrite "+row.Table+" credential", err)
+			}
+			if !changed {
+				continue
+			}
+			if err := tx.Table(row.Table).Where("id = ?", row.ID).UpdateColumn("password", value).Error; err != nil {
+				return errors.NewDatabaseError("update "+row.Table+" credential", err)
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
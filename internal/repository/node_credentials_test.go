@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"superview/internal/models"
+	"superview/internal/secrets"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupCredentialTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Node{}, &models.DiscoverySchedule{}, &models.DiscoveryCandidate{}))
+	return db
+}
+
+func newTestKeyring(t *testing.T) *secrets.Keyring {
+	raw, err := secrets.GenerateKey()
+	require.NoError(t, err)
+	keyring, err := secrets.NewKeyring(raw)
+	require.NoError(t, err)
+	return keyring
+}
+
+func rawPassword(t *testing.T, db *gorm.DB, id uint) string {
+	return rawTablePassword(t, db, "nodes", id)
+}
+
+func rawTablePassword(t *testing.T, db *gorm.DB, table string, id uint) string {
+	var row rawCredential
+	require.NoError(t, db.Table(table).Select("id", "password").Where("id = ?", id).Take(&row).Error)
+	return row.Password
+}
+
+func TestNodeCredentialsTransparentEncryption(t *testing.T) {
+	db := setupCredentialTestDB(t)
+	keyring := newTestKeyring(t)
+	models.SetCredentialCipher(keyring)
+	defer models.SetCredentialCipher(nil)
+
+	repo := NewNodeRepository(db)
+	node := &models.Node{Name: "web-01", Host: "10.0.0.1", Port: 9001, Username: "user", Password: "s3cret"}
+	require.NoError(t, repo.Create(node))
+	assert.Equal(t, "s3cret", node.Password, "caller keeps the plaintext")
+
+	stored := rawPassword(t, db, node.ID)
+	assert.True(t, secrets.IsEncrypted(stored))
+	assert.NotContains(t, stored, "s3cret")
+
+	loaded, err := repo.GetByName("web-01")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", loaded.Password)
+
+	// 再次保存不会重复加密
+	require.NoError(t, repo.Update(loaded))
+	loaded, err = repo.GetByID(node.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", loaded.Password)
+}
+
+func TestReencryptNodeCredentials(t *testing.T) {
+	db := setupCredentialTestDB(t)
+	defer models.SetCredentialCipher(nil)
+
+	// 未配置密钥时写入的历史明文
+	models.SetCredentialCipher(nil)
+	legacy := &models.Node{Name: "legacy", Host: "10.0.0.1", Port: 9001, Password: "plain"}
+	empty := &models.Node{Name: "empty", Host: "10.0.0.2", Port: 9001}
+	require.NoError(t, db.Create(legacy).Error)
+	require.NoError(t, db.Create(empty).Error)
+
+	oldKeyring := newTestKeyring(t)
+	count, err := ReencryptNodeCredentials(db, oldKeyring)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, oldKeyring.PrimaryKeyID(), secrets.EncryptedKeyID(rawPassword(t, db, legacy.ID)))
+	assert.Equal(t, "", rawPassword(t, db, empty.ID))
+
+	// 密钥轮换：旧密钥只用于解密
+	newRaw, err := secrets.GenerateKey()
+	require.NoError(t, err)
+	rotated, err := oldKeyring.WithPrimary(newRaw)
+	require.NoError(t, err)
+	count, err = ReencryptNodeCredentials(db, rotated)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	status, err := NodeCredentialStatus(db)
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.Total)
+	assert.Equal(t, 1, status.Empty)
+	assert.Equal(t, 0, status.Plaintext)
+	assert.Equal(t, map[string]int{rotated.PrimaryKeyID(): 1}, status.ByKey)
+
+	// 只有新密钥时仍可读取
+	newOnly, err := secrets.NewKeyring(newRaw)
+	require.NoError(t, err)
+	models.SetCredentialCipher(newOnly)
+	var loaded models.Node
+	require.NoError(t, db.First(&loaded, legacy.ID).Error)
+	assert.Equal(t, "plain", loaded.Password)
+
+	// 缺少密钥时整体回滚
+	models.SetCredentialCipher(nil)
+	_, err = ReencryptNodeCredentials(db, newTestKeyring(t))
+	assert.Error(t, err)
+	assert.Equal(t, newOnly.PrimaryKeyID(), secrets.EncryptedKeyID(rawPassword(t, db, legacy.ID)))
+
+	count, err = DecryptNodeCredentials(db, newOnly)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "plain", rawPassword(t, db, legacy.ID))
+}
+
+func TestReencryptDiscoveryCredentials(t *testing.T) {
+	db := setupCredentialTestDB(t)
+	defer models.SetCredentialCipher(nil)
+
+	models.SetCredentialCipher(nil)
+	node := &models.Node{Name: "web-01", Host: "10.0.0.1", Port: 9001, Password: "node-pass"}
+	schedule := &models.DiscoverySchedule{Name: "nightly", CronExpr: "0 2 * * *", CIDR: "10.0.0.0/24", Password: "scan-pass", CreatedBy: "admin"}
+	require.NoError(t, db.Create(node).Error)
+	require.NoError(t, db.Create(schedule).Error)
+	candidate := &models.DiscoveryCandidate{TaskID: 1, IP: "10.0.0.5", Port: 9001, Password: "found-pass",
+		LastSeenAt: time.Now(), Status: models.CandidateStatusPending}
+	require.NoError(t, db.Create(candidate).Error)
+
+	keyring := newTestKeyring(t)
+	count, err := ReencryptNodeCredentials(db, keyring)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, keyring.PrimaryKeyID(), secrets.EncryptedKeyID(rawTablePassword(t, db, "discovery_schedules", schedule.ID)))
+	assert.Equal(t, keyring.PrimaryKeyID(), secrets.EncryptedKeyID(rawTablePassword(t, db, "discovery_candidates", candidate.ID)))
+
+	status, err := NodeCredentialStatus(db)
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Total)
+	assert.Equal(t, map[string]int{keyring.PrimaryKeyID(): 3}, status.ByKey)
+	assert.Equal(t, map[string]int{"nodes": 1, "discovery_schedules": 1, "discovery_candidates": 1}, status.ByTable)
+
+	models.SetCredentialCipher(keyring)
+	var loaded models.DiscoverySchedule
+	require.NoError(t, db.First(&loaded, schedule.ID).Error)
+	assert.Equal(t, "scan-pass", loaded.Password)
+
+	count, err = DecryptNodeCredentials(db, keyring)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, "scan-pass", rawTablePassword(t, db, "discovery_schedules", schedule.ID))
+	assert.Equal(t, "found-pass", rawTablePassword(t, db, "discovery_candidates", candidate.ID))
+}
@@ -0,0 +1,211 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// EncryptedPrefix 加密值前缀，格式：enc:v1:<key_id>:<wrapped_data_key>:<ciphertext>
+const EncryptedPrefix = "enc:v1:"
+
+// KeySize 主密钥长度（AES-256）
+const KeySize = 32
+
+// masterKey 主密钥（KEK），只用于加密每个值各自的数据密钥
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// Keyring 信封加密密钥环：primary 用于加密，其余密钥只用于解密（轮换期间的旧密钥）
+type Keyring struct {
+	primary *masterKey
+	keys    map[string]*masterKey
+}
+
+// NewKeyring 创建密钥环，primary 为当前密钥，previous 为轮换前的旧密钥
+func NewKeyring(primary []byte, previous ...[]byte) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]*masterKey)}
+
+	pk, err := newMasterKey(primary)
+	if err != nil {
+		return nil, err
+	}
+	k.primary = pk
+	k.keys[pk.id] = pk
+
+	for _, raw := range previous {
+		mk, err := newMasterKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("previous key: %v", err)
+		}
+		if _, exists := k.keys[mk.id]; !exists {
+			k.keys[mk.id] = mk
+		}
+	}
+	return k, nil
+}
+
+// WithPrimary 返回以 raw 为当前密钥的新密钥环，原有密钥全部保留用于解密（密钥轮换）
+func (k *Keyring) WithPrimary(raw []byte) (*Keyring, error) {
+	pk, err := newMasterKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	rotated := &Keyring{primary: pk, keys: map[string]*masterKey{pk.id: pk}}
+	for id, mk := range k.keys {
+		if _, exists := rotated.keys[id]; !exists {
+			rotated.keys[id] = mk
+		}
+	}
+	return rotated, nil
+}
+
+func newMasterKey(raw []byte) (*masterKey, error) {
+	if len(raw) != KeySize {
+		return nil, fmt.Errorf("credential key must be %d bytes, got %d", KeySize, len(raw))
+	}
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &masterKey{id: KeyID(raw), aead: aead}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// KeyID 密钥标识：SHA-256 前 4 字节的十六进制
+func KeyID(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:4])
+}
+
+// PrimaryKeyID 当前加密密钥的标识
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary.id
+}
+
+// IsEncrypted 值是否为本包生成的密文
+func (k *Keyring) IsEncrypted(value string) bool {
+	return IsEncrypted(value)
+}
+
+// IsEncrypted 值是否为本包生成的密文
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, EncryptedPrefix)
+}
+
+// EncryptedKeyID 返回密文使用的密钥标识，非密文返回空字符串
+func EncryptedKeyID(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	parts := strings.SplitN(strings.TrimPrefix(value, EncryptedPrefix), ":", 3)
+	return parts[0]
+}
+
+// NeedsRotation 值是明文或使用的不是当前密钥
+func (k *Keyring) NeedsRotation(value string) bool {
+	if value == "" {
+		return false
+	}
+	return EncryptedKeyID(value) != k.primary.id
+}
+
+// Encrypt 使用随机数据密钥加密明文，数据密钥由当前主密钥加密后随密文保存；空字符串不加密
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(dataAEAD, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	// 主密钥标识作为附加数据，防止密文被挪到其他密钥下
+	wrappedKey, err := seal(k.primary.aead, dataKey, []byte(k.primary.id))
+	if err != nil {
+		return "", err
+	}
+
+	return EncryptedPrefix + k.primary.id + ":" +
+		base64.RawStdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt 解密 Encrypt 生成的密文；非密文（未加密的历史数据）原样返回
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, EncryptedPrefix), ":")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed encrypted credential")
+	}
+	mk, ok := k.keys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("credential encrypted with unknown key %s", parts[0])
+	}
+
+	wrappedKey, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted credential: %v", err)
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted credential: %v", err)
+	}
+
+	dataKey, err := open(mk.aead, wrappedKey, []byte(mk.id))
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap credential data key: %v", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataAEAD, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt credential: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// seal 加密并把 nonce 放在密文前
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, data, additionalData []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,131 @@
+package secrets
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustKey(t *testing.T) []byte {
+	raw, err := GenerateKey()
+	require.NoError(t, err)
+	return raw
+}
+
+func TestKeyringEncryptDecrypt(t *testing.T) {
+	keyring, err := NewKeyring(mustKey(t))
+	require.NoError(t, err)
+
+	first, err := keyring.Encrypt("s3cret")
+	require.NoError(t, err)
+	second, err := keyring.Encrypt("s3cret")
+	require.NoError(t, err)
+
+	assert.True(t, IsEncrypted(first))
+	assert.NotContains(t, first, "s3cret")
+	assert.NotEqual(t, first, second, "each value uses its own data key and nonce")
+	assert.Equal(t, keyring.PrimaryKeyID(), EncryptedKeyID(first))
+
+	plaintext, err := keyring.Decrypt(first)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", plaintext)
+
+	// 空值和历史明文原样返回
+	empty, err := keyring.Encrypt("")
+	require.NoError(t, err)
+	assert.Equal(t, "", empty)
+	legacy, err := keyring.Decrypt("plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", legacy)
+}
+
+func TestKeyringRejectsTamperedCiphertext(t *testing.T) {
+	keyring, err := NewKeyring(mustKey(t))
+	require.NoError(t, err)
+
+	value, err := keyring.Encrypt("s3cret")
+	require.NoError(t, err)
+
+	parts := strings.Split(value, ":")
+	last := []byte(parts[len(parts)-1])
+	last[len(last)-2] ^= 0x01
+	parts[len(parts)-1] = string(last)
+	_, err = keyring.Decrypt(strings.Join(parts, ":"))
+	assert.Error(t, err)
+
+	other, err := NewKeyring(mustKey(t))
+	require.NoError(t, err)
+	_, err = other.Decrypt(value)
+	assert.Error(t, err, "unknown key must not decrypt")
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldKeyring, err := NewKeyring(mustKey(t))
+	require.NoError(t, err)
+	value, err := oldKeyring.Encrypt("s3cret")
+	require.NoError(t, err)
+
+	rotated, err := oldKeyring.WithPrimary(mustKey(t))
+	require.NoError(t, err)
+	assert.NotEqual(t, oldKeyring.PrimaryKeyID(), rotated.PrimaryKeyID())
+	assert.True(t, rotated.NeedsRotation(value))
+	assert.True(t, rotated.NeedsRotation("plain"))
+	assert.False(t, rotated.NeedsRotation(""))
+
+	plaintext, err := rotated.Decrypt(value)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", plaintext)
+
+	reencrypted, err := rotated.Encrypt(plaintext)
+	require.NoError(t, err)
+	assert.False(t, rotated.NeedsRotation(reencrypted))
+}
+
+func TestDecodeKey(t *testing.T) {
+	raw := mustKey(t)
+
+	decoded, err := DecodeKey(EncodeKey(raw))
+	require.NoError(t, err)
+	assert.Equal(t, raw, decoded)
+
+	decoded, err = DecodeKey(hex.EncodeToString(raw) + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, raw, decoded)
+
+	_, err = DecodeKey("too-short")
+	assert.Error(t, err)
+}
+
+func TestLoadKeyringGeneratesKeyFile(t *testing.T) {
+	t.Setenv(EnvKey, "")
+	t.Setenv(EnvKeyFile, "")
+	t.Setenv(EnvPreviousKeys, "")
+	path := filepath.Join(t.TempDir(), "config", "credentials.key")
+
+	keyring, source, err := LoadKeyring(path)
+	require.NoError(t, err)
+	assert.True(t, source.Generated)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// 再次加载使用同一密钥
+	again, source, err := LoadKeyring(path)
+	require.NoError(t, err)
+	assert.False(t, source.Generated)
+	assert.Equal(t, keyring.PrimaryKeyID(), again.PrimaryKeyID())
+
+	// 环境变量优先于密钥文件
+	envKey := mustKey(t)
+	t.Setenv(EnvKey, EncodeKey(envKey))
+	fromEnv, source, err := LoadKeyring(path)
+	require.NoError(t, err)
+	assert.True(t, source.Env)
+	assert.Equal(t, KeyID(envKey), fromEnv.PrimaryKeyID())
+}
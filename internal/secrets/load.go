@@ -0,0 +1,156 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 节点凭据密钥相关环境变量
+const (
+	// EnvKey base64（或 64 位十六进制）编码的 32 字节主密钥
+	EnvKey = "NODE_CREDENTIALS_KEY"
+	// EnvKeyFile 主密钥文件路径，文件内容格式同 EnvKey
+	EnvKeyFile = "NODE_CREDENTIALS_KEY_FILE"
+	// EnvPreviousKeys 轮换前的旧密钥，逗号分隔，只用于解密
+	EnvPreviousKeys = "NODE_CREDENTIALS_PREVIOUS_KEYS"
+)
+
+// KeySource 主密钥来源，用于启动日志
+type KeySource struct {
+	// Env 密钥来自环境变量
+	Env bool
+	// File 密钥文件路径
+	File string
+	// Generated 本次启动自动生成了密钥文件
+	Generated bool
+}
+
+// LoadKeyring 按 NODE_CREDENTIALS_KEY、NODE_CREDENTIALS_KEY_FILE、defaultKeyFile 的顺序加载主密钥；
+// 都未设置且 defaultKeyFile 不存在时生成新密钥写入 defaultKeyFile（权限 0600）
+func LoadKeyring(defaultKeyFile string) (*Keyring, *KeySource, error) {
+	primary, source, err := loadPrimaryKey(defaultKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var previous [][]byte
+	for _, encoded := range strings.Split(os.Getenv(EnvPreviousKeys), ",") {
+		if strings.TrimSpace(encoded) == "" {
+			continue
+		}
+		raw, err := DecodeKey(encoded)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", EnvPreviousKeys, err)
+		}
+		previous = append(previous, raw)
+	}
+
+	keyring, err := NewKeyring(primary, previous...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return keyring, source, nil
+}
+
+func loadPrimaryKey(defaultKeyFile string) ([]byte, *KeySource, error) {
+	if encoded := os.Getenv(EnvKey); encoded != "" {
+		raw, err := DecodeKey(encoded)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", EnvKey, err)
+		}
+		return raw, &KeySource{Env: true}, nil
+	}
+
+	if path := os.Getenv(EnvKeyFile); path != "" {
+		raw, err := ReadKeyFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", EnvKeyFile, err)
+		}
+		return raw, &KeySource{File: path}, nil
+	}
+
+	raw, err := ReadKeyFile(defaultKeyFile)
+	if err == nil {
+		return raw, &KeySource{File: defaultKeyFile}, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	raw, err = GenerateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := WriteKeyFile(defaultKeyFile, raw); err != nil {
+		return nil, nil, err
+	}
+	return raw, &KeySource{File: defaultKeyFile, Generated: true}, nil
+}
+
+// GenerateKey 生成随机主密钥
+func GenerateKey() ([]byte, error) {
+	raw := make([]byte, KeySize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// EncodeKey 将密钥编码为 base64，用于写入 .env 或密钥文件
+func EncodeKey(raw []byte) string {
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// DecodeKey 解析 base64 或十六进制编码的密钥
+func DecodeKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	if len(encoded) == hex.EncodedLen(KeySize) {
+		if raw, err := hex.DecodeString(encoded); err == nil {
+			return raw, nil
+		}
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encoding, expected base64 or hex")
+	}
+	if len(raw) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(raw))
+	}
+	return raw, nil
+}
+
+// ReadKeyFile 读取密钥文件
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := DecodeKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return raw, nil
+}
+
+// WriteKeyFile 写入密钥文件（权限 0600），文件已存在时返回错误，避免覆盖正在使用的密钥
+func WriteKeyFile(path string, raw []byte) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(EncodeKey(raw) + "\n"); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
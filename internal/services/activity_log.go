@@ -1,25 +1,55 @@
 package services
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"superview/internal/logger"
 	"superview/internal/models"
 	"gorm.io/gorm"
 )
 
 type ActivityLogService struct {
-	db *gorm.DB
+	db  *gorm.DB
+	hub WebSocketHub
 }
 
 func NewActivityLogService(db *gorm.DB) *ActivityLogService {
 	return &ActivityLogService{db: db}
 }
 
+// SetHub 设置 WebSocket hub，新记录推送到 activity 主题
+func (s *ActivityLogService) SetHub(hub WebSocketHub) {
+	s.hub = hub
+}
+
 // LogActivity 记录活动日志
 func (s *ActivityLogService) LogActivity(log *models.ActivityLog) error {
-	return s.db.Create(log).Error
+	return s.save(log)
+}
+
+// save 写入记录，成功后推送 activity_log 事件
+func (s *ActivityLogService) save(log *models.ActivityLog) error {
+	if err := s.db.Create(log).Error; err != nil {
+		return err
+	}
+	if s.hub == nil {
+		return nil
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"type":   "activity_log",
+		"topics": []string{"activity"},
+		"data":   log,
+	})
+	if err != nil {
+		logger.Error("Failed to marshal activity log event", zap.Uint("id", log.ID), zap.Error(err))
+		return nil
+	}
+	s.hub.Broadcast(data)
+	return nil
 }
 
 // LogWithContext 从Gin上下文记录日志
@@ -61,7 +91,36 @@ func (s *ActivityLogService) LogWithContext(c *gin.Context, level, action, resou
 		CreatedAt: time.Now(),
 	}
 
-	s.db.Create(log)
+	s.save(log)
+}
+
+// LogForUser 记录没有 HTTP 请求上下文的用户操作（如 WebSocket 命令）
+func (s *ActivityLogService) LogForUser(userID, clientIP, userAgent, level, action, resource, target, message string) {
+	var username string
+	var user models.User
+	if userID != "" && s.db.Where("id = ?", userID).First(&user).Error == nil {
+		username = user.Username
+	}
+
+	status := models.StatusSuccess
+	if level == "ERROR" {
+		status = models.StatusError
+	}
+	log := &models.ActivityLog{
+		Level:     level,
+		Message:   message,
+		Action:    action,
+		Resource:  resource,
+		Target:    target,
+		UserID:    userID,
+		Username:  username,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+		CreatedAt: time.Now(),
+		Status:    status,
+	}
+
+	s.save(log)
 }
 
 // LogError 记录错误日志
@@ -103,7 +162,7 @@ func (s *ActivityLogService) LogError(c *gin.Context, action, resource, target s
 		CreatedAt: time.Now(),
 	}
 
-	s.db.Create(log)
+	s.save(log)
 }
 
 // GetActivityLogs 获取活动日志列表
@@ -416,5 +475,5 @@ func (s *ActivityLogService) LogSystemEvent(level, action, resource, target, mes
 		Status:    models.StatusSuccess,
 	}
 	
-	return s.db.Create(log).Error
+	return s.save(log)
 }
@@ -3,9 +3,12 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
+	"superview/internal/config"
 	"superview/internal/logger"
+	"superview/internal/metrics/instrument"
 	"superview/internal/models"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -14,11 +17,35 @@ import (
 // AlertService 告警服务
 type AlertService struct {
 	db *gorm.DB
+	// grouping 告警分组、等待和重复通知参数
+	grouping *config.AlertingSettings
+	// incidentMu 串行化告警加入事件，避免并发创建分组键相同的事件
+	incidentMu sync.Mutex
 }
 
 // NewAlertService 创建告警服务实例
 func NewAlertService(db *gorm.DB) *AlertService {
-	return &AlertService{db: db}
+	return &AlertService{db: db, grouping: config.DefaultAlertingSettings()}
+}
+
+// MetricsCollector 按级别和状态统计告警数量，供 /metrics 端点在抓取时查询
+func (s *AlertService) MetricsCollector() instrument.Collector {
+	return instrument.NewGaugeFunc("superview_alerts", "Alerts by severity and status",
+		[]string{"severity", "status"}, func(set func(value float64, labelValues ...string)) {
+			var rows []struct {
+				Severity string
+				Status   string
+				Count    int64
+			}
+			if err := s.db.Model(&models.Alert{}).Select("severity, status, COUNT(*) AS count").
+				Group("severity, status").Scan(&rows).Error; err != nil {
+				logger.Error("Failed to count alerts for metrics", zap.Error(err))
+				return
+			}
+			for _, row := range rows {
+				set(float64(row.Count), row.Severity, row.Status)
+			}
+		})
 }
 
 // CreateAlertRule 创建告警规则
@@ -119,6 +146,12 @@ func (s *AlertService) GetAlerts(page, pageSize int, filters map[string]interfac
 			query = query.Where("start_time <= ?", value)
 		case "search":
 			query = query.Where("message LIKE ?", "%"+value.(string)+"%")
+		case "suppressed":
+			if value.(bool) {
+				query = query.Where("alerts.suppressed_by <> ''")
+			} else {
+				query = query.Where("alerts.suppressed_by = '' OR alerts.suppressed_by IS NULL")
+			}
 		}
 	}
 
@@ -142,10 +175,10 @@ func (s *AlertService) GetAlertByID(id uint) (*models.Alert, error) {
 	return &alert, err
 }
 
-// AcknowledgeAlert 确认告警
+// AcknowledgeAlert 确认告警，同时确认告警所属的事件，停止事件的重复通知和升级
 func (s *AlertService) AcknowledgeAlert(id uint, userIDStr string) error {
 	now := time.Now()
-	return s.db.Model(&models.Alert{}).
+	err := s.db.Model(&models.Alert{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
 			"status":     models.AlertStatusAcknowledged,
@@ -153,6 +186,18 @@ func (s *AlertService) AcknowledgeAlert(id uint, userIDStr string) error {
 			"acked_at":   now,
 			"updated_at": now,
 		}).Error
+	if err != nil {
+		return err
+	}
+
+	var alert models.Alert
+	if err := s.db.Select("id", "incident_id").Where("id = ?", id).Limit(1).Find(&alert).Error; err != nil {
+		return err
+	}
+	if alert.IncidentID != nil {
+		return acknowledgeIncident(s.db, *alert.IncidentID, userIDStr, now)
+	}
+	return nil
 }
 
 // ResolveAlert 解决告警
@@ -280,100 +325,6 @@ func (s *AlertService) GetSystemMetrics(filters map[string]interface{}, limit in
 	return metrics, err
 }
 
-// CheckAlertRules 检查告警规则并触发告警
-func (s *AlertService) CheckAlertRules() error {
-	// 获取所有启用的告警规则
-	var rules []models.AlertRule
-	err := s.db.Where("enabled = ?", true).Find(&rules).Error
-	if err != nil {
-		return err
-	}
-
-	for _, rule := range rules {
-		err := s.checkSingleRule(&rule)
-		if err != nil {
-			logger.Error("Error checking rule", zap.Uint("rule_id", rule.ID), zap.Error(err))
-		}
-	}
-
-	return nil
-}
-
-// checkSingleRule 检查单个告警规则
-func (s *AlertService) checkSingleRule(rule *models.AlertRule) error {
-	// 获取最新的指标数据
-	filters := map[string]interface{}{
-		"metric_name":    rule.Metric,
-		"timestamp_from": time.Now().Add(-time.Duration(rule.Duration) * time.Second),
-	}
-
-	if rule.NodeID != nil {
-		filters["node_id"] = *rule.NodeID
-	}
-
-	if rule.ProcessName != nil {
-		filters["process_name"] = *rule.ProcessName
-	}
-
-	metrics, err := s.GetSystemMetrics(filters, 1)
-	if err != nil {
-		return err
-	}
-
-	if len(metrics) == 0 {
-		return nil // 没有数据，跳过检查
-	}
-
-	latestMetric := metrics[0]
-
-	// 检查是否应该触发告警
-	if rule.ShouldTrigger(latestMetric.Value) {
-		// 检查是否已经有活跃的告警
-		var existingAlert models.Alert
-		err := s.db.Where("rule_id = ? AND status = ?", rule.ID, models.AlertStatusActive).First(&existingAlert).Error
-		if err == gorm.ErrRecordNotFound {
-			// 创建新告警
-			alert := &models.Alert{
-				RuleID:      rule.ID,
-				ProcessName: rule.ProcessName,
-				Message:     s.generateAlertMessage(rule, latestMetric.Value),
-				Severity:    rule.Severity,
-				Status:      models.AlertStatusActive,
-				Value:       latestMetric.Value,
-				StartTime:   time.Now(),
-			}
-
-			err = s.CreateAlert(alert)
-			if err != nil {
-				return err
-			}
-
-			// 发送通知
-			return s.sendAlertNotifications(alert)
-		}
-	} else {
-		// 检查是否有活跃的告警需要自动解决
-		var activeAlert models.Alert
-		err := s.db.Where("rule_id = ? AND status = ?", rule.ID, models.AlertStatusActive).First(&activeAlert).Error
-		if err == nil {
-			// 自动解决告警
-			activeAlert.Status = models.AlertStatusResolved
-			now := time.Now()
-			activeAlert.EndTime = &now
-			activeAlert.ResolvedAt = &now
-			return s.db.Save(&activeAlert).Error
-		}
-	}
-
-	return nil
-}
-
-// generateAlertMessage 生成告警消息
-func (s *AlertService) generateAlertMessage(rule *models.AlertRule, value float64) string {
-	return fmt.Sprintf("告警: %s - %s 当前值: %.2f, 阈值: %s %.2f",
-		rule.Name, rule.Description, value, rule.Condition, rule.Threshold)
-}
-
 // sendAlertNotifications 发送告警通知
 func (s *AlertService) sendAlertNotifications(alert *models.Alert) error {
 	// 获取告警规则的通知渠道
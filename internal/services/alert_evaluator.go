@@ -0,0 +1,577 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"superview/internal/labels"
+	"superview/internal/logger"
+	"superview/internal/models"
+	"superview/internal/supervisor"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// alertRuleLookback 存储的指标超过这个时间没有更新视为无数据
+const alertRuleLookback = 5 * time.Minute
+
+// 由 AlertMonitor 直接维护的规则，见 CreateNodeOfflineAlert、CreateProcessStoppedAlert
+const (
+	alertRuleIDNodeOffline    = 1
+	alertRuleIDProcessStopped = 2
+)
+
+// isBuiltinAlertRule 节点离线、进程停止和进程抖动告警由各自的监控维护，不参与规则评估
+func isBuiltinAlertRule(rule *models.AlertRule) bool {
+	return rule.ID == alertRuleIDNodeOffline || rule.ID == alertRuleIDProcessStopped ||
+		rule.Name == models.AlertRuleProcessFlapping
+}
+
+// ruleTarget 规则评估的对象：节点（process 为空），或节点上的进程
+type ruleTarget struct {
+	node    string
+	process string
+}
+
+// ruleTargetState 规则在一个对象上的评估状态
+type ruleTargetState struct {
+	pendingSince time.Time // 触发条件开始成立的时间
+	clearSince   time.Time // 恢复条件开始成立的时间
+	firing       bool
+}
+
+// AlertTransition 一次评估中触发或恢复的告警
+type AlertTransition struct {
+	Alert    *models.Alert
+	Resolved bool
+}
+
+// AlertRuleEvaluator 按间隔评估启用的告警规则：
+//   - process_status 取节点上进程的实时状态码（0 STOPPED、20 RUNNING、200 FATAL 等）
+//   - cpu、memory 取最近上报的进程资源占用（process_metrics）和节点系统指标
+//   - 其他指标取 system_metrics 中 metric_type 或 metric_name 与之相同的最近记录
+//
+// 条件持续 Duration 秒后触发告警，越过 Hysteresis 放宽后的阈值并持续 ClearDuration 秒后恢复。
+// 没有数据的对象不会触发，已触发的告警保持不变。评估状态保存在内存中，只在主节点运行
+type AlertRuleEvaluator struct {
+	alertService      *AlertService
+	supervisorService *supervisor.SupervisorService
+	lookback          time.Duration
+
+	mu       sync.Mutex
+	states   map[uint]map[ruleTarget]*ruleTargetState
+	restored bool
+}
+
+// NewAlertRuleEvaluator 创建告警规则评估器
+func NewAlertRuleEvaluator(alertService *AlertService, supervisorService *supervisor.SupervisorService) *AlertRuleEvaluator {
+	return &AlertRuleEvaluator{
+		alertService:      alertService,
+		supervisorService: supervisorService,
+		lookback:          alertRuleLookback,
+		states:            make(map[uint]map[ruleTarget]*ruleTargetState),
+	}
+}
+
+// Reset 清空评估状态，下一次评估时从数据库恢复未解决的告警（接管主节点身份时调用）
+func (e *AlertRuleEvaluator) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.states = make(map[uint]map[ruleTarget]*ruleTargetState)
+	e.restored = false
+}
+
+// Evaluate 评估所有启用的规则，返回本次触发和恢复的告警
+func (e *AlertRuleEvaluator) Evaluate(now time.Time) ([]AlertTransition, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	db := e.alertService.db
+	var rules []models.AlertRule
+	if err := db.Where("enabled = ?", true).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	inventory, err := e.loadInventory()
+	if err != nil {
+		return nil, err
+	}
+	if !e.restored {
+		if err := e.restore(); err != nil {
+			return nil, err
+		}
+		e.restored = true
+	}
+
+	var transitions []AlertTransition
+	present := make(map[uint]bool, len(rules))
+	for i := range rules {
+		rule := &rules[i]
+		if isBuiltinAlertRule(rule) {
+			continue
+		}
+		present[rule.ID] = true
+
+		scope, err := parseRuleScope(rule)
+		if err != nil {
+			logger.Warn("Skipping alert rule with invalid scope",
+				zap.Uint("rule_id", rule.ID),
+				zap.String("rule_name", rule.Name),
+				zap.Error(err))
+			continue
+		}
+		samples, err := e.sample(rule, scope, inventory, now)
+		if err != nil {
+			logger.Error("Failed to evaluate alert rule",
+				zap.Uint("rule_id", rule.ID),
+				zap.String("rule_name", rule.Name),
+				zap.Error(err))
+			continue
+		}
+		transitions = append(transitions, e.step(rule, samples, now)...)
+	}
+
+	// 规则被禁用或删除后解决其告警
+	for ruleID, targets := range e.states {
+		if present[ruleID] {
+			continue
+		}
+		for target := range targets {
+			alert, err := e.alertService.resolveRuleAlert(ruleID, target, now)
+			if err != nil {
+				logger.Error("Failed to resolve alert of removed rule", zap.Uint("rule_id", ruleID), zap.Error(err))
+				continue
+			}
+			if alert != nil {
+				transitions = append(transitions, AlertTransition{Alert: alert, Resolved: true})
+			}
+		}
+		delete(e.states, ruleID)
+	}
+	return transitions, nil
+}
+
+// step 根据本次采样推进规则在各对象上的状态
+func (e *AlertRuleEvaluator) step(rule *models.AlertRule, samples map[ruleTarget]float64, now time.Time) []AlertTransition {
+	states := e.states[rule.ID]
+	if states == nil {
+		states = make(map[ruleTarget]*ruleTargetState)
+		e.states[rule.ID] = states
+	}
+
+	var transitions []AlertTransition
+	for _, target := range sortedTargets(samples) {
+		value := samples[target]
+		st := states[target]
+		if st == nil || !st.firing {
+			if !rule.ShouldTrigger(value) {
+				delete(states, target)
+				continue
+			}
+			if st == nil {
+				st = &ruleTargetState{pendingSince: now}
+				states[target] = st
+			}
+			if now.Sub(st.pendingSince) < time.Duration(rule.Duration)*time.Second {
+				continue
+			}
+			alert, created, err := e.alertService.fireRuleAlert(rule, target, value, now)
+			if err != nil {
+				logger.Error("Failed to create rule alert",
+					zap.Uint("rule_id", rule.ID),
+					zap.String("node_name", target.node),
+					zap.String("process_name", target.process),
+					zap.Error(err))
+				continue
+			}
+			st.firing = true
+			st.clearSince = time.Time{}
+			if created {
+				transitions = append(transitions, AlertTransition{Alert: alert})
+			}
+			continue
+		}
+
+		if !rule.ShouldClear(value) {
+			st.clearSince = time.Time{}
+			continue
+		}
+		if st.clearSince.IsZero() {
+			st.clearSince = now
+		}
+		if now.Sub(st.clearSince) < time.Duration(rule.ClearDuration)*time.Second {
+			continue
+		}
+		alert, err := e.alertService.resolveRuleAlert(rule.ID, target, now)
+		if err != nil {
+			logger.Error("Failed to resolve rule alert",
+				zap.Uint("rule_id", rule.ID),
+				zap.String("node_name", target.node),
+				zap.String("process_name", target.process),
+				zap.Error(err))
+			continue
+		}
+		delete(states, target)
+		if alert != nil {
+			transitions = append(transitions, AlertTransition{Alert: alert, Resolved: true})
+		}
+	}
+
+	// 没有数据的对象：未触发的重新计时，已触发的保持
+	for target, st := range states {
+		if _, ok := samples[target]; !ok && !st.firing {
+			delete(states, target)
+		}
+	}
+	return transitions
+}
+
+// restore 把数据库中未解决的规则告警恢复为已触发状态，恢复条件满足后正常解决
+func (e *AlertRuleEvaluator) restore() error {
+	db := e.alertService.db
+	var alerts []models.Alert
+	if err := db.Where("status IN (?, ?) AND rule_id NOT IN (?, ?)",
+		models.AlertStatusActive, models.AlertStatusAcknowledged,
+		alertRuleIDNodeOffline, alertRuleIDProcessStopped).
+		Find(&alerts).Error; err != nil {
+		return err
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	var builtin []uint
+	if err := db.Unscoped().Model(&models.AlertRule{}).
+		Where("name = ?", models.AlertRuleProcessFlapping).Pluck("id", &builtin).Error; err != nil {
+		return err
+	}
+	for _, alert := range alerts {
+		if containsUint(builtin, alert.RuleID) {
+			continue
+		}
+		target := ruleTarget{node: alert.NodeName}
+		if alert.ProcessName != nil {
+			target.process = *alert.ProcessName
+		}
+		if e.states[alert.RuleID] == nil {
+			e.states[alert.RuleID] = make(map[ruleTarget]*ruleTargetState)
+		}
+		e.states[alert.RuleID][target] = &ruleTargetState{firing: true}
+	}
+	return nil
+}
+
+// ruleNode 评估时的节点信息：ID 和标签来自数据库，进程和连接状态来自 supervisor
+type ruleNode struct {
+	id          uint
+	name        string
+	environment string
+	labels      map[string]string
+	maintenance bool
+	live        *supervisor.Node
+}
+
+type ruleInventory struct {
+	byID   map[uint]*ruleNode
+	byName map[string]*ruleNode
+}
+
+func (e *AlertRuleEvaluator) loadInventory() (*ruleInventory, error) {
+	var nodes []models.Node
+	if err := e.alertService.db.Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	inventory := &ruleInventory{
+		byID:   make(map[uint]*ruleNode, len(nodes)),
+		byName: make(map[string]*ruleNode, len(nodes)),
+	}
+	for i := range nodes {
+		n := &ruleNode{
+			id:          nodes[i].ID,
+			name:        nodes[i].Name,
+			environment: nodes[i].Environment,
+			labels:      nodes[i].GetLabels(),
+			maintenance: nodes[i].Maintenance,
+		}
+		inventory.byID[n.id] = n
+		inventory.byName[n.name] = n
+	}
+	if e.supervisorService != nil {
+		for _, live := range e.supervisorService.GetAllNodes() {
+			if n, ok := inventory.byName[live.Name]; ok {
+				n.live = live
+				n.maintenance = live.InMaintenance()
+			}
+		}
+	}
+	return inventory, nil
+}
+
+// sample 采集规则范围内各对象的当前指标值
+func (e *AlertRuleEvaluator) sample(rule *models.AlertRule, scope *ruleScope, inventory *ruleInventory, now time.Time) (map[ruleTarget]float64, error) {
+	samples := make(map[ruleTarget]float64)
+
+	if rule.Metric == "process_status" {
+		for _, n := range inventory.byName {
+			if n.live == nil || n.maintenance || !scope.matchesNode(n) {
+				continue
+			}
+			if connected, _ := n.live.GetConnectionStatus(); !connected {
+				continue
+			}
+			for _, p := range n.live.SerializeProcesses() {
+				name, _ := p["name"].(string)
+				group, _ := p["group"].(string)
+				state, _ := p["state"].(int)
+				if scope.matchesProcess(name, group) {
+					samples[ruleTarget{node: n.name, process: name}] = float64(state)
+				}
+			}
+		}
+		return samples, nil
+	}
+
+	db := e.alertService.db
+	from := now.Add(-e.lookback)
+
+	if rule.Metric == models.MetricTypeCPU || rule.Metric == models.MetricTypeMemory {
+		query := db.Where("timestamp > ? AND timestamp <= ?", from, now)
+		if scope.nodeID != nil {
+			query = query.Where("node_id = ?", *scope.nodeID)
+		}
+		var rows []models.ProcessMetrics
+		if err := query.Order("timestamp").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			row := &rows[i]
+			n := inventory.byID[row.NodeID]
+			if n == nil || n.maintenance || !hasResourceUsage(row) ||
+				!scope.matchesNode(n) || !scope.matchesProcess(row.ProcessName, "") {
+				continue
+			}
+			value := row.CPUPercent
+			if rule.Metric == models.MetricTypeMemory {
+				value = row.MemoryPercent
+			}
+			samples[ruleTarget{node: n.name, process: row.ProcessName}] = value
+		}
+	}
+
+	query := db.Where("timestamp > ? AND timestamp <= ? AND node_id IS NOT NULL AND (metric_type = ? OR metric_name = ?)",
+		from, now, rule.Metric, rule.Metric)
+	if scope.nodeID != nil {
+		query = query.Where("node_id = ?", *scope.nodeID)
+	}
+	var rows []models.SystemMetric
+	if err := query.Order("timestamp").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		n := inventory.byID[*row.NodeID]
+		if n == nil || n.maintenance || !scope.matchesNode(n) {
+			continue
+		}
+		target := ruleTarget{node: n.name}
+		if row.ProcessName != nil {
+			target.process = *row.ProcessName
+		}
+		if scope.process != "" && (target.process == "" || !scope.matchesProcess(target.process, "")) {
+			continue
+		}
+		samples[target] = row.Value
+	}
+	return samples, nil
+}
+
+// ruleScope 规则的适用范围：NodeID、ProcessName，以及 Tags 中的环境和节点标签
+type ruleScope struct {
+	nodeID      *uint
+	process     string
+	environment string
+	selector    labels.Selector
+}
+
+// parseRuleScope 解析规则范围。Tags 为 JSON 对象：environment 匹配节点环境，selector 为节点标签选择器，
+// 其他键按节点标签相等匹配，例如 {"environment":"prod","role":"web","selector":"region in (eu,us)"}
+func parseRuleScope(rule *models.AlertRule) (*ruleScope, error) {
+	scope := &ruleScope{nodeID: rule.NodeID}
+	if rule.ProcessName != nil {
+		scope.process = *rule.ProcessName
+	}
+
+	tags := strings.TrimSpace(rule.Tags)
+	if tags == "" || tags == "null" {
+		return scope, nil
+	}
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(tags), &raw); err != nil {
+		return nil, fmt.Errorf("tags must be a JSON object of strings: %w", err)
+	}
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var terms []string
+	for _, key := range keys {
+		switch key {
+		case "environment":
+			scope.environment = raw[key]
+		case "selector":
+			if strings.TrimSpace(raw[key]) != "" {
+				terms = append(terms, raw[key])
+			}
+		default:
+			terms = append(terms, key+"="+raw[key])
+		}
+	}
+	selector, err := labels.Parse(strings.Join(terms, ","))
+	if err != nil {
+		return nil, fmt.Errorf("invalid node selector in tags: %w", err)
+	}
+	scope.selector = selector
+	return scope, nil
+}
+
+// ValidateAlertRuleTags 检查告警规则的 Tags 是否能解析为适用范围
+func ValidateAlertRuleTags(tags string) error {
+	_, err := parseRuleScope(&models.AlertRule{Tags: tags})
+	return err
+}
+
+func (s *ruleScope) matchesNode(n *ruleNode) bool {
+	if s.nodeID != nil && *s.nodeID != n.id {
+		return false
+	}
+	if s.environment != "" && s.environment != n.environment {
+		return false
+	}
+	return s.selector.Matches(n.labels)
+}
+
+// matchesProcess ProcessName 可以是进程名、group:name 或进程组名；group 未知时只比较进程名部分
+func (s *ruleScope) matchesProcess(name, group string) bool {
+	if s.process == "" || s.process == name {
+		return true
+	}
+	if group != "" {
+		return s.process == group || s.process == group+":"+name
+	}
+	i := strings.LastIndex(s.process, ":")
+	return i >= 0 && s.process[i+1:] == name
+}
+
+func sortedTargets(samples map[ruleTarget]float64) []ruleTarget {
+	targets := make([]ruleTarget, 0, len(samples))
+	for target := range samples {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].node != targets[j].node {
+			return targets[i].node < targets[j].node
+		}
+		return targets[i].process < targets[j].process
+	})
+	return targets
+}
+
+func containsUint(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleAlertQuery 规则在对象上未解决的告警
+func (s *AlertService) ruleAlertQuery(ruleID uint, target ruleTarget) *gorm.DB {
+	query := s.db.Model(&models.Alert{}).Where("rule_id = ? AND node_name = ? AND status IN (?, ?)",
+		ruleID, target.node, models.AlertStatusActive, models.AlertStatusAcknowledged)
+	if target.process == "" {
+		return query.Where("process_name IS NULL")
+	}
+	return query.Where("process_name = ?", target.process)
+}
+
+// fireRuleAlert 创建规则告警并发送通知；已有未解决的告警时只更新当前值，created 为 false
+func (s *AlertService) fireRuleAlert(rule *models.AlertRule, target ruleTarget, value float64, now time.Time) (*models.Alert, bool, error) {
+	var existing models.Alert
+	err := s.ruleAlertQuery(rule.ID, target).First(&existing).Error
+	if err == nil {
+		s.db.Model(&existing).Update("value", value)
+		return &existing, false, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, false, err
+	}
+
+	alert := &models.Alert{
+		RuleID:    rule.ID,
+		NodeName:  target.node,
+		Message:   s.generateAlertMessage(rule, target, value),
+		Severity:  rule.Severity,
+		Status:    models.AlertStatusActive,
+		Value:     value,
+		StartTime: now,
+	}
+	if target.process != "" {
+		process := target.process
+		alert.ProcessName = &process
+	}
+	if err := s.CreateAlert(alert); err != nil {
+		return nil, false, err
+	}
+	logger.Info("Rule alert fired",
+		zap.Uint("rule_id", rule.ID),
+		zap.String("node_name", target.node),
+		zap.String("process_name", target.process),
+		zap.Float64("value", value))
+
+	s.notify(alert)
+	return alert, true, nil
+}
+
+// resolveRuleAlert 解决规则在对象上的告警，没有未解决的告警时返回 nil
+func (s *AlertService) resolveRuleAlert(ruleID uint, target ruleTarget, now time.Time) (*models.Alert, error) {
+	var alert models.Alert
+	err := s.ruleAlertQuery(ruleID, target).First(&alert).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = s.ruleAlertQuery(ruleID, target).Updates(map[string]interface{}{
+		"status":      models.AlertStatusResolved,
+		"end_time":    now,
+		"resolved_at": now,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	alert.Status = models.AlertStatusResolved
+	alert.EndTime = &now
+	alert.ResolvedAt = &now
+	logger.Info("Rule alert resolved",
+		zap.Uint("rule_id", ruleID),
+		zap.String("node_name", target.node),
+		zap.String("process_name", target.process))
+	return &alert, nil
+}
+
+// generateAlertMessage 生成告警消息
+func (s *AlertService) generateAlertMessage(rule *models.AlertRule, target ruleTarget, value float64) string {
+	subject := target.node
+	if target.process != "" {
+		subject = target.node + ":" + target.process
+	}
+	return fmt.Sprintf("告警: %s - %s %s 当前值: %.2f, 阈值: %s %.2f",
+		rule.Name, subject, rule.Metric, value, rule.Condition, rule.Threshold)
+}
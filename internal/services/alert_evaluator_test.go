@@ -0,0 +1,137 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"superview/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newAlertEvaluatorTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "superview.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Node{}, &models.ProcessMetrics{}, &models.SystemMetric{},
+		&models.AlertRule{}, &models.Alert{}, &models.AlertRuleNotificationChannel{}, &models.NotificationChannel{}))
+
+	web := &models.Node{Name: "web-1", Host: "10.0.0.1", Port: 9001, Environment: "prod"}
+	web.SetLabels(map[string]string{"role": "web"})
+	require.NoError(t, db.Create(web).Error)
+	require.NoError(t, db.Create(&models.Node{Name: "db-1", Host: "10.0.0.2", Port: 9001, Environment: "prod"}).Error)
+	// 内置规则占用 ID 1、2，评估器跳过它们
+	for _, name := range []string{"Node Offline", "Process Stopped"} {
+		require.NoError(t, db.Create(&models.AlertRule{Name: name, Metric: "process_status", Condition: "==", Duration: 1,
+			Severity: models.AlertSeverityHigh, Enabled: true, CreatedBy: "system"}).Error)
+	}
+	return db
+}
+
+func recordCPU(t *testing.T, db *gorm.DB, nodeID uint, process string, value float64, ts time.Time) {
+	require.NoError(t, db.Create(&models.ProcessMetrics{ProcessName: process, NodeID: nodeID, CPUPercent: value, Timestamp: ts}).Error)
+}
+
+func TestAlertRuleEvaluatorDurationAndHysteresis(t *testing.T) {
+	db := newAlertEvaluatorTestDB(t)
+	rule := &models.AlertRule{Name: "High CPU", Metric: "cpu", Condition: ">", Threshold: 90, Duration: 60,
+		Hysteresis: 10, Severity: models.AlertSeverityMedium, Enabled: true, CreatedBy: "admin"}
+	require.NoError(t, db.Create(rule).Error)
+	evaluator := NewAlertRuleEvaluator(NewAlertService(db), nil)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	evaluate := func(offset time.Duration, cpu float64) []AlertTransition {
+		now := base.Add(offset)
+		recordCPU(t, db, 1, "api", cpu, now)
+		transitions, err := evaluator.Evaluate(now)
+		require.NoError(t, err)
+		return transitions
+	}
+
+	// 条件成立不足 Duration 时不触发，中途恢复则重新计时
+	assert.Empty(t, evaluate(0, 95))
+	assert.Empty(t, evaluate(30*time.Second, 50))
+	assert.Empty(t, evaluate(60*time.Second, 95))
+	assert.Empty(t, evaluate(90*time.Second, 96))
+
+	transitions := evaluate(120*time.Second, 97)
+	require.Len(t, transitions, 1)
+	assert.False(t, transitions[0].Resolved)
+	assert.Equal(t, "web-1", transitions[0].Alert.NodeName)
+	assert.Equal(t, "api", *transitions[0].Alert.ProcessName)
+	assert.Equal(t, models.AlertSeverityMedium, transitions[0].Alert.Severity)
+
+	// 仍高于 Threshold-Hysteresis 时保持触发，不重复创建
+	assert.Empty(t, evaluate(150*time.Second, 85))
+	assert.Empty(t, evaluate(180*time.Second, 92))
+
+	transitions = evaluate(210*time.Second, 80)
+	require.Len(t, transitions, 1)
+	assert.True(t, transitions[0].Resolved)
+
+	var count int64
+	db.Model(&models.Alert{}).Where("rule_id = ? AND status = ?", rule.ID, models.AlertStatusResolved).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestAlertRuleEvaluatorScopeAndRestore(t *testing.T) {
+	db := newAlertEvaluatorTestDB(t)
+	webOnly := &models.AlertRule{Name: "Web disk", Metric: "disk", Condition: ">=", Threshold: 80, Duration: 1,
+		Tags: `{"environment":"prod","role":"web"}`, Severity: models.AlertSeverityHigh, Enabled: true, CreatedBy: "admin"}
+	require.NoError(t, db.Create(webOnly).Error)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, nodeID := range []uint{1, 2} {
+		id := nodeID
+		require.NoError(t, db.Create(&models.SystemMetric{NodeID: &id, MetricType: "disk", MetricName: "usage_percent", Value: 85, Timestamp: base}).Error)
+	}
+
+	evaluator := NewAlertRuleEvaluator(NewAlertService(db), nil)
+	_, err := evaluator.Evaluate(base)
+	require.NoError(t, err)
+	transitions, err := evaluator.Evaluate(base.Add(time.Second))
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+	assert.Equal(t, "web-1", transitions[0].Alert.NodeName)
+	assert.Nil(t, transitions[0].Alert.ProcessName)
+
+	// 新的主节点从数据库恢复已触发的告警，恢复条件满足后解决
+	id := uint(1)
+	require.NoError(t, db.Create(&models.SystemMetric{NodeID: &id, MetricType: "disk", MetricName: "usage_percent", Value: 40, Timestamp: base.Add(time.Minute)}).Error)
+	successor := NewAlertRuleEvaluator(NewAlertService(db), nil)
+	transitions, err = successor.Evaluate(base.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+	assert.True(t, transitions[0].Resolved)
+
+	// 禁用规则后解决其告警
+	evaluator.Reset()
+	require.NoError(t, db.Create(&models.SystemMetric{NodeID: &id, MetricType: "disk", MetricName: "usage_percent", Value: 90, Timestamp: base.Add(2 * time.Minute)}).Error)
+	_, err = evaluator.Evaluate(base.Add(2 * time.Minute))
+	require.NoError(t, err)
+	transitions, err = evaluator.Evaluate(base.Add(2*time.Minute + time.Second))
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+	require.NoError(t, db.Model(webOnly).Update("enabled", false).Error)
+	transitions, err = evaluator.Evaluate(base.Add(2*time.Minute + 2*time.Second))
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+	assert.True(t, transitions[0].Resolved)
+}
+
+func TestParseRuleScope(t *testing.T) {
+	process := "jobs:worker"
+	scope, err := parseRuleScope(&models.AlertRule{ProcessName: &process, Tags: `{"selector":"region in (eu,us)","role":"web"}`})
+	require.NoError(t, err)
+	assert.True(t, scope.matchesProcess("worker", "jobs"))
+	assert.True(t, scope.matchesProcess("worker", ""))
+	assert.False(t, scope.matchesProcess("worker", "other"))
+	assert.True(t, scope.matchesNode(&ruleNode{labels: map[string]string{"region": "eu", "role": "web"}}))
+	assert.False(t, scope.matchesNode(&ruleNode{labels: map[string]string{"region": "ap", "role": "web"}}))
+
+	assert.Error(t, ValidateAlertRuleTags(`["web"]`))
+	assert.Error(t, ValidateAlertRuleTags(`{"selector":"role in web"}`))
+	assert.NoError(t, ValidateAlertRuleTags(""))
+}
@@ -0,0 +1,540 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"superview/internal/config"
+	appErrors "superview/internal/errors"
+	"superview/internal/logger"
+	"superview/internal/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// maxIncidentMessageAlerts 事件通知中最多列出的告警条数
+const maxIncidentMessageAlerts = 20
+
+// SetGrouping 设置告警分组、等待和重复通知参数，在告警监控启动前调用
+func (s *AlertService) SetGrouping(settings *config.AlertingSettings) {
+	if settings != nil {
+		s.grouping = settings
+	}
+}
+
+// incidentGroup 计算告警的分组键（如 node=web-1,rule=2）和分组标签
+func (s *AlertService) incidentGroup(alert *models.Alert) (string, map[string]string) {
+	labels := make(map[string]string, len(s.grouping.GroupBy))
+	for _, key := range s.grouping.GroupBy {
+		switch key {
+		case "node":
+			labels[key] = alert.NodeName
+		case "environment":
+			labels[key] = s.alertSubject(alert).environment
+		case "rule":
+			labels[key] = strconv.FormatUint(uint64(alert.RuleID), 10)
+		}
+	}
+	return formatGroupLabels(labels), labels
+}
+
+func formatGroupLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+labels[key])
+	}
+	return strings.Join(parts, ",")
+}
+
+func severityLevel(severity string) int {
+	return (&models.Alert{Severity: severity}).GetSeverityLevel()
+}
+
+// addToIncident 将新告警加入分组键相同的未解决事件，没有时创建事件。
+// 事件在 group_wait 之后发送通知；已通知过的事件有新告警加入时，group_wait 之后发送更新。
+// 新告警未被确认，已确认的事件因此重新打开，恢复通知和升级
+func (s *AlertService) addToIncident(alert *models.Alert, now time.Time) error {
+	key, labels := s.incidentGroup(alert)
+	notifyAt := now.Add(s.grouping.GroupWait)
+
+	s.incidentMu.Lock()
+	defer s.incidentMu.Unlock()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var incident models.AlertIncident
+		if err := tx.Where("group_key = ? AND status IN (?, ?)", key, models.IncidentStatusOpen, models.IncidentStatusAcknowledged).
+			Order("id DESC").Limit(1).Find(&incident).Error; err != nil {
+			return err
+		}
+
+		if incident.ID == 0 {
+			var rule models.AlertRule
+			if err := tx.Unscoped().Where("id = ?", alert.RuleID).Limit(1).Find(&rule).Error; err != nil {
+				return err
+			}
+			incident = models.AlertIncident{
+				GroupKey:           key,
+				GroupLabels:        labels,
+				Status:             models.IncidentStatusOpen,
+				Severity:           alert.Severity,
+				RuleID:             alert.RuleID,
+				EscalationPolicyID: rule.EscalationPolicyID,
+				AlertCount:         1,
+				FirstAlertAt:       now,
+				NextNotifyAt:       &notifyAt,
+			}
+			if err := tx.Create(&incident).Error; err != nil {
+				return err
+			}
+		} else {
+			updates := map[string]interface{}{"alert_count": gorm.Expr("alert_count + 1")}
+			if severityLevel(alert.Severity) > severityLevel(incident.Severity) {
+				updates["severity"] = alert.Severity
+			}
+			if incident.Status == models.IncidentStatusAcknowledged {
+				logger.Info("Incident reopened by new alert",
+					zap.Uint("incident_id", incident.ID),
+					zap.Uint("alert_id", alert.ID))
+				updates["status"] = models.IncidentStatusOpen
+				updates["acked_by"] = nil
+				updates["acked_at"] = nil
+				updates["next_notify_at"] = notifyAt
+			} else if incident.NextNotifyAt == nil || incident.NextNotifyAt.After(notifyAt) {
+				updates["next_notify_at"] = notifyAt
+			}
+			if err := tx.Model(&incident).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		alert.IncidentID = &incident.ID
+		return tx.Model(alert).Update("incident_id", incident.ID).Error
+	})
+}
+
+// DispatchIncidents 处理到期的事件：解决告警已全部恢复的事件，发送到期的通知和重复通知，
+// 对超时未确认的事件执行升级。由告警监控在主节点上定期调用
+func (s *AlertService) DispatchIncidents(now time.Time) error {
+	var unresolved []models.AlertIncident
+	if err := s.db.Where("status IN (?, ?)", models.IncidentStatusOpen, models.IncidentStatusAcknowledged).
+		Find(&unresolved).Error; err != nil {
+		return err
+	}
+	for i := range unresolved {
+		if err := s.resolveIncidentIfCleared(&unresolved[i], now); err != nil {
+			logger.Error("Failed to resolve incident", zap.Uint("incident_id", unresolved[i].ID), zap.Error(err))
+		}
+	}
+
+	var due []models.AlertIncident
+	if err := s.db.Where("status = ? AND next_notify_at <= ?", models.IncidentStatusOpen, now).
+		Order("id").Find(&due).Error; err != nil {
+		return err
+	}
+	for i := range due {
+		if err := s.notifyIncident(&due[i], now); err != nil {
+			logger.Error("Failed to notify incident", zap.Uint("incident_id", due[i].ID), zap.Error(err))
+		}
+	}
+
+	var escalating []models.AlertIncident
+	if err := s.db.Where("status = ? AND next_escalation_at <= ?", models.IncidentStatusOpen, now).
+		Order("id").Find(&escalating).Error; err != nil {
+		return err
+	}
+	for i := range escalating {
+		if err := s.escalateIncident(&escalating[i], now); err != nil {
+			logger.Error("Failed to escalate incident", zap.Uint("incident_id", escalating[i].ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// resolveIncidentIfCleared 事件内的告警全部解决后解决事件
+func (s *AlertService) resolveIncidentIfCleared(incident *models.AlertIncident, now time.Time) error {
+	var count int64
+	if err := s.db.Model(&models.Alert{}).Where("incident_id = ? AND status <> ?", incident.ID, models.AlertStatusResolved).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	logger.Info("Incident resolved", zap.Uint("incident_id", incident.ID), zap.String("group_key", incident.GroupKey))
+	return s.db.Model(incident).Updates(map[string]interface{}{
+		"status":             models.IncidentStatusResolved,
+		"resolved_at":        now,
+		"next_notify_at":     nil,
+		"next_escalation_at": nil,
+	}).Error
+}
+
+// notifyIncident 发送事件的首次通知、更新或重复通知，并在有下一步升级时开始计时
+func (s *AlertService) notifyIncident(incident *models.AlertIncident, now time.Time) error {
+	steps, err := s.escalationSteps(incident)
+	if err != nil {
+		return err
+	}
+	if err := s.sendIncidentNotifications(incident, steps, false); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"last_notified_at": now,
+		"next_notify_at":   s.repeatAt(now),
+	}
+	if incident.NextEscalationAt == nil && incident.EscalationStep+1 < len(steps) {
+		updates["next_escalation_at"] = escalationAt(steps[incident.EscalationStep+1], now)
+	}
+	return s.db.Model(incident).Updates(updates).Error
+}
+
+// escalateIncident 事件超时未确认，升级到下一步并通知该步骤的渠道
+func (s *AlertService) escalateIncident(incident *models.AlertIncident, now time.Time) error {
+	steps, err := s.escalationSteps(incident)
+	if err != nil {
+		return err
+	}
+	if incident.EscalationStep+1 >= len(steps) {
+		return s.db.Model(incident).Update("next_escalation_at", nil).Error
+	}
+
+	incident.EscalationStep++
+	logger.Info("Escalating incident",
+		zap.Uint("incident_id", incident.ID),
+		zap.Int("step", incident.EscalationStep))
+	if err := s.sendIncidentNotifications(incident, steps, true); err != nil {
+		return err
+	}
+
+	var nextEscalation interface{}
+	if incident.EscalationStep+1 < len(steps) {
+		nextEscalation = escalationAt(steps[incident.EscalationStep+1], now)
+	}
+	return s.db.Model(incident).Updates(map[string]interface{}{
+		"escalation_step":    incident.EscalationStep,
+		"last_notified_at":   now,
+		"next_notify_at":     s.repeatAt(now),
+		"next_escalation_at": nextEscalation,
+	}).Error
+}
+
+// repeatAt 下一次重复通知的时间，repeat_interval 为 0 时不重复
+func (s *AlertService) repeatAt(now time.Time) interface{} {
+	if s.grouping.RepeatInterval <= 0 {
+		return nil
+	}
+	return now.Add(s.grouping.RepeatInterval)
+}
+
+func escalationAt(step models.EscalationStep, now time.Time) time.Time {
+	return now.Add(time.Duration(step.AfterMinutes) * time.Minute)
+}
+
+// escalationSteps 事件升级策略的步骤，没有策略时返回 nil
+func (s *AlertService) escalationSteps(incident *models.AlertIncident) ([]models.EscalationStep, error) {
+	if incident.EscalationPolicyID == nil {
+		return nil, nil
+	}
+	var policy models.AlertEscalationPolicy
+	if err := s.db.Where("id = ?", *incident.EscalationPolicyID).Limit(1).Find(&policy).Error; err != nil {
+		return nil, err
+	}
+	return policy.Steps, nil
+}
+
+// incidentChannels 事件当前应通知的渠道：有升级策略时为当前步骤的渠道，否则为事件内告警规则绑定的渠道
+func (s *AlertService) incidentChannels(incident *models.AlertIncident, steps []models.EscalationStep) ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	if len(steps) > 0 {
+		step := steps[min(incident.EscalationStep, len(steps)-1)]
+		if len(step.ChannelIDs) == 0 {
+			return nil, nil
+		}
+		err := s.db.Where("id IN ? AND enabled = ?", step.ChannelIDs, true).Order("id").Find(&channels).Error
+		return channels, err
+	}
+
+	var ruleIDs []uint
+	if err := s.db.Model(&models.Alert{}).Where("incident_id = ?", incident.ID).
+		Distinct("rule_id").Pluck("rule_id", &ruleIDs).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[uint]bool)
+	for _, ruleID := range ruleIDs {
+		ruleChannels, err := s.GetRuleChannels(ruleID)
+		if err != nil {
+			return nil, err
+		}
+		for _, channel := range ruleChannels {
+			if !seen[channel.ID] {
+				seen[channel.ID] = true
+				channels = append(channels, channel)
+			}
+		}
+	}
+	return channels, nil
+}
+
+// sendIncidentNotifications 向事件当前的渠道发送一条汇总通知，列出事件内未解决的告警
+func (s *AlertService) sendIncidentNotifications(incident *models.AlertIncident, steps []models.EscalationStep, escalated bool) error {
+	var alerts []models.Alert
+	if err := s.db.Where("incident_id = ? AND status <> ?", incident.ID, models.AlertStatusResolved).
+		Order("start_time").Find(&alerts).Error; err != nil {
+		return err
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+	channels, err := s.incidentChannels(incident, steps)
+	if err != nil {
+		return err
+	}
+
+	for i := range channels {
+		channel := channels[i]
+		notification := &models.Notification{
+			AlertID:    alerts[0].ID,
+			IncidentID: &incident.ID,
+			ChannelID:  channel.ID,
+			Status:     models.NotificationStatusPending,
+			Message:    formatIncidentMessage(incident, alerts, &channel, escalated),
+		}
+		if err := s.db.Create(notification).Error; err != nil {
+			logger.Error("Error creating notification", zap.Error(err))
+			continue
+		}
+
+		// 异步发送通知
+		go s.sendNotification(notification, &channel)
+	}
+	return nil
+}
+
+// formatIncidentMessage 格式化事件通知消息
+func formatIncidentMessage(incident *models.AlertIncident, alerts []models.Alert, channel *models.NotificationChannel, escalated bool) string {
+	title := fmt.Sprintf("告警事件 #%d：%d 条告警", incident.ID, len(alerts))
+	if escalated {
+		title = fmt.Sprintf("[升级 %d] %s", incident.EscalationStep, title)
+	}
+
+	var b strings.Builder
+	if group := formatGroupLabels(incident.GroupLabels); group != "" {
+		fmt.Fprintf(&b, "分组: %s\n", group)
+	}
+	fmt.Fprintf(&b, "严重级别: %s\n开始时间: %s\n", incident.Severity, incident.FirstAlertAt.Format("2006-01-02 15:04:05"))
+	for i, alert := range alerts {
+		if i == maxIncidentMessageAlerts {
+			fmt.Fprintf(&b, "… 另有 %d 条告警\n", len(alerts)-i)
+			break
+		}
+		fmt.Fprintf(&b, "- [%s] %s\n", alert.Severity, alert.Message)
+	}
+	body := strings.TrimRight(b.String(), "\n")
+
+	switch channel.Type {
+	case models.ChannelTypeEmail:
+		return title + "\n\n" + body
+	case models.ChannelTypeSlack:
+		return fmt.Sprintf(":warning: *%s*\n%s", title, body)
+	default:
+		return title + "\n" + body
+	}
+}
+
+// acknowledgeIncident 确认事件，停止重复通知和升级
+func acknowledgeIncident(db *gorm.DB, id uint, userID string, now time.Time) error {
+	return db.Model(&models.AlertIncident{}).
+		Where("id = ? AND status = ?", id, models.IncidentStatusOpen).
+		Updates(map[string]interface{}{
+			"status":             models.IncidentStatusAcknowledged,
+			"acked_by":           userID,
+			"acked_at":           now,
+			"next_notify_at":     nil,
+			"next_escalation_at": nil,
+		}).Error
+}
+
+// AcknowledgeIncident 确认事件及其所有活跃告警
+func (s *AlertService) AcknowledgeIncident(id uint, userID string) error {
+	incident, err := s.GetIncident(id)
+	if err != nil {
+		return err
+	}
+	if incident.Status == models.IncidentStatusResolved {
+		return appErrors.NewConflictError("incident", fmt.Sprintf("incident %d is already resolved", id))
+	}
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Alert{}).
+			Where("incident_id = ? AND status = ?", id, models.AlertStatusActive).
+			Updates(map[string]interface{}{
+				"status":   models.AlertStatusAcknowledged,
+				"acked_by": userID,
+				"acked_at": now,
+			}).Error; err != nil {
+			return appErrors.NewDatabaseError("acknowledge incident alerts", err)
+		}
+		if err := acknowledgeIncident(tx, id, userID, now); err != nil {
+			return appErrors.NewDatabaseError("acknowledge incident", err)
+		}
+		return nil
+	})
+}
+
+// ListIncidents 列出事件，status 为空时返回所有状态，按创建时间倒序
+func (s *AlertService) ListIncidents(status string, limit int) ([]models.AlertIncident, error) {
+	query := s.db.Order("id DESC")
+	switch status {
+	case "":
+	case models.IncidentStatusOpen, models.IncidentStatusAcknowledged, models.IncidentStatusResolved:
+		query = query.Where("status = ?", status)
+	default:
+		return nil, appErrors.NewValidationError("status", "status must be open, acknowledged or resolved")
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var incidents []models.AlertIncident
+	if err := query.Find(&incidents).Error; err != nil {
+		return nil, appErrors.NewDatabaseError("list incidents", err)
+	}
+	return incidents, nil
+}
+
+// GetIncident 获取事件及其告警
+func (s *AlertService) GetIncident(id uint) (*models.AlertIncident, error) {
+	var incident models.AlertIncident
+	if err := s.db.Preload("Alerts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_time")
+	}).First(&incident, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.NewNotFoundError("incident", fmt.Sprintf("%d", id))
+		}
+		return nil, appErrors.NewDatabaseError("get incident", err)
+	}
+	return &incident, nil
+}
+
+// ListEscalationPolicies 列出升级策略
+func (s *AlertService) ListEscalationPolicies() ([]models.AlertEscalationPolicy, error) {
+	var policies []models.AlertEscalationPolicy
+	if err := s.db.Order("name").Find(&policies).Error; err != nil {
+		return nil, appErrors.NewDatabaseError("list escalation policies", err)
+	}
+	return policies, nil
+}
+
+// GetEscalationPolicy 获取升级策略
+func (s *AlertService) GetEscalationPolicy(id uint) (*models.AlertEscalationPolicy, error) {
+	var policy models.AlertEscalationPolicy
+	if err := s.db.First(&policy, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.NewNotFoundError("escalation policy", fmt.Sprintf("%d", id))
+		}
+		return nil, appErrors.NewDatabaseError("get escalation policy", err)
+	}
+	return &policy, nil
+}
+
+// ValidateEscalationPolicyID 检查告警规则引用的升级策略是否存在
+func (s *AlertService) ValidateEscalationPolicyID(id uint) error {
+	var count int64
+	if err := s.db.Model(&models.AlertEscalationPolicy{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return appErrors.NewDatabaseError("check escalation policy", err)
+	}
+	if count == 0 {
+		return appErrors.NewValidationError("escalation_policy_id", fmt.Sprintf("escalation policy %d not found", id))
+	}
+	return nil
+}
+
+func (s *AlertService) validateEscalationPolicy(policy *models.AlertEscalationPolicy) error {
+	if strings.TrimSpace(policy.Name) == "" {
+		return appErrors.NewValidationError("name", "name is required")
+	}
+	if len(policy.Steps) == 0 {
+		return appErrors.NewValidationError("steps", "at least one step is required")
+	}
+	for i, step := range policy.Steps {
+		field := fmt.Sprintf("steps[%d]", i)
+		if len(step.ChannelIDs) == 0 {
+			return appErrors.NewValidationError(field, "channel_ids is required")
+		}
+		if i > 0 && step.AfterMinutes <= 0 {
+			return appErrors.NewValidationError(field, "after_minutes must be positive")
+		}
+		var count int64
+		if err := s.db.Model(&models.NotificationChannel{}).Where("id IN ?", step.ChannelIDs).Count(&count).Error; err != nil {
+			return appErrors.NewDatabaseError("check notification channels", err)
+		}
+		if int(count) != len(step.ChannelIDs) {
+			return appErrors.NewValidationError(field, "unknown notification channel in channel_ids")
+		}
+	}
+	return nil
+}
+
+// CreateEscalationPolicy 创建升级策略
+func (s *AlertService) CreateEscalationPolicy(policy *models.AlertEscalationPolicy) error {
+	if err := s.validateEscalationPolicy(policy); err != nil {
+		return err
+	}
+	if err := s.db.Create(policy).Error; err != nil {
+		if isDuplicateError(err) {
+			return appErrors.NewConflictError("escalation policy", "escalation policy "+policy.Name+" already exists")
+		}
+		return appErrors.NewDatabaseError("create escalation policy", err)
+	}
+	return nil
+}
+
+// UpdateEscalationPolicy 更新升级策略，进行中的事件在下一次升级时使用新的步骤
+func (s *AlertService) UpdateEscalationPolicy(id uint, update *models.AlertEscalationPolicy) (*models.AlertEscalationPolicy, error) {
+	policy, err := s.GetEscalationPolicy(id)
+	if err != nil {
+		return nil, err
+	}
+	policy.Name = update.Name
+	policy.Description = update.Description
+	policy.Steps = update.Steps
+	if err := s.validateEscalationPolicy(policy); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(policy).Error; err != nil {
+		if isDuplicateError(err) {
+			return nil, appErrors.NewConflictError("escalation policy", "escalation policy "+policy.Name+" already exists")
+		}
+		return nil, appErrors.NewDatabaseError("update escalation policy", err)
+	}
+	return policy, nil
+}
+
+// DeleteEscalationPolicy 删除升级策略，规则和事件对它的引用一并清除
+func (s *AlertService) DeleteEscalationPolicy(id uint) error {
+	if _, err := s.GetEscalationPolicy(id); err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AlertRule{}).Where("escalation_policy_id = ?", id).
+			Update("escalation_policy_id", nil).Error; err != nil {
+			return appErrors.NewDatabaseError("delete escalation policy", err)
+		}
+		if err := tx.Model(&models.AlertIncident{}).Where("escalation_policy_id = ?", id).
+			Updates(map[string]interface{}{"escalation_policy_id": nil, "next_escalation_at": nil}).Error; err != nil {
+			return appErrors.NewDatabaseError("delete escalation policy", err)
+		}
+		if err := tx.Delete(&models.AlertEscalationPolicy{}, id).Error; err != nil {
+			return appErrors.NewDatabaseError("delete escalation policy", err)
+		}
+		return nil
+	})
+}
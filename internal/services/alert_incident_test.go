@@ -0,0 +1,155 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"superview/internal/config"
+	"superview/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newAlertIncidentTestService(t *testing.T) (*AlertService, *gorm.DB) {
+	service, db := newAlertSilenceTestService(t)
+	require.NoError(t, db.AutoMigrate(&models.AlertIncident{}, &models.AlertEscalationPolicy{}, &models.Notification{}))
+	service.SetGrouping(&config.AlertingSettings{
+		GroupBy:        []string{"node", "rule"},
+		GroupWait:      30 * time.Second,
+		RepeatInterval: time.Hour,
+	})
+	return service, db
+}
+
+func createTestChannel(t *testing.T, db *gorm.DB, name string) *models.NotificationChannel {
+	// 不支持的渠道类型，发送失败只记录状态
+	channel := &models.NotificationChannel{Name: name, Type: models.ChannelTypeSMS, Config: "{}", Enabled: true}
+	require.NoError(t, db.Create(channel).Error)
+	return channel
+}
+
+func incidentNotifications(t *testing.T, db *gorm.DB, incidentID uint, channelID uint) int64 {
+	var count int64
+	require.NoError(t, db.Model(&models.Notification{}).
+		Where("incident_id = ? AND channel_id = ?", incidentID, channelID).Count(&count).Error)
+	return count
+}
+
+func TestIncidentGroupingAndRepeat(t *testing.T) {
+	service, db := newAlertIncidentTestService(t)
+	channel := createTestChannel(t, db, "ops")
+	require.NoError(t, service.AssignChannelToRule(2, channel.ID))
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var stopped []*models.Alert
+	for _, process := range []string{"api", "worker", "cron"} {
+		alert := createTestAlert(t, db, 2, "web-1", process, models.AlertSeverityHigh)
+		require.NoError(t, service.addToIncident(alert, base))
+		stopped = append(stopped, alert)
+	}
+	offline := createTestAlert(t, db, 1, "web-1", "", models.AlertSeverityCritical)
+	require.NoError(t, service.addToIncident(offline, base))
+
+	incidents, err := service.ListIncidents(models.IncidentStatusOpen, 0)
+	require.NoError(t, err)
+	require.Len(t, incidents, 2)
+	incident, err := service.GetIncident(*stopped[0].IncidentID)
+	require.NoError(t, err)
+	assert.Equal(t, "node=web-1,rule=2", incident.GroupKey)
+	assert.Equal(t, 3, incident.AlertCount)
+	assert.Len(t, incident.Alerts, 3)
+
+	// group_wait 内不通知，到期后三条告警合并为一条通知
+	require.NoError(t, service.DispatchIncidents(base.Add(10*time.Second)))
+	assert.Zero(t, incidentNotifications(t, db, incident.ID, channel.ID))
+	require.NoError(t, service.DispatchIncidents(base.Add(30*time.Second)))
+	assert.Equal(t, int64(1), incidentNotifications(t, db, incident.ID, channel.ID))
+	require.NoError(t, service.DispatchIncidents(base.Add(time.Minute)))
+	assert.Equal(t, int64(1), incidentNotifications(t, db, incident.ID, channel.ID))
+
+	// 未确认的事件按 repeat_interval 重复通知
+	require.NoError(t, service.DispatchIncidents(base.Add(30*time.Second+time.Hour)))
+	assert.Equal(t, int64(2), incidentNotifications(t, db, incident.ID, channel.ID))
+
+	// 告警全部解决后事件解决
+	for _, alert := range stopped {
+		require.NoError(t, service.ResolveAlert(alert.ID, "admin"))
+	}
+	require.NoError(t, service.DispatchIncidents(base.Add(2*time.Hour)))
+	incident, err = service.GetIncident(incident.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.IncidentStatusResolved, incident.Status)
+	assert.Nil(t, incident.NextNotifyAt)
+
+	// 新告警开启新的事件
+	alert := createTestAlert(t, db, 2, "web-1", "api", models.AlertSeverityHigh)
+	require.NoError(t, service.addToIncident(alert, base.Add(3*time.Hour)))
+	assert.NotEqual(t, incident.ID, *alert.IncidentID)
+}
+
+func TestIncidentEscalation(t *testing.T) {
+	service, db := newAlertIncidentTestService(t)
+	primary := createTestChannel(t, db, "primary")
+	secondary := createTestChannel(t, db, "secondary")
+	policy := &models.AlertEscalationPolicy{Name: "oncall", CreatedBy: "admin", Steps: []models.EscalationStep{
+		{ChannelIDs: []uint{primary.ID}},
+		{ChannelIDs: []uint{secondary.ID}, AfterMinutes: 10},
+	}}
+	require.NoError(t, service.CreateEscalationPolicy(policy))
+	require.NoError(t, db.Model(&models.AlertRule{}).Where("id = ?", 2).Update("escalation_policy_id", policy.ID).Error)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	escalate := func(node string) (*models.Alert, uint) {
+		alert := createTestAlert(t, db, 2, node, "api", models.AlertSeverityHigh)
+		require.NoError(t, service.addToIncident(alert, base))
+		return alert, *alert.IncidentID
+	}
+	_, unacked := escalate("web-1")
+	acked, ackedIncident := escalate("db-1")
+
+	require.NoError(t, service.DispatchIncidents(base.Add(30*time.Second)))
+	for _, id := range []uint{unacked, ackedIncident} {
+		assert.Equal(t, int64(1), incidentNotifications(t, db, id, primary.ID))
+		assert.Zero(t, incidentNotifications(t, db, id, secondary.ID))
+	}
+
+	// 确认告警即停止所属事件的升级
+	require.NoError(t, service.AcknowledgeAlert(acked.ID, "admin"))
+	require.NoError(t, service.DispatchIncidents(base.Add(10*time.Minute+30*time.Second)))
+	assert.Equal(t, int64(1), incidentNotifications(t, db, unacked, secondary.ID))
+	assert.Zero(t, incidentNotifications(t, db, ackedIncident, secondary.ID))
+
+	incident, err := service.GetIncident(unacked)
+	require.NoError(t, err)
+	assert.Equal(t, 1, incident.EscalationStep)
+	assert.Nil(t, incident.NextEscalationAt)
+	incident, err = service.GetIncident(ackedIncident)
+	require.NoError(t, err)
+	assert.Equal(t, models.IncidentStatusAcknowledged, incident.Status)
+	assert.Nil(t, incident.NextNotifyAt)
+
+	// 新告警加入已确认的事件时重新打开事件，group_wait 后通知并重新开始升级计时
+	later := createTestAlert(t, db, 2, "db-1", "worker", models.AlertSeverityCritical)
+	require.NoError(t, service.addToIncident(later, base.Add(11*time.Minute)))
+	assert.Equal(t, ackedIncident, *later.IncidentID)
+	incident, err = service.GetIncident(ackedIncident)
+	require.NoError(t, err)
+	assert.Equal(t, models.IncidentStatusOpen, incident.Status)
+	assert.Equal(t, models.AlertSeverityCritical, incident.Severity)
+	assert.Nil(t, incident.AckedBy)
+	require.NoError(t, service.DispatchIncidents(base.Add(11*time.Minute+10*time.Second)))
+	assert.Equal(t, int64(1), incidentNotifications(t, db, ackedIncident, primary.ID))
+	require.NoError(t, service.DispatchIncidents(base.Add(11*time.Minute+30*time.Second)))
+	assert.Equal(t, int64(2), incidentNotifications(t, db, ackedIncident, primary.ID))
+	require.NoError(t, service.DispatchIncidents(base.Add(21*time.Minute+30*time.Second)))
+	assert.Equal(t, int64(1), incidentNotifications(t, db, ackedIncident, secondary.ID))
+
+	assert.Error(t, service.CreateEscalationPolicy(&models.AlertEscalationPolicy{Name: "bad", Steps: []models.EscalationStep{
+		{ChannelIDs: []uint{primary.ID}}, {ChannelIDs: []uint{secondary.ID}},
+	}}))
+	assert.Error(t, service.CreateEscalationPolicy(&models.AlertEscalationPolicy{Name: "bad", Steps: []models.EscalationStep{
+		{ChannelIDs: []uint{99}},
+	}}))
+}
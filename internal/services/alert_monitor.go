@@ -7,25 +7,33 @@ import (
 	"sync"
 	"time"
 
+	"superview/internal/config"
 	"superview/internal/logger"
 	"superview/internal/models"
 	"superview/internal/supervisor"
 	"go.uber.org/zap"
 )
 
+// incidentDispatchInterval 检查拦截是否结束，以及事件通知、重复通知和升级是否到期的间隔
+const incidentDispatchInterval = 5 * time.Second
+
 // WebSocketHub interface for broadcasting messages
 type WebSocketHub interface {
 	Broadcast(message []byte)
 }
 
-// AlertMonitor 监控节点和进程状态变化，自动创建和解决告警
+// AlertMonitor 监控节点和进程状态变化，自动创建和解决告警，按间隔评估告警规则并分派事件通知
 type AlertMonitor struct {
-	alertService      *AlertService
-	supervisorService *supervisor.SupervisorService
-	hub               WebSocketHub
-	stopChan          chan struct{}
-	wg                sync.WaitGroup
-	mu                sync.RWMutex
+	alertService       *AlertService
+	supervisorService  *supervisor.SupervisorService
+	hub                WebSocketHub
+	evaluator          *AlertRuleEvaluator
+	evaluationInterval time.Duration
+	stopChan           chan struct{}
+	running            bool
+	runMu              sync.Mutex
+	wg                 sync.WaitGroup
+	mu                 sync.RWMutex
 	
 	// 缓存上一次的状态，用于检测变化
 	lastNodeStatus    map[string]bool // nodeName -> isConnected
@@ -35,17 +43,23 @@ type AlertMonitor struct {
 // NewAlertMonitor 创建 AlertMonitor 实例
 func NewAlertMonitor(alertService *AlertService, supervisorService *supervisor.SupervisorService, hub WebSocketHub) *AlertMonitor {
 	monitor := &AlertMonitor{
-		alertService:      alertService,
-		supervisorService: supervisorService,
-		hub:               hub,
-		stopChan:          make(chan struct{}),
-		lastNodeStatus:    make(map[string]bool),
-		lastProcessStatus: make(map[string]int),
+		alertService:       alertService,
+		supervisorService:  supervisorService,
+		hub:                hub,
+		evaluator:          NewAlertRuleEvaluator(alertService, supervisorService),
+		evaluationInterval: config.DefaultAlertEvaluationInterval,
+		lastNodeStatus:     make(map[string]bool),
+		lastProcessStatus:  make(map[string]int),
 	}
 	
 	// 确保系统默认规则存在
 	monitor.ensureDefaultRules()
 	
+	// 节点删除后清理缓存并解决该节点的告警
+	if supervisorService != nil {
+		supervisorService.OnNodeRemoved(monitor.forgetNode)
+	}
+	
 	return monitor
 }
 
@@ -67,63 +81,84 @@ func (m *AlertMonitor) ensureDefaultRules() {
 	}
 }
 
-// Start 启动 Alert Monitor
+// SetEvaluationInterval 设置告警规则评估间隔，在 Start 之前调用
+func (m *AlertMonitor) SetEvaluationInterval(interval time.Duration) {
+	if interval > 0 {
+		m.evaluationInterval = interval
+	}
+}
+
+// Start 启动 Alert Monitor（可在 Stop 之后再次启动，多实例部署时随主节点身份切换）
 func (m *AlertMonitor) Start() {
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+	if m.running {
+		return
+	}
 	logger.Info("Starting Alert Monitor")
 	
-	// 初始化当前状态
-	m.initializeStatus()
+	// 重置状态缓存
+	m.resetStatus()
+	m.evaluator.Reset()
 	
 	// 启动监控 goroutine
+	m.stopChan = make(chan struct{})
+	m.running = true
 	m.wg.Add(1)
-	go m.monitorLoop()
+	go m.monitorLoop(m.stopChan)
 	
 	logger.Info("Alert Monitor started")
 }
 
 // Stop 停止 Alert Monitor
 func (m *AlertMonitor) Stop() {
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+	if !m.running {
+		return
+	}
 	logger.Info("Stopping Alert Monitor")
 	close(m.stopChan)
 	m.wg.Wait()
+	m.running = false
 	logger.Info("Alert Monitor stopped")
 }
 
-// initializeStatus 初始化当前节点和进程状态
-func (m *AlertMonitor) initializeStatus() {
+// resetStatus 清空状态缓存，下一次检查会重新评估所有节点和进程
+// 告警的创建和解决都是幂等的；接管主节点身份时可以补上前任主节点遗漏的状态变化
+func (m *AlertMonitor) resetStatus() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	
-	nodes := m.supervisorService.GetAllNodes()
-	for _, node := range nodes {
-		m.lastNodeStatus[node.Name] = node.IsConnected
-		
-		// 初始化进程状态
-		if node.IsConnected {
-			for _, process := range node.Processes {
-				key := fmt.Sprintf("%s:%s", node.Name, process.Name)
-				m.lastProcessStatus[key] = process.State
-			}
-		}
-	}
-	
-	logger.Info("Alert Monitor status initialized",
-		zap.Int("nodes", len(m.lastNodeStatus)),
-		zap.Int("processes", len(m.lastProcessStatus)))
+	m.lastNodeStatus = make(map[string]bool)
+	m.lastProcessStatus = make(map[string]int)
 }
 
 // monitorLoop 监控循环
-func (m *AlertMonitor) monitorLoop() {
+func (m *AlertMonitor) monitorLoop(stopChan chan struct{}) {
 	defer m.wg.Done()
 	
 	ticker := time.NewTicker(30 * time.Second) // 每30秒检查一次
 	defer ticker.Stop()
+	evalTicker := time.NewTicker(m.evaluationInterval)
+	defer evalTicker.Stop()
+	incidentTicker := time.NewTicker(incidentDispatchInterval)
+	defer incidentTicker.Stop()
 	
 	for {
 		select {
 		case <-ticker.C:
 			m.checkStatus()
-		case <-m.stopChan:
+		case now := <-evalTicker.C:
+			m.evaluateRules(now)
+		case now := <-incidentTicker.C:
+			if err := m.alertService.ReleaseSuppressedAlerts(now); err != nil {
+				logger.Error("Failed to release suppressed alerts", zap.Error(err))
+			}
+			if err := m.alertService.DispatchIncidents(now); err != nil {
+				logger.Error("Failed to dispatch alert incidents", zap.Error(err))
+			}
+		case <-stopChan:
 			return
 		}
 	}
@@ -139,6 +174,11 @@ func (m *AlertMonitor) checkStatus() {
 	// 检查节点状态变化
 	currentNodeStatus := make(map[string]bool)
 	for _, node := range nodes {
+		// 维护中的节点不告警；不记入缓存，退出维护后重新评估
+		if node.InMaintenance() {
+			m.forgetProcessStatus(node.Name)
+			continue
+		}
 		currentNodeStatus[node.Name] = node.IsConnected
 		
 		lastStatus, exists := m.lastNodeStatus[node.Name]
@@ -167,6 +207,55 @@ func (m *AlertMonitor) checkStatus() {
 	m.lastNodeStatus = currentNodeStatus
 }
 
+// evaluateRules 评估告警规则并推送触发和恢复的告警
+func (m *AlertMonitor) evaluateRules(now time.Time) {
+	transitions, err := m.evaluator.Evaluate(now)
+	if err != nil {
+		logger.Error("Failed to evaluate alert rules", zap.Error(err))
+		return
+	}
+	for _, t := range transitions {
+		eventType := "alert_created"
+		if t.Resolved {
+			eventType = "alert_resolved"
+		}
+		processName := ""
+		if t.Alert.ProcessName != nil {
+			processName = *t.Alert.ProcessName
+		}
+		m.broadcastAlertEvent(eventType, t.Alert.NodeName, processName, t.Alert.Severity)
+	}
+}
+
+// forgetProcessStatus 清除节点下所有进程的状态缓存（调用方持有 m.mu）
+func (m *AlertMonitor) forgetProcessStatus(nodeName string) {
+	prefix := nodeName + ":"
+	for key := range m.lastProcessStatus {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.lastProcessStatus, key)
+		}
+	}
+}
+
+// forgetNode 节点被删除：清除缓存并解决该节点所有未关闭的告警
+func (m *AlertMonitor) forgetNode(nodeName string) {
+	m.mu.Lock()
+	delete(m.lastNodeStatus, nodeName)
+	m.forgetProcessStatus(nodeName)
+	m.mu.Unlock()
+	
+	count, err := m.alertService.ResolveNodeAlerts(nodeName)
+	if err != nil {
+		logger.Error("Failed to resolve alerts of removed node",
+			zap.String("node_name", nodeName),
+			zap.Error(err))
+		return
+	}
+	if count > 0 {
+		m.broadcastAlertEvent("alert_resolved", nodeName, "", "")
+	}
+}
+
 // handleNodeStatusChange 处理节点状态变化
 func (m *AlertMonitor) handleNodeStatusChange(nodeName string, isConnected bool) {
 	if isConnected {
@@ -178,7 +267,7 @@ func (m *AlertMonitor) handleNodeStatusChange(nodeName string, isConnected bool)
 				zap.String("node_name", nodeName),
 				zap.Error(err))
 		} else {
-			m.broadcastAlertEvent("alert_resolved", nodeName, "")
+			m.broadcastAlertEvent("alert_resolved", nodeName, "", models.AlertSeverityCritical)
 		}
 	} else {
 		// 节点离线，创建告警
@@ -189,7 +278,7 @@ func (m *AlertMonitor) handleNodeStatusChange(nodeName string, isConnected bool)
 				zap.String("node_name", nodeName),
 				zap.Error(err))
 		} else {
-			m.broadcastAlertEvent("alert_created", nodeName, "")
+			m.broadcastAlertEvent("alert_created", nodeName, "", models.AlertSeverityCritical)
 		}
 	}
 }
@@ -218,7 +307,7 @@ func (m *AlertMonitor) handleProcessStatusChange(nodeName, processName string, s
 				zap.String("process_name", processName),
 				zap.Error(err))
 		} else {
-			m.broadcastAlertEvent("alert_created", nodeName, processName)
+			m.broadcastAlertEvent("alert_created", nodeName, processName, models.AlertSeverityHigh)
 		}
 	} else if state == 20 {
 		// 进程运行中，解决告警
@@ -231,7 +320,7 @@ func (m *AlertMonitor) handleProcessStatusChange(nodeName, processName string, s
 				zap.String("process_name", processName),
 				zap.Error(err))
 		} else {
-			m.broadcastAlertEvent("alert_resolved", nodeName, processName)
+			m.broadcastAlertEvent("alert_resolved", nodeName, processName, models.AlertSeverityHigh)
 		}
 	}
 }
@@ -280,6 +369,7 @@ func (s *AlertService) CreateNodeOfflineAlert(nodeName string) error {
 	logger.Info("Node offline alert created",
 		zap.String("node_name", nodeName),
 		zap.Uint("alert_id", alert.ID))
+	s.notify(alert)
 	return nil
 }
 
@@ -365,6 +455,7 @@ func (s *AlertService) CreateProcessStoppedAlert(nodeName, processName string) e
 		zap.String("node_name", nodeName),
 		zap.String("process_name", processName),
 		zap.Uint("alert_id", alert.ID))
+	s.notify(alert)
 	return nil
 }
 
@@ -393,6 +484,30 @@ func (s *AlertService) ResolveProcessStoppedAlert(nodeName, processName string)
 	return nil
 }
 
+// ResolveNodeAlerts 解决节点的所有活跃和已确认告警（节点被删除时调用），返回解决数量
+func (s *AlertService) ResolveNodeAlerts(nodeName string) (int64, error) {
+	now := time.Now()
+	result := s.db.Model(&models.Alert{}).
+		Where("node_name = ? AND status IN (?, ?)",
+			nodeName, models.AlertStatusActive, models.AlertStatusAcknowledged).
+		Updates(map[string]interface{}{
+			"status":      models.AlertStatusResolved,
+			"end_time":    now,
+			"resolved_at": now,
+		})
+	
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	
+	if result.RowsAffected > 0 {
+		logger.Info("Alerts of removed node resolved",
+			zap.String("node_name", nodeName),
+			zap.Int64("count", result.RowsAffected))
+	}
+	return result.RowsAffected, nil
+}
+
 // GetActiveAlerts 获取所有活跃和已确认的告警
 func (s *AlertService) GetActiveAlerts() ([]models.Alert, error) {
 	var alerts []models.Alert
@@ -405,16 +520,18 @@ func (s *AlertService) GetActiveAlerts() ([]models.Alert, error) {
 	return alerts, err
 }
 
-// broadcastAlertEvent 广播告警事件到 WebSocket 客户端
-func (m *AlertMonitor) broadcastAlertEvent(eventType string, nodeName string, processName string) {
+// broadcastAlertEvent 广播告警事件到订阅了 alerts、对应级别或节点主题的客户端，severity 为空时只发到 alerts 和节点主题
+func (m *AlertMonitor) broadcastAlertEvent(eventType string, nodeName string, processName string, severity string) {
 	if m.hub == nil {
 		return
 	}
 	
 	event := map[string]interface{}{
 		"type":         eventType,
+		"topics":       alertTopics(severity, nodeName),
 		"node_name":    nodeName,
 		"process_name": processName,
+		"severity":     severity,
 		"timestamp":    time.Now().Format(time.RFC3339),
 	}
 	
@@ -431,3 +548,15 @@ func (m *AlertMonitor) broadcastAlertEvent(eventType string, nodeName string, pr
 		zap.String("event_type", eventType),
 		zap.String("node_name", nodeName))
 }
+
+// alertTopics 告警事件所属的 WebSocket 主题
+func alertTopics(severity, nodeName string) []string {
+	topics := []string{"alerts"}
+	if severity != "" {
+		topics = append(topics, "alerts:severity="+severity)
+	}
+	if nodeName != "" {
+		topics = append(topics, "node:"+nodeName)
+	}
+	return topics
+}
@@ -0,0 +1,563 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	appErrors "superview/internal/errors"
+	"superview/internal/labels"
+	"superview/internal/logger"
+	"superview/internal/models"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// alertSubject 匹配静默和维护窗口时使用的告警属性，环境和标签取自告警所在节点
+type alertSubject struct {
+	alert       *models.Alert
+	environment string
+	labels      map[string]string
+}
+
+func (s *AlertService) alertSubject(alert *models.Alert) alertSubject {
+	subject := alertSubject{alert: alert, labels: map[string]string{}}
+	if alert.NodeName == "" {
+		return subject
+	}
+	var node models.Node
+	if err := s.db.Where("name = ?", alert.NodeName).Limit(1).Find(&node).Error; err == nil && node.ID != 0 {
+		subject.environment = node.Environment
+		subject.labels = node.GetLabels()
+	}
+	return subject
+}
+
+// matchAlert 告警是否满足匹配条件
+func matchAlert(m models.AlertMatcher, subject alertSubject) bool {
+	alert := subject.alert
+	if m.NodeName != "" && !globMatch(m.NodeName, alert.NodeName) {
+		return false
+	}
+	if m.ProcessName != "" {
+		if alert.ProcessName == nil || !matchProcessPattern(m.ProcessName, *alert.ProcessName) {
+			return false
+		}
+	}
+	if m.Environment != "" && m.Environment != subject.environment {
+		return false
+	}
+	if m.Severity != "" && m.Severity != alert.Severity {
+		return false
+	}
+	if m.Selector != "" {
+		selector, err := labels.Parse(m.Selector)
+		if err != nil || !selector.Matches(subject.labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchProcessPattern 告警中的进程名不带进程组，pattern 为 group:name 时只比较进程名部分
+func matchProcessPattern(pattern, processName string) bool {
+	if globMatch(pattern, processName) {
+		return true
+	}
+	if i := strings.LastIndex(pattern, ":"); i >= 0 {
+		return globMatch(pattern[i+1:], processName)
+	}
+	return false
+}
+
+func globMatch(pattern, value string) bool {
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+// validateAlertMatcher 检查匹配条件，至少需要一个条件
+func validateAlertMatcher(m models.AlertMatcher) error {
+	if m.IsEmpty() {
+		return appErrors.NewValidationError("matcher", "at least one of node_name, process_name, environment, selector or severity is required")
+	}
+	for field, pattern := range map[string]string{"node_name": m.NodeName, "process_name": m.ProcessName} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return appErrors.NewValidationError(field, fmt.Sprintf("invalid pattern %q", pattern))
+		}
+	}
+	if m.Severity != "" && !isAlertSeverity(m.Severity) {
+		return appErrors.NewValidationError("severity", "invalid severity "+m.Severity)
+	}
+	if _, err := labels.Parse(m.Selector); err != nil {
+		return appErrors.NewValidationError("selector", err.Error())
+	}
+	return nil
+}
+
+func isAlertSeverity(severity string) bool {
+	switch severity {
+	case models.AlertSeverityLow, models.AlertSeverityMedium, models.AlertSeverityHigh, models.AlertSeverityCritical:
+		return true
+	}
+	return false
+}
+
+// maintenanceWindowActive 维护窗口在 now 时是否生效：now 之前 DurationMinutes 分钟内有过一次触发
+func maintenanceWindowActive(window *models.AlertMaintenanceWindow, now time.Time) bool {
+	if !window.Enabled || window.DurationMinutes <= 0 {
+		return false
+	}
+	schedule, err := cron.ParseStandard(window.Schedule)
+	if err != nil {
+		return false
+	}
+	first := schedule.Next(now.Add(-time.Duration(window.DurationMinutes) * time.Minute))
+	return !first.IsZero() && !first.After(now)
+}
+
+// SuppressionReason 检查告警是否被静默、维护窗口或抑制规则拦截，
+// 返回 silence:ID、maintenance:ID 或 inhibit:ID，未被拦截时返回空字符串
+func (s *AlertService) SuppressionReason(alert *models.Alert, now time.Time) (string, error) {
+	subject := s.alertSubject(alert)
+
+	var silences []models.AlertSilence
+	if err := s.db.Where("starts_at <= ? AND ends_at > ?", now, now).Order("id").Find(&silences).Error; err != nil {
+		return "", err
+	}
+	for i := range silences {
+		if matchAlert(silences[i].AlertMatcher, subject) {
+			return fmt.Sprintf("silence:%d", silences[i].ID), nil
+		}
+	}
+
+	var windows []models.AlertMaintenanceWindow
+	if err := s.db.Where("enabled = ?", true).Order("id").Find(&windows).Error; err != nil {
+		return "", err
+	}
+	for i := range windows {
+		if maintenanceWindowActive(&windows[i], now) && matchAlert(windows[i].AlertMatcher, subject) {
+			return fmt.Sprintf("maintenance:%d", windows[i].ID), nil
+		}
+	}
+
+	var rules []models.AlertInhibitRule
+	if err := s.db.Where("enabled = ?", true).Order("id").Find(&rules).Error; err != nil {
+		return "", err
+	}
+	for i := range rules {
+		inhibited, err := s.inhibitedBy(&rules[i], alert)
+		if err != nil {
+			return "", err
+		}
+		if inhibited {
+			return fmt.Sprintf("inhibit:%d", rules[i].ID), nil
+		}
+	}
+	return "", nil
+}
+
+// inhibitedBy 告警是否是抑制规则的目标，且存在 Equal 字段相同的未解决源告警
+func (s *AlertService) inhibitedBy(rule *models.AlertInhibitRule, alert *models.Alert) (bool, error) {
+	if rule.TargetRuleID != nil && *rule.TargetRuleID != alert.RuleID {
+		return false, nil
+	}
+	if rule.TargetSeverity != "" && rule.TargetSeverity != alert.Severity {
+		return false, nil
+	}
+
+	query := s.db.Model(&models.Alert{}).Where("id <> ? AND status IN (?, ?)",
+		alert.ID, models.AlertStatusActive, models.AlertStatusAcknowledged)
+	if rule.SourceRuleID != nil {
+		query = query.Where("rule_id = ?", *rule.SourceRuleID)
+	}
+	if rule.SourceSeverity != "" {
+		query = query.Where("severity = ?", rule.SourceSeverity)
+	}
+	for _, field := range inhibitEqualFields(rule.Equal) {
+		switch field {
+		case "node":
+			query = query.Where("node_name = ?", alert.NodeName)
+		case "process":
+			if alert.ProcessName == nil {
+				query = query.Where("process_name IS NULL")
+			} else {
+				query = query.Where("process_name = ?", *alert.ProcessName)
+			}
+		}
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func inhibitEqualFields(equal string) []string {
+	var fields []string
+	for _, field := range strings.Split(equal, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// notify 新告警发送通知前检查静默、维护窗口和抑制规则，被拦截时记录原因且不发送通知；
+// 未被拦截的告警加入事件，由 DispatchIncidents 发送通知。
+// 内置的节点离线（规则 1）、进程停止（规则 2）和进程抖动告警也走这里，否则维护期间的离线告警无从静默，
+// 以离线告警抑制进程停止告警也没有意义；这些规则默认没有通知渠道，分配渠道后才会实际发送
+func (s *AlertService) notify(alert *models.Alert) {
+	now := time.Now()
+	reason, err := s.SuppressionReason(alert, now)
+	if err != nil {
+		logger.Error("Failed to check alert suppression", zap.Uint("alert_id", alert.ID), zap.Error(err))
+	}
+	if reason != "" {
+		alert.SuppressedBy = reason
+		if err := s.db.Model(alert).Update("suppressed_by", reason).Error; err != nil {
+			logger.Error("Failed to record alert suppression", zap.Uint("alert_id", alert.ID), zap.Error(err))
+		}
+		logger.Info("Alert notification suppressed",
+			zap.Uint("alert_id", alert.ID),
+			zap.String("node_name", alert.NodeName),
+			zap.String("suppressed_by", reason))
+		return
+	}
+	s.deliver(alert, now)
+}
+
+// deliver 通知按事件分组发送，加入事件失败时直接发送
+func (s *AlertService) deliver(alert *models.Alert, now time.Time) {
+	if err := s.addToIncident(alert, now); err != nil {
+		logger.Error("Failed to group alert into incident", zap.Uint("alert_id", alert.ID), zap.Error(err))
+		if err := s.sendAlertNotifications(alert); err != nil {
+			logger.Error("Failed to send alert notifications", zap.Uint("alert_id", alert.ID), zap.Error(err))
+		}
+	}
+}
+
+// ReleaseSuppressedAlerts 重新检查仍活跃的被拦截告警：静默或维护窗口结束、抑制源告警解决后，
+// 清除拦截原因并按正常流程通知；仍被拦截的告警更新为当前原因
+func (s *AlertService) ReleaseSuppressedAlerts(now time.Time) error {
+	var alerts []models.Alert
+	if err := s.db.Where("status = ? AND suppressed_by <> ''", models.AlertStatusActive).
+		Order("id").Find(&alerts).Error; err != nil {
+		return appErrors.NewDatabaseError("list suppressed alerts", err)
+	}
+
+	for i := range alerts {
+		alert := &alerts[i]
+		reason, err := s.SuppressionReason(alert, now)
+		if err != nil {
+			return err
+		}
+		if reason == alert.SuppressedBy {
+			continue
+		}
+		if err := s.db.Model(alert).Update("suppressed_by", reason).Error; err != nil {
+			return appErrors.NewDatabaseError("update alert suppression", err)
+		}
+		if reason != "" {
+			continue
+		}
+		logger.Info("Alert suppression ended, sending notification",
+			zap.Uint("alert_id", alert.ID),
+			zap.String("node_name", alert.NodeName))
+		s.deliver(alert, now)
+	}
+	return nil
+}
+
+// ListSilences 列出静默，status 为 pending、active 或 expired 时只返回该状态的静默
+func (s *AlertService) ListSilences(status string) ([]models.AlertSilence, error) {
+	now := time.Now()
+	query := s.db.Order("starts_at DESC")
+	switch status {
+	case "":
+	case models.SilenceStatusPending:
+		query = query.Where("starts_at > ?", now)
+	case models.SilenceStatusActive:
+		query = query.Where("starts_at <= ? AND ends_at > ?", now, now)
+	case models.SilenceStatusExpired:
+		query = query.Where("ends_at <= ?", now)
+	default:
+		return nil, appErrors.NewValidationError("status", "status must be pending, active or expired")
+	}
+	var silences []models.AlertSilence
+	if err := query.Find(&silences).Error; err != nil {
+		return nil, appErrors.NewDatabaseError("list silences", err)
+	}
+	for i := range silences {
+		silences[i].Status = silences[i].StatusAt(now)
+	}
+	return silences, nil
+}
+
+// GetSilence 获取静默
+func (s *AlertService) GetSilence(id uint) (*models.AlertSilence, error) {
+	var silence models.AlertSilence
+	if err := s.db.First(&silence, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.NewNotFoundError("silence", fmt.Sprintf("%d", id))
+		}
+		return nil, appErrors.NewDatabaseError("get silence", err)
+	}
+	silence.Status = silence.StatusAt(time.Now())
+	return &silence, nil
+}
+
+func validateSilence(silence *models.AlertSilence) error {
+	if err := validateAlertMatcher(silence.AlertMatcher); err != nil {
+		return err
+	}
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = time.Now()
+	}
+	if !silence.EndsAt.After(silence.StartsAt) {
+		return appErrors.NewValidationError("ends_at", "ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// CreateSilence 创建静默，StartsAt 为空时立即生效
+func (s *AlertService) CreateSilence(silence *models.AlertSilence) error {
+	if err := validateSilence(silence); err != nil {
+		return err
+	}
+	if err := s.db.Create(silence).Error; err != nil {
+		return appErrors.NewDatabaseError("create silence", err)
+	}
+	silence.Status = silence.StatusAt(time.Now())
+	return nil
+}
+
+// UpdateSilence 更新静默的匹配条件、时间和备注
+func (s *AlertService) UpdateSilence(id uint, update *models.AlertSilence) (*models.AlertSilence, error) {
+	silence, err := s.GetSilence(id)
+	if err != nil {
+		return nil, err
+	}
+	silence.AlertMatcher = update.AlertMatcher
+	silence.Comment = update.Comment
+	silence.StartsAt = update.StartsAt
+	silence.EndsAt = update.EndsAt
+	if err := validateSilence(silence); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(silence).Error; err != nil {
+		return nil, appErrors.NewDatabaseError("update silence", err)
+	}
+	silence.Status = silence.StatusAt(time.Now())
+	return silence, nil
+}
+
+// DeleteSilence 删除静默
+func (s *AlertService) DeleteSilence(id uint) error {
+	if _, err := s.GetSilence(id); err != nil {
+		return err
+	}
+	if err := s.db.Delete(&models.AlertSilence{}, id).Error; err != nil {
+		return appErrors.NewDatabaseError("delete silence", err)
+	}
+	return nil
+}
+
+// ListMaintenanceWindows 列出维护窗口
+func (s *AlertService) ListMaintenanceWindows() ([]models.AlertMaintenanceWindow, error) {
+	var windows []models.AlertMaintenanceWindow
+	if err := s.db.Order("name").Find(&windows).Error; err != nil {
+		return nil, appErrors.NewDatabaseError("list maintenance windows", err)
+	}
+	now := time.Now()
+	for i := range windows {
+		windows[i].Active = maintenanceWindowActive(&windows[i], now)
+	}
+	return windows, nil
+}
+
+// GetMaintenanceWindow 获取维护窗口
+func (s *AlertService) GetMaintenanceWindow(id uint) (*models.AlertMaintenanceWindow, error) {
+	var window models.AlertMaintenanceWindow
+	if err := s.db.First(&window, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.NewNotFoundError("maintenance window", fmt.Sprintf("%d", id))
+		}
+		return nil, appErrors.NewDatabaseError("get maintenance window", err)
+	}
+	window.Active = maintenanceWindowActive(&window, time.Now())
+	return &window, nil
+}
+
+func validateMaintenanceWindow(window *models.AlertMaintenanceWindow) error {
+	if strings.TrimSpace(window.Name) == "" {
+		return appErrors.NewValidationError("name", "name is required")
+	}
+	if _, err := cron.ParseStandard(window.Schedule); err != nil {
+		return appErrors.NewValidationError("schedule", "invalid cron expression: "+err.Error())
+	}
+	if window.DurationMinutes <= 0 {
+		return appErrors.NewValidationError("duration_minutes", "duration_minutes must be positive")
+	}
+	return validateAlertMatcher(window.AlertMatcher)
+}
+
+// CreateMaintenanceWindow 创建维护窗口
+func (s *AlertService) CreateMaintenanceWindow(window *models.AlertMaintenanceWindow) error {
+	if err := validateMaintenanceWindow(window); err != nil {
+		return err
+	}
+	if err := s.db.Create(window).Error; err != nil {
+		if isDuplicateError(err) {
+			return appErrors.NewConflictError("maintenance window", "maintenance window "+window.Name+" already exists")
+		}
+		return appErrors.NewDatabaseError("create maintenance window", err)
+	}
+	window.Active = maintenanceWindowActive(window, time.Now())
+	return nil
+}
+
+// UpdateMaintenanceWindow 更新维护窗口
+func (s *AlertService) UpdateMaintenanceWindow(id uint, update *models.AlertMaintenanceWindow) (*models.AlertMaintenanceWindow, error) {
+	window, err := s.GetMaintenanceWindow(id)
+	if err != nil {
+		return nil, err
+	}
+	window.Name = update.Name
+	window.AlertMatcher = update.AlertMatcher
+	window.Schedule = update.Schedule
+	window.DurationMinutes = update.DurationMinutes
+	window.Enabled = update.Enabled
+	window.Comment = update.Comment
+	if err := validateMaintenanceWindow(window); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(window).Error; err != nil {
+		if isDuplicateError(err) {
+			return nil, appErrors.NewConflictError("maintenance window", "maintenance window "+window.Name+" already exists")
+		}
+		return nil, appErrors.NewDatabaseError("update maintenance window", err)
+	}
+	window.Active = maintenanceWindowActive(window, time.Now())
+	return window, nil
+}
+
+// DeleteMaintenanceWindow 删除维护窗口
+func (s *AlertService) DeleteMaintenanceWindow(id uint) error {
+	if _, err := s.GetMaintenanceWindow(id); err != nil {
+		return err
+	}
+	if err := s.db.Delete(&models.AlertMaintenanceWindow{}, id).Error; err != nil {
+		return appErrors.NewDatabaseError("delete maintenance window", err)
+	}
+	return nil
+}
+
+// ListInhibitRules 列出抑制规则
+func (s *AlertService) ListInhibitRules() ([]models.AlertInhibitRule, error) {
+	var rules []models.AlertInhibitRule
+	if err := s.db.Order("name").Find(&rules).Error; err != nil {
+		return nil, appErrors.NewDatabaseError("list inhibit rules", err)
+	}
+	return rules, nil
+}
+
+// GetInhibitRule 获取抑制规则
+func (s *AlertService) GetInhibitRule(id uint) (*models.AlertInhibitRule, error) {
+	var rule models.AlertInhibitRule
+	if err := s.db.First(&rule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.NewNotFoundError("inhibit rule", fmt.Sprintf("%d", id))
+		}
+		return nil, appErrors.NewDatabaseError("get inhibit rule", err)
+	}
+	return &rule, nil
+}
+
+func (s *AlertService) validateInhibitRule(rule *models.AlertInhibitRule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return appErrors.NewValidationError("name", "name is required")
+	}
+	if rule.SourceRuleID == nil && rule.SourceSeverity == "" {
+		return appErrors.NewValidationError("source", "source_rule_id or source_severity is required")
+	}
+	if rule.TargetRuleID == nil && rule.TargetSeverity == "" {
+		return appErrors.NewValidationError("target", "target_rule_id or target_severity is required")
+	}
+	for field, severity := range map[string]string{"source_severity": rule.SourceSeverity, "target_severity": rule.TargetSeverity} {
+		if severity != "" && !isAlertSeverity(severity) {
+			return appErrors.NewValidationError(field, "invalid severity "+severity)
+		}
+	}
+	for field, ruleID := range map[string]*uint{"source_rule_id": rule.SourceRuleID, "target_rule_id": rule.TargetRuleID} {
+		if ruleID == nil {
+			continue
+		}
+		var count int64
+		if err := s.db.Model(&models.AlertRule{}).Where("id = ?", *ruleID).Count(&count).Error; err != nil {
+			return appErrors.NewDatabaseError("check alert rule", err)
+		}
+		if count == 0 {
+			return appErrors.NewValidationError(field, fmt.Sprintf("alert rule %d not found", *ruleID))
+		}
+	}
+	if rule.Equal == "" {
+		rule.Equal = "node"
+	}
+	for _, field := range inhibitEqualFields(rule.Equal) {
+		if field != "node" && field != "process" {
+			return appErrors.NewValidationError("equal", "equal may only contain node and process")
+		}
+	}
+	return nil
+}
+
+// CreateInhibitRule 创建抑制规则
+func (s *AlertService) CreateInhibitRule(rule *models.AlertInhibitRule) error {
+	if err := s.validateInhibitRule(rule); err != nil {
+		return err
+	}
+	if err := s.db.Create(rule).Error; err != nil {
+		if isDuplicateError(err) {
+			return appErrors.NewConflictError("inhibit rule", "inhibit rule "+rule.Name+" already exists")
+		}
+		return appErrors.NewDatabaseError("create inhibit rule", err)
+	}
+	return nil
+}
+
+// UpdateInhibitRule 更新抑制规则
+func (s *AlertService) UpdateInhibitRule(id uint, update *models.AlertInhibitRule) (*models.AlertInhibitRule, error) {
+	rule, err := s.GetInhibitRule(id)
+	if err != nil {
+		return nil, err
+	}
+	rule.Name = update.Name
+	rule.SourceRuleID = update.SourceRuleID
+	rule.SourceSeverity = update.SourceSeverity
+	rule.TargetRuleID = update.TargetRuleID
+	rule.TargetSeverity = update.TargetSeverity
+	rule.Equal = update.Equal
+	rule.Enabled = update.Enabled
+	if err := s.validateInhibitRule(rule); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(rule).Error; err != nil {
+		if isDuplicateError(err) {
+			return nil, appErrors.NewConflictError("inhibit rule", "inhibit rule "+rule.Name+" already exists")
+		}
+		return nil, appErrors.NewDatabaseError("update inhibit rule", err)
+	}
+	return rule, nil
+}
+
+// DeleteInhibitRule 删除抑制规则
+func (s *AlertService) DeleteInhibitRule(id uint) error {
+	if _, err := s.GetInhibitRule(id); err != nil {
+		return err
+	}
+	if err := s.db.Delete(&models.AlertInhibitRule{}, id).Error; err != nil {
+		return appErrors.NewDatabaseError("delete inhibit rule", err)
+	}
+	return nil
+}
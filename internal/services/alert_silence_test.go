@@ -0,0 +1,164 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"superview/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newAlertSilenceTestService(t *testing.T) (*AlertService, *gorm.DB) {
+	db := newAlertEvaluatorTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.AlertSilence{}, &models.AlertMaintenanceWindow{}, &models.AlertInhibitRule{}))
+	return NewAlertService(db), db
+}
+
+func createTestAlert(t *testing.T, db *gorm.DB, ruleID uint, node, process, severity string) *models.Alert {
+	alert := &models.Alert{RuleID: ruleID, NodeName: node, Message: "test", Severity: severity,
+		Status: models.AlertStatusActive, StartTime: time.Now()}
+	if process != "" {
+		alert.ProcessName = &process
+	}
+	require.NoError(t, db.Create(alert).Error)
+	return alert
+}
+
+func TestSuppressionBySilence(t *testing.T) {
+	service, db := newAlertSilenceTestService(t)
+	now := time.Now()
+	silence := &models.AlertSilence{
+		AlertMatcher: models.AlertMatcher{NodeName: "web-*", ProcessName: "jobs:api", Selector: "role=web"},
+		StartsAt:     now.Add(-time.Minute), EndsAt: now.Add(time.Hour), CreatedBy: "admin",
+	}
+	require.NoError(t, service.CreateSilence(silence))
+	assert.Equal(t, models.SilenceStatusActive, silence.Status)
+
+	reason, err := service.SuppressionReason(createTestAlert(t, db, 2, "web-1", "api", models.AlertSeverityHigh), now)
+	require.NoError(t, err)
+	assert.Equal(t, "silence:1", reason)
+
+	// 进程不匹配、节点标签不匹配、静默过期时都不拦截
+	reason, err = service.SuppressionReason(createTestAlert(t, db, 2, "web-1", "worker", models.AlertSeverityHigh), now)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	reason, err = service.SuppressionReason(createTestAlert(t, db, 2, "db-1", "api", models.AlertSeverityHigh), now)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	reason, err = service.SuppressionReason(createTestAlert(t, db, 2, "web-1", "api", models.AlertSeverityHigh), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+
+	active, err := service.ListSilences(models.SilenceStatusActive)
+	require.NoError(t, err)
+	assert.Len(t, active, 1)
+
+	assert.Error(t, service.CreateSilence(&models.AlertSilence{StartsAt: now, EndsAt: now.Add(time.Hour)}))
+	assert.Error(t, service.CreateSilence(&models.AlertSilence{AlertMatcher: models.AlertMatcher{NodeName: "web-1"},
+		StartsAt: now, EndsAt: now.Add(-time.Hour)}))
+	assert.Error(t, service.CreateSilence(&models.AlertSilence{AlertMatcher: models.AlertMatcher{Selector: "role in web"},
+		StartsAt: now, EndsAt: now.Add(time.Hour)}))
+}
+
+func TestMaintenanceWindowActive(t *testing.T) {
+	window := &models.AlertMaintenanceWindow{Schedule: "CRON_TZ=UTC 0 2 * * 0", DurationMinutes: 120, Enabled: true}
+	sunday := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.False(t, maintenanceWindowActive(window, sunday.Add(time.Hour+59*time.Minute)))
+	assert.True(t, maintenanceWindowActive(window, sunday.Add(2*time.Hour)))
+	assert.True(t, maintenanceWindowActive(window, sunday.Add(3*time.Hour+59*time.Minute)))
+	assert.False(t, maintenanceWindowActive(window, sunday.Add(4*time.Hour)))
+	assert.False(t, maintenanceWindowActive(window, sunday.Add(24*time.Hour+3*time.Hour)))
+
+	window.Enabled = false
+	assert.False(t, maintenanceWindowActive(window, sunday.Add(3*time.Hour)))
+}
+
+func TestSuppressionByMaintenanceWindow(t *testing.T) {
+	service, db := newAlertSilenceTestService(t)
+	require.NoError(t, service.CreateMaintenanceWindow(&models.AlertMaintenanceWindow{Name: "always",
+		AlertMatcher: models.AlertMatcher{Environment: "prod", Severity: models.AlertSeverityLow},
+		Schedule:     "* * * * *", DurationMinutes: 5, Enabled: true, CreatedBy: "admin"}))
+
+	reason, err := service.SuppressionReason(createTestAlert(t, db, 2, "db-1", "", models.AlertSeverityLow), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "maintenance:1", reason)
+	reason, err = service.SuppressionReason(createTestAlert(t, db, 2, "db-1", "", models.AlertSeverityHigh), time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+
+	assert.Error(t, service.CreateMaintenanceWindow(&models.AlertMaintenanceWindow{Name: "bad",
+		AlertMatcher: models.AlertMatcher{NodeName: "db-1"}, Schedule: "every day", DurationMinutes: 5}))
+}
+
+func TestSuppressionByInhibitRule(t *testing.T) {
+	service, db := newAlertSilenceTestService(t)
+	source, target := uint(1), uint(2)
+	rule := &models.AlertInhibitRule{Name: "node offline", SourceRuleID: &source, TargetRuleID: &target, Enabled: true, CreatedBy: "admin"}
+	require.NoError(t, service.CreateInhibitRule(rule))
+	assert.Equal(t, "node", rule.Equal)
+
+	stopped := createTestAlert(t, db, 2, "web-1", "api", models.AlertSeverityHigh)
+	reason, err := service.SuppressionReason(stopped, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+
+	offline := createTestAlert(t, db, 1, "web-1", "", models.AlertSeverityCritical)
+	reason, err = service.SuppressionReason(stopped, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "inhibit:1", reason)
+
+	// 其他节点不受影响，源告警解决后不再抑制
+	reason, err = service.SuppressionReason(createTestAlert(t, db, 2, "db-1", "api", models.AlertSeverityHigh), time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	require.NoError(t, db.Model(offline).Update("status", models.AlertStatusResolved).Error)
+	reason, err = service.SuppressionReason(stopped, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+
+	// notify 记录拦截原因
+	createTestAlert(t, db, 1, "db-1", "", models.AlertSeverityCritical)
+	suppressed := createTestAlert(t, db, 2, "db-1", "worker", models.AlertSeverityHigh)
+	service.notify(suppressed)
+	var stored models.Alert
+	require.NoError(t, db.First(&stored, suppressed.ID).Error)
+	assert.Equal(t, "inhibit:1", stored.SuppressedBy)
+
+	missing := uint(99)
+	assert.Error(t, service.CreateInhibitRule(&models.AlertInhibitRule{Name: "bad", SourceRuleID: &missing, TargetRuleID: &target}))
+	assert.Error(t, service.CreateInhibitRule(&models.AlertInhibitRule{Name: "bad", SourceRuleID: &source, TargetRuleID: &target, Equal: "rule"}))
+}
+
+func TestReleaseSuppressedAlerts(t *testing.T) {
+	service, db := newAlertIncidentTestService(t)
+	now := time.Now()
+	require.NoError(t, service.CreateSilence(&models.AlertSilence{AlertMatcher: models.AlertMatcher{NodeName: "web-1"},
+		StartsAt: now.Add(-time.Minute), EndsAt: now.Add(time.Hour), CreatedBy: "admin"}))
+
+	silenced := createTestAlert(t, db, 2, "web-1", "api", models.AlertSeverityHigh)
+	service.notify(silenced)
+	assert.Equal(t, "silence:1", silenced.SuppressedBy)
+	assert.Nil(t, silenced.IncidentID)
+
+	// 静默期间保持拦截
+	require.NoError(t, service.ReleaseSuppressedAlerts(now.Add(30*time.Minute)))
+	var stored models.Alert
+	require.NoError(t, db.First(&stored, silenced.ID).Error)
+	assert.Equal(t, "silence:1", stored.SuppressedBy)
+	assert.Nil(t, stored.IncidentID)
+
+	// 静默结束后仍活跃的告警加入事件，已解决的告警不再通知
+	resolved := createTestAlert(t, db, 2, "web-1", "worker", models.AlertSeverityHigh)
+	service.notify(resolved)
+	require.NoError(t, service.ResolveAlert(resolved.ID, "admin"))
+	require.NoError(t, service.ReleaseSuppressedAlerts(now.Add(2*time.Hour)))
+	var released, stillResolved models.Alert
+	require.NoError(t, db.First(&released, silenced.ID).Error)
+	assert.Empty(t, released.SuppressedBy)
+	require.NotNil(t, released.IncidentID)
+	require.NoError(t, db.First(&stillResolved, resolved.ID).Error)
+	assert.Nil(t, stillResolved.IncidentID)
+}
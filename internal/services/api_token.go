@@ -0,0 +1,231 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"superview/internal/errors"
+	"superview/internal/logger"
+	"superview/internal/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// apiTokenRandomBytes 令牌随机部分的字节数（十六进制编码后为 64 个字符）
+const apiTokenRandomBytes = 32
+
+// apiTokenDisplayPrefixLen 对外展示的令牌前缀长度（含 sv_）
+const apiTokenDisplayPrefixLen = 10
+
+// apiTokenTouchInterval 最后使用时间的最小写入间隔，避免每个请求都写库
+const apiTokenTouchInterval = time.Minute
+
+// APITokenScopes 可授予 API 令牌的权限列表
+var APITokenScopes = []string{
+	models.PermissionSystemManage,
+	models.PermissionSystemConfig,
+	models.PermissionUserRead,
+	models.PermissionUserWrite,
+	models.PermissionUserDelete,
+	models.PermissionNodeRead,
+	models.PermissionNodeWrite,
+	models.PermissionNodeDelete,
+	models.PermissionProcessRead,
+	models.PermissionProcessWrite,
+	models.PermissionProcessExecute,
+	models.PermissionProcessDelete,
+	models.PermissionLogRead,
+	models.PermissionLogWrite,
+	models.PermissionLogDelete,
+	models.PermissionConfigRead,
+	models.PermissionConfigWrite,
+	models.PermissionConfigDelete,
+}
+
+// CreateAPITokenRequest 创建 API 令牌请求
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required,min=1,max=100"`
+	Permissions   []string `json:"permissions"`
+	Nodes         []string `json:"nodes"`
+	Environments  []string `json:"environments"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 表示永不过期
+}
+
+// APITokenService API 令牌服务
+type APITokenService struct {
+	db *gorm.DB
+}
+
+// NewAPITokenService 创建 API 令牌服务
+func NewAPITokenService(db *gorm.DB) *APITokenService {
+	return &APITokenService{db: db}
+}
+
+// HashAPIToken 计算令牌明文的 SHA-256 摘要
+// 令牌本身是高熵随机值，使用确定性摘要即可支持按摘要查找
+func HashAPIToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIToken 判断字符串是否为个人 API 令牌格式
+func IsAPIToken(value string) bool {
+	return strings.HasPrefix(value, models.APITokenPrefix)
+}
+
+// generateAPIToken 生成新的令牌明文
+func generateAPIToken() (string, error) {
+	buf := make([]byte, apiTokenRandomBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return models.APITokenPrefix + hex.EncodeToString(buf), nil
+}
+
+// CreateToken 为用户创建新的 API 令牌，返回令牌明文（仅此一次）
+func (s *APITokenService) CreateToken(userID string, req *CreateAPITokenRequest) (string, *models.APIToken, error) {
+	var user models.User
+	if err := s.db.Preload("Roles.Permissions").Where("id = ?", userID).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", nil, errors.NewNotFoundError("user", userID)
+		}
+		return "", nil, errors.NewDatabaseError("get user", err)
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		return "", nil, errors.NewValidationError("name", "name is required")
+	}
+	if req.ExpiresInDays < 0 {
+		return "", nil, errors.NewValidationError("expires_in_days", "expires_in_days must not be negative")
+	}
+
+	// 令牌权限必须是用户自身权限的子集
+	for _, permission := range req.Permissions {
+		if !isKnownAPITokenScope(permission) {
+			return "", nil, errors.NewValidationError("permissions", fmt.Sprintf("unknown permission: %s", permission))
+		}
+		if !user.IsSuperAdmin() && !user.HasPermission(permission) {
+			return "", nil, errors.NewForbiddenError(fmt.Sprintf("cannot grant permission %s that the user does not hold", permission))
+		}
+	}
+
+	plain, err := generateAPIToken()
+	if err != nil {
+		return "", nil, errors.NewInternalError("failed to generate token", err)
+	}
+
+	token := &models.APIToken{
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		TokenHash:   HashAPIToken(plain),
+		TokenPrefix: plain[:apiTokenDisplayPrefixLen],
+	}
+	token.SetPermissions(req.Permissions)
+	token.SetNodes(req.Nodes)
+	token.SetEnvironments(req.Environments)
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.db.Create(token).Error; err != nil {
+		return "", nil, errors.NewDatabaseError("create api token", err)
+	}
+
+	logger.Info("API token created",
+		zap.String("user_id", userID),
+		zap.Uint("token_id", token.ID),
+		zap.String("name", token.Name))
+	return plain, token, nil
+}
+
+// ListTokens 获取用户的所有 API 令牌
+func (s *APITokenService) ListTokens(userID string) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, errors.NewDatabaseError("list api tokens", err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken 吊销用户的 API 令牌
+func (s *APITokenService) RevokeToken(userID string, tokenID uint) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := s.db.Where("id = ? AND user_id = ?", tokenID, userID).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("api token", fmt.Sprintf("%d", tokenID))
+		}
+		return nil, errors.NewDatabaseError("get api token", err)
+	}
+
+	if token.RevokedAt == nil {
+		now := time.Now()
+		token.RevokedAt = &now
+		if err := s.db.Model(&token).Update("revoked_at", now).Error; err != nil {
+			return nil, errors.NewDatabaseError("revoke api token", err)
+		}
+	}
+	return &token, nil
+}
+
+// Authenticate 校验令牌明文，返回令牌及其所属用户
+func (s *APITokenService) Authenticate(plain, clientIP string) (*models.APIToken, *models.User, error) {
+	if !IsAPIToken(plain) {
+		return nil, nil, errors.NewUnauthorizedError("invalid api token")
+	}
+
+	var token models.APIToken
+	if err := s.db.Where("token_hash = ?", HashAPIToken(plain)).First(&token).Error; err != nil {
+		return nil, nil, errors.NewUnauthorizedError("invalid api token")
+	}
+	if token.IsRevoked() {
+		return nil, nil, errors.NewUnauthorizedError("api token has been revoked")
+	}
+	if token.IsExpired() {
+		return nil, nil, errors.NewUnauthorizedError("api token has expired")
+	}
+
+	var user models.User
+	if err := s.db.Where("id = ?", token.UserID).First(&user).Error; err != nil {
+		return nil, nil, errors.NewUnauthorizedError("api token owner not found")
+	}
+	if !user.IsActive {
+		return nil, nil, errors.NewUnauthorizedError("user account is disabled")
+	}
+
+	s.touch(&token, clientIP)
+	return &token, &user, nil
+}
+
+// touch 记录令牌最后使用时间和来源 IP
+func (s *APITokenService) touch(token *models.APIToken, clientIP string) {
+	now := time.Now()
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < apiTokenTouchInterval && token.LastUsedIP == clientIP {
+		return
+	}
+	token.LastUsedAt = &now
+	token.LastUsedIP = clientIP
+	if err := s.db.Model(token).UpdateColumns(map[string]interface{}{
+		"last_used_at": now,
+		"last_used_ip": clientIP,
+	}).Error; err != nil {
+		logger.Warn("Failed to record api token usage",
+			zap.Uint("token_id", token.ID),
+			zap.Error(err))
+	}
+}
+
+// isKnownAPITokenScope 检查权限名是否可授予令牌
+func isKnownAPITokenScope(permission string) bool {
+	for _, scope := range APITokenScopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
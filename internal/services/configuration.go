@@ -8,6 +8,7 @@ import (
 
 	"superview/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ConfigurationService 配置管理服务
@@ -24,8 +25,8 @@ func NewConfigurationService(db *gorm.DB) *ConfigurationService {
 func (s *ConfigurationService) CreateConfiguration(config *models.Configuration) error {
 	// 检查配置键是否已存在
 	var existing models.Configuration
-	err := s.db.Where("key = ? AND scope = ? AND COALESCE(node_id, 0) = COALESCE(?, 0) AND COALESCE(user_id, 0) = COALESCE(?, 0)",
-		config.Key, config.Scope, config.NodeID, config.UserID).First(&existing).Error
+	err := s.db.Where(map[string]interface{}{"key": config.Key, "scope": config.Scope}).
+		Where("COALESCE(node_id, 0) = COALESCE(?, 0) AND COALESCE(user_id, 0) = COALESCE(?, 0)", config.NodeID, config.UserID).First(&existing).Error
 	if err == nil {
 		return fmt.Errorf("configuration key already exists in this scope")
 	}
@@ -63,7 +64,7 @@ func (s *ConfigurationService) GetConfigurations(page, pageSize int, filters map
 		case "is_secret":
 			query = query.Where("is_secret = ?", value)
 		case "search":
-			query = query.Where("key LIKE ? OR description LIKE ?", "%"+value.(string)+"%", "%"+value.(string)+"%")
+			query = query.Where("? LIKE ? OR description LIKE ?", clause.Column{Name: "key"}, "%"+value.(string)+"%", "%"+value.(string)+"%")
 		}
 	}
 
@@ -109,7 +110,7 @@ func (s *ConfigurationService) GetConfigurationByID(id uint, userID uint, showSe
 // GetConfigurationByKey 根据键获取配置
 func (s *ConfigurationService) GetConfigurationByKey(key string, scope string, nodeID *uint, userID *uint) (*models.Configuration, error) {
 	var config models.Configuration
-	query := s.db.Where("key = ? AND scope = ?", key, scope)
+	query := s.db.Where(map[string]interface{}{"key": key, "scope": scope})
 
 	if nodeID != nil {
 		query = query.Where("node_id = ?", *nodeID)
@@ -497,8 +498,8 @@ func (s *ConfigurationService) RestoreBackup(id uint, userID uint, options map[s
 
 			// 检查是否已存在，如果存在则更新，否则创建
 			var existing models.Configuration
-			existErr := tx.Where("key = ? AND scope = ? AND COALESCE(node_id, 0) = COALESCE(?, 0) AND COALESCE(user_id, 0) = COALESCE(?, 0)",
-				config.Key, config.Scope, config.NodeID, config.UserID).First(&existing).Error
+			existErr := tx.Where(map[string]interface{}{"key": config.Key, "scope": config.Scope}).
+				Where("COALESCE(node_id, 0) = COALESCE(?, 0) AND COALESCE(user_id, 0) = COALESCE(?, 0)", config.NodeID, config.UserID).First(&existing).Error
 			if existErr == nil {
 				// 更新现有配置
 				tx.Model(&existing).Updates(map[string]interface{}{
@@ -667,8 +668,8 @@ func (s *ConfigurationService) ImportConfigurations(data map[string]interface{},
 
 			// 检查是否已存在
 			var existing models.Configuration
-			existErr := tx.Where("key = ? AND scope = ? AND COALESCE(node_id, 0) = COALESCE(?, 0) AND COALESCE(user_id, 0) = COALESCE(?, 0)",
-				config.Key, config.Scope, config.NodeID, config.UserID).First(&existing).Error
+			existErr := tx.Where(map[string]interface{}{"key": config.Key, "scope": config.Scope}).
+				Where("COALESCE(node_id, 0) = COALESCE(?, 0) AND COALESCE(user_id, 0) = COALESCE(?, 0)", config.NodeID, config.UserID).First(&existing).Error
 			if existErr == nil {
 				if overwriteExisting {
 					// 更新现有配置
@@ -875,7 +876,7 @@ func (s *ConfigurationService) InitializeSystemConfigurations() error {
 	for _, config := range defaultConfigs {
 		// 检查配置是否已存在
 		var existing models.Configuration
-		err := s.db.Where("key = ? AND scope = ?", config.Key, config.Scope).First(&existing).Error
+		err := s.db.Where(map[string]interface{}{"key": config.Key, "scope": config.Scope}).First(&existing).Error
 		if err == gorm.ErrRecordNotFound {
 			// 配置不存在，创建新配置
 			s.db.Create(&config)
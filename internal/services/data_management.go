@@ -15,9 +15,14 @@ import (
 	"github.com/google/uuid"
 	"superview/internal/database"
 	"superview/internal/models"
+	"superview/internal/repository"
+	"superview/internal/secrets"
 	"gorm.io/gorm"
 )
 
+// redactedValue 导出和备份中替换敏感值的占位符
+const redactedValue = "********"
+
 // DataManagementService 数据管理服务
 type DataManagementService struct {
 	DB *gorm.DB
@@ -153,6 +158,7 @@ func (s *DataManagementService) exportConfigs(filePath, format string) (int, err
 	if err := s.DB.Find(&configs).Error; err != nil {
 		return 0, err
 	}
+	redactConfigurations(configs)
 
 	switch format {
 	case models.ExportFormatJSON:
@@ -204,6 +210,7 @@ func (s *DataManagementService) exportAll(filePath, format string) (int, error)
 	// 配置数据
 	var configs []models.Configuration
 	if err := s.DB.Find(&configs).Error; err == nil {
+		redactConfigurations(configs)
 		allData["configs"] = configs
 		totalRecords += len(configs)
 	}
@@ -221,6 +228,15 @@ func (s *DataManagementService) exportAll(filePath, format string) (int, error)
 	return totalRecords, s.exportToJSON(filePath, allData)
 }
 
+// redactConfigurations 隐藏敏感配置的值
+func redactConfigurations(configs []models.Configuration) {
+	for i := range configs {
+		if configs[i].IsSecret && configs[i].Value != "" {
+			configs[i].Value = redactedValue
+		}
+	}
+}
+
 // exportToJSON 导出为JSON格式
 func (s *DataManagementService) exportToJSON(filePath string, data interface{}) error {
 	file, err := os.Create(filePath)
@@ -499,8 +515,13 @@ func (s *DataManagementService) createFullBackup(backupFilePath string) error {
 	zipWriter := zip.NewWriter(zipFile)
 	defer zipWriter.Close()
 
-	// 备份数据库文件
-	if err := s.addFileToZip(zipWriter, "data/superview.db", "superview.db"); err != nil {
+	// 备份数据库快照（节点凭据只保留密文，密钥文件不在备份中）
+	snapshotPath := backupFilePath + ".db.tmp"
+	defer os.Remove(snapshotPath)
+	if err := s.snapshotDatabase(snapshotPath); err != nil {
+		return fmt.Errorf("failed to backup database: %v", err)
+	}
+	if err := s.addFileToZip(zipWriter, snapshotPath, "superview.db"); err != nil {
 		return fmt.Errorf("failed to backup database: %v", err)
 	}
 
@@ -527,6 +548,36 @@ func (s *DataManagementService) createFullBackup(backupFilePath string) error {
 	return nil
 }
 
+// snapshotDatabase 生成一致的 SQLite 数据库快照，并清除其中未加密的凭据（节点、定时发现和待审核节点）
+func (s *DataManagementService) snapshotDatabase(snapshotPath string) error {
+	if dialect := database.DialectOf(s.DB); dialect != database.DialectSQLite {
+		return fmt.Errorf("full backup only supports SQLite, use the native backup tools for %s", dialect)
+	}
+	os.Remove(snapshotPath)
+
+	// ATTACH 只对当前连接有效，所有语句在同一连接上执行
+	return s.DB.Connection(func(tx *gorm.DB) error {
+		if err := tx.Exec("VACUUM INTO ?", snapshotPath).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("ATTACH DATABASE ? AS backup_snapshot", snapshotPath).Error; err != nil {
+			return err
+		}
+		defer tx.Exec("DETACH DATABASE backup_snapshot")
+		for _, table := range repository.CredentialTables() {
+			// 尚未迁移的旧库中表可能不存在
+			if !tx.Migrator().HasTable(table) {
+				continue
+			}
+			if err := tx.Exec("UPDATE backup_snapshot."+table+" SET password = '' WHERE password <> '' AND password NOT LIKE ?",
+				secrets.EncryptedPrefix+"%").Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // createConfigBackup 创建配置备份
 func (s *DataManagementService) createConfigBackup(backupFilePath string) error {
 	// 导出配置数据为JSON
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"superview/internal/models"
+	"superview/internal/secrets"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestSnapshotDatabaseRedactsPlaintextCredentials(t *testing.T) {
+	dir := t.TempDir()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "superview.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Node{}))
+
+	raw, err := secrets.GenerateKey()
+	require.NoError(t, err)
+	keyring, err := secrets.NewKeyring(raw)
+	require.NoError(t, err)
+
+	// 一条历史明文，一条密文
+	models.SetCredentialCipher(nil)
+	require.NoError(t, db.Create(&models.Node{Name: "legacy", Host: "10.0.0.1", Port: 9001, Password: "plain"}).Error)
+	models.SetCredentialCipher(keyring)
+	defer models.SetCredentialCipher(nil)
+	require.NoError(t, db.Create(&models.Node{Name: "encrypted", Host: "10.0.0.2", Port: 9001, Password: "s3cret"}).Error)
+
+	service := &DataManagementService{DB: db}
+	snapshotPath := filepath.Join(dir, "snapshot.db")
+	require.NoError(t, service.snapshotDatabase(snapshotPath))
+
+	snapshot, err := gorm.Open(sqlite.Open(snapshotPath), &gorm.Config{})
+	require.NoError(t, err)
+	var rows []struct {
+		Name     string
+		Password string
+	}
+	require.NoError(t, snapshot.Table("nodes").Select("name", "password").Order("name").Find(&rows).Error)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "encrypted", rows[0].Name)
+	assert.True(t, secrets.IsEncrypted(rows[0].Password))
+	assert.Equal(t, "legacy", rows[1].Name)
+	assert.Equal(t, "", rows[1].Password)
+
+	// 源数据库不受影响
+	var legacy struct{ Password string }
+	require.NoError(t, db.Table("nodes").Select("password").Where("name = ?", "legacy").Take(&legacy).Error)
+	assert.Equal(t, "plain", legacy.Password)
+}
+
+func TestSnapshotDatabaseRedactsDiscoveryCredentials(t *testing.T) {
+	dir := t.TempDir()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "superview.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Node{}, &models.DiscoverySchedule{}, &models.DiscoveryCandidate{}))
+
+	models.SetCredentialCipher(nil)
+	require.NoError(t, db.Create(&models.DiscoverySchedule{
+		Name: "nightly", CronExpr: "0 2 * * *", CIDR: "10.0.0.0/24", Port: 9001,
+		Username: "admin", Password: "schedule-secret", CreatedBy: "admin",
+	}).Error)
+	require.NoError(t, db.Create(&models.DiscoveryCandidate{
+		TaskID: 1, IP: "10.0.0.5", Port: 9001, Username: "admin", Password: "candidate-secret",
+		LastSeenAt: time.Now(), Status: models.CandidateStatusPending,
+	}).Error)
+
+	service := &DataManagementService{DB: db}
+	snapshotPath := filepath.Join(dir, "snapshot.db")
+	require.NoError(t, service.snapshotDatabase(snapshotPath))
+
+	snapshot, err := gorm.Open(sqlite.Open(snapshotPath), &gorm.Config{})
+	require.NoError(t, err)
+	for _, table := range []string{"discovery_schedules", "discovery_candidates"} {
+		var row struct{ Password string }
+		require.NoError(t, snapshot.Table(table).Select("password").Take(&row).Error)
+		assert.Equal(t, "", row.Password, table)
+	}
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"superview/internal/errors"
+	"superview/internal/models"
+)
+
+// DependencyGraphNode 依赖图中的一个进程，ID 为 node:process
+type DependencyGraphNode struct {
+	ID          string `json:"id"`
+	Node        string `json:"node"`
+	NodeID      uint   `json:"node_id"`
+	Environment string `json:"environment"`
+	Process     string `json:"process"`
+}
+
+// DependencyGraphEdge 一条依赖：From 依赖 To
+type DependencyGraphEdge struct {
+	ID        uint   `json:"id"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Type      string `json:"type"`
+	Required  bool   `json:"required"`
+	Timeout   int    `json:"timeout"`
+	CrossNode bool   `json:"cross_node"`
+}
+
+// DependencyGraph 所有节点上的进程依赖
+type DependencyGraph struct {
+	Nodes []DependencyGraphNode `json:"nodes"`
+	Edges []DependencyGraphEdge `json:"edges"`
+}
+
+// ParseProcessRef 解析 node:process 形式的进程引用，进程名本身可以是 group:name
+func ParseProcessRef(ref string) (string, string, error) {
+	node, process, ok := strings.Cut(strings.TrimSpace(ref), ":")
+	if !ok || node == "" || process == "" {
+		return "", "", errors.NewValidationError("process", fmt.Sprintf("invalid process reference %q, expected node:process", ref))
+	}
+	return node, normalizeProcessName(process), nil
+}
+
+// normalizeProcessName group:name 中组名与进程名相同时只保留进程名，与 supervisor 的命名一致
+func normalizeProcessName(name string) string {
+	if group, process, ok := strings.Cut(name, ":"); ok && group == process {
+		return process
+	}
+	return name
+}
+
+// ResolveProcessRef 把 node:process 解析为节点 ID 和进程名
+func (s *ProcessEnhancedService) ResolveProcessRef(ref string) (uint, string, error) {
+	nodeName, process, err := ParseProcessRef(ref)
+	if err != nil {
+		return 0, "", err
+	}
+	var node models.Node
+	if err := s.db.Select("id").Where("name = ?", nodeName).First(&node).Error; err != nil {
+		return 0, "", errors.NewNotFoundError("node", nodeName)
+	}
+	return node.ID, process, nil
+}
+
+// dependencyNodes 节点 ID 到节点的映射（包含已删除的节点，与编排器一致）
+func (s *ProcessEnhancedService) dependencyNodes() (map[uint]models.Node, error) {
+	var nodes []models.Node
+	if err := s.db.Unscoped().Select("id", "name", "environment").Find(&nodes).Error; err != nil {
+		return nil, errors.NewDatabaseError("list nodes", err)
+	}
+	result := make(map[uint]models.Node, len(nodes))
+	for _, node := range nodes {
+		result[node.ID] = node
+	}
+	return result, nil
+}
+
+// validateDependency 校验依赖类型并检查加入后全局依赖图是否出现环
+func (s *ProcessEnhancedService) validateDependency(dependency *models.ProcessDependency) error {
+	switch dependency.DependencyType {
+	case models.DependencyTypeStartAfter, models.DependencyTypeStopBefore, models.DependencyTypeRestartWith:
+	default:
+		return errors.NewValidationError("dependency_type", "must be one of start_after, stop_before, restart_with")
+	}
+	if dependency.NodeID == dependency.DependentNodeID && dependency.ProcessName == dependency.DependentProcess {
+		return errors.NewValidationError("dependent_process", "a process cannot depend on itself")
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.ProcessDependency{}).
+		Where("process_name = ? AND node_id = ? AND dependent_process = ? AND dependent_node_id = ? AND dependency_type = ?",
+			dependency.ProcessName, dependency.NodeID, dependency.DependentProcess, dependency.DependentNodeID, dependency.DependencyType).
+		Count(&existing).Error; err != nil {
+		return errors.NewDatabaseError("check process dependency", err)
+	}
+	if existing > 0 {
+		return errors.NewConflictError("process_dependency", "dependency already exists")
+	}
+
+	// restart_with 只扩展重启范围，不影响顺序，不参与环检查
+	if dependency.DependencyType == models.DependencyTypeRestartWith {
+		return nil
+	}
+	cycle, err := s.findDependencyCycle(dependency)
+	if err != nil {
+		return err
+	}
+	if cycle != "" {
+		return errors.NewConflictError("process_dependency", "would create dependency cycle: "+cycle)
+	}
+	return nil
+}
+
+// findDependencyCycle 在 start_after 和 stop_before 构成的全局依赖图中查找加入新依赖后形成的环
+func (s *ProcessEnhancedService) findDependencyCycle(dependency *models.ProcessDependency) (string, error) {
+	var dependencies []models.ProcessDependency
+	if err := s.db.Where("dependency_type IN ?", []string{models.DependencyTypeStartAfter, models.DependencyTypeStopBefore}).
+		Find(&dependencies).Error; err != nil {
+		return "", errors.NewDatabaseError("list process dependencies", err)
+	}
+
+	type vertex struct {
+		node    uint
+		process string
+	}
+	edges := make(map[vertex][]vertex)
+	for _, dep := range dependencies {
+		from := vertex{dep.NodeID, normalizeProcessName(dep.ProcessName)}
+		edges[from] = append(edges[from], vertex{dep.DependentNodeID, normalizeProcessName(dep.DependentProcess)})
+	}
+
+	// 新依赖 from -> to 形成环当且仅当已有路径 to -> ... -> from
+	from := vertex{dependency.NodeID, dependency.ProcessName}
+	to := vertex{dependency.DependentNodeID, dependency.DependentProcess}
+	visited := make(map[vertex]bool)
+	var path []vertex
+	var visit func(v vertex) bool
+	visit = func(v vertex) bool {
+		path = append(path, v)
+		if v == from {
+			return true
+		}
+		visited[v] = true
+		for _, next := range edges[v] {
+			if !visited[next] && visit(next) {
+				return true
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if !visit(to) {
+		return "", nil
+	}
+
+	nodes, err := s.dependencyNodes()
+	if err != nil {
+		return "", err
+	}
+	parts := []string{fmt.Sprintf("%s:%s", nodes[from.node].Name, from.process)}
+	for _, v := range path {
+		parts = append(parts, fmt.Sprintf("%s:%s", nodes[v.node].Name, v.process))
+	}
+	return strings.Join(parts, " -> "), nil
+}
+
+// GetDependencyGraph 获取全部进程依赖构成的图；引用已不存在节点的记录被忽略
+func (s *ProcessEnhancedService) GetDependencyGraph() (*DependencyGraph, error) {
+	nodes, err := s.dependencyNodes()
+	if err != nil {
+		return nil, err
+	}
+	var dependencies []models.ProcessDependency
+	if err := s.db.Order("id ASC").Find(&dependencies).Error; err != nil {
+		return nil, errors.NewDatabaseError("list process dependencies", err)
+	}
+
+	graph := &DependencyGraph{Nodes: []DependencyGraphNode{}, Edges: []DependencyGraphEdge{}}
+	seen := make(map[string]bool)
+	vertex := func(nodeID uint, process string) string {
+		node := nodes[nodeID]
+		process = normalizeProcessName(process)
+		id := node.Name + ":" + process
+		if !seen[id] {
+			seen[id] = true
+			graph.Nodes = append(graph.Nodes, DependencyGraphNode{
+				ID: id, Node: node.Name, NodeID: nodeID, Environment: node.Environment, Process: process,
+			})
+		}
+		return id
+	}
+
+	for _, dep := range dependencies {
+		_, ok := nodes[dep.NodeID]
+		_, ok2 := nodes[dep.DependentNodeID]
+		if !ok || !ok2 {
+			continue
+		}
+		from := vertex(dep.NodeID, dep.ProcessName)
+		to := vertex(dep.DependentNodeID, dep.DependentProcess)
+		graph.Edges = append(graph.Edges, DependencyGraphEdge{
+			ID: dep.ID, From: from, To: to, Type: dep.DependencyType,
+			Required: dep.Required, Timeout: dep.Timeout, CrossNode: dep.NodeID != dep.DependentNodeID,
+		})
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	return graph, nil
+}
+
+// Filter 只保留两端节点都满足 allowed 的依赖
+func (g *DependencyGraph) Filter(allowed func(node, environment string) bool) *DependencyGraph {
+	visible := make(map[string]bool)
+	for _, node := range g.Nodes {
+		visible[node.ID] = allowed(node.Node, node.Environment)
+	}
+
+	result := &DependencyGraph{Nodes: []DependencyGraphNode{}, Edges: []DependencyGraphEdge{}}
+	used := make(map[string]bool)
+	for _, edge := range g.Edges {
+		if visible[edge.From] && visible[edge.To] {
+			result.Edges = append(result.Edges, edge)
+			used[edge.From] = true
+			used[edge.To] = true
+		}
+	}
+	for _, node := range g.Nodes {
+		if used[node.ID] {
+			result.Nodes = append(result.Nodes, node)
+		}
+	}
+	return result
+}
+
+// DOT 以 Graphviz DOT 格式输出，每个节点一个子图；弱依赖为虚线
+func (g *DependencyGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n\trankdir=LR;\n\tnode [shape=box];\n")
+
+	var order []string
+	byNode := make(map[string][]DependencyGraphNode)
+	for _, node := range g.Nodes {
+		if _, ok := byNode[node.Node]; !ok {
+			order = append(order, node.Node)
+		}
+		byNode[node.Node] = append(byNode[node.Node], node)
+	}
+	for i, name := range order {
+		processes := byNode[name]
+		label := name
+		if processes[0].Environment != "" {
+			label += " (" + processes[0].Environment + ")"
+		}
+		fmt.Fprintf(&b, "\tsubgraph cluster_%d {\n\t\tlabel=%s;\n", i, dotQuote(label))
+		for _, process := range processes {
+			fmt.Fprintf(&b, "\t\t%s [label=%s];\n", dotQuote(process.ID), dotQuote(process.Process))
+		}
+		b.WriteString("\t}\n")
+	}
+	for _, edge := range g.Edges {
+		attrs := "label=" + dotQuote(edge.Type)
+		if !edge.Required {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(&b, "\t%s -> %s [%s];\n", dotQuote(edge.From), dotQuote(edge.To), attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
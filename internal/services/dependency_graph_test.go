@@ -0,0 +1,108 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"superview/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newDependencyTestService(t *testing.T) *ProcessEnhancedService {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "superview.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Node{}, &models.ProcessDependency{}))
+	require.NoError(t, db.Create(&models.Node{Name: "api-1", Host: "10.0.0.1", Port: 9001, Environment: "prod"}).Error)
+	require.NoError(t, db.Create(&models.Node{Name: "queue-1", Host: "10.0.0.2", Port: 9001, Environment: "shared"}).Error)
+	return NewProcessEnhancedService(db)
+}
+
+func createRefDependency(s *ProcessEnhancedService, process, dependsOn, kind string, required bool) error {
+	nodeID, name, err := s.ResolveProcessRef(process)
+	if err != nil {
+		return err
+	}
+	dependentNodeID, dependentName, err := s.ResolveProcessRef(dependsOn)
+	if err != nil {
+		return err
+	}
+	return s.CreateProcessDependency(&models.ProcessDependency{
+		ProcessName: name, NodeID: nodeID, DependentProcess: dependentName, DependentNodeID: dependentNodeID,
+		DependencyType: kind, Required: required,
+	})
+}
+
+func TestParseProcessRef(t *testing.T) {
+	node, process, err := ParseProcessRef("queue-1:jobs:worker")
+	require.NoError(t, err)
+	assert.Equal(t, "queue-1", node)
+	assert.Equal(t, "jobs:worker", process)
+
+	_, process, err = ParseProcessRef("api-1:web:web")
+	require.NoError(t, err)
+	assert.Equal(t, "web", process, "group equal to the process name is dropped")
+
+	for _, ref := range []string{"", "api-1", "api-1:", ":web"} {
+		_, _, err := ParseProcessRef(ref)
+		assert.Error(t, err, ref)
+	}
+}
+
+func TestCreateCrossNodeDependency(t *testing.T) {
+	s := newDependencyTestService(t)
+
+	require.NoError(t, createRefDependency(s, "api-1:web", "queue-1:jobs:worker", models.DependencyTypeStartAfter, false))
+	var dep models.ProcessDependency
+	require.NoError(t, s.db.First(&dep).Error)
+	assert.NotEqual(t, dep.NodeID, dep.DependentNodeID)
+	assert.False(t, dep.Required, "weak dependencies are persisted as such")
+	assert.Equal(t, 30, dep.Timeout)
+
+	assert.Error(t, createRefDependency(s, "api-1:web", "queue-1:jobs:worker", models.DependencyTypeStartAfter, true), "duplicate")
+	assert.Error(t, createRefDependency(s, "api-1:web", "api-1:web", models.DependencyTypeStartAfter, true), "self")
+	assert.Error(t, createRefDependency(s, "api-1:web", "missing:web", models.DependencyTypeStartAfter, true), "unknown node")
+	assert.Error(t, createRefDependency(s, "api-1:web", "queue-1:db", "before", true), "unknown type")
+}
+
+func TestDependencyCycleAcrossNodes(t *testing.T) {
+	s := newDependencyTestService(t)
+	require.NoError(t, createRefDependency(s, "api-1:web", "queue-1:jobs:worker", models.DependencyTypeStartAfter, true))
+	require.NoError(t, createRefDependency(s, "queue-1:jobs:worker", "queue-1:redis", models.DependencyTypeStopBefore, true))
+
+	err := createRefDependency(s, "queue-1:redis", "api-1:web", models.DependencyTypeStartAfter, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "queue-1:redis -> api-1:web -> queue-1:jobs:worker -> queue-1:redis")
+
+	// restart_with 不影响顺序，可以反向关联
+	require.NoError(t, createRefDependency(s, "queue-1:redis", "api-1:web", models.DependencyTypeRestartWith, true))
+}
+
+func TestDependencyGraph(t *testing.T) {
+	s := newDependencyTestService(t)
+	require.NoError(t, createRefDependency(s, "api-1:web", "queue-1:jobs:worker", models.DependencyTypeStartAfter, true))
+	require.NoError(t, createRefDependency(s, "api-1:web", "api-1:cache", models.DependencyTypeStartAfter, false))
+
+	graph, err := s.GetDependencyGraph()
+	require.NoError(t, err)
+	ids := make([]string, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		ids[i] = node.ID
+	}
+	assert.Equal(t, []string{"api-1:cache", "api-1:web", "queue-1:jobs:worker"}, ids)
+	require.Len(t, graph.Edges, 2)
+	assert.True(t, graph.Edges[0].CrossNode)
+	assert.False(t, graph.Edges[1].CrossNode)
+
+	dot := graph.DOT()
+	assert.Contains(t, dot, `label="queue-1 (shared)";`)
+	assert.Contains(t, dot, `"api-1:web" -> "queue-1:jobs:worker" [label="start_after"];`)
+	assert.Contains(t, dot, `"api-1:web" -> "api-1:cache" [label="start_after", style=dashed];`)
+
+	filtered := graph.Filter(func(node, environment string) bool { return environment == "prod" })
+	assert.Len(t, filtered.Edges, 1)
+	assert.Len(t, filtered.Nodes, 2)
+}
@@ -2,7 +2,12 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"superview/internal/errors"
 	"superview/internal/logger"
@@ -10,19 +15,26 @@ import (
 	"superview/internal/repository"
 	"superview/internal/utils"
 
+	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // DiscoveryRequest represents a request to start a discovery scan.
+// CIDR and Port may be combined with or replaced by Targets and Ports.
 type DiscoveryRequest struct {
-	CIDR           string `json:"cidr"`
-	Port           int    `json:"port"`
-	Username       string `json:"username"`
-	Password       string `json:"password"`
-	TimeoutSeconds int    `json:"timeout_seconds"` // optional, default 3
-	MaxWorkers     int    `json:"max_workers"`     // optional, default 50
-	CreatedBy      string `json:"created_by"`
+	CIDR            string                   `json:"cidr"`
+	Port            int                      `json:"port"`
+	Targets         *models.DiscoveryTargets `json:"targets"`
+	Ports           string                   `json:"ports"` // e.g. "9001,9002,9100-9105"
+	Username        string                   `json:"username"`
+	Password        string                   `json:"password"`
+	TimeoutSeconds  int                      `json:"timeout_seconds"`  // optional, default 3
+	MaxWorkers      int                      `json:"max_workers"`      // optional, default 50
+	RateLimit       int                      `json:"rate_limit"`       // optional probes per second, 0 = unlimited
+	RequireApproval bool                     `json:"require_approval"` // queue new nodes for review instead of registering them
+	CreatedBy       string                   `json:"created_by"`
+	ScheduleID      *uint                    `json:"schedule_id,omitempty"` // set for runs of a DiscoverySchedule
 }
 
 // Default values for discovery requests
@@ -31,6 +43,9 @@ const (
 	DefaultMaxWorkers     = 50
 	MinPort               = 1
 	MaxPort               = 65535
+	MaxRateLimit          = 10000
+	// targetResolveTimeout bounds DNS lookups done while creating a task
+	targetResolveTimeout = 15 * time.Second
 )
 
 // ScanContext holds the context for an active scan.
@@ -43,6 +58,7 @@ type ScanContext struct {
 // SupervisorService interface for adding discovered nodes to memory
 type SupervisorService interface {
 	AddNode(name, environment, host string, port int, username, password string) error
+	SetNodeLabels(name string, labels map[string]string) error
 }
 
 // DiscoveryService handles node discovery operations.
@@ -53,8 +69,14 @@ type DiscoveryService struct {
 	hub                WebSocketHub
 	supervisorService  SupervisorService
 	activityLogService *ActivityLogService
+	resolver           TargetResolver
 	activeScans        map[uint]*ScanContext
 	mu                 sync.RWMutex
+
+	// Discovery schedules run on the leader only
+	cron        *cron.Cron
+	cronEntries map[uint]cron.EntryID
+	scheduleMu  sync.Mutex
 }
 
 // NewDiscoveryService creates a new DiscoveryService instance.
@@ -66,6 +88,7 @@ func NewDiscoveryService(db *gorm.DB, repo repository.DiscoveryRepository, nodeR
 		hub:                hub,
 		supervisorService:  supervisorService,
 		activityLogService: NewActivityLogService(db),
+		resolver:           net.DefaultResolver,
 		activeScans:        make(map[uint]*ScanContext),
 	}
 }
@@ -73,20 +96,39 @@ func NewDiscoveryService(db *gorm.DB, repo repository.DiscoveryRepository, nodeR
 // StartDiscovery validates input, creates a task, and starts the scan.
 // Requirements: 2.1, 2.2
 func (s *DiscoveryService) StartDiscovery(req *DiscoveryRequest) (*models.DiscoveryTask, error) {
-	// Validate CIDR
-	cidrRange, err := utils.ParseCIDR(req.CIDR)
-	if err != nil {
-		logger.Warn("Invalid CIDR provided",
-			zap.String("cidr", req.CIDR),
-			zap.Error(err))
-		return nil, errors.NewValidationError("cidr", err.Error())
+	targets := &models.DiscoveryTargets{}
+	if req.Targets != nil {
+		*targets = *req.Targets
 	}
 
-	// Validate port
-	if req.Port < MinPort || req.Port > MaxPort {
-		logger.Warn("Invalid port provided",
-			zap.Int("port", req.Port))
-		return nil, errors.NewValidationError("port", "port must be between 1 and 65535")
+	// Validate CIDR; it is optional when other targets are given
+	if req.CIDR != "" || targets.Empty() {
+		if _, err := utils.ParseCIDR(req.CIDR); err != nil {
+			logger.Warn("Invalid CIDR provided",
+				zap.String("cidr", req.CIDR),
+				zap.Error(err))
+			return nil, errors.NewValidationError("cidr", err.Error())
+		}
+	}
+
+	// Validate ports: Port and the Ports list are merged
+	var ports []int
+	if req.Ports != "" {
+		parsed, err := utils.ParsePortList(req.Ports)
+		if err != nil {
+			return nil, errors.NewValidationError("ports", err.Error())
+		}
+		ports = parsed
+	}
+	if req.Port != 0 || len(ports) == 0 {
+		if req.Port < MinPort || req.Port > MaxPort {
+			logger.Warn("Invalid port provided",
+				zap.Int("port", req.Port))
+			return nil, errors.NewValidationError("port", "port must be between 1 and 65535")
+		}
+		if !containsPort(ports, req.Port) {
+			ports = append([]int{req.Port}, ports...)
+		}
 	}
 
 	// Validate username (required for Supervisor auth)
@@ -104,6 +146,10 @@ func (s *DiscoveryService) StartDiscovery(req *DiscoveryRequest) (*models.Discov
 		return nil, errors.NewValidationError("created_by", "created_by is required")
 	}
 
+	if req.RateLimit < 0 || req.RateLimit > MaxRateLimit {
+		return nil, errors.NewValidationError("rate_limit", fmt.Sprintf("rate_limit must be between 0 and %d", MaxRateLimit))
+	}
+
 	// Apply defaults
 	timeoutSeconds := req.TimeoutSeconds
 	if timeoutSeconds <= 0 {
@@ -115,41 +161,80 @@ func (s *DiscoveryService) StartDiscovery(req *DiscoveryRequest) (*models.Discov
 		maxWorkers = DefaultMaxWorkers
 	}
 
+	// Expand all targets into deduplicated host:port probes
+	scanTargets := *targets
+	if req.CIDR != "" {
+		scanTargets.CIDRs = append([]string{req.CIDR}, scanTargets.CIDRs...)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), targetResolveTimeout)
+	probes, warnings, err := ExpandDiscoveryTargets(ctx, s.resolver, &scanTargets, ports)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	if len(probes) == 0 {
+		message := "no addresses to scan"
+		if len(warnings) > 0 {
+			message += ": " + strings.Join(warnings, "; ")
+		}
+		return nil, errors.NewValidationError("targets", message)
+	}
+
 	// Create task with status "pending"
 	task := &models.DiscoveryTask{
-		CIDR:      req.CIDR,
-		Port:      req.Port,
-		Username:  req.Username,
-		Status:    models.DiscoveryStatusPending,
-		TotalIPs:  cidrRange.Count(),
-		CreatedBy: req.CreatedBy,
+		CIDR:       req.CIDR,
+		Port:       ports[0],
+		Username:   req.Username,
+		Status:     models.DiscoveryStatusPending,
+		TotalIPs:   len(probes),
+		RateLimit:  req.RateLimit,
+		CreatedBy:  req.CreatedBy,
+		ScheduleID: req.ScheduleID,
+
+		RequireApproval: req.RequireApproval,
+	}
+	if !targets.Empty() || len(targets.Exclude) > 0 {
+		task.Targets = targets
+	}
+	if req.Ports != "" {
+		task.Ports = formatPorts(ports)
 	}
 
 	// Persist to database
 	if err := s.repo.CreateTask(task); err != nil {
 		logger.Error("Failed to create discovery task",
-			zap.String("cidr", req.CIDR),
+			zap.String("scope", task.Scope()),
 			zap.Error(err))
 		return nil, err
 	}
 
 	logger.Info("Discovery task created",
 		zap.Uint("task_id", task.ID),
-		zap.String("cidr", req.CIDR),
-		zap.Int("port", req.Port),
+		zap.String("scope", task.Scope()),
+		zap.Ints("ports", ports),
 		zap.Int("total_ips", task.TotalIPs),
 		zap.String("created_by", req.CreatedBy))
 
+	if len(warnings) > 0 && s.activityLogService != nil {
+		message := fmt.Sprintf("Discovery task %d skipped unresolvable targets: %s", task.ID, strings.Join(warnings, "; "))
+		s.activityLogService.LogSystemEvent("WARNING", "discovery_targets_skipped", "discovery", fmt.Sprintf("task-%d", task.ID), message, nil)
+	}
+
 	// Start the scan asynchronously
 	scanner := NewScanner(s)
 	scanConfig := &ScanConfig{
 		TaskID:         task.ID,
 		CIDR:           req.CIDR,
-		Port:           req.Port,
+		Port:           ports[0],
+		Targets:        probes,
 		Username:       req.Username,
 		Password:       req.Password,
 		TimeoutSeconds: timeoutSeconds,
 		MaxWorkers:     maxWorkers,
+		RateLimit:      req.RateLimit,
+		ScheduleID:     req.ScheduleID,
+
+		RequireApproval: req.RequireApproval,
 	}
 
 	if err := scanner.StartScan(scanConfig); err != nil {
@@ -163,6 +248,24 @@ func (s *DiscoveryService) StartDiscovery(req *DiscoveryRequest) (*models.Discov
 	return task, nil
 }
 
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+// formatPorts renders ports as a comma separated list
+func formatPorts(ports []int) string {
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = strconv.Itoa(port)
+	}
+	return strings.Join(parts, ",")
+}
+
 // CancelDiscovery stops a running scan and updates its status.
 // Requirements: 2.4
 func (s *DiscoveryService) CancelDiscovery(taskID uint) error {
@@ -209,6 +312,72 @@ func (s *DiscoveryService) CancelDiscovery(taskID uint) error {
 	return nil
 }
 
+// HandleLeadershipChange reacts to leader election in multi-instance deployments.
+// Scans and schedules only run on the leader: losing leadership stops schedules and
+// local scans, and gaining it fails tasks left pending or running by an instance
+// that is no longer leader before starting the schedules.
+func (s *DiscoveryService) HandleLeadershipChange(isLeader bool) {
+	if !isLeader {
+		s.stopSchedules()
+		s.abortActiveScans("scan aborted: instance lost leadership")
+		return
+	}
+	s.failOrphanedTasks()
+	s.startSchedules()
+}
+
+// abortActiveScans stops all scans running on this instance and marks them failed.
+func (s *DiscoveryService) abortActiveScans(reason string) {
+	s.mu.Lock()
+	aborted := make([]uint, 0, len(s.activeScans))
+	for taskID, scanCtx := range s.activeScans {
+		scanCtx.Cancel()
+		if scanCtx.Pool != nil {
+			scanCtx.Pool.Stop()
+		}
+		delete(s.activeScans, taskID)
+		aborted = append(aborted, taskID)
+	}
+	s.mu.Unlock()
+
+	for _, taskID := range aborted {
+		if err := s.UpdateTaskStatus(taskID, models.DiscoveryStatusFailed, reason); err != nil {
+			logger.Error("Failed to mark aborted scan as failed",
+				zap.Uint("task_id", taskID),
+				zap.Error(err))
+		}
+	}
+	if len(aborted) > 0 {
+		logger.Warn("Aborted active discovery scans", zap.Int("count", len(aborted)), zap.String("reason", reason))
+	}
+}
+
+// failOrphanedTasks marks non-terminal tasks that have no local scan as failed.
+func (s *DiscoveryService) failOrphanedTasks() {
+	var tasks []*models.DiscoveryTask
+	err := s.db.Where("status IN ?", []string{models.DiscoveryStatusPending, models.DiscoveryStatusRunning}).
+		Find(&tasks).Error
+	if err != nil {
+		logger.Error("Failed to load unfinished discovery tasks", zap.Error(err))
+		return
+	}
+
+	for _, task := range tasks {
+		if s.IsTaskRunning(task.ID) {
+			continue
+		}
+		task.Status = models.DiscoveryStatusFailed
+		task.ErrorMsg = "scan interrupted: the instance running it stopped"
+		if err := s.repo.UpdateTask(task); err != nil {
+			logger.Error("Failed to mark orphaned discovery task as failed",
+				zap.Uint("task_id", task.ID),
+				zap.Error(err))
+			continue
+		}
+		logger.Warn("Marked orphaned discovery task as failed", zap.Uint("task_id", task.ID))
+	}
+}
+
 // GetTask retrieves a discovery task by ID.
 // Requirements: 7.1, 7.2
 func (s *DiscoveryService) GetTask(taskID uint) (*models.DiscoveryTask, error) {
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"superview/internal/errors"
+	"superview/internal/labels"
+	"superview/internal/logger"
+	"superview/internal/models"
+	"superview/internal/utils"
+	"superview/internal/validation"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// EventTypeDiscoveryCandidate is broadcast when a node is queued for approval
+const EventTypeDiscoveryCandidate = "discovery_candidate"
+
+// ApproveOptions overrides the suggested settings of approved candidates.
+// Environment and Labels apply to every candidate; Names is keyed by candidate ID.
+type ApproveOptions struct {
+	Names       map[uint]string
+	Environment string
+	Labels      map[string]string
+	ReviewedBy  string
+}
+
+// CandidateOutcome is the result of approving or rejecting one candidate.
+type CandidateOutcome struct {
+	ID       uint   `json:"id"`
+	Status   string `json:"status"`
+	NodeName string `json:"node_name,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// MatchBlocklist returns the first entry matching the host, IP and port, or nil.
+func MatchBlocklist(entries []*models.DiscoveryBlocklistEntry, host, ip string, port int) *models.DiscoveryBlocklistEntry {
+	for _, entry := range entries {
+		if entry.Port != 0 && entry.Port != port {
+			continue
+		}
+		address := strings.ToLower(entry.Address)
+		switch {
+		case strings.Contains(address, "/"):
+			if network, err := utils.ParseCIDR(address); err == nil && network.Contains(ip) {
+				return entry
+			}
+		case address == ip || (host != "" && address == strings.ToLower(host)):
+			return entry
+		}
+	}
+	return nil
+}
+
+// Blocklist returns all blocklist entries.
+func (s *DiscoveryService) Blocklist() ([]*models.DiscoveryBlocklistEntry, error) {
+	var entries []*models.DiscoveryBlocklistEntry
+	if err := s.db.Order("id").Find(&entries).Error; err != nil {
+		return nil, errors.NewDatabaseError("list discovery blocklist", err)
+	}
+	return entries, nil
+}
+
+// AddBlocklistEntry validates and saves a blocklist entry.
+func (s *DiscoveryService) AddBlocklistEntry(entry *models.DiscoveryBlocklistEntry) error {
+	entry.Address = strings.TrimSpace(entry.Address)
+	switch {
+	case entry.Address == "":
+		return errors.NewValidationError("address", "address is required")
+	case strings.Contains(entry.Address, "/"):
+		if _, err := utils.ParseCIDR(entry.Address); err != nil {
+			return errors.NewValidationError("address", err.Error())
+		}
+	case net.ParseIP(entry.Address) == nil:
+		if _, err := utils.ExpandHostPattern(entry.Address); err != nil || strings.ContainsAny(entry.Address, "[]") {
+			return errors.NewValidationError("address", "address must be an IP, CIDR or hostname")
+		}
+	}
+	if entry.Port < 0 || entry.Port > MaxPort {
+		return errors.NewValidationError("port", "port must be between 0 and 65535")
+	}
+
+	var count int64
+	s.db.Model(&models.DiscoveryBlocklistEntry{}).Where("address = ? AND port = ?", entry.Address, entry.Port).Count(&count)
+	if count > 0 {
+		return errors.NewConflictError("discovery_blocklist", "entry already exists")
+	}
+	if err := s.db.Create(entry).Error; err != nil {
+		return errors.NewDatabaseError("create discovery blocklist entry", err)
+	}
+	return nil
+}
+
+// DeleteBlocklistEntry removes a blocklist entry.
+func (s *DiscoveryService) DeleteBlocklistEntry(id uint) error {
+	result := s.db.Delete(&models.DiscoveryBlocklistEntry{}, id)
+	if result.Error != nil {
+		return errors.NewDatabaseError("delete discovery blocklist entry", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewNotFoundError("discovery_blocklist", fmt.Sprint(id))
+	}
+	return nil
+}
+
+// createDiscoveredNode saves a discovered node and loads it into the SupervisorService.
+// Approval and auto-registration run on the leader only; other instances load the
+// node from the database through NodeReloader.Sync.
+func (s *DiscoveryService) createDiscoveredNode(node *models.Node) error {
+	if err := s.nodeRepo.Create(node); err != nil {
+		logger.Error("Failed to create discovered node",
+			zap.String("node_name", node.Name),
+			zap.Error(err))
+		return err
+	}
+
+	logger.Info("Discovered node registered in database",
+		zap.String("node_name", node.Name),
+		zap.String("host", node.Host),
+		zap.Int("port", node.Port))
+
+	// Add node to SupervisorService memory
+	if s.supervisorService != nil {
+		err := s.supervisorService.AddNode(node.Name, node.Environment, node.Host, node.Port, node.Username, node.Password)
+		if errors.IsConflictError(err) {
+			// Already loaded from the database by a concurrent node sync
+			return nil
+		}
+		if err != nil {
+			logger.Warn("Failed to add discovered node to supervisor service",
+				zap.String("node_name", node.Name),
+				zap.Error(err))
+			// Not fatal - node is in database, the next node sync or restart loads it
+		} else {
+			if labels := node.GetLabels(); len(labels) > 0 {
+				s.supervisorService.SetNodeLabels(node.Name, labels)
+			}
+			logger.Info("Discovered node loaded into supervisor service",
+				zap.String("node_name", node.Name))
+		}
+	}
+	return nil
+}
+
+// queueCandidate adds a discovered node to the review queue. A pending
+// candidate for the same address and port is refreshed instead.
+func (s *DiscoveryService) queueCandidate(taskID uint, probe *ProbeTask, node *models.Node) error {
+	var candidate models.DiscoveryCandidate
+	err := s.db.Where("ip = ? AND port = ? AND status = ?", probe.IP, probe.Port, models.CandidateStatusPending).
+		First(&candidate).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	isNew := err == gorm.ErrRecordNotFound
+
+	candidate.TaskID = taskID
+	candidate.Host = probe.Host
+	candidate.IP = probe.IP
+	candidate.Port = probe.Port
+	candidate.Username = node.Username
+	candidate.Password = node.Password
+	candidate.Version = probe.Version
+	candidate.Identification = probe.Identification
+	candidate.ProcessCount = probe.ProcessCount
+	candidate.LastSeenAt = time.Now()
+	candidate.Rule = probe.Rule
+	candidate.Name = node.Name
+	candidate.Environment = node.Environment
+	candidate.Labels = node.GetLabels()
+	candidate.Status = models.CandidateStatusPending
+	if err := s.db.Save(&candidate).Error; err != nil {
+		return err
+	}
+
+	if isNew {
+		if s.activityLogService != nil {
+			message := fmt.Sprintf("Supervisor at %s:%d (version %s, %d processes) is waiting for approval (task %d)",
+				candidate.Address(), candidate.Port, candidate.Version, candidate.ProcessCount, taskID)
+			s.activityLogService.LogSystemEvent("INFO", "node_pending_approval", "discovery", fmt.Sprintf("candidate-%d", candidate.ID), message, nil)
+		}
+		s.broadcastEvent(EventTypeDiscoveryCandidate, &candidate)
+	}
+	return nil
+}
+
+// ListCandidates returns candidates with the given status (all if empty), newest first.
+func (s *DiscoveryService) ListCandidates(status string, offset, limit int) ([]*models.DiscoveryCandidate, int64, error) {
+	query := s.db.Model(&models.DiscoveryCandidate{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.NewDatabaseError("count discovery candidates", err)
+	}
+	var candidates []*models.DiscoveryCandidate
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&candidates).Error; err != nil {
+		return nil, 0, errors.NewDatabaseError("list discovery candidates", err)
+	}
+	return candidates, total, nil
+}
+
+// pendingCandidate loads a candidate that can still be reviewed
+func (s *DiscoveryService) pendingCandidate(id uint) (*models.DiscoveryCandidate, error) {
+	var candidate models.DiscoveryCandidate
+	if err := s.db.First(&candidate, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("discovery_candidate", fmt.Sprint(id))
+		}
+		return nil, errors.NewDatabaseError("get discovery candidate", err)
+	}
+	if candidate.Status != models.CandidateStatusPending {
+		return nil, errors.NewConflictError("discovery_candidate", "candidate is already "+candidate.Status)
+	}
+	return &candidate, nil
+}
+
+// ApproveCandidates registers pending candidates as nodes. Each candidate is
+// handled on its own; failures are reported per candidate.
+func (s *DiscoveryService) ApproveCandidates(ids []uint, opts ApproveOptions) []CandidateOutcome {
+	outcomes := make([]CandidateOutcome, 0, len(ids))
+	for _, id := range ids {
+		outcome := CandidateOutcome{ID: id}
+		node, err := s.approveCandidate(id, opts)
+		if err != nil {
+			outcome.Status = "failed"
+			outcome.Error = err.Error()
+		} else {
+			outcome.Status = models.CandidateStatusApproved
+			outcome.NodeName = node.Name
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes
+}
+
+func (s *DiscoveryService) approveCandidate(id uint, opts ApproveOptions) (*models.Node, error) {
+	candidate, err := s.pendingCandidate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	name := candidate.Name
+	if override := strings.TrimSpace(opts.Names[id]); override != "" {
+		validator := validation.NewValidator()
+		validator.ValidateNodeName("name", override)
+		if validator.HasErrors() {
+			return nil, errors.NewValidationError("name", validator.Errors()[0].Message)
+		}
+		name = override
+	}
+	if name == "" {
+		name = generateNodeName(candidate.Address())
+	}
+	environment := candidate.Environment
+	if opts.Environment != "" {
+		environment = opts.Environment
+	}
+	if environment == "" {
+		environment = "discovered"
+	}
+	nodeLabels := make(map[string]string)
+	for key, value := range candidate.Labels {
+		nodeLabels[key] = value
+	}
+	for key, value := range opts.Labels {
+		nodeLabels[key] = value
+	}
+	if err := labels.Validate(nodeLabels); err != nil {
+		return nil, errors.NewValidationError("labels", err.Error())
+	}
+
+	// The node may have been added since the candidate was queued
+	for _, host := range uniqueHosts(candidate.Address(), candidate.IP) {
+		if exists, err := s.nodeRepo.ExistsByHostPort(host, candidate.Port); err != nil {
+			return nil, err
+		} else if exists {
+			return nil, errors.NewConflictError("node", fmt.Sprintf("a node at %s:%d already exists", host, candidate.Port))
+		}
+	}
+	if exists, err := s.nodeRepo.ExistsByName(name); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, errors.NewConflictError("node", fmt.Sprintf("node name %s is already in use", name))
+	}
+
+	node := &models.Node{
+		Name:        name,
+		Host:        candidate.Address(),
+		Port:        candidate.Port,
+		Username:    candidate.Username,
+		Password:    candidate.Password,
+		Status:      "discovered",
+		Environment: environment,
+		Source:      models.NodeSourceDiscovery,
+	}
+	node.SetLabels(nodeLabels)
+	if err := s.createDiscoveredNode(node); err != nil {
+		return nil, errors.NewDatabaseError("create node", err)
+	}
+
+	now := time.Now()
+	if err := s.db.Model(candidate).Updates(map[string]interface{}{
+		"status":      models.CandidateStatusApproved,
+		"reviewed_by": opts.ReviewedBy,
+		"reviewed_at": &now,
+		"node_id":     node.ID,
+		"name":        name,
+		"environment": environment,
+	}).Error; err != nil {
+		logger.Error("Failed to mark discovery candidate approved",
+			zap.Uint("candidate_id", id),
+			zap.Error(err))
+	}
+
+	if s.activityLogService != nil {
+		message := fmt.Sprintf("Node %s approved and registered at %s:%d in environment %s by %s",
+			name, node.Host, node.Port, environment, opts.ReviewedBy)
+		s.activityLogService.LogSystemEvent("INFO", "node_approved", "discovery", name, message, nil)
+	}
+	return node, nil
+}
+
+// RejectCandidates rejects pending candidates and optionally adds their
+// address and port to the blocklist so later scans skip them.
+func (s *DiscoveryService) RejectCandidates(ids []uint, blocklist bool, reason, reviewedBy string) []CandidateOutcome {
+	outcomes := make([]CandidateOutcome, 0, len(ids))
+	for _, id := range ids {
+		outcome := CandidateOutcome{ID: id, Status: models.CandidateStatusRejected}
+		if err := s.rejectCandidate(id, blocklist, reason, reviewedBy); err != nil {
+			outcome.Status = "failed"
+			outcome.Error = err.Error()
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes
+}
+
+func (s *DiscoveryService) rejectCandidate(id uint, blocklist bool, reason, reviewedBy string) error {
+	candidate, err := s.pendingCandidate(id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := s.db.Model(candidate).Updates(map[string]interface{}{
+		"status":      models.CandidateStatusRejected,
+		"reviewed_by": reviewedBy,
+		"reviewed_at": &now,
+		"reason":      reason,
+	}).Error; err != nil {
+		return errors.NewDatabaseError("reject discovery candidate", err)
+	}
+
+	if blocklist {
+		entry := &models.DiscoveryBlocklistEntry{
+			Address:   candidate.Address(),
+			Port:      candidate.Port,
+			Reason:    reason,
+			CreatedBy: reviewedBy,
+		}
+		if err := s.AddBlocklistEntry(entry); err != nil && !errors.IsConflictError(err) {
+			return err
+		}
+	}
+
+	if s.activityLogService != nil {
+		message := fmt.Sprintf("Supervisor at %s:%d rejected by %s", candidate.Address(), candidate.Port, reviewedBy)
+		if blocklist {
+			message += " and blocklisted"
+		}
+		if reason != "" {
+			message += ": " + reason
+		}
+		s.activityLogService.LogSystemEvent("INFO", "node_rejected", "discovery", fmt.Sprintf("candidate-%d", id), message, nil)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"superview/internal/models"
+	"superview/internal/supervisor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchBlocklist(t *testing.T) {
+	entries := []*models.DiscoveryBlocklistEntry{
+		{Address: "10.5.0.0/24", Port: 0},
+		{Address: "10.6.0.7", Port: 9002},
+		{Address: "Legacy.example.com", Port: 0},
+	}
+
+	assert.NotNil(t, MatchBlocklist(entries, "", "10.5.0.9", 9001))
+	assert.NotNil(t, MatchBlocklist(entries, "", "10.6.0.7", 9002))
+	assert.Nil(t, MatchBlocklist(entries, "", "10.6.0.7", 9001), "other port")
+	assert.NotNil(t, MatchBlocklist(entries, "legacy.example.com", "10.9.0.1", 9001))
+	assert.Nil(t, MatchBlocklist(entries, "app.example.com", "10.9.0.1", 9001))
+}
+
+func TestDiscoveryCandidateReview(t *testing.T) {
+	s := newScheduleTestService(t)
+	scanner := NewScanner(s)
+	rules := []*models.DiscoveryRule{
+		{Name: "prod", Enabled: true, CIDR: "10.1.0.0/16", Action: models.DiscoveryActionRegister,
+			Environment: "prod", Labels: map[string]string{"role": "worker"}},
+	}
+	policy := &registrationPolicy{rules: rules, requireApproval: true}
+
+	queue := func(ip string, version string) *ProbeTask {
+		probe := &ProbeTask{IP: ip, Port: 9001, Status: models.ResultStatusSuccess, Version: version, ProcessCount: 3}
+		scanner.registerDiscoveredNode(context.Background(), 1, probe, policy, "u", "p")
+		return probe
+	}
+
+	probe := queue("10.1.0.5", "4.2.1")
+	assert.Equal(t, models.ResultActionPending, probe.Action, "require_approval holds rule matches too")
+	queue("10.1.0.5", "4.2.5")
+	queue("10.2.0.5", "4.2.5")
+
+	candidates, total, err := s.ListCandidates(models.CandidateStatusPending, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), total, "a pending candidate is refreshed, not duplicated")
+	first := candidates[1]
+	assert.Equal(t, "4.2.5", first.Version)
+	assert.Equal(t, 3, first.ProcessCount)
+	assert.Equal(t, "prod", first.Environment)
+	assert.Equal(t, "p", first.Password)
+	exists, _ := s.nodeRepo.ExistsByHostPort("10.1.0.5", 9001)
+	assert.False(t, exists)
+
+	// 名称和标签与手动创建节点一样校验
+	invalid := s.ApproveCandidates([]uint{first.ID}, ApproveOptions{Names: map[uint]string{first.ID: "web 1; drop"}, ReviewedBy: "admin"})
+	assert.Equal(t, "failed", invalid[0].Status)
+	invalid = s.ApproveCandidates([]uint{first.ID}, ApproveOptions{Labels: map[string]string{"bad key!": "x"}, ReviewedBy: "admin"})
+	assert.Equal(t, "failed", invalid[0].Status)
+
+	outcomes := s.ApproveCandidates([]uint{first.ID, 999}, ApproveOptions{
+		Names:      map[uint]string{first.ID: "web-1"},
+		Labels:     map[string]string{"team": "ops"},
+		ReviewedBy: "admin",
+	})
+	require.Len(t, outcomes, 2)
+	assert.Equal(t, models.CandidateStatusApproved, outcomes[0].Status)
+	assert.Equal(t, "failed", outcomes[1].Status)
+	node, err := s.nodeRepo.GetByName("web-1")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", node.Environment)
+	assert.Equal(t, map[string]string{"role": "worker", "team": "ops"}, node.GetLabels())
+
+	again := s.ApproveCandidates([]uint{first.ID}, ApproveOptions{ReviewedBy: "admin"})
+	assert.Equal(t, "failed", again[0].Status, "already approved")
+
+	second := candidates[0]
+	outcomes = s.RejectCandidates([]uint{second.ID}, true, "test rig", "admin")
+	assert.Equal(t, models.CandidateStatusRejected, outcomes[0].Status)
+	blocklist, err := s.Blocklist()
+	require.NoError(t, err)
+	policy.blocklist = blocklist
+
+	probe = queue("10.2.0.5", "4.2.5")
+	assert.Equal(t, models.ResultActionBlocked, probe.Action)
+	_, total, err = s.ListCandidates(models.CandidateStatusPending, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	assert.Error(t, s.AddBlocklistEntry(&models.DiscoveryBlocklistEntry{Address: "10.2.0.5", Port: 9001}), "duplicate")
+	assert.Error(t, s.AddBlocklistEntry(&models.DiscoveryBlocklistEntry{Address: "not a host"}))
+	require.NoError(t, s.DeleteBlocklistEntry(blocklist[0].ID))
+	assert.Error(t, s.DeleteBlocklistEntry(blocklist[0].ID))
+}
+
+func TestDiscoveredNodesReachOtherInstances(t *testing.T) {
+	s := newScheduleTestService(t)
+	scanner := NewScanner(s)
+	policy := &registrationPolicy{rules: []*models.DiscoveryRule{
+		{Name: "local", Enabled: true, CIDR: "127.0.0.2/32", Action: models.DiscoveryActionRegister,
+			Environment: "prod", Labels: map[string]string{"role": "worker"}},
+	}}
+
+	// 主节点自动注册一个节点，审批另一个节点
+	registered := &ProbeTask{IP: "127.0.0.2", Port: 1, Status: models.ResultStatusSuccess}
+	scanner.registerDiscoveredNode(context.Background(), 1, registered, policy, "", "")
+	require.Equal(t, models.ResultActionRegistered, registered.Action)
+
+	policy.requireApproval = true
+	scanner.registerDiscoveredNode(context.Background(), 1, &ProbeTask{IP: "127.0.0.3", Port: 1, Status: models.ResultStatusSuccess}, policy, "", "")
+	candidates, _, err := s.ListCandidates(models.CandidateStatusPending, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	outcomes := s.ApproveCandidates([]uint{candidates[0].ID}, ApproveOptions{Names: map[uint]string{candidates[0].ID: "approved-1"}, ReviewedBy: "admin"})
+	require.Equal(t, models.CandidateStatusApproved, outcomes[0].Status)
+
+	// 其他实例同步数据库后开始展示和操作这些节点
+	follower := supervisor.NewSupervisorService()
+	defer follower.Shutdown(context.Background())
+	_, err = NewNodeReloader(s.db, follower, nil).Sync()
+	require.NoError(t, err)
+
+	node, err := follower.GetNode(registered.NodeName)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", node.Environment)
+	assert.Equal(t, map[string]string{"role": "worker"}, node.Labels())
+	_, err = follower.GetNode("approved-1")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,578 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"superview/internal/errors"
+	"superview/internal/labels"
+	"superview/internal/logger"
+	"superview/internal/models"
+	"superview/internal/utils"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// EventTypeDiscoveryReport is broadcast when a scheduled run finds changes
+const EventTypeDiscoveryReport = "discovery_report"
+
+// discoveryCronParser accepts standard five field expressions, an optional
+// leading seconds field and descriptors such as @hourly or @every 6h.
+var discoveryCronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// MatchDiscoveryRule returns the first enabled rule matching the host and IP,
+// or nil. Rules must be sorted by priority.
+func MatchDiscoveryRule(rules []*models.DiscoveryRule, host, ip string) *models.DiscoveryRule {
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		if rule.CIDR != "" {
+			network, err := utils.ParseCIDR(rule.CIDR)
+			if err != nil || !network.Contains(ip) {
+				continue
+			}
+		}
+		if rule.HostPattern != "" {
+			if host == "" {
+				continue
+			}
+			if ok, _ := path.Match(strings.ToLower(rule.HostPattern), strings.ToLower(host)); !ok {
+				continue
+			}
+		}
+		return rule
+	}
+	return nil
+}
+
+// validateDiscoveryRule checks a rule before it is saved
+func validateDiscoveryRule(rule *models.DiscoveryRule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return errors.NewValidationError("name", "name is required")
+	}
+	switch rule.Action {
+	case models.DiscoveryActionRegister, models.DiscoveryActionApprove, models.DiscoveryActionIgnore:
+	default:
+		return errors.NewValidationError("action", "action must be one of register, approve, ignore")
+	}
+	if rule.CIDR != "" {
+		if _, err := utils.ParseCIDR(rule.CIDR); err != nil {
+			return errors.NewValidationError("cidr", err.Error())
+		}
+	}
+	if rule.HostPattern != "" {
+		if _, err := path.Match(rule.HostPattern, ""); err != nil {
+			return errors.NewValidationError("host_pattern", "invalid glob pattern")
+		}
+	}
+	if rule.NamePrefix != "" && !validNamePrefix(rule.NamePrefix) {
+		return errors.NewValidationError("name_prefix", "name_prefix may only contain letters, digits, '-', '_' and '.'")
+	}
+	if err := labels.Validate(rule.Labels); err != nil {
+		return errors.NewValidationError("labels", err.Error())
+	}
+	return nil
+}
+
+func validNamePrefix(prefix string) bool {
+	for _, r := range prefix {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeNameByHostPort returns the name of the node registered at host:port, or ""
+func (s *DiscoveryService) nodeNameByHostPort(host string, port int) string {
+	var node models.Node
+	if err := s.db.Select("name").Where("host = ? AND port = ?", host, port).First(&node).Error; err != nil {
+		return ""
+	}
+	return node.Name
+}
+
+// EnabledRules returns the enabled discovery rules in evaluation order.
+func (s *DiscoveryService) EnabledRules() ([]*models.DiscoveryRule, error) {
+	var rules []*models.DiscoveryRule
+	err := s.db.Where("enabled = ?", true).Order("priority, id").Find(&rules).Error
+	return rules, err
+}
+
+// ListRules returns all discovery rules in evaluation order.
+func (s *DiscoveryService) ListRules() ([]*models.DiscoveryRule, error) {
+	var rules []*models.DiscoveryRule
+	if err := s.db.Order("priority, id").Find(&rules).Error; err != nil {
+		return nil, errors.NewDatabaseError("list discovery rules", err)
+	}
+	return rules, nil
+}
+
+// CreateRule validates and saves a discovery rule.
+func (s *DiscoveryService) CreateRule(rule *models.DiscoveryRule) error {
+	if err := validateDiscoveryRule(rule); err != nil {
+		return err
+	}
+	enabled := rule.Enabled
+	if err := s.db.Create(rule).Error; err != nil {
+		return errors.NewDatabaseError("create discovery rule", err)
+	}
+	// gorm skips zero values for columns with defaults
+	if !enabled {
+		rule.Enabled = false
+		return s.db.Model(rule).Update("enabled", false).Error
+	}
+	return nil
+}
+
+// UpdateRule replaces the settings of a discovery rule.
+func (s *DiscoveryService) UpdateRule(id uint, rule *models.DiscoveryRule) (*models.DiscoveryRule, error) {
+	var existing models.DiscoveryRule
+	if err := s.db.First(&existing, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("discovery_rule", fmt.Sprint(id))
+		}
+		return nil, errors.NewDatabaseError("get discovery rule", err)
+	}
+	if err := validateDiscoveryRule(rule); err != nil {
+		return nil, err
+	}
+	rule.ID = existing.ID
+	rule.CreatedAt = existing.CreatedAt
+	if err := s.db.Select("*").Omit("created_at").Save(rule).Error; err != nil {
+		return nil, errors.NewDatabaseError("update discovery rule", err)
+	}
+	return rule, nil
+}
+
+// DeleteRule deletes a discovery rule.
+func (s *DiscoveryService) DeleteRule(id uint) error {
+	result := s.db.Delete(&models.DiscoveryRule{}, id)
+	if result.Error != nil {
+		return errors.NewDatabaseError("delete discovery rule", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewNotFoundError("discovery_rule", fmt.Sprint(id))
+	}
+	return nil
+}
+
+// validateDiscoverySchedule checks a schedule before it is saved. Hostnames and
+// SRV names are only resolved when the schedule runs.
+func validateDiscoverySchedule(schedule *models.DiscoverySchedule) error {
+	if strings.TrimSpace(schedule.Name) == "" {
+		return errors.NewValidationError("name", "name is required")
+	}
+	if _, err := discoveryCronParser.Parse(schedule.CronExpr); err != nil {
+		return errors.NewValidationError("cron_expr", err.Error())
+	}
+	if schedule.CIDR == "" && schedule.Targets.Empty() {
+		return errors.NewValidationError("targets", "cidr or targets is required")
+	}
+	if schedule.CIDR != "" {
+		if _, err := utils.ParseCIDR(schedule.CIDR); err != nil {
+			return errors.NewValidationError("cidr", err.Error())
+		}
+	}
+	if schedule.Port == 0 && schedule.Ports == "" {
+		return errors.NewValidationError("port", "port or ports is required")
+	}
+	if schedule.Port != 0 && (schedule.Port < MinPort || schedule.Port > MaxPort) {
+		return errors.NewValidationError("port", "port must be between 1 and 65535")
+	}
+	if schedule.Ports != "" {
+		if _, err := utils.ParsePortList(schedule.Ports); err != nil {
+			return errors.NewValidationError("ports", err.Error())
+		}
+	}
+	if schedule.Username == "" {
+		return errors.NewValidationError("username", "username is required")
+	}
+	if schedule.RateLimit < 0 || schedule.RateLimit > MaxRateLimit {
+		return errors.NewValidationError("rate_limit", fmt.Sprintf("rate_limit must be between 0 and %d", MaxRateLimit))
+	}
+	return nil
+}
+
+// ListSchedules returns all discovery schedules.
+func (s *DiscoveryService) ListSchedules() ([]*models.DiscoverySchedule, error) {
+	var schedules []*models.DiscoverySchedule
+	if err := s.db.Order("id").Find(&schedules).Error; err != nil {
+		return nil, errors.NewDatabaseError("list discovery schedules", err)
+	}
+	return schedules, nil
+}
+
+// GetSchedule returns a discovery schedule by ID.
+func (s *DiscoveryService) GetSchedule(id uint) (*models.DiscoverySchedule, error) {
+	var schedule models.DiscoverySchedule
+	if err := s.db.First(&schedule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("discovery_schedule", fmt.Sprint(id))
+		}
+		return nil, errors.NewDatabaseError("get discovery schedule", err)
+	}
+	return &schedule, nil
+}
+
+// CreateSchedule validates and saves a schedule and adds it to the running cron.
+func (s *DiscoveryService) CreateSchedule(schedule *models.DiscoverySchedule) error {
+	if err := validateDiscoverySchedule(schedule); err != nil {
+		return err
+	}
+	if schedule.Password == "" {
+		return errors.NewValidationError("password", "password is required")
+	}
+	var count int64
+	s.db.Model(&models.DiscoverySchedule{}).Where("name = ?", schedule.Name).Count(&count)
+	if count > 0 {
+		return errors.NewConflictError("discovery_schedule", "schedule name already exists")
+	}
+
+	enabled := schedule.Enabled
+	if err := s.db.Create(schedule).Error; err != nil {
+		return errors.NewDatabaseError("create discovery schedule", err)
+	}
+	// gorm skips zero values for columns with defaults
+	if !enabled {
+		schedule.Enabled = false
+		if err := s.db.Model(schedule).Update("enabled", false).Error; err != nil {
+			return errors.NewDatabaseError("create discovery schedule", err)
+		}
+	}
+	s.reloadSchedule(schedule)
+	return nil
+}
+
+// UpdateSchedule replaces the settings of a schedule. An empty password keeps
+// the stored one.
+func (s *DiscoveryService) UpdateSchedule(id uint, schedule *models.DiscoverySchedule) (*models.DiscoverySchedule, error) {
+	existing, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDiscoverySchedule(schedule); err != nil {
+		return nil, err
+	}
+	var count int64
+	s.db.Model(&models.DiscoverySchedule{}).Where("name = ? AND id <> ?", schedule.Name, id).Count(&count)
+	if count > 0 {
+		return nil, errors.NewConflictError("discovery_schedule", "schedule name already exists")
+	}
+
+	schedule.ID = existing.ID
+	schedule.CreatedAt = existing.CreatedAt
+	schedule.CreatedBy = existing.CreatedBy
+	schedule.LastRunAt = existing.LastRunAt
+	schedule.LastTaskID = existing.LastTaskID
+	schedule.LastError = existing.LastError
+	if schedule.Password == "" {
+		schedule.Password = existing.Password
+	}
+	if err := s.db.Select("*").Omit("created_at").Save(schedule).Error; err != nil {
+		return nil, errors.NewDatabaseError("update discovery schedule", err)
+	}
+	s.reloadSchedule(schedule)
+	return schedule, nil
+}
+
+// DeleteSchedule deletes a schedule and removes it from the cron. Tasks and
+// reports it created are kept.
+func (s *DiscoveryService) DeleteSchedule(id uint) error {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(schedule).Error; err != nil {
+		return errors.NewDatabaseError("delete discovery schedule", err)
+	}
+	schedule.Enabled = false
+	s.reloadSchedule(schedule)
+	return nil
+}
+
+// RunSchedule starts a discovery task for the schedule right away. Runs do not
+// overlap: if the previous task is still active a ConflictError is returned.
+func (s *DiscoveryService) RunSchedule(id uint) (*models.DiscoveryTask, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.LastTaskID != nil {
+		if last, err := s.repo.GetTask(*schedule.LastTaskID); err == nil && !last.IsTerminal() {
+			return nil, errors.NewConflictError("discovery_schedule",
+				fmt.Sprintf("previous run (task %d) is still %s", last.ID, last.Status))
+		}
+	}
+
+	task, err := s.StartDiscovery(&DiscoveryRequest{
+		CIDR:           schedule.CIDR,
+		Port:           schedule.Port,
+		Targets:        schedule.Targets,
+		Ports:          schedule.Ports,
+		Username:       schedule.Username,
+		Password:       schedule.Password,
+		TimeoutSeconds: schedule.TimeoutSeconds,
+		MaxWorkers:     schedule.MaxWorkers,
+		RateLimit:      schedule.RateLimit,
+		CreatedBy:      schedule.CreatedBy,
+		ScheduleID:     &schedule.ID,
+
+		RequireApproval: schedule.RequireApproval,
+	})
+
+	now := time.Now()
+	updates := map[string]interface{}{"last_run_at": &now, "last_error": ""}
+	if err != nil {
+		message := err.Error()
+		if len(message) > 500 {
+			message = message[:500]
+		}
+		updates["last_error"] = message
+	} else {
+		updates["last_task_id"] = task.ID
+	}
+	if dbErr := s.db.Model(schedule).Updates(updates).Error; dbErr != nil {
+		logger.Error("Failed to update discovery schedule after run",
+			zap.Uint("schedule_id", schedule.ID),
+			zap.Error(dbErr))
+	}
+	return task, err
+}
+
+// runScheduled is the cron job of a schedule
+func (s *DiscoveryService) runScheduled(id uint) {
+	task, err := s.RunSchedule(id)
+	if err != nil {
+		logger.Warn("Scheduled discovery did not start",
+			zap.Uint("schedule_id", id),
+			zap.Error(err))
+		if s.activityLogService != nil {
+			message := fmt.Sprintf("Scheduled discovery %d did not start: %s", id, err.Error())
+			s.activityLogService.LogSystemEvent("WARNING", "discovery_schedule_failed", "discovery", fmt.Sprintf("schedule-%d", id), message, nil)
+		}
+		return
+	}
+	logger.Info("Scheduled discovery started",
+		zap.Uint("schedule_id", id),
+		zap.Uint("task_id", task.ID))
+}
+
+// startSchedules loads enabled schedules into a new cron. Called when this
+// instance becomes leader.
+func (s *DiscoveryService) startSchedules() {
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+	if s.cron != nil {
+		return
+	}
+
+	var schedules []*models.DiscoverySchedule
+	if err := s.db.Where("enabled = ?", true).Find(&schedules).Error; err != nil {
+		logger.Error("Failed to load discovery schedules", zap.Error(err))
+		return
+	}
+
+	s.cron = cron.New(cron.WithParser(discoveryCronParser))
+	s.cronEntries = make(map[uint]cron.EntryID)
+	for _, schedule := range schedules {
+		s.addCronEntry(schedule)
+	}
+	s.cron.Start()
+	logger.Info("Discovery schedules started", zap.Int("schedules", len(schedules)))
+}
+
+// stopSchedules stops the cron. Called when this instance loses leadership.
+func (s *DiscoveryService) stopSchedules() {
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+	if s.cron == nil {
+		return
+	}
+	<-s.cron.Stop().Done()
+	s.cron = nil
+	s.cronEntries = nil
+	logger.Info("Discovery schedules stopped")
+}
+
+// reloadSchedule replaces the cron entry of a schedule after it changed
+func (s *DiscoveryService) reloadSchedule(schedule *models.DiscoverySchedule) {
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+	if s.cron == nil {
+		return
+	}
+	if entryID, ok := s.cronEntries[schedule.ID]; ok {
+		s.cron.Remove(entryID)
+		delete(s.cronEntries, schedule.ID)
+	}
+	if schedule.Enabled {
+		s.addCronEntry(schedule)
+	}
+}
+
+// addCronEntry adds a schedule to the cron (caller holds scheduleMu)
+func (s *DiscoveryService) addCronEntry(schedule *models.DiscoverySchedule) {
+	id := schedule.ID
+	entryID, err := s.cron.AddFunc(schedule.CronExpr, func() { s.runScheduled(id) })
+	if err != nil {
+		logger.Error("Failed to add discovery schedule",
+			zap.Uint("schedule_id", id),
+			zap.String("cron_expr", schedule.CronExpr),
+			zap.Error(err))
+		return
+	}
+	s.cronEntries[id] = entryID
+}
+
+// CreateReport compares a finished scheduled task with the previous completed
+// task of the same schedule and saves the differences.
+func (s *DiscoveryService) CreateReport(scheduleID, taskID uint) (*models.DiscoveryReport, error) {
+	current, err := s.successfulResults(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.DiscoveryReport{
+		ScheduleID: scheduleID,
+		TaskID:     taskID,
+		Added:      []models.DiscoveryChange{},
+		Vanished:   []models.DiscoveryChange{},
+		Changed:    []models.DiscoveryChange{},
+	}
+
+	var baseline models.DiscoveryTask
+	err = s.db.Where("schedule_id = ? AND id < ? AND status = ?", scheduleID, taskID, models.DiscoveryStatusCompleted).
+		Order("id DESC").First(&baseline).Error
+	previous := map[string]*models.DiscoveryResult{}
+	if err == nil {
+		report.BaselineTaskID = &baseline.ID
+		if previous, err = s.successfulResults(baseline.ID); err != nil {
+			return nil, err
+		}
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	for _, key := range sortedResultKeys(current) {
+		result := current[key]
+		before, seen := previous[key]
+		switch {
+		case !seen:
+			report.Added = append(report.Added, discoveryChange(result))
+		case before.Version != result.Version:
+			change := discoveryChange(result)
+			change.PreviousVersion = before.Version
+			report.Changed = append(report.Changed, change)
+		}
+	}
+	for _, key := range sortedResultKeys(previous) {
+		if _, ok := current[key]; !ok {
+			report.Vanished = append(report.Vanished, discoveryChange(previous[key]))
+		}
+	}
+
+	if err := s.db.Create(report).Error; err != nil {
+		return nil, err
+	}
+
+	if report.HasChanges() {
+		message := fmt.Sprintf("Scheduled discovery task %d: %d new, %d vanished, %d version changes",
+			taskID, len(report.Added), len(report.Vanished), len(report.Changed))
+		if s.activityLogService != nil {
+			s.activityLogService.LogSystemEvent("INFO", "discovery_report", "discovery", fmt.Sprintf("schedule-%d", scheduleID), message, nil)
+		}
+		s.broadcastEvent(EventTypeDiscoveryReport, report)
+	}
+	return report, nil
+}
+
+// successfulResults returns the successful probes of a task keyed by address:port
+func (s *DiscoveryService) successfulResults(taskID uint) (map[string]*models.DiscoveryResult, error) {
+	var results []*models.DiscoveryResult
+	if err := s.db.Where("task_id = ? AND status = ?", taskID, models.ResultStatusSuccess).Find(&results).Error; err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*models.DiscoveryResult, len(results))
+	for _, result := range results {
+		address := result.Host
+		if address == "" {
+			address = result.IP
+		}
+		byKey[fmt.Sprintf("%s:%d", address, result.Port)] = result
+	}
+	return byKey, nil
+}
+
+func sortedResultKeys(results map[string]*models.DiscoveryResult) []string {
+	keys := make([]string, 0, len(results))
+	for key := range results {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func discoveryChange(result *models.DiscoveryResult) models.DiscoveryChange {
+	return models.DiscoveryChange{
+		Host:     result.Host,
+		IP:       result.IP,
+		Port:     result.Port,
+		Version:  result.Version,
+		NodeName: result.NodeName,
+		Action:   result.Action,
+	}
+}
+
+// broadcastEvent sends a discovery event via WebSocket
+func (s *DiscoveryService) broadcastEvent(eventType string, payload interface{}) {
+	if s.hub == nil {
+		return
+	}
+	data, err := json.Marshal(struct {
+		Type   string      `json:"type"`
+		Topics []string    `json:"topics"`
+		Data   interface{} `json:"data"`
+	}{Type: eventType, Topics: []string{"discovery"}, Data: payload})
+	if err != nil {
+		logger.Error("Failed to marshal discovery event", zap.String("type", eventType), zap.Error(err))
+		return
+	}
+	s.hub.Broadcast(data)
+}
+
+// ListReports returns reports of a schedule, newest first. scheduleID 0 lists all.
+func (s *DiscoveryService) ListReports(scheduleID uint, offset, limit int) ([]*models.DiscoveryReport, int64, error) {
+	query := s.db.Model(&models.DiscoveryReport{})
+	if scheduleID != 0 {
+		query = query.Where("schedule_id = ?", scheduleID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.NewDatabaseError("count discovery reports", err)
+	}
+	var reports []*models.DiscoveryReport
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&reports).Error; err != nil {
+		return nil, 0, errors.NewDatabaseError("list discovery reports", err)
+	}
+	return reports, total, nil
+}
+
+// GetReport returns a report by ID.
+func (s *DiscoveryService) GetReport(id uint) (*models.DiscoveryReport, error) {
+	var report models.DiscoveryReport
+	if err := s.db.First(&report, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("discovery_report", fmt.Sprint(id))
+		}
+		return nil, errors.NewDatabaseError("get discovery report", err)
+	}
+	return &report, nil
+}
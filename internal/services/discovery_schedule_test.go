@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"superview/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newScheduleTestService(t *testing.T) *DiscoveryService {
+	db := setupDiscoveryTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.DiscoverySchedule{}, &models.DiscoveryRule{}, &models.DiscoveryReport{},
+		&models.DiscoveryCandidate{}, &models.DiscoveryBlocklistEntry{}))
+	return NewDiscoveryService(db, newMockDiscoveryRepository(db), newMockNodeRepository(db), &mockWebSocketHub{}, nil)
+}
+
+func TestMatchDiscoveryRule(t *testing.T) {
+	rules := []*models.DiscoveryRule{
+		{Name: "disabled", Enabled: false, Action: models.DiscoveryActionIgnore},
+		{Name: "prod-hosts", Enabled: true, HostPattern: "*.prod.example.com", Action: models.DiscoveryActionRegister},
+		{Name: "lab", Enabled: true, CIDR: "10.9.0.0/16", Action: models.DiscoveryActionIgnore},
+		{Name: "rest", Enabled: true, Action: models.DiscoveryActionApprove},
+	}
+
+	assert.Equal(t, "prod-hosts", MatchDiscoveryRule(rules, "App-1.Prod.example.com", "10.9.0.1").Name)
+	assert.Equal(t, "lab", MatchDiscoveryRule(rules, "", "10.9.3.4").Name)
+	assert.Equal(t, "rest", MatchDiscoveryRule(rules, "", "10.1.0.1").Name)
+	assert.Nil(t, MatchDiscoveryRule(rules[:3], "", "10.1.0.1"))
+}
+
+func TestRegisterDiscoveredNodeWithRules(t *testing.T) {
+	s := newScheduleTestService(t)
+	scanner := NewScanner(s)
+	rules := []*models.DiscoveryRule{
+		{Name: "prod", Enabled: true, CIDR: "10.1.0.0/16", Action: models.DiscoveryActionRegister,
+			Environment: "prod", NamePrefix: "prod-", Labels: map[string]string{"role": "worker"}},
+		{Name: "review", Enabled: true, CIDR: "10.2.0.0/16", Action: models.DiscoveryActionApprove},
+		{Name: "skip", Enabled: true, CIDR: "10.3.0.0/16", Action: models.DiscoveryActionIgnore},
+	}
+	policy := &registrationPolicy{rules: rules}
+
+	probe := &ProbeTask{IP: "10.1.0.5", Port: 9001, Status: models.ResultStatusSuccess}
+	scanner.registerDiscoveredNode(context.Background(), 1, probe, policy, "u", "p")
+	assert.Equal(t, models.ResultActionRegistered, probe.Action)
+	assert.Equal(t, "prod", probe.Rule)
+	assert.Equal(t, "prod-10-1-0-5", probe.NodeName)
+	node, err := s.nodeRepo.GetByName("prod-10-1-0-5")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", node.Environment)
+	assert.Equal(t, map[string]string{"role": "worker"}, node.GetLabels())
+
+	again := &ProbeTask{IP: "10.1.0.5", Port: 9001, Status: models.ResultStatusSuccess}
+	scanner.registerDiscoveredNode(context.Background(), 1, again, policy, "u", "p")
+	assert.Equal(t, models.ResultActionExisting, again.Action)
+	assert.Equal(t, "prod-10-1-0-5", again.NodeName)
+
+	for ip, action := range map[string]string{"10.2.0.5": models.ResultActionPending, "10.3.0.5": models.ResultActionIgnored} {
+		probe := &ProbeTask{IP: ip, Port: 9001, Status: models.ResultStatusSuccess}
+		scanner.registerDiscoveredNode(context.Background(), 1, probe, policy, "u", "p")
+		assert.Equal(t, action, probe.Action, ip)
+		exists, _ := s.nodeRepo.ExistsByHostPort(ip, 9001)
+		assert.False(t, exists, ip)
+	}
+
+	probe = &ProbeTask{IP: "10.4.0.5", Port: 9001, Status: models.ResultStatusSuccess}
+	scanner.registerDiscoveredNode(context.Background(), 1, probe, policy, "u", "p")
+	assert.Equal(t, "node-10-4-0-5", probe.NodeName, "defaults without a matching rule")
+	node, err = s.nodeRepo.GetByName("node-10-4-0-5")
+	require.NoError(t, err)
+	assert.Equal(t, "discovered", node.Environment)
+}
+
+func TestDiscoveryReport(t *testing.T) {
+	s := newScheduleTestService(t)
+	scheduleID := uint(7)
+
+	createRun := func(results ...models.DiscoveryResult) uint {
+		task := &models.DiscoveryTask{CIDR: "10.0.0.0/24", Port: 9001, Username: "u", CreatedBy: "tester",
+			Status: models.DiscoveryStatusCompleted, ScheduleID: &scheduleID}
+		require.NoError(t, s.db.Create(task).Error)
+		for _, result := range results {
+			result.TaskID = task.ID
+			result.Status = models.ResultStatusSuccess
+			require.NoError(t, s.db.Create(&result).Error)
+		}
+		return task.ID
+	}
+
+	first := createRun(
+		models.DiscoveryResult{IP: "10.0.0.1", Port: 9001, Version: "4.2.1"},
+		models.DiscoveryResult{IP: "10.0.0.2", Port: 9001, Version: "4.2.1"},
+	)
+	report, err := s.CreateReport(scheduleID, first)
+	require.NoError(t, err)
+	assert.Nil(t, report.BaselineTaskID)
+	assert.Len(t, report.Added, 2, "everything is new on the first run")
+
+	second := createRun(
+		models.DiscoveryResult{IP: "10.0.0.1", Port: 9001, Version: "4.2.5"},
+		models.DiscoveryResult{Host: "app-3.example.com", IP: "10.0.0.3", Port: 9001, Version: "4.2.5", Action: models.ResultActionPending},
+	)
+	report, err = s.CreateReport(scheduleID, second)
+	require.NoError(t, err)
+	require.NotNil(t, report.BaselineTaskID)
+	assert.Equal(t, first, *report.BaselineTaskID)
+	require.Len(t, report.Added, 1)
+	assert.Equal(t, "app-3.example.com", report.Added[0].Host)
+	assert.Equal(t, models.ResultActionPending, report.Added[0].Action)
+	require.Len(t, report.Vanished, 1)
+	assert.Equal(t, "10.0.0.2", report.Vanished[0].IP)
+	require.Len(t, report.Changed, 1)
+	assert.Equal(t, "4.2.1", report.Changed[0].PreviousVersion)
+	assert.Equal(t, "4.2.5", report.Changed[0].Version)
+
+	reports, total, err := s.ListReports(scheduleID, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, second, reports[0].TaskID)
+}
+
+func TestValidateDiscoveryRule(t *testing.T) {
+	rule := &models.DiscoveryRule{Name: "prod", Action: models.DiscoveryActionRegister, CIDR: "10.1.0.0/16",
+		Labels: map[string]string{"role": "worker"}}
+	assert.NoError(t, validateDiscoveryRule(rule))
+
+	rule.Labels = map[string]string{"bad key!": "worker"}
+	assert.Error(t, validateDiscoveryRule(rule))
+	rule.Labels = nil
+	rule.Action = "delete"
+	assert.Error(t, validateDiscoveryRule(rule))
+}
+
+func TestDiscoveryScheduleLifecycle(t *testing.T) {
+	s := newScheduleTestService(t)
+	s.startSchedules()
+	defer s.stopSchedules()
+
+	schedule := &models.DiscoverySchedule{
+		Name: "nightly", CronExpr: "0 3 * * *", CIDR: "10.0.0.0/30", Port: 9001,
+		Username: "u", Password: "p", CreatedBy: "tester", Enabled: true,
+	}
+	require.NoError(t, s.CreateSchedule(schedule))
+	assert.Len(t, s.cronEntries, 1)
+
+	duplicate := *schedule
+	duplicate.ID = 0
+	assert.Error(t, s.CreateSchedule(&duplicate), "duplicate name")
+
+	invalid := *schedule
+	invalid.Name, invalid.CronExpr = "bad", "every day"
+	assert.Error(t, s.CreateSchedule(&invalid))
+
+	update := *schedule
+	update.Enabled = false
+	update.Password = ""
+	updated, err := s.UpdateSchedule(schedule.ID, &update)
+	require.NoError(t, err)
+	assert.Equal(t, "p", updated.Password, "empty password keeps the stored one")
+	assert.Empty(t, s.cronEntries)
+
+	// Runs do not overlap
+	running := &models.DiscoveryTask{CIDR: "10.0.0.0/30", Port: 9001, Username: "u", CreatedBy: "tester",
+		Status: models.DiscoveryStatusRunning, ScheduleID: &schedule.ID}
+	require.NoError(t, s.repo.CreateTask(running))
+	require.NoError(t, s.db.Model(schedule).Update("last_task_id", running.ID).Error)
+	_, err = s.RunSchedule(schedule.ID)
+	assert.Error(t, err)
+
+	running.Status = models.DiscoveryStatusCompleted
+	require.NoError(t, s.repo.UpdateTask(running))
+	task, err := s.RunSchedule(schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, schedule.ID, *task.ScheduleID)
+	s.CancelDiscovery(task.ID)
+	stored, err := s.GetSchedule(schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, task.ID, *stored.LastTaskID)
+	assert.NotNil(t, stored.LastRunAt)
+
+	require.NoError(t, s.DeleteSchedule(schedule.ID))
+	_, err = s.GetSchedule(schedule.ID)
+	assert.Error(t, err)
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"superview/internal/errors"
+	"superview/internal/logger"
+	"superview/internal/models"
+	"superview/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// MaxDiscoveryProbes is the maximum number of host:port probes in one task
+const MaxDiscoveryProbes = utils.MaxCIDRAddresses
+
+// TargetResolver resolves hostnames and SRV records. *net.Resolver implements it.
+type TargetResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// ProbeTarget is a single address to probe. Host is the hostname the IP was
+// resolved from and is empty for targets given as addresses.
+type ProbeTarget struct {
+	Host string
+	IP   string
+	Port int
+}
+
+// Address returns the host used to connect to and register the node.
+func (t ProbeTarget) Address() string {
+	if t.Host != "" {
+		return t.Host
+	}
+	return t.IP
+}
+
+// targetSet collects probe targets deduplicated by IP and port, keeping input order.
+// When the same IP:port is reached by address and by hostname the hostname is kept.
+type targetSet struct {
+	targets      []ProbeTarget
+	index        map[string]int
+	excluded     map[string]bool
+	excludedNets []*utils.CIDRRange
+	names        map[string]bool
+}
+
+func newTargetSet() *targetSet {
+	return &targetSet{index: make(map[string]int), excluded: make(map[string]bool), names: make(map[string]bool)}
+}
+
+func (s *targetSet) isExcluded(target ProbeTarget) bool {
+	if s.excluded[target.IP] || (target.Host != "" && s.names[strings.ToLower(target.Host)]) {
+		return true
+	}
+	for _, network := range s.excludedNets {
+		if network.Contains(target.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *targetSet) add(target ProbeTarget) error {
+	if s.isExcluded(target) {
+		return nil
+	}
+	key := net.JoinHostPort(target.IP, fmt.Sprint(target.Port))
+	if i, ok := s.index[key]; ok {
+		if s.targets[i].Host == "" {
+			s.targets[i].Host = target.Host
+		}
+		return nil
+	}
+	if len(s.targets) >= MaxDiscoveryProbes {
+		return errors.NewValidationError("targets", fmt.Sprintf("scan exceeds maximum of %d host:port probes", MaxDiscoveryProbes))
+	}
+	s.index[key] = len(s.targets)
+	s.targets = append(s.targets, target)
+	return nil
+}
+
+// ExpandDiscoveryTargets turns CIDRs, IP ranges, hostname patterns and SRV names into
+// a deduplicated list of probe targets. Every address is combined with every port;
+// SRV records carry their own port. Names that fail to resolve are skipped and
+// reported in the returned warnings.
+func ExpandDiscoveryTargets(ctx context.Context, resolver TargetResolver, targets *models.DiscoveryTargets, ports []int) ([]ProbeTarget, []string, error) {
+	set := newTargetSet()
+	var warnings []string
+
+	for _, spec := range targets.Exclude {
+		spec = strings.TrimSpace(spec)
+		if strings.Contains(spec, "/") {
+			network, err := utils.ParseCIDR(spec)
+			if err != nil {
+				return nil, nil, errors.NewValidationError("exclude", err.Error())
+			}
+			set.excludedNets = append(set.excludedNets, network)
+			continue
+		}
+		ips, names, err := parseAddressSpec(spec)
+		if err != nil {
+			return nil, nil, errors.NewValidationError("exclude", err.Error())
+		}
+		for _, ip := range ips {
+			set.excluded[ip] = true
+		}
+		for _, name := range names {
+			set.names[strings.ToLower(name)] = true
+			resolved, err := resolver.LookupHost(ctx, name)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("exclude %s: %v", name, err))
+				continue
+			}
+			for _, ip := range resolved {
+				set.excluded[ip] = true
+			}
+		}
+	}
+
+	addIPs := func(ips []string, host string) error {
+		for _, ip := range ips {
+			for _, port := range ports {
+				if err := set.add(ProbeTarget{Host: host, IP: ip, Port: port}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, cidr := range targets.CIDRs {
+		cidrRange, err := utils.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, nil, errors.NewValidationError("cidrs", err.Error())
+		}
+		if err := addIPs(cidrRange.IPs(), ""); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, spec := range targets.IPRanges {
+		ips, err := utils.ParseIPRange(spec)
+		if err != nil {
+			return nil, nil, errors.NewValidationError("ip_ranges", err.Error())
+		}
+		if err := addIPs(ips, ""); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, pattern := range targets.Hostnames {
+		hosts, err := utils.ExpandHostPattern(pattern)
+		if err != nil {
+			return nil, nil, errors.NewValidationError("hostnames", err.Error())
+		}
+		for _, host := range hosts {
+			ips, err := lookupIPv4(ctx, resolver, host)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("hostname %s: %v", host, err))
+				continue
+			}
+			if err := addIPs(ips, host); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	for _, name := range targets.SRV {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		_, records, err := resolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("srv %s: %v", name, err))
+			continue
+		}
+		for _, record := range records {
+			host := strings.TrimSuffix(record.Target, ".")
+			ips, err := lookupIPv4(ctx, resolver, host)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("srv %s target %s: %v", name, host, err))
+				continue
+			}
+			for _, ip := range ips {
+				if err := set.add(ProbeTarget{Host: host, IP: ip, Port: int(record.Port)}); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	for _, warning := range warnings {
+		logger.Warn("Discovery target skipped", zap.String("reason", warning))
+	}
+	return set.targets, warnings, nil
+}
+
+// parseAddressSpec parses an exclude entry other than a CIDR: an IP, IP range or hostname
+func parseAddressSpec(spec string) ([]string, []string, error) {
+	switch {
+	case net.ParseIP(spec) != nil:
+		return []string{spec}, nil, nil
+	case strings.Contains(spec, "-") && net.ParseIP(strings.SplitN(spec, "-", 2)[0]) != nil:
+		ips, err := utils.ParseIPRange(spec)
+		return ips, nil, err
+	default:
+		hosts, err := utils.ExpandHostPattern(spec)
+		return nil, hosts, err
+	}
+}
+
+// lookupIPv4 resolves a hostname to its IPv4 addresses; the scanner only supports IPv4
+func lookupIPv4(ctx context.Context, resolver TargetResolver, host string) ([]string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() == nil {
+			return nil, fmt.Errorf("only IPv4 addresses are supported")
+		}
+		return []string{ip.String()}, nil
+	}
+	addrs, err := resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var ips []string
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil && ip.To4() != nil {
+			ips = append(ips, ip.To4().String())
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no IPv4 addresses")
+	}
+	return ips, nil
+}
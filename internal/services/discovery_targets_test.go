@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"superview/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver resolves names from static tables
+type fakeResolver struct {
+	hosts map[string][]string
+	srv   map[string][]*net.SRV
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, fmt.Errorf("no such host")
+}
+
+func (r *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if records, ok := r.srv[name]; ok {
+		return name, records, nil
+	}
+	return "", nil, fmt.Errorf("no such host")
+}
+
+func newFakeResolver() *fakeResolver {
+	return &fakeResolver{
+		hosts: map[string][]string{
+			"app-1.example.com": {"10.0.0.1"},
+			"app-2.example.com": {"10.0.0.2", "fd00::2"},
+			"sv-a.example.com":  {"10.0.1.1"},
+			"skip.example.com":  {"10.0.0.3"},
+		},
+		srv: map[string][]*net.SRV{
+			"_supervisor._tcp.example.com": {
+				{Target: "sv-a.example.com.", Port: 9101},
+				{Target: "app-1.example.com.", Port: 9001},
+			},
+		},
+	}
+}
+
+func TestExpandDiscoveryTargets(t *testing.T) {
+	targets := &models.DiscoveryTargets{
+		CIDRs:     []string{"10.0.0.0/30"},
+		IPRanges:  []string{"10.0.0.2-4"},
+		Hostnames: []string{"app-[1-3].example.com"},
+		SRV:       []string{"_supervisor._tcp.example.com"},
+		Exclude:   []string{"10.0.0.4", "skip.example.com"},
+	}
+
+	probes, warnings, err := ExpandDiscoveryTargets(context.Background(), newFakeResolver(), targets, []int{9001, 9002})
+	require.NoError(t, err)
+
+	var got []string
+	for _, probe := range probes {
+		got = append(got, fmt.Sprintf("%s/%s:%d", probe.Host, probe.IP, probe.Port))
+	}
+	assert.Equal(t, []string{
+		"app-1.example.com/10.0.0.1:9001", "app-1.example.com/10.0.0.1:9002",
+		"app-2.example.com/10.0.0.2:9001", "app-2.example.com/10.0.0.2:9002",
+		"sv-a.example.com/10.0.1.1:9101",
+	}, got, "addresses are deduplicated by IP:port, hostnames win and excluded IPs are dropped")
+	assert.Equal(t, []string{"hostname app-3.example.com: no such host"}, warnings)
+	assert.Equal(t, "app-1.example.com", probes[0].Address())
+}
+
+func TestExpandDiscoveryTargetsExcludeCIDR(t *testing.T) {
+	targets := &models.DiscoveryTargets{
+		CIDRs:   []string{"10.0.0.0/29"},
+		Exclude: []string{"10.0.0.0/30", "10.0.0.6-7"},
+	}
+	probes, _, err := ExpandDiscoveryTargets(context.Background(), newFakeResolver(), targets, []int{9001})
+	require.NoError(t, err)
+	require.Len(t, probes, 2)
+	assert.Equal(t, "10.0.0.4", probes[0].IP)
+	assert.Equal(t, "10.0.0.5", probes[1].IP)
+
+	_, _, err = ExpandDiscoveryTargets(context.Background(), newFakeResolver(),
+		&models.DiscoveryTargets{Hostnames: []string{"bad host"}}, []int{9001})
+	assert.Error(t, err)
+}
+
+func TestStartDiscoveryWithTargets(t *testing.T) {
+	db := setupDiscoveryTestDB(t)
+	service := NewDiscoveryService(db, newMockDiscoveryRepository(db), newMockNodeRepository(db), &mockWebSocketHub{}, nil)
+	service.resolver = newFakeResolver()
+
+	task, err := service.StartDiscovery(&DiscoveryRequest{
+		Targets:   &models.DiscoveryTargets{Hostnames: []string{"app-[1-2].example.com"}},
+		Ports:     "9001-9003",
+		Username:  "admin",
+		Password:  "secret",
+		RateLimit: 100,
+		CreatedBy: "tester",
+	})
+	require.NoError(t, err)
+	defer service.CancelDiscovery(task.ID)
+
+	assert.Equal(t, 6, task.TotalIPs)
+	assert.Equal(t, 9001, task.Port)
+	assert.Equal(t, "9001,9002,9003", task.Ports)
+	assert.Equal(t, "app-[1-2].example.com", task.Scope())
+
+	_, err = service.StartDiscovery(&DiscoveryRequest{
+		Targets:   &models.DiscoveryTargets{Hostnames: []string{"missing.example.com"}},
+		Port:      9001,
+		Username:  "admin",
+		Password:  "secret",
+		CreatedBy: "tester",
+	})
+	assert.Error(t, err, "nothing resolvable to scan")
+
+	_, err = service.StartDiscovery(&DiscoveryRequest{
+		CIDR:      "10.0.0.0/30",
+		Port:      9001,
+		Username:  "admin",
+		Password:  "secret",
+		RateLimit: -1,
+		CreatedBy: "tester",
+	})
+	assert.Error(t, err)
+}
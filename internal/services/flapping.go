@@ -0,0 +1,307 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	appErrors "superview/internal/errors"
+	"superview/internal/logger"
+	"superview/internal/models"
+	"superview/internal/supervisor"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// FlappingService 处理进程抖动：创建抖动告警，按配置停止并隔离进程，隔离期间拒绝启动，直到有人确认
+type FlappingService struct {
+	db                 *gorm.DB
+	alertService       *AlertService
+	supervisorService  *supervisor.SupervisorService
+	activityLogService *ActivityLogService
+	hub                WebSocketHub
+	quarantine         bool
+}
+
+// NewFlappingService 创建服务并注册到 supervisorService 的抖动回调和启动检查
+func NewFlappingService(db *gorm.DB, alertService *AlertService, supervisorService *supervisor.SupervisorService,
+	activityLogService *ActivityLogService, hub WebSocketHub, quarantine bool) *FlappingService {
+	s := &FlappingService{
+		db:                 db,
+		alertService:       alertService,
+		supervisorService:  supervisorService,
+		activityLogService: activityLogService,
+		hub:                hub,
+		quarantine:         quarantine,
+	}
+	if supervisorService != nil {
+		supervisorService.OnProcessFlapping(s.HandleFlapEvent)
+		supervisorService.SetStartGuard(s.CheckStart)
+	}
+	return s
+}
+
+// HandleFlapEvent 进程开始抖动时告警（并按配置隔离），停止抖动且未被隔离时解决告警
+func (s *FlappingService) HandleFlapEvent(event supervisor.FlapEvent) {
+	if !event.Flapping {
+		quarantined, err := s.IsQuarantined(event.NodeName, event.ProcessName)
+		if err != nil || quarantined {
+			return
+		}
+		if err := s.alertService.ResolveProcessFlappingAlert(event.NodeName, event.ProcessName); err != nil {
+			logger.Error("Failed to resolve process flapping alert",
+				zap.String("node_name", event.NodeName),
+				zap.String("process_name", event.ProcessName),
+				zap.Error(err))
+			return
+		}
+		s.broadcast("alert_resolved", event.NodeName, event.ProcessName, nil)
+		return
+	}
+
+	message := fmt.Sprintf("Process '%s' on node '%s' is flapping: %d restarts within %s",
+		event.ProcessName, event.NodeName, event.Restarts, event.WindowText)
+	logger.Warn("Process flapping detected",
+		zap.String("node_name", event.NodeName),
+		zap.String("process_name", event.ProcessName),
+		zap.Int("restarts", event.Restarts),
+		zap.String("window", event.WindowText),
+		zap.Float64("score", event.Score))
+
+	alert, err := s.alertService.CreateProcessFlappingAlert(event.NodeName, event.ProcessName, message, event.Score)
+	if err != nil {
+		logger.Error("Failed to create process flapping alert",
+			zap.String("node_name", event.NodeName),
+			zap.String("process_name", event.ProcessName),
+			zap.Error(err))
+	} else {
+		s.broadcast("alert_created", event.NodeName, event.ProcessName, nil)
+	}
+
+	if s.activityLogService != nil {
+		s.activityLogService.LogSystemEvent("WARNING", "process_flapping", "process",
+			event.NodeName+":"+event.ProcessName, message, event.FlapStatus)
+	}
+
+	if s.quarantine {
+		if err := s.quarantineProcess(event.FlapStatus, message, alert); err != nil {
+			logger.Error("Failed to quarantine flapping process",
+				zap.String("node_name", event.NodeName),
+				zap.String("process_name", event.ProcessName),
+				zap.Error(err))
+		}
+	}
+}
+
+// quarantineProcess 停止进程并记录隔离
+func (s *FlappingService) quarantineProcess(status supervisor.FlapStatus, reason string, alert *models.Alert) error {
+	quarantined, err := s.IsQuarantined(status.NodeName, status.ProcessName)
+	if err != nil || quarantined {
+		return err
+	}
+
+	fullName := status.ProcessName
+	if status.Group != "" && status.Group != status.ProcessName {
+		fullName = status.Group + ":" + status.ProcessName
+	}
+	if err := s.supervisorService.StopProcess(status.NodeName, fullName); err != nil && !strings.Contains(err.Error(), "NOT_RUNNING") {
+		return fmt.Errorf("stop process: %w", err)
+	}
+
+	record := &models.ProcessQuarantine{
+		NodeName:      status.NodeName,
+		ProcessName:   status.ProcessName,
+		Reason:        reason,
+		Score:         status.Score,
+		Restarts:      status.Restarts,
+		Window:        status.WindowText,
+		QuarantinedAt: time.Now(),
+	}
+	if alert != nil {
+		record.AlertID = &alert.ID
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		return err
+	}
+
+	logger.Warn("Flapping process stopped and quarantined",
+		zap.String("node_name", status.NodeName),
+		zap.String("process_name", status.ProcessName))
+	if s.activityLogService != nil {
+		s.activityLogService.LogSystemEvent("WARNING", "process_quarantined", "process",
+			status.NodeName+":"+status.ProcessName,
+			fmt.Sprintf("Process %s on node %s stopped and quarantined for flapping", status.ProcessName, status.NodeName), nil)
+	}
+	s.broadcast("process_quarantined", status.NodeName, status.ProcessName, record)
+	return nil
+}
+
+// IsQuarantined 进程是否处于未确认的隔离状态。processName 可以是 group:name 形式
+func (s *FlappingService) IsQuarantined(nodeName, processName string) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.ProcessQuarantine{}).
+		Where("node_name = ? AND process_name IN ? AND acknowledged_at IS NULL", nodeName, processNameVariants(processName)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CheckStart 启动进程前的检查，隔离中的进程返回冲突错误
+func (s *FlappingService) CheckStart(nodeName, processName string) error {
+	quarantined, err := s.IsQuarantined(nodeName, processName)
+	if err != nil {
+		return appErrors.NewDatabaseError("check process quarantine", err)
+	}
+	if quarantined {
+		return appErrors.NewConflictError("process",
+			fmt.Sprintf("process %s on node %s is quarantined for flapping; acknowledge the quarantine before starting it", processName, nodeName))
+	}
+	return nil
+}
+
+// ListQuarantines 列出隔离记录，activeOnly 为 true 时只返回未确认的
+func (s *FlappingService) ListQuarantines(activeOnly bool) ([]models.ProcessQuarantine, error) {
+	var records []models.ProcessQuarantine
+	query := s.db.Order("quarantined_at DESC")
+	if activeOnly {
+		query = query.Where("acknowledged_at IS NULL")
+	}
+	err := query.Limit(500).Find(&records).Error
+	return records, err
+}
+
+// Acknowledge 确认隔离：解除启动限制、解决抖动告警并清空重启统计。进程不会自动启动
+func (s *FlappingService) Acknowledge(nodeName, processName, username string) (*models.ProcessQuarantine, error) {
+	var record models.ProcessQuarantine
+	err := s.db.Where("node_name = ? AND process_name IN ? AND acknowledged_at IS NULL", nodeName, processNameVariants(processName)).
+		Order("quarantined_at DESC").First(&record).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, appErrors.NewNotFoundError("process quarantine", nodeName+":"+processName)
+	}
+	if err != nil {
+		return nil, appErrors.NewDatabaseError("get process quarantine", err)
+	}
+
+	now := time.Now()
+	err = s.db.Model(&models.ProcessQuarantine{}).
+		Where("node_name = ? AND process_name = ? AND acknowledged_at IS NULL", record.NodeName, record.ProcessName).
+		Updates(map[string]interface{}{"acknowledged_at": now, "acknowledged_by": username}).Error
+	if err != nil {
+		return nil, appErrors.NewDatabaseError("acknowledge process quarantine", err)
+	}
+	record.AcknowledgedAt = &now
+	record.AcknowledgedBy = username
+
+	if err := s.alertService.ResolveProcessFlappingAlert(record.NodeName, record.ProcessName); err != nil {
+		logger.Error("Failed to resolve process flapping alert",
+			zap.String("node_name", record.NodeName),
+			zap.String("process_name", record.ProcessName),
+			zap.Error(err))
+	}
+	if s.supervisorService != nil {
+		s.supervisorService.ResetFlapping(record.NodeName, record.ProcessName)
+	}
+	s.broadcast("process_quarantine_released", record.NodeName, record.ProcessName, &record)
+	return &record, nil
+}
+
+// processNameVariants 隔离记录使用进程名，API 可能传入 group:name
+func processNameVariants(processName string) []string {
+	names := []string{processName}
+	if i := strings.LastIndex(processName, ":"); i >= 0 && i < len(processName)-1 {
+		names = append(names, processName[i+1:])
+	}
+	return names
+}
+
+// broadcast 推送抖动和隔离事件到告警和节点主题
+func (s *FlappingService) broadcast(eventType, nodeName, processName string, quarantine *models.ProcessQuarantine) {
+	if s.hub == nil {
+		return
+	}
+	event := map[string]interface{}{
+		"type":         eventType,
+		"topics":       alertTopics(models.AlertSeverityHigh, nodeName),
+		"node_name":    nodeName,
+		"process_name": processName,
+		"severity":     models.AlertSeverityHigh,
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}
+	if quarantine != nil {
+		event["quarantine"] = quarantine
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("Failed to marshal flapping event", zap.String("event_type", eventType), zap.Error(err))
+		return
+	}
+	s.hub.Broadcast(data)
+}
+
+// flappingRuleID 抖动告警使用的系统规则，不存在时创建
+func (s *AlertService) flappingRuleID() (uint, error) {
+	rule := models.AlertRule{
+		Name:        models.AlertRuleProcessFlapping,
+		Description: "Process restarted too often within the configured flapping windows",
+		Metric:      "process_status",
+		Condition:   ">=",
+		Threshold:   1,
+		Duration:    1,
+		Severity:    models.AlertSeverityHigh,
+		Enabled:     true,
+		CreatedBy:   "system",
+	}
+	err := s.db.Where("name = ?", rule.Name).Attrs(rule).FirstOrCreate(&rule).Error
+	return rule.ID, err
+}
+
+// CreateProcessFlappingAlert 创建进程抖动告警，已有未解决的告警时更新消息和分数
+func (s *AlertService) CreateProcessFlappingAlert(nodeName, processName, message string, score float64) (*models.Alert, error) {
+	ruleID, err := s.flappingRuleID()
+	if err != nil {
+		return nil, err
+	}
+
+	var existing models.Alert
+	err = s.db.Where("rule_id = ? AND node_name = ? AND process_name = ? AND status IN (?, ?)",
+		ruleID, nodeName, processName, models.AlertStatusActive, models.AlertStatusAcknowledged).
+		First(&existing).Error
+	if err == nil {
+		s.db.Model(&existing).Updates(map[string]interface{}{"message": message, "value": score})
+		return &existing, nil
+	}
+
+	alert := &models.Alert{
+		RuleID:      ruleID,
+		NodeName:    nodeName,
+		ProcessName: &processName,
+		Message:     message,
+		Severity:    models.AlertSeverityHigh,
+		Status:      models.AlertStatusActive,
+		Value:       score,
+		StartTime:   time.Now(),
+	}
+	if err := s.db.Create(alert).Error; err != nil {
+		return nil, err
+	}
+	s.notify(alert)
+	return alert, nil
+}
+
+// ResolveProcessFlappingAlert 解决进程抖动告警
+func (s *AlertService) ResolveProcessFlappingAlert(nodeName, processName string) error {
+	ruleID, err := s.flappingRuleID()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return s.db.Model(&models.Alert{}).
+		Where("rule_id = ? AND node_name = ? AND process_name = ? AND status IN (?, ?)",
+			ruleID, nodeName, processName, models.AlertStatusActive, models.AlertStatusAcknowledged).
+		Updates(map[string]interface{}{
+			"status":      models.AlertStatusResolved,
+			"end_time":    now,
+			"resolved_at": now,
+		}).Error
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	appErrors "superview/internal/errors"
+	"superview/internal/labels"
+	"superview/internal/models"
+	"superview/internal/supervisor"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newFlappingTestService(t *testing.T) (*FlappingService, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "superview.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Node{}, &models.AlertRule{}, &models.Alert{}, &models.ProcessQuarantine{}))
+	return NewFlappingService(db, NewAlertService(db), nil, nil, nil, false), db
+}
+
+func flapEvent(flapping bool) supervisor.FlapEvent {
+	return supervisor.FlapEvent{
+		FlapStatus: supervisor.FlapStatus{
+			NodeName: "web-1", ProcessName: "api", Group: "api",
+			Score: 1.2, Restarts: 6, Window: 10 * time.Minute, WindowText: "10m0s", Flapping: flapping,
+		},
+		Time: time.Now(),
+	}
+}
+
+func activeFlappingAlerts(t *testing.T, db *gorm.DB) []models.Alert {
+	var alerts []models.Alert
+	require.NoError(t, db.Joins("JOIN alert_rules ON alert_rules.id = alerts.rule_id").
+		Where("alert_rules.name = ? AND alerts.status = ?", models.AlertRuleProcessFlapping, models.AlertStatusActive).
+		Find(&alerts).Error)
+	return alerts
+}
+
+func TestFlappingServiceAlertLifecycle(t *testing.T) {
+	s, db := newFlappingTestService(t)
+
+	s.HandleFlapEvent(flapEvent(true))
+	s.HandleFlapEvent(flapEvent(true))
+	alerts := activeFlappingAlerts(t, db)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, models.AlertSeverityHigh, alerts[0].Severity)
+	assert.Contains(t, alerts[0].Message, "6 restarts within 10m0s")
+
+	// 未开启隔离时进程可以继续启动
+	assert.NoError(t, s.CheckStart("web-1", "api"))
+
+	s.HandleFlapEvent(flapEvent(false))
+	assert.Empty(t, activeFlappingAlerts(t, db))
+}
+
+func TestFlappingServiceQuarantineAcknowledge(t *testing.T) {
+	s, db := newFlappingTestService(t)
+
+	s.HandleFlapEvent(flapEvent(true))
+	require.NoError(t, db.Create(&models.ProcessQuarantine{
+		NodeName: "web-1", ProcessName: "api", Reason: "flapping", QuarantinedAt: time.Now(),
+	}).Error)
+
+	err := s.CheckStart("web-1", "api:api")
+	assert.True(t, appErrors.IsConflictError(err))
+
+	// 隔离期间停止抖动不会解决告警
+	s.HandleFlapEvent(flapEvent(false))
+	assert.Len(t, activeFlappingAlerts(t, db), 1)
+
+	record, err := s.Acknowledge("web-1", "api", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", record.AcknowledgedBy)
+	assert.NotNil(t, record.AcknowledgedAt)
+	assert.NoError(t, s.CheckStart("web-1", "api"))
+	assert.Empty(t, activeFlappingAlerts(t, db))
+
+	_, err = s.Acknowledge("web-1", "api", "alice")
+	assert.True(t, appErrors.IsNotFoundError(err))
+
+	quarantines, err := s.ListQuarantines(true)
+	require.NoError(t, err)
+	assert.Empty(t, quarantines)
+}
+
+// fakeSupervisord 只返回一个 api 进程的 supervisord XML-RPC 服务，记录收到的方法调用
+type fakeSupervisord struct {
+	mu      sync.Mutex
+	methods []string
+}
+
+func (f *fakeSupervisord) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	method := string(body)
+	if start := strings.Index(method, "<methodName>"); start >= 0 {
+		method = method[start+len("<methodName>"):]
+		method = method[:strings.Index(method, "</methodName>")]
+	}
+	f.mu.Lock()
+	f.methods = append(f.methods, method)
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/xml")
+	if method == "supervisor.getAllProcessInfo" {
+		io.WriteString(w, `<?xml version="1.0"?><methodResponse><params><param><value><array><data>`+
+			`<value><struct>`+
+			`<member><name>name</name><value><string>api</string></value></member>`+
+			`<member><name>group</name><value><string>api</string></value></member>`+
+			`<member><name>statename</name><value><string>STOPPED</string></value></member>`+
+			`<member><name>state</name><value><int>0</int></value></member>`+
+			`</struct></value>`+
+			`</data></array></value></param></params></methodResponse>`)
+		return
+	}
+	io.WriteString(w, `<?xml version="1.0"?><methodResponse><params><param><value><boolean>1</boolean></value></param></params></methodResponse>`)
+}
+
+func (f *fakeSupervisord) calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.methods...)
+}
+
+func TestQuarantineBlocksEveryStartPath(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "superview.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Node{}, &models.AlertRule{}, &models.Alert{}, &models.ProcessQuarantine{},
+		&models.ScheduledTask{}, &models.SystemSettings{}))
+
+	supervisord := &fakeSupervisord{}
+	server := httptest.NewServer(supervisord)
+	defer server.Close()
+	address, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(address.Port())
+	require.NoError(t, err)
+
+	supervisorService := supervisor.NewSupervisorService()
+	defer supervisorService.Shutdown(context.Background())
+	require.NoError(t, supervisorService.AddNode("web-1", "prod", address.Hostname(), port, "", ""))
+	// 启动检查在节点加入之后注册，同样作用于已有节点
+	NewFlappingService(db, NewAlertService(db), supervisorService, nil, nil, true)
+	require.NoError(t, db.Create(&models.ProcessQuarantine{
+		NodeName: "web-1", ProcessName: "api", Reason: "flapping", QuarantinedAt: time.Now(),
+	}).Error)
+
+	processService := NewProcessEnhancedService(db)
+	processService.SetSupervisorService(supervisorService)
+	task := &models.ScheduledTask{Name: "nightly restart", TaskType: models.TaskTypeRestart,
+		TargetType: models.TargetTypeProcess, TargetID: "api"}
+	output, err := processService.executeRestartTask(task)
+	require.Error(t, err)
+	assert.Contains(t, output, "quarantined")
+
+	assert.True(t, appErrors.IsConflictError(supervisorService.StartProcess("web-1", "api")))
+	assert.Error(t, supervisorService.StartAllProcesses("web-1"))
+	require.NoError(t, supervisorService.RestartAllProcesses("web-1"))
+	require.NoError(t, supervisorService.RestartGroupProcesses("api", "", labels.Selector{}))
+
+	for _, method := range supervisord.calls() {
+		assert.Equal(t, "supervisor.getAllProcessInfo", method, "quarantined process must not be stopped or started")
+	}
+}
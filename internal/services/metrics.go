@@ -0,0 +1,24 @@
+package services
+
+import "superview/internal/metrics/instrument"
+
+// 发现扫描指标，由 /metrics 端点输出
+var (
+	discoveryScans = instrument.NewCounterVec("superview_discovery_scans_total",
+		"Finished discovery scans by outcome", "status")
+	discoveryProbes = instrument.NewCounterVec("superview_discovery_probes_total",
+		"Probed discovery targets by result", "result")
+	discoveryScanDuration = instrument.NewHistogramVec("superview_discovery_scan_duration_seconds",
+		"Wall-clock duration of discovery scans", []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800}, "status")
+)
+
+func init() {
+	instrument.MustRegister(discoveryScans, discoveryProbes, discoveryScanDuration)
+}
+
+// 扫描结果标签
+const (
+	scanOutcomeCompleted = "completed"
+	scanOutcomeFailed    = "failed"
+	scanOutcomeCancelled = "cancelled"
+)
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"superview/internal/logger"
+	"superview/internal/models"
+	"superview/internal/promql"
+	"superview/internal/supervisor"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 历史指标名称。进程指标来自 process_metrics 表，告警数量按告警的起止时间推算
+const (
+	metricProcessCPUPercent    = "superview_process_cpu_percent"
+	metricProcessMemoryBytes   = "superview_process_memory_bytes"
+	metricProcessMemoryPercent = "superview_process_memory_percent"
+	metricProcessOpenFiles     = "superview_process_open_files"
+	metricProcessConnections   = "superview_process_connections"
+	metricProcessUptime        = "superview_process_uptime_seconds"
+	metricProcessRestarts      = "superview_process_restarts_total"
+	metricAlertsActive         = "superview_alerts_active"
+)
+
+// processMetricColumns 每个进程历史指标对应的取值方式。resource 为 true 的列只有通过 API 上报
+// 资源占用的记录才有值，MetricsHistoryRecorder 写入的快照不参与这些序列
+var processMetricColumns = []struct {
+	name     string
+	resource bool
+	value    func(m *models.ProcessMetrics) float64
+}{
+	{metricProcessCPUPercent, true, func(m *models.ProcessMetrics) float64 { return m.CPUPercent }},
+	{metricProcessMemoryBytes, true, func(m *models.ProcessMetrics) float64 { return m.MemoryMB * 1024 * 1024 }},
+	{metricProcessMemoryPercent, true, func(m *models.ProcessMetrics) float64 { return m.MemoryPercent }},
+	{metricProcessOpenFiles, true, func(m *models.ProcessMetrics) float64 { return float64(m.OpenFiles) }},
+	{metricProcessConnections, true, func(m *models.ProcessMetrics) float64 { return float64(m.Connections) }},
+	{metricProcessUptime, false, func(m *models.ProcessMetrics) float64 { return float64(m.Uptime) }},
+	{metricProcessRestarts, false, func(m *models.ProcessMetrics) float64 { return float64(m.Restarts) }},
+}
+
+// hasResourceUsage 记录是否带有资源占用数据
+func hasResourceUsage(m *models.ProcessMetrics) bool {
+	return m.CPUPercent != 0 || m.MemoryMB != 0 || m.MemoryPercent != 0 || m.OpenFiles != 0 || m.Connections != 0
+}
+
+// MetricsHistoryStore 以 process_metrics、system_metrics 和 alerts 表作为 PromQL 查询的数据源：
+//   - superview_process_*{node,process}：进程指标历史
+//   - superview_<metric_type>_<metric_name>{node,process,unit}：系统指标，名称中的非法字符替换为下划线
+//   - superview_alerts_active{node,severity}：各时间点未解决的告警数量
+type MetricsHistoryStore struct {
+	db *gorm.DB
+}
+
+// NewMetricsHistoryStore 创建历史指标数据源
+func NewMetricsHistoryStore(db *gorm.DB) *MetricsHistoryStore {
+	return &MetricsHistoryStore{db: db}
+}
+
+// Select 实现 promql.Storage
+func (s *MetricsHistoryStore) Select(hints promql.SelectHints, matchers []*promql.Matcher) ([]promql.Series, error) {
+	from := hints.Start.Add(-hints.Lookback - hints.Range)
+	to := hints.End
+
+	nodeNames, err := s.nodeNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []promql.Series
+	for _, load := range []func(time.Time, time.Time, map[uint]string, []*promql.Matcher) ([]promql.Series, error){
+		s.selectProcessMetrics,
+		s.selectSystemMetrics,
+	} {
+		series, err := load(from, to, nodeNames, matchers)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, series...)
+	}
+
+	if nameMatches(matchers, metricAlertsActive) {
+		series, err := s.selectActiveAlerts(hints, from, matchers)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, series...)
+	}
+	return result, nil
+}
+
+// LabelNames 所有历史指标可能出现的标签名称
+func (s *MetricsHistoryStore) LabelNames() []string {
+	return []string{promql.MetricNameLabel, "node", "process", "severity", "unit"}
+}
+
+// MetricNames 当前可以查询的指标名称
+func (s *MetricsHistoryStore) MetricNames() ([]string, error) {
+	names := []string{metricAlertsActive}
+	for _, column := range processMetricColumns {
+		names = append(names, column.name)
+	}
+
+	var types []struct {
+		MetricType string
+		MetricName string
+	}
+	if err := s.db.Model(&models.SystemMetric{}).Distinct("metric_type", "metric_name").Find(&types).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range types {
+		names = append(names, systemMetricName(t.MetricType, t.MetricName))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// nodeNames 节点 ID 到名称的映射，包括已删除的节点，以便查询其历史数据
+func (s *MetricsHistoryStore) nodeNames() (map[uint]string, error) {
+	var nodes []models.Node
+	if err := s.db.Unscoped().Select("id", "name").Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(nodes))
+	for _, node := range nodes {
+		names[node.ID] = node.Name
+	}
+	return names, nil
+}
+
+func (s *MetricsHistoryStore) selectProcessMetrics(from, to time.Time, nodeNames map[uint]string, matchers []*promql.Matcher) ([]promql.Series, error) {
+	var wanted []int
+	for i, column := range processMetricColumns {
+		if nameMatches(matchers, column.name) {
+			wanted = append(wanted, i)
+		}
+	}
+	if len(wanted) == 0 {
+		return nil, nil
+	}
+
+	query := s.db.Where("timestamp > ? AND timestamp <= ?", from, to)
+	if process, ok := equalityValue(matchers, "process"); ok {
+		query = query.Where("process_name = ?", process)
+	}
+	var rows []models.ProcessMetrics
+	if err := query.Order("timestamp").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	builder := newSeriesBuilder(matchers)
+	for i := range rows {
+		row := &rows[i]
+		sampled := hasResourceUsage(row)
+		for _, idx := range wanted {
+			column := processMetricColumns[idx]
+			if column.resource && !sampled {
+				continue
+			}
+			builder.add(promql.Labels{
+				promql.MetricNameLabel: column.name,
+				"node":                 nodeNames[row.NodeID],
+				"process":              row.ProcessName,
+			}, row.Timestamp, column.value(row))
+		}
+	}
+	return builder.series(), nil
+}
+
+func (s *MetricsHistoryStore) selectSystemMetrics(from, to time.Time, nodeNames map[uint]string, matchers []*promql.Matcher) ([]promql.Series, error) {
+	if name, ok := equalityValue(matchers, promql.MetricNameLabel); ok && !strings.HasPrefix(name, "superview_") {
+		return nil, nil
+	}
+
+	var rows []models.SystemMetric
+	if err := s.db.Where("timestamp > ? AND timestamp <= ?", from, to).Order("timestamp").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	builder := newSeriesBuilder(matchers)
+	for _, row := range rows {
+		labels := promql.Labels{promql.MetricNameLabel: systemMetricName(row.MetricType, row.MetricName)}
+		if row.NodeID != nil {
+			labels["node"] = nodeNames[*row.NodeID]
+		}
+		if row.ProcessName != nil && *row.ProcessName != "" {
+			labels["process"] = *row.ProcessName
+		}
+		if row.Unit != "" {
+			labels["unit"] = row.Unit
+		}
+		builder.add(labels, row.Timestamp, row.Value)
+	}
+	return builder.series(), nil
+}
+
+// selectActiveAlerts 在查询的步长网格上统计未解决的告警数量。出现过告警的 node/severity
+// 组合在没有告警的时间点上为 0，便于画出连续的曲线
+func (s *MetricsHistoryStore) selectActiveAlerts(hints promql.SelectHints, from time.Time, matchers []*promql.Matcher) ([]promql.Series, error) {
+	var alerts []models.Alert
+	err := s.db.Unscoped().
+		Select("node_name", "severity", "status", "start_time", "end_time", "resolved_at").
+		Where("start_time <= ?", hints.End).
+		Where("(end_time IS NULL OR end_time > ?) AND (resolved_at IS NULL OR resolved_at > ?)", from, from).
+		Find(&alerts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	type group struct {
+		labels promql.Labels
+		alerts []models.Alert
+	}
+	groups := make(map[string]*group)
+	var keys []string
+	for _, alert := range alerts {
+		labels := promql.Labels{promql.MetricNameLabel: metricAlertsActive, "node": alert.NodeName, "severity": alert.Severity}
+		if !promql.MatchLabels(labels, matchers) {
+			continue
+		}
+		key := alert.NodeName + "\xff" + alert.Severity
+		g, ok := groups[key]
+		if !ok {
+			g = &group{labels: labels}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.alerts = append(g.alerts, alert)
+	}
+	sort.Strings(keys)
+
+	step := hints.Step
+	if step <= 0 {
+		step = time.Minute
+	}
+	// 与查询引擎的求值时间点对齐：Start + k*step，向前延伸到 from
+	first := hints.Start
+	for first.Add(-step).After(from) {
+		first = first.Add(-step)
+	}
+
+	result := make([]promql.Series, 0, len(keys))
+	for _, key := range keys {
+		g := groups[key]
+		series := promql.Series{Labels: g.labels}
+		for t := first; !t.After(hints.End); t = t.Add(step) {
+			var active float64
+			for _, alert := range g.alerts {
+				if alertActiveAt(alert, t) {
+					active++
+				}
+			}
+			series.Points = append(series.Points, promql.Point{T: t.UnixMilli(), V: active})
+		}
+		result = append(result, series)
+	}
+	return result, nil
+}
+
+// alertActiveAt 告警在时间点 t 是否处于未解决状态
+func alertActiveAt(alert models.Alert, t time.Time) bool {
+	if alert.StartTime.After(t) {
+		return false
+	}
+	end := alert.EndTime
+	if end == nil {
+		end = alert.ResolvedAt
+	}
+	if end == nil {
+		return alert.Status != models.AlertStatusResolved
+	}
+	return end.After(t)
+}
+
+// seriesBuilder 按标签集合归并采样点，只保留满足匹配条件的序列
+type seriesBuilder struct {
+	matchers []*promql.Matcher
+	index    map[string]int
+	result   []promql.Series
+}
+
+func newSeriesBuilder(matchers []*promql.Matcher) *seriesBuilder {
+	return &seriesBuilder{matchers: matchers, index: make(map[string]int)}
+}
+
+func (b *seriesBuilder) add(labels promql.Labels, ts time.Time, value float64) {
+	if !promql.MatchLabels(labels, b.matchers) {
+		return
+	}
+	key := labels[promql.MetricNameLabel] + "\xff" + labels["node"] + "\xff" + labels["process"] + "\xff" + labels["unit"]
+	i, ok := b.index[key]
+	if !ok {
+		i = len(b.result)
+		b.index[key] = i
+		b.result = append(b.result, promql.Series{Labels: labels})
+	}
+	point := promql.Point{T: ts.UnixMilli(), V: value}
+	points := b.result[i].Points
+	// 同一毫秒的重复记录以最后一条为准
+	if n := len(points); n > 0 && points[n-1].T == point.T {
+		points[n-1] = point
+		return
+	}
+	b.result[i].Points = append(points, point)
+}
+
+func (b *seriesBuilder) series() []promql.Series {
+	return b.result
+}
+
+var invalidMetricChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// systemMetricName 系统指标在查询中使用的名称，如 cpu/usage → superview_cpu_usage
+func systemMetricName(metricType, metricName string) string {
+	name := "superview_" + strings.ToLower(metricType) + "_" + strings.ToLower(metricName)
+	return strings.Trim(invalidMetricChars.ReplaceAllString(name, "_"), "_")
+}
+
+// nameMatches 指标名称是否满足 __name__ 上的匹配条件
+func nameMatches(matchers []*promql.Matcher, name string) bool {
+	for _, m := range matchers {
+		if m.Name == promql.MetricNameLabel && !m.Matches(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalityValue 标签上的等值匹配条件，用于缩小数据库查询范围
+func equalityValue(matchers []*promql.Matcher, name string) (string, bool) {
+	for _, m := range matchers {
+		if m.Name == name && m.Type == promql.MatchEqual {
+			return m.Value, true
+		}
+	}
+	return "", false
+}
+
+// MetricsHistoryRecorder 定期把各节点进程的运行时间和重启次数写入 process_metrics，
+// 并清理超过保留时长的记录。多实例部署时只在主节点运行
+type MetricsHistoryRecorder struct {
+	db                *gorm.DB
+	supervisorService *supervisor.SupervisorService
+	interval          time.Duration
+	retention         time.Duration
+
+	runMu    sync.Mutex
+	running  bool
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewMetricsHistoryRecorder 创建记录器，interval 为 0 时 Start 不做任何事
+func NewMetricsHistoryRecorder(db *gorm.DB, supervisorService *supervisor.SupervisorService, interval, retention time.Duration) *MetricsHistoryRecorder {
+	return &MetricsHistoryRecorder{
+		db:                db,
+		supervisorService: supervisorService,
+		interval:          interval,
+		retention:         retention,
+	}
+}
+
+// Start 启动记录（可在 Stop 之后再次启动）
+func (r *MetricsHistoryRecorder) Start() {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+	if r.running || r.interval <= 0 {
+		return
+	}
+	r.stopChan = make(chan struct{})
+	r.running = true
+	r.wg.Add(1)
+	go r.loop(r.stopChan)
+	logger.Info("Metrics history recorder started",
+		zap.Duration("interval", r.interval), zap.Duration("retention", r.retention))
+}
+
+// Stop 停止记录
+func (r *MetricsHistoryRecorder) Stop() {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+	if !r.running {
+		return
+	}
+	close(r.stopChan)
+	r.wg.Wait()
+	r.running = false
+	logger.Info("Metrics history recorder stopped")
+}
+
+func (r *MetricsHistoryRecorder) loop(stopChan chan struct{}) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if err := r.Record(now); err != nil {
+				logger.Warn("Failed to record metrics history", zap.Error(err))
+			}
+			if err := r.Cleanup(now); err != nil {
+				logger.Warn("Failed to clean up metrics history", zap.Error(err))
+			}
+		case <-stopChan:
+			return
+		}
+	}
+}
+
+// Record 记录一次所有在线节点（维护中的除外）的进程快照
+func (r *MetricsHistoryRecorder) Record(now time.Time) error {
+	var nodes []models.Node
+	if err := r.db.Select("id", "name").Find(&nodes).Error; err != nil {
+		return err
+	}
+	nodeIDs := make(map[string]uint, len(nodes))
+	for _, node := range nodes {
+		nodeIDs[node.Name] = node.ID
+	}
+
+	var rows []models.ProcessMetrics
+	for _, node := range r.supervisorService.GetAllNodes() {
+		nodeID, ok := nodeIDs[node.Name]
+		if !ok || node.InMaintenance() {
+			continue
+		}
+		if connected, _ := node.GetConnectionStatus(); !connected {
+			continue
+		}
+		for _, proc := range node.SerializeProcesses() {
+			name, _ := proc["name"].(string)
+			pid, _ := proc["pid"].(int)
+			uptime, _ := proc["uptime"].(float64)
+			rows = append(rows, models.ProcessMetrics{
+				ProcessName: name,
+				NodeID:      nodeID,
+				PID:         pid,
+				Uptime:      int(uptime),
+				Restarts:    supervisor.ObservedRestarts(node.Name, name),
+				Timestamp:   now,
+			})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(rows, 100).Error
+}
+
+// Cleanup 删除超过保留时长的进程指标
+func (r *MetricsHistoryRecorder) Cleanup(now time.Time) error {
+	if r.retention <= 0 {
+		return nil
+	}
+	return r.db.Where("timestamp < ?", now.Add(-r.retention)).Delete(&models.ProcessMetrics{}).Error
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"superview/internal/models"
+	"superview/internal/promql"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newMetricsHistoryTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "superview.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Node{}, &models.ProcessMetrics{}, &models.SystemMetric{},
+		&models.AlertRule{}, &models.Alert{}))
+	require.NoError(t, db.Create(&models.Node{Name: "web-1", Host: "10.0.0.1", Port: 9001}).Error)
+	require.NoError(t, db.Create(&models.Node{Name: "web-2", Host: "10.0.0.2", Port: 9001}).Error)
+	return db
+}
+
+func TestMetricsHistoryProcessRestarts(t *testing.T) {
+	db := newMetricsHistoryTestDB(t)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, restarts := range []int{0, 1, 1, 3} {
+		ts := base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, db.Create(&models.ProcessMetrics{ProcessName: "api", NodeID: 1, Restarts: restarts, Uptime: 60, Timestamp: ts}).Error)
+		require.NoError(t, db.Create(&models.ProcessMetrics{ProcessName: "api", NodeID: 2, Restarts: 0, Uptime: 60 * (i + 1), Timestamp: ts}).Error)
+	}
+
+	engine := promql.NewEngine(NewMetricsHistoryStore(db))
+	value, err := engine.Instant(`sum by (node) (increase(superview_process_restarts_total{process="api"}[3m]))`, base.Add(3*time.Minute))
+	require.NoError(t, err)
+	vector := value.(promql.Vector)
+	require.Len(t, vector, 2)
+	assert.Equal(t, promql.Labels{"node": "web-1"}, vector[0].Labels)
+	assert.InDelta(t, 3.0, vector[0].V, 1e-9)
+	assert.InDelta(t, 0.0, vector[1].V, 1e-9)
+
+	// 快照记录没有资源占用数据，不产生 CPU 序列
+	value, err = engine.Instant(`superview_process_cpu_percent`, base.Add(3*time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, value.(promql.Vector), 0)
+
+	matrix, err := engine.Range(`superview_process_uptime_seconds{node=~"web-2"}`, base, base.Add(3*time.Minute), time.Minute)
+	require.NoError(t, err)
+	require.Len(t, matrix, 1)
+	assert.Len(t, matrix[0].Points, 4)
+	assert.Equal(t, 240.0, matrix[0].Points[3].V)
+}
+
+func TestMetricsHistorySystemMetricsAndAlerts(t *testing.T) {
+	db := newMetricsHistoryTestDB(t)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	nodeID := uint(1)
+	require.NoError(t, db.Create(&models.SystemMetric{NodeID: &nodeID, MetricType: "disk", MetricName: "usage.root", Value: 42, Unit: "%", Timestamp: base}).Error)
+
+	rule := models.AlertRule{Name: "process down", Metric: "process_status", Condition: "==", Threshold: 0, Duration: 60, Severity: models.AlertSeverityHigh, CreatedBy: "admin"}
+	require.NoError(t, db.Create(&rule).Error)
+	resolvedAt := base.Add(2 * time.Minute)
+	require.NoError(t, db.Create(&models.Alert{RuleID: rule.ID, NodeName: "web-1", Message: "down", Severity: models.AlertSeverityHigh,
+		Status: models.AlertStatusResolved, StartTime: base, EndTime: &resolvedAt, ResolvedAt: &resolvedAt}).Error)
+	require.NoError(t, db.Create(&models.Alert{RuleID: rule.ID, NodeName: "web-1", Message: "down again", Severity: models.AlertSeverityHigh,
+		Status: models.AlertStatusActive, StartTime: base.Add(time.Minute)}).Error)
+
+	store := NewMetricsHistoryStore(db)
+	names, err := store.MetricNames()
+	require.NoError(t, err)
+	assert.Contains(t, names, "superview_disk_usage_root")
+
+	engine := promql.NewEngine(store)
+	value, err := engine.Instant(`superview_disk_usage_root`, base.Add(time.Minute))
+	require.NoError(t, err)
+	vector := value.(promql.Vector)
+	require.Len(t, vector, 1)
+	assert.Equal(t, "web-1", vector[0].Labels["node"])
+	assert.Equal(t, "%", vector[0].Labels["unit"])
+
+	matrix, err := engine.Range(`sum by (severity) (superview_alerts_active)`, base.Add(-time.Minute), base.Add(3*time.Minute), time.Minute)
+	require.NoError(t, err)
+	require.Len(t, matrix, 1)
+	var values []float64
+	for _, p := range matrix[0].Points {
+		values = append(values, p.V)
+	}
+	assert.Equal(t, []float64{0, 1, 2, 1, 1}, values)
+}
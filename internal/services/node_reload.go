@@ -0,0 +1,599 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"superview/internal/config"
+	"superview/internal/errors"
+	"superview/internal/labels"
+	"superview/internal/logger"
+	"superview/internal/models"
+	"superview/internal/supervisor"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 节点重载变更类型
+const (
+	NodeChangeAdd       = "add"       // 配置新增的节点
+	NodeChangeUpdate    = "update"    // 配置中连接参数或环境变化的节点
+	NodeChangeRemove    = "remove"    // 已从配置移除的节点
+	NodeChangeReconnect = "reconnect" // 数据库有但内存缺失或连接参数过期，只重建内存连接
+	NodeChangeUnload    = "unload"    // 内存有但数据库没有，只从内存卸载
+	NodeChangeSkip      = "skip"      // 与 API 删除、API/发现节点冲突，不处理
+)
+
+// NodeChange 单个节点的变更
+type NodeChange struct {
+	Action      string   `json:"action"`
+	Name        string   `json:"name"`
+	Host        string   `json:"host,omitempty"`
+	Port        int      `json:"port,omitempty"`
+	Environment string   `json:"environment,omitempty"`
+	Fields      []string `json:"fields,omitempty"` // update/reconnect 变化的字段，密码只标记不输出
+	Reason      string   `json:"reason,omitempty"`
+	Error       string   `json:"error,omitempty"` // 应用到内存失败的原因（数据库已更新）
+}
+
+// NodeReloadPlan 节点重载计划（dry-run 返回，或应用后的结果）
+type NodeReloadPlan struct {
+	Trigger string         `json:"trigger"`
+	DryRun  bool           `json:"dry_run"`
+	Changes []NodeChange   `json:"changes"`
+	Summary map[string]int `json:"summary"`
+}
+
+func newNodeReloadPlan(trigger string, dryRun bool, changes []NodeChange) *NodeReloadPlan {
+	if changes == nil {
+		changes = []NodeChange{}
+	}
+	plan := &NodeReloadPlan{Trigger: trigger, DryRun: dryRun, Changes: changes, Summary: make(map[string]int)}
+	for _, change := range changes {
+		plan.Summary[change.Action]++
+	}
+	return plan
+}
+
+// HasChanges 是否有需要应用的变更（skip 不算）
+func (p *NodeReloadPlan) HasChanges() bool {
+	for _, change := range p.Changes {
+		if change.Action != NodeChangeSkip {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeReloader 将配置文件中的节点列表同步到数据库和 SupervisorService
+// 只增删改 source=config 的节点；通过 API 删除的节点（软删除记录）不会被重新导入
+type NodeReloader struct {
+	db                 *gorm.DB
+	service            *supervisor.SupervisorService
+	load               func() ([]config.NodeConfig, error)
+	activityLogService *ActivityLogService
+	mu                 sync.Mutex // 串行化重载，SIGHUP、文件监听和 API 可能同时触发
+}
+
+// NewNodeReloader 创建节点重载器，load 读取当前配置文件中的节点列表
+func NewNodeReloader(db *gorm.DB, service *supervisor.SupervisorService, load func() ([]config.NodeConfig, error), activityLogService ...*ActivityLogService) *NodeReloader {
+	r := &NodeReloader{db: db, service: service, load: load}
+	if len(activityLogService) > 0 {
+		r.activityLogService = activityLogService[0]
+	}
+	return r
+}
+
+// Preview 读取配置文件并返回将要执行的变更，不做任何修改
+func (r *NodeReloader) Preview() (*NodeReloadPlan, error) {
+	nodes, err := r.loadNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.Node
+	if err := r.db.Unscoped().Find(&rows).Error; err != nil {
+		return nil, errors.NewDatabaseError("load nodes", err)
+	}
+	return newNodeReloadPlan("preview", true, planNodeReload(nodes, rows, r.memorySnapshot())), nil
+}
+
+// Reload 读取配置文件并应用变更
+func (r *NodeReloader) Reload(trigger string) (*NodeReloadPlan, error) {
+	nodes, err := r.loadNodes()
+	if err != nil {
+		return nil, err
+	}
+	return r.Apply(nodes, trigger)
+}
+
+// Apply 把 nodes 作为期望的配置节点列表应用：
+// 数据库变更在一个事务内完成，事务失败时不修改内存；提交后再逐个重建内存中的节点连接
+func (r *NodeReloader) Apply(nodes []config.NodeConfig, trigger string) (*NodeReloadPlan, error) {
+	if err := validateReloadNodes(nodes); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	memory := r.memorySnapshot()
+	var changes []NodeChange
+	rowsByName := make(map[string]models.Node)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var rows []models.Node
+		if err := tx.Unscoped().Find(&rows).Error; err != nil {
+			return err
+		}
+		changes = planNodeReload(nodes, rows, memory)
+
+		live := make(map[string]models.Node)
+		for _, row := range rows {
+			if !row.DeletedAt.Valid {
+				live[row.Name] = row
+			}
+		}
+
+		// 先删除再新增，配置中改名（同一 host:port）时不会冲突
+		for _, change := range changes {
+			if change.Action != NodeChangeRemove {
+				continue
+			}
+			// 硬删除：配置移除的节点之后重新加回配置时可以再次导入
+			if err := tx.Unscoped().Delete(&models.Node{}, live[change.Name].ID).Error; err != nil {
+				return err
+			}
+		}
+		for _, change := range changes {
+			desired, _ := findReloadNode(nodes, change.Name)
+			switch change.Action {
+			case NodeChangeAdd:
+				row := models.Node{
+					Name:        desired.Name,
+					Host:        desired.Host,
+					Port:        desired.Port,
+					Username:    desired.Username,
+					Password:    desired.Password,
+					Environment: desired.Environment,
+					Status:      "configured",
+					Source:      models.NodeSourceConfig,
+				}
+				row.SetLabels(desired.Labels)
+				if err := tx.Create(&row).Error; err != nil {
+					return err
+				}
+				rowsByName[row.Name] = row
+			case NodeChangeUpdate:
+				row := live[change.Name]
+				row.Host = desired.Host
+				row.Port = desired.Port
+				row.Username = desired.Username
+				row.Password = desired.Password
+				row.Environment = desired.Environment
+				if desired.Labels != nil {
+					row.SetLabels(desired.Labels)
+				}
+				if err := tx.Model(&row).Select("host", "port", "username", "password", "environment", "labels").Updates(&row).Error; err != nil {
+					return err
+				}
+				rowsByName[row.Name] = row
+			case NodeChangeReconnect:
+				rowsByName[change.Name] = live[change.Name]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Node reload failed, no changes applied", zap.String("trigger", trigger), zap.Error(err))
+		return nil, errors.NewDatabaseError("reload nodes", err)
+	}
+
+	for i := range changes {
+		if err := r.applyToService(&changes[i], rowsByName[changes[i].Name]); err != nil {
+			changes[i].Error = err.Error()
+			logger.Warn("Failed to apply node change to supervisor service",
+				zap.String("action", changes[i].Action),
+				zap.String("node", changes[i].Name),
+				zap.Error(err))
+		}
+		r.logChange(changes[i], trigger)
+	}
+
+	plan := newNodeReloadPlan(trigger, false, changes)
+	logger.Info("Node list reloaded",
+		zap.String("trigger", trigger),
+		zap.Any("summary", plan.Summary))
+	return plan, nil
+}
+
+// Sync 按数据库重建内存中的节点：加载缺失或连接参数过期的节点，卸载已删除的节点，同步维护模式
+// 多实例部署时每个实例定期调用，其他实例通过 API、发现审批或配置重载写入数据库的节点变更由此生效
+func (r *NodeReloader) Sync() ([]NodeChange, error) {
+	if r.service == nil {
+		return nil, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 先取内存快照再读数据库：本实例 API 在两者之间完成的变更只会被重复应用，不会被旧数据覆盖
+	memory := r.memorySnapshot()
+	maintenance := make(map[string]bool)
+	for _, node := range r.service.GetAllNodes() {
+		maintenance[node.Name] = node.InMaintenance()
+	}
+
+	var rows []models.Node
+	if err := r.db.Find(&rows).Error; err != nil {
+		return nil, errors.NewDatabaseError("load nodes", err)
+	}
+
+	changes := planNodeSync(rows, memory)
+	rowsByName := make(map[string]models.Node)
+	for _, row := range rows {
+		rowsByName[row.Name] = row
+	}
+	for i := range changes {
+		err := r.applyToService(&changes[i], rowsByName[changes[i].Name])
+		// 与本实例 API 并发时节点可能已被加载或移除
+		if err != nil && !errors.IsConflictError(err) && !errors.IsNotFoundError(err) {
+			changes[i].Error = err.Error()
+			logger.Warn("Failed to sync node from database",
+				zap.String("action", changes[i].Action),
+				zap.String("node", changes[i].Name),
+				zap.Error(err))
+		}
+	}
+
+	for _, row := range rows {
+		loaded, ok := maintenance[row.Name]
+		if !ok || loaded == row.Maintenance {
+			continue
+		}
+		if err := r.service.SetNodeMaintenance(row.Name, row.Maintenance); err != nil && !errors.IsNotFoundError(err) {
+			logger.Warn("Failed to sync node maintenance mode", zap.String("node", row.Name), zap.Error(err))
+		}
+	}
+
+	if len(changes) > 0 {
+		logger.Info("Nodes synced from database", zap.Any("summary", newNodeReloadPlan("sync", false, changes).Summary))
+	}
+	return changes, nil
+}
+
+// StartSync 按 interval 定期执行 Sync，关闭返回的通道时停止
+func (r *NodeReloader) StartSync(interval time.Duration) chan struct{} {
+	ticker := time.NewTicker(interval)
+	stopChan := make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := r.Sync(); err != nil {
+					logger.Warn("Failed to sync nodes from database", zap.Error(err))
+				}
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+
+	return stopChan
+}
+
+// applyToService 把单个变更应用到内存中的 SupervisorService
+func (r *NodeReloader) applyToService(change *NodeChange, row models.Node) error {
+	if r.service == nil {
+		return nil
+	}
+
+	switch change.Action {
+	case NodeChangeRemove, NodeChangeUnload:
+		if err := r.service.RemoveNode(change.Name); err != nil && !errors.IsNotFoundError(err) {
+			return err
+		}
+	case NodeChangeAdd, NodeChangeUpdate, NodeChangeReconnect:
+		environment := nodeEnvironment(row.Environment)
+		if _, err := r.service.GetNode(row.Name); err == nil {
+			// 只有标签变化时不需要重连
+			if len(change.Fields) != 1 || change.Fields[0] != "labels" {
+				if err := r.service.ReplaceNode(row.Name, environment, row.Host, row.Port, row.Username, row.Password); err != nil {
+					return err
+				}
+			}
+			return r.service.SetNodeLabels(row.Name, row.GetLabels())
+		}
+		if err := r.service.AddNode(row.Name, environment, row.Host, row.Port, row.Username, row.Password); err != nil {
+			return err
+		}
+		r.service.SetNodeLabels(row.Name, row.GetLabels())
+		if row.Maintenance {
+			return r.service.SetNodeMaintenance(row.Name, true)
+		}
+	}
+	return nil
+}
+
+// logChange 记录节点变更活动日志；只在内存中生效的变更不记录
+func (r *NodeReloader) logChange(change NodeChange, trigger string) {
+	if r.activityLogService == nil {
+		return
+	}
+
+	var action, level, message string
+	switch change.Action {
+	case NodeChangeAdd:
+		action, level = "create_node", "INFO"
+		message = fmt.Sprintf("Added node %s (%s:%d) from config reload", change.Name, change.Host, change.Port)
+	case NodeChangeUpdate:
+		action, level = "update_node", "INFO"
+		message = fmt.Sprintf("Updated node %s (%s) from config reload", change.Name, strings.Join(change.Fields, ", "))
+	case NodeChangeRemove:
+		action, level = "delete_node", "WARNING"
+		message = fmt.Sprintf("Removed node %s from config reload", change.Name)
+	default:
+		return
+	}
+	message += " [" + trigger + "]"
+	if change.Error != "" {
+		message += ": " + change.Error
+	}
+	r.activityLogService.LogSystemEvent(level, action, "node", change.Name, message, change)
+}
+
+func (r *NodeReloader) loadNodes() ([]config.NodeConfig, error) {
+	if r.load == nil {
+		return nil, errors.NewInternalError("node list loader is not configured", nil)
+	}
+	nodes, err := r.load()
+	if err != nil {
+		return nil, errors.NewInternalError("failed to load node list", err)
+	}
+	if err := validateReloadNodes(nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// memorySnapshot 当前内存中节点的连接参数；没有 SupervisorService 时返回 nil
+func (r *NodeReloader) memorySnapshot() map[string]config.NodeConfig {
+	if r.service == nil {
+		return nil
+	}
+	snapshot := make(map[string]config.NodeConfig)
+	for _, node := range r.service.GetAllNodes() {
+		snapshot[node.Name] = config.NodeConfig{
+			Name:        node.Name,
+			Environment: node.Environment,
+			Host:        node.Host,
+			Port:        node.Port,
+			Username:    node.Username,
+			Password:    node.Password,
+			Labels:      node.Labels(),
+		}
+	}
+	return snapshot
+}
+
+// validateReloadNodes 校验配置节点；任何一个节点无效时整个重载被拒绝，保持现有节点不变
+func validateReloadNodes(nodes []config.NodeConfig) error {
+	validator := config.NewValidator()
+	seen := make(map[string]bool)
+	for i, node := range nodes {
+		if err := validator.ValidateNode(node); err != nil {
+			return errors.NewValidationError(fmt.Sprintf("nodes[%d]", i), err.Error())
+		}
+		if seen[node.Name] {
+			return errors.NewValidationError(fmt.Sprintf("nodes[%d]", i), "duplicate node name "+node.Name)
+		}
+		seen[node.Name] = true
+	}
+	return nil
+}
+
+// planNodeReload 计算期望节点列表与数据库（含软删除记录）、内存之间的差异；memory 为 nil 时不比较内存
+func planNodeReload(desired []config.NodeConfig, rows []models.Node, memory map[string]config.NodeConfig) []NodeChange {
+	live := make(map[string]models.Node)
+	owners := make(map[string]models.Node) // host:port -> 未删除的节点
+	tombstones := make(map[string]bool)    // 通过 API 删除的节点名和 host:port
+	for _, row := range rows {
+		if row.DeletedAt.Valid {
+			tombstones[row.Name] = true
+			tombstones[row.GetConnectionString()] = true
+			continue
+		}
+		live[row.Name] = row
+		owners[row.GetConnectionString()] = row
+	}
+
+	desiredNames := make(map[string]bool)
+	for _, node := range desired {
+		desiredNames[node.Name] = true
+	}
+	// 占用 host:port 的节点是否在本次重载中被移除
+	removed := func(row models.Node) bool {
+		return row.Source == models.NodeSourceConfig && !desiredNames[row.Name]
+	}
+
+	var changes []NodeChange
+	handled := make(map[string]bool) // 本次变更会重建内存连接的节点
+	for _, node := range desired {
+		address := fmt.Sprintf("%s:%d", node.Host, node.Port)
+		change := NodeChange{Name: node.Name, Host: node.Host, Port: node.Port, Environment: node.Environment}
+
+		row, exists := live[node.Name]
+		if owner, taken := owners[address]; taken && owner.Name != node.Name && !removed(owner) {
+			change.Action = NodeChangeSkip
+			change.Reason = fmt.Sprintf("%s is already registered as node %s", address, owner.Name)
+			changes = append(changes, change)
+			continue
+		}
+
+		if !exists {
+			if tombstones[node.Name] || tombstones[address] {
+				change.Action = NodeChangeSkip
+				change.Reason = "node was deleted via API"
+				changes = append(changes, change)
+				continue
+			}
+			change.Action = NodeChangeAdd
+			changes = append(changes, change)
+			handled[node.Name] = true
+			continue
+		}
+
+		fields := diffNodeFields(nodeConfigFromRow(row), node)
+		if len(fields) == 0 {
+			continue
+		}
+		if row.Source != models.NodeSourceConfig {
+			change.Action = NodeChangeSkip
+			change.Reason = fmt.Sprintf("node is managed via %s", row.Source)
+			change.Fields = fields
+			changes = append(changes, change)
+			continue
+		}
+		change.Action = NodeChangeUpdate
+		change.Fields = fields
+		changes = append(changes, change)
+		handled[node.Name] = true
+	}
+
+	// 已从配置移除的节点
+	names := make([]string, 0, len(live))
+	for name := range live {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		row := live[name]
+		if removed(row) {
+			changes = append(changes, NodeChange{
+				Action: NodeChangeRemove, Name: name, Host: row.Host, Port: row.Port, Environment: row.Environment,
+			})
+			handled[name] = true
+		}
+	}
+
+	if memory == nil {
+		return changes
+	}
+	return append(changes, planMemorySync(live, memory, handled)...)
+}
+
+// planNodeSync 只比较数据库（未删除的节点）与内存，不读取配置文件
+func planNodeSync(rows []models.Node, memory map[string]config.NodeConfig) []NodeChange {
+	live := make(map[string]models.Node)
+	for _, row := range rows {
+		if !row.DeletedAt.Valid {
+			live[row.Name] = row
+		}
+	}
+	return planMemorySync(live, memory, nil)
+}
+
+// planMemorySync 数据库与内存不一致的节点；handled 中的节点已由本次重载重建，不再比较
+func planMemorySync(live map[string]models.Node, memory map[string]config.NodeConfig, handled map[string]bool) []NodeChange {
+	names := make([]string, 0, len(live))
+	for name := range live {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changes []NodeChange
+	for _, name := range names {
+		if handled[name] {
+			continue
+		}
+		row := live[name]
+		change := NodeChange{Action: NodeChangeReconnect, Name: name, Host: row.Host, Port: row.Port, Environment: row.Environment}
+		loaded, ok := memory[name]
+		if !ok {
+			change.Reason = "node is not loaded"
+		} else if fields := diffNodeFields(loaded, nodeConfigFromRow(row)); len(fields) > 0 {
+			change.Reason = "loaded connection settings are stale"
+			change.Fields = fields
+		} else {
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	loadedNames := make([]string, 0, len(memory))
+	for name := range memory {
+		loadedNames = append(loadedNames, name)
+	}
+	sort.Strings(loadedNames)
+	for _, name := range loadedNames {
+		if _, exists := live[name]; exists || handled[name] {
+			continue
+		}
+		loaded := memory[name]
+		changes = append(changes, NodeChange{
+			Action: NodeChangeUnload, Name: name, Host: loaded.Host, Port: loaded.Port, Environment: loaded.Environment,
+			Reason: "node is not in database",
+		})
+	}
+
+	return changes
+}
+
+// diffNodeFields 比较连接参数和标签，返回变化的字段名；desired 未设置标签时不比较标签
+func diffNodeFields(current, desired config.NodeConfig) []string {
+	var fields []string
+	if current.Host != desired.Host {
+		fields = append(fields, "host")
+	}
+	if current.Port != desired.Port {
+		fields = append(fields, "port")
+	}
+	if current.Username != desired.Username {
+		fields = append(fields, "username")
+	}
+	if current.Password != desired.Password {
+		fields = append(fields, "password")
+	}
+	if nodeEnvironment(current.Environment) != nodeEnvironment(desired.Environment) {
+		fields = append(fields, "environment")
+	}
+	if desired.Labels != nil && !labels.Equal(current.Labels, desired.Labels) {
+		fields = append(fields, "labels")
+	}
+	return fields
+}
+
+func nodeConfigFromRow(row models.Node) config.NodeConfig {
+	return config.NodeConfig{
+		Name:        row.Name,
+		Environment: row.Environment,
+		Host:        row.Host,
+		Port:        row.Port,
+		Username:    row.Username,
+		Password:    row.Password,
+		Labels:      row.GetLabels(),
+	}
+}
+
+func findReloadNode(nodes []config.NodeConfig, name string) (config.NodeConfig, bool) {
+	for _, node := range nodes {
+		if node.Name == name {
+			return node, true
+		}
+	}
+	return config.NodeConfig{}, false
+}
+
+// nodeEnvironment 空环境按 default 处理（与启动加载一致）
+func nodeEnvironment(environment string) string {
+	if environment == "" {
+		return "default"
+	}
+	return environment
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"superview/internal/config"
+	"superview/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func changeByName(changes []NodeChange, name string) *NodeChange {
+	for i := range changes {
+		if changes[i].Name == name {
+			return &changes[i]
+		}
+	}
+	return nil
+}
+
+func TestPlanNodeReload(t *testing.T) {
+	rows := []models.Node{
+		{Name: "same", Host: "10.0.0.1", Port: 9001, Environment: "prod", Source: models.NodeSourceConfig},
+		{Name: "moved", Host: "10.0.0.2", Port: 9001, Environment: "prod", Password: "old", Source: models.NodeSourceConfig},
+		{Name: "dropped", Host: "10.0.0.3", Port: 9001, Source: models.NodeSourceConfig},
+		{Name: "manual", Host: "10.0.0.4", Port: 9001, Source: models.NodeSourceAPI},
+		{Name: "deleted", Host: "10.0.0.5", Port: 9001, Source: models.NodeSourceConfig, DeletedAt: gorm.DeletedAt{Valid: true}},
+	}
+	desired := []config.NodeConfig{
+		{Name: "same", Host: "10.0.0.1", Port: 9001, Environment: "prod"},
+		{Name: "moved", Host: "10.0.0.20", Port: 9001, Environment: "prod", Password: "new"},
+		{Name: "manual", Host: "10.0.0.4", Port: 9002, Environment: "default"},
+		{Name: "deleted", Host: "10.0.0.5", Port: 9001, Environment: "prod"},
+		{Name: "fresh", Host: "10.0.0.6", Port: 9001, Environment: "prod"},
+		{Name: "clash", Host: "10.0.0.4", Port: 9001, Environment: "prod"},
+	}
+	memory := map[string]config.NodeConfig{
+		"moved":   {Name: "moved", Host: "10.0.0.2", Port: 9001, Environment: "prod", Password: "old"},
+		"dropped": {Name: "dropped", Host: "10.0.0.3", Port: 9001, Environment: "default"},
+		"manual":  {Name: "manual", Host: "10.0.0.4", Port: 9001, Environment: "default"},
+		"ghost":   {Name: "ghost", Host: "10.0.0.9", Port: 9001, Environment: "default"},
+	}
+
+	changes := planNodeReload(desired, rows, memory)
+
+	assert.Equal(t, NodeChangeReconnect, changeByName(changes, "same").Action, "in DB but not loaded")
+	moved := changeByName(changes, "moved")
+	assert.Equal(t, NodeChangeUpdate, moved.Action)
+	assert.Equal(t, []string{"host", "password"}, moved.Fields)
+	assert.Equal(t, NodeChangeRemove, changeByName(changes, "dropped").Action)
+	assert.Equal(t, NodeChangeSkip, changeByName(changes, "manual").Action, "API nodes are not managed by config")
+	assert.Equal(t, NodeChangeSkip, changeByName(changes, "deleted").Action, "API deletions are not re-imported")
+	assert.Equal(t, NodeChangeAdd, changeByName(changes, "fresh").Action)
+	assert.Equal(t, NodeChangeSkip, changeByName(changes, "clash").Action, "host:port owned by another node")
+	assert.Equal(t, NodeChangeUnload, changeByName(changes, "ghost").Action)
+}
+
+func TestPlanNodeReloadRename(t *testing.T) {
+	rows := []models.Node{{Name: "old-name", Host: "10.0.0.1", Port: 9001, Source: models.NodeSourceConfig}}
+	desired := []config.NodeConfig{{Name: "new-name", Host: "10.0.0.1", Port: 9001, Environment: "prod"}}
+
+	changes := planNodeReload(desired, rows, map[string]config.NodeConfig{})
+
+	assert.Equal(t, NodeChangeAdd, changeByName(changes, "new-name").Action)
+	assert.Equal(t, NodeChangeRemove, changeByName(changes, "old-name").Action)
+}
+
+func TestNodeReloaderApply(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "superview.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Node{}, &models.ActivityLog{}))
+
+	require.NoError(t, db.Create(&models.Node{Name: "keep", Host: "10.0.0.1", Port: 9001, Environment: "prod", Source: models.NodeSourceConfig}).Error)
+	require.NoError(t, db.Create(&models.Node{Name: "gone", Host: "10.0.0.2", Port: 9001, Source: models.NodeSourceConfig}).Error)
+
+	desired := []config.NodeConfig{
+		{Name: "keep", Host: "10.0.0.1", Port: 9002, Environment: "prod", Username: "admin"},
+		{Name: "new", Host: "10.0.0.3", Port: 9001, Environment: "staging"},
+	}
+	reloader := NewNodeReloader(db, nil, func() ([]config.NodeConfig, error) { return desired, nil }, NewActivityLogService(db))
+
+	preview, err := reloader.Preview()
+	require.NoError(t, err)
+	assert.True(t, preview.DryRun)
+	assert.Equal(t, map[string]int{NodeChangeAdd: 1, NodeChangeUpdate: 1, NodeChangeRemove: 1}, preview.Summary)
+	var count int64
+	db.Model(&models.Node{}).Count(&count)
+	assert.Equal(t, int64(2), count, "preview must not modify the database")
+
+	plan, err := reloader.Reload("test")
+	require.NoError(t, err)
+	assert.Equal(t, preview.Summary, plan.Summary)
+
+	var keep models.Node
+	require.NoError(t, db.Where("name = ?", "keep").First(&keep).Error)
+	assert.Equal(t, 9002, keep.Port)
+	assert.Equal(t, "admin", keep.Username)
+	var added models.Node
+	require.NoError(t, db.Where("name = ?", "new").First(&added).Error)
+	assert.Equal(t, models.NodeSourceConfig, added.Source)
+	db.Unscoped().Model(&models.Node{}).Where("name = ?", "gone").Count(&count)
+	assert.Zero(t, count, "config removals are hard deletes so the node can be re-added later")
+
+	db.Model(&models.ActivityLog{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+
+	// 再次应用没有变更
+	plan, err = reloader.Reload("test")
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges())
+}
+
+func TestNodeReloaderRejectsInvalidNodeList(t *testing.T) {
+	reloader := NewNodeReloader(nil, nil, nil)
+	_, err := reloader.Apply([]config.NodeConfig{
+		{Name: "a", Host: "10.0.0.1", Port: 9001, Environment: "prod"},
+		{Name: "a", Host: "10.0.0.2", Port: 9001, Environment: "prod"},
+	}, "test")
+	assert.Error(t, err)
+}
+
+func TestPlanNodeReloadLabels(t *testing.T) {
+	row := models.Node{Name: "web", Host: "10.0.0.1", Port: 9001, Environment: "prod", Source: models.NodeSourceConfig}
+	row.SetLabels(map[string]string{"role": "web"})
+	memory := map[string]config.NodeConfig{
+		"web": {Name: "web", Host: "10.0.0.1", Port: 9001, Environment: "prod", Labels: map[string]string{"role": "web"}},
+	}
+
+	// 未配置 labels 时保留数据库中的标签
+	changes := planNodeReload([]config.NodeConfig{{Name: "web", Host: "10.0.0.1", Port: 9001, Environment: "prod"}}, []models.Node{row}, memory)
+	assert.Nil(t, changeByName(changes, "web"))
+
+	changes = planNodeReload([]config.NodeConfig{
+		{Name: "web", Host: "10.0.0.1", Port: 9001, Environment: "prod", Labels: map[string]string{"role": "web", "canary": "true"}},
+	}, []models.Node{row}, memory)
+	web := changeByName(changes, "web")
+	require.NotNil(t, web)
+	assert.Equal(t, NodeChangeUpdate, web.Action)
+	assert.Equal(t, []string{"labels"}, web.Fields)
+}
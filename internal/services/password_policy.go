@@ -0,0 +1,379 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"superview/internal/errors"
+	"superview/internal/logger"
+	"superview/internal/models"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 密码策略在 SystemSettings 中的键（category = security）
+const (
+	SettingPasswordMinLength        = "password.min_length"
+	SettingPasswordRequireUppercase = "password.require_uppercase"
+	SettingPasswordRequireLowercase = "password.require_lowercase"
+	SettingPasswordRequireDigit     = "password.require_digit"
+	SettingPasswordRequireSpecial   = "password.require_special"
+	SettingPasswordHistoryCount     = "password.history_count"
+	SettingPasswordMaxAgeDays       = "password.max_age_days"
+	SettingPasswordWordlistCheck    = "password.wordlist_check"
+	SettingPasswordWordlistPath     = "password.wordlist_path"
+)
+
+// DefaultPasswordWordlistPath 默认的本地泄露密码字典（每行一个密码）
+const DefaultPasswordWordlistPath = "config/breached-passwords.txt"
+
+// passwordPolicyCacheTTL 策略缓存时间，AuthMiddleware 每个请求都会读取策略
+const passwordPolicyCacheTTL = 30 * time.Second
+
+// passwordPolicyGeneration 策略缓存的版本，InvalidateCache 递增后所有服务实例都会重新读取策略
+var passwordPolicyGeneration atomic.Uint64
+
+// builtinBreachedPasswords 内置的常见弱密码，字典文件不存在时仍可拦截
+var builtinBreachedPasswords = []string{
+	"password", "password1", "password123", "12345678", "123456789", "1234567890",
+	"qwerty123", "qwertyuiop", "11111111", "iloveyou", "admin123", "administrator",
+	"welcome1", "letmein1", "abc12345", "passw0rd", "p@ssw0rd", "changeme",
+	"superview", "supervisor", "1q2w3e4r", "sunshine1", "football1", "baseball1",
+}
+
+// PasswordPolicy 本地用户密码策略
+type PasswordPolicy struct {
+	MinLength        int    `json:"min_length"`
+	RequireUppercase bool   `json:"require_uppercase"`
+	RequireLowercase bool   `json:"require_lowercase"`
+	RequireDigit     bool   `json:"require_digit"`
+	RequireSpecial   bool   `json:"require_special"`
+	HistoryCount     int    `json:"history_count"`  // 禁止重复使用最近 N 个密码，0 表示不限制
+	MaxAgeDays       int    `json:"max_age_days"`   // 密码最长使用天数，0 表示不强制轮换
+	WordlistCheck    bool   `json:"wordlist_check"` // 是否检查泄露密码字典
+	WordlistPath     string `json:"wordlist_path"`
+}
+
+// DefaultPasswordPolicy 获取默认密码策略
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:     8,
+		HistoryCount:  0,
+		MaxAgeDays:    0,
+		WordlistCheck: true,
+		WordlistPath:  DefaultPasswordWordlistPath,
+	}
+}
+
+// IsExpired 检查用户密码是否已超过最长使用期限
+func (p *PasswordPolicy) IsExpired(user *models.User) bool {
+	if p.MaxAgeDays <= 0 {
+		return false
+	}
+	return user.PasswordAge() > time.Duration(p.MaxAgeDays)*24*time.Hour
+}
+
+// ChangeRequiredReason 用户必须先修改密码的原因（管理员重置或密码过期），不需要时返回空字符串
+func (p *PasswordPolicy) ChangeRequiredReason(user *models.User) string {
+	if user.MustChangePassword {
+		return "Password change required"
+	}
+	if p.IsExpired(user) {
+		return "Password expired, please change your password"
+	}
+	return ""
+}
+
+// Validate 校验密码是否满足长度、字符类别和字典要求，返回所有不满足项
+func (p *PasswordPolicy) Validate(password, username string) []string {
+	var problems []string
+
+	if len([]rune(password)) < p.MinLength {
+		problems = append(problems, fmt.Sprintf("password must be at least %d characters", p.MinLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+	if p.RequireUppercase && !hasUpper {
+		problems = append(problems, "password must contain an uppercase letter")
+	}
+	if p.RequireLowercase && !hasLower {
+		problems = append(problems, "password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		problems = append(problems, "password must contain a digit")
+	}
+	if p.RequireSpecial && !hasSpecial {
+		problems = append(problems, "password must contain a special character")
+	}
+
+	if username != "" && strings.EqualFold(password, username) {
+		problems = append(problems, "password must not equal the username")
+	}
+
+	if p.WordlistCheck && isBreachedPassword(password, p.WordlistPath) {
+		problems = append(problems, "password appears in a list of breached passwords")
+	}
+
+	return problems
+}
+
+// PasswordPolicyService 密码策略服务
+type PasswordPolicyService struct {
+	db *gorm.DB
+
+	mu               sync.Mutex
+	cached           *PasswordPolicy
+	cachedAt         time.Time
+	cachedGeneration uint64
+}
+
+// NewPasswordPolicyService 创建密码策略服务
+func NewPasswordPolicyService(db *gorm.DB) *PasswordPolicyService {
+	return &PasswordPolicyService{db: db}
+}
+
+// GetPolicy 从 SystemSettings 读取密码策略（带短时缓存）
+func (s *PasswordPolicyService) GetPolicy() *PasswordPolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	generation := passwordPolicyGeneration.Load()
+	if s.cached != nil && s.cachedGeneration == generation && time.Since(s.cachedAt) < passwordPolicyCacheTTL {
+		return s.cached
+	}
+
+	policy := DefaultPasswordPolicy()
+	if s.db != nil {
+		var settings []models.SystemSettings
+		if err := s.db.Where("? LIKE ?", clause.Column{Name: "key"}, "password.%").Find(&settings).Error; err != nil {
+			logger.Warn("Failed to load password policy settings, using defaults", zap.Error(err))
+		}
+		for _, setting := range settings {
+			applyPasswordPolicySetting(policy, setting.Key, setting.Value)
+		}
+	}
+
+	s.cached = policy
+	s.cachedAt = time.Now()
+	s.cachedGeneration = generation
+	return policy
+}
+
+// InvalidateCache 清除策略缓存（设置更新后调用），认证中间件等其他实例的缓存同样失效
+func (s *PasswordPolicyService) InvalidateCache() {
+	s.mu.Lock()
+	s.cached = nil
+	s.mu.Unlock()
+	passwordPolicyGeneration.Add(1)
+}
+
+// ChangePassword 按策略校验并修改用户密码，记录历史
+// mustChange 为 true 时用户下次登录必须再次修改（管理员重置场景）
+func (s *PasswordPolicyService) ChangePassword(user *models.User, newPassword string, mustChange bool) error {
+	policy := s.GetPolicy()
+
+	if problems := policy.Validate(newPassword, user.Username); len(problems) > 0 {
+		return errors.NewValidationError("password", strings.Join(problems, "; "))
+	}
+
+	if reused, err := s.isReused(user, newPassword, policy.HistoryCount); err != nil {
+		return err
+	} else if reused {
+		return errors.NewValidationError("password", fmt.Sprintf("password must not match any of the last %d passwords", policy.HistoryCount))
+	}
+
+	if err := user.SetPassword(newPassword); err != nil {
+		return errors.NewInternalError("failed to hash password", err)
+	}
+	user.MustChangePassword = mustChange
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"password":             user.Password,
+			"password_changed_at":  user.PasswordChangedAt,
+			"must_change_password": user.MustChangePassword,
+		}).Error; err != nil {
+			return errors.NewDatabaseError("update password", err)
+		}
+		return s.recordHistory(tx, user, policy.HistoryCount)
+	})
+}
+
+// RecordInitialPassword 为新建用户记录首个密码历史
+func (s *PasswordPolicyService) RecordInitialPassword(user *models.User) error {
+	return s.recordHistory(s.db, user, s.GetPolicy().HistoryCount)
+}
+
+// isReused 检查新密码是否与当前密码或最近 N 个历史密码相同
+func (s *PasswordPolicyService) isReused(user *models.User, password string, historyCount int) (bool, error) {
+	if historyCount <= 0 {
+		return false, nil
+	}
+
+	if user.Password != "" && bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) == nil {
+		return true, nil
+	}
+
+	var history []models.PasswordHistory
+	if err := s.db.Where("user_id = ?", user.ID).
+		Order("created_at DESC, id DESC").
+		Limit(historyCount).
+		Find(&history).Error; err != nil {
+		return false, errors.NewDatabaseError("get password history", err)
+	}
+	for _, h := range history {
+		if bcrypt.CompareHashAndPassword([]byte(h.PasswordHash), []byte(password)) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordHistory 写入当前密码哈希并清理超出保留数量的历史
+func (s *PasswordPolicyService) recordHistory(tx *gorm.DB, user *models.User, historyCount int) error {
+	if historyCount <= 0 {
+		return nil
+	}
+
+	if err := tx.Create(&models.PasswordHistory{
+		UserID:       user.ID,
+		PasswordHash: user.Password,
+	}).Error; err != nil {
+		return errors.NewDatabaseError("record password history", err)
+	}
+
+	var staleIDs []uint
+	if err := tx.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", user.ID).
+		Order("created_at DESC, id DESC").
+		Offset(historyCount).
+		Pluck("id", &staleIDs).Error; err != nil {
+		return errors.NewDatabaseError("prune password history", err)
+	}
+	if len(staleIDs) > 0 {
+		if err := tx.Where("id IN ?", staleIDs).Delete(&models.PasswordHistory{}).Error; err != nil {
+			return errors.NewDatabaseError("prune password history", err)
+		}
+	}
+	return nil
+}
+
+// applyPasswordPolicySetting 将单个设置项应用到策略
+func applyPasswordPolicySetting(policy *PasswordPolicy, key, value string) {
+	value = strings.TrimSpace(value)
+	switch key {
+	case SettingPasswordMinLength:
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			policy.MinLength = n
+		}
+	case SettingPasswordRequireUppercase:
+		policy.RequireUppercase = value == "true"
+	case SettingPasswordRequireLowercase:
+		policy.RequireLowercase = value == "true"
+	case SettingPasswordRequireDigit:
+		policy.RequireDigit = value == "true"
+	case SettingPasswordRequireSpecial:
+		policy.RequireSpecial = value == "true"
+	case SettingPasswordHistoryCount:
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			policy.HistoryCount = n
+		}
+	case SettingPasswordMaxAgeDays:
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			policy.MaxAgeDays = n
+		}
+	case SettingPasswordWordlistCheck:
+		policy.WordlistCheck = value != "false"
+	case SettingPasswordWordlistPath:
+		if value != "" {
+			policy.WordlistPath = value
+		}
+	}
+}
+
+// breachedWordlist 本地泄露密码字典缓存
+var breachedWordlist = struct {
+	sync.Mutex
+	path    string
+	modTime time.Time
+	words   map[string]struct{}
+}{}
+
+// isBreachedPassword 检查密码是否在内置列表或本地字典中（不区分大小写）
+func isBreachedPassword(password, path string) bool {
+	lower := strings.ToLower(password)
+	for _, p := range builtinBreachedPasswords {
+		if p == lower {
+			return true
+		}
+	}
+
+	words := loadBreachedWordlist(path)
+	_, found := words[lower]
+	return found
+}
+
+// loadBreachedWordlist 加载字典文件，文件修改后自动重新加载
+func loadBreachedWordlist(path string) map[string]struct{} {
+	breachedWordlist.Lock()
+	defer breachedWordlist.Unlock()
+
+	if path == "" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if breachedWordlist.path == path && breachedWordlist.modTime.Equal(info.ModTime()) {
+		return breachedWordlist.words
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		logger.Warn("Failed to open password wordlist", zap.String("path", path), zap.Error(err))
+		return nil
+	}
+	defer file.Close()
+
+	words := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Warn("Failed to read password wordlist", zap.String("path", path), zap.Error(err))
+	}
+
+	breachedWordlist.path = path
+	breachedWordlist.modTime = info.ModTime()
+	breachedWordlist.words = words
+	logger.Info("Password wordlist loaded", zap.String("path", path), zap.Int("entries", len(words)))
+	return words
+}
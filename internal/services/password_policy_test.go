@@ -0,0 +1,95 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"superview/internal/models"
+)
+
+func setupPasswordPolicyTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.PasswordHistory{}, &models.SystemSettings{}))
+	return db
+}
+
+func setPolicySetting(t *testing.T, db *gorm.DB, key, value string) {
+	require.NoError(t, db.Create(&models.SystemSettings{ID: key, Key: key, Value: value, Category: "security"}).Error)
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+	policy.RequireUppercase = true
+	policy.RequireDigit = true
+	policy.RequireSpecial = true
+
+	assert.Empty(t, policy.Validate("Tr0ub4dor&3x", "alice"))
+	assert.NotEmpty(t, policy.Validate("short", "alice"), "too short")
+	assert.NotEmpty(t, policy.Validate("alllowercase1!", "alice"), "missing uppercase")
+	assert.NotEmpty(t, policy.Validate("NoDigitsHere!", "alice"), "missing digit")
+	assert.NotEmpty(t, policy.Validate("NoSpecial123", "alice"), "missing special")
+	assert.NotEmpty(t, DefaultPasswordPolicy().Validate("Password123", "alice"), "breached password")
+	assert.NotEmpty(t, DefaultPasswordPolicy().Validate("aliceSmith", "alicesmith"), "equals username")
+}
+
+func TestPasswordPolicyService_LoadsSettings(t *testing.T) {
+	db := setupPasswordPolicyTestDB(t)
+	setPolicySetting(t, db, SettingPasswordMinLength, "12")
+	setPolicySetting(t, db, SettingPasswordHistoryCount, "3")
+	setPolicySetting(t, db, SettingPasswordMaxAgeDays, "90")
+	setPolicySetting(t, db, SettingPasswordWordlistCheck, "false")
+
+	policy := NewPasswordPolicyService(db).GetPolicy()
+	assert.Equal(t, 12, policy.MinLength)
+	assert.Equal(t, 3, policy.HistoryCount)
+	assert.Equal(t, 90, policy.MaxAgeDays)
+	assert.False(t, policy.WordlistCheck)
+}
+
+func TestPasswordPolicyService_History(t *testing.T) {
+	db := setupPasswordPolicyTestDB(t)
+	setPolicySetting(t, db, SettingPasswordHistoryCount, "2")
+	service := NewPasswordPolicyService(db)
+
+	user := &models.User{Username: "bob", IsActive: true}
+	require.NoError(t, user.SetPassword("first-Secret-1"))
+	require.NoError(t, db.Create(user).Error)
+	require.NoError(t, service.RecordInitialPassword(user))
+
+	require.NoError(t, service.ChangePassword(user, "second-Secret-2", false))
+	assert.Error(t, service.ChangePassword(user, "second-Secret-2", false), "current password must not be reused")
+	assert.Error(t, service.ChangePassword(user, "first-Secret-1", false), "recent password must not be reused")
+
+	require.NoError(t, service.ChangePassword(user, "third-Secret-3", true))
+	assert.True(t, user.MustChangePassword)
+
+	var count int64
+	db.Model(&models.PasswordHistory{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(2), count, "history is pruned to history_count")
+
+	// 超出保留数量后旧密码可以再次使用
+	require.NoError(t, service.ChangePassword(user, "first-Secret-1", false))
+
+	var stored models.User
+	require.NoError(t, db.First(&stored, "id = ?", user.ID).Error)
+	assert.True(t, stored.VerifyPassword("first-Secret-1"))
+	assert.False(t, stored.MustChangePassword)
+}
+
+func TestPasswordPolicy_IsExpired(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+	old := time.Now().AddDate(0, 0, -100)
+	user := &models.User{PasswordChangedAt: &old}
+
+	assert.False(t, policy.IsExpired(user), "expiry disabled by default")
+	policy.MaxAgeDays = 90
+	assert.True(t, policy.IsExpired(user))
+	policy.MaxAgeDays = 120
+	assert.False(t, policy.IsExpired(user))
+}
@@ -2,26 +2,40 @@ package services
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/robfig/cron/v3"
+	"superview/internal/errors"
+	"superview/internal/labels"
 	"superview/internal/logger"
 	"superview/internal/models"
+	"superview/internal/supervisor"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// SchedulerEnabledKey 调度器开关在系统设置中的键；持久化后重启或主节点切换时自动恢复
+const SchedulerEnabledKey = "scheduler.enabled"
+
 // ProcessEnhancedService 进程增强服务
 type ProcessEnhancedService struct {
-	db        *gorm.DB
-	cronJob   *cron.Cron
-	scheduler *TaskScheduler
+	db         *gorm.DB
+	cronJob    *cron.Cron
+	scheduler  *TaskScheduler
+	supervisor *supervisor.SupervisorService
+	mu         sync.Mutex
 }
 
 // TaskScheduler 任务调度器
 type TaskScheduler struct {
 	service *ProcessEnhancedService
 	running bool
+	// leader 当前实例是否允许运行调度器（多实例部署时只有主节点运行）
+	leader bool
 }
 
 // NewProcessEnhancedService 创建进程增强服务实例
@@ -33,16 +47,65 @@ func NewProcessEnhancedService(db *gorm.DB) *ProcessEnhancedService {
 	service.scheduler = &TaskScheduler{
 		service: service,
 		running: false,
+		leader:  true,
 	}
 	return service
 }
 
+// SetSupervisorService 设置定时任务操作进程使用的 SupervisorService
+func (s *ProcessEnhancedService) SetSupervisorService(supervisorService *supervisor.SupervisorService) {
+	s.supervisor = supervisorService
+}
+
 // StartScheduler 启动任务调度器
 func (s *ProcessEnhancedService) StartScheduler() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if s.scheduler.running {
 		return fmt.Errorf("scheduler is already running")
 	}
+	if err := s.setSchedulerEnabled(true); err != nil {
+		return err
+	}
+	if !s.scheduler.leader {
+		logger.Info("Task scheduler enabled, it will run on the leader instance")
+		return nil
+	}
+	return s.startCron()
+}
+
+// StopScheduler 停止任务调度器
+func (s *ProcessEnhancedService) StopScheduler() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.setSchedulerEnabled(false); err != nil {
+		logger.Error("Failed to persist scheduler state", zap.Error(err))
+	}
+	s.stopCron()
+}
+
+// HandleLeadershipChange 主节点身份变化：成为主节点时按持久化的开关恢复调度器，失去身份时停止
+func (s *ProcessEnhancedService) HandleLeadershipChange(isLeader bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scheduler.leader = isLeader
+	if !isLeader {
+		s.stopCron()
+		return
+	}
+	if s.scheduler.running || !s.schedulerEnabled() {
+		return
+	}
+	if err := s.startCron(); err != nil {
+		logger.Error("Failed to resume task scheduler", zap.Error(err))
+	}
+}
 
+// startCron 加载定时任务并启动 cron（调用方持有 s.mu）
+func (s *ProcessEnhancedService) startCron() error {
 	// 加载所有启用的定时任务
 	err := s.loadScheduledTasks()
 	if err != nil {
@@ -55,15 +118,47 @@ func (s *ProcessEnhancedService) StartScheduler() error {
 	return nil
 }
 
-// StopScheduler 停止任务调度器
-func (s *ProcessEnhancedService) StopScheduler() {
+// stopCron 停止 cron 并丢弃已加载的任务，下次启动时重新加载（调用方持有 s.mu）
+func (s *ProcessEnhancedService) stopCron() {
 	if s.scheduler.running {
-		s.cronJob.Stop()
+		<-s.cronJob.Stop().Done()
+		s.cronJob = cron.New(cron.WithSeconds())
 		s.scheduler.running = false
 		logger.Info("Task scheduler stopped")
 	}
 }
 
+// schedulerEnabled 读取持久化的调度器开关
+func (s *ProcessEnhancedService) schedulerEnabled() bool {
+	var setting models.SystemSettings
+	if err := s.db.Where(&models.SystemSettings{Key: SchedulerEnabledKey}).First(&setting).Error; err != nil {
+		return false
+	}
+	enabled, _ := strconv.ParseBool(setting.Value)
+	return enabled
+}
+
+// setSchedulerEnabled 持久化调度器开关
+func (s *ProcessEnhancedService) setSchedulerEnabled(enabled bool) error {
+	value := strconv.FormatBool(enabled)
+	var setting models.SystemSettings
+	err := s.db.Where(&models.SystemSettings{Key: SchedulerEnabledKey}).First(&setting).Error
+	if err == gorm.ErrRecordNotFound {
+		return s.db.Create(&models.SystemSettings{
+			ID:          uuid.New().String(),
+			Category:    "system",
+			Key:         SchedulerEnabledKey,
+			Value:       value,
+			ValueType:   "boolean",
+			Description: "Whether the scheduled task runner is enabled",
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+	return s.db.Model(&setting).Update("value", value).Error
+}
+
 // loadScheduledTasks 加载定时任务
 func (s *ProcessEnhancedService) loadScheduledTasks() error {
 	var tasks []models.ScheduledTask
@@ -189,23 +284,32 @@ func (s *ProcessEnhancedService) ReorderProcessesInGroup(groupID uint, processOr
 	return nil
 }
 
-// CreateProcessDependency 创建进程依赖
+// CreateProcessDependency 创建进程依赖，依赖两端可以在不同节点上
 func (s *ProcessEnhancedService) CreateProcessDependency(dependency *models.ProcessDependency) error {
+	dependency.ProcessName = normalizeProcessName(dependency.ProcessName)
+	dependency.DependentProcess = normalizeProcessName(dependency.DependentProcess)
+	if dependency.DependencyType == "" {
+		dependency.DependencyType = models.DependencyTypeStartAfter
+	}
+	if dependency.Timeout <= 0 {
+		dependency.Timeout = int(defaultDependencyTimeout.Seconds())
+	}
 	// 检查是否会形成循环依赖
-	if s.wouldCreateCircularDependency(dependency) {
-		return fmt.Errorf("would create circular dependency")
+	if err := s.validateDependency(dependency); err != nil {
+		return err
 	}
-	return s.db.Create(dependency).Error
-}
 
-// wouldCreateCircularDependency 检查是否会形成循环依赖
-func (s *ProcessEnhancedService) wouldCreateCircularDependency(newDep *models.ProcessDependency) bool {
-	// 简化的循环依赖检查
-	// 实际实现应该使用图算法进行深度检查
-	var existingDep models.ProcessDependency
-	err := s.db.Where("process_name = ? AND node_id = ? AND dependent_process = ? AND dependent_node_id = ?",
-		newDep.DependentProcess, newDep.DependentNodeID, newDep.ProcessName, newDep.NodeID).First(&existingDep).Error
-	return err == nil
+	required := dependency.Required
+	if err := s.db.Create(dependency).Error; err != nil {
+		return errors.NewDatabaseError("create process dependency", err)
+	}
+	// required 列默认值为 true，零值不会随 Create 写入
+	if !required {
+		if err := s.db.Model(dependency).Update("required", false).Error; err != nil {
+			return errors.NewDatabaseError("create process dependency", err)
+		}
+	}
+	return nil
 }
 
 // GetProcessDependencies 获取进程依赖列表
@@ -227,52 +331,40 @@ func (s *ProcessEnhancedService) DeleteProcessDependency(id uint) error {
 	return s.db.Delete(&models.ProcessDependency{}, id).Error
 }
 
-// GetStartupOrder 获取进程启动顺序
+// GetStartupOrder 获取进程启动顺序，存在循环依赖时返回错误
 func (s *ProcessEnhancedService) GetStartupOrder(processes []string, nodeID uint) ([]string, error) {
 	// 构建依赖图
-	dependencyMap := make(map[string][]string)
-	for _, process := range processes {
+	dependencyMap := make(map[ProcessUnit][]ProcessUnit)
+	units := make([]ProcessUnit, len(processes))
+	for i, process := range processes {
+		units[i] = ProcessUnit{Process: process}
 		deps, err := s.GetProcessDependencies(process, nodeID)
 		if err != nil {
 			return nil, err
 		}
 		for _, dep := range deps {
-			if dep.DependencyType == models.DependencyTypeStartAfter {
-				dependencyMap[process] = append(dependencyMap[process], dep.DependentProcess)
+			if dep.DependencyType == models.DependencyTypeStartAfter && dep.DependentNodeID == nodeID {
+				dependencyMap[units[i]] = append(dependencyMap[units[i]], ProcessUnit{Process: dep.DependentProcess})
 			}
 		}
 	}
 
 	// 拓扑排序
-	return s.topologicalSort(processes, dependencyMap), nil
-}
-
-// topologicalSort 拓扑排序
-func (s *ProcessEnhancedService) topologicalSort(processes []string, dependencies map[string][]string) []string {
-	// 简化的拓扑排序实现
-	visited := make(map[string]bool)
-	result := make([]string, 0)
-
-	var visit func(string)
-	visit = func(process string) {
-		if visited[process] {
-			return
+	order, cycles := orderUnits(units, func(unit ProcessUnit) []ProcessUnit {
+		return dependencyMap[unit]
+	})
+	if len(cycles) > 0 {
+		names := make([]string, len(cycles))
+		for i, unit := range cycles {
+			names[i] = unit.Process
 		}
-		visited[process] = true
-
-		// 先访问依赖的进程
-		for _, dep := range dependencies[process] {
-			visit(dep)
-		}
-
-		result = append(result, process)
+		return nil, errors.NewConflictError("process_dependency", "dependency cycle among "+strings.Join(names, ", "))
 	}
-
-	for _, process := range processes {
-		visit(process)
+	result := make([]string, len(order))
+	for i, unit := range order {
+		result[i] = unit.Process
 	}
-
-	return result
+	return result, nil
 }
 
 // CreateScheduledTask 创建定时任务
@@ -282,6 +374,9 @@ func (s *ProcessEnhancedService) CreateScheduledTask(task *models.ScheduledTask)
 	if err != nil {
 		return fmt.Errorf("invalid cron expression: %v", err)
 	}
+	if _, err := labels.Parse(task.NodeSelector); err != nil {
+		return err
+	}
 
 	// 计算下次运行时间
 	nextRun := s.calculateNextRun(task.CronExpr)
@@ -293,6 +388,8 @@ func (s *ProcessEnhancedService) CreateScheduledTask(task *models.ScheduledTask)
 	}
 
 	// 如果调度器正在运行且任务启用，添加到cron
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.scheduler.running && task.Enabled {
 		_, err = s.cronJob.AddFunc(task.CronExpr, func() {
 			s.executeScheduledTask(task)
@@ -356,6 +453,12 @@ func (s *ProcessEnhancedService) GetScheduledTaskByID(id uint) (*models.Schedule
 
 // UpdateScheduledTask 更新定时任务
 func (s *ProcessEnhancedService) UpdateScheduledTask(id uint, updates map[string]interface{}) error {
+	if selector, exists := updates["node_selector"]; exists {
+		value, _ := selector.(string)
+		if _, err := labels.Parse(value); err != nil {
+			return err
+		}
+	}
 	// 如果更新了cron表达式，重新计算下次运行时间
 	if cronExpr, exists := updates["cron_expr"]; exists {
 		nextRun := s.calculateNextRun(cronExpr.(string))
@@ -431,21 +534,120 @@ func (s *ProcessEnhancedService) executeScheduledTask(task *models.ScheduledTask
 
 // executeStartTask 执行启动任务
 func (s *ProcessEnhancedService) executeStartTask(task *models.ScheduledTask) (string, error) {
-	// 这里应该调用实际的进程启动逻辑
-	// 为了演示，返回模拟结果
-	return fmt.Sprintf("Started %s %s", task.TargetType, task.TargetID), nil
+	return s.executeProcessTask(task, models.TaskTypeStart)
 }
 
 // executeStopTask 执行停止任务
 func (s *ProcessEnhancedService) executeStopTask(task *models.ScheduledTask) (string, error) {
-	// 这里应该调用实际的进程停止逻辑
-	return fmt.Sprintf("Stopped %s %s", task.TargetType, task.TargetID), nil
+	return s.executeProcessTask(task, models.TaskTypeStop)
 }
 
 // executeRestartTask 执行重启任务
 func (s *ProcessEnhancedService) executeRestartTask(task *models.ScheduledTask) (string, error) {
-	// 这里应该调用实际的进程重启逻辑
-	return fmt.Sprintf("Restarted %s %s", task.TargetType, task.TargetID), nil
+	return s.executeProcessTask(task, models.TaskTypeRestart)
+}
+
+// executeProcessTask 在任务选中的节点上对目标进程执行操作：
+// process 目标按进程名匹配，group 目标按 supervisor 进程组匹配，node 目标作用于节点上的所有进程
+func (s *ProcessEnhancedService) executeProcessTask(task *models.ScheduledTask, operation string) (string, error) {
+	nodes, err := s.taskNodes(task)
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	failed := 0
+	for _, node := range nodes {
+		processes, err := s.supervisor.GetNodeProcesses(node.Name)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("%s: %v", node.Name, err))
+			failed++
+			continue
+		}
+		for _, process := range processes {
+			if !taskTargetsProcess(task, process) {
+				continue
+			}
+			fullName := process.Name
+			if process.Group != "" && process.Group != process.Name {
+				fullName = process.Group + ":" + process.Name
+			}
+			var opErr error
+			switch operation {
+			case models.TaskTypeStart:
+				opErr = node.StartProcess(fullName)
+			case models.TaskTypeStop:
+				opErr = node.StopProcess(fullName)
+			case models.TaskTypeRestart:
+				opErr = node.RestartProcess(fullName)
+			}
+			if opErr != nil {
+				lines = append(lines, fmt.Sprintf("%s/%s: %v", node.Name, fullName, opErr))
+				failed++
+			} else {
+				lines = append(lines, fmt.Sprintf("%s/%s: ok", node.Name, fullName))
+			}
+		}
+	}
+
+	if len(lines) == 0 {
+		return "", fmt.Errorf("no %s %s found on %d selected nodes", task.TargetType, task.TargetID, len(nodes))
+	}
+	output := strings.Join(lines, "\n")
+	if failed > 0 {
+		return output, fmt.Errorf("%d of %d operations failed", failed, len(lines))
+	}
+	return output, nil
+}
+
+// taskNodes 定时任务作用的节点：NodeSelector 按标签选择，NodeID 限定单个节点，node 目标按节点名过滤（"*" 表示全部选中的节点）
+func (s *ProcessEnhancedService) taskNodes(task *models.ScheduledTask) ([]*supervisor.Node, error) {
+	if s.supervisor == nil {
+		return nil, fmt.Errorf("supervisor service is not configured")
+	}
+	selector, err := labels.Parse(task.NodeSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeName := ""
+	if task.NodeID != nil {
+		var node models.Node
+		if err := s.db.Select("name").First(&node, *task.NodeID).Error; err != nil {
+			return nil, fmt.Errorf("node %d not found", *task.NodeID)
+		}
+		nodeName = node.Name
+	}
+	if task.TargetType == models.TargetTypeNode && task.TargetID != "*" {
+		if nodeName != "" && nodeName != task.TargetID {
+			return nil, fmt.Errorf("target node %s does not match node_id %d", task.TargetID, *task.NodeID)
+		}
+		nodeName = task.TargetID
+	}
+
+	var nodes []*supervisor.Node
+	for _, node := range s.supervisor.GetNodesBySelector(selector) {
+		if nodeName == "" || node.Name == nodeName {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes match the task")
+	}
+	return nodes, nil
+}
+
+// taskTargetsProcess 进程是否属于任务目标
+func taskTargetsProcess(task *models.ScheduledTask, process supervisor.Process) bool {
+	switch task.TargetType {
+	case models.TargetTypeProcess:
+		return process.Name == task.TargetID || process.Group+":"+process.Name == task.TargetID
+	case models.TargetTypeGroup:
+		return process.Group == task.TargetID
+	case models.TargetTypeNode:
+		return true
+	}
+	return false
 }
 
 // executeCustomCommand 执行自定义命令
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"superview/internal/models"
+)
+
+func setupSchedulerTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.ScheduledTask{}, &models.SystemSettings{}))
+	return db
+}
+
+func TestProcessEnhancedService_SchedulerFollowsLeadership(t *testing.T) {
+	db := setupSchedulerTestDB(t)
+
+	follower := NewProcessEnhancedService(db)
+	follower.HandleLeadershipChange(false)
+
+	// 非主节点开启调度器只记录开关，不运行 cron
+	require.NoError(t, follower.StartScheduler())
+	assert.False(t, follower.scheduler.running)
+	assert.True(t, follower.schedulerEnabled())
+
+	// 成为主节点后按开关恢复运行
+	follower.HandleLeadershipChange(true)
+	assert.True(t, follower.scheduler.running)
+
+	// 失去主节点身份时停止，但保留开关供下一任主节点使用
+	follower.HandleLeadershipChange(false)
+	assert.False(t, follower.scheduler.running)
+	assert.True(t, follower.schedulerEnabled())
+
+	leader := NewProcessEnhancedService(db)
+	leader.HandleLeadershipChange(true)
+	assert.True(t, leader.scheduler.running)
+
+	leader.StopScheduler()
+	assert.False(t, leader.scheduler.running)
+	assert.False(t, leader.schedulerEnabled())
+}
@@ -0,0 +1,598 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"superview/internal/errors"
+	"superview/internal/logger"
+	"superview/internal/models"
+	"superview/internal/supervisor"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 编排操作
+const (
+	OrchestrateStart   = "start"
+	OrchestrateStop    = "stop"
+	OrchestrateRestart = "restart"
+)
+
+// 编排步骤结果
+const (
+	StepSucceeded = "succeeded"
+	StepFailed    = "failed"
+	StepSkipped   = "skipped"
+)
+
+// defaultDependencyTimeout ProcessDependency.Timeout 未设置时等待依赖的时间
+const defaultDependencyTimeout = 30 * time.Second
+
+// ProcessUnit 一个节点上的一个进程，Process 为 supervisor 可识别的名称（group:name 或 name）
+type ProcessUnit struct {
+	Node    string `json:"node"`
+	Process string `json:"process"`
+}
+
+func (u ProcessUnit) String() string {
+	return u.Node + ":" + u.Process
+}
+
+// OrchestrationStep 编排中对一个进程的操作结果
+type OrchestrationStep struct {
+	Order     int      `json:"order"`
+	Node      string   `json:"node"`
+	Process   string   `json:"process"`
+	Action    string   `json:"action"`
+	Status    string   `json:"status"`
+	Error     string   `json:"error,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+	WaitedFor []string `json:"waited_for,omitempty"`
+	Cascade   bool     `json:"cascade,omitempty"` // 因强依赖被连带停止或重启
+	Duration  int64    `json:"duration_ms"`
+}
+
+// OrchestrationResult 一次按依赖顺序启停的结果
+type OrchestrationResult struct {
+	Operation string              `json:"operation"`
+	Steps     []OrchestrationStep `json:"steps"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+	Skipped   int                 `json:"skipped"`
+
+	onStep func(OrchestrationStep)
+}
+
+// OK 是否所有步骤都成功
+func (r *OrchestrationResult) OK() bool {
+	return r.Failed == 0 && r.Skipped == 0
+}
+
+func (r *OrchestrationResult) add(step OrchestrationStep) {
+	step.Order = len(r.Steps) + 1
+	switch step.Status {
+	case StepSucceeded:
+		r.Succeeded++
+	case StepFailed:
+		r.Failed++
+	case StepSkipped:
+		r.Skipped++
+	}
+	r.Steps = append(r.Steps, step)
+	if r.onStep != nil {
+		r.onStep(step)
+	}
+}
+
+// dependencyEdge 依赖关系中的另一端
+type dependencyEdge struct {
+	unit     ProcessUnit
+	required bool
+	timeout  time.Duration
+}
+
+// dependencyGraph 由 ProcessDependency 构建的依赖图
+// after: 进程启动前需要等待的进程（start_after）
+// stopFirst: 进程停止前需要先停止的进程（start_after 和 stop_before 的反向）
+// restartWith: 进程重启时需要一起重启的进程
+type dependencyGraph struct {
+	after       map[ProcessUnit][]dependencyEdge
+	stopFirst   map[ProcessUnit][]dependencyEdge
+	restartWith map[ProcessUnit][]ProcessUnit
+}
+
+// ProcessOrchestrator 按 ProcessDependency 顺序启停进程：启动按拓扑顺序并等待依赖进入 RUNNING，
+// 停止按相反顺序，强依赖的进程被连带停止
+type ProcessOrchestrator struct {
+	db                 *gorm.DB
+	controller         ProcessController
+	activityLogService *ActivityLogService
+	pollInterval       time.Duration
+}
+
+// NewProcessOrchestrator 创建进程编排器
+func NewProcessOrchestrator(db *gorm.DB, controller ProcessController, activityLogService ...*ActivityLogService) *ProcessOrchestrator {
+	o := &ProcessOrchestrator{
+		db:           db,
+		controller:   controller,
+		pollInterval: 500 * time.Millisecond,
+	}
+	if len(activityLogService) > 0 {
+		o.activityLogService = activityLogService[0]
+	}
+	return o
+}
+
+// NodeUnits 节点上的所有进程
+func (o *ProcessOrchestrator) NodeUnits(nodeName string) ([]ProcessUnit, error) {
+	processes, err := o.controller.GetNodeProcesses(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	units := make([]ProcessUnit, len(processes))
+	for i, process := range processes {
+		units[i] = ProcessUnit{Node: nodeName, Process: processFullName(process)}
+	}
+	return units, nil
+}
+
+// GroupUnits 各节点上属于 supervisor 进程组 groupName 的进程（未设置组名的进程属于 default），无法获取进程列表的节点跳过
+func (o *ProcessOrchestrator) GroupUnits(nodeNames []string, groupName string) []ProcessUnit {
+	var units []ProcessUnit
+	for _, nodeName := range nodeNames {
+		processes, err := o.controller.GetNodeProcesses(nodeName)
+		if err != nil {
+			logger.Warn("Skipping node for group operation", zap.String("node", nodeName), zap.Error(err))
+			continue
+		}
+		for _, process := range processes {
+			group := process.Group
+			if group == "" {
+				group = "default"
+			}
+			if group == groupName {
+				units = append(units, ProcessUnit{Node: nodeName, Process: processFullName(process)})
+			}
+		}
+	}
+	return units
+}
+
+// ProcessGroupUnits 进程分组（ProcessGroup）中的进程，按组内顺序排列
+func (o *ProcessOrchestrator) ProcessGroupUnits(groupID uint) ([]ProcessUnit, error) {
+	var group models.ProcessGroup
+	err := o.db.Preload("Processes", func(db *gorm.DB) *gorm.DB {
+		return db.Order(`"order" ASC, id ASC`)
+	}).First(&group, groupID).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("process_group", fmt.Sprintf("%d", groupID))
+		}
+		return nil, errors.NewDatabaseError("get process group", err)
+	}
+
+	names, err := o.nodeNames()
+	if err != nil {
+		return nil, err
+	}
+	units := make([]ProcessUnit, 0, len(group.Processes))
+	for _, item := range group.Processes {
+		nodeName, ok := names[item.NodeID]
+		if !ok {
+			return nil, errors.NewNotFoundError("node", fmt.Sprintf("%d", item.NodeID))
+		}
+		units = append(units, ProcessUnit{Node: nodeName, Process: item.ProcessName})
+	}
+	return units, nil
+}
+
+// Start 按依赖顺序启动进程
+func (o *ProcessOrchestrator) Start(units []ProcessUnit) (*OrchestrationResult, error) {
+	return o.Run(OrchestrateStart, units, nil)
+}
+
+// Stop 按依赖的相反顺序停止进程，依赖这些进程的强依赖进程先被停止
+func (o *ProcessOrchestrator) Stop(units []ProcessUnit) (*OrchestrationResult, error) {
+	return o.Run(OrchestrateStop, units, nil)
+}
+
+// Restart 先按相反顺序停止（含连带停止和 restart_with 的进程），再按依赖顺序启动
+func (o *ProcessOrchestrator) Restart(units []ProcessUnit) (*OrchestrationResult, error) {
+	return o.Run(OrchestrateRestart, units, nil)
+}
+
+// Run 执行 operation 对应的启停，每完成一步调用 onStep（可为 nil）
+func (o *ProcessOrchestrator) Run(operation string, units []ProcessUnit, onStep func(OrchestrationStep)) (*OrchestrationResult, error) {
+	switch operation {
+	case OrchestrateStart, OrchestrateStop, OrchestrateRestart:
+	default:
+		return nil, errors.NewValidationError("operation", "unknown operation "+operation)
+	}
+	graph, resolver, err := o.prepare(units)
+	if err != nil {
+		return nil, err
+	}
+	result := &OrchestrationResult{Operation: operation, Steps: []OrchestrationStep{}, onStep: onStep}
+
+	switch operation {
+	case OrchestrateStart:
+		o.runStart(result, resolver.canonicalAll(units), graph, resolver, nil)
+	case OrchestrateStop:
+		set, cascade := expandStopCascade(resolver.canonicalAll(units), graph)
+		o.runStop(result, set, cascade, graph)
+	case OrchestrateRestart:
+		set := resolver.canonicalAll(units)
+		set = expandRestartWith(set, graph)
+		set, cascade := expandStopCascade(set, graph)
+		stopped := o.runStop(result, set, cascade, graph)
+
+		// 停止失败的进程不再启动
+		var startable []ProcessUnit
+		for _, unit := range set {
+			if stopped[unit] {
+				startable = append(startable, unit)
+			}
+		}
+		o.runStart(result, startable, graph, resolver, cascade)
+	}
+	return result, nil
+}
+
+// prepare 加载依赖图
+func (o *ProcessOrchestrator) prepare(units []ProcessUnit) (*dependencyGraph, *processResolver, error) {
+	if len(units) == 0 {
+		return nil, nil, errors.NewValidationError("processes", "no processes to operate on")
+	}
+	resolver := newProcessResolver(o.controller)
+	graph, err := o.loadGraph(resolver)
+	if err != nil {
+		return nil, nil, err
+	}
+	return graph, resolver, nil
+}
+
+// nodeNames 节点 ID 到名称的映射（包含已删除的节点，避免依赖记录引用失效）
+func (o *ProcessOrchestrator) nodeNames() (map[uint]string, error) {
+	var nodes []models.Node
+	if err := o.db.Unscoped().Select("id", "name").Find(&nodes).Error; err != nil {
+		return nil, errors.NewDatabaseError("list nodes", err)
+	}
+	names := make(map[uint]string, len(nodes))
+	for _, node := range nodes {
+		names[node.ID] = node.Name
+	}
+	return names, nil
+}
+
+// loadGraph 从 ProcessDependency 构建依赖图；引用已不存在节点的记录被忽略
+func (o *ProcessOrchestrator) loadGraph(resolver *processResolver) (*dependencyGraph, error) {
+	names, err := o.nodeNames()
+	if err != nil {
+		return nil, err
+	}
+	var dependencies []models.ProcessDependency
+	if err := o.db.Order("id ASC").Find(&dependencies).Error; err != nil {
+		return nil, errors.NewDatabaseError("list process dependencies", err)
+	}
+
+	graph := &dependencyGraph{
+		after:       make(map[ProcessUnit][]dependencyEdge),
+		stopFirst:   make(map[ProcessUnit][]dependencyEdge),
+		restartWith: make(map[ProcessUnit][]ProcessUnit),
+	}
+	for _, dep := range dependencies {
+		node, ok := names[dep.NodeID]
+		dependencyNode, ok2 := names[dep.DependentNodeID]
+		if !ok || !ok2 {
+			continue
+		}
+		process := resolver.canonical(ProcessUnit{Node: node, Process: dep.ProcessName})
+		dependency := resolver.canonical(ProcessUnit{Node: dependencyNode, Process: dep.DependentProcess})
+		timeout := time.Duration(dep.Timeout) * time.Second
+		if timeout <= 0 {
+			timeout = defaultDependencyTimeout
+		}
+
+		switch dep.DependencyType {
+		case models.DependencyTypeStartAfter:
+			graph.after[process] = append(graph.after[process], dependencyEdge{unit: dependency, required: dep.Required, timeout: timeout})
+			graph.stopFirst[dependency] = append(graph.stopFirst[dependency], dependencyEdge{unit: process, required: dep.Required, timeout: timeout})
+		case models.DependencyTypeStopBefore:
+			graph.stopFirst[dependency] = append(graph.stopFirst[dependency], dependencyEdge{unit: process, required: dep.Required, timeout: timeout})
+		case models.DependencyTypeRestartWith:
+			graph.restartWith[dependency] = append(graph.restartWith[dependency], process)
+		}
+	}
+	return graph, nil
+}
+
+// runStart 按拓扑顺序启动，依赖失败的强依赖进程被跳过；返回成功启动的进程
+func (o *ProcessOrchestrator) runStart(result *OrchestrationResult, units []ProcessUnit, graph *dependencyGraph, resolver *processResolver, cascade map[ProcessUnit]bool) {
+	order, cycles := orderUnits(units, func(unit ProcessUnit) []ProcessUnit {
+		return edgeUnits(graph.after[unit])
+	})
+	failed := make(map[ProcessUnit]string)
+	for _, unit := range cycles {
+		failed[unit] = "dependency cycle"
+		result.add(OrchestrationStep{
+			Node: unit.Node, Process: unit.Process, Action: OrchestrateStart, Status: StepSkipped,
+			Error: "dependency cycle: " + describeCycle(unit, units, graph), Cascade: cascade[unit],
+		})
+	}
+
+	for _, unit := range order {
+		started := time.Now()
+		step := OrchestrationStep{Node: unit.Node, Process: unit.Process, Action: OrchestrateStart, Cascade: cascade[unit]}
+
+		for _, edge := range graph.after[unit] {
+			if reason, ok := failed[edge.unit]; ok {
+				if edge.required {
+					step.Status = StepSkipped
+					step.Error = fmt.Sprintf("dependency %s was not started: %s", edge.unit, reason)
+					break
+				}
+				step.Warnings = append(step.Warnings, fmt.Sprintf("optional dependency %s was not started", edge.unit))
+				continue
+			}
+			step.WaitedFor = append(step.WaitedFor, edge.unit.String())
+			if err := o.waitRunning(edge.unit, edge.timeout); err != nil {
+				if edge.required {
+					step.Status = StepFailed
+					step.Error = err.Error()
+					break
+				}
+				step.Warnings = append(step.Warnings, err.Error())
+			}
+		}
+
+		if step.Status == "" {
+			if err := o.controller.StartProcess(unit.Node, unit.Process); err != nil && !strings.Contains(err.Error(), "ALREADY_STARTED") {
+				step.Status = StepFailed
+				step.Error = err.Error()
+			} else {
+				step.Status = StepSucceeded
+			}
+		}
+		if step.Status != StepSucceeded {
+			failed[unit] = step.Error
+		}
+		step.Duration = time.Since(started).Milliseconds()
+		result.add(step)
+	}
+}
+
+// runStop 按相反的拓扑顺序停止，返回已停止的进程
+func (o *ProcessOrchestrator) runStop(result *OrchestrationResult, units []ProcessUnit, cascade map[ProcessUnit]bool, graph *dependencyGraph) map[ProcessUnit]bool {
+	order, cycles := orderUnits(units, func(unit ProcessUnit) []ProcessUnit {
+		return edgeUnits(graph.stopFirst[unit])
+	})
+	for _, unit := range cycles {
+		result.add(OrchestrationStep{
+			Node: unit.Node, Process: unit.Process, Action: OrchestrateStop, Status: StepSkipped,
+			Error: "dependency cycle in stop order", Cascade: cascade[unit],
+		})
+	}
+
+	stopped := make(map[ProcessUnit]bool)
+	for _, unit := range order {
+		started := time.Now()
+		step := OrchestrationStep{Node: unit.Node, Process: unit.Process, Action: OrchestrateStop, Cascade: cascade[unit]}
+		for _, edge := range graph.stopFirst[unit] {
+			if inUnits(units, edge.unit) && !stopped[edge.unit] {
+				step.Warnings = append(step.Warnings, fmt.Sprintf("dependent %s is still running", edge.unit))
+			}
+		}
+		if err := o.controller.StopProcess(unit.Node, unit.Process); err != nil && !strings.Contains(err.Error(), "NOT_RUNNING") {
+			step.Status = StepFailed
+			step.Error = err.Error()
+		} else {
+			step.Status = StepSucceeded
+			stopped[unit] = true
+		}
+		step.Duration = time.Since(started).Milliseconds()
+		result.add(step)
+	}
+	return stopped
+}
+
+// waitRunning 等待进程进入 RUNNING
+func (o *ProcessOrchestrator) waitRunning(unit ProcessUnit, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		// 依赖可以在其他节点上，节点不可达时记录原因并继续等待
+		processes, err := o.controller.GetNodeProcesses(unit.Node)
+		if err == nil {
+			for _, process := range processes {
+				if matchesProcess(process, unit.Process) {
+					if process.State == processStateRunning {
+						return nil
+					}
+					if process.State == processStateFatal {
+						return fmt.Errorf("dependency %s is FATAL", unit)
+					}
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("dependency %s not running after %s: %v", unit, timeout, err)
+			}
+			return fmt.Errorf("dependency %s not running after %s", unit, timeout)
+		}
+		time.Sleep(o.pollInterval)
+	}
+}
+
+// expandStopCascade 加入强依赖于待停止进程的进程（递归），返回完整集合和连带加入的进程
+func expandStopCascade(units []ProcessUnit, graph *dependencyGraph) ([]ProcessUnit, map[ProcessUnit]bool) {
+	cascade := make(map[ProcessUnit]bool)
+	result := append([]ProcessUnit(nil), units...)
+	for i := 0; i < len(result); i++ {
+		for _, edge := range graph.stopFirst[result[i]] {
+			if edge.required && !inUnits(result, edge.unit) {
+				result = append(result, edge.unit)
+				cascade[edge.unit] = true
+			}
+		}
+	}
+	return result, cascade
+}
+
+// expandRestartWith 加入 restart_with 关联的进程（递归）
+func expandRestartWith(units []ProcessUnit, graph *dependencyGraph) []ProcessUnit {
+	result := append([]ProcessUnit(nil), units...)
+	for i := 0; i < len(result); i++ {
+		for _, unit := range graph.restartWith[result[i]] {
+			if !inUnits(result, unit) {
+				result = append(result, unit)
+			}
+		}
+	}
+	return result
+}
+
+// orderUnits 拓扑排序：before(unit) 返回需要排在 unit 之前的进程，集合外的进程不参与排序
+// 没有约束的进程保持输入顺序；无法排序的进程（处于环中或依赖环中的进程）单独返回
+func orderUnits(units []ProcessUnit, before func(ProcessUnit) []ProcessUnit) ([]ProcessUnit, []ProcessUnit) {
+	index := make(map[ProcessUnit]int, len(units))
+	for i, unit := range units {
+		index[unit] = i
+	}
+	pending := make([]int, len(units))
+	next := make([][]int, len(units))
+	for i, unit := range units {
+		seen := make(map[int]bool)
+		for _, dep := range before(unit) {
+			j, ok := index[dep]
+			if !ok || j == i || seen[j] {
+				continue
+			}
+			seen[j] = true
+			pending[i]++
+			next[j] = append(next[j], i)
+		}
+	}
+
+	done := make([]bool, len(units))
+	order := make([]ProcessUnit, 0, len(units))
+	for {
+		picked := -1
+		for i := range units {
+			if !done[i] && pending[i] == 0 {
+				picked = i
+				break
+			}
+		}
+		if picked < 0 {
+			break
+		}
+		done[picked] = true
+		order = append(order, units[picked])
+		for _, j := range next[picked] {
+			pending[j]--
+		}
+	}
+
+	var cycles []ProcessUnit
+	for i, unit := range units {
+		if !done[i] {
+			cycles = append(cycles, unit)
+		}
+	}
+	return order, cycles
+}
+
+// describeCycle 找出经过 start 的依赖环用于报告，找不到时说明是依赖了环中的进程
+func describeCycle(start ProcessUnit, units []ProcessUnit, graph *dependencyGraph) string {
+	var path []ProcessUnit
+	visited := make(map[ProcessUnit]bool)
+	var visit func(unit ProcessUnit) bool
+	visit = func(unit ProcessUnit) bool {
+		path = append(path, unit)
+		visited[unit] = true
+		for _, edge := range graph.after[unit] {
+			if !inUnits(units, edge.unit) {
+				continue
+			}
+			if edge.unit == start {
+				path = append(path, start)
+				return true
+			}
+			if !visited[edge.unit] && visit(edge.unit) {
+				return true
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if !visit(start) {
+		return "depends on a process in a cycle"
+	}
+	parts := make([]string, len(path))
+	for i, unit := range path {
+		parts[i] = unit.String()
+	}
+	return strings.Join(parts, " -> ")
+}
+
+func edgeUnits(edges []dependencyEdge) []ProcessUnit {
+	units := make([]ProcessUnit, len(edges))
+	for i, edge := range edges {
+		units[i] = edge.unit
+	}
+	return units
+}
+
+func inUnits(units []ProcessUnit, unit ProcessUnit) bool {
+	for _, u := range units {
+		if u == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesProcess 进程名可以是 group:name 或 name
+func matchesProcess(process supervisor.Process, name string) bool {
+	return processFullName(process) == name || process.Name == name
+}
+
+// processResolver 把依赖记录和请求中的进程名统一为 supervisor 的完整名称，按节点缓存进程列表
+type processResolver struct {
+	controller ProcessController
+	processes  map[string][]supervisor.Process
+}
+
+func newProcessResolver(controller ProcessController) *processResolver {
+	return &processResolver{controller: controller, processes: make(map[string][]supervisor.Process)}
+}
+
+// canonical 返回统一名称，节点不可达或找不到进程时保持原样
+func (r *processResolver) canonical(unit ProcessUnit) ProcessUnit {
+	processes, ok := r.processes[unit.Node]
+	if !ok {
+		processes, _ = r.controller.GetNodeProcesses(unit.Node)
+		r.processes[unit.Node] = processes
+	}
+	for _, process := range processes {
+		if matchesProcess(process, unit.Process) {
+			return ProcessUnit{Node: unit.Node, Process: processFullName(process)}
+		}
+	}
+	return unit
+}
+
+// canonicalAll 统一名称并去重，保持顺序
+func (r *processResolver) canonicalAll(units []ProcessUnit) []ProcessUnit {
+	result := make([]ProcessUnit, 0, len(units))
+	for _, unit := range units {
+		unit = r.canonical(unit)
+		if !inUnits(result, unit) {
+			result = append(result, unit)
+		}
+	}
+	return result
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"superview/internal/models"
+	"superview/internal/supervisor"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newOrchestratorTest 节点 a 上有 db、cache、web、worker 四个进程
+func newOrchestratorTest(t *testing.T) (*ProcessOrchestrator, *fakeProcessController, uint) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "superview.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Node{}, &models.ProcessDependency{}, &models.ProcessGroup{}, &models.ProcessGroupItem{}))
+
+	node := &models.Node{Name: "a", Host: "127.0.0.1", Port: 9001, Environment: "prod"}
+	require.NoError(t, db.Create(node).Error)
+
+	fake := newFakeProcessController()
+	fake.nodes["a"] = []supervisor.Process{
+		{Name: "web", Group: "web", State: processStateRunning},
+		{Name: "db", Group: "db", State: processStateRunning},
+		{Name: "worker", Group: "jobs", State: processStateRunning},
+		{Name: "cache", Group: "cache", State: processStateRunning},
+	}
+	orchestrator := NewProcessOrchestrator(db, fake)
+	orchestrator.pollInterval = 5 * time.Millisecond
+	return orchestrator, fake, node.ID
+}
+
+func addDependency(t *testing.T, o *ProcessOrchestrator, nodeID uint, process, dependsOn, kind string, required bool) {
+	dep := &models.ProcessDependency{
+		ProcessName: process, NodeID: nodeID, DependentProcess: dependsOn, DependentNodeID: nodeID,
+		DependencyType: kind, Timeout: 1,
+	}
+	require.NoError(t, o.db.Create(dep).Error)
+	// Required 的数据库默认值为 true，零值需要单独更新
+	require.NoError(t, o.db.Model(dep).Update("required", required).Error)
+}
+
+func stepNames(result *OrchestrationResult) []string {
+	names := make([]string, len(result.Steps))
+	for i, step := range result.Steps {
+		names[i] = step.Action + " " + step.Process + " " + step.Status
+	}
+	return names
+}
+
+func TestOrchestratorStartOrder(t *testing.T) {
+	o, fake, nodeID := newOrchestratorTest(t)
+	addDependency(t, o, nodeID, "web", "db", models.DependencyTypeStartAfter, true)
+	addDependency(t, o, nodeID, "jobs:worker", "web", models.DependencyTypeStartAfter, true)
+	addDependency(t, o, nodeID, "web", "cache", models.DependencyTypeStartAfter, false)
+
+	units, err := o.NodeUnits("a")
+	require.NoError(t, err)
+	result, err := o.Start(units)
+	require.NoError(t, err)
+	assert.True(t, result.OK())
+	assert.Equal(t, []string{"start a/db", "start a/cache", "start a/web", "start a/jobs:worker"}, fake.ops)
+	assert.ElementsMatch(t, []string{"a:db", "a:cache"}, result.Steps[2].WaitedFor)
+}
+
+func TestOrchestratorRequiredDependencyFailure(t *testing.T) {
+	o, fake, nodeID := newOrchestratorTest(t)
+	addDependency(t, o, nodeID, "web", "db", models.DependencyTypeStartAfter, true)
+	addDependency(t, o, nodeID, "worker", "cache", models.DependencyTypeStartAfter, false)
+	fake.failing["a/db"] = true
+	fake.failing["a/cache"] = true
+
+	result, err := o.Start([]ProcessUnit{{"a", "web"}, {"a", "db"}, {"a", "worker"}, {"a", "cache"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"start db failed",
+		"start web skipped",
+		"start cache failed",
+		"start jobs:worker succeeded",
+	}, stepNames(result))
+	assert.Contains(t, result.Steps[1].Error, "a:db")
+	assert.NotEmpty(t, result.Steps[3].Warnings, "weak dependency only warns")
+	assert.Equal(t, 1, result.Succeeded)
+	assert.Equal(t, 2, result.Failed)
+	assert.Equal(t, 1, result.Skipped)
+}
+
+func TestOrchestratorWaitsForRunning(t *testing.T) {
+	o, fake, nodeID := newOrchestratorTest(t)
+	addDependency(t, o, nodeID, "web", "db", models.DependencyTypeStartAfter, true)
+	fake.nodes["a"][1].State = 0
+
+	// 依赖不在本次操作中且未运行时，等待超时后失败
+	result, err := o.Start([]ProcessUnit{{"a", "web"}})
+	require.NoError(t, err)
+	require.Len(t, result.Steps, 1)
+	assert.Equal(t, StepFailed, result.Steps[0].Status)
+	assert.Contains(t, result.Steps[0].Error, "not running after")
+	assert.Empty(t, fake.started)
+}
+
+func TestOrchestratorCycle(t *testing.T) {
+	o, fake, nodeID := newOrchestratorTest(t)
+	addDependency(t, o, nodeID, "web", "db", models.DependencyTypeStartAfter, true)
+	addDependency(t, o, nodeID, "db", "web", models.DependencyTypeStartAfter, true)
+
+	result, err := o.Start([]ProcessUnit{{"a", "web"}, {"a", "db"}, {"a", "cache"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"start a/cache"}, fake.ops)
+	assert.Equal(t, 2, result.Skipped)
+	assert.Contains(t, result.Steps[0].Error, "dependency cycle: a:web -> a:db -> a:web")
+}
+
+func TestOrchestratorStopCascade(t *testing.T) {
+	o, fake, nodeID := newOrchestratorTest(t)
+	addDependency(t, o, nodeID, "web", "db", models.DependencyTypeStartAfter, true)
+	addDependency(t, o, nodeID, "worker", "web", models.DependencyTypeStartAfter, true)
+	addDependency(t, o, nodeID, "cache", "db", models.DependencyTypeStopBefore, false)
+
+	result, err := o.Stop([]ProcessUnit{{"a", "db"}, {"a", "cache"}})
+	require.NoError(t, err)
+	assert.True(t, result.OK())
+	assert.Equal(t, []string{"stop a/cache", "stop a/jobs:worker", "stop a/web", "stop a/db"}, fake.ops)
+	cascaded := map[string]bool{}
+	for _, step := range result.Steps {
+		cascaded[step.Process] = step.Cascade
+	}
+	assert.Equal(t, map[string]bool{"db": false, "cache": false, "web": true, "jobs:worker": true}, cascaded)
+}
+
+func TestOrchestratorRestart(t *testing.T) {
+	o, fake, nodeID := newOrchestratorTest(t)
+	addDependency(t, o, nodeID, "web", "db", models.DependencyTypeStartAfter, true)
+	addDependency(t, o, nodeID, "cache", "db", models.DependencyTypeRestartWith, false)
+
+	result, err := o.Restart([]ProcessUnit{{"a", "db"}})
+	require.NoError(t, err)
+	assert.True(t, result.OK())
+	assert.Equal(t, []string{
+		"stop a/cache", "stop a/web", "stop a/db",
+		"start a/db", "start a/cache", "start a/web",
+	}, fake.ops)
+}
+
+func TestOrchestratorProcessGroupUnits(t *testing.T) {
+	o, _, nodeID := newOrchestratorTest(t)
+	group := &models.ProcessGroup{Name: "stack"}
+	require.NoError(t, o.db.Create(group).Error)
+	require.NoError(t, o.db.Create(&models.ProcessGroupItem{GroupID: group.ID, ProcessName: "web", NodeID: nodeID, Order: 2}).Error)
+	require.NoError(t, o.db.Create(&models.ProcessGroupItem{GroupID: group.ID, ProcessName: "db", NodeID: nodeID, Order: 1}).Error)
+
+	units, err := o.ProcessGroupUnits(group.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []ProcessUnit{{"a", "db"}, {"a", "web"}}, units)
+
+	_, err = o.ProcessGroupUnits(999)
+	assert.Error(t, err)
+}
+
+func TestOrchestratorWaitsForRemoteDependency(t *testing.T) {
+	o, fake, nodeID := newOrchestratorTest(t)
+	remote := &models.Node{Name: "b", Host: "127.0.0.2", Port: 9001, Environment: "shared"}
+	require.NoError(t, o.db.Create(remote).Error)
+	fake.nodes["b"] = []supervisor.Process{{Name: "worker", Group: "queue", State: 0}}
+	require.NoError(t, o.db.Create(&models.ProcessDependency{
+		ProcessName: "web", NodeID: nodeID, DependentProcess: "queue:worker", DependentNodeID: remote.ID,
+		DependencyType: models.DependencyTypeStartAfter, Required: true, Timeout: 2,
+	}).Error)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		fake.mu.Lock()
+		fake.nodes["b"][0].State = processStateRunning
+		fake.mu.Unlock()
+	}()
+	result, err := o.Start([]ProcessUnit{{"a", "web"}})
+	require.NoError(t, err)
+	require.True(t, result.OK())
+	assert.Equal(t, []string{"b:queue:worker"}, result.Steps[0].WaitedFor)
+
+	// 远程节点不可达时，超时错误中带上原因
+	delete(fake.nodes, "b")
+	result, err = o.Start([]ProcessUnit{{"a", "web"}})
+	require.NoError(t, err)
+	assert.Contains(t, result.Steps[0].Error, "node b not connected")
+}
@@ -0,0 +1,782 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"superview/internal/errors"
+	"superview/internal/logger"
+	"superview/internal/models"
+	"superview/internal/supervisor"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 滚动重启参数默认值和上限
+const (
+	DefaultRolloutHealthTimeout = 60
+	maxRolloutSeconds           = 3600
+	defaultRolloutPollInterval  = 2 * time.Second
+)
+
+// supervisor 进程状态码
+const (
+	processStateRunning = 20
+	processStateFatal   = 200
+)
+
+// ProcessController 编排进程启停需要的节点操作，由 *supervisor.SupervisorService 实现
+type ProcessController interface {
+	GetNodeProcesses(nodeName string) ([]supervisor.Process, error)
+	StopProcess(nodeName, processName string) error
+	StartProcess(nodeName, processName string) error
+}
+
+// RolloutNode 候选节点（调用方已按权限和标签选择器过滤）
+type RolloutNode struct {
+	Name        string
+	Environment string
+}
+
+// RolloutRequest 创建滚动重启的参数
+// ProcessName 与 GroupName 二选一；BatchSize 优先于 BatchPercent，都为 0 时每批一个节点
+// 允许失败的节点数取 MaxFailures 和 MaxFailurePercent 换算结果中较大的一个
+type RolloutRequest struct {
+	ProcessName          string
+	GroupName            string
+	Selector             string
+	Nodes                []RolloutNode
+	BatchSize            int
+	BatchPercent         int
+	PauseSeconds         int
+	HealthySeconds       int
+	HealthTimeoutSeconds int
+	MaxFailures          int
+	MaxFailurePercent    int
+	CreatedBy            string
+}
+
+// RolloutDetail 滚动重启及各节点进度
+type RolloutDetail struct {
+	models.Rollout
+	Targets []models.RolloutTarget `json:"targets"`
+}
+
+// rolloutRun 本实例正在执行的滚动重启；rollout 和 targets 由 mu 保护
+type rolloutRun struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	mu          sync.Mutex
+	rollout     *models.Rollout
+	targets     []models.RolloutTarget
+	paused      bool
+	resume      chan struct{}
+	abortReason string
+}
+
+// RolloutService 跨节点分批重启进程，只在主节点执行
+type RolloutService struct {
+	db                 *gorm.DB
+	supervisor         ProcessController
+	hub                WebSocketHub
+	activityLogService *ActivityLogService
+	pollInterval       time.Duration
+	runs               map[uint]*rolloutRun
+	mu                 sync.Mutex
+	wg                 sync.WaitGroup
+}
+
+// NewRolloutService 创建滚动重启服务，hub 为 nil 时不推送进度事件
+func NewRolloutService(db *gorm.DB, supervisorService ProcessController, hub WebSocketHub, activityLogService ...*ActivityLogService) *RolloutService {
+	s := &RolloutService{
+		db:           db,
+		supervisor:   supervisorService,
+		hub:          hub,
+		pollInterval: defaultRolloutPollInterval,
+		runs:         make(map[uint]*rolloutRun),
+	}
+	if len(activityLogService) > 0 {
+		s.activityLogService = activityLogService[0]
+	}
+	return s
+}
+
+// Start 校验参数、解析目标节点并在后台开始滚动重启
+func (s *RolloutService) Start(req *RolloutRequest) (*RolloutDetail, error) {
+	if err := validateRolloutRequest(req); err != nil {
+		return nil, err
+	}
+
+	var active int64
+	query := s.db.Model(&models.Rollout{}).
+		Where("status IN ?", []string{models.RolloutStatusPending, models.RolloutStatusRunning, models.RolloutStatusPaused})
+	if req.ProcessName != "" {
+		query = query.Where("process_name = ?", req.ProcessName)
+	} else {
+		query = query.Where("group_name = ?", req.GroupName)
+	}
+	if err := query.Count(&active).Error; err != nil {
+		return nil, errors.NewDatabaseError("count active rollouts", err)
+	}
+	if active > 0 {
+		return nil, errors.NewConflictError("rollout", "another rollout for the same target is still in progress")
+	}
+
+	targets := s.resolveTargets(req)
+	if len(targets) == 0 {
+		return nil, errors.NewValidationError("nodes", "no connected node runs the target process")
+	}
+
+	batchSize := rolloutBatchSize(len(targets), req.BatchSize, req.BatchPercent)
+	for i := range targets {
+		targets[i].Batch = i/batchSize + 1
+	}
+	maxFailures := req.MaxFailures
+	if byPercent := len(targets) * req.MaxFailurePercent / 100; byPercent > maxFailures {
+		maxFailures = byPercent
+	}
+	healthTimeout := req.HealthTimeoutSeconds
+	if healthTimeout == 0 {
+		healthTimeout = DefaultRolloutHealthTimeout
+	}
+
+	now := time.Now()
+	rollout := &models.Rollout{
+		ProcessName:          req.ProcessName,
+		GroupName:            req.GroupName,
+		Selector:             req.Selector,
+		BatchSize:            batchSize,
+		PauseSeconds:         req.PauseSeconds,
+		HealthySeconds:       req.HealthySeconds,
+		HealthTimeoutSeconds: healthTimeout,
+		MaxFailures:          maxFailures,
+		Status:               models.RolloutStatusRunning,
+		TotalBatches:         targets[len(targets)-1].Batch,
+		TotalTargets:         len(targets),
+		StartedAt:            &now,
+		CreatedBy:            req.CreatedBy,
+	}
+	rollout.SetTargets(targets)
+	if err := s.db.Create(rollout).Error; err != nil {
+		return nil, errors.NewDatabaseError("create rollout", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &rolloutRun{ctx: ctx, cancel: cancel, rollout: rollout, targets: targets}
+	s.mu.Lock()
+	s.runs[rollout.ID] = run
+	s.mu.Unlock()
+
+	logger.Info("Rollout started",
+		zap.Uint("rollout_id", rollout.ID),
+		zap.String("target", rolloutTargetName(rollout)),
+		zap.Int("nodes", len(targets)),
+		zap.Int("batches", rollout.TotalBatches))
+	detail := run.detail()
+	s.broadcast(detail)
+
+	s.wg.Add(1)
+	go s.execute(run)
+	return detail, nil
+}
+
+// validateRolloutRequest 校验滚动重启参数
+func validateRolloutRequest(req *RolloutRequest) error {
+	if (req.ProcessName == "") == (req.GroupName == "") {
+		return errors.NewValidationError("process_name", "exactly one of process_name and group_name is required")
+	}
+	if req.BatchSize < 0 {
+		return errors.NewValidationError("batch_size", "batch_size must not be negative")
+	}
+	if req.BatchPercent < 0 || req.BatchPercent > 100 {
+		return errors.NewValidationError("batch_percent", "batch_percent must be between 0 and 100")
+	}
+	if req.MaxFailures < 0 {
+		return errors.NewValidationError("max_failures", "max_failures must not be negative")
+	}
+	if req.MaxFailurePercent < 0 || req.MaxFailurePercent > 100 {
+		return errors.NewValidationError("max_failure_percent", "max_failure_percent must be between 0 and 100")
+	}
+	for field, value := range map[string]int{
+		"pause_seconds":          req.PauseSeconds,
+		"healthy_seconds":        req.HealthySeconds,
+		"health_timeout_seconds": req.HealthTimeoutSeconds,
+	} {
+		if value < 0 || value > maxRolloutSeconds {
+			return errors.NewValidationError(field, fmt.Sprintf("%s must be between 0 and %d", field, maxRolloutSeconds))
+		}
+	}
+	return nil
+}
+
+// rolloutBatchSize 计算每批节点数
+func rolloutBatchSize(total, size, percent int) int {
+	if size == 0 && percent > 0 {
+		size = (total*percent + 99) / 100
+	}
+	if size <= 0 {
+		size = 1
+	}
+	if size > total {
+		size = total
+	}
+	return size
+}
+
+// resolveTargets 找出运行目标进程的节点，按节点名排序；获取进程列表失败的节点跳过
+func (s *RolloutService) resolveTargets(req *RolloutRequest) []models.RolloutTarget {
+	targets := make([]models.RolloutTarget, 0, len(req.Nodes))
+	for _, node := range req.Nodes {
+		processes, err := s.supervisor.GetNodeProcesses(node.Name)
+		if err != nil {
+			logger.Warn("Skipping node for rollout",
+				zap.String("node", node.Name),
+				zap.Error(err))
+			continue
+		}
+		var names []string
+		for _, process := range processes {
+			group := process.Group
+			if group == "" {
+				group = "default"
+			}
+			if (req.ProcessName != "" && process.Name == req.ProcessName) ||
+				(req.GroupName != "" && group == req.GroupName) {
+				names = append(names, processFullName(process))
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		sort.Strings(names)
+		targets = append(targets, models.RolloutTarget{
+			Node:        node.Name,
+			Environment: node.Environment,
+			Processes:   names,
+			Status:      models.RolloutTargetPending,
+		})
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Node < targets[j].Node })
+	return targets
+}
+
+// processFullName 返回 supervisor 可识别的 group:name 形式（组名与进程名相同时只用进程名）
+func processFullName(process supervisor.Process) string {
+	if process.Group == "" || process.Group == process.Name {
+		return process.Name
+	}
+	return process.Group + ":" + process.Name
+}
+
+// execute 逐批执行滚动重启
+func (s *RolloutService) execute(run *rolloutRun) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.runs, run.rollout.ID)
+		s.mu.Unlock()
+		run.cancel()
+	}()
+
+	rollout := run.rollout
+	for batch := 1; batch <= rollout.TotalBatches; batch++ {
+		if !run.waitIfPaused() {
+			s.finish(run)
+			return
+		}
+
+		var nodes []string
+		run.mu.Lock()
+		rollout.CurrentBatch = batch
+		for _, target := range run.targets {
+			if target.Batch == batch {
+				nodes = append(nodes, target.Node)
+			}
+		}
+		run.mu.Unlock()
+		s.save(run)
+		s.logStep(rollout, "INFO", "rollout_batch_started",
+			fmt.Sprintf("Rollout %d batch %d/%d started on %s", rollout.ID, batch, rollout.TotalBatches, strings.Join(nodes, ", ")))
+
+		var wg sync.WaitGroup
+		for i := range run.targets {
+			if run.targets[i].Batch != batch {
+				continue
+			}
+			wg.Add(1)
+			go func(index int) {
+				defer wg.Done()
+				s.restartTarget(run, index)
+			}(i)
+		}
+		wg.Wait()
+
+		run.mu.Lock()
+		failed := rollout.FailedTargets
+		run.mu.Unlock()
+		if run.ctx.Err() != nil {
+			s.finish(run)
+			return
+		}
+		if failed > rollout.MaxFailures {
+			run.mu.Lock()
+			rollout.ErrorMsg = fmt.Sprintf("aborted after batch %d: %d node(s) failed, %d allowed", batch, failed, rollout.MaxFailures)
+			run.mu.Unlock()
+			s.finish(run)
+			return
+		}
+
+		if batch < rollout.TotalBatches && rollout.PauseSeconds > 0 {
+			select {
+			case <-time.After(time.Duration(rollout.PauseSeconds) * time.Second):
+			case <-run.ctx.Done():
+				s.finish(run)
+				return
+			}
+		}
+	}
+	s.finish(run)
+}
+
+// restartTarget 重启一个节点上的目标进程并等待健康检查通过
+func (s *RolloutService) restartTarget(run *rolloutRun, index int) {
+	run.mu.Lock()
+	now := time.Now()
+	target := &run.targets[index]
+	target.Status = models.RolloutTargetRestarting
+	target.StartedAt = &now
+	node, processes := target.Node, target.Processes
+	run.mu.Unlock()
+	s.save(run)
+
+	err := s.restartProcesses(node, processes)
+	if err == nil {
+		err = s.waitHealthy(run, node, processes)
+	}
+
+	run.mu.Lock()
+	finished := time.Now()
+	target = &run.targets[index]
+	target.FinishedAt = &finished
+	switch {
+	case err == nil:
+		target.Status = models.RolloutTargetHealthy
+		run.rollout.SucceededTargets++
+	case run.ctx.Err() != nil:
+		target.Status = models.RolloutTargetSkipped
+		target.Error = "interrupted before the health check passed"
+	default:
+		target.Status = models.RolloutTargetFailed
+		target.Error = err.Error()
+		run.rollout.FailedTargets++
+	}
+	rollout := run.rollout
+	run.mu.Unlock()
+	s.save(run)
+
+	if err == nil {
+		s.logStep(rollout, "INFO", "rollout_node_restarted",
+			fmt.Sprintf("Rollout %d restarted %s on node %s", rollout.ID, strings.Join(processes, ", "), node))
+	} else {
+		s.logStep(rollout, "WARNING", "rollout_node_failed",
+			fmt.Sprintf("Rollout %d failed on node %s: %v", rollout.ID, node, err))
+	}
+}
+
+// restartProcesses 停止并启动进程；已停止或已启动的进程不视为失败
+func (s *RolloutService) restartProcesses(node string, processes []string) error {
+	for _, process := range processes {
+		if err := s.supervisor.StopProcess(node, process); err != nil && !strings.Contains(err.Error(), "NOT_RUNNING") {
+			return fmt.Errorf("stop %s: %v", process, err)
+		}
+		if err := s.supervisor.StartProcess(node, process); err != nil && !strings.Contains(err.Error(), "ALREADY_STARTED") {
+			return fmt.Errorf("start %s: %v", process, err)
+		}
+	}
+	return nil
+}
+
+// waitHealthy 等待所有进程 RUNNING 且持续 HealthySeconds；进程 FATAL 或超时视为失败
+func (s *RolloutService) waitHealthy(run *rolloutRun, node string, processes []string) error {
+	healthy := time.Duration(run.rollout.HealthySeconds) * time.Second
+	deadline := time.Now().Add(healthy + time.Duration(run.rollout.HealthTimeoutSeconds)*time.Second)
+
+	for {
+		states, err := s.supervisor.GetNodeProcesses(node)
+		if err == nil {
+			ready, fatal := checkRolloutHealth(states, processes, healthy)
+			if fatal != "" {
+				return fmt.Errorf("process %s entered FATAL state", fatal)
+			}
+			if ready {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("health check timed out: %v", err)
+			}
+			return fmt.Errorf("health check timed out: processes not running for %s", healthy)
+		}
+		select {
+		case <-time.After(s.pollInterval):
+		case <-run.ctx.Done():
+			return run.ctx.Err()
+		}
+	}
+}
+
+// checkRolloutHealth 检查进程是否都已健康，返回是否全部健康和进入 FATAL 的进程名
+func checkRolloutHealth(states []supervisor.Process, processes []string, healthy time.Duration) (bool, string) {
+	ready := true
+	for _, name := range processes {
+		found := false
+		for _, state := range states {
+			if processFullName(state) != name {
+				continue
+			}
+			found = true
+			if state.State == processStateFatal {
+				return false, name
+			}
+			if state.State != processStateRunning || state.Uptime < healthy {
+				ready = false
+			}
+		}
+		if !found {
+			ready = false
+		}
+	}
+	return ready, ""
+}
+
+// finish 根据结束原因设置最终状态，未执行的节点标记为跳过
+func (s *RolloutService) finish(run *rolloutRun) {
+	run.mu.Lock()
+	rollout := run.rollout
+	now := time.Now()
+	rollout.CompletedAt = &now
+	switch {
+	case run.abortReason != "":
+		rollout.Status = models.RolloutStatusFailed
+		rollout.ErrorMsg = run.abortReason
+	case run.ctx.Err() != nil:
+		rollout.Status = models.RolloutStatusCancelled
+	case rollout.ErrorMsg != "":
+		rollout.Status = models.RolloutStatusFailed
+	default:
+		rollout.Status = models.RolloutStatusCompleted
+	}
+	for i := range run.targets {
+		if run.targets[i].Status == models.RolloutTargetPending {
+			run.targets[i].Status = models.RolloutTargetSkipped
+		}
+	}
+	status, message := rollout.Status, rollout.ErrorMsg
+	run.mu.Unlock()
+	s.save(run)
+
+	level := "INFO"
+	if status == models.RolloutStatusFailed {
+		level = "ERROR"
+	}
+	text := fmt.Sprintf("Rollout %d of %s %s (%d succeeded, %d failed)",
+		rollout.ID, rolloutTargetName(rollout), status, rollout.SucceededTargets, rollout.FailedTargets)
+	if message != "" {
+		text += ": " + message
+	}
+	s.logStep(rollout, level, "rollout_"+status, text)
+	logger.Info("Rollout finished", zap.Uint("rollout_id", rollout.ID), zap.String("status", status))
+}
+
+// waitIfPaused 暂停时阻塞直到恢复，被取消时返回 false
+func (r *rolloutRun) waitIfPaused() bool {
+	for {
+		r.mu.Lock()
+		paused, resume := r.paused, r.resume
+		r.mu.Unlock()
+		if !paused {
+			return r.ctx.Err() == nil
+		}
+		select {
+		case <-resume:
+		case <-r.ctx.Done():
+			return false
+		}
+	}
+}
+
+// detail 生成当前进度快照
+func (r *rolloutRun) detail() *RolloutDetail {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return snapshotRollout(r.rollout, r.targets)
+}
+
+func snapshotRollout(rollout *models.Rollout, targets []models.RolloutTarget) *RolloutDetail {
+	detail := &RolloutDetail{Rollout: *rollout, Targets: make([]models.RolloutTarget, len(targets))}
+	copy(detail.Targets, targets)
+	detail.Rollout.SetTargets(detail.Targets)
+	return detail
+}
+
+// save 持久化进度并推送 WebSocket 事件
+func (s *RolloutService) save(run *rolloutRun) {
+	detail := run.detail()
+	if err := s.db.Save(&detail.Rollout).Error; err != nil {
+		logger.Error("Failed to save rollout progress",
+			zap.Uint("rollout_id", detail.ID),
+			zap.Error(err))
+	}
+	s.broadcast(detail)
+}
+
+// broadcast 推送 rollout_progress 事件
+func (s *RolloutService) broadcast(detail *RolloutDetail) {
+	if s.hub == nil {
+		return
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"type":      "rollout_progress",
+		"data":      detail,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		logger.Error("Failed to marshal rollout event", zap.Uint("rollout_id", detail.ID), zap.Error(err))
+		return
+	}
+	s.hub.Broadcast(data)
+}
+
+// logStep 记录执行步骤到活动日志
+func (s *RolloutService) logStep(rollout *models.Rollout, level, action, message string) {
+	if s.activityLogService == nil {
+		return
+	}
+	if err := s.activityLogService.LogSystemEvent(level, action, "rollout", fmt.Sprintf("rollout-%d", rollout.ID), message, nil); err != nil {
+		logger.Warn("Failed to record rollout step", zap.Uint("rollout_id", rollout.ID), zap.Error(err))
+	}
+}
+
+// rolloutTargetName 用于日志的目标描述
+func rolloutTargetName(rollout *models.Rollout) string {
+	if rollout.GroupName != "" {
+		return "group " + rollout.GroupName
+	}
+	return "process " + rollout.ProcessName
+}
+
+// Pause 暂停滚动重启，当前批次完成后不再开始新批次
+func (s *RolloutService) Pause(id uint) (*RolloutDetail, error) {
+	run, err := s.activeRun(id)
+	if err != nil {
+		return nil, err
+	}
+	run.mu.Lock()
+	if run.rollout.Status != models.RolloutStatusRunning {
+		status := run.rollout.Status
+		run.mu.Unlock()
+		return nil, errors.NewConflictError("rollout", "rollout is "+status)
+	}
+	run.paused = true
+	run.resume = make(chan struct{})
+	run.rollout.Status = models.RolloutStatusPaused
+	run.mu.Unlock()
+	s.save(run)
+	return run.detail(), nil
+}
+
+// Resume 恢复已暂停的滚动重启
+func (s *RolloutService) Resume(id uint) (*RolloutDetail, error) {
+	run, err := s.activeRun(id)
+	if err != nil {
+		return nil, err
+	}
+	run.mu.Lock()
+	if run.rollout.Status != models.RolloutStatusPaused {
+		status := run.rollout.Status
+		run.mu.Unlock()
+		return nil, errors.NewConflictError("rollout", "rollout is "+status)
+	}
+	run.paused = false
+	close(run.resume)
+	run.rollout.Status = models.RolloutStatusRunning
+	run.mu.Unlock()
+	s.save(run)
+	return run.detail(), nil
+}
+
+// Cancel 取消滚动重启；正在等待健康检查的节点被中断，未开始的节点跳过
+func (s *RolloutService) Cancel(id uint) (*RolloutDetail, error) {
+	s.mu.Lock()
+	run, exists := s.runs[id]
+	s.mu.Unlock()
+	if exists {
+		run.cancel()
+		s.waitRun(id)
+		return s.Get(id)
+	}
+
+	// 没有本地执行的记录（执行实例已退出），直接标记为取消
+	rollout, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if rollout.IsTerminal() {
+		return nil, errors.NewConflictError("rollout", "rollout is already "+rollout.Status)
+	}
+	now := time.Now()
+	rollout.Status = models.RolloutStatusCancelled
+	rollout.CompletedAt = &now
+	if err := s.db.Save(rollout).Error; err != nil {
+		return nil, errors.NewDatabaseError("cancel rollout", err)
+	}
+	detail := snapshotRollout(rollout, rollout.GetTargets())
+	s.broadcast(detail)
+	return detail, nil
+}
+
+// waitRun 等待本地执行结束
+func (s *RolloutService) waitRun(id uint) {
+	for {
+		s.mu.Lock()
+		_, exists := s.runs[id]
+		s.mu.Unlock()
+		if !exists {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// activeRun 获取本实例正在执行的滚动重启
+func (s *RolloutService) activeRun(id uint) (*rolloutRun, error) {
+	s.mu.Lock()
+	run, exists := s.runs[id]
+	s.mu.Unlock()
+	if exists {
+		return run, nil
+	}
+	rollout, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	return nil, errors.NewConflictError("rollout", "rollout is "+rollout.Status)
+}
+
+func (s *RolloutService) load(id uint) (*models.Rollout, error) {
+	var rollout models.Rollout
+	if err := s.db.First(&rollout, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("rollout", fmt.Sprintf("%d", id))
+		}
+		return nil, errors.NewDatabaseError("get rollout", err)
+	}
+	return &rollout, nil
+}
+
+// Get 获取滚动重启详情，本实例正在执行时返回内存中的最新进度
+func (s *RolloutService) Get(id uint) (*RolloutDetail, error) {
+	s.mu.Lock()
+	run, exists := s.runs[id]
+	s.mu.Unlock()
+	if exists {
+		return run.detail(), nil
+	}
+	rollout, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	return snapshotRollout(rollout, rollout.GetTargets()), nil
+}
+
+// List 分页列出滚动重启，status 为空时不过滤
+func (s *RolloutService) List(offset, limit int, status string) ([]*RolloutDetail, int64, error) {
+	query := s.db.Model(&models.Rollout{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.NewDatabaseError("count rollouts", err)
+	}
+	var rollouts []models.Rollout
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&rollouts).Error; err != nil {
+		return nil, 0, errors.NewDatabaseError("list rollouts", err)
+	}
+	details := make([]*RolloutDetail, len(rollouts))
+	for i := range rollouts {
+		details[i] = snapshotRollout(&rollouts[i], rollouts[i].GetTargets())
+	}
+	return details, total, nil
+}
+
+// HandleLeadershipChange 滚动重启只在主节点执行：失去主节点身份时中止本地执行，
+// 成为主节点时把其他实例遗留的未完成记录标记为失败
+func (s *RolloutService) HandleLeadershipChange(isLeader bool) {
+	if !isLeader {
+		s.abortAll("rollout aborted: instance lost leadership")
+		return
+	}
+	s.failOrphaned()
+}
+
+// abortAll 中止本实例所有执行中的滚动重启
+func (s *RolloutService) abortAll(reason string) {
+	s.mu.Lock()
+	runs := make([]*rolloutRun, 0, len(s.runs))
+	for _, run := range s.runs {
+		runs = append(runs, run)
+	}
+	s.mu.Unlock()
+
+	for _, run := range runs {
+		run.mu.Lock()
+		run.abortReason = reason
+		run.mu.Unlock()
+		run.cancel()
+	}
+	if len(runs) > 0 {
+		logger.Warn("Aborted active rollouts", zap.Int("count", len(runs)), zap.String("reason", reason))
+	}
+}
+
+// failOrphaned 把没有本地执行的未完成记录标记为失败
+func (s *RolloutService) failOrphaned() {
+	var rollouts []models.Rollout
+	err := s.db.Where("status IN ?", []string{models.RolloutStatusPending, models.RolloutStatusRunning, models.RolloutStatusPaused}).
+		Find(&rollouts).Error
+	if err != nil {
+		logger.Error("Failed to load unfinished rollouts", zap.Error(err))
+		return
+	}
+
+	for i := range rollouts {
+		s.mu.Lock()
+		_, exists := s.runs[rollouts[i].ID]
+		s.mu.Unlock()
+		if exists {
+			continue
+		}
+		now := time.Now()
+		rollouts[i].Status = models.RolloutStatusFailed
+		rollouts[i].ErrorMsg = "rollout interrupted: the instance running it stopped"
+		rollouts[i].CompletedAt = &now
+		if err := s.db.Save(&rollouts[i]).Error; err != nil {
+			logger.Error("Failed to mark orphaned rollout as failed", zap.Uint("rollout_id", rollouts[i].ID), zap.Error(err))
+			continue
+		}
+		logger.Warn("Marked orphaned rollout as failed", zap.Uint("rollout_id", rollouts[i].ID))
+	}
+}
+
+// Wait 等待所有执行中的滚动重启结束（用于关闭和测试）
+func (s *RolloutService) Wait() {
+	s.wg.Wait()
+}
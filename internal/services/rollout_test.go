@@ -0,0 +1,287 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"superview/internal/models"
+	"superview/internal/supervisor"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeProcessController 记录启停顺序，可以让指定节点启动失败或阻塞
+type fakeProcessController struct {
+	mu      sync.Mutex
+	nodes   map[string][]supervisor.Process
+	failing map[string]bool
+	gate    chan struct{} // 非 nil 时 StartProcess 等待放行
+	started []string
+	ops     []string // "start node/process" 或 "stop node/process"
+}
+
+func newFakeProcessController(nodes ...string) *fakeProcessController {
+	f := &fakeProcessController{nodes: make(map[string][]supervisor.Process), failing: make(map[string]bool)}
+	for _, node := range nodes {
+		f.nodes[node] = []supervisor.Process{
+			{Name: "app", Group: "app", State: processStateRunning, Uptime: time.Hour},
+			{Name: "worker", Group: "jobs", State: processStateRunning, Uptime: time.Hour},
+		}
+	}
+	return f
+}
+
+func (f *fakeProcessController) GetNodeProcesses(nodeName string) ([]supervisor.Process, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	processes, ok := f.nodes[nodeName]
+	if !ok {
+		return nil, fmt.Errorf("node %s not connected", nodeName)
+	}
+	return append([]supervisor.Process(nil), processes...), nil
+}
+
+func (f *fakeProcessController) StopProcess(nodeName, processName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ops = append(f.ops, "stop "+nodeName+"/"+processName)
+	f.setState(nodeName, processName, 0)
+	return nil
+}
+
+func (f *fakeProcessController) StartProcess(nodeName, processName string) error {
+	if f.gate != nil {
+		<-f.gate
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.started = append(f.started, nodeName+"/"+processName)
+	f.ops = append(f.ops, "start "+nodeName+"/"+processName)
+	if f.failing[nodeName] || f.failing[nodeName+"/"+processName] {
+		return fmt.Errorf("spawn error")
+	}
+	f.setState(nodeName, processName, processStateRunning)
+	return nil
+}
+
+// setState 调用方持有锁
+func (f *fakeProcessController) setState(nodeName, processName string, state int) {
+	for i, process := range f.nodes[nodeName] {
+		if matchesProcess(process, processName) {
+			f.nodes[nodeName][i].State = state
+		}
+	}
+}
+
+func (f *fakeProcessController) startedCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.started)
+}
+
+func newRolloutTestService(t *testing.T, fake *fakeProcessController) *RolloutService {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "superview.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Rollout{}, &models.ActivityLog{}))
+	service := NewRolloutService(db, fake, nil, NewActivityLogService(db))
+	service.pollInterval = 5 * time.Millisecond
+	return service
+}
+
+func rolloutNodes(names ...string) []RolloutNode {
+	nodes := make([]RolloutNode, len(names))
+	for i, name := range names {
+		nodes[i] = RolloutNode{Name: name, Environment: "prod"}
+	}
+	return nodes
+}
+
+func TestRolloutBatches(t *testing.T) {
+	fake := newFakeProcessController("c", "a", "b", "d", "e")
+	service := newRolloutTestService(t, fake)
+
+	detail, err := service.Start(&RolloutRequest{
+		ProcessName:  "app",
+		Nodes:        rolloutNodes("c", "a", "b", "d", "e", "offline"),
+		BatchPercent: 40,
+		CreatedBy:    "admin",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, detail.TotalTargets, "offline nodes are not targets")
+	assert.Equal(t, 2, detail.BatchSize)
+	assert.Equal(t, 3, detail.TotalBatches)
+	service.Wait()
+
+	result, err := service.Get(detail.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutStatusCompleted, result.Status)
+	assert.Equal(t, 5, result.SucceededTargets)
+	for _, target := range result.Targets {
+		assert.Equal(t, models.RolloutTargetHealthy, target.Status, target.Node)
+	}
+	// 下一批在上一批全部完成后才开始
+	assert.ElementsMatch(t, []string{"a/app", "b/app"}, fake.started[:2])
+	assert.ElementsMatch(t, []string{"c/app", "d/app"}, fake.started[2:4])
+	assert.Equal(t, "e/app", fake.started[4])
+
+	var steps int64
+	service.db.Model(&models.ActivityLog{}).Where("resource = ?", "rollout").Count(&steps)
+	assert.Equal(t, int64(3+5+1), steps, "batch starts, node results and the final status are logged")
+}
+
+func TestRolloutGroupTargets(t *testing.T) {
+	fake := newFakeProcessController("a")
+	service := newRolloutTestService(t, fake)
+
+	detail, err := service.Start(&RolloutRequest{GroupName: "jobs", Nodes: rolloutNodes("a"), CreatedBy: "admin"})
+	require.NoError(t, err)
+	service.Wait()
+	assert.Equal(t, []string{"jobs:worker"}, detail.Targets[0].Processes)
+	assert.Equal(t, []string{"a/jobs:worker"}, fake.started)
+}
+
+func TestRolloutAbortsOnFailureThreshold(t *testing.T) {
+	fake := newFakeProcessController("a", "b", "c")
+	fake.failing["b"] = true
+	service := newRolloutTestService(t, fake)
+
+	detail, err := service.Start(&RolloutRequest{ProcessName: "app", Nodes: rolloutNodes("a", "b", "c"), CreatedBy: "admin"})
+	require.NoError(t, err)
+	service.Wait()
+
+	result, err := service.Get(detail.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutStatusFailed, result.Status)
+	assert.Equal(t, 2, result.CurrentBatch)
+	assert.Equal(t, 1, result.FailedTargets)
+	assert.Contains(t, result.ErrorMsg, "1 node(s) failed")
+	assert.Equal(t, models.RolloutTargetFailed, result.Targets[1].Status)
+	assert.Equal(t, models.RolloutTargetSkipped, result.Targets[2].Status)
+
+	// 允许一个失败时继续执行
+	fake.started = nil
+	detail, err = service.Start(&RolloutRequest{ProcessName: "app", Nodes: rolloutNodes("a", "b", "c"), MaxFailures: 1, CreatedBy: "admin"})
+	require.NoError(t, err)
+	service.Wait()
+	result, err = service.Get(detail.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutStatusCompleted, result.Status)
+	assert.Equal(t, 2, result.SucceededTargets)
+}
+
+func TestRolloutHealthGate(t *testing.T) {
+	fake := newFakeProcessController("a")
+	fake.nodes["a"][0].Uptime = 0
+	service := newRolloutTestService(t, fake)
+
+	detail, err := service.Start(&RolloutRequest{ProcessName: "app", Nodes: rolloutNodes("a"), HealthySeconds: 1, HealthTimeoutSeconds: 0, CreatedBy: "admin"})
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	current, err := service.Get(detail.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutTargetRestarting, current.Targets[0].Status, "waits until the process has been running long enough")
+
+	fake.mu.Lock()
+	fake.nodes["a"][0].State = processStateFatal
+	fake.mu.Unlock()
+	service.Wait()
+
+	result, err := service.Get(detail.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutStatusFailed, result.Status)
+	assert.Contains(t, result.Targets[0].Error, "FATAL")
+}
+
+func TestRolloutPauseResumeCancel(t *testing.T) {
+	fake := newFakeProcessController("a", "b", "c")
+	fake.gate = make(chan struct{})
+	service := newRolloutTestService(t, fake)
+
+	detail, err := service.Start(&RolloutRequest{ProcessName: "app", Nodes: rolloutNodes("a", "b", "c"), CreatedBy: "admin"})
+	require.NoError(t, err)
+	targetStatus := func(index int) string {
+		current, err := service.Get(detail.ID)
+		require.NoError(t, err)
+		return current.Targets[index].Status
+	}
+	require.Eventually(t, func() bool { return targetStatus(0) == models.RolloutTargetRestarting }, time.Second, 5*time.Millisecond)
+
+	paused, err := service.Pause(detail.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutStatusPaused, paused.Status)
+	_, err = service.Pause(detail.ID)
+	assert.Error(t, err, "already paused")
+
+	// 当前批次继续完成，之后不再开始新批次
+	fake.gate <- struct{}{}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, fake.startedCount())
+
+	_, err = service.Resume(detail.ID)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return targetStatus(1) == models.RolloutTargetRestarting }, time.Second, 5*time.Millisecond)
+	_, err = service.Pause(detail.ID)
+	require.NoError(t, err)
+	fake.gate <- struct{}{}
+	require.Eventually(t, func() bool { return targetStatus(1) == models.RolloutTargetHealthy }, time.Second, 5*time.Millisecond)
+
+	cancelled, err := service.Cancel(detail.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutStatusCancelled, cancelled.Status)
+	assert.Equal(t, models.RolloutTargetSkipped, cancelled.Targets[2].Status)
+	assert.Equal(t, 2, cancelled.SucceededTargets)
+
+	_, err = service.Cancel(detail.ID)
+	assert.Error(t, err, "already cancelled")
+}
+
+func TestRolloutValidation(t *testing.T) {
+	fake := newFakeProcessController("a")
+	fake.gate = make(chan struct{})
+	service := newRolloutTestService(t, fake)
+
+	_, err := service.Start(&RolloutRequest{ProcessName: "app", GroupName: "app", Nodes: rolloutNodes("a")})
+	assert.Error(t, err)
+	_, err = service.Start(&RolloutRequest{ProcessName: "app", BatchPercent: 150, Nodes: rolloutNodes("a")})
+	assert.Error(t, err)
+	_, err = service.Start(&RolloutRequest{ProcessName: "missing", Nodes: rolloutNodes("a")})
+	assert.Error(t, err)
+
+	_, err = service.Start(&RolloutRequest{ProcessName: "app", Nodes: rolloutNodes("a")})
+	require.NoError(t, err)
+	_, err = service.Start(&RolloutRequest{ProcessName: "app", Nodes: rolloutNodes("a")})
+	assert.Error(t, err, "one active rollout per target")
+	close(fake.gate)
+	service.Wait()
+}
+
+func TestRolloutLeadershipLoss(t *testing.T) {
+	fake := newFakeProcessController("a", "b")
+	fake.gate = make(chan struct{})
+	service := newRolloutTestService(t, fake)
+
+	detail, err := service.Start(&RolloutRequest{ProcessName: "app", Nodes: rolloutNodes("a", "b"), CreatedBy: "admin"})
+	require.NoError(t, err)
+	service.HandleLeadershipChange(false)
+	close(fake.gate)
+	service.Wait()
+
+	result, err := service.Get(detail.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutStatusFailed, result.Status)
+	assert.Contains(t, result.ErrorMsg, "lost leadership")
+
+	// 其他实例遗留的记录在成为主节点时标记为失败
+	orphan := &models.Rollout{ProcessName: "other", Status: models.RolloutStatusRunning, CreatedBy: "admin"}
+	require.NoError(t, service.db.Create(orphan).Error)
+	service.HandleLeadershipChange(true)
+	result, err = service.Get(orphan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutStatusFailed, result.Status)
+}
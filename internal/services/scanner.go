@@ -16,6 +16,7 @@ import (
 	"superview/internal/utils"
 
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
 // ProbeTask represents a single IP probe task.
@@ -23,6 +24,7 @@ import (
 // Requirements: 3.1, 3.2, 3.3, 3.4, 4.1, 4.2, 4.3, 4.4, 4.5, 4.6
 type ProbeTask struct {
 	TaskID   uint
+	Host     string // Hostname the IP was resolved from, empty for plain addresses
 	IP       string
 	Port     int
 	Username string
@@ -34,6 +36,22 @@ type ProbeTask struct {
 	Version  string
 	ErrorMsg string
 	Duration time.Duration
+	NodeName string // Name of the node registered or matched for this probe
+
+	// Details read after a successful probe
+	Identification string
+	ProcessCount   int
+
+	Action string // Outcome of a successful probe, see models.ResultAction*
+	Rule   string // Discovery rule that decided Action
+}
+
+// Address returns the hostname if known, otherwise the IP.
+func (t *ProbeTask) Address() string {
+	if t.Host != "" {
+		return t.Host
+	}
+	return t.IP
 }
 
 // ID returns a unique identifier for this probe task.
@@ -92,10 +110,48 @@ func (t *ProbeTask) Execute(ctx context.Context) error {
 		}
 		// Success
 		t.Status = models.ResultStatusSuccess
+		t.collectDetails(ctx)
 		return nil
 	}
 }
 
+// collectDetails reads the version, identification and process count of a
+// Supervisor that answered the probe. It is bounded by the probe timeout and
+// failures are not fatal.
+func (t *ProbeTask) collectDetails(ctx context.Context) {
+	type probeDetails struct {
+		version        string
+		identification string
+		processCount   int
+	}
+	detailsCh := make(chan probeDetails, 1)
+
+	go func() {
+		var details probeDetails
+		client, err := xmlrpc.NewSupervisorClient(t.IP, t.Port, t.Username, t.Password)
+		if err == nil {
+			details.version, _ = client.GetSupervisorVersion()
+			details.identification, _ = client.GetIdentification()
+			if processes, err := client.GetAllProcessInfo(); err == nil {
+				details.processCount = len(processes)
+			}
+		}
+		detailsCh <- details
+	}()
+
+	detailsCtx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+	select {
+	case details := <-detailsCh:
+		if details.version != "" {
+			t.Version = details.version
+		}
+		t.Identification = details.identification
+		t.ProcessCount = details.processCount
+	case <-detailsCtx.Done():
+	}
+}
+
 // categorizeError determines the error type and sets appropriate status.
 // Requirements: 4.4, 4.5, 4.6
 func (t *ProbeTask) categorizeError(err error) {
@@ -215,28 +271,38 @@ func NewScanner(service *DiscoveryService) *Scanner {
 }
 
 // ScanConfig holds configuration for a scan operation.
+// When Targets is empty the scan covers CIDR on Port.
 type ScanConfig struct {
 	TaskID         uint
 	CIDR           string
 	Port           int
+	Targets        []ProbeTarget
 	Username       string
 	Password       string
 	TimeoutSeconds int
 	MaxWorkers     int
+	RateLimit      int   // probes per second, 0 = unlimited
+	ScheduleID     *uint // set when started by a DiscoverySchedule
+	// RequireApproval holds every new node for review
+	RequireApproval bool
 }
 
 // StartScan initiates an asynchronous network scan.
 // This runs in a goroutine and updates the task status as it progresses.
 // Requirements: 3.1, 3.2, 3.3, 3.4, 3.5
 func (s *Scanner) StartScan(config *ScanConfig) error {
-	// Parse CIDR to get IP list
-	cidrRange, err := utils.ParseCIDR(config.CIDR)
-	if err != nil {
-		return fmt.Errorf("invalid CIDR: %w", err)
+	targets := config.Targets
+	if len(targets) == 0 {
+		// Parse CIDR to get IP list
+		cidrRange, err := utils.ParseCIDR(config.CIDR)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR: %w", err)
+		}
+		for _, ip := range cidrRange.IPs() {
+			targets = append(targets, ProbeTarget{IP: ip, Port: config.Port})
+		}
 	}
-
-	ips := cidrRange.IPs()
-	if len(ips) == 0 {
+	if len(targets) == 0 {
 		return fmt.Errorf("no IPs in CIDR range")
 	}
 
@@ -257,8 +323,8 @@ func (s *Scanner) StartScan(config *ScanConfig) error {
 	// Create worker pool
 	poolConfig := &utils.WorkerPoolConfig{
 		Workers:      maxWorkers,
-		QueueSize:    len(ips),
-		ResultBuffer: len(ips),
+		QueueSize:    len(targets),
+		ResultBuffer: len(targets),
 		TaskTimeout:  timeout + time.Second, // Add buffer for task timeout
 	}
 	pool := utils.NewWorkerPool(poolConfig)
@@ -272,13 +338,13 @@ func (s *Scanner) StartScan(config *ScanConfig) error {
 	s.service.RegisterScan(config.TaskID, scanCtx)
 
 	// Start the scan in a goroutine
-	go s.runScan(ctx, config, ips, timeout, pool)
+	go s.runScan(ctx, config, targets, timeout, pool)
 
 	return nil
 }
 
 // runScan executes the actual scanning process.
-func (s *Scanner) runScan(ctx context.Context, config *ScanConfig, ips []string, timeout time.Duration, pool *utils.WorkerPool) {
+func (s *Scanner) runScan(ctx context.Context, config *ScanConfig, targets []ProbeTarget, timeout time.Duration, pool *utils.WorkerPool) {
 	taskID := config.TaskID
 
 	// Ensure cleanup on exit
@@ -294,43 +360,80 @@ func (s *Scanner) runScan(ctx context.Context, config *ScanConfig, ips []string,
 			zap.Uint("task_id", taskID),
 			zap.Error(err))
 		s.markTaskFailed(taskID, "failed to start scan: "+err.Error())
+		discoveryScans.Inc(scanOutcomeFailed)
 		return
 	}
 
 	logger.Info("Starting network scan",
 		zap.Uint("task_id", taskID),
 		zap.String("cidr", config.CIDR),
-		zap.Int("total_ips", len(ips)),
-		zap.Int("workers", pool.Stats().Workers))
+		zap.Int("total_ips", len(targets)),
+		zap.Int("workers", pool.Stats().Workers),
+		zap.Int("rate_limit", config.RateLimit))
+
+	// Auto-registration rules and the blocklist apply to every node found by this scan
+	policy := &registrationPolicy{requireApproval: config.RequireApproval}
+	var err error
+	if policy.rules, err = s.service.EnabledRules(); err != nil {
+		logger.Warn("Failed to load discovery rules, registering with defaults",
+			zap.Uint("task_id", taskID),
+			zap.Error(err))
+	}
+	if policy.blocklist, err = s.service.Blocklist(); err != nil {
+		logger.Warn("Failed to load discovery blocklist",
+			zap.Uint("task_id", taskID),
+			zap.Error(err))
+	}
 
-	// Create probe tasks for all IPs
-	probeTasks := make([]*ProbeTask, len(ips))
-	for i, ip := range ips {
-		probeTasks[i] = &ProbeTask{
+	// Create probe tasks for all targets
+	probeTasks := make(map[string]*ProbeTask, len(targets))
+	ordered := make([]*ProbeTask, len(targets))
+	for i, target := range targets {
+		probe := &ProbeTask{
 			TaskID:   taskID,
-			IP:       ip,
-			Port:     config.Port,
+			Host:     target.Host,
+			IP:       target.IP,
+			Port:     target.Port,
 			Username: config.Username,
 			Password: config.Password,
 			Timeout:  timeout,
 		}
+		probeTasks[probe.ID()] = probe
+		ordered[i] = probe
 	}
 
-	// Submit all tasks to the worker pool
-	for _, task := range probeTasks {
-		select {
-		case <-ctx.Done():
-			logger.Info("Scan cancelled during task submission",
-				zap.Uint("task_id", taskID))
-			return
-		default:
-			if err := pool.Submit(task); err != nil {
-				logger.Warn("Failed to submit probe task",
-					zap.String("task_id", task.ID()),
-					zap.Error(err))
+	// Submit tasks to the worker pool, throttled by the task's rate limit.
+	// Submission runs alongside result collection so progress is reported while throttled.
+	submitted := make(chan struct{})
+	defer func() { <-submitted }()
+	go func() {
+		defer close(submitted)
+		var limiter *rate.Limiter
+		if config.RateLimit > 0 {
+			limiter = rate.NewLimiter(rate.Limit(config.RateLimit), 1)
+		}
+		for _, task := range ordered {
+			if limiter != nil {
+				if err := limiter.Wait(ctx); err != nil {
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				logger.Info("Scan cancelled during task submission",
+					zap.Uint("task_id", taskID))
+				return
+			default:
+				if err := pool.Submit(task); err == context.Canceled {
+					return
+				} else if err != nil {
+					logger.Warn("Failed to submit probe task",
+						zap.String("task_id", task.ID()),
+						zap.Error(err))
+				}
 			}
 		}
-	}
+	}()
 
 	// Collect results
 	var scannedIPs int32
@@ -343,7 +446,7 @@ func (s *Scanner) runScan(ctx context.Context, config *ScanConfig, ips []string,
 
 	// Process results from worker pool
 	resultsCh := pool.Results()
-	expectedResults := len(ips)
+	expectedResults := len(targets)
 	receivedResults := 0
 
 	// Progress broadcast interval
@@ -355,6 +458,8 @@ func (s *Scanner) runScan(ctx context.Context, config *ScanConfig, ips []string,
 		case <-ctx.Done():
 			logger.Info("Scan cancelled during result collection",
 				zap.Uint("task_id", taskID))
+			discoveryScans.Inc(scanOutcomeCancelled)
+			discoveryScanDuration.Observe(time.Since(now).Seconds(), scanOutcomeCancelled)
 			return
 
 		case result, ok := <-resultsCh:
@@ -367,33 +472,28 @@ func (s *Scanner) runScan(ctx context.Context, config *ScanConfig, ips []string,
 			atomic.AddInt32(&scannedIPs, 1)
 
 			// Find the corresponding probe task
-			var probeTask *ProbeTask
-			for _, pt := range probeTasks {
-				if pt.ID() == result.TaskID {
-					probeTask = pt
-					break
-				}
-			}
-
-			if probeTask == nil {
+			probeTask, ok := probeTasks[result.TaskID]
+			if !ok {
 				continue
 			}
 
 			resultMu.Lock()
-			resultMap[probeTask.IP] = probeTask
+			resultMap[probeTask.ID()] = probeTask
 			resultMu.Unlock()
 
 			// Count results
 			if probeTask.Status == models.ResultStatusSuccess {
 				atomic.AddInt32(&foundNodes, 1)
+				discoveryProbes.Inc("found")
 
 				// Register the discovered node
-				s.registerDiscoveredNode(ctx, taskID, probeTask, config.Username, config.Password)
+				s.registerDiscoveredNode(ctx, taskID, probeTask, policy, config.Username, config.Password)
 
 				// Broadcast node discovered event
 				s.broadcastNodeDiscovered(taskID, probeTask)
 			} else {
 				atomic.AddInt32(&failedIPs, 1)
+				discoveryProbes.Inc("failed")
 			}
 
 			// Create discovery result record
@@ -401,8 +501,8 @@ func (s *Scanner) runScan(ctx context.Context, config *ScanConfig, ips []string,
 
 			// Broadcast progress periodically
 			currentScanned := int(atomic.LoadInt32(&scannedIPs))
-			if currentScanned-lastBroadcast >= progressInterval || currentScanned == len(ips) {
-				s.broadcastProgress(taskID, currentScanned, len(ips),
+			if currentScanned-lastBroadcast >= progressInterval || currentScanned == len(targets) {
+				s.broadcastProgress(taskID, currentScanned, len(targets),
 					int(atomic.LoadInt32(&foundNodes)),
 					int(atomic.LoadInt32(&failedIPs)))
 				lastBroadcast = currentScanned
@@ -423,9 +523,11 @@ done:
 		int(atomic.LoadInt32(&scannedIPs)),
 		int(atomic.LoadInt32(&foundNodes)),
 		int(atomic.LoadInt32(&failedIPs)))
+	discoveryScans.Inc(scanOutcomeCompleted)
+	discoveryScanDuration.Observe(completedAt.Sub(now).Seconds(), scanOutcomeCompleted)
 
 	// Broadcast completion event
-	s.broadcastCompleted(taskID, len(ips),
+	s.broadcastCompleted(taskID, len(targets),
 		int(atomic.LoadInt32(&foundNodes)),
 		int(atomic.LoadInt32(&failedIPs)))
 
@@ -434,6 +536,15 @@ done:
 		zap.Int("scanned", int(atomic.LoadInt32(&scannedIPs))),
 		zap.Int("found", int(atomic.LoadInt32(&foundNodes))),
 		zap.Int("failed", int(atomic.LoadInt32(&failedIPs))))
+
+	// Compare scheduled runs with the previous run
+	if config.ScheduleID != nil {
+		if _, err := s.service.CreateReport(*config.ScheduleID, taskID); err != nil {
+			logger.Error("Failed to create discovery report",
+				zap.Uint("task_id", taskID),
+				zap.Error(err))
+		}
+	}
 }
 
 // updateTaskStarted updates the task status to running.
@@ -474,8 +585,8 @@ func (s *Scanner) markTaskCompleted(taskID uint, completedAt *time.Time, scanned
 
 	// Log task completion
 	if activityLog := s.service.GetActivityLogService(); activityLog != nil {
-		message := fmt.Sprintf("Discovery task %d completed for CIDR %s (scanned %d IPs, found %d nodes)",
-			taskID, task.CIDR, scanned, found)
+		message := fmt.Sprintf("Discovery task %d completed for %s (scanned %d IPs, found %d nodes)",
+			taskID, task.Scope(), scanned, found)
 		activityLog.LogSystemEvent("INFO", "discovery_completed", "discovery", fmt.Sprintf("task-%d", taskID), message, nil)
 	}
 }
@@ -504,103 +615,173 @@ func (s *Scanner) markTaskFailed(taskID uint, errorMsg string) {
 
 	// Log task failure - Requirement 8.4
 	if activityLog := s.service.GetActivityLogService(); activityLog != nil {
-		message := fmt.Sprintf("Discovery task %d failed for CIDR %s: %s",
-			taskID, task.CIDR, errorMsg)
+		message := fmt.Sprintf("Discovery task %d failed for %s: %s",
+			taskID, task.Scope(), errorMsg)
 		activityLog.LogSystemEvent("ERROR", "discovery_failed", "discovery", fmt.Sprintf("task-%d", taskID), message, nil)
 	}
 }
 
+// registrationPolicy decides what happens to the nodes found by one scan.
+type registrationPolicy struct {
+	rules           []*models.DiscoveryRule
+	blocklist       []*models.DiscoveryBlocklistEntry
+	requireApproval bool
+}
+
 // registerDiscoveredNode creates a new node record for a discovered Supervisor.
+// Blocklisted Supervisors are skipped. The first matching discovery rule decides
+// whether the node is registered, held for approval or ignored, and sets its
+// environment, name prefix and labels. Tasks that require approval hold every
+// new node in the review queue.
 // Requirements: 5.1, 5.2, 5.3, 5.4, 5.5, 8.2
-func (s *Scanner) registerDiscoveredNode(ctx context.Context, taskID uint, probe *ProbeTask, username, password string) {
+func (s *Scanner) registerDiscoveredNode(ctx context.Context, taskID uint, probe *ProbeTask, policy *registrationPolicy, username, password string) {
 	nodeRepo := s.service.GetNodeRepository()
 
 	// Check if node already exists by host:port (Requirement 5.3)
-	exists, err := nodeRepo.ExistsByHostPort(probe.IP, probe.Port)
-	if err != nil {
-		logger.Error("Failed to check if node exists by host:port",
-			zap.String("host", probe.IP),
-			zap.Int("port", probe.Port),
-			zap.Error(err))
-		return
+	// Nodes may have been registered by IP or by hostname
+	for _, host := range uniqueHosts(probe.Address(), probe.IP) {
+		exists, err := nodeRepo.ExistsByHostPort(host, probe.Port)
+		if err != nil {
+			logger.Error("Failed to check if node exists by host:port",
+				zap.String("host", host),
+				zap.Int("port", probe.Port),
+				zap.Error(err))
+			return
+		}
+
+		if exists {
+			logger.Debug("Node with same host:port already exists, marking as duplicate",
+				zap.String("host", host),
+				zap.Int("port", probe.Port))
+			probe.Action = models.ResultActionExisting
+			probe.NodeName = s.service.nodeNameByHostPort(host, probe.Port)
+			return
+		}
 	}
 
-	if exists {
-		logger.Debug("Node with same host:port already exists, marking as duplicate",
-			zap.String("host", probe.IP),
-			zap.Int("port", probe.Port))
+	if entry := MatchBlocklist(policy.blocklist, probe.Host, probe.IP, probe.Port); entry != nil {
+		probe.Action = models.ResultActionBlocked
+		logger.Info("Discovered node is blocklisted",
+			zap.Uint("task_id", taskID),
+			zap.String("address", probe.Address()),
+			zap.Int("port", probe.Port),
+			zap.String("entry", entry.Address))
 		return
 	}
 
-	// Generate node name from IP (Requirement 5.2)
-	nodeName := generateNodeName(probe.IP)
-
-	// Create new node with status "discovered" (Requirements 5.1, 5.4, 5.5)
-	node := &models.Node{
-		Name:     nodeName,
-		Host:     probe.IP,
-		Port:     probe.Port,
-		Username: username,
-		Password: password,
-		Status:   "discovered",
+	environment, prefix := "discovered", "node-"
+	var labels map[string]string
+	hold := policy.requireApproval
+	if rule := MatchDiscoveryRule(policy.rules, probe.Host, probe.IP); rule != nil {
+		probe.Rule = rule.Name
+		switch rule.Action {
+		case models.DiscoveryActionIgnore:
+			probe.Action = models.ResultActionIgnored
+			logger.Info("Discovered node ignored by rule",
+				zap.Uint("task_id", taskID),
+				zap.String("address", probe.Address()),
+				zap.Int("port", probe.Port),
+				zap.String("rule", rule.Name))
+			return
+		case models.DiscoveryActionApprove:
+			hold = true
+		}
+		if rule.Environment != "" {
+			environment = rule.Environment
+		}
+		if rule.NamePrefix != "" {
+			prefix = rule.NamePrefix
+		}
+		labels = rule.Labels
 	}
 
-	if err := nodeRepo.Create(node); err != nil {
-		logger.Error("Failed to create discovered node",
-			zap.String("node_name", nodeName),
-			zap.Error(err))
-		return
+	// Generate node name from IP or hostname (Requirement 5.2); a second port on
+	// the same host gets the port appended
+	nodeName := prefix + strings.TrimPrefix(generateNodeName(probe.Address()), "node-")
+	if existing, err := nodeRepo.GetByName(nodeName); err == nil && existing != nil {
+		nodeName = fmt.Sprintf("%s-%d", nodeName, probe.Port)
 	}
 
-	logger.Info("Discovered node registered in database",
-		zap.Uint("task_id", taskID),
-		zap.String("node_name", nodeName),
-		zap.String("host", probe.IP),
-		zap.Int("port", probe.Port))
-
-	// Add node to SupervisorService memory
-	supervisorSvc := s.service.GetSupervisorService()
-	if supervisorSvc != nil {
-		if err := supervisorSvc.AddNode(nodeName, "discovered", probe.IP, probe.Port, username, password); err != nil {
-			logger.Warn("Failed to add discovered node to supervisor service",
-				zap.String("node_name", nodeName),
+	// Node details for registration or review
+	node := &models.Node{
+		Name:        nodeName,
+		Host:        probe.Address(),
+		Port:        probe.Port,
+		Username:    username,
+		Password:    password,
+		Status:      "discovered",
+		Environment: environment,
+		Source:      models.NodeSourceDiscovery,
+	}
+	node.SetLabels(labels)
+
+	if hold {
+		probe.Action = models.ResultActionPending
+		if err := s.service.queueCandidate(taskID, probe, node); err != nil {
+			logger.Error("Failed to queue discovered node for approval",
+				zap.String("address", probe.Address()),
+				zap.Int("port", probe.Port),
 				zap.Error(err))
-			// Not fatal - node is in database, can be loaded on restart
-		} else {
-			logger.Info("Discovered node loaded into supervisor service",
-				zap.String("node_name", nodeName))
+			return
 		}
+		logger.Info("Discovered node held for approval",
+			zap.Uint("task_id", taskID),
+			zap.String("address", probe.Address()),
+			zap.Int("port", probe.Port),
+			zap.String("rule", probe.Rule))
+		return
+	}
+
+	// Create new node with status "discovered" (Requirements 5.1, 5.4, 5.5)
+	if err := s.service.createDiscoveredNode(node); err != nil {
+		return
 	}
+	probe.NodeName = nodeName
+	probe.Action = models.ResultActionRegistered
 
 	// Log node registration - Requirement 8.2
 	if activityLog := s.service.GetActivityLogService(); activityLog != nil {
 		message := fmt.Sprintf("Node %s discovered and registered at %s:%d (task %d)",
-			nodeName, probe.IP, probe.Port, taskID)
+			nodeName, probe.Address(), probe.Port, taskID)
 		activityLog.LogSystemEvent("INFO", "node_discovered", "discovery", nodeName, message, nil)
 	}
 }
 
-// generateNodeName creates a node name from an IP address.
+// generateNodeName creates a node name from an IP address or hostname.
 // Format: node-{ip-with-dashes} (e.g., node-192-168-1-100)
 func generateNodeName(ip string) string {
 	return "node-" + strings.ReplaceAll(ip, ".", "-")
 }
 
+// uniqueHosts returns the hostname and IP of a probe, once if they are the same
+func uniqueHosts(address, ip string) []string {
+	if address == ip {
+		return []string{ip}
+	}
+	return []string{address, ip}
+}
+
 // createDiscoveryResult creates a discovery result record.
 func (s *Scanner) createDiscoveryResult(taskID uint, probe *ProbeTask) {
 	result := &models.DiscoveryResult{
 		TaskID:   taskID,
+		Host:     probe.Host,
 		IP:       probe.IP,
 		Port:     probe.Port,
 		Status:   probe.Status,
 		Version:  probe.Version,
 		ErrorMsg: probe.ErrorMsg,
 		Duration: probe.Duration.Milliseconds(),
+		Action:   probe.Action,
+		Rule:     probe.Rule,
 	}
 
-	// If successful, try to get the node ID
-	if probe.Status == models.ResultStatusSuccess {
-		nodeName := generateNodeName(probe.IP)
+	// If registered, try to get the node ID
+	if probe.Status == models.ResultStatusSuccess && (probe.Action == models.ResultActionRegistered || probe.Action == models.ResultActionExisting) {
+		nodeName := probe.NodeName
+		if nodeName == "" {
+			nodeName = generateNodeName(probe.Address())
+		}
 		result.NodeName = nodeName
 
 		// Try to get the node to set NodeID
@@ -668,10 +849,12 @@ func (s *Scanner) broadcastProgress(taskID uint, scanned, total, found, failed i
 	}
 
 	event := struct {
-		Type string                 `json:"type"`
-		Data DiscoveryProgressEvent `json:"data"`
+		Type   string                 `json:"type"`
+		Topics []string               `json:"topics"`
+		Data   DiscoveryProgressEvent `json:"data"`
 	}{
-		Type: EventTypeDiscoveryProgress,
+		Type:   EventTypeDiscoveryProgress,
+		Topics: discoveryTopics(taskID),
 		Data: DiscoveryProgressEvent{
 			TaskID:     taskID,
 			ScannedIPs: scanned,
@@ -702,15 +885,17 @@ func (s *Scanner) broadcastNodeDiscovered(taskID uint, probe *ProbeTask) {
 	}
 
 	event := struct {
-		Type string              `json:"type"`
-		Data NodeDiscoveredEvent `json:"data"`
+		Type   string              `json:"type"`
+		Topics []string            `json:"topics"`
+		Data   NodeDiscoveredEvent `json:"data"`
 	}{
-		Type: EventTypeNodeDiscovered,
+		Type:   EventTypeNodeDiscovered,
+		Topics: discoveryTopics(taskID),
 		Data: NodeDiscoveredEvent{
 			TaskID:   taskID,
 			IP:       probe.IP,
 			Port:     probe.Port,
-			NodeName: generateNodeName(probe.IP),
+			NodeName: probe.NodeName,
 			Version:  probe.Version,
 		},
 	}
@@ -742,10 +927,12 @@ func (s *Scanner) broadcastCompleted(taskID uint, total, found, failed int) {
 	}
 
 	event := struct {
-		Type string                  `json:"type"`
-		Data DiscoveryCompletedEvent `json:"data"`
+		Type   string                  `json:"type"`
+		Topics []string                `json:"topics"`
+		Data   DiscoveryCompletedEvent `json:"data"`
 	}{
-		Type: EventTypeDiscoveryCompleted,
+		Type:   EventTypeDiscoveryCompleted,
+		Topics: discoveryTopics(taskID),
 		Data: DiscoveryCompletedEvent{
 			TaskID:          taskID,
 			Status:          models.DiscoveryStatusCompleted,
@@ -765,3 +952,8 @@ func (s *Scanner) broadcastCompleted(taskID uint, total, found, failed int) {
 
 	hub.Broadcast(data)
 }
+
+// discoveryTopics returns the WebSocket topics a task's scan events are published to.
+func discoveryTopics(taskID uint) []string {
+	return []string{"discovery", fmt.Sprintf("discovery:task/%d", taskID)}
+}
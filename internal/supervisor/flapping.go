@@ -0,0 +1,232 @@
+package supervisor
+
+import (
+	"sync"
+	"time"
+
+	"superview/internal/config"
+)
+
+// FlapStatus 进程的抖动情况。Score 为各窗口内重启次数与阈值之比的最大值，达到 1 即为抖动
+type FlapStatus struct {
+	NodeName    string        `json:"node_name"`
+	ProcessName string        `json:"process_name"`
+	Group       string        `json:"group"`
+	Score       float64       `json:"flapping_score"`
+	Restarts    int           `json:"restarts"` // Score 最大的窗口内的重启次数
+	Window      time.Duration `json:"-"`        // Score 最大的窗口
+	WindowText  string        `json:"window"`   // 便于阅读的窗口时长
+	Flapping    bool          `json:"flapping"`
+}
+
+// FlapEvent 进程开始或停止抖动
+type FlapEvent struct {
+	FlapStatus
+	Time time.Time
+}
+
+// flapKey 进程在检测器中的标识
+type flapKey struct {
+	node    string
+	process string
+}
+
+type flapState struct {
+	group     string
+	state     int
+	startTime time.Time
+	restarts  []time.Time
+	flapping  bool
+}
+
+// FlapDetector 按配置的时间窗口统计进程重启次数。
+// 重启指进程启动时间变化，或从非运行状态（STOPPED、EXITED、BACKOFF、FATAL 等）进入 STARTING/RUNNING
+type FlapDetector struct {
+	mu        sync.Mutex
+	windows   []config.FlapWindow
+	maxWindow time.Duration
+	processes map[flapKey]*flapState
+}
+
+// NewFlapDetector 创建检测器，windows 为空时使用 config.DefaultFlapWindows
+func NewFlapDetector(windows []config.FlapWindow) *FlapDetector {
+	if len(windows) == 0 {
+		windows = config.DefaultFlapWindows
+	}
+	d := &FlapDetector{windows: windows, processes: make(map[flapKey]*flapState)}
+	for _, w := range windows {
+		if w.Window > d.maxWindow {
+			d.maxWindow = w.Window
+		}
+	}
+	return d
+}
+
+// Observe 记录一次刷新看到的进程状态，进程开始或停止抖动时返回事件
+func (d *FlapDetector) Observe(nodeName string, p Process, now time.Time) *FlapEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := flapKey{nodeName, p.Name}
+	st, ok := d.processes[key]
+	if !ok {
+		d.processes[key] = &flapState{group: p.Group, state: p.State, startTime: p.StartTime}
+		return nil
+	}
+
+	if isRestart(st, p) {
+		st.restarts = append(st.restarts, now)
+	}
+	st.group, st.state, st.startTime = p.Group, p.State, p.StartTime
+	d.prune(st, now)
+
+	status := d.status(key, st, now)
+	if status.Flapping == st.flapping {
+		return nil
+	}
+	st.flapping = status.Flapping
+	return &FlapEvent{FlapStatus: status, Time: now}
+}
+
+// isRestart 两次观察之间进程是否重启过
+func isRestart(previous *flapState, current Process) bool {
+	if !previous.startTime.IsZero() && !current.StartTime.IsZero() && !previous.startTime.Equal(current.StartTime) {
+		return true
+	}
+	wasRunning := previous.state == 10 || previous.state == 20 // STARTING, RUNNING
+	isRunning := current.State == 10 || current.State == 20
+	return !wasRunning && isRunning
+}
+
+// prune 丢弃超出最大窗口的重启记录
+func (d *FlapDetector) prune(st *flapState, now time.Time) {
+	cutoff := now.Add(-d.maxWindow)
+	i := 0
+	for i < len(st.restarts) && !st.restarts[i].After(cutoff) {
+		i++
+	}
+	st.restarts = st.restarts[i:]
+}
+
+func (d *FlapDetector) status(key flapKey, st *flapState, now time.Time) FlapStatus {
+	status := FlapStatus{NodeName: key.node, ProcessName: key.process, Group: st.group}
+	for _, w := range d.windows {
+		cutoff := now.Add(-w.Window)
+		count := 0
+		for _, t := range st.restarts {
+			if t.After(cutoff) {
+				count++
+			}
+		}
+		score := float64(count) / float64(w.MaxRestarts)
+		if score > status.Score || status.Window == 0 {
+			status.Score, status.Restarts, status.Window = score, count, w.Window
+		}
+	}
+	status.WindowText = status.Window.String()
+	status.Flapping = status.Score >= 1
+	return status
+}
+
+// Status 进程当前的抖动情况，未观察过的进程得分为 0
+func (d *FlapDetector) Status(nodeName, processName string, now time.Time) FlapStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := flapKey{nodeName, processName}
+	st, ok := d.processes[key]
+	if !ok {
+		st = &flapState{}
+	}
+	d.prune(st, now)
+	return d.status(key, st, now)
+}
+
+// List 所有窗口内有重启记录的进程
+func (d *FlapDetector) List(now time.Time) []FlapStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var result []FlapStatus
+	for key, st := range d.processes {
+		d.prune(st, now)
+		if len(st.restarts) == 0 {
+			continue
+		}
+		result = append(result, d.status(key, st, now))
+	}
+	return result
+}
+
+// Reset 清除进程的重启记录（人工确认隔离后重新开始统计）
+func (d *FlapDetector) Reset(nodeName, processName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if st, ok := d.processes[flapKey{nodeName, processName}]; ok {
+		st.restarts = nil
+		st.flapping = false
+	}
+}
+
+// ForgetNode 节点被删除时清除其所有进程
+func (d *FlapDetector) ForgetNode(nodeName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key := range d.processes {
+		if key.node == nodeName {
+			delete(d.processes, key)
+		}
+	}
+}
+
+// SetFlapWindows 设置抖动判定窗口，已有的重启记录被清空
+func (s *SupervisorService) SetFlapWindows(windows []config.FlapWindow) {
+	s.flapDetector = NewFlapDetector(windows)
+}
+
+// OnProcessFlapping 注册进程开始或停止抖动的回调，回调在状态监控 goroutine 中同步执行
+func (s *SupervisorService) OnProcessFlapping(fn func(FlapEvent)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flapHandlers = append(s.flapHandlers, fn)
+}
+
+// SetStartGuard 设置启动进程前的检查，对已添加和之后添加的节点都生效
+func (s *SupervisorService) SetStartGuard(fn func(nodeName, processName string) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startGuard = fn
+	for _, node := range s.nodes {
+		node.setStartGuard(fn)
+	}
+}
+
+// FlapStatus 进程当前的抖动情况。重启记录只在运行状态监控的实例（主节点）上累积
+func (s *SupervisorService) FlapStatus(nodeName, processName string) FlapStatus {
+	return s.flapDetector.Status(nodeName, processName, time.Now())
+}
+
+// FlappingProcesses 统计窗口内有重启记录的进程
+func (s *SupervisorService) FlappingProcesses() []FlapStatus {
+	return s.flapDetector.List(time.Now())
+}
+
+// ResetFlapping 清除进程的重启记录
+func (s *SupervisorService) ResetFlapping(nodeName, processName string) {
+	s.flapDetector.Reset(nodeName, processName)
+}
+
+func (s *SupervisorService) notifyFlapping(events []FlapEvent) {
+	if len(events) == 0 {
+		return
+	}
+	s.mu.RLock()
+	handlers := make([]func(FlapEvent), len(s.flapHandlers))
+	copy(handlers, s.flapHandlers)
+	s.mu.RUnlock()
+	for _, event := range events {
+		for _, fn := range handlers {
+			fn(event)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"superview/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlapDetectorWindows(t *testing.T) {
+	d := NewFlapDetector([]config.FlapWindow{{Window: 10 * time.Minute, MaxRestarts: 3}})
+	now := time.Unix(1700000000, 0)
+
+	assert.Nil(t, d.Observe("web-1", Process{Name: "api", State: 20, StartTime: now}, now))
+
+	// 运行 → 退出 → 运行，每次回到 RUNNING 计一次重启
+	var event *FlapEvent
+	for i := 1; i <= 3; i++ {
+		now = now.Add(time.Minute)
+		assert.Nil(t, d.Observe("web-1", Process{Name: "api", State: 100, StartTime: now.Add(-time.Minute)}, now))
+		now = now.Add(time.Minute)
+		event = d.Observe("web-1", Process{Name: "api", State: 20, StartTime: now}, now)
+		if i < 3 {
+			assert.Nil(t, event)
+		}
+	}
+	require.NotNil(t, event)
+	assert.True(t, event.Flapping)
+	assert.Equal(t, 3, event.Restarts)
+	assert.Equal(t, 1.0, event.Score)
+	assert.Equal(t, "10m0s", event.WindowText)
+
+	// 窗口过后重启记录过期，停止抖动
+	now = now.Add(15 * time.Minute)
+	event = d.Observe("web-1", Process{Name: "api", State: 20, StartTime: now.Add(-15 * time.Minute)}, now)
+	require.NotNil(t, event)
+	assert.False(t, event.Flapping)
+	assert.Equal(t, 0.0, d.Status("web-1", "api", now).Score)
+}
+
+func TestFlapDetectorStartTimeChangeAndReset(t *testing.T) {
+	d := NewFlapDetector([]config.FlapWindow{{Window: time.Hour, MaxRestarts: 4}})
+	now := time.Unix(1700000000, 0)
+
+	d.Observe("web-1", Process{Name: "api", State: 20, StartTime: now}, now)
+	// 两次刷新之间完成了重启，只能从启动时间的变化看出来
+	now = now.Add(time.Minute)
+	d.Observe("web-1", Process{Name: "api", State: 20, StartTime: now}, now)
+	// 手动停止不计入
+	now = now.Add(time.Minute)
+	d.Observe("web-1", Process{Name: "api", State: 0, StartTime: now.Add(-time.Minute)}, now)
+
+	status := d.Status("web-1", "api", now)
+	assert.Equal(t, 1, status.Restarts)
+	assert.Equal(t, 0.25, status.Score)
+	assert.Len(t, d.List(now), 1)
+
+	d.Reset("web-1", "api")
+	assert.Equal(t, 0, d.Status("web-1", "api", now).Restarts)
+
+	d.ForgetNode("web-1")
+	assert.Empty(t, d.List(now))
+}
@@ -0,0 +1,68 @@
+package supervisor
+
+import (
+	"time"
+
+	"superview/internal/metrics/instrument"
+)
+
+// 节点和进程的运行指标，由 /metrics 端点输出
+var (
+	xmlrpcCallDuration = instrument.NewHistogramVec("superview_xmlrpc_call_duration_seconds",
+		"XML-RPC call latency to supervisord", nil, "node", "method")
+	xmlrpcErrors = instrument.NewCounterVec("superview_xmlrpc_errors_total",
+		"XML-RPC calls that failed or returned a fault", "node", "method")
+	processTransitions = instrument.NewCounterVec("superview_process_state_transitions_total",
+		"Observed process state transitions", "node", "process", "from", "to")
+	processRestarts = instrument.NewCounterVec("superview_process_restarts_total",
+		"Observed process restarts (start time changed between refreshes)", "node", "process")
+	processSpawnFailures = instrument.NewCounterVec("superview_process_spawn_failures_total",
+		"Processes that entered BACKOFF or FATAL", "node", "process")
+	refreshCycleDuration = instrument.NewHistogramVec("superview_refresh_cycle_duration_seconds",
+		"Duration of one polling cycle over all nodes", []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}, "cycle")
+)
+
+func init() {
+	instrument.MustRegister(xmlrpcCallDuration, xmlrpcErrors, processTransitions, processRestarts,
+		processSpawnFailures, refreshCycleDuration)
+}
+
+// observeXMLRPCCall 记录节点 XML-RPC 调用的耗时和错误
+func (n *Node) observeXMLRPCCall(method string, duration time.Duration, err error) {
+	xmlrpcCallDuration.Observe(duration.Seconds(), n.Name, method)
+	if err != nil {
+		xmlrpcErrors.Inc(n.Name, method)
+	}
+}
+
+// recordProcessChanges 对比两次刷新的进程列表，统计状态转换、重启和启动失败。首次刷新没有可比较的数据
+func recordProcessChanges(nodeName string, previous, current []Process) {
+	if len(previous) == 0 {
+		return
+	}
+	known := make(map[string]Process, len(previous))
+	for _, p := range previous {
+		known[p.Group+":"+p.Name] = p
+	}
+
+	for _, p := range current {
+		old, ok := known[p.Group+":"+p.Name]
+		if !ok {
+			continue
+		}
+		if old.State != p.State {
+			processTransitions.Inc(nodeName, p.Name, old.StateString, p.StateString)
+			if p.State == 30 || p.State == 200 { // BACKOFF, FATAL
+				processSpawnFailures.Inc(nodeName, p.Name)
+			}
+		}
+		if !old.StartTime.IsZero() && !p.StartTime.IsZero() && !old.StartTime.Equal(p.StartTime) {
+			processRestarts.Inc(nodeName, p.Name)
+		}
+	}
+}
+
+// ObservedRestarts 本实例启动以来观察到的进程重启次数
+func ObservedRestarts(nodeName, processName string) int {
+	return int(processRestarts.Value(nodeName, processName))
+}
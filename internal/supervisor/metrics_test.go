@@ -0,0 +1,35 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordProcessChanges(t *testing.T) {
+	started := time.Unix(1700000000, 0)
+	previous := []Process{
+		{Name: "api", Group: "web", State: 20, StateString: "RUNNING", StartTime: started},
+		{Name: "worker", Group: "jobs", State: 10, StateString: "STARTING"},
+	}
+	current := []Process{
+		{Name: "api", Group: "web", State: 20, StateString: "RUNNING", StartTime: started.Add(time.Minute)},
+		{Name: "worker", Group: "jobs", State: 30, StateString: "BACKOFF"},
+		{Name: "new", Group: "jobs", State: 20, StateString: "RUNNING", StartTime: started},
+	}
+
+	restarts := processRestarts.Value("metrics-node", "api")
+	transitions := processTransitions.Value("metrics-node", "worker", "STARTING", "BACKOFF")
+	failures := processSpawnFailures.Value("metrics-node", "worker")
+
+	recordProcessChanges("metrics-node", previous, current)
+	assert.Equal(t, restarts+1, processRestarts.Value("metrics-node", "api"))
+	assert.Equal(t, transitions+1, processTransitions.Value("metrics-node", "worker", "STARTING", "BACKOFF"))
+	assert.Equal(t, failures+1, processSpawnFailures.Value("metrics-node", "worker"))
+	assert.Equal(t, 0.0, processTransitions.Value("metrics-node", "new", "", "RUNNING"))
+
+	// 首次刷新不计数
+	recordProcessChanges("metrics-first", nil, current)
+	assert.Equal(t, 0.0, processRestarts.Value("metrics-first", "api"))
+}
@@ -3,6 +3,7 @@ package supervisor
 import (
 	"fmt"
 	"superview/internal/errors"
+	"superview/internal/labels"
 	"superview/internal/logger"
 	"strings"
 	"sync"
@@ -53,6 +54,9 @@ type Node struct {
 	IsConnected  bool
 	LastPing     time.Time
 	Processes    []Process
+	maintenance  bool // 维护模式：暂停轮询和告警
+	labels       map[string]string
+	startGuard   func(nodeName, processName string) error // 由 SupervisorService 设置，所有启动和重启都先经过它
 	
 	client       *xmlrpc.SupervisorClient
 }
@@ -100,7 +104,7 @@ func NewNode(name, environment, host string, port int, username, password string
 		return nil, err
 	}
 
-	return &Node{
+	node := &Node{
 		Name:        name,
 		Environment: environment,
 		Host:        host,
@@ -109,7 +113,39 @@ func NewNode(name, environment, host string, port int, username, password string
 		Password:    password,
 		client:      client,
 		Processes:   make([]Process, 0),
-	}, nil
+	}
+	client.SetObserver(node.observeXMLRPCCall)
+	return node, nil
+}
+
+// ConnectionTestResult 连接测试结果
+type ConnectionTestResult struct {
+	Connected    bool   `json:"connected"`
+	LatencyMs    int64  `json:"latency_ms"`
+	ProcessCount int    `json:"process_count"`
+	Error        string `json:"error,omitempty"`
+}
+
+// TestConnection 使用给定参数连接一次 Supervisor，不创建或修改任何节点
+func TestConnection(host string, port int, username, password string) *ConnectionTestResult {
+	result := &ConnectionTestResult{}
+	client, err := xmlrpc.NewSupervisorClient(host, port, username, password)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	start := time.Now()
+	processes, err := client.GetAllProcessInfo()
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Connected = true
+	result.ProcessCount = len(processes)
+	return result
 }
 
 func (n *Node) Connect() error {
@@ -208,9 +244,12 @@ func (n *Node) RefreshProcesses() error {
 	}
 
 	n.mu.Lock()
+	previous := n.Processes
 	n.Processes = processes
 	n.mu.Unlock()
 
+	recordProcessChanges(n.Name, previous, processes)
+
 	logger.Info("Successfully refreshed processes for node",
 		zap.String("node", n.Name),
 		zap.Int("process_count", len(processes)))
@@ -256,7 +295,15 @@ func getPIDForState(state int, originalPID int) int {
 	}
 }
 
+// StartProcess 启动进程；startGuard 拒绝时（如进程已被隔离）不发起调用
 func (n *Node) StartProcess(name string) error {
+	if err := n.checkStart(name); err != nil {
+		return err
+	}
+	return n.startProcess(name)
+}
+
+func (n *Node) startProcess(name string) error {
 	n.mu.RLock()
 	connected := n.IsConnected
 	n.mu.RUnlock()
@@ -268,6 +315,24 @@ func (n *Node) StartProcess(name string) error {
 	return n.client.StartProcess(name)
 }
 
+// checkStart 执行 startGuard
+func (n *Node) checkStart(name string) error {
+	n.mu.RLock()
+	guard := n.startGuard
+	n.mu.RUnlock()
+
+	if guard == nil {
+		return nil
+	}
+	return guard(n.Name, name)
+}
+
+func (n *Node) setStartGuard(fn func(nodeName, processName string) error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.startGuard = fn
+}
+
 func (n *Node) StopProcess(name string) error {
 	n.mu.RLock()
 	connected := n.IsConnected
@@ -280,7 +345,25 @@ func (n *Node) StopProcess(name string) error {
 	return n.client.StopProcess(name)
 }
 
+// SignalProcess 向进程发送信号
+func (n *Node) SignalProcess(name, signal string) error {
+	n.mu.RLock()
+	connected := n.IsConnected
+	n.mu.RUnlock()
+
+	if !connected {
+		return ErrNodeNotConnected
+	}
+
+	return n.client.SignalProcess(name, signal)
+}
+
+// RestartProcess 先停止再启动；startGuard 拒绝启动时不停止进程
 func (n *Node) RestartProcess(name string) error {
+	if err := n.checkStart(name); err != nil {
+		return err
+	}
+
 	n.mu.RLock()
 	connected := n.IsConnected
 	n.mu.RUnlock()
@@ -545,6 +628,8 @@ func (n *Node) Serialize() map[string]interface{} {
 		"last_ping":      lastPing,
 		"process_count":  len(n.Processes),
 		"running_count":  runningCount,
+		"maintenance":    n.maintenance,
+		"labels":         labels.Clone(n.labels),
 	}
 }
 
@@ -573,6 +658,41 @@ func (n *Node) SerializeProcesses() []map[string]interface{} {
 	return processes
 }
 
+// SetMaintenance 设置维护模式
+func (n *Node) SetMaintenance(enabled bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.maintenance = enabled
+}
+
+// SetLabels 设置节点标签
+func (n *Node) SetLabels(nodeLabels map[string]string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.labels = labels.Clone(nodeLabels)
+}
+
+// Labels 获取节点标签的副本
+func (n *Node) Labels() map[string]string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return labels.Clone(n.labels)
+}
+
+// MatchesSelector 节点标签是否满足选择器
+func (n *Node) MatchesSelector(selector labels.Selector) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return selector.Matches(n.labels)
+}
+
+// InMaintenance 节点是否处于维护模式
+func (n *Node) InMaintenance() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.maintenance
+}
+
 // GetConnectionStatus 安全地获取连接状态
 func (n *Node) GetConnectionStatus() (bool, time.Time) {
 	n.mu.RLock()
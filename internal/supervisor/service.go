@@ -8,6 +8,7 @@ import (
 	"time"
 	"superview/internal/config"
 	"superview/internal/errors"
+	"superview/internal/labels"
 	"superview/internal/logger"
 	"go.uber.org/zap"
 )
@@ -28,6 +29,15 @@ type SupervisorService struct {
 	nodeStates         map[string]bool            // nodeName -> isConnected
 	statesMu           sync.RWMutex
 	
+	// 节点移除回调（告警监控、WebSocket Hub 清理各自的节点状态）
+	removeHandlers     []func(name string)
+	
+	// 进程抖动检测：状态监控中统计重启次数，开始或停止抖动时通知 flapHandlers
+	flapDetector       *FlapDetector
+	flapHandlers       []func(FlapEvent)
+	// startGuard 不为 nil 时设置到每个节点，任何途径启动或重启进程前都会调用，返回错误则拒绝启动（如进程已被隔离）
+	startGuard         func(nodeName, processName string) error
+	
 	// Connection management - configurable
 	connectionSemaphore chan struct{} // Configurable concurrent connections limit
 	config             *config.PerformanceConfig
@@ -44,6 +54,7 @@ func NewSupervisorService() *SupervisorService {
 		nodeStates:          make(map[string]bool),
 		connectionSemaphore: make(chan struct{}, 100), // Default fallback
 		timeoutManager:      NewTimeoutManager(nil),   // Use default config
+		flapDetector:        NewFlapDetector(nil),
 	}
 }
 
@@ -62,6 +73,7 @@ func NewSupervisorServiceWithConfig(perfConfig *config.PerformanceConfig) *Super
 		connectionSemaphore: make(chan struct{}, maxConn),
 		config:              perfConfig,
 		timeoutManager:      NewTimeoutManager(nil), // Use default config
+		flapDetector:        NewFlapDetector(nil),
 	}
 }
 
@@ -105,6 +117,9 @@ func (s *SupervisorService) StopMonitoring(stopChan chan struct{}) {
 
 // monitorStates 监控节点和进程状态变化
 func (s *SupervisorService) monitorStates() {
+	start := time.Now()
+	defer func() { refreshCycleDuration.Observe(time.Since(start).Seconds(), "monitor") }()
+
 	s.mu.RLock()
 	nodes := make([]*Node, 0, len(s.nodes))
 	for _, node := range s.nodes {
@@ -113,6 +128,10 @@ func (s *SupervisorService) monitorStates() {
 	s.mu.RUnlock()
 
 	for _, node := range nodes {
+		if node.InMaintenance() {
+			continue
+		}
+
 		// 检查节点连接状态
 		s.checkNodeConnectionState(node)
 
@@ -158,6 +177,11 @@ func (s *SupervisorService) checkProcessStates(node *Node) {
 		return
 	}
 
+	// 抖动事件在释放 statesMu 之后再通知，处理函数可能需要停止进程
+	var flapEvents []FlapEvent
+	defer func() { s.notifyFlapping(flapEvents) }()
+	now := time.Now()
+
 	s.statesMu.Lock()
 	defer s.statesMu.Unlock()
 
@@ -167,6 +191,10 @@ func (s *SupervisorService) checkProcessStates(node *Node) {
 	}
 
 	for _, process := range node.Processes {
+		if event := s.flapDetector.Observe(node.Name, process, now); event != nil {
+			flapEvents = append(flapEvents, *event)
+		}
+
 		processKey := process.Name
 		previousState, exists := s.processStates[node.Name][processKey]
 		currentState := process.State
@@ -282,6 +310,7 @@ func (s *SupervisorService) AddNode(name, environment, host string, port int, us
 		}
 	}
 
+	node.setStartGuard(s.startGuard)
 	s.nodes[name] = node
 	logger.Info("Node added to service",
 		zap.String("name", name),
@@ -332,6 +361,143 @@ func (s *SupervisorService) UpdateNodeInfo(oldName, newName, environment string)
 	return nil
 }
 
+// ReplaceNode 用新的连接参数重建节点并重新连接，保留维护模式
+// 与 RemoveNode + AddNode 不同，不触发节点移除回调（告警、日志订阅保持不变）
+func (s *SupervisorService) ReplaceNode(name, environment, host string, port int, username, password string) error {
+	if atomic.LoadInt32(&s.shutdown) != 0 {
+		return errors.NewInternalError("service is shutting down", nil)
+	}
+
+	node, err := NewNode(name, environment, host, port, username, password)
+	if err != nil {
+		return err
+	}
+
+	// 连接在锁外进行，避免慢节点阻塞其他读取
+	if err := node.Connect(); err != nil {
+		logger.Warn("Failed to connect to node",
+			zap.String("name", name),
+			zap.Error(err))
+		node.IsConnected = false
+	} else {
+		node.IsConnected = true
+		node.LastPing = time.Now()
+		if err := node.RefreshProcesses(); err != nil {
+			logger.Warn("Failed to refresh processes on reconnect",
+				zap.String("name", name),
+				zap.Error(err))
+		}
+	}
+
+	s.mu.Lock()
+	old, exists := s.nodes[name]
+	if !exists {
+		s.mu.Unlock()
+		return errors.NewNotFoundError("node", name)
+	}
+	node.SetMaintenance(old.InMaintenance())
+	node.SetLabels(old.Labels())
+	node.setStartGuard(s.startGuard)
+	s.nodes[name] = node
+	s.mu.Unlock()
+
+	s.statesMu.Lock()
+	delete(s.processStates, name)
+	delete(s.nodeStates, name)
+	s.statesMu.Unlock()
+	s.flapDetector.ForgetNode(name)
+
+	logger.Info("Node replaced in service",
+		zap.String("name", name),
+		zap.String("host", host),
+		zap.Int("port", port),
+		zap.Bool("connected", node.IsConnected))
+	return nil
+}
+
+// OnNodeRemoved 注册节点移除回调，回调在 RemoveNode 返回前同步执行
+func (s *SupervisorService) OnNodeRemoved(fn func(name string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeHandlers = append(s.removeHandlers, fn)
+}
+
+// RemoveNode 从服务中移除节点，并清理状态监控缓存
+func (s *SupervisorService) RemoveNode(name string) error {
+	s.mu.Lock()
+	if atomic.LoadInt32(&s.shutdown) != 0 {
+		s.mu.Unlock()
+		return errors.NewInternalError("service is shutting down", nil)
+	}
+	if _, exists := s.nodes[name]; !exists {
+		s.mu.Unlock()
+		return errors.NewNotFoundError("node", name)
+	}
+	delete(s.nodes, name)
+	handlers := make([]func(string), len(s.removeHandlers))
+	copy(handlers, s.removeHandlers)
+	s.mu.Unlock()
+
+	s.statesMu.Lock()
+	delete(s.processStates, name)
+	delete(s.nodeStates, name)
+	s.statesMu.Unlock()
+
+	for _, fn := range handlers {
+		fn(name)
+	}
+
+	logger.Info("Node removed from service", zap.String("name", name))
+	return nil
+}
+
+// SetNodeMaintenance 设置节点维护模式；维护中的节点不再轮询连接和进程状态，也不产生告警
+// 退出维护时清空状态缓存，避免把维护期间的变化当作新事件记录
+func (s *SupervisorService) SetNodeMaintenance(name string, enabled bool) error {
+	node, err := s.GetNode(name)
+	if err != nil {
+		return err
+	}
+	node.SetMaintenance(enabled)
+
+	if !enabled {
+		s.statesMu.Lock()
+		delete(s.processStates, name)
+		delete(s.nodeStates, name)
+		s.statesMu.Unlock()
+	}
+
+	logger.Info("Node maintenance mode changed",
+		zap.String("name", name),
+		zap.Bool("maintenance", enabled))
+	return nil
+}
+
+// SetNodeLabels 设置节点标签
+func (s *SupervisorService) SetNodeLabels(name string, nodeLabels map[string]string) error {
+	node, err := s.GetNode(name)
+	if err != nil {
+		return err
+	}
+	node.SetLabels(nodeLabels)
+	return nil
+}
+
+// GetNodesBySelector 返回标签满足选择器的节点，空选择器返回所有节点
+func (s *SupervisorService) GetNodesBySelector(selector labels.Selector) []*Node {
+	nodes := s.GetAllNodes()
+	if selector.Empty() {
+		return nodes
+	}
+	matched := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		if node.MatchesSelector(selector) {
+			matched = append(matched, node)
+		}
+	}
+	return matched
+}
+
 func (s *SupervisorService) GetAllNodes() []*Node {
 	// 检查是否已关闭
 	if atomic.LoadInt32(&s.shutdown) != 0 {
@@ -386,13 +552,17 @@ func (s *SupervisorService) StartProcess(nodeName, processName string) error {
 	if err != nil {
 		return err
 	}
+	// 在重试之外检查，被拒绝的启动不计入熔断器
+	if err := node.checkStart(processName); err != nil {
+		return err
+	}
 	
 	// 使用超时管理器执行操作
 	ctx := context.Background()
 	operationName := fmt.Sprintf("start_process_%s_%s", nodeName, processName)
 	
 	return s.timeoutManager.ExecuteWithRetry(ctx, operationName, func(ctx context.Context) error {
-		return node.StartProcess(processName)
+		return node.startProcess(processName)
 	})
 }
 
@@ -411,6 +581,15 @@ func (s *SupervisorService) StopProcess(nodeName, processName string) error {
 	})
 }
 
+// SignalProcess 向进程发送信号
+func (s *SupervisorService) SignalProcess(nodeName, processName, signal string) error {
+	node, err := s.GetNode(nodeName)
+	if err != nil {
+		return err
+	}
+	return node.SignalProcess(processName, signal)
+}
+
 func (s *SupervisorService) GetProcessLogs(nodeName, processName string) (map[string][]string, error) {
 	node, err := s.GetNode(nodeName)
 	if err != nil {
@@ -701,22 +880,22 @@ func (s *SupervisorService) GetGroupDetails(groupName string) map[string]interfa
 }
 
 // StartGroupProcesses 启动分组中的所有进程
-func (s *SupervisorService) StartGroupProcesses(groupName, environmentName string) error {
-	return s.operateGroupProcesses(groupName, environmentName, "start")
+func (s *SupervisorService) StartGroupProcesses(groupName, environmentName string, selector labels.Selector) error {
+	return s.operateGroupProcesses(groupName, environmentName, selector, "start")
 }
 
 // StopGroupProcesses 停止分组中的所有进程
-func (s *SupervisorService) StopGroupProcesses(groupName, environmentName string) error {
-	return s.operateGroupProcesses(groupName, environmentName, "stop")
+func (s *SupervisorService) StopGroupProcesses(groupName, environmentName string, selector labels.Selector) error {
+	return s.operateGroupProcesses(groupName, environmentName, selector, "stop")
 }
 
 // RestartGroupProcesses 重启分组中的所有进程
-func (s *SupervisorService) RestartGroupProcesses(groupName, environmentName string) error {
-	return s.operateGroupProcesses(groupName, environmentName, "restart")
+func (s *SupervisorService) RestartGroupProcesses(groupName, environmentName string, selector labels.Selector) error {
+	return s.operateGroupProcesses(groupName, environmentName, selector, "restart")
 }
 
-// operateGroupProcesses 对分组中的进程执行操作
-func (s *SupervisorService) operateGroupProcesses(groupName, environmentName, operation string) error {
+// operateGroupProcesses 对分组中的进程执行操作；environmentName 为空、selector 为空时不按该条件过滤节点
+func (s *SupervisorService) operateGroupProcesses(groupName, environmentName string, selector labels.Selector, operation string) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	
@@ -724,6 +903,9 @@ func (s *SupervisorService) operateGroupProcesses(groupName, environmentName, op
 		if environmentName != "" && node.Environment != environmentName {
 			continue
 		}
+		if !node.MatchesSelector(selector) {
+			continue
+		}
 		
 		isConnected, _ := node.GetConnectionStatus()
 		if !isConnected {
@@ -770,6 +952,7 @@ func (s *SupervisorService) StartAutoRefresh(interval time.Duration) chan struct
 			case <-ticker.C:
 				// 刷新所有节点状态
 				logger.Debug("Auto-refreshing node connections")
+				cycleStart := time.Now()
 				
 				// 收集节点列表，避免在持有锁时进行网络操作
 				s.mu.RLock()
@@ -781,6 +964,9 @@ func (s *SupervisorService) StartAutoRefresh(interval time.Duration) chan struct
 				
 				// 在锁外进行网络操作
 				for _, node := range nodes {
+					if node.InMaintenance() {
+						continue
+					}
 					prevConnected := node.IsConnected
 					if err := node.Connect(); err == nil {
 						node.IsConnected = true
@@ -798,6 +984,7 @@ func (s *SupervisorService) StartAutoRefresh(interval time.Duration) chan struct
 						}
 					}
 				}
+				refreshCycleDuration.Observe(time.Since(cycleStart).Seconds(), "connection")
 			case <-stopChan:
 				logger.Debug("Stopping auto-refresh goroutine")
 				return
@@ -363,4 +363,78 @@ func TestConnectionSemaphore(t *testing.T) {
 	if semaphoreLength != 0 {
 		t.Errorf("Expected empty semaphore initially, got length %d", semaphoreLength)
 	}
-}
\ No newline at end of file
+}
+
+// 单元测试：RemoveNode 移除节点、清理状态缓存并触发回调
+func TestRemoveNode(t *testing.T) {
+	service := NewSupervisorService()
+
+	var removed []string
+	service.OnNodeRemoved(func(name string) {
+		removed = append(removed, name)
+	})
+
+	if err := service.AddNode("node-a", "test-env", "127.0.0.1", 1, "", ""); err != nil {
+		t.Fatalf("Failed to add node: %v", err)
+	}
+	service.statesMu.Lock()
+	service.nodeStates["node-a"] = false
+	service.processStates["node-a"] = map[string]int{"web": 20}
+	service.statesMu.Unlock()
+
+	if err := service.RemoveNode("node-a"); err != nil {
+		t.Fatalf("Failed to remove node: %v", err)
+	}
+	if _, err := service.GetNode("node-a"); err == nil {
+		t.Error("Expected node to be removed")
+	}
+	if _, exists := service.nodeStates["node-a"]; exists {
+		t.Error("Expected node state to be cleared")
+	}
+	if _, exists := service.processStates["node-a"]; exists {
+		t.Error("Expected process states to be cleared")
+	}
+	if len(removed) != 1 || removed[0] != "node-a" {
+		t.Errorf("Expected remove callback for node-a, got %v", removed)
+	}
+
+	if err := service.RemoveNode("node-a"); err == nil {
+		t.Error("Expected error when removing unknown node")
+	}
+}
+
+// 单元测试：维护模式的节点不参与状态监控
+func TestSetNodeMaintenance(t *testing.T) {
+	service := NewSupervisorService()
+	if err := service.AddNode("node-a", "test-env", "127.0.0.1", 1, "", ""); err != nil {
+		t.Fatalf("Failed to add node: %v", err)
+	}
+
+	if err := service.SetNodeMaintenance("node-a", true); err != nil {
+		t.Fatalf("Failed to enable maintenance: %v", err)
+	}
+	node, _ := service.GetNode("node-a")
+	if !node.InMaintenance() {
+		t.Error("Expected node to be in maintenance")
+	}
+	if node.Serialize()["maintenance"] != true {
+		t.Error("Expected serialized node to report maintenance")
+	}
+
+	service.monitorStates()
+	if _, exists := service.nodeStates["node-a"]; exists {
+		t.Error("Expected maintenance node to be skipped by monitoring")
+	}
+
+	if err := service.SetNodeMaintenance("node-a", false); err != nil {
+		t.Fatalf("Failed to disable maintenance: %v", err)
+	}
+	service.monitorStates()
+	if _, exists := service.nodeStates["node-a"]; !exists {
+		t.Error("Expected node to be monitored after leaving maintenance")
+	}
+
+	if err := service.SetNodeMaintenance("missing", true); err == nil {
+		t.Error("Expected error for unknown node")
+	}
+}
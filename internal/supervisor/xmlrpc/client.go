@@ -15,8 +15,12 @@ type Client struct {
 	username string
 	password string
 	client   *http.Client
+	observer CallObserver
 }
 
+// CallObserver 每次调用结束后收到方法名、耗时和错误（包括 XML-RPC fault），用于指标统计
+type CallObserver func(method string, duration time.Duration, err error)
+
 func NewClient(host string, port int, username, password string) (*Client, error) {
 	if !strings.HasPrefix(host, "http://") && !strings.HasPrefix(host, "https://") {
 		host = "http://" + host
@@ -46,7 +50,25 @@ func NewClient(host string, port int, username, password string) (*Client, error
 	}, nil
 }
 
+// SetObserver 设置调用观察者
+func (c *Client) SetObserver(observer CallObserver) {
+	c.observer = observer
+}
+
 func (c *Client) Call(method string, args []interface{}) (interface{}, error) {
+	start := time.Now()
+	result, err := c.call(method, args)
+	if c.observer != nil {
+		observed := err
+		if body, ok := result.(string); ok && err == nil && strings.Contains(body, "<fault>") {
+			observed = fmt.Errorf("XML-RPC fault in response")
+		}
+		c.observer(method, time.Since(start), observed)
+	}
+	return result, err
+}
+
+func (c *Client) call(method string, args []interface{}) (interface{}, error) {
 	// 构建XML-RPC请求
 	request := fmt.Sprintf(`<?xml version="1.0"?>
 <methodCall>
@@ -38,6 +38,11 @@ func NewSupervisorClient(host string, port int, username, password string) (*Sup
 	return &SupervisorClient{client: client}, nil
 }
 
+// SetObserver 设置 XML-RPC 调用观察者
+func (s *SupervisorClient) SetObserver(observer CallObserver) {
+	s.client.SetObserver(observer)
+}
+
 // GetAllProcessInfo 获取所有进程信息 - 符合官方 API 规范
 func (s *SupervisorClient) GetAllProcessInfo() ([]ProcessInfo, error) {
 	result, err := s.client.Call("supervisor.getAllProcessInfo", nil)
@@ -306,6 +311,38 @@ func formatUptime(seconds int64) string {
 	return fmt.Sprintf("%dd %dh", days, hours%24)
 }
 
+// GetSupervisorVersion 获取 supervisord 版本
+func (s *SupervisorClient) GetSupervisorVersion() (string, error) {
+	return s.callString("supervisor.getSupervisorVersion")
+}
+
+// GetIdentification 获取 supervisord 标识（配置中的 identifier，默认 "supervisor"）
+func (s *SupervisorClient) GetIdentification() (string, error) {
+	return s.callString("supervisor.getIdentification")
+}
+
+// callString 调用无参数、返回字符串的方法
+func (s *SupervisorClient) callString(method string) (string, error) {
+	result, err := s.client.Call(method, nil)
+	if err != nil {
+		return "", fmt.Errorf("XML-RPC call failed: %v", err)
+	}
+
+	xmlResponse, ok := result.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected response type: %T", result)
+	}
+	if _, faultString, isFault := parseFaultResponse(xmlResponse); isFault {
+		return "", fmt.Errorf("XML-RPC fault: %s", faultString)
+	}
+
+	start := strings.Index(xmlResponse, "<param>")
+	if start == -1 {
+		return "", fmt.Errorf("no value found in response")
+	}
+	return extractStringValue(xmlResponse[start:]), nil
+}
+
 // StartProcess 启动进程
 func (s *SupervisorClient) StartProcess(name string) error {
 	result, err := s.client.Call("supervisor.startProcess", []interface{}{name})
@@ -390,6 +427,32 @@ func (s *SupervisorClient) StopProcess(name string) error {
 	return nil
 }
 
+// SignalProcess 向进程发送信号（supervisor 3.2+），signal 可为名称（HUP）或编号
+func (s *SupervisorClient) SignalProcess(name, signal string) error {
+	result, err := s.client.Call("supervisor.signalProcess", []interface{}{name, signal})
+	if err != nil {
+		return err
+	}
+
+	xmlResponse, ok := result.(string)
+	if !ok {
+		return fmt.Errorf("unexpected response type: %T", result)
+	}
+
+	if faultCode, faultString, isFault := parseFaultResponse(xmlResponse); isFault {
+		return fmt.Errorf("supervisor fault [%d]: %s", faultCode, faultString)
+	}
+
+	success, err := parseBooleanResponse(xmlResponse)
+	if err != nil {
+		return fmt.Errorf("failed to parse response for process %s: %v", name, err)
+	}
+	if !success {
+		return fmt.Errorf("supervisor rejected signal %s for process %s", signal, name)
+	}
+	return nil
+}
+
 // GetProcessInfo 获取单个进程信息
 func (s *SupervisorClient) GetProcessInfo(name string) (*ProcessInfo, error) {
 	result, err := s.client.Call("supervisor.getProcessInfo", []interface{}{name})
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxPorts is the maximum number of ports a single port list may expand to
+const MaxPorts = 1024
+
+// MaxHostPatternExpansion is the maximum number of hostnames a pattern may expand to
+const MaxHostPatternExpansion = 4096
+
+// ParseIPRange parses an IPv4 range such as "10.0.0.5-10.0.0.20" or "10.0.0.5-20"
+// (the short form replaces the last octet) and returns all addresses in order.
+func ParseIPRange(spec string) ([]string, error) {
+	startStr, endStr, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid IP range %q: expected start-end", spec)
+	}
+	start := net.ParseIP(strings.TrimSpace(startStr)).To4()
+	if start == nil {
+		return nil, fmt.Errorf("invalid IP range %q: start is not an IPv4 address", spec)
+	}
+	endStr = strings.TrimSpace(endStr)
+	var end net.IP
+	if octet, err := strconv.Atoi(endStr); err == nil {
+		if octet < 0 || octet > 255 {
+			return nil, fmt.Errorf("invalid IP range %q: last octet out of range", spec)
+		}
+		end = net.IPv4(start[0], start[1], start[2], byte(octet)).To4()
+	} else if end = net.ParseIP(endStr).To4(); end == nil {
+		return nil, fmt.Errorf("invalid IP range %q: end is not an IPv4 address", spec)
+	}
+
+	first := binary.BigEndian.Uint32(start)
+	last := binary.BigEndian.Uint32(end)
+	if last < first {
+		return nil, fmt.Errorf("invalid IP range %q: end is before start", spec)
+	}
+	// Count in uint64: 0.0.0.0-255.255.255.255 would wrap to 0 in uint32
+	count := uint64(last) - uint64(first) + 1
+	if count > MaxCIDRAddresses {
+		return nil, fmt.Errorf("invalid IP range %q: exceeds maximum of %d addresses", spec, MaxCIDRAddresses)
+	}
+
+	ips := make([]string, 0, count)
+	for i := first; ; i++ {
+		ipBytes := make([]byte, 4)
+		binary.BigEndian.PutUint32(ipBytes, i)
+		ips = append(ips, net.IP(ipBytes).String())
+		if i == last {
+			break
+		}
+	}
+	return ips, nil
+}
+
+// ParsePortList parses a comma separated list of ports and port ranges such as
+// "9001,9002,9100-9105" and returns the distinct ports in ascending order.
+func ParsePortList(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lowStr, highStr, isRange := strings.Cut(part, "-")
+		low, err := parsePort(lowStr)
+		if err != nil {
+			return nil, err
+		}
+		high := low
+		if isRange {
+			if high, err = parsePort(highStr); err != nil {
+				return nil, err
+			}
+			if high < low {
+				return nil, fmt.Errorf("invalid port range %q", part)
+			}
+		}
+		if len(seen)+high-low+1 > MaxPorts {
+			return nil, fmt.Errorf("port list %q exceeds maximum of %d ports", spec, MaxPorts)
+		}
+		for port := low; port <= high; port++ {
+			seen[port] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("empty port list")
+	}
+
+	ports := make([]int, 0, len(seen))
+	for port := range seen {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q: must be between 1 and 65535", strings.TrimSpace(s))
+	}
+	return port, nil
+}
+
+var hostPatternRange = regexp.MustCompile(`\[(\d+)-(\d+)\]`)
+
+// ExpandHostPattern expands numeric ranges in a hostname pattern, for example
+// "app-[1-3].example.com" or "web[01-10].dc1". Leading zeros in the start of a
+// range set the width of the generated numbers. Patterns without ranges are
+// returned unchanged.
+func ExpandHostPattern(pattern string) ([]string, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("empty hostname")
+	}
+
+	match := hostPatternRange.FindStringSubmatchIndex(pattern)
+	if match == nil {
+		if strings.ContainsAny(pattern, "[]") {
+			return nil, fmt.Errorf("invalid hostname pattern %q: ranges must look like [1-10]", pattern)
+		}
+		if !validHostname(pattern) {
+			return nil, fmt.Errorf("invalid hostname %q", pattern)
+		}
+		return []string{pattern}, nil
+	}
+
+	lowStr := pattern[match[2]:match[3]]
+	low, _ := strconv.Atoi(lowStr)
+	high, err := strconv.Atoi(pattern[match[4]:match[5]])
+	if err != nil || high < low {
+		return nil, fmt.Errorf("invalid hostname pattern %q: bad range", pattern)
+	}
+	if high-low+1 > MaxHostPatternExpansion {
+		return nil, fmt.Errorf("hostname pattern %q exceeds maximum of %d names", pattern, MaxHostPatternExpansion)
+	}
+	width := 0
+	if len(lowStr) > 1 && lowStr[0] == '0' {
+		width = len(lowStr)
+	}
+
+	prefix, suffix := pattern[:match[0]], pattern[match[1]:]
+	var hosts []string
+	for i := low; i <= high; i++ {
+		rest, err := ExpandHostPattern(prefix + fmt.Sprintf("%0*d", width, i) + suffix)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, rest...)
+		if len(hosts) > MaxHostPatternExpansion {
+			return nil, fmt.Errorf("hostname pattern %q exceeds maximum of %d names", pattern, MaxHostPatternExpansion)
+		}
+	}
+	return hosts, nil
+}
+
+// validHostname checks RFC 1123 hostname syntax
+func validHostname(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if len(host) == 0 || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIPRange(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected []string
+	}{
+		{"10.0.0.5-10.0.0.7", []string{"10.0.0.5", "10.0.0.6", "10.0.0.7"}},
+		{"10.0.0.254-1", nil},
+		{"10.0.0.8-9", []string{"10.0.0.8", "10.0.0.9"}},
+		{"10.0.0.255-10.0.1.1", []string{"10.0.0.255", "10.0.1.0", "10.0.1.1"}},
+		{"10.0.0.1-10.0.0.1", []string{"10.0.0.1"}},
+	}
+	for _, tt := range tests {
+		ips, err := ParseIPRange(tt.spec)
+		if tt.expected == nil {
+			if err == nil {
+				t.Errorf("ParseIPRange(%q) expected error", tt.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseIPRange(%q) unexpected error: %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(ips, tt.expected) {
+			t.Errorf("ParseIPRange(%q) = %v, want %v", tt.spec, ips, tt.expected)
+		}
+	}
+
+	for _, spec := range []string{"", "10.0.0.1", "a-b", "10.0.0.1-256", "::1-::2", "10.0.0.0-10.1.0.0",
+		"0.0.0.0-255.255.255.255", "0.0.0.1-255.255.255.255", "0.0.0.0-255.255.255.254"} {
+		if _, err := ParseIPRange(spec); err == nil {
+			t.Errorf("ParseIPRange(%q) expected error", spec)
+		}
+	}
+}
+
+func TestParsePortList(t *testing.T) {
+	ports, err := ParsePortList("9002, 9001,9100-9102,9001")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ports, []int{9001, 9002, 9100, 9101, 9102}) {
+		t.Errorf("ParsePortList = %v", ports)
+	}
+
+	for _, spec := range []string{"", "0", "65536", "9002-9001", "abc", "1-2000"} {
+		if _, err := ParsePortList(spec); err == nil {
+			t.Errorf("ParsePortList(%q) expected error", spec)
+		}
+	}
+}
+
+func TestExpandHostPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"sv.example.com", []string{"sv.example.com"}},
+		{"app-[1-3].example.com", []string{"app-1.example.com", "app-2.example.com", "app-3.example.com"}},
+		{"web[08-10]", []string{"web08", "web09", "web10"}},
+		{"r[1-2]n[1-2]", []string{"r1n1", "r1n2", "r2n1", "r2n2"}},
+	}
+	for _, tt := range tests {
+		hosts, err := ExpandHostPattern(tt.pattern)
+		if err != nil {
+			t.Errorf("ExpandHostPattern(%q) unexpected error: %v", tt.pattern, err)
+			continue
+		}
+		if !reflect.DeepEqual(hosts, tt.expected) {
+			t.Errorf("ExpandHostPattern(%q) = %v, want %v", tt.pattern, hosts, tt.expected)
+		}
+	}
+
+	for _, pattern := range []string{"", "app-[3-1]", "app-[a-b]", "bad host", "-app", "app-[1-5000]"} {
+		if _, err := ExpandHostPattern(pattern); err == nil {
+			t.Errorf("ExpandHostPattern(%q) expected error", pattern)
+		}
+	}
+}
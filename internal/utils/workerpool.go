@@ -24,6 +24,8 @@ type WorkerPool struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	mu         sync.RWMutex
+	stopOnce   sync.Once
+	stopped    bool
 	activeJobs int
 	totalJobs  int64
 	errors     int64
@@ -156,6 +158,13 @@ func (wp *WorkerPool) Submit(task Task) error {
 	default:
 	}
 	
+	// 持有读锁，避免与 Stop 关闭任务队列并发
+	wp.mu.RLock()
+	defer wp.mu.RUnlock()
+	if wp.stopped {
+		return context.Canceled
+	}
+
 	// 尝试提交任务，使用非阻塞发送避免 panic
 	select {
 	case <-wp.ctx.Done():
@@ -187,23 +196,28 @@ func (wp *WorkerPool) Results() <-chan TaskResult {
 	return wp.results
 }
 
-// Stop 停止工作池
+// Stop 停止工作池，可重复调用
 func (wp *WorkerPool) Stop() {
-	logger.Info("Stopping worker pool")
-	
-	// 取消上下文
-	wp.cancel()
-	
-	// 关闭任务队列
-	close(wp.taskQueue)
-	
-	// 等待所有工作协程完成
-	wp.wg.Wait()
-	
-	// 关闭结果通道
-	close(wp.results)
-	
-	logger.Info("Worker pool stopped")
+	wp.stopOnce.Do(func() {
+		logger.Info("Stopping worker pool")
+
+		// 取消上下文
+		wp.cancel()
+
+		// 关闭任务队列
+		wp.mu.Lock()
+		wp.stopped = true
+		close(wp.taskQueue)
+		wp.mu.Unlock()
+
+		// 等待所有工作协程完成
+		wp.wg.Wait()
+
+		// 关闭结果通道
+		close(wp.results)
+
+		logger.Info("Worker pool stopped")
+	})
 }
 
 // StopWithTimeout 带超时的停止
@@ -0,0 +1,269 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"superview/internal/logger"
+)
+
+// DefaultEventBufferSize 默认保留的事件条数，SSE 客户端重连时按 Last-Event-ID 重放
+const DefaultEventBufferSize = 512
+
+// 周期性推送或自带续传机制的消息，重连后会重新收到完整状态，不分配事件 ID
+var transientMessageTypes = map[string]bool{
+	"ping":                   true,
+	"pong":                   true,
+	"nodes_update":           true,
+	MessageTypeNodesSnapshot: true,
+	MessageTypeNodesDelta:    true,
+	"system_stats":           true,
+	"server_shutdown":        true,
+}
+
+// hubEvent 已推送的事件，match 为推送时的接收条件
+type hubEvent struct {
+	id          uint64
+	messageType string
+	data        []byte
+	match       func(*Client) bool
+}
+
+// eventHistory 固定容量的事件环形缓冲区
+type eventHistory struct {
+	entries []hubEvent
+	next    int
+	size    int
+}
+
+func newEventHistory(capacity int) *eventHistory {
+	if capacity <= 0 {
+		capacity = DefaultEventBufferSize
+	}
+	return &eventHistory{entries: make([]hubEvent, capacity)}
+}
+
+func (e *eventHistory) add(event hubEvent) {
+	e.entries[e.next] = event
+	e.next = (e.next + 1) % len(e.entries)
+	if e.size < len(e.entries) {
+		e.size++
+	}
+}
+
+// since 返回 lastID 之后到 current 的全部事件；缺失时返回 false
+func (e *eventHistory) since(lastID, current uint64) ([]hubEvent, bool) {
+	if lastID > current {
+		return nil, false
+	}
+	missing := current - lastID
+	if missing > uint64(e.size) {
+		return nil, false
+	}
+
+	result := make([]hubEvent, 0, missing)
+	start := (e.next - int(missing) + len(e.entries)) % len(e.entries)
+	for i := 0; i < int(missing); i++ {
+		result = append(result, e.entries[(start+i)%len(e.entries)])
+	}
+	return result, true
+}
+
+// messageEnvelope 读取已编码消息的 type 和 topics 字段
+func messageEnvelope(data []byte) (string, []string) {
+	var envelope struct {
+		Type   string   `json:"type"`
+		Topics []string `json:"topics"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return "", nil
+	}
+	return envelope.Type, envelope.Topics
+}
+
+// parseEventID 解析 "<epoch>-<id>" 格式的事件 ID
+func parseEventID(value string) (string, uint64, bool) {
+	i := strings.LastIndex(value, "-")
+	if i <= 0 {
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(value[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return value[:i], id, true
+}
+
+// accepts 客户端是否接收该类型的消息。未设置 types 时接收全部，ping 和关闭通知总是接收
+func (c *Client) accepts(messageType string) bool {
+	if c.types == nil || messageType == "ping" || messageType == "server_shutdown" {
+		return true
+	}
+	for _, pattern := range c.types {
+		if pattern == messageType || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(messageType, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// frame 按客户端的传输方式编码消息：WebSocket 原样发送，SSE 编码为事件（id 为 0 时不带 id）
+func (c *Client) frame(id uint64, data []byte) []byte {
+	if !c.sse {
+		return data
+	}
+	var buf bytes.Buffer
+	if id > 0 {
+		fmt.Fprintf(&buf, "id: %s-%d\n", c.hub.epoch, id)
+	}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// closeConn 断开客户端的底层连接
+func (c *Client) closeConn() {
+	if c.conn != nil {
+		c.conn.Close()
+	} else if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// publish 向满足条件（match 为 nil 表示全部）且订阅了该类型的客户端推送消息，两种传输共用。
+// 非周期性消息分配事件 ID 并写入缓冲区，发送队列满的客户端交给清理协程
+func (h *Hub) publish(messageType string, data []byte, match func(*Client) bool) {
+	h.eventMu.Lock()
+	defer h.eventMu.Unlock()
+
+	var id uint64
+	if !transientMessageTypes[messageType] {
+		h.eventID++
+		id = h.eventID
+		h.events.add(hubEvent{id: id, messageType: messageType, data: data, match: match})
+	}
+
+	var nodes []string
+	nodesParsed := false
+
+	h.clientsMu.RLock()
+	clientsToRemove := make([]*Client, 0)
+	for client := range h.clients {
+		if match != nil && !match(client) {
+			continue
+		}
+		// 受限客户端只接收范围内节点的消息
+		if client.restricted() {
+			if !nodesParsed {
+				nodes, nodesParsed = messageNodes(data), true
+			}
+			if !client.allowsMessage(messageType, nodes) {
+				continue
+			}
+		}
+		// 等待重放的客户端由 replayEvents 补发
+		if !client.accepts(messageType) || (id > 0 && client.eventsPending) {
+			continue
+		}
+		select {
+		case client.send <- client.frame(id, data):
+		default:
+			droppedMessages.Inc(dropClientFull)
+			clientsToRemove = append(clientsToRemove, client)
+		}
+	}
+	h.clientsMu.RUnlock()
+
+	h.dropClients(clientsToRemove)
+}
+
+// dropClients 通过清理协程移除发送队列已满的客户端
+func (h *Hub) dropClients(clients []*Client) {
+	for _, client := range clients {
+		select {
+		case h.cleanup <- client:
+		default:
+			// Cleanup channel full, force close
+			logger.Warn("Cleanup channel full, force closing client",
+				zap.String("user_id", client.userID))
+			client.closeConn()
+		}
+	}
+}
+
+// sendToClient 向单个已注册的客户端发送消息，发送队列满时返回 false
+func (h *Hub) sendToClient(client *Client, data []byte) bool {
+	h.clientsMu.RLock()
+	defer h.clientsMu.RUnlock()
+	if _, ok := h.clients[client]; !ok {
+		return false
+	}
+	select {
+	case client.send <- client.frame(0, data):
+		return true
+	default:
+		droppedMessages.Inc(dropClientFull)
+		logger.Warn("Client send channel full",
+			zap.String("user_id", client.userID))
+		return false
+	}
+}
+
+// replayEvents 重连的客户端补发 Last-Event-ID 之后错过的事件。事件已不在缓冲区（或实例已重启）时
+// 发送 events_reset，客户端应通过 REST 接口重新加载
+func (h *Hub) replayEvents(client *Client) {
+	h.eventMu.Lock()
+	defer h.eventMu.Unlock()
+	if !client.eventsPending {
+		return
+	}
+	client.eventsPending = false
+
+	missed, ok := h.events.since(client.resumeEventID, h.eventID)
+	if client.resumeEventEpoch != h.epoch || !ok {
+		data, _ := json.Marshal(Message{Type: "events_reset", Data: map[string]interface{}{"last_event_id": h.eventID}})
+		h.sendToClient(client, data)
+		return
+	}
+
+	replayed := 0
+	for _, event := range missed {
+		if (event.match != nil && !event.match(client)) || !client.accepts(event.messageType) {
+			continue
+		}
+		if client.restricted() && !client.allowsMessage(event.messageType, messageNodes(event.data)) {
+			continue
+		}
+		h.clientsMu.RLock()
+		_, registered := h.clients[client]
+		full := false
+		if registered {
+			select {
+			case client.send <- client.frame(event.id, event.data):
+				replayed++
+			default:
+				droppedMessages.Inc(dropClientFull)
+				full = true
+			}
+		}
+		h.clientsMu.RUnlock()
+		if full {
+			h.dropClients([]*Client{client})
+		}
+		if !registered || full {
+			return
+		}
+	}
+	logger.Debug("Client resumed events",
+		zap.String("user_id", client.userID),
+		zap.Uint64("last_event_id", client.resumeEventID),
+		zap.Int("replayed", replayed))
+}
@@ -111,9 +111,15 @@ func (c *Client) writePump() {
 
 func (c *Client) handleClientMessage(msg ClientMessage) {
 	switch msg.Type {
+	case "subscribe", "unsubscribe":
+		c.handleSubscribe(msg.Data, msg.Type == "subscribe")
+
 	case "subscribe_node":
 		if nodeName, ok := msg.Data["node_name"].(string); ok {
-			c.subscribed.Store(nodeName, true)
+			if rejected := c.hub.subscribeTopics(c, []string{TopicNode + ":" + nodeName}); len(rejected) > 0 {
+				c.replySubscription(rejected)
+				return
+			}
 			logger.Info("Client subscribed to node",
 				zap.String("user_id", c.userID),
 				zap.String("node_name", nodeName))
@@ -135,6 +141,7 @@ func (c *Client) handleClientMessage(msg ClientMessage) {
 					select {
 					case c.send <- data:
 					default:
+						droppedMessages.Inc(dropClientFull)
 						logger.Warn("Client send channel full",
 							zap.String("user_id", c.userID))
 					}
@@ -144,7 +151,7 @@ func (c *Client) handleClientMessage(msg ClientMessage) {
 
 	case "unsubscribe_node":
 		if nodeName, ok := msg.Data["node_name"].(string); ok {
-			c.subscribed.Delete(nodeName)
+			c.subscribed.Delete(TopicNode + ":" + nodeName)
 			logger.Info("Client unsubscribed from node",
 				zap.String("user_id", c.userID),
 				zap.String("node_name", nodeName))
@@ -174,6 +181,7 @@ func (c *Client) handleClientMessage(msg ClientMessage) {
 					select {
 					case c.send <- data:
 					default:
+						droppedMessages.Inc(dropClientFull)
 						logger.Warn("Client send channel full",
 							zap.String("user_id", c.userID))
 					}
@@ -185,7 +193,10 @@ func (c *Client) handleClientMessage(msg ClientMessage) {
 		if nodeName, ok := msg.Data["node_name"].(string); ok {
 			if processName, ok := msg.Data["process_name"].(string); ok {
 				logKey := fmt.Sprintf("%s:%s", nodeName, processName)
-				c.subscribed.Store("logs:"+logKey, true)
+				if rejected := c.hub.subscribeTopics(c, []string{"logs:" + logKey}); len(rejected) > 0 {
+					c.replySubscription(rejected)
+					return
+				}
 				logger.Info("Client subscribed to process logs",
 					zap.String("user_id", c.userID),
 					zap.String("node_name", nodeName),
@@ -207,6 +218,15 @@ func (c *Client) handleClientMessage(msg ClientMessage) {
 			}
 		}
 
+	case "command":
+		c.handleCommand(msg.Data)
+
+	case "resync":
+		// 增量模式的客户端发现序号不连续时请求完整快照
+		if c.delta {
+			c.hub.syncDeltaClient(c, "", 0)
+		}
+
 	case "ping":
 		// Respond with pong
 		pongMsg := Message{
@@ -220,6 +240,7 @@ func (c *Client) handleClientMessage(msg ClientMessage) {
 			select {
 			case c.send <- data:
 			default:
+				droppedMessages.Inc(dropClientFull)
 				logger.Warn("Client send channel full",
 					zap.String("user_id", c.userID))
 			}
@@ -242,32 +263,8 @@ func (h *Hub) SendToSubscribedClients(nodeName string, message Message) {
 		return
 	}
 
-	// Use collect-then-modify pattern to avoid race conditions
-	h.clientsMu.RLock()
-	clientsToRemove := make([]*Client, 0)
-	
-	for client := range h.clients {
-		if _, subscribed := client.subscribed.Load(nodeName); subscribed {
-			select {
-			case client.send <- data:
-				// Successfully sent
-			default:
-				// Client's send channel is full, collect for removal
-				clientsToRemove = append(clientsToRemove, client)
-			}
-		}
-	}
-	h.clientsMu.RUnlock()
-	
-	// Remove clients with full channels via cleanup worker
-	for _, client := range clientsToRemove {
-		select {
-		case h.cleanup <- client:
-		default:
-			// Cleanup channel full, force close
-			logger.Warn("Cleanup channel full, force closing client",
-				zap.String("user_id", client.userID))
-			client.conn.Close()
-		}
-	}
+	h.publish(message.Type, data, func(client *Client) bool {
+		_, subscribed := client.subscribed.Load(TopicNode + ":" + nodeName)
+		return subscribed
+	})
 }
@@ -0,0 +1,165 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"superview/internal/logger"
+)
+
+// 命令相关的消息类型
+const (
+	MessageTypeCommandAck      = "command_ack"
+	MessageTypeCommandProgress = "command_progress"
+	MessageTypeCommandResult   = "command_result"
+)
+
+// MaxConcurrentCommands 每个连接同时执行的命令数上限
+const MaxConcurrentCommands = 4
+
+// Command 客户端通过 WebSocket 发来的控制命令，ID 由客户端生成，原样出现在 ack/progress/result 中
+type Command struct {
+	ID          string `json:"id"`
+	Action      string `json:"action"`
+	NodeName    string `json:"node_name,omitempty"`
+	ProcessName string `json:"process_name,omitempty"`
+	GroupName   string `json:"group_name,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Selector    string `json:"selector,omitempty"`
+	Signal      string `json:"signal,omitempty"`
+}
+
+// CommandContext 发起命令的连接信息
+type CommandContext struct {
+	UserID    string
+	ClientIP  string
+	UserAgent string
+}
+
+// CommandHandler 执行 WebSocket 命令
+// Authorize 在发送 ack 之前校验参数和权限；Execute 通过 progress 推送中间进度，返回值作为结果
+type CommandHandler interface {
+	Authorize(ctx *CommandContext, cmd *Command) error
+	Execute(ctx *CommandContext, cmd *Command, progress func(data interface{})) (interface{}, error)
+}
+
+// CommandError 命令失败原因，code 与 REST 接口的错误码一致
+type CommandError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// CommandReply ack/progress/result 消息的内容
+type CommandReply struct {
+	ID        string        `json:"id"`
+	Action    string        `json:"action"`
+	Success   *bool         `json:"success,omitempty"` // 仅 result
+	Progress  interface{}   `json:"progress,omitempty"`
+	Result    interface{}   `json:"result,omitempty"`
+	Error     *CommandError `json:"error,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// codedError 带错误码的错误（errors.AppError 满足该接口）
+type codedError interface {
+	Code() string
+	Message() string
+}
+
+func newCommandError(err error) *CommandError {
+	if coded, ok := err.(codedError); ok {
+		return &CommandError{Code: coded.Code(), Message: coded.Message()}
+	}
+	return &CommandError{Code: "INTERNAL_ERROR", Message: err.Error()}
+}
+
+// SetCommandHandler 设置命令处理器，未设置时拒绝所有命令
+func (h *Hub) SetCommandHandler(handler CommandHandler) {
+	h.commandMu.Lock()
+	defer h.commandMu.Unlock()
+	h.commandHandler = handler
+}
+
+func (h *Hub) getCommandHandler() CommandHandler {
+	h.commandMu.RLock()
+	defer h.commandMu.RUnlock()
+	return h.commandHandler
+}
+
+// handleCommand 校验命令后异步执行，不阻塞读循环
+func (c *Client) handleCommand(data map[string]interface{}) {
+	var cmd Command
+	raw, _ := json.Marshal(data)
+	if err := json.Unmarshal(raw, &cmd); err != nil || cmd.ID == "" || len(cmd.ID) > 64 || cmd.Action == "" {
+		c.replyCommand(MessageTypeCommandResult, &cmd, false, nil, &CommandError{
+			Code: "VALIDATION_ERROR", Message: "command requires id (at most 64 characters) and action"})
+		return
+	}
+
+	handler := c.hub.getCommandHandler()
+	if handler == nil {
+		c.replyCommand(MessageTypeCommandResult, &cmd, false, nil, &CommandError{
+			Code: "NOT_SUPPORTED", Message: "commands are not enabled"})
+		return
+	}
+	if !c.limiter.Allow() {
+		c.replyCommand(MessageTypeCommandResult, &cmd, false, nil, &CommandError{
+			Code: "RATE_LIMITED", Message: "too many commands"})
+		return
+	}
+	if atomic.AddInt32(&c.runningCommands, 1) > MaxConcurrentCommands {
+		atomic.AddInt32(&c.runningCommands, -1)
+		c.replyCommand(MessageTypeCommandResult, &cmd, false, nil, &CommandError{
+			Code: "RATE_LIMITED", Message: "too many commands in progress"})
+		return
+	}
+
+	ctx := &CommandContext{UserID: c.userID, ClientIP: c.clientIP, UserAgent: c.userAgent}
+	if err := handler.Authorize(ctx, &cmd); err != nil {
+		atomic.AddInt32(&c.runningCommands, -1)
+		logger.Warn("WebSocket command rejected",
+			zap.String("user_id", c.userID),
+			zap.String("command_id", cmd.ID),
+			zap.String("action", cmd.Action),
+			zap.Error(err))
+		c.replyCommand(MessageTypeCommandResult, &cmd, false, nil, newCommandError(err))
+		return
+	}
+	c.replyCommand(MessageTypeCommandAck, &cmd, false, nil, nil)
+
+	go func() {
+		defer atomic.AddInt32(&c.runningCommands, -1)
+		result, err := handler.Execute(ctx, &cmd, func(progress interface{}) {
+			c.sendCommandReply(MessageTypeCommandProgress, &CommandReply{
+				ID: cmd.ID, Action: cmd.Action, Progress: progress, Timestamp: time.Now()})
+		})
+		if err != nil {
+			c.replyCommand(MessageTypeCommandResult, &cmd, false, result, newCommandError(err))
+			return
+		}
+		c.replyCommand(MessageTypeCommandResult, &cmd, true, result, nil)
+	}()
+}
+
+// replyCommand 发送 ack 或 result
+func (c *Client) replyCommand(messageType string, cmd *Command, success bool, result interface{}, cmdErr *CommandError) {
+	reply := &CommandReply{ID: cmd.ID, Action: cmd.Action, Result: result, Error: cmdErr, Timestamp: time.Now()}
+	if messageType == MessageTypeCommandResult {
+		reply.Success = &success
+	}
+	c.sendCommandReply(messageType, reply)
+}
+
+func (c *Client) sendCommandReply(messageType string, reply *CommandReply) {
+	data, err := json.Marshal(Message{Type: messageType, Data: reply})
+	if err != nil {
+		logger.Error("Error marshaling command reply",
+			zap.String("command_id", reply.ID),
+			zap.Error(err))
+		return
+	}
+	// 连接可能已断开，通过 hub 检查后再发送
+	c.hub.sendToClient(c, data)
+}
@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	"superview/internal/supervisor"
+)
+
+type fakeCommandHandler struct {
+	release chan struct{}
+}
+
+func (h *fakeCommandHandler) Authorize(ctx *CommandContext, cmd *Command) error {
+	if cmd.Action == "forbidden" {
+		return errors.New("not allowed")
+	}
+	return nil
+}
+
+func (h *fakeCommandHandler) Execute(ctx *CommandContext, cmd *Command, progress func(data interface{})) (interface{}, error) {
+	<-h.release
+	progress("half way")
+	if cmd.Action == "fail" {
+		return nil, errors.New("boom")
+	}
+	return map[string]string{"node": cmd.NodeName}, nil
+}
+
+type commandReplyMessage struct {
+	Type string       `json:"type"`
+	Data CommandReply `json:"data"`
+}
+
+func TestHandleCommand(t *testing.T) {
+	hub := NewHub(&supervisor.SupervisorService{})
+	defer hub.cancel()
+	client := &Client{hub: hub, send: make(chan []byte, 64), limiter: rate.NewLimiter(100, 100), userID: "u1"}
+	hub.clients[client] = true
+
+	next := func() commandReplyMessage {
+		select {
+		case data := <-client.send:
+			var message commandReplyMessage
+			require.NoError(t, json.Unmarshal(data, &message))
+			return message
+		case <-time.After(2 * time.Second):
+			t.Fatal("no reply")
+			return commandReplyMessage{}
+		}
+	}
+	send := func(id, action string) {
+		client.handleCommand(map[string]interface{}{"id": id, "action": action, "node_name": "web1"})
+	}
+
+	send("c0", "start_process")
+	reply := next()
+	assert.Equal(t, MessageTypeCommandResult, reply.Type)
+	assert.Equal(t, "NOT_SUPPORTED", reply.Data.Error.Code)
+
+	handler := &fakeCommandHandler{release: make(chan struct{})}
+	hub.SetCommandHandler(handler)
+
+	client.handleCommand(map[string]interface{}{"action": "start_process"})
+	reply = next()
+	assert.Equal(t, "VALIDATION_ERROR", reply.Data.Error.Code, "id is required")
+
+	send("c1", "forbidden")
+	reply = next()
+	assert.Equal(t, MessageTypeCommandResult, reply.Type)
+	assert.Equal(t, "c1", reply.Data.ID)
+	assert.False(t, *reply.Data.Success)
+
+	for i := 0; i < MaxConcurrentCommands; i++ {
+		send(string(rune('a'+i)), "start_process")
+		assert.Equal(t, MessageTypeCommandAck, next().Type)
+	}
+	send("over", "start_process")
+	reply = next()
+	assert.Equal(t, "over", reply.Data.ID)
+	assert.Equal(t, "RATE_LIMITED", reply.Data.Error.Code)
+
+	for i := 0; i < MaxConcurrentCommands; i++ {
+		handler.release <- struct{}{}
+		assert.Equal(t, MessageTypeCommandProgress, next().Type)
+		reply = next()
+		assert.Equal(t, MessageTypeCommandResult, reply.Type)
+		assert.True(t, *reply.Data.Success)
+	}
+
+	send("c2", "fail")
+	assert.Equal(t, MessageTypeCommandAck, next().Type)
+	handler.release <- struct{}{}
+	assert.Equal(t, "half way", next().Data.Progress)
+	reply = next()
+	assert.Equal(t, "c2", reply.Data.ID)
+	assert.False(t, *reply.Data.Success)
+	assert.Equal(t, "INTERNAL_ERROR", reply.Data.Error.Code)
+}
@@ -0,0 +1,327 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"superview/internal/logger"
+	"go.uber.org/zap"
+)
+
+// 增量推送的消息类型
+const (
+	MessageTypeNodesSnapshot = "nodes_snapshot"
+	MessageTypeNodesDelta    = "nodes_delta"
+)
+
+// DefaultDeltaBufferSize 默认保留的增量条数
+const DefaultDeltaBufferSize = 256
+
+// 每个周期都会变化的字段，不参与比较
+var (
+	volatileNodeFields    = map[string]bool{"last_ping": true}
+	volatileProcessFields = map[string]bool{"uptime": true, "uptime_human": true, "now": true}
+)
+
+// NodesDelta 两次推送之间的节点变化
+type NodesDelta struct {
+	Added     []map[string]interface{} `json:"added,omitempty"`   // 新节点，含 processes
+	Removed   []string                 `json:"removed,omitempty"` // 删除的节点名
+	Changed   []NodeDelta              `json:"changed,omitempty"`
+	Timestamp time.Time                `json:"timestamp"`
+}
+
+// NodeDelta 单个节点的变化，进程以 group:name 标识
+type NodeDelta struct {
+	Name             string                   `json:"name"`
+	Fields           map[string]interface{}   `json:"fields,omitempty"` // 变化的节点字段
+	AddedProcesses   []map[string]interface{} `json:"added_processes,omitempty"`
+	RemovedProcesses []string                 `json:"removed_processes,omitempty"`
+	ChangedProcesses []map[string]interface{} `json:"changed_processes,omitempty"`
+}
+
+// Empty 没有任何变化
+func (d *NodesDelta) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// nodeState 上次推送时的节点状态，值为去掉易变字段后的 JSON
+type nodeState struct {
+	fields    map[string]string
+	processes map[string]string
+}
+
+// processKey 进程在节点内的唯一标识
+func processKey(process map[string]interface{}) string {
+	return fmt.Sprintf("%v:%v", process["group"], process["name"])
+}
+
+// fingerprint 序列化用于比较的值
+func fingerprint(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		logger.Debug("Failed to fingerprint value", zap.Error(err))
+		return ""
+	}
+	return string(data)
+}
+
+// stableProcess 去掉易变字段后的进程指纹
+func stableProcess(process map[string]interface{}) string {
+	stable := make(map[string]interface{}, len(process))
+	for key, value := range process {
+		if !volatileProcessFields[key] {
+			stable[key] = value
+		}
+	}
+	return fingerprint(stable)
+}
+
+// newNodeState 从带 processes 的节点数据生成比较状态
+func newNodeState(node map[string]interface{}) *nodeState {
+	state := &nodeState{fields: make(map[string]string), processes: make(map[string]string)}
+	for key, value := range node {
+		if key == "processes" || volatileNodeFields[key] {
+			continue
+		}
+		state.fields[key] = fingerprint(value)
+	}
+	for _, process := range nodeProcesses(node) {
+		state.processes[processKey(process)] = stableProcess(process)
+	}
+	return state
+}
+
+func nodeProcesses(node map[string]interface{}) []map[string]interface{} {
+	processes, _ := node["processes"].([]map[string]interface{})
+	return processes
+}
+
+// diffNodes 比较上次状态和当前节点数据，返回增量和新状态
+func diffNodes(prev map[string]*nodeState, nodes []map[string]interface{}) (*NodesDelta, map[string]*nodeState) {
+	delta := &NodesDelta{Timestamp: time.Now()}
+	next := make(map[string]*nodeState, len(nodes))
+
+	for _, node := range nodes {
+		name, _ := node["name"].(string)
+		state := newNodeState(node)
+		next[name] = state
+
+		old, ok := prev[name]
+		if !ok {
+			delta.Added = append(delta.Added, node)
+			continue
+		}
+
+		change := NodeDelta{Name: name}
+		for key, value := range state.fields {
+			if old.fields[key] != value {
+				if change.Fields == nil {
+					change.Fields = make(map[string]interface{})
+				}
+				change.Fields[key] = node[key]
+			}
+		}
+		for _, process := range nodeProcesses(node) {
+			key := processKey(process)
+			previous, existed := old.processes[key]
+			switch {
+			case !existed:
+				change.AddedProcesses = append(change.AddedProcesses, process)
+			case previous != state.processes[key]:
+				change.ChangedProcesses = append(change.ChangedProcesses, process)
+			}
+		}
+		for key := range old.processes {
+			if _, ok := state.processes[key]; !ok {
+				change.RemovedProcesses = append(change.RemovedProcesses, key)
+			}
+		}
+		sort.Strings(change.RemovedProcesses)
+
+		if change.Fields != nil || change.AddedProcesses != nil || change.ChangedProcesses != nil || change.RemovedProcesses != nil {
+			delta.Changed = append(delta.Changed, change)
+		}
+	}
+
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			delta.Removed = append(delta.Removed, name)
+		}
+	}
+	sort.Strings(delta.Removed)
+	return delta, next
+}
+
+// deltaEntry 已编码的增量消息
+type deltaEntry struct {
+	seq  uint64
+	data []byte
+}
+
+// deltaRing 固定容量的增量环形缓冲区，写满后覆盖最旧的条目
+type deltaRing struct {
+	entries []deltaEntry
+	next    int
+	size    int
+}
+
+func newDeltaRing(capacity int) *deltaRing {
+	if capacity <= 0 {
+		capacity = DefaultDeltaBufferSize
+	}
+	return &deltaRing{entries: make([]deltaEntry, capacity)}
+}
+
+// add 追加一条增量，seq 必须连续递增
+func (r *deltaRing) add(seq uint64, data []byte) {
+	r.entries[r.next] = deltaEntry{seq: seq, data: data}
+	r.next = (r.next + 1) % len(r.entries)
+	if r.size < len(r.entries) {
+		r.size++
+	}
+}
+
+// since 返回 lastSeq 之后到 current 的全部增量；缺失时返回 false
+func (r *deltaRing) since(lastSeq, current uint64) ([][]byte, bool) {
+	if lastSeq > current {
+		return nil, false
+	}
+	missing := current - lastSeq
+	if missing == 0 {
+		return nil, true
+	}
+	if missing > uint64(r.size) {
+		return nil, false
+	}
+
+	result := make([][]byte, 0, missing)
+	start := (r.next - int(missing) + len(r.entries)) % len(r.entries)
+	for i := 0; i < int(missing); i++ {
+		entry := r.entries[(start+i)%len(r.entries)]
+		if entry.seq != lastSeq+uint64(i)+1 {
+			return nil, false
+		}
+		result = append(result, entry.data)
+	}
+	return result, true
+}
+
+// collectNodes 当前所有节点数据，含进程列表
+func (h *Hub) collectNodes() []map[string]interface{} {
+	nodes := h.service.GetAllNodes()
+	nodesData := make([]map[string]interface{}, len(nodes))
+	for i, node := range nodes {
+		data := node.Serialize()
+		data["processes"] = node.SerializeProcesses()
+		nodesData[i] = data
+	}
+	return nodesData
+}
+
+// publishNodesDelta 计算节点变化并推送给增量模式的客户端
+func (h *Hub) publishNodesDelta() {
+	h.deltaMu.Lock()
+	defer h.deltaMu.Unlock()
+	h.advanceDeltaLocked()
+}
+
+// advanceDeltaLocked 与上次状态比较，有变化时分配序号、写入缓冲区并推送，返回当前节点数据。
+// 调用方需持有 deltaMu
+func (h *Hub) advanceDeltaLocked() []map[string]interface{} {
+	nodes := h.collectNodes()
+	delta, next := diffNodes(h.deltaState, nodes)
+	environments := nodeEnvironments(h.deltaState, nodes)
+	h.deltaState = next
+	if delta.Empty() {
+		return nodes
+	}
+
+	h.deltaSeq++
+	data, err := json.Marshal(Message{Type: MessageTypeNodesDelta, Seq: h.deltaSeq, Epoch: h.epoch, Data: delta})
+	if err != nil {
+		// 序号已占用但不在缓冲区中，续传时会退回完整快照
+		logger.Error("Error marshaling nodes delta", zap.Error(err))
+		return nodes
+	}
+	h.deltaRing.add(h.deltaSeq, data)
+	h.publish(MessageTypeNodesDelta, data, func(client *Client) bool { return client.delta && client.deltaReady })
+
+	// 受限客户端收到同一序号的过滤后增量，保持序号连续
+	for _, client := range h.restrictedClients(func(client *Client) bool {
+		return client.delta && client.deltaReady && client.accepts(MessageTypeNodesDelta)
+	}) {
+		data, err := json.Marshal(Message{Type: MessageTypeNodesDelta, Seq: h.deltaSeq, Epoch: h.epoch, Data: client.visibleDelta(delta, environments)})
+		if err != nil {
+			logger.Error("Error marshaling nodes delta", zap.Error(err))
+			break
+		}
+		h.sendToClient(client, data)
+	}
+	return nodes
+}
+
+// nodeEnvironments 当前和上次推送的节点所属环境，用于过滤已删除节点
+func nodeEnvironments(prev map[string]*nodeState, nodes []map[string]interface{}) map[string]string {
+	environments := make(map[string]string, len(nodes))
+	for name, state := range prev {
+		var environment string
+		if json.Unmarshal([]byte(state.fields["environment"]), &environment) == nil {
+			environments[name] = environment
+		}
+	}
+	for _, node := range nodes {
+		name, _ := node["name"].(string)
+		environment, _ := node["environment"].(string)
+		environments[name] = environment
+	}
+	return environments
+}
+
+// syncDeltaClient 让增量模式的客户端追上当前序号：缓冲区中还有缺失的增量时按序重放，否则发送完整快照
+func (h *Hub) syncDeltaClient(client *Client, epoch string, lastSeq uint64) {
+	h.deltaMu.Lock()
+	defer h.deltaMu.Unlock()
+
+	client.deltaReady = false
+	nodes := h.advanceDeltaLocked()
+
+	// 缓冲区中是未过滤的增量，受限客户端总是重新发送快照
+	if epoch == h.epoch && lastSeq > 0 && !client.restricted() {
+		// 积压超过发送队列一半时直接发快照
+		if missed, ok := h.deltaRing.since(lastSeq, h.deltaSeq); ok && len(missed) <= cap(client.send)/2 {
+			for _, data := range missed {
+				if !h.sendToClient(client, data) {
+					return
+				}
+			}
+			client.deltaReady = true
+			logger.Debug("WebSocket client resumed node deltas",
+				zap.String("user_id", client.userID),
+				zap.Uint64("last_seq", lastSeq),
+				zap.Int("replayed", len(missed)))
+			return
+		}
+	}
+
+	data, err := json.Marshal(Message{Type: MessageTypeNodesSnapshot, Seq: h.deltaSeq, Epoch: h.epoch, Data: client.visibleNodes(nodes)})
+	if err != nil {
+		logger.Error("Error marshaling nodes snapshot", zap.Error(err))
+		return
+	}
+	client.deltaReady = h.sendToClient(client, data)
+}
+
+// hasLegacyClients 是否有未使用增量模式的客户端
+func (h *Hub) hasLegacyClients() bool {
+	h.clientsMu.RLock()
+	defer h.clientsMu.RUnlock()
+	for client := range h.clients {
+		if !client.delta {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"superview/internal/supervisor"
+)
+
+func testNode(name string, connected bool, processes ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"name":         name,
+		"is_connected": connected,
+		"last_ping":    fmt.Sprint(len(processes)),
+		"processes":    processes,
+	}
+}
+
+func testProcess(name string, state int, uptime float64) map[string]interface{} {
+	return map[string]interface{}{"name": name, "group": name, "state": state, "uptime": uptime}
+}
+
+func TestDiffNodes(t *testing.T) {
+	delta, state := diffNodes(nil, []map[string]interface{}{
+		testNode("a", true, testProcess("web", 20, 10), testProcess("worker", 20, 10)),
+		testNode("b", true),
+	})
+	assert.Len(t, delta.Added, 2, "everything is new on the first diff")
+
+	// Only volatile fields changed
+	delta, state = diffNodes(state, []map[string]interface{}{
+		testNode("a", true, testProcess("web", 20, 15), testProcess("worker", 20, 15)),
+		testNode("b", true),
+	})
+	assert.True(t, delta.Empty())
+
+	delta, _ = diffNodes(state, []map[string]interface{}{
+		testNode("a", true, testProcess("web", 0, 0), testProcess("cron", 20, 1)),
+		testNode("c", false),
+	})
+	require.Len(t, delta.Added, 1)
+	assert.Equal(t, "c", delta.Added[0]["name"])
+	assert.Equal(t, []string{"b"}, delta.Removed)
+	require.Len(t, delta.Changed, 1)
+	change := delta.Changed[0]
+	assert.Equal(t, "a", change.Name)
+	assert.Nil(t, change.Fields)
+	require.Len(t, change.ChangedProcesses, 1)
+	assert.Equal(t, "web", change.ChangedProcesses[0]["name"])
+	require.Len(t, change.AddedProcesses, 1)
+	assert.Equal(t, "cron", change.AddedProcesses[0]["name"])
+	assert.Equal(t, []string{"worker:worker"}, change.RemovedProcesses)
+}
+
+func TestDeltaRing(t *testing.T) {
+	ring := newDeltaRing(3)
+	for seq := uint64(1); seq <= 5; seq++ {
+		ring.add(seq, []byte(fmt.Sprint(seq)))
+	}
+
+	missed, ok := ring.since(3, 5)
+	require.True(t, ok)
+	assert.Equal(t, [][]byte{[]byte("4"), []byte("5")}, missed)
+
+	missed, ok = ring.since(5, 5)
+	assert.True(t, ok)
+	assert.Empty(t, missed)
+
+	_, ok = ring.since(1, 5)
+	assert.False(t, ok, "seq 2 was evicted")
+	_, ok = ring.since(7, 5)
+	assert.False(t, ok, "client is ahead, e.g. after a server restart")
+}
+
+func TestSyncDeltaClient(t *testing.T) {
+	hub := NewHub(&supervisor.SupervisorService{})
+	defer hub.cancel() // Run is not started, Close would wait for its goroutines
+
+	for seq := uint64(1); seq <= 3; seq++ {
+		data, _ := json.Marshal(Message{Type: MessageTypeNodesDelta, Seq: seq, Epoch: hub.epoch})
+		hub.deltaRing.add(seq, data)
+	}
+	hub.deltaSeq = 3
+
+	receive := func(epoch string, lastSeq uint64) []Message {
+		client := &Client{hub: hub, send: make(chan []byte, 16), delta: true}
+		hub.clients[client] = true
+		defer delete(hub.clients, client)
+
+		hub.syncDeltaClient(client, epoch, lastSeq)
+		assert.True(t, client.deltaReady)
+		var messages []Message
+		for len(client.send) > 0 {
+			var message Message
+			require.NoError(t, json.Unmarshal(<-client.send, &message))
+			messages = append(messages, message)
+		}
+		return messages
+	}
+
+	messages := receive(hub.epoch, 1)
+	require.Len(t, messages, 2, "missed deltas are replayed")
+	assert.Equal(t, uint64(2), messages[0].Seq)
+	assert.Equal(t, uint64(3), messages[1].Seq)
+
+	for _, messages := range [][]Message{receive("", 0), receive("other", 2)} {
+		require.Len(t, messages, 1)
+		assert.Equal(t, MessageTypeNodesSnapshot, messages[0].Type)
+		assert.Equal(t, uint64(3), messages[0].Seq)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -31,6 +32,8 @@ type WebSocketConfig struct {
 	AllowedOrigins    []string      // 允许的来源
 	MaxMessageSize    int64         // 最大消息大小
 	MaxViolations     int           // 最大违规次数
+	DeltaBufferSize   int           // 保留的节点增量条数，用于断线重连补齐
+	EventBufferSize   int           // 保留的事件条数，用于 SSE 按 Last-Event-ID 续传
 }
 
 // globalAllowedOrigins 全局配置的允许来源（从 config.toml 加载）
@@ -73,6 +76,8 @@ func GetDefaultWebSocketConfig() *WebSocketConfig {
 		AllowedOrigins:    allowedOrigins,
 		MaxMessageSize:    1024,             // 1KB最大消息大小
 		MaxViolations:     5,                // 最大5次违规
+		DeltaBufferSize:   DefaultDeltaBufferSize,
+		EventBufferSize:   DefaultEventBufferSize,
 	}
 }
 
@@ -134,6 +139,35 @@ type Hub struct {
 	// Log streaming offsets - shared across goroutines
 	logOffsets    map[string]int
 	logOffsetsMu  sync.RWMutex
+	
+	// 多实例部署时将广播转发给其他实例
+	relay         BroadcastRelay
+	relayMu       sync.RWMutex
+	
+	// 节点增量推送：deltaMu 保护以下字段，并保证增量按序号顺序发送
+	deltaMu       sync.Mutex
+	deltaSeq      uint64
+	deltaState    map[string]*nodeState
+	deltaRing     *deltaRing
+	epoch         string // 实例启动标识，重启后序号从头开始
+	
+	// 客户端命令处理
+	commandHandler CommandHandler
+	commandMu      sync.RWMutex
+	
+	// 事件推送：eventMu 保证事件 ID 与推送顺序一致，events 供 SSE 续传
+	eventMu       sync.Mutex
+	eventID       uint64
+	events        *eventHistory
+	
+	// 主题订阅的权限校验
+	topicAuthorizer TopicAuthorizer
+	topicMu         sync.RWMutex
+}
+
+// BroadcastRelay 实例间广播转发
+type BroadcastRelay interface {
+	Publish(data []byte)
 }
 
 type Client struct {
@@ -147,11 +181,36 @@ type Client struct {
 	mu         sync.RWMutex
 	violationCount int          // 违规计数
 	closed     bool            // 连接是否已关闭
+	
+	// 增量模式：连接时带 ?delta=1，先收到快照再收到 nodes_delta
+	delta       bool
+	deltaReady  bool   // 已同步到当前序号，受 hub.deltaMu 保护
+	resumeEpoch string // 重连时客户端上报的 epoch 和 last_seq
+	resumeSeq   uint64
+	
+	// 命令执行时记录的连接信息和进行中的命令数
+	clientIP        string
+	userAgent       string
+	runningCommands int32 // atomic
+	
+	// SSE 连接：conn 为空，通过 cancel 断开；types 为消息类型过滤（支持 * 后缀），为空时接收全部
+	sse              bool
+	cancel           context.CancelFunc
+	types            []string
+	eventsPending    bool   // 等待补发 Last-Event-ID 之后的事件，受 hub.eventMu 保护
+	resumeEventEpoch string
+	resumeEventID    uint64
+
+	// 通过 API 令牌连接时的访问范围，nil 表示不限制
+	scope ClientScope
 }
 
 type Message struct {
-	Type string      `json:"type"`
-	Data interface{} `json:"data"`
+	Type   string      `json:"type"`
+	Topics []string    `json:"topics,omitempty"` // 事件所属主题，为空时推送给所有客户端
+	Seq    uint64      `json:"seq,omitempty"`    // 节点快照和增量的序号
+	Epoch  string      `json:"epoch,omitempty"`  // 与 Seq 配合判断能否续传
+	Data   interface{} `json:"data"`
 }
 
 type NodeUpdateMessage struct {
@@ -205,8 +264,15 @@ func NewHub(service *supervisor.SupervisorService) *Hub {
 		logOffsets:      make(map[string]int),
 	}
 	
+	hub.deltaRing = newDeltaRing(hub.config.DeltaBufferSize)
+	hub.events = newEventHistory(hub.config.EventBufferSize)
+	hub.epoch = strconv.FormatInt(time.Now().UnixNano(), 36)
+	
 	// Pre-add WaitGroup count for background goroutines
 	hub.wg.Add(3) // heartbeat, cleanup, log streaming
+	if service != nil {
+		service.OnNodeRemoved(hub.forgetNode)
+	}
 	return hub
 }
 
@@ -229,8 +295,15 @@ func NewHubWithConfig(service *supervisor.SupervisorService, config *WebSocketCo
 		logOffsets:      make(map[string]int),
 	}
 	
+	hub.deltaRing = newDeltaRing(hub.config.DeltaBufferSize)
+	hub.events = newEventHistory(hub.config.EventBufferSize)
+	hub.epoch = strconv.FormatInt(time.Now().UnixNano(), 36)
+	
 	// Pre-add WaitGroup count for background goroutines
 	hub.wg.Add(3) // heartbeat, cleanup, log streaming
+	if service != nil {
+		service.OnNodeRemoved(hub.forgetNode)
+	}
 	return hub
 }
 
@@ -312,7 +385,7 @@ func (h *Hub) Run() {
 					zap.String("userID", client.userID),
 					zap.Int64("current_connections", atomic.LoadInt64(&h.connectionCount)),
 					zap.Int("max_connections", h.config.MaxConnections))
-				client.conn.Close()
+				client.closeConn()
 				continue
 			}
 
@@ -320,6 +393,7 @@ func (h *Hub) Run() {
 			h.clients[client] = true
 			h.clientsMu.Unlock()
 			atomic.AddInt64(&h.connectionCount, 1)
+			connectionsGauge.Inc(client.transport())
 			logger.Info("WebSocket client connected",
 				zap.String("user_id", client.userID),
 				zap.Int64("total_connections", atomic.LoadInt64(&h.connectionCount)))
@@ -333,6 +407,7 @@ func (h *Hub) Run() {
 				delete(h.clients, client)
 				close(client.send)
 				atomic.AddInt64(&h.connectionCount, -1)
+				connectionsGauge.Dec(client.transport())
 			}
 			h.clientsMu.Unlock()
 			logger.Info("WebSocket client disconnected",
@@ -340,32 +415,12 @@ func (h *Hub) Run() {
 				zap.Int64("total_connections", atomic.LoadInt64(&h.connectionCount)))
 
 		case message := <-h.broadcast:
-			// Use collect-then-modify pattern to avoid race conditions
-			h.clientsMu.RLock()
-			clientsToRemove := make([]*Client, 0)
-			
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-					// 发送成功
-				default:
-					// 客户端阻塞，收集待移除的客户端
-					clientsToRemove = append(clientsToRemove, client)
-				}
-			}
-			h.clientsMu.RUnlock()
-			
-			// 安全地移除阻塞的客户端
-			for _, client := range clientsToRemove {
-				select {
-				case h.cleanup <- client:
-				default:
-					// Cleanup channel full, force close
-					logger.Warn("Cleanup channel full, force closing client",
-						zap.String("user_id", client.userID))
-					client.conn.Close()
-				}
+			messageType, topics := messageEnvelope(message)
+			var match func(*Client) bool
+			if len(topics) > 0 {
+				match = func(client *Client) bool { return client.subscribedTo(topics) }
 			}
+			h.publish(messageType, message, match)
 		}
 	}
 }
@@ -384,6 +439,7 @@ func (h *Hub) startCleanupWorker() {
 				close(client.send)
 				delete(h.clients, client)
 				atomic.AddInt64(&h.connectionCount, -1)
+				connectionsGauge.Dec(client.transport())
 				logger.Debug("Client cleaned up",
 					zap.String("user_id", client.userID))
 			}
@@ -513,6 +569,35 @@ func (h *Hub) getSubscribedLogStreams() map[string]bool {
 	return subscribedLogs
 }
 
+// forgetNode 节点被删除：清除日志流偏移量和客户端的日志订阅，并推送最新节点列表
+func (h *Hub) forgetNode(nodeName string) {
+	logPrefix := nodeName + ":"
+
+	h.logOffsetsMu.Lock()
+	for logKey := range h.logOffsets {
+		if strings.HasPrefix(logKey, logPrefix) {
+			delete(h.logOffsets, logKey)
+		}
+	}
+	h.logOffsetsMu.Unlock()
+
+	subscriptionPrefix := "logs:" + logPrefix
+	nodeTopic := TopicNode + ":" + nodeName
+	h.clientsMu.RLock()
+	for client := range h.clients {
+		client.subscribed.Delete(nodeTopic)
+		client.subscribed.Range(func(key, value interface{}) bool {
+			if keyStr, ok := key.(string); ok && strings.HasPrefix(keyStr, subscriptionPrefix) {
+				client.subscribed.Delete(key)
+			}
+			return true
+		})
+	}
+	h.clientsMu.RUnlock()
+
+	h.broadcastNodesUpdate()
+}
+
 // SendLogStreamToSubscribedClients sends log stream messages to clients subscribed to specific process logs
 func (h *Hub) SendLogStreamToSubscribedClients(nodeName, processName string, logStream *supervisor.LogStream) {
 	logKey := fmt.Sprintf("%s:%s", nodeName, processName)
@@ -538,43 +623,14 @@ func (h *Hub) SendLogStreamToSubscribedClients(nodeName, processName string, log
 		return
 	}
 
-	// Use collect-then-modify pattern to avoid race conditions
-	h.clientsMu.RLock()
-	clientsToRemove := make([]*Client, 0)
-	sentCount := 0
-	
-	for client := range h.clients {
-		if _, subscribed := client.subscribed.Load(subscriptionKey); subscribed {
-			select {
-			case client.send <- data:
-				sentCount++
-			default:
-				// Client's send channel is full, collect for removal
-				clientsToRemove = append(clientsToRemove, client)
-			}
-		}
-	}
-	h.clientsMu.RUnlock()
-	
-	if sentCount > 0 {
-		logger.Debug("Sent log stream to clients",
-			zap.String("node", nodeName),
-			zap.String("process", processName),
-			zap.Int("entries", len(logStream.Entries)),
-			zap.Int("clients", sentCount))
-	}
-	
-	// Remove clients with full channels via cleanup worker
-	for _, client := range clientsToRemove {
-		select {
-		case h.cleanup <- client:
-		default:
-			// Cleanup channel full, force close
-			logger.Warn("Cleanup channel full, force closing client",
-				zap.String("user_id", client.userID))
-			client.conn.Close()
-		}
-	}
+	h.publish(message.Type, data, func(client *Client) bool {
+		_, subscribed := client.subscribed.Load(subscriptionKey)
+		return subscribed
+	})
+	logger.Debug("Sent log stream to subscribed clients",
+		zap.String("node", nodeName),
+		zap.String("process", processName),
+		zap.Int("entries", len(logStream.Entries)))
 }
 
 func (h *Hub) startHeartbeatChecker() {
@@ -625,7 +681,7 @@ func (h *Hub) checkHeartbeats() {
 			case h.unregister <- client:
 			default:
 				// 如果channel满了，直接关闭连接
-				client.conn.Close()
+				client.closeConn()
 			}
 		}
 		client.mu.Unlock()
@@ -662,6 +718,16 @@ func (h *Hub) sendInitialData(client *Client) {
 			zap.String("user_id", client.userID))
 		return
 	}
+	// SSE 客户端重连时在初始数据之后补发错过的事件
+	defer h.replayEvents(client)
+	
+	if client.delta {
+		h.syncDeltaClient(client, client.resumeEpoch, client.resumeSeq)
+		return
+	}
+	if !client.accepts("nodes_update") {
+		return
+	}
 
 	// Send current nodes data
 	nodes := h.service.GetAllNodes()
@@ -672,7 +738,7 @@ func (h *Hub) sendInitialData(client *Client) {
 
 	message := Message{
 		Type: "nodes_update",
-		Data: nodesData,
+		Data: client.visibleNodes(nodesData),
 	}
 
 	data, err := json.Marshal(message)
@@ -685,7 +751,7 @@ func (h *Hub) sendInitialData(client *Client) {
 
 	// Try to send with timeout, but don't panic if channel is closed
 	select {
-	case client.send <- data:
+	case client.send <- client.frame(0, data):
 		// Successfully sent
 	case <-time.After(1 * time.Second):
 		// Timeout - client may have disconnected
@@ -694,7 +760,13 @@ func (h *Hub) sendInitialData(client *Client) {
 	}
 }
 
+// broadcastNodesUpdate 推送节点状态：增量模式的客户端收到 nodes_delta，其余客户端收到完整的 nodes_update
 func (h *Hub) broadcastNodesUpdate() {
+	h.publishNodesDelta()
+	if !h.hasLegacyClients() {
+		return
+	}
+	
 	nodes := h.service.GetAllNodes()
 	nodesData := make([]map[string]interface{}, len(nodes))
 	for i, node := range nodes {
@@ -712,10 +784,16 @@ func (h *Hub) broadcastNodesUpdate() {
 		return
 	}
 
-	select {
-	case h.broadcast <- data:
-	default:
-		logger.Warn("Broadcast channel full, dropping nodes update")
+	h.publish("nodes_update", data, func(client *Client) bool { return !client.delta })
+
+	// 受限客户端只收到范围内的节点
+	for _, client := range h.restrictedClients(func(client *Client) bool { return !client.delta && client.accepts("nodes_update") }) {
+		data, err := json.Marshal(Message{Type: "nodes_update", Data: client.visibleNodes(nodesData)})
+		if err != nil {
+			logger.Error("Error marshaling nodes update", zap.Error(err))
+			return
+		}
+		h.sendToClient(client, data)
 	}
 }
 
@@ -762,6 +840,7 @@ func (h *Hub) broadcastSystemStats() {
 	case h.broadcast <- data:
 	default:
 		logger.Warn("Broadcast channel full, dropping system stats")
+		droppedMessages.Inc(dropBroadcastFull)
 	}
 }
 
@@ -786,6 +865,7 @@ func (h *Hub) BroadcastProcessStatusChange(nodeName, processName, status string)
 	case h.broadcast <- data:
 	default:
 		logger.Warn("Broadcast channel full, dropping process status change")
+		droppedMessages.Inc(dropBroadcastFull)
 	}
 }
 
@@ -814,7 +894,7 @@ func (c *Client) handleViolation(reason string) {
 		
 		// 异步关闭连接以避免阻塞
 		go func() {
-			c.conn.Close()
+			c.closeConn()
 		}()
 	}
 }
@@ -841,6 +921,9 @@ func (h *Hub) HandleWebSocket(c *gin.Context) {
 	// 创建速率限制器
 	limiter := rate.NewLimiter(rate.Limit(h.config.RateLimit), h.config.RateBurst)
 
+	// 增量模式：?delta=1，重连时带上 epoch 和 last_seq 补齐缺失的增量
+	lastSeq, _ := strconv.ParseUint(c.Query("last_seq"), 10, 64)
+
 	client := &Client{
 		hub:            h,
 		conn:           conn,
@@ -850,7 +933,13 @@ func (h *Hub) HandleWebSocket(c *gin.Context) {
 		lastPong:       time.Now(),
 		violationCount: 0,
 		closed:         false,
+		delta:          c.Query("delta") == "1" || c.Query("delta") == "true",
+		resumeEpoch:    c.Query("epoch"),
+		resumeSeq:      lastSeq,
+		clientIP:       c.ClientIP(),
+		userAgent:      c.GetHeader("User-Agent"),
 	}
+	client.subscribeInitialTopics(c.Query("topics"))
 
 	// 设置pong处理器
 	conn.SetPongHandler(func(string) error {
@@ -868,11 +957,32 @@ func (h *Hub) HandleWebSocket(c *gin.Context) {
 	go client.readPump()
 }
 
+// SetRelay 设置实例间广播转发，Broadcast 的消息会同时发送给其他实例的客户端
+func (h *Hub) SetRelay(relay BroadcastRelay) {
+	h.relayMu.Lock()
+	defer h.relayMu.Unlock()
+	h.relay = relay
+}
+
 // Broadcast sends a message to all connected clients
+// 设置了 relay 时同时转发给其他实例；节点状态和系统统计等周期消息由各实例自行生成，不经过这里
 func (h *Hub) Broadcast(data []byte) {
+	h.BroadcastLocal(data)
+
+	h.relayMu.RLock()
+	relay := h.relay
+	h.relayMu.RUnlock()
+	if relay != nil {
+		relay.Publish(data)
+	}
+}
+
+// BroadcastLocal 只发送给连接到本实例的客户端（用于投递其他实例转发来的消息）
+func (h *Hub) BroadcastLocal(data []byte) {
 	select {
 	case h.broadcast <- data:
 	default:
 		logger.Warn("Broadcast channel full, message dropped")
+		droppedMessages.Inc(dropBroadcastFull)
 	}
 }
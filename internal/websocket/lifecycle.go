@@ -168,7 +168,7 @@ func (hl *HubLifecycle) closeAllConnections() {
 		if !client.closed {
 			// 尝试发送关闭消息
 			select {
-			case client.send <- shutdownMsg:
+			case client.send <- client.frame(0, shutdownMsg):
 				// 等待一小段时间让消息发送
 				time.Sleep(100 * time.Millisecond)
 			default:
@@ -177,7 +177,7 @@ func (hl *HubLifecycle) closeAllConnections() {
 			
 			// 关闭连接
 			client.closed = true
-			client.conn.Close()
+			client.closeConn()
 		}
 		client.mu.Unlock()
 	}
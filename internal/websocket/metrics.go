@@ -0,0 +1,29 @@
+package websocket
+
+import "superview/internal/metrics/instrument"
+
+// 推送连接指标，由 /metrics 端点输出
+var (
+	connectionsGauge = instrument.NewGaugeVec("superview_websocket_connections",
+		"Connected push clients", "transport")
+	droppedMessages = instrument.NewCounterVec("superview_websocket_dropped_messages_total",
+		"Messages dropped because the broadcast channel or a client send queue was full", "reason")
+)
+
+// 消息丢弃原因
+const (
+	dropBroadcastFull = "broadcast_full"
+	dropClientFull    = "client_queue_full"
+)
+
+func init() {
+	instrument.MustRegister(connectionsGauge, droppedMessages)
+}
+
+// transport 客户端的传输方式
+func (c *Client) transport() string {
+	if c.sse {
+		return "sse"
+	}
+	return "websocket"
+}
@@ -0,0 +1,147 @@
+package websocket
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ClientScopeContextKey gin 上下文中客户端访问范围的键，由 API 层在通过 API 令牌认证时设置
+const ClientScopeContextKey = "ws_client_scope"
+
+// ClientScope 通过 API 令牌连接的客户端的访问范围（*models.APIToken 实现）
+type ClientScope interface {
+	HasPermission(permission string) bool
+	HasNodeRestrictions() bool
+	AllowsNode(nodeName, environment string) bool
+}
+
+// 包含全部节点的消息，受限客户端改为逐个推送过滤后的内容；system_stats 是全部节点的汇总，不推送给受限客户端
+var scopedMessageTypes = map[string]bool{
+	"nodes_update":           true,
+	MessageTypeNodesSnapshot: true,
+	MessageTypeNodesDelta:    true,
+	"system_stats":           true,
+}
+
+// restricted 客户端是否只能看到部分节点
+func (c *Client) restricted() bool {
+	return c.scope != nil && c.scope.HasNodeRestrictions()
+}
+
+// allowsNode 客户端能否看到节点，environment 为空时从 supervisor 读取
+func (c *Client) allowsNode(nodeName, environment string) bool {
+	if !c.restricted() {
+		return true
+	}
+	if environment == "" {
+		environment = c.hub.nodeEnvironment(nodeName)
+	}
+	return c.scope.AllowsNode(nodeName, environment)
+}
+
+// allowsMessage 受限客户端能否收到消息：整体节点消息单独推送，其余消息涉及的节点都需在范围内
+func (c *Client) allowsMessage(messageType string, nodes []string) bool {
+	if !c.restricted() {
+		return true
+	}
+	if scopedMessageTypes[messageType] {
+		return false
+	}
+	for _, nodeName := range nodes {
+		if !c.allowsNode(nodeName, "") {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeEnvironment 节点所属环境，节点不存在时为空
+func (h *Hub) nodeEnvironment(nodeName string) string {
+	if h.service == nil {
+		return ""
+	}
+	node, err := h.service.GetNode(nodeName)
+	if err != nil {
+		return ""
+	}
+	return node.Environment
+}
+
+// messageNodes 已编码消息涉及的节点：node:<name> 主题，以及顶层或 data 中的 node_name
+func messageNodes(data []byte) []string {
+	var envelope struct {
+		Topics   []string        `json:"topics"`
+		NodeName string          `json:"node_name"`
+		Data     json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var nodes []string
+	add := func(nodeName string) {
+		if nodeName != "" && !seen[nodeName] {
+			seen[nodeName] = true
+			nodes = append(nodes, nodeName)
+		}
+	}
+	for _, topic := range envelope.Topics {
+		if name, rest, ok := strings.Cut(topic, ":"); ok && name == TopicNode {
+			add(rest)
+		}
+	}
+	add(envelope.NodeName)
+	var payload struct {
+		NodeName string `json:"node_name"`
+	}
+	if len(envelope.Data) > 0 && envelope.Data[0] == '{' && json.Unmarshal(envelope.Data, &payload) == nil {
+		add(payload.NodeName)
+	}
+	return nodes
+}
+
+// visibleNodes 过滤出客户端能看到的节点数据
+func (c *Client) visibleNodes(nodes []map[string]interface{}) []map[string]interface{} {
+	if !c.restricted() {
+		return nodes
+	}
+	visible := make([]map[string]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		name, _ := node["name"].(string)
+		environment, _ := node["environment"].(string)
+		if c.scope.AllowsNode(name, environment) {
+			visible = append(visible, node)
+		}
+	}
+	return visible
+}
+
+// visibleDelta 过滤出客户端能看到的节点变化，environments 为变化涉及节点（含已删除节点）的环境
+func (c *Client) visibleDelta(delta *NodesDelta, environments map[string]string) *NodesDelta {
+	visible := &NodesDelta{Added: c.visibleNodes(delta.Added), Timestamp: delta.Timestamp}
+	for _, name := range delta.Removed {
+		if c.scope.AllowsNode(name, environments[name]) {
+			visible.Removed = append(visible.Removed, name)
+		}
+	}
+	for _, change := range delta.Changed {
+		if c.scope.AllowsNode(change.Name, environments[change.Name]) {
+			visible.Changed = append(visible.Changed, change)
+		}
+	}
+	return visible
+}
+
+// restrictedClients 满足条件的受限客户端
+func (h *Hub) restrictedClients(match func(*Client) bool) []*Client {
+	h.clientsMu.RLock()
+	defer h.clientsMu.RUnlock()
+	var clients []*Client
+	for client := range h.clients {
+		if client.restricted() && match(client) {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"superview/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// sseRetry 浏览器 EventSource 断线后的重连间隔
+const sseRetry = 3 * time.Second
+
+// HandleSSE 以 Server-Sent Events 推送与 WebSocket 相同的消息，供无法升级 WebSocket 的网络环境使用。
+// 查询参数：topics 为订阅的主题（同 /ws），types 为逗号分隔的消息类型过滤（支持 * 后缀）；
+// delta=1 使用节点增量推送；重连时通过 Last-Event-ID 请求头（或 last_event_id 参数）补发错过的事件
+func (h *Hub) HandleSSE(c *gin.Context) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Streaming is not supported"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		userID = "anonymous"
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	lastSeq, _ := strconv.ParseUint(c.Query("last_seq"), 10, 64)
+	client := &Client{
+		hub:         h,
+		send:        make(chan []byte, 256),
+		userID:      userID,
+		lastPong:    time.Now(),
+		delta:       c.Query("delta") == "1" || c.Query("delta") == "true",
+		resumeEpoch: c.Query("epoch"),
+		resumeSeq:   lastSeq,
+		clientIP:    c.ClientIP(),
+		userAgent:   c.GetHeader("User-Agent"),
+		sse:         true,
+		cancel:      cancel,
+	}
+
+	if scope, ok := c.Get(ClientScopeContextKey); ok {
+		client.scope, _ = scope.(ClientScope)
+	}
+
+	for _, messageType := range strings.Split(c.Query("types"), ",") {
+		if messageType = strings.TrimSpace(messageType); messageType != "" {
+			client.types = append(client.types, messageType)
+		}
+	}
+	client.subscribeInitialTopics(c.Query("topics"))
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	if lastEventID != "" {
+		epoch, id, ok := parseEventID(lastEventID)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid Last-Event-ID"})
+			return
+		}
+		client.eventsPending = true
+		client.resumeEventEpoch = epoch
+		client.resumeEventID = id
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetry.Milliseconds())
+	flusher.Flush()
+
+	select {
+	case h.register <- client:
+	case <-ctx.Done():
+		return
+	case <-h.ctx.Done():
+		return
+	}
+	defer func() {
+		select {
+		case h.unregister <- client:
+		case <-h.ctx.Done():
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case data, ok := <-client.send:
+			if !ok {
+				// hub 已移除该客户端
+				return
+			}
+			if _, err := c.Writer.Write(data); err != nil {
+				logger.Debug("SSE write failed",
+					zap.String("user_id", client.userID),
+					zap.Error(err))
+				return
+			}
+			flusher.Flush()
+			// 写入成功即视为存活，心跳检测依赖周期性的 ping
+			client.mu.Lock()
+			client.lastPong = time.Now()
+			client.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"superview/internal/supervisor"
+)
+
+func drain(client *Client) []string {
+	var frames []string
+	for len(client.send) > 0 {
+		frames = append(frames, string(<-client.send))
+	}
+	return frames
+}
+
+func TestPublishFiltersAndReplay(t *testing.T) {
+	hub := NewHub(&supervisor.SupervisorService{})
+	defer hub.cancel()
+
+	ws := &Client{hub: hub, send: make(chan []byte, 16)}
+	sse := &Client{hub: hub, send: make(chan []byte, 16), sse: true, types: []string{"alert_*"}}
+	hub.clients[ws] = true
+	hub.clients[sse] = true
+
+	hub.publish("alert_created", []byte(`{"type":"alert_created"}`), nil)
+	hub.publish("system_stats", []byte(`{"type":"system_stats"}`), nil)
+	hub.publish("discovery_progress", []byte(`{"type":"discovery_progress"}`), nil)
+	hub.publish("alert_resolved", []byte(`{"type":"alert_resolved"}`), func(c *Client) bool { return c.sse })
+
+	assert.Equal(t, []string{`{"type":"alert_created"}`, `{"type":"system_stats"}`, `{"type":"discovery_progress"}`}, drain(ws))
+	assert.Equal(t, []string{
+		fmt.Sprintf("id: %s-1\ndata: {\"type\":\"alert_created\"}\n\n", hub.epoch),
+		fmt.Sprintf("id: %s-3\ndata: {\"type\":\"alert_resolved\"}\n\n", hub.epoch),
+	}, drain(sse), "system_stats is transient and gets no id")
+
+	resumed := &Client{hub: hub, send: make(chan []byte, 16), sse: true,
+		eventsPending: true, resumeEventEpoch: hub.epoch, resumeEventID: 1}
+	hub.clients[resumed] = true
+	hub.publish("alert_created", []byte(`{"type":"alert_created"}`), nil)
+	assert.Empty(t, drain(resumed), "live events wait for the replay")
+	hub.replayEvents(resumed)
+	frames := drain(resumed)
+	require.Len(t, frames, 3)
+	assert.Contains(t, frames[0], "-2\n")
+	assert.Contains(t, frames[2], "-4\n")
+
+	stale := &Client{hub: hub, send: make(chan []byte, 16), sse: true,
+		eventsPending: true, resumeEventEpoch: "previous", resumeEventID: 3}
+	hub.clients[stale] = true
+	hub.replayEvents(stale)
+	frames = drain(stale)
+	require.Len(t, frames, 1)
+	assert.Contains(t, frames[0], "events_reset")
+}
+
+func TestHandleSSE(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hub := NewHub(&supervisor.SupervisorService{})
+	go hub.Run()
+	defer hub.Close()
+
+	router := gin.New()
+	router.GET("/api/events", func(c *gin.Context) {
+		c.Set("user_id", "u1")
+		hub.HandleSSE(c)
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events?types=nodes_update,alert_*&topics=alerts")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan string, 16)
+	go func() {
+		reader := bufio.NewReader(resp.Body)
+		var event strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				close(events)
+				return
+			}
+			if line == "\n" {
+				events <- event.String()
+				event.Reset()
+				continue
+			}
+			event.WriteString(line)
+		}
+	}()
+	next := func() string {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(3 * time.Second):
+			t.Fatal("no event")
+			return ""
+		}
+	}
+
+	assert.Equal(t, "retry: 3000\n", next())
+	assert.Contains(t, next(), `"type":"nodes_update"`)
+
+	data, _ := json.Marshal(Message{Type: "alert_created", Topics: []string{TopicAlerts}, Data: map[string]string{"node": "web1"}})
+	hub.Broadcast([]byte(`{"type":"discovery_progress"}`))
+	hub.Broadcast([]byte(`{"type":"alert_created","topics":["node:web2"]}`))
+	hub.Broadcast(data)
+	event := next()
+	assert.True(t, strings.HasPrefix(event, "id: "+hub.epoch+"-"), event)
+	assert.Contains(t, event, "data: "+string(data))
+	assert.Equal(t, int64(1), hub.GetConnectionCount())
+}
+
+// fakeScope 只允许列出的节点
+type fakeScope map[string]bool
+
+func (s fakeScope) HasPermission(string) bool      { return true }
+func (s fakeScope) HasNodeRestrictions() bool      { return true }
+func (s fakeScope) AllowsNode(name, _ string) bool { return s[name] }
+
+func TestRestrictedClientScope(t *testing.T) {
+	hub := NewHub(&supervisor.SupervisorService{})
+	defer hub.cancel()
+
+	scoped := &Client{hub: hub, send: make(chan []byte, 16), sse: true, scope: fakeScope{"web1": true}}
+	hub.clients[scoped] = true
+
+	hub.publish("process_status_change", []byte(`{"type":"process_status_change","data":{"node_name":"web1"}}`), nil)
+	hub.publish("process_status_change", []byte(`{"type":"process_status_change","data":{"node_name":"web2"}}`), nil)
+	hub.publish("alert_created", []byte(`{"type":"alert_created","topics":["alerts","node:web2"]}`), nil)
+	hub.publish("system_stats", []byte(`{"type":"system_stats"}`), nil)
+	hub.publish("nodes_update", []byte(`{"type":"nodes_update","data":[]}`), nil)
+	frames := drain(scoped)
+	require.Len(t, frames, 1)
+	assert.Contains(t, frames[0], `"node_name":"web1"`)
+
+	nodes := []map[string]interface{}{testNode("web1", true), testNode("web2", true)}
+	visible := scoped.visibleNodes(nodes)
+	require.Len(t, visible, 1)
+	assert.Equal(t, "web1", visible[0]["name"])
+
+	delta := scoped.visibleDelta(&NodesDelta{
+		Added:   nodes,
+		Removed: []string{"web1", "web3"},
+		Changed: []NodeDelta{{Name: "web2"}},
+	}, map[string]string{})
+	assert.Len(t, delta.Added, 1)
+	assert.Equal(t, []string{"web1"}, delta.Removed)
+	assert.Empty(t, delta.Changed)
+
+	// 受限客户端不从未过滤的缓冲区续传，而是收到过滤后的快照
+	hub.deltaRing.add(1, []byte(`{"type":"nodes_delta"}`))
+	hub.deltaRing.add(2, []byte(`{"type":"nodes_delta"}`))
+	hub.deltaSeq = 2
+	scoped.delta = true
+	hub.syncDeltaClient(scoped, hub.epoch, 1)
+	frames = drain(scoped)
+	require.Len(t, frames, 1)
+	assert.Contains(t, frames[0], MessageTypeNodesSnapshot)
+}
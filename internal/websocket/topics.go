@@ -0,0 +1,202 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"superview/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// 主题名称。事件通过 Message.Topics 声明所属主题，只推送给订阅了其中任一主题的客户端；
+// 未声明主题的消息（节点列表、系统统计等）推送给所有客户端
+const (
+	TopicAlerts    = "alerts"    // alerts、alerts:severity=critical
+	TopicDiscovery = "discovery" // discovery、discovery:task/<id>
+	TopicActivity  = "activity"
+	TopicNode      = "node" // node:<name>
+	TopicLogs      = "logs" // logs:<node>:<process>
+)
+
+// MessageTypeSubscription 订阅变化后的回复
+const MessageTypeSubscription = "subscription"
+
+// DefaultTopics 连接时未指定 topics 的客户端默认订阅的主题，与引入主题之前收到的事件一致
+var DefaultTopics = []string{TopicAlerts, TopicDiscovery}
+
+var alertSeverities = map[string]bool{"low": true, "medium": true, "high": true, "critical": true}
+
+// TopicAuthorizer 校验用户能否订阅主题，scope 为通过 API 令牌连接时的令牌范围（可能为 nil）
+type TopicAuthorizer interface {
+	AuthorizeTopic(userID string, scope ClientScope, topic string) error
+}
+
+// TopicName 主题的名称部分，如 alerts:severity=critical 返回 alerts
+func TopicName(topic string) string {
+	name, _, _ := strings.Cut(topic, ":")
+	return name
+}
+
+// TopicNodeName node 和 logs 主题所属的节点，其他主题返回空
+func TopicNodeName(topic string) string {
+	name, qualifier, _ := strings.Cut(topic, ":")
+	switch name {
+	case TopicNode:
+		return qualifier
+	case TopicLogs:
+		node, _, _ := strings.Cut(qualifier, ":")
+		return node
+	}
+	return ""
+}
+
+// ValidateTopic 校验主题格式
+func ValidateTopic(topic string) error {
+	name, qualifier, qualified := strings.Cut(topic, ":")
+	switch name {
+	case TopicAlerts:
+		if !qualified {
+			return nil
+		}
+		if severity, ok := strings.CutPrefix(qualifier, "severity="); ok && alertSeverities[severity] {
+			return nil
+		}
+		return fmt.Errorf("alerts topic only supports severity=low|medium|high|critical")
+	case TopicDiscovery:
+		if !qualified {
+			return nil
+		}
+		if id, ok := strings.CutPrefix(qualifier, "task/"); ok {
+			if _, err := strconv.ParseUint(id, 10, 64); err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("discovery topic only supports task/<id>")
+	case TopicActivity:
+		if qualified {
+			return fmt.Errorf("activity topic takes no qualifier")
+		}
+		return nil
+	case TopicNode:
+		if qualifier == "" {
+			return fmt.Errorf("node topic requires a node name")
+		}
+		return nil
+	case TopicLogs:
+		node, process, ok := strings.Cut(qualifier, ":")
+		if !ok || node == "" || process == "" {
+			return fmt.Errorf("logs topic requires <node>:<process>")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown topic: %s", name)
+}
+
+// SetTopicAuthorizer 设置主题权限校验，未设置时允许订阅所有主题
+func (h *Hub) SetTopicAuthorizer(authorizer TopicAuthorizer) {
+	h.topicMu.Lock()
+	defer h.topicMu.Unlock()
+	h.topicAuthorizer = authorizer
+}
+
+// subscribeTopics 校验格式和权限后记录订阅，返回被拒绝的主题及原因
+func (h *Hub) subscribeTopics(client *Client, topics []string) map[string]string {
+	h.topicMu.RLock()
+	authorizer := h.topicAuthorizer
+	h.topicMu.RUnlock()
+
+	rejected := make(map[string]string)
+	for _, topic := range topics {
+		topic = strings.TrimSpace(topic)
+		if topic == "" {
+			continue
+		}
+		if err := ValidateTopic(topic); err != nil {
+			rejected[topic] = err.Error()
+			continue
+		}
+		if authorizer != nil {
+			if err := authorizer.AuthorizeTopic(client.userID, client.scope, topic); err != nil {
+				rejected[topic] = err.Error()
+				continue
+			}
+		}
+		client.subscribed.Store(topic, true)
+	}
+	if len(rejected) > 0 {
+		logger.Debug("Topic subscriptions rejected",
+			zap.String("user_id", client.userID),
+			zap.Any("rejected", rejected))
+	}
+	return rejected
+}
+
+// subscribeInitialTopics 订阅连接参数中逗号分隔的主题，未指定时订阅 DefaultTopics
+func (c *Client) subscribeInitialTopics(param string) {
+	topics := DefaultTopics
+	if param != "" {
+		topics = strings.Split(param, ",")
+	}
+	c.hub.subscribeTopics(c, topics)
+}
+
+// subscribedTo 客户端是否订阅了任一主题
+func (c *Client) subscribedTo(topics []string) bool {
+	for _, topic := range topics {
+		if _, ok := c.subscribed.Load(topic); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Topics 客户端当前订阅的主题
+func (c *Client) Topics() []string {
+	var topics []string
+	c.subscribed.Range(func(key, value interface{}) bool {
+		topics = append(topics, key.(string))
+		return true
+	})
+	sort.Strings(topics)
+	return topics
+}
+
+// handleSubscribe 处理 subscribe/unsubscribe 消息：{"topics": ["alerts", "node:web-1"]}
+func (c *Client) handleSubscribe(data map[string]interface{}, subscribe bool) {
+	var topics []string
+	if list, ok := data["topics"].([]interface{}); ok {
+		for _, item := range list {
+			if topic, ok := item.(string); ok {
+				topics = append(topics, topic)
+			}
+		}
+	}
+
+	rejected := map[string]string{}
+	if subscribe {
+		rejected = c.hub.subscribeTopics(c, topics)
+	} else {
+		for _, topic := range topics {
+			c.subscribed.Delete(strings.TrimSpace(topic))
+		}
+	}
+	c.replySubscription(rejected)
+}
+
+// replySubscription 回复当前订阅和被拒绝的主题
+func (c *Client) replySubscription(rejected map[string]string) {
+	reply := Message{
+		Type: MessageTypeSubscription,
+		Data: map[string]interface{}{
+			"topics":   c.Topics(),
+			"rejected": rejected,
+		},
+	}
+	if data, err := json.Marshal(reply); err == nil {
+		c.hub.sendToClient(c, data)
+	}
+}
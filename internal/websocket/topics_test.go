@@ -0,0 +1,88 @@
+package websocket
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"superview/internal/supervisor"
+)
+
+type fakeTopicAuthorizer struct{}
+
+func (fakeTopicAuthorizer) AuthorizeTopic(userID string, _ ClientScope, topic string) error {
+	if userID == "viewer" && TopicName(topic) == TopicActivity {
+		return errors.New("permission log:read is required")
+	}
+	return nil
+}
+
+func TestValidateTopic(t *testing.T) {
+	for _, topic := range []string{"alerts", "alerts:severity=critical", "discovery", "discovery:task/12", "activity", "node:web-1", "logs:web-1:app"} {
+		assert.NoError(t, ValidateTopic(topic), topic)
+	}
+	for _, topic := range []string{"", "metrics", "alerts:severity=urgent", "alerts:web-1", "discovery:task/x", "activity:all", "node", "node:", "logs:web-1", "logs::app"} {
+		assert.Error(t, ValidateTopic(topic), topic)
+	}
+	assert.Equal(t, "alerts", TopicName("alerts:severity=high"))
+	assert.Equal(t, "web-1", TopicNodeName("node:web-1"))
+	assert.Equal(t, "web-1", TopicNodeName("logs:web-1:app"))
+	assert.Empty(t, TopicNodeName("alerts:severity=high"))
+}
+
+func TestTopicSubscriptions(t *testing.T) {
+	hub := NewHub(&supervisor.SupervisorService{})
+	hub.SetTopicAuthorizer(fakeTopicAuthorizer{})
+	go hub.Run()
+	defer hub.Close()
+
+	viewer := &Client{hub: hub, send: make(chan []byte, 16), userID: "viewer"}
+	viewer.subscribeInitialTopics("")
+	assert.Equal(t, []string{"alerts", "discovery"}, viewer.Topics())
+
+	critical := &Client{hub: hub, send: make(chan []byte, 16), userID: "admin"}
+	critical.subscribeInitialTopics("alerts:severity=critical,activity")
+	assert.Equal(t, []string{"activity", "alerts:severity=critical"}, critical.Topics())
+
+	hub.clientsMu.Lock()
+	hub.clients[viewer] = true
+	hub.clients[critical] = true
+	hub.clientsMu.Unlock()
+
+	viewer.handleSubscribe(map[string]interface{}{"topics": []interface{}{"activity", "node:web-1", "bogus"}}, true)
+	reply := drain(viewer)
+	require.Len(t, reply, 1)
+	assert.Contains(t, reply[0], `"type":"subscription"`)
+	assert.Contains(t, reply[0], `"activity":"permission log:read is required"`)
+	assert.Contains(t, reply[0], `"bogus"`)
+	assert.Equal(t, []string{"alerts", "discovery", "node:web-1"}, viewer.Topics())
+
+	viewer.handleSubscribe(map[string]interface{}{"topics": []interface{}{"discovery"}}, false)
+	drain(viewer)
+
+	hub.Broadcast([]byte(`{"type":"alert_created","topics":["alerts","alerts:severity=high","node:web-2"]}`))
+	hub.Broadcast([]byte(`{"type":"alert_created","topics":["alerts","alerts:severity=critical"]}`))
+	hub.Broadcast([]byte(`{"type":"discovery_progress","topics":["discovery","discovery:task/1"]}`))
+	hub.Broadcast([]byte(`{"type":"activity_log","topics":["activity"]}`))
+	hub.Broadcast([]byte(`{"type":"config_changed"}`))
+
+	collect := func(client *Client, n int) []string {
+		var types []string
+		for len(types) < n {
+			select {
+			case frame := <-client.send:
+				types = append(types, strings.Split(string(frame), `"`)[3])
+			case <-time.After(2 * time.Second):
+				t.Fatalf("expected %d messages, got %v", n, types)
+			}
+		}
+		return types
+	}
+	assert.Equal(t, []string{"alert_created", "alert_created", "config_changed"}, collect(viewer, 3))
+	assert.Equal(t, []string{"alert_created", "activity_log", "config_changed"}, collect(critical, 3))
+	assert.Empty(t, drain(viewer))
+	assert.Empty(t, drain(critical))
+}
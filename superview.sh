@@ -2,7 +2,7 @@
 set -euo pipefail
 
 # Superview 运维脚本
-# 用法: ./superview.sh [start|stop|restart|status|run]
+# 用法: ./superview.sh [start|stop|restart|status|run|migrate]
 
 readonly APP_NAME="superview"
 readonly PID_FILE="pids/backend.pid"
@@ -98,6 +98,11 @@ run() {
     exec "./$APP_NAME"
 }
 
+migrate() {
+    check_binary
+    "./$APP_NAME" migrate "$@"
+}
+
 show_help() {
     cat << EOF
 Superview 运维脚本
@@ -110,6 +115,7 @@ Superview 运维脚本
   restart   重启
   status    查看状态
   run       前台运行
+  migrate   数据库迁移（up | down <N> | status）
 
 构建请使用 Makefile:
   make              构建前后端
@@ -127,6 +133,7 @@ main() {
         restart)        restart ;;
         status)         status ;;
         run)            run ;;
+        migrate)        shift; migrate "$@" ;;
         help|-h|--help) show_help ;;
         *)
             log_error "Unknown command: $1"